@@ -47,26 +47,47 @@ class AsterBridge:
         base_url: Optional[str] = None,
         max_retries: int = 3,
         retry_delay: float = 0.5,
+        execution_id: Optional[str] = None,
+        token: Optional[str] = None,
     ):
         """
         初始化桥接客户端
 
         Args:
-            base_url: HTTP 桥接服务器地址,默认从环境变量 ASTER_BRIDGE_URL 获取
+            base_url: HTTP 桥接服务器地址,默认从环境变量 ASTER_BRIDGE_URL 获取,
+                支持 "unix:<path>" 形式的 unix socket 地址
             max_retries: 最大重试次数(默认3次)
             retry_delay: 重试延迟秒数(默认0.5秒,指数退避)
+            execution_id: 执行 ID,服务端据此校验工具授权,默认从环境变量 ASTER_EXECUTION_ID 获取
+            token: 访问令牌,默认从环境变量 ASTER_BRIDGE_TOKEN 获取
         """
         self.base_url = base_url or os.environ.get(
             "ASTER_BRIDGE_URL", "http://localhost:8080"
         )
         self.max_retries = max_retries
         self.retry_delay = retry_delay
+        self.execution_id = execution_id or os.environ.get("ASTER_EXECUTION_ID")
+        self.token = token or os.environ.get("ASTER_BRIDGE_TOKEN")
+        self.socket_path: Optional[str] = None
+        if self.base_url.startswith("unix:"):
+            self.socket_path = self.base_url[len("unix:"):]
+            self.base_url = "http://localhost"
         self._session: Optional[aiohttp.ClientSession] = None
 
     async def _get_session(self) -> aiohttp.ClientSession:
         """获取或创建 HTTP 会话"""
         if self._session is None or self._session.closed:
-            self._session = aiohttp.ClientSession()
+            headers = {}
+            if self.token:
+                headers["Authorization"] = f"Bearer {self.token}"
+            if self.execution_id:
+                headers["X-Aster-Execution-ID"] = self.execution_id
+            connector = (
+                aiohttp.UnixConnector(path=self.socket_path)
+                if self.socket_path
+                else None
+            )
+            self._session = aiohttp.ClientSession(headers=headers, connector=connector)
         return self._session
 
     async def call_tool(self, name: str, **kwargs) -> Any:
@@ -107,6 +128,11 @@ class AsterBridge:
                             continue
                         raise last_error
 
+                    if resp.status == 403:
+                        # 工具未授权,不重试
+                        error_text = await resp.text()
+                        raise ToolExecutionError(name, f"not allowed: {error_text}")
+
                     if resp.status >= 400:
                         # 客户端错误,不重试
                         error_text = await resp.text()
@@ -34,12 +34,23 @@ func (a *SimpleAgent) Receive(ctx *actor.Context, msg actor.Message) {
 			},
 		}
 
-		// 发送响应
-		select {
-		case m.ReplyTo <- result:
-		case <-time.After(time.Second):
-			fmt.Println("发送响应超时")
+		// 发送响应: A2A Server 通过 Request 调用，结果经 ctx.Reply 返回
+		if m.ReplyTo != nil {
+			m.ReplyTo <- result
+			return
 		}
+		ctx.Reply(result)
+
+	case *pkgagent.StreamMsg:
+		// 逐段输出响应，结束时发送 done 并关闭通道
+		go func() {
+			defer close(m.EventCh)
+			for _, chunk := range []string{"你好!", "这是一条", "流式响应。"} {
+				time.Sleep(50 * time.Millisecond)
+				m.EventCh <- &pkgagent.StreamEventMsg{Type: "text", Content: chunk}
+			}
+			m.EventCh <- &pkgagent.StreamEventMsg{Type: "done"}
+		}()
 	}
 }
 
@@ -197,5 +208,36 @@ func main() {
 
 	fmt.Println("✅ 任务已取消")
 
+	// 9. 流式消息 (message/stream)
+	// 通过 HTTP 接入时由 Handler 以 SSE 推送，客户端使用 a2a.Client.SendMessageStream 读取
+	fmt.Println("\n--- 步骤 6: 流式消息 (message/stream) ---")
+	streamReq := &a2a.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-005",
+		Method:  "message/stream",
+		Params: a2a.MessageStreamParams{
+			Message: a2a.Message{
+				MessageID: "msg-003",
+				Role:      "user",
+				Parts:     []a2a.Part{{Kind: "text", Text: "请流式回复"}},
+				Kind:      "message",
+			},
+			ContextID: "context-001",
+		},
+	}
+
+	err = a2aServer.HandleStream(ctx, "demo-agent", streamReq, func(resp *a2a.JSONRPCResponse) error {
+		switch event := resp.Result.(type) {
+		case *a2a.TaskStatusUpdateEvent:
+			fmt.Printf("[status] %s (final=%v)\n", event.Status.State, event.Final)
+		case *a2a.TaskArtifactUpdateEvent:
+			fmt.Printf("[artifact] %s (lastChunk=%v)\n", event.Artifact.Parts[0].Text, event.LastChunk)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("流式消息失败: %v", err)
+	}
+
 	fmt.Println("\n=== 示例完成 ===")
 }
@@ -118,7 +118,12 @@ func createHITLMiddleware() (*middleware.HumanInTheLoopMiddleware, error) {
 				"allowed_decisions": []string{"approve", "reject"},
 			},
 		},
-		// 智能审核处理器
+		// 声明式自动审核规则：匹配的操作不再询问审核员
+		AutoApproveRules: []middleware.AutoApproveRule{
+			{Tool: "Bash", Field: "command", Pattern: `(ls|cat|pwd)(\s.*)?`, Reason: "只读命令自动批准"},
+			{Tool: "Bash", Field: "command", Pattern: `rm -rf|mkfs|dd if=`, Decision: middleware.DecisionReject, Reason: "高危命令自动拒绝"},
+		},
+		// 智能审核处理器（处理未匹配规则的操作）
 		ApprovalHandler: smartApprovalHandler,
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/astercloud/aster/pkg/agent"
 	"github.com/astercloud/aster/pkg/provider"
@@ -63,6 +64,14 @@ func main() {
 		log.Fatalf("Failed to create manager: %v", err)
 	}
 
+	// 后台健康检查：连续失败的模型自动禁用，恢复后自动重新启用
+	manager.StartHealthCheck(agent.FallbackHealthConfig{
+		Interval:         time.Minute,
+		FailureThreshold: 3,
+		Cooldown:         5 * time.Minute,
+	})
+	defer manager.StopHealthCheck()
+
 	// 示例 1: 非流式请求
 	fmt.Println("示例 1: 非流式请求")
 	fmt.Println("---")
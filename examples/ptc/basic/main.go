@@ -77,6 +77,9 @@ func main() {
 		},
 	}
 
+	// 代码中只能调用 AllowedCallers 包含 code_execution 的工具
+	codeExecTool.SetAllowedToolsFromSchemas(toolSchemas)
+
 	// 6. 创建 Anthropic Provider
 	providerConfig := &types.ModelConfig{
 		Provider: "anthropic",
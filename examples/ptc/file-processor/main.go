@@ -53,6 +53,9 @@ func main() {
 		})
 	}
 
+	// 代码中只能调用 AllowedCallers 包含 code_execution 的工具
+	codeExecTool.SetAllowedToolsFromSchemas(toolSchemas)
+
 	// 3. 创建 Anthropic Provider
 	providerConfig := &types.ModelConfig{
 		Provider: "anthropic",
@@ -25,6 +25,7 @@ func main() {
 
 	// 3. 启动 HTTP 桥接服务器
 	server := bridge.NewHTTPBridgeServer(toolBridge, "localhost:18080")
+	server.SetAllowedTools([]string{"Read", "Write", "Glob"})
 
 	fmt.Println("启动 HTTP 桥接服务器...")
 	if err := server.StartAsync(); err != nil {
@@ -0,0 +1,249 @@
+package a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Client A2A 客户端
+// 向单个远程 Agent 的 JSON-RPC 端点（Agent Card 中的 URL）发送请求
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient 创建 A2A 客户端
+// httpClient 为 nil 时使用 http.DefaultClient
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+// StreamEvent message/stream 推送的事件，四个字段中只有一个非空
+type StreamEvent struct {
+	Task           *Task
+	Message        *Message
+	StatusUpdate   *TaskStatusUpdateEvent
+	ArtifactUpdate *TaskArtifactUpdateEvent
+}
+
+// IsFinal 是否为流的最后一个事件
+func (e *StreamEvent) IsFinal() bool {
+	return e.StatusUpdate != nil && e.StatusUpdate.Final
+}
+
+// StreamHandler 处理流式事件，返回错误时停止读取
+type StreamHandler func(event *StreamEvent) error
+
+// SendMessage 调用 message/send
+func (c *Client) SendMessage(ctx context.Context, params *MessageSendParams) (*MessageSendResult, error) {
+	var result MessageSendResult
+	if err := c.call(ctx, "message/send", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTask 调用 tasks/get
+func (c *Client) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	var result TasksGetResult
+	if err := c.call(ctx, "tasks/get", &TasksGetParams{TaskID: taskID}, &result); err != nil {
+		return nil, err
+	}
+	return result.Task, nil
+}
+
+// CancelTask 调用 tasks/cancel
+func (c *Client) CancelTask(ctx context.Context, taskID string) (*TasksCancelResult, error) {
+	var result TasksCancelResult
+	if err := c.call(ctx, "tasks/cancel", &TasksCancelParams{TaskID: taskID}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetTaskPushNotificationConfig 调用 tasks/pushNotificationConfig/set
+func (c *Client) SetTaskPushNotificationConfig(ctx context.Context, config *TaskPushNotificationConfig) (*TaskPushNotificationConfig, error) {
+	var result TaskPushNotificationConfig
+	if err := c.call(ctx, "tasks/pushNotificationConfig/set", config, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTaskPushNotificationConfig 调用 tasks/pushNotificationConfig/get
+func (c *Client) GetTaskPushNotificationConfig(ctx context.Context, taskID string) (*TaskPushNotificationConfig, error) {
+	var result TaskPushNotificationConfig
+	if err := c.call(ctx, "tasks/pushNotificationConfig/get", &PushNotificationConfigGetParams{TaskID: taskID}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SendMessageStream 调用 message/stream 并逐个处理 SSE 事件
+// 收到 final 状态更新后返回 nil；流在此之前结束时返回 io.ErrUnexpectedEOF。
+func (c *Client) SendMessageStream(ctx context.Context, params *MessageStreamParams, handler StreamHandler) error {
+	resp, err := c.post(ctx, "message/stream", params)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// 服务端在进入流之前出错时返回普通 JSON-RPC 响应
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		var rpcResp JSONRPCResponse
+		if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+			return fmt.Errorf("decode response (status %d): %w", resp.StatusCode, err)
+		}
+		if rpcResp.Error != nil {
+			return rpcResp.Error
+		}
+		return fmt.Errorf("unexpected non-stream response with status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			// 只关心 data 字段，多行 data 以换行拼接
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(value, " "))
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+
+		event, err := decodeStreamEvent([]byte(data.String()))
+		data.Reset()
+		if err != nil {
+			return err
+		}
+		if err := handler(event); err != nil {
+			return err
+		}
+		if event.IsFinal() {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read stream: %w", err)
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// decodeStreamEvent 按 kind 字段解析一个 SSE 事件
+func decodeStreamEvent(data []byte) (*StreamEvent, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode stream event: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(resp.Result, &header); err != nil {
+		return nil, fmt.Errorf("decode stream event: %w", err)
+	}
+
+	event := &StreamEvent{}
+	var target any
+	switch header.Kind {
+	case "task":
+		event.Task = &Task{}
+		target = event.Task
+	case "message":
+		event.Message = &Message{}
+		target = event.Message
+	case "status-update":
+		event.StatusUpdate = &TaskStatusUpdateEvent{}
+		target = event.StatusUpdate
+	case "artifact-update":
+		event.ArtifactUpdate = &TaskArtifactUpdateEvent{}
+		target = event.ArtifactUpdate
+	default:
+		return nil, fmt.Errorf("unknown stream event kind: %q", header.Kind)
+	}
+
+	if err := json.Unmarshal(resp.Result, target); err != nil {
+		return nil, fmt.Errorf("decode %s event: %w", header.Kind, err)
+	}
+	return event, nil
+}
+
+// call 发送同步 JSON-RPC 请求并解析结果
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	resp, err := c.post(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("decode response (status %d): %w", resp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if len(rpcResp.Result) == 0 {
+		return errors.New("empty result")
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+func (c *Client) post(ctx context.Context, method string, params any) (*http.Response, error) {
+	body, err := json.Marshal(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      generateID(),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if method == "message/stream" {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	return resp, nil
+}
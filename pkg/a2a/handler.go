@@ -110,6 +110,12 @@ func (h *Handler) HandleJSONRPC(c *gin.Context) {
 		return
 	}
 
+	// 流式请求通过 SSE 推送
+	if req.Method == "message/stream" {
+		h.handleStream(c, agentID, &req)
+		return
+	}
+
 	// 处理请求
 	resp := h.server.HandleRequest(ctx, agentID, &req)
 
@@ -132,6 +138,52 @@ func (h *Handler) HandleJSONRPC(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// handleStream 以 Server-Sent Events 推送 message/stream 事件
+// 每个事件的 data 为一个 JSON-RPC 响应
+func (h *Handler) handleStream(c *gin.Context, agentID string, req *JSONRPCRequest) {
+	ctx := c.Request.Context()
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusOK, NewErrorResponse(req.ID, ErrorCodeUnsupportedOperation,
+			"streaming not supported", nil))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	events := 0
+	err := h.server.HandleStream(ctx, agentID, req, func(resp *JSONRPCResponse) error {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		events++
+		return nil
+	})
+
+	if err != nil {
+		logging.Warn(ctx, "a2a.stream_error", map[string]any{
+			"agent_id": agentID,
+			"events":   events,
+			"error":    err.Error(),
+		})
+		return
+	}
+
+	logging.Info(ctx, "a2a.stream_completed", map[string]any{
+		"agent_id": agentID,
+		"events":   events,
+	})
+}
+
 // HandleBatchJSONRPC 处理批量 JSON-RPC 2.0 请求
 // POST /a2a/{agentId}/batch
 func (h *Handler) HandleBatchJSONRPC(c *gin.Context) {
@@ -306,7 +358,7 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	// A2A JSON-RPC 端点
 	a2a := rg.Group("/a2a")
 	{
-		// 单个 JSON-RPC 请求
+		// 单个 JSON-RPC 请求 (message/stream 以 SSE 响应)
 		a2a.POST("/:agentId", h.HandleJSONRPC)
 
 		// 批量 JSON-RPC 请求
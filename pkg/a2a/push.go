@@ -0,0 +1,327 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/security/netguard"
+)
+
+// 推送请求头
+const (
+	HeaderNotificationToken = "X-A2A-Notification-Token" // PushNotificationConfig.Token
+	HeaderSignature         = "X-A2A-Signature-256"      // "sha256=" + hex(HMAC-SHA256(timestamp + "." + body))
+	HeaderTimestamp         = "X-A2A-Timestamp"          // Unix 秒，参与签名，用于防重放
+)
+
+// PushNotifierConfig 推送通知配置
+type PushNotifierConfig struct {
+	// HTTPClient 发送 webhook 的客户端
+	// 默认超时 10s，建立连接和重定向时都经过 Guard 校验；自定义客户端需要自行防护 SSRF
+	HTTPClient *http.Client
+
+	// Guard 校验 webhook 地址的 SSRF 防护，默认拦截私有、回环、链路本地和云元数据地址
+	// 需要推送到内网地址时通过 netguard.Config.AllowedNetworks 显式放行
+	Guard *netguard.Guard
+
+	// SigningKey HMAC-SHA256 签名密钥，为空时不签名
+	// 接收方使用同一密钥调用 VerifyPushSignature 校验
+	SigningKey []byte
+
+	// MaxAttempts 单次推送的最大尝试次数，默认 5
+	MaxAttempts int
+
+	// InitialBackoff 首次重试前的等待时间，之后每次翻倍，默认 1s
+	InitialBackoff time.Duration
+
+	// MaxBackoff 单次等待时间上限，默认 30s
+	MaxBackoff time.Duration
+}
+
+// PushNotifier 在任务状态变化时向 webhook POST 任务对象
+// 同一任务的推送按状态变化顺序依次发送，失败时按指数退避重试。
+type PushNotifier struct {
+	client         *http.Client
+	guard          *netguard.Guard
+	signingKey     []byte
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mu     sync.Mutex
+	queues map[string]*pushQueue // key: "agentID-taskID"
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type pushJob struct {
+	config *PushNotificationConfig
+	task   *Task
+}
+
+type pushQueue struct {
+	jobs []pushJob
+}
+
+// maxPushRedirects 推送请求允许的最大重定向次数
+const maxPushRedirects = 3
+
+// NewPushNotifier 创建推送通知器
+func NewPushNotifier(config *PushNotifierConfig) *PushNotifier {
+	if config == nil {
+		config = &PushNotifierConfig{}
+	}
+
+	n := &PushNotifier{
+		client:         config.HTTPClient,
+		guard:          config.Guard,
+		signingKey:     config.SigningKey,
+		maxAttempts:    config.MaxAttempts,
+		initialBackoff: config.InitialBackoff,
+		maxBackoff:     config.MaxBackoff,
+		queues:         make(map[string]*pushQueue),
+	}
+	if n.guard == nil {
+		n.guard, _ = netguard.New(nil)
+	}
+	if n.client == nil {
+		n.client = n.guard.Client(n.guard.Transport(10*time.Second), maxPushRedirects)
+		n.client.Timeout = 10 * time.Second
+	}
+	if n.maxAttempts <= 0 {
+		n.maxAttempts = 5
+	}
+	if n.initialBackoff <= 0 {
+		n.initialBackoff = time.Second
+	}
+	if n.maxBackoff <= 0 {
+		n.maxBackoff = 30 * time.Second
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+
+	return n
+}
+
+// Enqueue 异步推送任务当前状态
+func (n *PushNotifier) Enqueue(agentID string, config *PushNotificationConfig, task *Task) {
+	job := pushJob{config: copyPushConfig(config), task: copyTask(task)}
+	key := makeKey(agentID, task.ID)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.ctx.Err() != nil {
+		return
+	}
+
+	// 已有投递 goroutine 时排队，保证同一任务的推送顺序
+	if q, ok := n.queues[key]; ok {
+		q.jobs = append(q.jobs, job)
+		return
+	}
+
+	q := &pushQueue{jobs: []pushJob{job}}
+	n.queues[key] = q
+	n.wg.Add(1)
+	go n.run(key, q)
+}
+
+// run 依次投递队列中的推送
+func (n *PushNotifier) run(key string, q *pushQueue) {
+	defer n.wg.Done()
+
+	for {
+		n.mu.Lock()
+		if len(q.jobs) == 0 {
+			delete(n.queues, key)
+			n.mu.Unlock()
+			return
+		}
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		n.mu.Unlock()
+
+		if err := n.Deliver(n.ctx, job.config, job.task); err != nil {
+			a2aLog.Warn(n.ctx, "push notification failed", map[string]any{
+				"task_id": job.task.ID,
+				"state":   job.task.Status.State,
+				"url":     job.config.URL,
+				"error":   err,
+			})
+		}
+	}
+}
+
+// Deliver 同步推送任务，失败时按退避策略重试
+// 网络错误、429 和 5xx 会重试，其余 4xx 视为永久失败。
+func (n *PushNotifier) Deliver(ctx context.Context, config *PushNotificationConfig, task *Task) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("marshal task: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(n.backoff(attempt - 1)):
+			}
+		}
+
+		retry, err := n.post(ctx, config, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", n.maxAttempts, lastErr)
+}
+
+// post 发送一次推送，返回失败时是否可以重试
+func (n *PushNotifier) post(ctx context.Context, config *PushNotificationConfig, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if config.Token != "" {
+		req.Header.Set(HeaderNotificationToken, config.Token)
+	}
+	if err := setPushAuthorization(req, config.Authentication); err != nil {
+		return false, err
+	}
+	if len(n.signingKey) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, signPayload(n.signingKey, timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, netguard.ErrBlocked), fmt.Errorf("send request: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// backoff 第 attempt 次重试前的等待时间
+func (n *PushNotifier) backoff(attempt int) time.Duration {
+	wait := n.initialBackoff
+	for i := 1; i < attempt && wait < n.maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, n.maxBackoff)
+}
+
+// Close 停止重试并等待进行中的推送结束
+func (n *PushNotifier) Close() {
+	n.mu.Lock()
+	n.cancel()
+	n.mu.Unlock()
+	n.wg.Wait()
+}
+
+// setPushAuthorization 按 authentication 中第一个支持的方案设置 Authorization 请求头
+func setPushAuthorization(req *http.Request, auth *PushNotificationAuthenticationInfo) error {
+	if auth == nil || len(auth.Schemes) == 0 {
+		return nil
+	}
+	for _, scheme := range auth.Schemes {
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			req.Header.Set("Authorization", "Bearer "+auth.Credentials)
+			return nil
+		case strings.EqualFold(scheme, "Basic"):
+			req.Header.Set("Authorization", "Basic "+auth.Credentials)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported authentication schemes: %v", auth.Schemes)
+}
+
+// validatePushNotificationConfig 校验推送配置，webhook 地址须通过 SSRF 防护
+func validatePushNotificationConfig(config *PushNotificationConfig, guard *netguard.Guard) error {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return fmt.Errorf("invalid push notification url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("push notification url must be an absolute http(s) url: %q", config.URL)
+	}
+	if err := guard.CheckURL(u); err != nil {
+		return fmt.Errorf("push notification url not allowed: %w", err)
+	}
+
+	if auth := config.Authentication; auth != nil && len(auth.Schemes) > 0 {
+		req := &http.Request{Header: make(http.Header)}
+		if err := setPushAuthorization(req, auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signPayload 计算推送签名
+func signPayload(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrInvalidSignature 推送签名校验失败
+var ErrInvalidSignature = errors.New("invalid push notification signature")
+
+// VerifyPushSignature 校验推送请求的签名和时间戳
+// 供 webhook 接收方使用，maxAge 为 0 时不检查时间戳
+func VerifyPushSignature(key []byte, header http.Header, body []byte, maxAge time.Duration) error {
+	timestamp := header.Get(HeaderTimestamp)
+	signature := header.Get(HeaderSignature)
+	if timestamp == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	if maxAge > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+			return fmt.Errorf("%w: timestamp outside of allowed window", ErrInvalidSignature)
+		}
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signPayload(key, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
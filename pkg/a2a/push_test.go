@@ -0,0 +1,323 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/actor"
+	"github.com/astercloud/aster/pkg/security/netguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver 记录收到的推送
+type webhookReceiver struct {
+	t        *testing.T
+	key      []byte
+	failures int32 // 前 N 次请求返回 503
+	attempts atomic.Int32
+	tasks    chan *Task
+	headers  chan http.Header
+}
+
+func newWebhookReceiver(t *testing.T, key []byte, failures int32) (*webhookReceiver, string) {
+	r := &webhookReceiver{
+		t:        t,
+		key:      key,
+		failures: failures,
+		tasks:    make(chan *Task, 10),
+		headers:  make(chan http.Header, 10),
+	}
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return r, ts.URL
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.attempts.Add(1) <= r.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if r.key != nil {
+		if err := VerifyPushSignature(r.key, req.Header, body, time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var task Task
+	if err := json.Unmarshal(body, &task); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.headers <- req.Header.Clone()
+	r.tasks <- &task
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *webhookReceiver) next() *Task {
+	r.t.Helper()
+	select {
+	case task := <-r.tasks:
+		return task
+	case <-time.After(5 * time.Second):
+		r.t.Fatal("Timed out waiting for push notification")
+		return nil
+	}
+}
+
+func fastNotifier(t *testing.T, key []byte) *PushNotifier {
+	// httptest 服务监听回环地址，需要显式放行
+	guard, err := netguard.New(&netguard.Config{AllowedNetworks: []string{"127.0.0.1", "::1"}})
+	require.NoError(t, err)
+	n := NewPushNotifier(&PushNotifierConfig{
+		Guard:          guard,
+		SigningKey:     key,
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+	})
+	t.Cleanup(n.Close)
+	return n
+}
+
+func TestPushNotifier_DeliverWithRetry(t *testing.T) {
+	key := []byte("secret")
+	receiver, url := newWebhookReceiver(t, key, 2)
+	notifier := fastNotifier(t, key)
+
+	task := NewTask("task-1", "ctx-1")
+	task.UpdateStatus(TaskStateCompleted, nil)
+
+	err := notifier.Deliver(context.Background(), &PushNotificationConfig{
+		URL:   url,
+		Token: "task-token",
+		Authentication: &PushNotificationAuthenticationInfo{
+			Schemes:     []string{"Bearer"},
+			Credentials: "webhook-credential",
+		},
+	}, task)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), receiver.attempts.Load())
+
+	received := receiver.next()
+	assert.Equal(t, "task-1", received.ID)
+	assert.Equal(t, TaskStateCompleted, received.Status.State)
+
+	header := <-receiver.headers
+	assert.Equal(t, "task-token", header.Get(HeaderNotificationToken))
+	assert.Equal(t, "Bearer webhook-credential", header.Get("Authorization"))
+}
+
+func TestPushNotifier_GivesUp(t *testing.T) {
+	receiver, url := newWebhookReceiver(t, nil, 100)
+	notifier := fastNotifier(t, nil)
+
+	err := notifier.Deliver(context.Background(), &PushNotificationConfig{URL: url}, NewTask("task-1", "ctx-1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "giving up after 3 attempts")
+	assert.Equal(t, int32(3), receiver.attempts.Load())
+}
+
+func TestPushNotifier_PermanentFailure(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	err := fastNotifier(t, nil).Deliver(context.Background(), &PushNotificationConfig{URL: ts.URL}, NewTask("task-1", "ctx-1"))
+	require.Error(t, err)
+	assert.Equal(t, int32(1), attempts.Load(), "4xx responses should not be retried")
+}
+
+func TestPushNotifier_BlocksPrivateTargets(t *testing.T) {
+	receiver, url := newWebhookReceiver(t, nil, 0)
+	notifier := NewPushNotifier(&PushNotifierConfig{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	t.Cleanup(notifier.Close)
+
+	// 默认防护在建立连接时拦截回环地址，且不重试
+	err := notifier.Deliver(context.Background(), &PushNotificationConfig{URL: url}, NewTask("task-1", "ctx-1"))
+	require.ErrorIs(t, err, netguard.ErrBlocked)
+	assert.Equal(t, int32(0), receiver.attempts.Load())
+
+	// 设置推送配置时拒绝私有和元数据地址
+	system := actor.NewSystem("push-guard")
+	defer system.Shutdown()
+	server := NewServer(system, nil)
+	server.SetPushNotifier(notifier)
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://metadata.google.internal/",
+		"http://[::1]/hook",
+	} {
+		_, code, err := server.setPushNotificationConfig("agent", "task-1", &PushNotificationConfig{URL: target})
+		require.ErrorIs(t, err, netguard.ErrBlocked, target)
+		assert.Equal(t, ErrorCodeInvalidParams, code, target)
+	}
+}
+
+func TestVerifyPushSignature(t *testing.T) {
+	key := []byte("secret")
+	body := []byte(`{"id":"task-1"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	header := http.Header{}
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderSignature, signPayload(key, timestamp, body))
+	require.NoError(t, VerifyPushSignature(key, header, body, time.Minute))
+
+	// 篡改内容
+	assert.ErrorIs(t, VerifyPushSignature(key, header, []byte(`{"id":"task-2"}`), time.Minute), ErrInvalidSignature)
+	// 错误密钥
+	assert.ErrorIs(t, VerifyPushSignature([]byte("other"), header, body, time.Minute), ErrInvalidSignature)
+
+	// 过期时间戳
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header.Set(HeaderTimestamp, old)
+	header.Set(HeaderSignature, signPayload(key, old, body))
+	assert.ErrorIs(t, VerifyPushSignature(key, header, body, time.Minute), ErrInvalidSignature)
+	assert.NoError(t, VerifyPushSignature(key, header, body, 0))
+}
+
+func TestServer_PushNotificationOnStateTransitions(t *testing.T) {
+	system := actor.NewSystem("test-a2a-push")
+	defer system.Shutdown()
+	system.Spawn(&MockAgentActor{responses: []string{"Done"}}, "push-agent")
+
+	key := []byte("secret")
+	receiver, url := newWebhookReceiver(t, key, 1)
+
+	server := NewServer(system, nil)
+	server.SetPushNotifier(fastNotifier(t, key))
+
+	card, err := server.GetAgentCard("push-agent")
+	require.NoError(t, err)
+	assert.True(t, card.Capabilities.PushNotifications)
+
+	// 随消息设置推送配置
+	ctx := context.Background()
+	resp := server.HandleRequest(ctx, "push-agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-1",
+		Method:  "message/send",
+		Params: &MessageSendParams{
+			Message: NewTextMessage("msg-1", "user", "Hi"),
+			Configuration: &MessageSendConfiguration{
+				PushNotificationConfig: &PushNotificationConfig{URL: url, Token: "tok"},
+			},
+		},
+	})
+	require.Nil(t, resp.Error)
+	taskID := resp.Result.(*MessageSendResult).TaskID
+
+	// 首次推送失败后重试，顺序保持不变
+	working := receiver.next()
+	assert.Equal(t, taskID, working.ID)
+	assert.Equal(t, TaskStateWorking, working.Status.State)
+	completed := receiver.next()
+	assert.Equal(t, TaskStateCompleted, completed.Status.State)
+	require.Len(t, completed.History, 2)
+
+	// 查询配置
+	resp = server.HandleRequest(ctx, "push-agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-2",
+		Method:  "tasks/pushNotificationConfig/get",
+		Params:  &PushNotificationConfigGetParams{TaskID: taskID},
+	})
+	require.Nil(t, resp.Error)
+	config := resp.Result.(*TaskPushNotificationConfig)
+	assert.Equal(t, url, config.PushNotificationConfig.URL)
+	assert.NotEmpty(t, config.PushNotificationConfig.ID)
+
+	// 通过 set 更新配置后取消任务，推送发往新的地址
+	receiver2, url2 := newWebhookReceiver(t, key, 0)
+	resp = server.HandleRequest(ctx, "push-agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-3",
+		Method:  "tasks/pushNotificationConfig/set",
+		Params: &TaskPushNotificationConfig{
+			TaskID:                 taskID,
+			PushNotificationConfig: PushNotificationConfig{URL: url2},
+		},
+	})
+	require.Nil(t, resp.Error)
+
+	// 已完成的任务重新收到消息后再取消
+	task, err := server.taskStore.Load("push-agent", taskID)
+	require.NoError(t, err)
+	task.UpdateStatus(TaskStateWorking, nil)
+	require.NoError(t, server.taskStore.Save("push-agent", task))
+
+	resp = server.HandleRequest(ctx, "push-agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-4",
+		Method:  "tasks/cancel",
+		Params:  &TasksCancelParams{TaskID: taskID},
+	})
+	require.Nil(t, resp.Error)
+	assert.Equal(t, TaskStateCanceled, receiver2.next().Status.State)
+}
+
+func TestServer_PushNotificationConfigErrors(t *testing.T) {
+	system := actor.NewSystem("test-a2a-push")
+	defer system.Shutdown()
+
+	ctx := context.Background()
+	server := NewServer(system, nil)
+	set := func(config *TaskPushNotificationConfig) *RPCError {
+		return server.HandleRequest(ctx, "agent", &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req",
+			Method:  "tasks/pushNotificationConfig/set",
+			Params:  config,
+		}).Error
+	}
+
+	// 未启用推送
+	err := set(&TaskPushNotificationConfig{TaskID: "task-1", PushNotificationConfig: PushNotificationConfig{URL: "http://example.com"}})
+	require.NotNil(t, err)
+	assert.Equal(t, ErrorCodePushNotificationNotSupported, err.Code)
+
+	server.SetPushNotifier(fastNotifier(t, nil))
+
+	// 任务不存在
+	err = set(&TaskPushNotificationConfig{TaskID: "missing", PushNotificationConfig: PushNotificationConfig{URL: "http://example.com"}})
+	require.NotNil(t, err)
+	assert.Equal(t, ErrorCodeTaskNotFound, err.Code)
+
+	require.NoError(t, server.taskStore.Save("agent", NewTask("task-1", "ctx-1")))
+
+	// 非法 URL 和不支持的认证方案
+	for _, config := range []PushNotificationConfig{
+		{URL: "file:///etc/passwd"},
+		{URL: "/relative"},
+		{URL: "https://example.com", Authentication: &PushNotificationAuthenticationInfo{Schemes: []string{"Digest"}}},
+	} {
+		err = set(&TaskPushNotificationConfig{TaskID: "task-1", PushNotificationConfig: config})
+		require.NotNil(t, err, "config %+v", config)
+		assert.Equal(t, ErrorCodeInvalidParams, err.Code)
+	}
+
+	// 未设置时 get 返回错误
+	resp := server.HandleRequest(ctx, "agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req",
+		Method:  "tasks/pushNotificationConfig/get",
+		Params:  &PushNotificationConfigGetParams{TaskID: "task-1"},
+	})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, ErrorCodeInvalidParams, resp.Error.Code)
+}
@@ -22,6 +22,7 @@ var a2aLog = logging.ForComponent("A2AServer")
 type Server struct {
 	actorSystem *actor.System
 	taskStore   TaskStore
+	notifier    *PushNotifier
 }
 
 // NewServer 创建 A2A 服务器
@@ -36,6 +37,12 @@ func NewServer(actorSystem *actor.System, taskStore TaskStore) *Server {
 	}
 }
 
+// SetPushNotifier 启用任务推送通知
+// 未设置时 tasks/pushNotificationConfig/* 返回 PushNotificationNotSupported
+func (s *Server) SetPushNotifier(notifier *PushNotifier) {
+	s.notifier = notifier
+}
+
 // HandleRequest 处理 JSON-RPC 请求
 func (s *Server) HandleRequest(ctx context.Context, agentID string, req *JSONRPCRequest) *JSONRPCResponse {
 	switch req.Method {
@@ -47,6 +54,10 @@ func (s *Server) HandleRequest(ctx context.Context, agentID string, req *JSONRPC
 		return s.handleTasksGet(ctx, agentID, req)
 	case "tasks/cancel":
 		return s.handleTasksCancel(ctx, agentID, req)
+	case "tasks/pushNotificationConfig/set":
+		return s.handlePushNotificationConfigSet(ctx, agentID, req)
+	case "tasks/pushNotificationConfig/get":
+		return s.handlePushNotificationConfigGet(ctx, agentID, req)
 	default:
 		return NewErrorResponse(req.ID, ErrorCodeMethodNotFound,
 			"method not found: "+req.Method, nil)
@@ -74,7 +85,7 @@ func (s *Server) GetAgentCard(agentID string) (*AgentCard, error) {
 		Version: "1.0",
 		Capabilities: Capabilities{
 			Streaming:              true,
-			PushNotifications:      false,
+			PushNotifications:      s.notifier != nil,
 			StateTransitionHistory: false,
 		},
 		DefaultInputModes:  []string{"text"},
@@ -104,32 +115,19 @@ func (s *Server) handleMessageSend(ctx context.Context, agentID string, req *JSO
 		taskID = generateID()
 	}
 
-	task, err := s.loadOrCreateTask(agentID, taskID, params.ContextID, params.Metadata)
-	if err != nil {
-		return NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil)
+	if resp := s.applyConfiguration(agentID, taskID, params.Configuration, req.ID); resp != nil {
+		return resp
 	}
 
-	// 添加用户消息到历史
-	task.AddMessage(params.Message)
-
-	// 更新状态为 working
-	task.UpdateStatus(TaskStateWorking, nil)
-	if err := s.taskStore.Save(agentID, task); err != nil {
+	// 添加用户消息到历史，更新状态为 working
+	if _, err := s.startTask(ctx, agentID, taskID, params.Message, params.ContextID, params.Metadata); err != nil {
 		return NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil)
 	}
 
 	// 通过 Actor 系统发送消息
 	pid, exists := s.actorSystem.GetActor(agentID)
 	if !exists {
-		task.UpdateStatus(TaskStateFailed, &Message{
-			MessageID: generateID(),
-			Role:      "agent",
-			Parts:     []Part{{Kind: "text", Text: "agent not found: " + agentID}},
-			Kind:      "message",
-		})
-		if err := s.taskStore.Save(agentID, task); err != nil {
-			a2aLog.Warn(ctx, "save task error", map[string]any{"error": err})
-		}
+		s.finishTask(ctx, agentID, taskID, TaskStateFailed, agentMessage("agent not found: "+agentID))
 		return NewErrorResponse(req.ID, ErrorCodeInternalError, "agent not found: "+agentID, nil)
 	}
 
@@ -145,56 +143,28 @@ func (s *Server) handleMessageSend(ctx context.Context, agentID string, req *JSO
 
 	if err != nil {
 		// 失败
-		task.UpdateStatus(TaskStateFailed, &Message{
-			MessageID: generateID(),
-			Role:      "agent",
-			Parts:     []Part{{Kind: "text", Text: fmt.Sprintf("agent error: %v", err)}},
-			Kind:      "message",
-		})
-		if err := s.taskStore.Save(agentID, task); err != nil {
-			a2aLog.Warn(ctx, "save task error", map[string]any{"error": err})
-		}
+		s.finishTask(ctx, agentID, taskID, TaskStateFailed, agentMessage(fmt.Sprintf("agent error: %v", err)))
 		return NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil)
 	}
 
 	// 成功
 	chatResult, ok := result.(*agent.ChatResultMsg)
 	if !ok {
-		task.UpdateStatus(TaskStateFailed, &Message{
-			MessageID: generateID(),
-			Role:      "agent",
-			Parts:     []Part{{Kind: "text", Text: "invalid response type"}},
-			Kind:      "message",
-		})
-		if err := s.taskStore.Save(agentID, task); err != nil {
-			a2aLog.Warn(ctx, "save task error", map[string]any{"error": err})
-		}
+		s.finishTask(ctx, agentID, taskID, TaskStateFailed, agentMessage("invalid response type"))
 		return NewErrorResponse(req.ID, ErrorCodeInternalError, "invalid response type", nil)
 	}
 
 	// 构建响应消息
-	responseMsg := Message{
-		MessageID: generateID(),
-		Role:      "agent",
-		Parts:     []Part{{Kind: "text", Text: chatResult.Result.Text}},
-		Kind:      "message",
-		TaskID:    taskID,
-	}
-
-	task.AddMessage(responseMsg)
-	task.UpdateStatus(TaskStateCompleted, &responseMsg)
-	if err := s.taskStore.Save(agentID, task); err != nil {
-		a2aLog.Warn(ctx, "save task error", map[string]any{"error": err})
-	}
+	s.finishTask(ctx, agentID, taskID, TaskStateCompleted, agentMessage(chatResult.Result.Text))
 
 	return NewSuccessResponse(req.ID, &MessageSendResult{TaskID: taskID})
 }
 
 // handleMessageStream 处理 message/stream 方法
+// 流式响应需要 SSE 连接，由 HandleStream 处理；批量请求等同步路径不支持
 func (s *Server) handleMessageStream(_ context.Context, _ string, req *JSONRPCRequest) *JSONRPCResponse {
-	// 流式响应需要特殊处理，这里先返回不支持
 	return NewErrorResponse(req.ID, ErrorCodeUnsupportedOperation,
-		"streaming not yet implemented", nil)
+		"message/stream requires a single SSE request", nil)
 }
 
 // handleTasksGet 处理 tasks/get 方法
@@ -213,74 +183,223 @@ func (s *Server) handleTasksGet(_ context.Context, agentID string, req *JSONRPCR
 }
 
 // handleTasksCancel 处理 tasks/cancel 方法
-func (s *Server) handleTasksCancel(_ context.Context, agentID string, req *JSONRPCRequest) *JSONRPCResponse {
+func (s *Server) handleTasksCancel(ctx context.Context, agentID string, req *JSONRPCRequest) *JSONRPCResponse {
 	var params TasksCancelParams
 	if err := parseParams(req.Params, &params); err != nil {
 		return NewErrorResponse(req.ID, ErrorCodeInvalidParams, err.Error(), nil)
 	}
 
-	task, err := s.taskStore.Load(agentID, params.TaskID)
+	// 检查是否可以取消并更新任务状态
+	canceled := false
+	_, err := s.updateTask(ctx, agentID, params.TaskID, func(task *Task) error {
+		canceled = !task.IsFinalState()
+		if !canceled {
+			return nil
+		}
+		task.UpdateStatus(TaskStateCanceled, &Message{
+			MessageID: generateID(),
+			Role:      "agent",
+			Parts:     []Part{{Kind: "text", Text: "Task canceled by request."}},
+			Kind:      "message",
+		})
+		return nil
+	})
 	if err != nil {
-		return NewErrorResponse(req.ID, ErrorCodeTaskNotFound, err.Error(), nil)
+		if errors.Is(err, ErrTaskNotFound) {
+			return NewErrorResponse(req.ID, ErrorCodeTaskNotFound, err.Error(), nil)
+		}
+		return NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil)
 	}
 
-	// 检查是否可以取消
-	if task.IsFinalState() {
+	if !canceled {
 		return NewSuccessResponse(req.ID, &TasksCancelResult{
 			Success: false,
 			Message: "Task is already in final state",
 		})
 	}
 
-	// 设置取消信号
+	// 设置取消信号，通知正在执行的流式请求停止
 	s.taskStore.AddCancellation(params.TaskID)
 
-	// 更新任务状态
-	task.UpdateStatus(TaskStateCanceled, &Message{
-		MessageID: generateID(),
-		Role:      "agent",
-		Parts:     []Part{{Kind: "text", Text: "Task canceled by request."}},
-		Kind:      "message",
+	return NewSuccessResponse(req.ID, &TasksCancelResult{
+		Success: true,
+		Message: "Task canceled successfully",
 	})
+}
 
-	if err := s.taskStore.Save(agentID, task); err != nil {
-		a2aLog.Warn(context.Background(), "save task error", map[string]any{"error": err})
+// handlePushNotificationConfigSet 处理 tasks/pushNotificationConfig/set 方法
+func (s *Server) handlePushNotificationConfigSet(_ context.Context, agentID string, req *JSONRPCRequest) *JSONRPCResponse {
+	if s.notifier == nil {
+		return NewErrorResponse(req.ID, ErrorCodePushNotificationNotSupported,
+			"push notifications are not enabled", nil)
 	}
 
-	return NewSuccessResponse(req.ID, &TasksCancelResult{
-		Success: true,
-		Message: "Task canceled successfully",
+	var params TaskPushNotificationConfig
+	if err := parseParams(req.Params, &params); err != nil {
+		return NewErrorResponse(req.ID, ErrorCodeInvalidParams, err.Error(), nil)
+	}
+
+	if _, err := s.taskStore.Load(agentID, params.TaskID); err != nil {
+		return NewErrorResponse(req.ID, ErrorCodeTaskNotFound, err.Error(), nil)
+	}
+
+	config, code, err := s.setPushNotificationConfig(agentID, params.TaskID, &params.PushNotificationConfig)
+	if err != nil {
+		return NewErrorResponse(req.ID, code, err.Error(), nil)
+	}
+
+	return NewSuccessResponse(req.ID, &TaskPushNotificationConfig{
+		TaskID:                 params.TaskID,
+		PushNotificationConfig: *config,
+	})
+}
+
+// handlePushNotificationConfigGet 处理 tasks/pushNotificationConfig/get 方法
+func (s *Server) handlePushNotificationConfigGet(_ context.Context, agentID string, req *JSONRPCRequest) *JSONRPCResponse {
+	if s.notifier == nil {
+		return NewErrorResponse(req.ID, ErrorCodePushNotificationNotSupported,
+			"push notifications are not enabled", nil)
+	}
+
+	var params PushNotificationConfigGetParams
+	if err := parseParams(req.Params, &params); err != nil {
+		return NewErrorResponse(req.ID, ErrorCodeInvalidParams, err.Error(), nil)
+	}
+
+	if _, err := s.taskStore.Load(agentID, params.TaskID); err != nil {
+		return NewErrorResponse(req.ID, ErrorCodeTaskNotFound, err.Error(), nil)
+	}
+
+	config, err := s.taskStore.GetPushNotificationConfig(agentID, params.TaskID)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil)
+	}
+	if config == nil {
+		return NewErrorResponse(req.ID, ErrorCodeInvalidParams,
+			"push notification config not set for task: "+params.TaskID, nil)
+	}
+
+	return NewSuccessResponse(req.ID, &TaskPushNotificationConfig{
+		TaskID:                 params.TaskID,
+		PushNotificationConfig: *config,
 	})
 }
 
-// loadOrCreateTask 加载或创建任务
-func (s *Server) loadOrCreateTask(agentID, taskID, contextID string, metadata Metadata) (*Task, error) {
-	task, err := s.taskStore.Load(agentID, taskID)
-	if err == nil {
-		// 任务已存在
-		if task.IsFinalState() {
-			// 已完成的任务收到新消息，重启任务
-			task.UpdateStatus(TaskStateSubmitted, nil)
-		} else if task.Status.State == TaskStateInputRequired {
-			// 需要输入的任务收到消息，继续工作
-			task.UpdateStatus(TaskStateWorking, nil)
+// applyConfiguration 应用 message/send 和 message/stream 附带的配置，失败时返回错误响应
+func (s *Server) applyConfiguration(agentID, taskID string, configuration *MessageSendConfiguration, reqID any) *JSONRPCResponse {
+	if configuration == nil || configuration.PushNotificationConfig == nil {
+		return nil
+	}
+	if _, code, err := s.setPushNotificationConfig(agentID, taskID, configuration.PushNotificationConfig); err != nil {
+		return NewErrorResponse(reqID, code, err.Error(), nil)
+	}
+	return nil
+}
+
+// setPushNotificationConfig 校验并保存推送配置，失败时返回对应的错误码
+func (s *Server) setPushNotificationConfig(agentID, taskID string, config *PushNotificationConfig) (*PushNotificationConfig, int, error) {
+	if s.notifier == nil {
+		return nil, ErrorCodePushNotificationNotSupported, errors.New("push notifications are not enabled")
+	}
+	if err := validatePushNotificationConfig(config, s.notifier.guard); err != nil {
+		return nil, ErrorCodeInvalidParams, err
+	}
+
+	config = copyPushConfig(config)
+	if config.ID == "" {
+		config.ID = generateID()
+	}
+	if err := s.taskStore.SetPushNotificationConfig(agentID, taskID, config); err != nil {
+		return nil, ErrorCodeInternalError, err
+	}
+	return config, 0, nil
+}
+
+// startTask 原子地创建或恢复任务，追加用户消息并进入 working 状态
+func (s *Server) startTask(ctx context.Context, agentID, taskID string, message Message, contextID string, metadata Metadata) (*Task, error) {
+	var previous TaskStatus
+	task, err := s.taskStore.Update(agentID, taskID, func(task *Task) (*Task, error) {
+		if task == nil {
+			// 创建新任务
+			if contextID == "" {
+				contextID = generateID()
+			}
+			task = NewTask(taskID, contextID)
+			if metadata != nil {
+				task.Metadata = metadata
+			}
 		}
+		previous = task.Status
+
+		// 已结束或等待输入的任务收到新消息后继续工作
+		task.AddMessage(message)
+		task.UpdateStatus(TaskStateWorking, nil)
 		return task, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 创建新任务
-	if contextID == "" {
-		contextID = generateID()
+	s.notifyTransition(ctx, agentID, previous, task)
+	return task, nil
+}
+
+// finishTask 以最终状态结束任务
+// 执行期间已被取消的任务保持取消状态；completed 时回复同时加入历史
+func (s *Server) finishTask(ctx context.Context, agentID, taskID string, state TaskState, message Message) {
+	message.TaskID = taskID
+	_, err := s.updateTask(ctx, agentID, taskID, func(task *Task) error {
+		if task.Status.State == TaskStateCanceled {
+			return nil
+		}
+		if state == TaskStateCompleted {
+			task.AddMessage(message)
+		}
+		task.UpdateStatus(state, &message)
+		return nil
+	})
+	if err != nil {
+		a2aLog.Warn(ctx, "save task error", map[string]any{"task_id": taskID, "error": err})
 	}
+}
 
-	task = NewTask(taskID, contextID)
-	if metadata != nil {
-		task.Metadata = metadata
+// updateTask 原子地修改已存在的任务，状态变化且配置了推送时异步通知
+func (s *Server) updateTask(ctx context.Context, agentID, taskID string, fn func(task *Task) error) (*Task, error) {
+	var previous TaskStatus
+	task, err := s.taskStore.Update(agentID, taskID, func(task *Task) (*Task, error) {
+		if task == nil {
+			return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+		}
+		previous = task.Status
+		if err := fn(task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	s.notifyTransition(ctx, agentID, previous, task)
 	return task, nil
 }
 
+// notifyTransition 任务状态变化且配置了推送时异步通知
+func (s *Server) notifyTransition(ctx context.Context, agentID string, previous TaskStatus, task *Task) {
+	if s.notifier == nil || (previous.State == task.Status.State && previous.Timestamp == task.Status.Timestamp) {
+		return
+	}
+
+	config, err := s.taskStore.GetPushNotificationConfig(agentID, task.ID)
+	if err != nil {
+		a2aLog.Warn(ctx, "load push notification config error", map[string]any{"task_id": task.ID, "error": err})
+		return
+	}
+	if config != nil {
+		s.notifier.Enqueue(agentID, config, task)
+	}
+}
+
 // ============== 辅助函数 ==============
 
 // parseParams 解析 JSON-RPC 参数
@@ -302,6 +421,16 @@ func parseParams(params any, target any) error {
 	return nil
 }
 
+// agentMessage 创建 Agent 文本消息
+func agentMessage(text string) Message {
+	return Message{
+		MessageID: generateID(),
+		Role:      "agent",
+		Parts:     []Part{{Kind: "text", Text: text}},
+		Kind:      "message",
+	}
+}
+
 // extractText 从 Parts 中提取文本
 func extractText(parts []Part) string {
 	for _, part := range parts {
@@ -1,19 +1,32 @@
 package a2a
 
 import (
+	"errors"
 	"fmt"
 	"maps"
 	"sync"
 )
 
+// ErrTaskNotFound 任务不存在
+var ErrTaskNotFound = errors.New("task not found")
+
+// TaskUpdateFunc 在存储内部修改任务
+// 任务不存在时 task 为 nil，需要返回新建的任务；返回错误时不保存。
+// 分布式存储在并发冲突时会重试，fn 可能被调用多次。
+type TaskUpdateFunc func(task *Task) (*Task, error)
+
 // TaskStore Task 存储接口
 type TaskStore interface {
-	// Load 加载任务
+	// Load 加载任务，不存在时返回 ErrTaskNotFound
 	Load(agentID, taskID string) (*Task, error)
 
 	// Save 保存任务
 	Save(agentID string, task *Task) error
 
+	// Update 原子地读取、修改并保存任务，返回保存后的任务
+	// 并发请求修改同一任务时不会丢失彼此的更新
+	Update(agentID, taskID string, fn TaskUpdateFunc) (*Task, error)
+
 	// Delete 删除任务
 	Delete(agentID, taskID string) error
 
@@ -28,14 +41,21 @@ type TaskStore interface {
 
 	// IsCanceled 检查是否已取消
 	IsCanceled(taskID string) bool
+
+	// SetPushNotificationConfig 保存任务的推送配置
+	SetPushNotificationConfig(agentID, taskID string, config *PushNotificationConfig) error
+
+	// GetPushNotificationConfig 获取任务的推送配置，未设置时返回 nil
+	GetPushNotificationConfig(agentID, taskID string) (*PushNotificationConfig, error)
 }
 
 // InMemoryTaskStore 内存任务存储
 // 使用 Map 存储，支持并发访问
 type InMemoryTaskStore struct {
 	mu                  sync.RWMutex
-	tasks               map[string]*Task // key: "agentID-taskID"
-	activeCancellations map[string]bool  // key: taskID
+	tasks               map[string]*Task                   // key: "agentID-taskID"
+	activeCancellations map[string]bool                    // key: taskID
+	pushConfigs         map[string]*PushNotificationConfig // key: "agentID-taskID"
 }
 
 // NewInMemoryTaskStore 创建内存任务存储
@@ -43,6 +63,7 @@ func NewInMemoryTaskStore() *InMemoryTaskStore {
 	return &InMemoryTaskStore{
 		tasks:               make(map[string]*Task),
 		activeCancellations: make(map[string]bool),
+		pushConfigs:         make(map[string]*PushNotificationConfig),
 	}
 }
 
@@ -54,7 +75,7 @@ func (s *InMemoryTaskStore) Load(agentID, taskID string) (*Task, error) {
 	key := makeKey(agentID, taskID)
 	task, exists := s.tasks[key]
 	if !exists {
-		return nil, fmt.Errorf("task not found: %s", taskID)
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
 	}
 
 	// 返回副本，防止外部修改
@@ -73,6 +94,24 @@ func (s *InMemoryTaskStore) Save(agentID string, task *Task) error {
 	return nil
 }
 
+// Update 原子地更新任务
+func (s *InMemoryTaskStore) Update(agentID, taskID string, fn TaskUpdateFunc) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := makeKey(agentID, taskID)
+	task, err := fn(copyTask(s.tasks[key]))
+	if err != nil {
+		return nil, err
+	}
+	if task == nil || task.ID != taskID {
+		return nil, fmt.Errorf("update must return task %s", taskID)
+	}
+
+	s.tasks[key] = copyTask(task)
+	return copyTask(task), nil
+}
+
 // Delete 删除任务
 func (s *InMemoryTaskStore) Delete(agentID, taskID string) error {
 	s.mu.Lock()
@@ -80,6 +119,7 @@ func (s *InMemoryTaskStore) Delete(agentID, taskID string) error {
 
 	key := makeKey(agentID, taskID)
 	delete(s.tasks, key)
+	delete(s.pushConfigs, key)
 
 	return nil
 }
@@ -125,6 +165,23 @@ func (s *InMemoryTaskStore) IsCanceled(taskID string) bool {
 	return s.activeCancellations[taskID]
 }
 
+// SetPushNotificationConfig 保存任务的推送配置
+func (s *InMemoryTaskStore) SetPushNotificationConfig(agentID, taskID string, config *PushNotificationConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pushConfigs[makeKey(agentID, taskID)] = copyPushConfig(config)
+	return nil
+}
+
+// GetPushNotificationConfig 获取任务的推送配置
+func (s *InMemoryTaskStore) GetPushNotificationConfig(agentID, taskID string) (*PushNotificationConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return copyPushConfig(s.pushConfigs[makeKey(agentID, taskID)]), nil
+}
+
 // makeKey 生成存储键
 func makeKey(agentID, taskID string) string {
 	return agentID + "-" + taskID
@@ -173,3 +230,18 @@ func copyTask(task *Task) *Task {
 
 	return copied
 }
+
+// copyPushConfig 深拷贝推送配置
+func copyPushConfig(config *PushNotificationConfig) *PushNotificationConfig {
+	if config == nil {
+		return nil
+	}
+
+	copied := *config
+	if config.Authentication != nil {
+		auth := *config.Authentication
+		auth.Schemes = append([]string(nil), config.Authentication.Schemes...)
+		copied.Authentication = &auth
+	}
+	return &copied
+}
@@ -0,0 +1,333 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileTaskStoreConfig JSON 文件任务存储配置
+type FileTaskStoreConfig struct {
+	// Dir 存储目录，每个任务保存为 {Dir}/{agentID}/{taskID}.json
+	Dir string
+
+	// TTL 已结束（completed/failed/canceled）的任务保留时长，0 表示永久保留
+	// 过期任务在读取时视为不存在，并由 PurgeExpired 清理
+	TTL time.Duration
+}
+
+// FileTaskStore JSON 文件任务存储
+// 进程重启后任务仍可通过 tasks/get 查询。同一进程内的更新是原子的，
+// 但不支持多个进程共享同一目录，多实例部署请使用 RedisTaskStore。
+// 取消信号只用于通知本进程内正在执行的请求，保存在内存中。
+type FileTaskStore struct {
+	dir string
+	ttl time.Duration
+
+	mu                  sync.Mutex
+	activeCancellations map[string]bool // key: taskID
+}
+
+// taskRecord 持久化的任务记录
+type taskRecord struct {
+	Task       *Task                   `json:"task,omitempty"`
+	PushConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+	UpdatedAt  time.Time               `json:"updatedAt"`
+}
+
+// NewFileTaskStore 创建 JSON 文件任务存储
+func NewFileTaskStore(config *FileTaskStoreConfig) (*FileTaskStore, error) {
+	if config == nil || config.Dir == "" {
+		return nil, errors.New("file task store requires a directory")
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("create task store directory: %w", err)
+	}
+
+	return &FileTaskStore{
+		dir:                 config.Dir,
+		ttl:                 config.TTL,
+		activeCancellations: make(map[string]bool),
+	}, nil
+}
+
+// Load 加载任务
+func (s *FileTaskStore) Load(agentID, taskID string) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.read(agentID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Task == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	return record.Task, nil
+}
+
+// Save 保存任务
+func (s *FileTaskStore) Save(agentID string, task *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.read(agentID, task.ID)
+	if err != nil {
+		return err
+	}
+	record.Task = task
+	return s.write(agentID, task.ID, record)
+}
+
+// Update 原子地更新任务
+func (s *FileTaskStore) Update(agentID, taskID string, fn TaskUpdateFunc) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.read(agentID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := fn(record.Task)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil || task.ID != taskID {
+		return nil, fmt.Errorf("update must return task %s", taskID)
+	}
+
+	record.Task = task
+	if err := s.write(agentID, taskID, record); err != nil {
+		return nil, err
+	}
+	return copyTask(task), nil
+}
+
+// Delete 删除任务
+func (s *FileTaskStore) Delete(agentID, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(agentID, taskID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete task: %w", err)
+	}
+	return nil
+}
+
+// List 列出 Agent 的所有任务
+func (s *FileTaskStore) List(agentID string) ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.agentDir(agentID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Task{}, nil
+		}
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		record, err := s.readFile(filepath.Join(s.agentDir(agentID), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if record.Task != nil {
+			tasks = append(tasks, record.Task)
+		}
+	}
+	return tasks, nil
+}
+
+// AddCancellation 添加取消信号
+func (s *FileTaskStore) AddCancellation(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.activeCancellations[taskID] = true
+}
+
+// RemoveCancellation 移除取消信号
+func (s *FileTaskStore) RemoveCancellation(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.activeCancellations, taskID)
+}
+
+// IsCanceled 检查是否已取消
+func (s *FileTaskStore) IsCanceled(taskID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.activeCancellations[taskID]
+}
+
+// SetPushNotificationConfig 保存任务的推送配置
+func (s *FileTaskStore) SetPushNotificationConfig(agentID, taskID string, config *PushNotificationConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.read(agentID, taskID)
+	if err != nil {
+		return err
+	}
+	record.PushConfig = config
+	return s.write(agentID, taskID, record)
+}
+
+// GetPushNotificationConfig 获取任务的推送配置
+func (s *FileTaskStore) GetPushNotificationConfig(agentID, taskID string) (*PushNotificationConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.read(agentID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return record.PushConfig, nil
+}
+
+// PurgeExpired 删除所有已过期的任务，返回删除数量
+// 未设置 TTL 时不做任何操作，可由调用方定期执行
+func (s *FileTaskStore) PurgeExpired() (int, error) {
+	if s.ttl <= 0 {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agents, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("read task store directory: %w", err)
+	}
+
+	purged := 0
+	for _, agent := range agents {
+		if !agent.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, agent.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return purged, fmt.Errorf("read agent directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			record, err := readTaskRecord(path)
+			if err != nil {
+				return purged, err
+			}
+			if s.expired(record) {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return purged, fmt.Errorf("remove expired task: %w", err)
+				}
+				purged++
+			}
+		}
+	}
+	return purged, nil
+}
+
+// read 读取任务记录，不存在或已过期时返回空记录
+func (s *FileTaskStore) read(agentID, taskID string) (*taskRecord, error) {
+	return s.readFile(s.path(agentID, taskID))
+}
+
+// readFile 读取任务记录，顺带删除已过期的文件
+func (s *FileTaskStore) readFile(path string) (*taskRecord, error) {
+	record, err := readTaskRecord(path)
+	if err != nil {
+		return nil, err
+	}
+	if s.expired(record) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			a2aLog.Warn(context.Background(), "remove expired task error", map[string]any{"path": path, "error": err})
+		}
+		return &taskRecord{}, nil
+	}
+	return record, nil
+}
+
+// readTaskRecord 读取任务记录文件，不存在时返回空记录
+func readTaskRecord(path string) (*taskRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &taskRecord{}, nil
+		}
+		return nil, fmt.Errorf("read task: %w", err)
+	}
+
+	var record taskRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("unmarshal task %s: %w", path, err)
+	}
+	return &record, nil
+}
+
+// write 先写临时文件再重命名，避免写入中断时损坏原文件
+func (s *FileTaskStore) write(agentID, taskID string, record *taskRecord) error {
+	record.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal task: %w", err)
+	}
+
+	path := s.path(agentID, taskID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create agent directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("write task: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename task file: %w", err)
+	}
+	return nil
+}
+
+// expired 已结束的任务超过 TTL 后过期
+func (s *FileTaskStore) expired(record *taskRecord) bool {
+	if s.ttl <= 0 || record.UpdatedAt.IsZero() || (record.Task != nil && !record.Task.IsFinalState()) {
+		return false
+	}
+	return time.Since(record.UpdatedAt) > s.ttl
+}
+
+func (s *FileTaskStore) agentDir(agentID string) string {
+	return filepath.Join(s.dir, escapeID(agentID))
+}
+
+func (s *FileTaskStore) path(agentID, taskID string) string {
+	return filepath.Join(s.agentDir(agentID), escapeID(taskID)+".json")
+}
+
+// escapeID 将 ID 转换为可安全用作文件名或 Redis key 片段的字符串
+// 转义路径分隔符和 ":"，防止越出存储目录或与 key 分隔符混淆
+func escapeID(id string) string {
+	escaped := strings.ReplaceAll(url.PathEscape(id), ":", "%3A")
+	if escaped == "." || escaped == ".." {
+		return strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
@@ -0,0 +1,277 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/actor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTaskStore 所有 TaskStore 实现共用的行为测试
+func testTaskStore(t *testing.T, store TaskStore) {
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		task := NewTask("task-1", "ctx-1")
+		task.AddMessage(NewTextMessage("msg-1", "user", "Hello"))
+		require.NoError(t, store.Save("agent-1", task))
+
+		loaded, err := store.Load("agent-1", "task-1")
+		require.NoError(t, err)
+		assert.Equal(t, "ctx-1", loaded.ContextID)
+		require.Len(t, loaded.History, 1)
+		assert.Equal(t, "Hello", loaded.History[0].Parts[0].Text)
+
+		// 不同 Agent 的任务互不可见
+		_, err = store.Load("agent-2", "task-1")
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		created, err := store.Update("agent-1", "task-2", func(task *Task) (*Task, error) {
+			assert.Nil(t, task)
+			return NewTask("task-2", "ctx-2"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, TaskStateSubmitted, created.Status.State)
+
+		_, err = store.Update("agent-1", "task-2", func(task *Task) (*Task, error) {
+			return nil, fmt.Errorf("abort")
+		})
+		require.Error(t, err)
+
+		_, err = store.Update("agent-1", "task-2", func(task *Task) (*Task, error) {
+			return NewTask("other", "ctx-2"), nil
+		})
+		require.Error(t, err)
+
+		loaded, err := store.Load("agent-1", "task-2")
+		require.NoError(t, err)
+		assert.Equal(t, TaskStateSubmitted, loaded.Status.State)
+	})
+
+	t.Run("ConcurrentUpdate", func(t *testing.T) {
+		require.NoError(t, store.Save("agent-1", NewTask("task-3", "ctx-3")))
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := store.Update("agent-1", "task-3", func(task *Task) (*Task, error) {
+					task.AddMessage(NewTextMessage(fmt.Sprintf("msg-%d", i), "user", "Hi"))
+					return task, nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		loaded, err := store.Load("agent-1", "task-3")
+		require.NoError(t, err)
+		assert.Len(t, loaded.History, 50, "concurrent updates must not be lost")
+	})
+
+	t.Run("ListAndDelete", func(t *testing.T) {
+		require.NoError(t, store.Save("agent-list", NewTask("task-a", "ctx")))
+		require.NoError(t, store.Save("agent-list", NewTask("task-b", "ctx")))
+
+		tasks, err := store.List("agent-list")
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		require.NoError(t, store.Delete("agent-list", "task-a"))
+		tasks, err = store.List("agent-list")
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "task-b", tasks[0].ID)
+
+		tasks, err = store.List("agent-empty")
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("PushNotificationConfig", func(t *testing.T) {
+		require.NoError(t, store.Save("agent-1", NewTask("task-4", "ctx-4")))
+
+		config, err := store.GetPushNotificationConfig("agent-1", "task-4")
+		require.NoError(t, err)
+		assert.Nil(t, config)
+
+		require.NoError(t, store.SetPushNotificationConfig("agent-1", "task-4", &PushNotificationConfig{
+			ID:  "cfg-1",
+			URL: "https://example.com/hook",
+		}))
+		config, err = store.GetPushNotificationConfig("agent-1", "task-4")
+		require.NoError(t, err)
+		require.NotNil(t, config)
+		assert.Equal(t, "https://example.com/hook", config.URL)
+
+		// 更新任务不影响推送配置
+		_, err = store.Update("agent-1", "task-4", func(task *Task) (*Task, error) {
+			task.UpdateStatus(TaskStateWorking, nil)
+			return task, nil
+		})
+		require.NoError(t, err)
+		config, err = store.GetPushNotificationConfig("agent-1", "task-4")
+		require.NoError(t, err)
+		require.NotNil(t, config)
+		assert.Equal(t, "cfg-1", config.ID)
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		assert.False(t, store.IsCanceled("task-5"))
+		store.AddCancellation("task-5")
+		assert.True(t, store.IsCanceled("task-5"))
+		store.RemoveCancellation("task-5")
+		assert.False(t, store.IsCanceled("task-5"))
+	})
+}
+
+func TestInMemoryTaskStore_Conformance(t *testing.T) {
+	testTaskStore(t, NewInMemoryTaskStore())
+}
+
+func TestFileTaskStore_Conformance(t *testing.T) {
+	store, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: t.TempDir()})
+	require.NoError(t, err)
+	testTaskStore(t, store)
+}
+
+func TestFileTaskStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: dir})
+	require.NoError(t, err)
+
+	task := NewTask("task-1", "ctx-1")
+	task.UpdateStatus(TaskStateCompleted, nil)
+	require.NoError(t, store.Save("agent-1", task))
+	require.NoError(t, store.SetPushNotificationConfig("agent-1", "task-1", &PushNotificationConfig{URL: "https://example.com"}))
+
+	// 模拟进程重启
+	reopened, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: dir})
+	require.NoError(t, err)
+
+	loaded, err := reopened.Load("agent-1", "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, TaskStateCompleted, loaded.Status.State)
+
+	config, err := reopened.GetPushNotificationConfig("agent-1", "task-1")
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, "https://example.com", config.URL)
+}
+
+func TestFileTaskStore_TTL(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: dir, TTL: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	done := NewTask("done", "ctx")
+	done.UpdateStatus(TaskStateCompleted, nil)
+	require.NoError(t, store.Save("agent-1", done))
+	require.NoError(t, store.Save("agent-1", NewTask("running", "ctx")))
+
+	time.Sleep(100 * time.Millisecond)
+
+	// 已结束的任务过期，未结束的任务保留
+	_, err = store.Load("agent-1", "done")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = store.Load("agent-1", "running")
+	require.NoError(t, err)
+
+	tasks, err := store.List("agent-1")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "running", tasks[0].ID)
+
+	// PurgeExpired 清理未被读取过的过期任务
+	_, err = store.Update("agent-1", "running", func(task *Task) (*Task, error) {
+		task.UpdateStatus(TaskStateFailed, nil)
+		return task, nil
+	})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	purged, err := store.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "agent-1"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFileTaskStore_EscapesIDs(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: filepath.Join(dir, "tasks")})
+	require.NoError(t, err)
+
+	for _, id := range []string{"../escape", "..", "a/b", `a\b`, "a:b"} {
+		require.NoError(t, store.Save("../agent", NewTask(id, "ctx")), id)
+		loaded, err := store.Load("../agent", id)
+		require.NoError(t, err, id)
+		assert.Equal(t, id, loaded.ID)
+	}
+
+	// 所有文件都位于存储目录内
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "tasks", entries[0].Name())
+
+	tasks, err := store.List("../agent")
+	require.NoError(t, err)
+	assert.Len(t, tasks, 5)
+}
+
+func TestServer_FileTaskStoreSurvivesRestart(t *testing.T) {
+	system := actor.NewSystem("test-a2a-file")
+	defer system.Shutdown()
+	system.Spawn(&MockAgentActor{}, "file-agent")
+
+	dir := t.TempDir()
+	store, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: dir})
+	require.NoError(t, err)
+	server := NewServer(system, store)
+
+	// 并发向同一任务发送消息，所有消息和回复都应保留
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := NewTextMessage(fmt.Sprintf("msg-%d", i), "user", "Hi")
+			msg.TaskID = "shared-task"
+			resp := server.HandleRequest(ctx, "file-agent", &JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      i,
+				Method:  "message/send",
+				Params:  &MessageSendParams{Message: msg},
+			})
+			assert.Nil(t, resp.Error)
+		}()
+	}
+	wg.Wait()
+
+	// 新的 Server 和 Store 读取同一目录
+	reopened, err := NewFileTaskStore(&FileTaskStoreConfig{Dir: dir})
+	require.NoError(t, err)
+	resp := NewServer(system, reopened).HandleRequest(ctx, "file-agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "get",
+		Method:  "tasks/get",
+		Params:  &TasksGetParams{TaskID: "shared-task"},
+	})
+	require.Nil(t, resp.Error)
+
+	task := resp.Result.(*TasksGetResult).Task
+	assert.Equal(t, TaskStateCompleted, task.Status.State)
+	assert.Len(t, task.History, 10)
+}
@@ -0,0 +1,324 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisTaskPrefix  = "aster:a2a:"
+	defaultRedisTaskTimeout = 3 * time.Second
+
+	// redisCancellationTTL 取消信号的保留时长，防止进程崩溃后残留
+	redisCancellationTTL = 24 * time.Hour
+
+	// redisUpdateRetries 乐观锁冲突时的最大重试次数
+	redisUpdateRetries = 16
+)
+
+// RedisTaskStoreConfig Redis 任务存储配置
+type RedisTaskStoreConfig struct {
+	Addr     string        // Redis 地址，格式: "host:port"
+	Password string        // 密码
+	DB       int           // 数据库编号
+	Prefix   string        // Key 前缀，默认 "aster:a2a:"
+	Timeout  time.Duration // 单次操作超时，默认 3s
+
+	// TTL 已结束（completed/failed/canceled）的任务保留时长，0 表示永久保留
+	TTL time.Duration
+
+	// Client 复用已有的 Redis 客户端（可选），设置后忽略 Addr/Password/DB
+	Client redis.UniversalClient
+}
+
+// RedisTaskStore Redis 任务存储
+// 适用于多实例部署：任务、推送配置和取消信号在实例间共享，
+// Update 使用 WATCH/MULTI 乐观锁保证原子性。
+//
+// Key 布局:
+//   - {prefix}task:{agentID}:{taskID}  任务记录 (JSON)
+//   - {prefix}tasks:{agentID}          Agent 的任务 ID 集合
+//   - {prefix}cancel:{taskID}          取消信号
+type RedisTaskStore struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+	ttl     time.Duration
+}
+
+// NewRedisTaskStore 创建 Redis 任务存储，不在创建时连接
+func NewRedisTaskStore(config *RedisTaskStoreConfig) (*RedisTaskStore, error) {
+	if config == nil || (config.Client == nil && config.Addr == "") {
+		return nil, errors.New("redis task store requires addr or client")
+	}
+
+	client := config.Client
+	if client == nil {
+		client = redis.NewClient(&redis.Options{
+			Addr:     config.Addr,
+			Password: config.Password,
+			DB:       config.DB,
+		})
+	}
+
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = defaultRedisTaskPrefix
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultRedisTaskTimeout
+	}
+
+	return &RedisTaskStore{
+		client:  client,
+		prefix:  prefix,
+		timeout: timeout,
+		ttl:     config.TTL,
+	}, nil
+}
+
+// Load 加载任务
+func (s *RedisTaskStore) Load(agentID, taskID string) (*Task, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	record, err := s.read(ctx, s.client, agentID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Task == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	return record.Task, nil
+}
+
+// Save 保存任务
+func (s *RedisTaskStore) Save(agentID string, task *Task) error {
+	return s.modify(agentID, task.ID, func(record *taskRecord) error {
+		record.Task = task
+		return nil
+	})
+}
+
+// Update 原子地更新任务
+// 与其他实例的写入冲突时重新读取并再次调用 fn
+func (s *RedisTaskStore) Update(agentID, taskID string, fn TaskUpdateFunc) (*Task, error) {
+	var updated *Task
+	err := s.modify(agentID, taskID, func(record *taskRecord) error {
+		task, err := fn(record.Task)
+		if err != nil {
+			return err
+		}
+		if task == nil || task.ID != taskID {
+			return fmt.Errorf("update must return task %s", taskID)
+		}
+		record.Task = task
+		updated = task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyTask(updated), nil
+}
+
+// Delete 删除任务
+func (s *RedisTaskStore) Delete(agentID, taskID string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.taskKey(agentID, taskID))
+		pipe.SRem(ctx, s.indexKey(agentID), taskID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis delete task: %w", err)
+	}
+	return nil
+}
+
+// List 列出 Agent 的所有任务
+// 已过期任务的 ID 会顺带从索引中移除
+func (s *RedisTaskStore) List(agentID string) ([]*Task, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	taskIDs, err := s.client.SMembers(ctx, s.indexKey(agentID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list tasks: %w", err)
+	}
+	if len(taskIDs) == 0 {
+		return []*Task{}, nil
+	}
+
+	keys := make([]string, len(taskIDs))
+	for i, taskID := range taskIDs {
+		keys[i] = s.taskKey(agentID, taskID)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis list tasks: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(values))
+	var missing []any
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			missing = append(missing, taskIDs[i])
+			continue
+		}
+		var record taskRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return nil, fmt.Errorf("unmarshal task %s: %w", taskIDs[i], err)
+		}
+		if record.Task != nil {
+			tasks = append(tasks, record.Task)
+		}
+	}
+
+	if len(missing) > 0 {
+		if err := s.client.SRem(ctx, s.indexKey(agentID), missing...).Err(); err != nil {
+			a2aLog.Warn(ctx, "remove expired task ids error", map[string]any{"agent_id": agentID, "error": err})
+		}
+	}
+	return tasks, nil
+}
+
+// AddCancellation 添加取消信号
+func (s *RedisTaskStore) AddCancellation(taskID string) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err := s.client.Set(ctx, s.cancelKey(taskID), "1", redisCancellationTTL).Err(); err != nil {
+		a2aLog.Warn(ctx, "add cancellation error", map[string]any{"task_id": taskID, "error": err})
+	}
+}
+
+// RemoveCancellation 移除取消信号
+func (s *RedisTaskStore) RemoveCancellation(taskID string) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err := s.client.Del(ctx, s.cancelKey(taskID)).Err(); err != nil {
+		a2aLog.Warn(ctx, "remove cancellation error", map[string]any{"task_id": taskID, "error": err})
+	}
+}
+
+// IsCanceled 检查是否已取消，Redis 不可用时视为未取消
+func (s *RedisTaskStore) IsCanceled(taskID string) bool {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	n, err := s.client.Exists(ctx, s.cancelKey(taskID)).Result()
+	return err == nil && n > 0
+}
+
+// SetPushNotificationConfig 保存任务的推送配置
+func (s *RedisTaskStore) SetPushNotificationConfig(agentID, taskID string, config *PushNotificationConfig) error {
+	return s.modify(agentID, taskID, func(record *taskRecord) error {
+		record.PushConfig = config
+		return nil
+	})
+}
+
+// GetPushNotificationConfig 获取任务的推送配置
+func (s *RedisTaskStore) GetPushNotificationConfig(agentID, taskID string) (*PushNotificationConfig, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	record, err := s.read(ctx, s.client, agentID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return record.PushConfig, nil
+}
+
+// Close 关闭 Redis 客户端
+func (s *RedisTaskStore) Close() error {
+	return s.client.Close()
+}
+
+// modify 在 WATCH 事务中读取、修改并写回任务记录
+func (s *RedisTaskStore) modify(agentID, taskID string, fn func(record *taskRecord) error) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	key := s.taskKey(agentID, taskID)
+	txf := func(tx *redis.Tx) error {
+		record, err := s.read(ctx, tx, agentID, taskID)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+
+		record.UpdatedAt = time.Now()
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshal task: %w", err)
+		}
+
+		// 已结束的任务在 TTL 后过期，重新开始的任务恢复为永久保留
+		var expiration time.Duration
+		if s.ttl > 0 && record.Task != nil && record.Task.IsFinalState() {
+			expiration = s.ttl
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, expiration)
+			pipe.SAdd(ctx, s.indexKey(agentID), taskID)
+			return nil
+		})
+		return err
+	}
+
+	for range redisUpdateRetries {
+		err := s.client.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("update task %s: too many concurrent modifications", taskID)
+}
+
+// read 读取任务记录，不存在时返回空记录
+func (s *RedisTaskStore) read(ctx context.Context, client redis.Cmdable, agentID, taskID string) (*taskRecord, error) {
+	data, err := client.Get(ctx, s.taskKey(agentID, taskID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &taskRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get task: %w", err)
+	}
+
+	var record taskRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("unmarshal task %s: %w", taskID, err)
+	}
+	return &record, nil
+}
+
+func (s *RedisTaskStore) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+func (s *RedisTaskStore) taskKey(agentID, taskID string) string {
+	return s.prefix + "task:" + escapeID(agentID) + ":" + escapeID(taskID)
+}
+
+func (s *RedisTaskStore) indexKey(agentID string) string {
+	return s.prefix + "tasks:" + escapeID(agentID)
+}
+
+func (s *RedisTaskStore) cancelKey(taskID string) string {
+	return s.prefix + "cancel:" + escapeID(taskID)
+}
@@ -0,0 +1,294 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/agent"
+	"github.com/astercloud/aster/pkg/session"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// cancelPollInterval 流式执行期间检查 tasks/cancel 信号的间隔
+const cancelPollInterval = 200 * time.Millisecond
+
+// StreamEmitter 发送一个流式事件，返回错误时停止推送（通常是客户端已断开）
+type StreamEmitter func(resp *JSONRPCResponse) error
+
+// HandleStream 处理 message/stream 请求
+// 通过 agent.StreamMsg 驱动 Agent Actor，依次发送:
+//   - Task 快照
+//   - working 状态更新
+//   - 每段增量文本对应的 artifact-update (append)
+//   - 完整产出物 (lastChunk) 和 final 状态更新
+//
+// Actor 需要在结束时发送 "done" 事件并关闭 EventCh。
+func (s *Server) HandleStream(ctx context.Context, agentID string, req *JSONRPCRequest, emit StreamEmitter) error {
+	var params MessageStreamParams
+	if err := parseParams(req.Params, &params); err != nil {
+		return emit(NewErrorResponse(req.ID, ErrorCodeInvalidParams, err.Error(), nil))
+	}
+
+	taskID := params.Message.TaskID
+	if taskID == "" {
+		taskID = generateID()
+	}
+
+	if resp := s.applyConfiguration(agentID, taskID, params.Configuration, req.ID); resp != nil {
+		return emit(resp)
+	}
+
+	// 任务重新开始执行，清除上一轮的取消信号
+	s.taskStore.RemoveCancellation(taskID)
+	task, err := s.startTask(ctx, agentID, taskID, params.Message, params.ContextID, params.Metadata)
+	if err != nil {
+		return emit(NewErrorResponse(req.ID, ErrorCodeInternalError, err.Error(), nil))
+	}
+
+	ts := &taskStream{
+		server:     s,
+		agentID:    agentID,
+		task:       task,
+		reqID:      req.ID,
+		emit:       emit,
+		artifactID: generateID(),
+	}
+	defer ts.abandon(ctx)
+
+	if err := ts.send(copyTask(task)); err != nil {
+		return err
+	}
+	if err := ts.sendStatus(false); err != nil {
+		return err
+	}
+
+	pid, exists := s.actorSystem.GetActor(agentID)
+	if !exists {
+		return ts.finish(TaskStateFailed, "agent not found: "+agentID)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh := make(chan *agent.StreamEventMsg, 16)
+	pid.Tell(&agent.StreamMsg{
+		Text:    extractText(params.Message.Parts),
+		Ctx:     streamCtx,
+		EventCh: eventCh,
+	})
+	// 提前返回时继续读取剩余事件，避免 Actor 阻塞在发送上
+	defer func() {
+		go func() {
+			for range eventCh {
+			}
+		}()
+	}()
+
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			if s.taskStore.IsCanceled(taskID) {
+				cancel()
+				return ts.finish(TaskStateCanceled, "Task canceled by request.")
+			}
+
+		case event, ok := <-eventCh:
+			if !ok {
+				return ts.complete()
+			}
+			if s.taskStore.IsCanceled(taskID) {
+				cancel()
+				return ts.finish(TaskStateCanceled, "Task canceled by request.")
+			}
+
+			switch event.Type {
+			case "done", "error":
+				if event.Error != nil && !errors.Is(event.Error, io.EOF) {
+					return ts.finish(TaskStateFailed, fmt.Sprintf("agent error: %v", event.Error))
+				}
+				return ts.complete()
+			default:
+				if err := ts.appendText(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// taskStream 单个 message/stream 请求的推送状态
+type taskStream struct {
+	server     *Server
+	agentID    string
+	task       *Task
+	reqID      any
+	emit       StreamEmitter
+	artifactID string
+
+	text     strings.Builder // 已推送的全部文本
+	pending  strings.Builder // 上一条完整消息之后推送的增量文本
+	chunks   int             // pending 中的增量数量
+	finished bool
+}
+
+// appendText 推送一段增量文本
+func (ts *taskStream) appendText(event *agent.StreamEventMsg) error {
+	chunk, blocks := streamEventText(event)
+	if chunk == "" {
+		return nil
+	}
+
+	// Agent 在一轮增量输出之后还会发送一条包含完整回复的事件，跳过以免重复
+	if ts.chunks > 0 && blocks == ts.chunks && chunk == ts.pending.String() {
+		ts.pending.Reset()
+		ts.chunks = 0
+		return nil
+	}
+
+	update := &TaskArtifactUpdateEvent{
+		TaskID:    ts.task.ID,
+		ContextID: ts.task.ContextID,
+		Kind:      "artifact-update",
+		Artifact: Artifact{
+			ArtifactID: ts.artifactID,
+			Name:       "response",
+			Parts:      []Part{{Kind: "text", Text: chunk}},
+		},
+		Append: ts.text.Len() > 0,
+	}
+	if err := ts.send(update); err != nil {
+		return err
+	}
+
+	ts.text.WriteString(chunk)
+	ts.pending.WriteString(chunk)
+	ts.chunks += blocks
+	return nil
+}
+
+// complete 推送完整产出物并以 completed 状态结束
+func (ts *taskStream) complete() error {
+	text := ts.text.String()
+	artifact := Artifact{
+		ArtifactID: ts.artifactID,
+		Name:       "response",
+		Parts:      []Part{{Kind: "text", Text: text}},
+	}
+	responseMsg := agentMessage(text)
+	responseMsg.TaskID = ts.task.ID
+
+	ts.save(context.Background(), func(task *Task) {
+		if text != "" {
+			task.Artifacts = append(task.Artifacts, artifact)
+		}
+		task.AddMessage(responseMsg)
+		task.UpdateStatus(TaskStateCompleted, &responseMsg)
+	})
+
+	// 以完整内容替换之前追加的分片
+	if text != "" && ts.task.Status.State == TaskStateCompleted {
+		if err := ts.send(&TaskArtifactUpdateEvent{
+			TaskID:    ts.task.ID,
+			ContextID: ts.task.ContextID,
+			Kind:      "artifact-update",
+			Artifact:  artifact,
+			LastChunk: true,
+		}); err != nil {
+			return err
+		}
+	}
+	return ts.sendStatus(true)
+}
+
+// finish 以指定的最终状态结束
+func (ts *taskStream) finish(state TaskState, reason string) error {
+	message := agentMessage(reason)
+	ts.save(context.Background(), func(task *Task) {
+		task.UpdateStatus(state, &message)
+	})
+	return ts.sendStatus(true)
+}
+
+// abandon 客户端断开导致流提前结束时，将任务标记为已取消
+func (ts *taskStream) abandon(ctx context.Context) {
+	if ts.finished {
+		return
+	}
+	message := agentMessage("Stream closed before the task finished.")
+	ts.save(ctx, func(task *Task) {
+		task.UpdateStatus(TaskStateCanceled, &message)
+	})
+}
+
+// save 原子地更新任务，已被 tasks/cancel 取消的任务保持取消状态
+func (ts *taskStream) save(ctx context.Context, fn func(task *Task)) {
+	ts.finished = true
+	task, err := ts.server.updateTask(ctx, ts.agentID, ts.task.ID, func(task *Task) error {
+		if task.Status.State != TaskStateCanceled {
+			fn(task)
+		}
+		return nil
+	})
+	if err != nil {
+		a2aLog.Warn(ctx, "save task error", map[string]any{"error": err})
+		return
+	}
+	ts.task = task
+}
+
+func (ts *taskStream) sendStatus(final bool) error {
+	return ts.send(&TaskStatusUpdateEvent{
+		TaskID:    ts.task.ID,
+		ContextID: ts.task.ContextID,
+		Kind:      "status-update",
+		Status:    ts.task.Status,
+		Final:     final,
+	})
+}
+
+func (ts *taskStream) send(result any) error {
+	return ts.emit(NewSuccessResponse(ts.reqID, result))
+}
+
+// streamEventText 提取流式事件中的文本及其包含的文本块数量
+// 推理内容不作为产出物推送
+func streamEventText(event *agent.StreamEventMsg) (string, int) {
+	switch content := event.Content.(type) {
+	case string:
+		return content, 1
+	case *session.Event:
+		return messageText(&content.Content)
+	case *types.Message:
+		return messageText(content)
+	}
+	return "", 0
+}
+
+func messageText(msg *types.Message) (string, int) {
+	if len(msg.ContentBlocks) == 0 {
+		if msg.Content == "" {
+			return "", 0
+		}
+		return msg.Content, 1
+	}
+
+	var sb strings.Builder
+	blocks := 0
+	for _, block := range msg.ContentBlocks {
+		if tb, ok := block.(*types.TextBlock); ok {
+			sb.WriteString(tb.Text)
+			blocks++
+		}
+	}
+	return sb.String(), blocks
+}
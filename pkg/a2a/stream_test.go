@@ -0,0 +1,226 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/actor"
+	pkgagent "github.com/astercloud/aster/pkg/agent"
+	"github.com/astercloud/aster/pkg/session"
+	"github.com/astercloud/aster/pkg/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStreamActor 模拟流式输出的 Agent Actor
+// 先逐段发送增量文本，再发送包含完整回复的事件，与 Agent.Stream 的行为一致
+type MockStreamActor struct {
+	chunks []string
+	err    error
+	block  bool // 发送第一段后阻塞直到 context 取消
+}
+
+func (a *MockStreamActor) Receive(_ *actor.Context, msg actor.Message) {
+	m, ok := msg.(*pkgagent.StreamMsg)
+	if !ok {
+		return
+	}
+
+	go func() {
+		defer close(m.EventCh)
+
+		blocks := make([]types.ContentBlock, 0, len(a.chunks))
+		for _, chunk := range a.chunks {
+			m.EventCh <- &pkgagent.StreamEventMsg{Type: "event", Content: textEvent(chunk)}
+			blocks = append(blocks, &types.TextBlock{Text: chunk})
+
+			if a.block {
+				<-m.Ctx.Done()
+				m.EventCh <- &pkgagent.StreamEventMsg{Type: "done", Error: m.Ctx.Err()}
+				return
+			}
+		}
+
+		if a.err != nil {
+			m.EventCh <- &pkgagent.StreamEventMsg{Type: "done", Error: a.err}
+			return
+		}
+
+		// 完整回复事件
+		m.EventCh <- &pkgagent.StreamEventMsg{Type: "event", Content: &session.Event{
+			Content: types.Message{Role: types.RoleAssistant, ContentBlocks: blocks},
+		}}
+		m.EventCh <- &pkgagent.StreamEventMsg{Type: "done", Error: io.EOF}
+	}()
+}
+
+func textEvent(text string) *session.Event {
+	return &session.Event{
+		Content: types.Message{
+			Role:          types.RoleAssistant,
+			ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: text}},
+		},
+	}
+}
+
+// startStreamServer 启动挂载 A2A 路由的测试 HTTP 服务，返回指定 Agent 的客户端
+func startStreamServer(t *testing.T, agentID string, agentActor actor.Actor) (*Client, TaskStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	system := actor.NewSystem("test-a2a-stream")
+	t.Cleanup(system.Shutdown)
+	system.Spawn(agentActor, agentID)
+
+	taskStore := NewInMemoryTaskStore()
+	router := gin.New()
+	NewHandler(NewServer(system, taskStore)).RegisterRoutes(router.Group(""))
+
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	return NewClient(ts.URL+"/a2a/"+agentID, ts.Client()), taskStore
+}
+
+func streamParams(text string) *MessageStreamParams {
+	return &MessageStreamParams{
+		Message: Message{
+			MessageID: "msg-1",
+			Role:      "user",
+			Parts:     []Part{{Kind: "text", Text: text}},
+			Kind:      "message",
+		},
+		ContextID: "context-1",
+	}
+}
+
+func TestHandler_MessageStream(t *testing.T) {
+	client, _ := startStreamServer(t, "stream-agent", &MockStreamActor{chunks: []string{"Hello", " world"}})
+
+	ctx := context.Background()
+	var events []*StreamEvent
+	err := client.SendMessageStream(ctx, streamParams("Hi"), func(event *StreamEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+
+	// Task 快照
+	require.NotNil(t, events[0].Task)
+	taskID := events[0].Task.ID
+	assert.Equal(t, "context-1", events[0].Task.ContextID)
+
+	// working 状态
+	require.NotNil(t, events[1].StatusUpdate)
+	assert.Equal(t, TaskStateWorking, events[1].StatusUpdate.Status.State)
+	assert.False(t, events[1].StatusUpdate.Final)
+
+	// 增量文本，完整回复事件不重复推送
+	require.NotNil(t, events[2].ArtifactUpdate)
+	assert.Equal(t, "Hello", events[2].ArtifactUpdate.Artifact.Parts[0].Text)
+	assert.False(t, events[2].ArtifactUpdate.Append)
+	require.NotNil(t, events[3].ArtifactUpdate)
+	assert.Equal(t, " world", events[3].ArtifactUpdate.Artifact.Parts[0].Text)
+	assert.True(t, events[3].ArtifactUpdate.Append)
+	assert.Equal(t, events[2].ArtifactUpdate.Artifact.ArtifactID, events[3].ArtifactUpdate.Artifact.ArtifactID)
+
+	// 完整产出物
+	require.NotNil(t, events[4].ArtifactUpdate)
+	assert.True(t, events[4].ArtifactUpdate.LastChunk)
+	assert.False(t, events[4].ArtifactUpdate.Append)
+	assert.Equal(t, "Hello world", events[4].ArtifactUpdate.Artifact.Parts[0].Text)
+
+	// final 状态
+	require.NotNil(t, events[5].StatusUpdate)
+	assert.True(t, events[5].IsFinal())
+	assert.Equal(t, TaskStateCompleted, events[5].StatusUpdate.Status.State)
+	assert.Equal(t, taskID, events[5].StatusUpdate.TaskID)
+
+	// 任务已写入 TaskStore
+	task, err := client.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskStateCompleted, task.Status.State)
+	require.Len(t, task.History, 2)
+	assert.Equal(t, "Hello world", task.History[1].Parts[0].Text)
+	require.Len(t, task.Artifacts, 1)
+	assert.Equal(t, "Hello world", task.Artifacts[0].Parts[0].Text)
+}
+
+func TestHandler_MessageStream_AgentError(t *testing.T) {
+	client, _ := startStreamServer(t, "stream-agent", &MockStreamActor{
+		chunks: []string{"partial"},
+		err:    errors.New("model unavailable"),
+	})
+
+	var final *StreamEvent
+	err := client.SendMessageStream(context.Background(), streamParams("Hi"), func(event *StreamEvent) error {
+		final = event
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, final.IsFinal())
+	assert.Equal(t, TaskStateFailed, final.StatusUpdate.Status.State)
+	assert.Contains(t, final.StatusUpdate.Status.Message.Parts[0].Text, "model unavailable")
+}
+
+func TestHandler_MessageStream_Cancel(t *testing.T) {
+	client, taskStore := startStreamServer(t, "stream-agent", &MockStreamActor{
+		chunks: []string{"thinking"},
+		block:  true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var final *StreamEvent
+	err := client.SendMessageStream(ctx, streamParams("Hi"), func(event *StreamEvent) error {
+		if event.ArtifactUpdate != nil {
+			result, err := client.CancelTask(ctx, event.ArtifactUpdate.TaskID)
+			require.NoError(t, err)
+			assert.True(t, result.Success)
+		}
+		final = event
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, final.IsFinal())
+	assert.Equal(t, TaskStateCanceled, final.StatusUpdate.Status.State)
+
+	task, err := taskStore.Load("stream-agent", final.StatusUpdate.TaskID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskStateCanceled, task.Status.State)
+}
+
+func TestHandler_MessageStream_AgentNotFound(t *testing.T) {
+	client, _ := startStreamServer(t, "stream-agent", &MockStreamActor{})
+	client.url += "-missing"
+
+	var final *StreamEvent
+	err := client.SendMessageStream(context.Background(), streamParams("Hi"), func(event *StreamEvent) error {
+		final = event
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStateFailed, final.StatusUpdate.Status.State)
+}
+
+func TestServer_HandleRequest_MessageStreamRequiresSSE(t *testing.T) {
+	system := actor.NewSystem("test-a2a")
+	defer system.Shutdown()
+
+	server := NewServer(system, nil)
+	resp := server.HandleRequest(context.Background(), "agent", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-1",
+		Method:  "message/stream",
+		Params:  streamParams("Hi"),
+	})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, ErrorCodeUnsupportedOperation, resp.Error.Code)
+}
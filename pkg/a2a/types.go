@@ -2,7 +2,10 @@
 // 基于 JSON-RPC 2.0 和 A2A 标准
 package a2a
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // ============== Agent Card ==============
 
@@ -76,8 +79,9 @@ const (
 
 // Artifact 任务产出物
 type Artifact struct {
-	Name  string `json:"name"`
-	Parts []Part `json:"parts"`
+	ArtifactID string `json:"artifactId,omitempty"`
+	Name       string `json:"name"`
+	Parts      []Part `json:"parts"`
 }
 
 // Metadata 任务元数据
@@ -108,6 +112,57 @@ type Part struct {
 	URL      string `json:"url,omitempty"`
 }
 
+// ============== 推送通知 ==============
+
+// PushNotificationConfig 任务状态变化的 webhook 推送配置
+type PushNotificationConfig struct {
+	ID             string                              `json:"id,omitempty"`
+	URL            string                              `json:"url"`
+	Token          string                              `json:"token,omitempty"` // 原样放入 X-A2A-Notification-Token 请求头，供接收方校验
+	Authentication *PushNotificationAuthenticationInfo `json:"authentication,omitempty"`
+}
+
+// PushNotificationAuthenticationInfo 推送 webhook 时使用的认证信息
+type PushNotificationAuthenticationInfo struct {
+	Schemes     []string `json:"schemes"`               // 支持 "Bearer"、"Basic"
+	Credentials string   `json:"credentials,omitempty"` // 对应方案的凭证，直接放入 Authorization 请求头
+}
+
+// TaskPushNotificationConfig 任务及其推送配置
+type TaskPushNotificationConfig struct {
+	TaskID                 string                 `json:"taskId"`
+	PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig"`
+}
+
+// MessageSendConfiguration message/send 和 message/stream 的可选配置
+type MessageSendConfiguration struct {
+	// PushNotificationConfig 随消息一起为任务设置推送配置
+	PushNotificationConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+}
+
+// ============== 流式事件 ==============
+
+// TaskStatusUpdateEvent 任务状态更新事件 (message/stream)
+type TaskStatusUpdateEvent struct {
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Kind      string     `json:"kind"` // 固定为 "status-update"
+	Status    TaskStatus `json:"status"`
+	Final     bool       `json:"final"` // 为 true 时表示流结束
+	Metadata  Metadata   `json:"metadata,omitempty"`
+}
+
+// TaskArtifactUpdateEvent 任务产出物更新事件 (message/stream)
+type TaskArtifactUpdateEvent struct {
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Kind      string   `json:"kind"` // 固定为 "artifact-update"
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append,omitempty"`    // 追加到同一 ArtifactID 的已有内容，否则替换
+	LastChunk bool     `json:"lastChunk,omitempty"` // 该产出物的最后一块
+	Metadata  Metadata `json:"metadata,omitempty"`
+}
+
 // ============== JSON-RPC 2.0 ==============
 
 // JSONRPCRequest JSON-RPC 2.0 请求
@@ -133,6 +188,11 @@ type RPCError struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+// Error 实现 error 接口
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("a2a error %d: %s", e.Code, e.Message)
+}
+
 // 标准 JSON-RPC 错误码
 const (
 	ErrorCodeParseError     = -32700
@@ -154,16 +214,18 @@ const (
 
 // MessageSendParams message/send 方法参数
 type MessageSendParams struct {
-	Message   Message  `json:"message"`
-	ContextID string   `json:"contextId,omitempty"`
-	Metadata  Metadata `json:"metadata,omitempty"`
+	Message       Message                   `json:"message"`
+	ContextID     string                    `json:"contextId,omitempty"`
+	Metadata      Metadata                  `json:"metadata,omitempty"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
 }
 
 // MessageStreamParams message/stream 方法参数
 type MessageStreamParams struct {
-	Message   Message  `json:"message"`
-	ContextID string   `json:"contextId,omitempty"`
-	Metadata  Metadata `json:"metadata,omitempty"`
+	Message       Message                   `json:"message"`
+	ContextID     string                    `json:"contextId,omitempty"`
+	Metadata      Metadata                  `json:"metadata,omitempty"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
 }
 
 // TasksGetParams tasks/get 方法参数
@@ -176,6 +238,11 @@ type TasksCancelParams struct {
 	TaskID string `json:"taskId"`
 }
 
+// PushNotificationConfigGetParams tasks/pushNotificationConfig/get 方法参数
+type PushNotificationConfigGetParams struct {
+	TaskID string `json:"taskId"`
+}
+
 // ============== 方法返回结果 ==============
 
 // MessageSendResult message/send 方法返回结果
@@ -314,9 +314,10 @@ func (a *AgentActor) handleChat(ctx *actor.Context, msg *ChatMsg) {
 // handleStream 处理流式对话
 func (a *AgentActor) handleStream(_ *actor.Context, msg *StreamMsg) {
 	execCtx, cancel := a.withCancellation(msg.Ctx)
-	defer cancel()
 
 	go func() {
+		// 流在后台读取，结束后才能取消 context
+		defer cancel()
 		reader := a.agent.Stream(execCtx, msg.Text)
 
 		for {
@@ -349,7 +350,8 @@ func (a *AgentActor) handleToolCall(ctx *actor.Context, msg *ToolCallMsg) {
 	defer cancel()
 
 	go func() {
-		result := a.agent.executeSingleTool(execCtx, msg.ToolUse)
+		// 单个工具调用不属于运行，不可恢复的错误同样通过 IsError 结果返回
+		result, _ := a.agent.executeSingleTool(execCtx, msg.ToolUse)
 
 		var response *ToolResultMsg
 
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/astercloud/aster/pkg/commands"
@@ -61,6 +62,7 @@ type Agent struct {
 	state               types.AgentRuntimeState
 	breakpoint          types.BreakpointState
 	messages            []types.Message
+	savedMessages       int // messages 中已持久化的前缀长度，-1 表示需要整体重写
 	toolRecords         map[string]*types.ToolCallRecord
 	runningTools        map[string]*runningToolHandle
 	stepCount           int
@@ -70,6 +72,20 @@ type Agent struct {
 	lastSfpIndex        int
 	lastBookmark        *types.Bookmark
 	createdAt           time.Time
+	clock               tools.Clock      // 时钟（Dependencies.Clock 或系统时钟）
+	usage               types.TokenUsage // 累计 Token 使用量
+	runUsage            types.TokenUsage // 当前运行累计的 Token 使用量
+	runModelCalls       int              // 当前运行上报了用量的模型调用次数
+	lastErr             error            // 最近一次处理失败的错误（供 Chat 返回）
+	turnRetries         int              // 当前轮次已进行的整轮重试次数
+	retryNudge          string           // 下一次模型调用需追加的重试提示
+	runID               string           // 当前运行 ID，用于生成工具调用 ID
+	toolCallSeq         int              // 当前运行内的工具调用序号
+	pendingInput        bool             // 运行期间收到了新的用户输入，运行结束后需重新处理
+
+	// 会话标题在后台生成
+	titleRunning atomic.Bool
+	titleWG      sync.WaitGroup
 
 	// 权限管理
 	pendingPermissions  map[string]chan string        // callID -> decision channel
@@ -78,6 +94,9 @@ type Agent struct {
 	// Plan 模式管理
 	planMode *PlanModeManager
 
+	// 运行时工具策略
+	toolPolicy *ToolPolicy
+
 	// 执行计划管理
 	executionPlanMgr *ExecutionPlanManager
 
@@ -184,10 +203,17 @@ func Create(ctx context.Context, config *types.AgentConfig, deps *Dependencies)
 	}
 
 	// 创建工具执行器
-	executor := tools.NewExecutor(tools.ExecutorConfig{
+	executorConfig := tools.ExecutorConfig{
 		MaxConcurrency: 3,
 		DefaultTimeout: 60 * time.Second,
-	})
+		ValidateInputs: config.ValidateToolInputs,
+	}
+	if limits := config.ToolRateLimits; limits != nil {
+		executorConfig.RateLimit = limits.Default
+		executorConfig.ToolRateLimits = limits.Tools
+		executorConfig.RateLimitMaxWait = time.Duration(limits.MaxWaitMs) * time.Millisecond
+	}
+	executor := tools.NewExecutor(executorConfig)
 
 	// 解析工具列表
 	toolNames := config.Tools
@@ -431,6 +457,11 @@ func Create(ctx context.Context, config *types.AgentConfig, deps *Dependencies)
 		}
 	}
 
+	clock := deps.Clock
+	if clock == nil {
+		clock = tools.SystemClock
+	}
+
 	// 创建Agent
 	agent := &Agent{
 		id:                  config.AgentID,
@@ -454,10 +485,17 @@ func Create(ctx context.Context, config *types.AgentConfig, deps *Dependencies)
 		pendingPermissions:  make(map[string]chan string),
 		planMode:            NewPlanModeManager(),
 		maxIterations:       50, // 默认最大迭代50次
-		createdAt:           time.Now(),
+		createdAt:           clock.Now(),
+		clock:               clock,
 		stopCh:              make(chan struct{}),
 		iterationContinueCh: make(chan bool, 1),
 	}
+	agent.planMode.onProposed = agent.onPlanProposed
+
+	// 工具事件会进入时间线和追踪，发出前掩码其中的沙箱密钥
+	if masker, ok := sb.(sandbox.SecretMasker); ok {
+		agent.eventBus.SetRedactor(func(event any) any { return redactToolEvent(masker, event) })
+	}
 
 	// 初始化 EnhancedInspector (Claude SDK 风格的权限检查器)
 	permMode := permission.ModeSmartApprove
@@ -508,6 +546,12 @@ func (a *Agent) initialize(ctx context.Context) error {
 				_ = a.deps.Store.SaveMessages(ctx, a.id, messages)
 			}
 		}
+		// 兼容旧版本持久化的消息：补充消息 ID，下次保存时写回
+		a.savedMessages = len(messages)
+		if slices.ContainsFunc(messages, func(msg types.Message) bool { return msg.ID == "" }) {
+			assignMessageIDs(messages)
+			a.savedMessages = -1
+		}
 		a.messages = messages
 	}
 
@@ -518,6 +562,9 @@ func (a *Agent) initialize(ctx context.Context) error {
 		}
 	}
 
+	// 恢复 Plan 模式计划（未审批的计划重启后继续等待审批）
+	a.loadPlan(ctx)
+
 	// 注意：工具手册已在 Agent 创建时注入，这里不再重复注入
 
 	// 保存Agent信息
@@ -529,6 +576,10 @@ func (a *Agent) initialize(ctx context.Context) error {
 		ConfigVersion: "v1.0.0",
 		MessageCount:  len(a.messages),
 	}
+	// 保留已有元数据（如缓存的会话标题）
+	if existing, err := a.deps.Store.LoadInfo(ctx, a.id); err == nil && existing != nil {
+		info.Metadata = existing.Metadata
+	}
 
 	if err := a.deps.Store.SaveInfo(ctx, a.id, info); err != nil {
 		return err
@@ -905,6 +956,7 @@ func (a *Agent) ExecutionPlan() *ExecutionPlanManager {
 func (a *Agent) Send(ctx context.Context, text string) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.lastErr = nil
 
 	// 检测 slash command（只有当 commandExecutor 已初始化时才处理）
 	if a.commandExecutor != nil && strings.HasPrefix(text, "/") && a.commandExecutor.IsSlashCommand(text) {
@@ -945,7 +997,7 @@ func (a *Agent) Send(ctx context.Context, text string) error {
 		},
 	}
 
-	a.messages = append(a.messages, message)
+	a.appendMessages(message)
 
 	// ✅ 修复：保存前在内存中修剪
 	if a.shouldTrimMessages() {
@@ -959,8 +1011,8 @@ func (a *Agent) Send(ctx context.Context, text string) error {
 
 	a.stepCount++
 
-	// 持久化（已修剪的消息）
-	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
+	// 持久化（仅追加新消息）
+	if err := a.persistMessages(ctx); err != nil {
 		return fmt.Errorf("save messages: %w", err)
 	}
 
@@ -972,8 +1024,13 @@ func (a *Agent) Send(ctx context.Context, text string) error {
 
 // SendWithContent 发送多模态消息
 func (a *Agent) SendWithContent(ctx context.Context, blocks []types.ContentBlock) error {
+	if err := a.checkContentSupport(blocks); err != nil {
+		return err
+	}
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.lastErr = nil
 
 	// 创建用户消息
 	message := types.Message{
@@ -981,7 +1038,7 @@ func (a *Agent) SendWithContent(ctx context.Context, blocks []types.ContentBlock
 		ContentBlocks: blocks,
 	}
 
-	a.messages = append(a.messages, message)
+	a.appendMessages(message)
 
 	// ✅ 修复：保存前在内存中修剪
 	if a.shouldTrimMessages() {
@@ -995,8 +1052,8 @@ func (a *Agent) SendWithContent(ctx context.Context, blocks []types.ContentBlock
 
 	a.stepCount++
 
-	// 持久化（已修剪的消息）
-	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
+	// 持久化（仅追加新消息）
+	if err := a.persistMessages(ctx); err != nil {
 		return fmt.Errorf("save multimodal messages: %w", err)
 	}
 
@@ -1006,7 +1063,27 @@ func (a *Agent) SendWithContent(ctx context.Context, blocks []types.ContentBlock
 	return nil
 }
 
+// SendWithAttachments 发送带附件（如图片）的文本消息
+func (a *Agent) SendWithAttachments(ctx context.Context, text string, attachments ...types.ContentBlock) error {
+	blocks := make([]types.ContentBlock, 0, len(attachments)+1)
+	blocks = append(blocks, &types.TextBlock{Text: text})
+	blocks = append(blocks, attachments...)
+	return a.SendWithContent(ctx, blocks)
+}
+
+// checkContentSupport 检查当前 Provider 是否支持消息中的多模态内容
+func (a *Agent) checkContentSupport(blocks []types.ContentBlock) error {
+	for _, block := range blocks {
+		if _, ok := block.(*types.ImageContent); ok && !a.provider.Capabilities().SupportVision {
+			return newChatError(ErrUnsupportedInput, "model",
+				fmt.Errorf("provider %s does not support image input", a.provider.Config().Provider))
+		}
+	}
+	return nil
+}
+
 // Chat 同步对话(阻塞式)
+// 处理失败时返回 *ChatError，可通过 errors.Is(err, ErrProviderAuth) 等判断失败类型
 func (a *Agent) Chat(ctx context.Context, text string) (*types.CompleteResult, error) {
 	// 发送消息
 	if err := a.Send(ctx, text); err != nil {
@@ -1016,6 +1093,16 @@ func (a *Agent) Chat(ctx context.Context, text string) (*types.CompleteResult, e
 	return a.waitForCompletion(ctx)
 }
 
+// ChatWithAttachments 同步发送带附件（如图片）的文本消息
+// Provider 不支持附件类型时返回 ErrUnsupportedInput
+func (a *Agent) ChatWithAttachments(ctx context.Context, text string, attachments ...types.ContentBlock) (*types.CompleteResult, error) {
+	if err := a.SendWithAttachments(ctx, text, attachments...); err != nil {
+		return nil, err
+	}
+
+	return a.waitForCompletion(ctx)
+}
+
 // ChatWithContent 同步对话(阻塞式)，支持多模态内容
 func (a *Agent) ChatWithContent(ctx context.Context, blocks []types.ContentBlock) (*types.CompleteResult, error) {
 	// 发送多模态消息
@@ -1032,8 +1119,14 @@ func (a *Agent) waitForCompletion(ctx context.Context) (*types.CompleteResult, e
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, newChatError(ErrCancelled, "run", ctx.Err())
 		case <-time.After(100 * time.Millisecond):
+			// 调用方取消时处理流程可能已结束并记录了内部阶段的取消错误，
+			// 先检查 ctx，保证取消总是报告为 "run" 阶段
+			if err := ctx.Err(); err != nil {
+				return nil, newChatError(ErrCancelled, "run", err)
+			}
+
 			a.mu.RLock()
 			state := a.state
 			a.mu.RUnlock()
@@ -1043,6 +1136,10 @@ func (a *Agent) waitForCompletion(ctx context.Context) (*types.CompleteResult, e
 				a.mu.RLock()
 				defer a.mu.RUnlock()
 
+				if a.lastErr != nil {
+					return nil, a.lastErr
+				}
+
 				var text string
 				for i := len(a.messages) - 1; i >= 0; i-- {
 					if a.messages[i].Role == types.MessageRoleAssistant {
@@ -1056,10 +1153,12 @@ func (a *Agent) waitForCompletion(ctx context.Context) (*types.CompleteResult, e
 					}
 				}
 
+				usage := a.runUsage
 				return &types.CompleteResult{
 					Status: "ok",
 					Text:   text,
 					Last:   a.lastBookmark,
+					Usage:  &usage,
 				}, nil
 			}
 		}
@@ -1097,6 +1196,16 @@ func (a *Agent) Status() *types.AgentStatus {
 	}
 }
 
+// Messages 返回当前消息历史的副本
+func (a *Agent) Messages() []types.Message {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	messages := make([]types.Message, len(a.messages))
+	copy(messages, a.messages)
+	return messages
+}
+
 // GetSystemPrompt 获取当前的 System Prompt
 func (a *Agent) GetSystemPrompt() string {
 	a.mu.RLock()
@@ -1105,6 +1214,18 @@ func (a *Agent) GetSystemPrompt() string {
 	return a.template.SystemPrompt
 }
 
+// Config 获取创建 Agent 时使用的配置（浅拷贝，不应修改其中的 map 和切片）
+func (a *Agent) Config() *types.AgentConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.config == nil {
+		return &types.AgentConfig{AgentID: a.id, TemplateID: a.template.ID}
+	}
+	config := *a.config
+	return &config
+}
+
 // ExecuteToolDirect 直接执行工具（程序化工具调用）
 // 这个方法允许 Agent 或外部代码直接调用工具，绕过 LLM 决策
 // 主要用于程序化工具编排场景
@@ -1117,6 +1238,10 @@ func (a *Agent) ExecuteToolDirect(ctx context.Context, toolName string, input ma
 		return nil, fmt.Errorf("tool not found: %s", toolName)
 	}
 
+	if allowed, reason := a.checkToolPolicy(ctx, toolName, input); !allowed {
+		return nil, fmt.Errorf("tool not permitted: %s (%s)", toolName, reason)
+	}
+
 	// 构建工具上下文
 	tc := a.buildToolContext(ctx)
 
@@ -1208,6 +1333,8 @@ func (a *Agent) RespondToIterationLimit(continueExecution bool) {
 // Close 关闭Agent
 func (a *Agent) Close() error {
 	close(a.stopCh)
+	// 等待后台的标题生成退出，stopCh 关闭后会立即取消
+	a.titleWG.Wait()
 
 	// 通知 Middleware Agent 停止 (Phase 6C)
 	if a.middlewareStack != nil {
@@ -1235,6 +1362,7 @@ func (a *Agent) buildToolContext(ctx context.Context) *tools.ToolContext {
 		Sandbox:  a.sandbox,
 		Signal:   ctx,
 		Services: make(map[string]any),
+		Clock:    a.clock,
 	}
 
 	// 为 ToolHelp 等工具注入当前可用工具的手册信息, 支持按需查询。
@@ -1309,11 +1437,11 @@ func (a *Agent) handleSlashCommand(ctx context.Context, text string) error {
 		},
 	}
 
-	a.messages = append(a.messages, userMessage)
+	a.appendMessages(userMessage)
 	a.stepCount++
 
 	// 持久化
-	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
+	if err := a.persistMessages(ctx); err != nil {
 		return fmt.Errorf("save messages: %w", err)
 	}
 
@@ -1586,6 +1714,25 @@ func generateAgentID() string {
 	return "agt-" + uuid.New().String()
 }
 
+// newRunID 生成运行 ID
+func newRunID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
+
+// nextToolCallID 生成当前运行内单调递增的工具调用 ID
+// 格式为 run-{runID}-tc-{n}（n 从 1 开始），同一 ID 贯穿工具事件、
+// ToolCallRecord.ID 和 ToolContext.CallID；模型返回的原始 ID 保存在 ToolCallRecord.ToolUseID。
+func (a *Agent) nextToolCallID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.runID == "" {
+		a.runID = newRunID()
+	}
+	a.toolCallSeq++
+	return fmt.Sprintf("run-%s-tc-%d", a.runID, a.toolCallSeq)
+}
+
 // getExecutionMode 获取执行模式
 func (a *Agent) getExecutionMode() types.ExecutionMode {
 	if a.config != nil && a.config.ModelConfig != nil && a.config.ModelConfig.ExecutionMode != "" {
@@ -1594,6 +1741,14 @@ func (a *Agent) getExecutionMode() types.ExecutionMode {
 	return types.ExecutionModeStreaming // 默认流式（向后兼容）
 }
 
+// streamCoalesceWindow 获取流式文本增量合并窗口
+func (a *Agent) streamCoalesceWindow() time.Duration {
+	if a.config != nil && a.config.ModelConfig != nil {
+		return a.config.ModelConfig.StreamCoalesceWindow
+	}
+	return 0
+}
+
 // inferProviderFromModel 从模型名称推断 provider
 func inferProviderFromModel(model string) string {
 	model = strings.ToLower(model)
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// ErrMessageNotFound 指定 ID 的消息不存在
+var ErrMessageNotFound = errors.New("message not found")
+
+// AnnotateMessage 为消息设置注解并持久化，value 为 nil 时删除该注解
+func (a *Agent) AnnotateMessage(ctx context.Context, msgID, key string, value any) error {
+	if key == "" {
+		return errors.New("annotation key is required")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	idx := a.messageIndex(msgID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, msgID)
+	}
+
+	// 写时复制，避免影响 Messages/Export 已返回的副本
+	annotations := maps.Clone(a.messages[idx].Annotations)
+	if annotations == nil {
+		annotations = make(map[string]any)
+	}
+	if value == nil {
+		delete(annotations, key)
+	} else {
+		annotations[key] = value
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	a.messages[idx].Annotations = annotations
+
+	if err := a.saveAllMessages(ctx); err != nil {
+		return fmt.Errorf("save annotations: %w", err)
+	}
+	return nil
+}
+
+// MessageAnnotations 返回消息注解的副本
+func (a *Agent) MessageAnnotations(msgID string) (map[string]any, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	idx := a.messageIndex(msgID)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, msgID)
+	}
+	return maps.Clone(a.messages[idx].Annotations), nil
+}
+
+// messageIndex 查找消息下标，调用方需持有锁
+func (a *Agent) messageIndex(msgID string) int {
+	if msgID == "" {
+		return -1
+	}
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].ID == msgID {
+			return i
+		}
+	}
+	return -1
+}
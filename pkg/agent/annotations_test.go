@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_MessageAnnotationsPersist(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDeps(t)
+	config := &types.AgentConfig{
+		AgentID:    "annotated-agent",
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}
+
+	ag, err := Create(ctx, config, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ag.provider = &MockProvider{name: "mock"}
+	if _, err := ag.Chat(ctx, "hello"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	messages := ag.Messages()
+	if len(messages) < 2 {
+		t.Fatalf("expected user and assistant messages, got %d", len(messages))
+	}
+	reply := messages[len(messages)-1]
+	if reply.ID == "" || messages[0].ID == "" || reply.ID == messages[0].ID {
+		t.Fatalf("messages should get unique ids, got %q and %q", messages[0].ID, reply.ID)
+	}
+	if err := ag.AnnotateMessage(ctx, reply.ID, "rating", "👍"); err != nil {
+		t.Fatalf("annotate: %v", err)
+	}
+	if err := ag.AnnotateMessage(ctx, "msg-missing", "rating", "👎"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("expected ErrMessageNotFound, got %v", err)
+	}
+	if messages[len(messages)-1].Annotations != nil {
+		t.Error("annotating should not mutate previously returned copies")
+	}
+	_ = ag.Close()
+
+	// 重新加载后注解仍然存在，且包含在导出快照中
+	reloaded, err := Create(ctx, config, deps)
+	if err != nil {
+		t.Fatalf("Failed to reload agent: %v", err)
+	}
+	defer func() { _ = reloaded.Close() }()
+
+	annotations, err := reloaded.MessageAnnotations(reply.ID)
+	if err != nil {
+		t.Fatalf("load annotations: %v", err)
+	}
+	if annotations["rating"] != "👍" {
+		t.Errorf("expected persisted rating annotation, got %v", annotations)
+	}
+
+	snapshot, err := reloaded.Export()
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	exported := snapshot.Messages[len(snapshot.Messages)-1]
+	if exported.ID != reply.ID || exported.Annotations["rating"] != "👍" {
+		t.Errorf("export should include message annotations, got %+v", exported)
+	}
+}
+
+func TestAgent_MessagesAppendedIncrementally(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDeps(t)
+	config := &types.AgentConfig{
+		AgentID:    "append-agent",
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+		Store: &types.StoreConfig{MaxMessages: 3, AutoTrim: true},
+	}
+
+	ag, err := Create(ctx, config, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ag.provider = &MockProvider{name: "mock"}
+	for _, text := range []string{"first", "second"} {
+		if _, err := ag.Chat(ctx, text); err != nil {
+			t.Fatalf("chat: %v", err)
+		}
+	}
+	expected := ag.Messages()
+	_ = ag.Close()
+
+	// Store 中的历史与内存一致（追加后同步修剪）
+	stored, err := deps.Store.LoadMessages(ctx, config.AgentID)
+	if err != nil {
+		t.Fatalf("load messages: %v", err)
+	}
+	if len(stored) != len(expected) || len(stored) != 3 {
+		t.Fatalf("expected %d stored messages, got %d", len(expected), len(stored))
+	}
+	for i := range expected {
+		if stored[i].ID != expected[i].ID {
+			t.Errorf("message %d: expected id %q, got %q", i, expected[i].ID, stored[i].ID)
+		}
+	}
+}
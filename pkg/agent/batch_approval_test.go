@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/middleware"
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestChat_BatchApprovalPartiallyApproved(t *testing.T) {
+	var calls atomic.Int32
+	var toolResults []*types.ToolResultBlock
+	mock := &MockProvider{
+		name: "mock",
+		completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+			if calls.Add(1) == 1 {
+				return &provider.CompleteResponse{Message: types.Message{
+					Role: types.MessageRoleAssistant,
+					ContentBlocks: []types.ContentBlock{
+						&types.ToolUseBlock{ID: "call-a", Name: "Write", Input: map[string]any{"file_path": "/tmp/test/a.txt", "content": "a"}},
+						&types.ToolUseBlock{ID: "call-b", Name: "Write", Input: map[string]any{"file_path": "/tmp/test/b.txt", "content": "b"}},
+					},
+				}}, nil
+			}
+			for _, block := range messages[len(messages)-1].ContentBlocks {
+				if result, ok := block.(*types.ToolResultBlock); ok {
+					toolResults = append(toolResults, result)
+				}
+			}
+			return &provider.CompleteResponse{Message: types.Message{
+				Role:          types.MessageRoleAssistant,
+				ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "done"}},
+			}}, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+
+	var reviews int
+	hitl, err := middleware.NewHumanInTheLoopMiddleware(&middleware.HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Write": true},
+		ApprovalHandler: func(ctx context.Context, request *middleware.ReviewRequest) ([]middleware.Decision, error) {
+			reviews++
+			return []middleware.Decision{
+				{Type: middleware.DecisionApprove},
+				{Type: middleware.DecisionReject, Reason: "not allowed"},
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ag.middlewareStack = middleware.NewStack([]middleware.Middleware{hitl})
+
+	result, err := ag.Chat(context.Background(), "write two files")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if result.Text != "done" || reviews != 1 {
+		t.Fatalf("expected a single batch review, got %d reviews, text %q", reviews, result.Text)
+	}
+	if len(toolResults) != 2 {
+		t.Fatalf("expected 2 tool results, got %d", len(toolResults))
+	}
+	if strings.Contains(toolResults[0].Content, "rejected") {
+		t.Errorf("approved tool should execute, got %s", toolResults[0].Content)
+	}
+	if !toolResults[1].IsError || !strings.Contains(toolResults[1].Content, "not allowed") {
+		t.Errorf("rejected tool should return an error result, got %+v", toolResults[1])
+	}
+}
+
+func TestChat_BatchApprovalSkipsBlockedTools(t *testing.T) {
+	var calls atomic.Int32
+	mock := &MockProvider{
+		name: "mock",
+		completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+			if calls.Add(1) == 1 {
+				return &provider.CompleteResponse{Message: types.Message{
+					Role: types.MessageRoleAssistant,
+					ContentBlocks: []types.ContentBlock{
+						&types.ToolUseBlock{ID: "call_0", Name: "Write", Input: map[string]any{"file_path": "/tmp/test/a.txt", "content": "a"}},
+						&types.ToolUseBlock{ID: "call_1", Name: "Write", Input: map[string]any{"file_path": "/etc/passwd", "content": "b"}},
+						&types.ToolUseBlock{ID: "call_2", Name: "Write", Input: map[string]any{"file_path": "/tmp/test/c.txt", "content": "c"}},
+					},
+				}}, nil
+			}
+			return &provider.CompleteResponse{Message: types.Message{
+				Role:          types.MessageRoleAssistant,
+				ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "done"}},
+			}}, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	ag.SetToolPolicy(NewToolPolicy().WithPredicate("Write", func(ctx context.Context, toolName string, input map[string]any) (bool, string) {
+		path, _ := input["file_path"].(string)
+		return strings.HasPrefix(path, "/tmp/"), "outside workspace"
+	}))
+
+	var reviewed []string
+	hitl, err := middleware.NewHumanInTheLoopMiddleware(&middleware.HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Write": true},
+		ApprovalHandler: func(ctx context.Context, request *middleware.ReviewRequest) ([]middleware.Decision, error) {
+			for _, action := range request.ActionRequests {
+				reviewed = append(reviewed, action.Input["file_path"].(string))
+			}
+			return []middleware.Decision{{Type: middleware.DecisionApprove}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ag.middlewareStack = middleware.NewStack([]middleware.Middleware{hitl})
+
+	if _, err := ag.Chat(context.Background(), "write three files"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if len(reviewed) != 2 || slices.Contains(reviewed, "/etc/passwd") {
+		t.Errorf("blocked tool should not be batch reviewed, got %v", reviewed)
+	}
+
+	// 这批结束后不残留决策：复用 ID 的调用重新审核
+	reviewed = nil
+	resp, err := ag.middlewareStack.ExecuteToolCall(context.Background(), &middleware.ToolCallRequest{
+		ToolUseID: "call_1", ToolName: "Write", ToolInput: map[string]any{"file_path": "/etc/passwd", "content": "b"},
+	}, func(ctx context.Context, req *middleware.ToolCallRequest) (*middleware.ToolCallResponse, error) {
+		return &middleware.ToolCallResponse{Result: "ok"}, nil
+	})
+	if err != nil || resp == nil || len(reviewed) != 1 {
+		t.Errorf("expected the reused tool_use ID to be reviewed again, got %d reviews (%v)", len(reviewed), err)
+	}
+}
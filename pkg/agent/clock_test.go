@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_InjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	deps := setupTestDeps(t)
+	deps.Clock = tools.FixedClock{Time: fixed}
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		TemplateID: "test-template",
+		Tools:      []string{"DateTime"},
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	defer func() { _ = ag.Close() }()
+
+	if prompt := ag.GetSystemPrompt(); !strings.Contains(prompt, "Date: 2024-03-15") {
+		t.Errorf("environment info should use injected clock, got prompt:\n%s", prompt)
+	}
+
+	tool, ok := ag.toolMap["DateTime"]
+	if !ok {
+		t.Fatal("DateTime tool not loaded")
+	}
+	result, err := tool.Execute(context.Background(), map[string]any{}, ag.buildToolContext(context.Background()))
+	if err != nil {
+		t.Fatalf("DateTime failed: %v", err)
+	}
+	if got := result.(map[string]any)["datetime"]; got != "2024-03-15T09:00:00Z" {
+		t.Errorf("DateTime should report injected time, got %v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_StreamCoalescing(t *testing.T) {
+	var want strings.Builder
+	mock := &MockProvider{name: "mock", streamFunc: func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		ch := make(chan provider.StreamChunk, 101)
+		for i := range 100 {
+			ch <- provider.StreamChunk{Type: string(provider.ChunkTypeText), TextDelta: string(rune('a' + i%26))}
+		}
+		ch <- provider.StreamChunk{Type: string(provider.ChunkTypeDone), FinishReason: "stop"}
+		close(ch)
+		return ch, nil
+	}}
+	for i := range 100 {
+		want.WriteRune(rune('a' + i%26))
+	}
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider:             "anthropic",
+			Model:                "claude-sonnet-4-5",
+			APIKey:               "test-key",
+			StreamCoalesceWindow: 50 * time.Millisecond,
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}, setupTestDeps(t))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	defer func() { _ = ag.Close() }()
+	ag.provider = mock
+
+	progress := ag.Subscribe([]types.AgentChannel{types.ChannelProgress}, nil)
+	result, err := ag.Chat(context.Background(), "write the alphabet")
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if result.Text != want.String() {
+		t.Errorf("assembled text differs:\n got %q\nwant %q", result.Text, want.String())
+	}
+
+	var chunkEvents int
+	var streamed strings.Builder
+	timeout := time.After(time.Second)
+	for streamed.Len() < want.Len() {
+		select {
+		case env := <-progress:
+			if chunk, ok := env.Event.(*types.ProgressTextChunkEvent); ok {
+				chunkEvents++
+				streamed.WriteString(chunk.Delta)
+			}
+		case <-timeout:
+			t.Fatalf("missing text chunk events, streamed %q", streamed.String())
+		}
+	}
+	if streamed.String() != want.String() {
+		t.Errorf("streamed deltas differ from assembled text: %q", streamed.String())
+	}
+	if chunkEvents > 10 {
+		t.Errorf("expected coalescing to produce far fewer than 100 events, got %d", chunkEvents)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/router"
 	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/session"
 	"github.com/astercloud/aster/pkg/store"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/types"
@@ -33,6 +34,14 @@ type Dependencies struct {
 
 	// EmbedderFactory 嵌入模型工厂（用于 RAG 和语义记忆）
 	EmbedderFactory *factory.EmbedderFactory
+
+	// Clock 可选的时钟，用于环境信息和 DateTime 等工具（nil 使用系统时钟）
+	// 测试中可注入 tools.FixedClock 保证时间相关行为确定
+	Clock tools.Clock
+
+	// TitleGenerator 可选的会话标题生成器
+	// 配置后每轮对话结束时在后台按需更新标题，缓存在 AgentInfo.Metadata 中
+	TitleGenerator *session.TitleGenerator
 }
 
 // TemplateRegistry 模板注册表
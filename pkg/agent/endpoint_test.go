@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// newFailoverTestProvider 第一个端点不可用，第二个端点正常返回
+func newFailoverTestProvider(t *testing.T) *provider.FailoverProvider {
+	t.Helper()
+
+	create := func(config *types.ModelConfig) (provider.Provider, error) {
+		down := config.BaseURL == "https://primary.example.com"
+		return &MockProvider{
+			name: config.BaseURL,
+			streamFunc: func(ctx context.Context, _ []types.Message, _ *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+				if down {
+					return nil, errors.New("API error: 503 - unavailable")
+				}
+				ch := make(chan provider.StreamChunk, 1)
+				ch <- provider.StreamChunk{Type: "text", TextDelta: "ok"}
+				close(ch)
+				return ch, nil
+			},
+			completeFunc: func(ctx context.Context, _ []types.Message, _ *provider.StreamOptions) (*provider.CompleteResponse, error) {
+				if down {
+					return nil, errors.New("API error: 503 - unavailable")
+				}
+				return &provider.CompleteResponse{Message: types.Message{
+					Role:          types.MessageRoleAssistant,
+					ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "ok"}},
+				}}, nil
+			},
+		}, nil
+	}
+
+	failover, err := provider.NewFailoverProvider(
+		&types.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5"},
+		[]string{"https://primary.example.com", "https://backup.example.com"},
+		0, create,
+	)
+	if err != nil {
+		t.Fatalf("create failover provider: %v", err)
+	}
+	return failover
+}
+
+func TestChat_EmitsModelEndpoint(t *testing.T) {
+	for _, mode := range []types.ExecutionMode{types.ExecutionModeStreaming, types.ExecutionModeNonStreaming} {
+		t.Run(string(mode), func(t *testing.T) {
+			ag := newChatErrorTestAgent(t, mode, &MockProvider{name: "mock"}, false)
+			ag.provider = newFailoverTestProvider(t)
+
+			events := ag.Subscribe([]types.AgentChannel{types.ChannelMonitor}, nil)
+			defer ag.Unsubscribe(events)
+
+			if _, err := ag.Chat(context.Background(), "hello"); err != nil {
+				t.Fatalf("Chat failed: %v", err)
+			}
+
+			var endpoints []string
+			for len(events) > 0 {
+				env := <-events
+				if evt, ok := env.Event.(*types.MonitorModelEndpointEvent); ok {
+					endpoints = append(endpoints, evt.Endpoint)
+				}
+			}
+			if len(endpoints) != 1 || endpoints[0] != "https://backup.example.com" {
+				t.Errorf("expected one model_endpoint event for the backup endpoint, got %v", endpoints)
+			}
+		})
+	}
+}
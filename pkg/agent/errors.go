@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/guardrails"
+	"github.com/astercloud/aster/pkg/provider"
+)
+
+// Chat 失败类型，通过 errors.Is 判断；需要原因和阶段时通过 errors.As 获取 *ChatError
+var (
+	ErrProviderAuth     = errors.New("provider authentication failed")
+	ErrRateLimited      = errors.New("provider rate limited")
+	ErrGuardrailBlocked = errors.New("blocked by guardrail")
+	ErrContextExceeded  = errors.New("context window exceeded")
+	ErrToolFailed       = errors.New("tool execution failed") // 工具返回了 tools.ErrFatal
+	ErrRunLimit         = errors.New("run limit reached")
+	ErrCancelled        = errors.New("run canceled")
+	ErrUnsupportedInput = errors.New("input not supported by provider")
+)
+
+// ChatError Chat/Send 处理失败的结构化错误
+// Kind 为上面的失败类型之一，Cause 为底层原因；两者都可以通过 errors.Is 匹配。
+type ChatError struct {
+	Kind  error
+	Phase string // "model" | "tool" | "run"
+	Cause error
+}
+
+func (e *ChatError) Error() string {
+	if e.Cause == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Cause.Error()
+}
+
+// Unwrap 同时暴露失败类型和底层原因
+func (e *ChatError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// newChatError 创建结构化错误，err 已是 ChatError 时原样返回
+func newChatError(kind error, phase string, err error) error {
+	var chatErr *ChatError
+	if errors.As(err, &chatErr) {
+		return err
+	}
+	return &ChatError{Kind: kind, Phase: phase, Cause: err}
+}
+
+// classifyModelError 将模型调用错误归类为结构化错误，无法识别时原样返回
+func classifyModelError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var chatErr *ChatError
+	if errors.As(err, &chatErr) {
+		return err
+	}
+
+	var guardErr *guardrails.GuardrailError
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return newChatError(ErrCancelled, "model", err)
+	case errors.As(err, &guardErr):
+		return newChatError(ErrGuardrailBlocked, "model", err)
+	}
+
+	switch provider.StatusCodeOf(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newChatError(ErrProviderAuth, "model", err)
+	case http.StatusTooManyRequests:
+		return newChatError(ErrRateLimited, "model", err)
+	}
+
+	if isContextExceeded(err) {
+		return newChatError(ErrContextExceeded, "model", err)
+	}
+	return err
+}
+
+// contextExceededMarkers 各 Provider 上下文超限错误中的特征文本
+var contextExceededMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context window",
+	"prompt is too long",
+	"too many tokens",
+	"input is too long",
+}
+
+// isContextExceeded 判断错误是否为上下文超限
+func isContextExceeded(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range contextExceededMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/guardrails"
+	"github.com/astercloud/aster/pkg/middleware"
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// failingRecordStore 保存工具记录时失败的 Store
+type failingRecordStore struct {
+	store.Store
+}
+
+func (s *failingRecordStore) SaveToolCallRecords(ctx context.Context, agentID string, records []types.ToolCallRecord) error {
+	return errors.New("disk full")
+}
+
+// guardrailMiddleware 拒绝所有模型调用的中间件
+type guardrailMiddleware struct {
+	*middleware.BaseMiddleware
+}
+
+func (m *guardrailMiddleware) WrapModelCall(ctx context.Context, req *middleware.ModelRequest, handler middleware.ModelCallHandler) (*middleware.ModelResponse, error) {
+	return nil, &guardrails.GuardrailError{
+		GuardrailName: "pii",
+		Trigger:       guardrails.CheckTriggerPIIDetected,
+		Message:       "PII detected in input",
+	}
+}
+
+func newChatErrorTestAgent(t *testing.T, mode types.ExecutionMode, mock *MockProvider, wrapStore bool) *Agent {
+	t.Helper()
+
+	deps := setupTestDeps(t)
+	if wrapStore {
+		deps.Store = &failingRecordStore{Store: deps.Store}
+	}
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider:      "anthropic",
+			Model:         "claude-sonnet-4-5",
+			APIKey:        "test-key",
+			ExecutionMode: mode,
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:           types.SandboxKindMock,
+			WorkDir:        "/tmp/test",
+			PermissionMode: types.SandboxPermissionBypass,
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	t.Cleanup(func() { _ = ag.Close() })
+
+	ag.provider = mock
+	return ag
+}
+
+// streamError 返回指定错误的流式调用
+func streamError(err error) func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+	return func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		return nil, err
+	}
+}
+
+// completeToolCall 总是返回工具调用的非流式响应
+func completeToolCall(context.Context, []types.Message, *provider.StreamOptions) (*provider.CompleteResponse, error) {
+	return &provider.CompleteResponse{
+		Message: types.Message{
+			Role: types.MessageRoleAssistant,
+			ContentBlocks: []types.ContentBlock{
+				&types.ToolUseBlock{ID: "call-1", Name: "missing_tool", Input: map[string]any{}},
+			},
+		},
+	}, nil
+}
+
+func assertChatError(t *testing.T, err error, kind error, phase string) {
+	t.Helper()
+
+	if !errors.Is(err, kind) {
+		t.Fatalf("expected %v, got %v", kind, err)
+	}
+	var chatErr *ChatError
+	if !errors.As(err, &chatErr) {
+		t.Fatalf("expected *ChatError, got %T", err)
+	}
+	if chatErr.Phase != phase {
+		t.Errorf("expected phase %q, got %q", phase, chatErr.Phase)
+	}
+	if chatErr.Cause == nil || !strings.Contains(err.Error(), chatErr.Cause.Error()) {
+		t.Errorf("error message should include cause, got %q", err.Error())
+	}
+}
+
+func TestChat_ProviderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"auth", errors.New("anthropic API error: 401 - invalid x-api-key"), ErrProviderAuth},
+		{"rate_limit", &provider.StatusError{Provider: "openai", StatusCode: 429, Body: "slow down"}, ErrRateLimited},
+		{"context", errors.New("anthropic API error: 400 - prompt is too long: 210000 tokens > 200000 maximum"), ErrContextExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock", streamFunc: streamError(tt.err)}, false)
+			_, err := ag.Chat(context.Background(), "hello")
+			assertChatError(t, err, tt.kind, "model")
+		})
+	}
+}
+
+func TestChat_GuardrailBlocked(t *testing.T) {
+	ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock"}, false)
+	ag.middlewareStack = middleware.NewStack([]middleware.Middleware{
+		&guardrailMiddleware{BaseMiddleware: middleware.NewBaseMiddleware("guardrail", 10)},
+	})
+
+	_, err := ag.Chat(context.Background(), "my ssn is 123-45-6789")
+	assertChatError(t, err, ErrGuardrailBlocked, "model")
+
+	var guardErr *guardrails.GuardrailError
+	if !errors.As(err, &guardErr) || guardErr.GuardrailName != "pii" {
+		t.Errorf("expected underlying GuardrailError, got %v", err)
+	}
+}
+
+// failingTool 总是返回指定错误的工具
+type failingTool struct {
+	err error
+}
+
+func (t *failingTool) Name() string                { return "Deploy" }
+func (t *failingTool) Description() string         { return "always fails" }
+func (t *failingTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (t *failingTool) Prompt() string              { return "" }
+
+func (t *failingTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	return nil, t.err
+}
+
+func TestChat_ToolFailed(t *testing.T) {
+	var calls int
+	mock := &MockProvider{name: "mock", completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+		calls++
+		if calls > 1 {
+			return &provider.CompleteResponse{Message: types.Message{
+				Role:          types.MessageRoleAssistant,
+				ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "recovered"}},
+			}}, nil
+		}
+		return &provider.CompleteResponse{Message: types.Message{
+			Role: types.MessageRoleAssistant,
+			ContentBlocks: []types.ContentBlock{
+				&types.ToolUseBlock{ID: "call-1", Name: "Deploy", Input: map[string]any{}},
+			},
+		}}, nil
+	}}
+
+	// 普通工具错误交给模型处理，Chat 不失败
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	ag.toolMap["Deploy"] = &failingTool{err: errors.New("connection reset")}
+	result, err := ag.Chat(context.Background(), "deploy")
+	if err != nil || result.Text != "recovered" {
+		t.Fatalf("recoverable tool error should go back to the model, got %v", err)
+	}
+
+	// 不可恢复的工具错误停止运行
+	calls = 0
+	ag = newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	ag.toolMap["Deploy"] = &failingTool{err: fmt.Errorf("%w: credentials revoked", tools.ErrFatal)}
+	_, err = ag.Chat(context.Background(), "deploy")
+	assertChatError(t, err, ErrToolFailed, "tool")
+	if !errors.Is(err, tools.ErrFatal) || !strings.Contains(err.Error(), "Deploy") {
+		t.Errorf("expected fatal cause naming the tool, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("run should stop after the fatal tool error, got %d model calls", calls)
+	}
+
+	// 工具结果已保存，模型下一轮能看到
+	msgs := ag.Messages()
+	last := msgs[len(msgs)-1]
+	if len(last.ContentBlocks) != 1 {
+		t.Fatalf("expected tool result to be saved, got %+v", last)
+	}
+	if res, ok := last.ContentBlocks[0].(*types.ToolResultBlock); !ok || !res.IsError {
+		t.Errorf("expected error tool result, got %+v", last.ContentBlocks[0])
+	}
+}
+
+func TestChat_ToolRecordSaveFailed(t *testing.T) {
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, &MockProvider{name: "mock", completeFunc: completeToolCall}, true)
+
+	// 存储失败不是工具失败
+	_, err := ag.Chat(context.Background(), "run a tool")
+	if err == nil || errors.Is(err, ErrToolFailed) || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected untyped store error, got %v", err)
+	}
+}
+
+func TestChat_RunLimit(t *testing.T) {
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, &MockProvider{name: "mock", completeFunc: completeToolCall}, false)
+	ag.SetMaxIterations(1)
+	ag.RespondToIterationLimit(false)
+
+	_, err := ag.Chat(context.Background(), "loop forever")
+	assertChatError(t, err, ErrRunLimit, "run")
+}
+
+func TestChat_Cancelled(t *testing.T) {
+	mock := &MockProvider{name: "mock", streamFunc: func(ctx context.Context, _ []types.Message, _ *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	ag := newChatErrorTestAgent(t, "", mock, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// 无论处理流程是否已在模型阶段记录取消错误，调用方取消都报告为 "run" 阶段
+	_, err := ag.Chat(ctx, "hello")
+	assertChatError(t, err, ErrCancelled, "run")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected underlying deadline error, got %v", err)
+	}
+}
+
+func TestChatWithAttachments_Vision(t *testing.T) {
+	image := types.NewImageFromURL("https://example.com/cat.png", "image/png")
+
+	t.Run("unsupported", func(t *testing.T) {
+		ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, &MockProvider{name: "mock"}, false)
+		_, err := ag.ChatWithAttachments(context.Background(), "describe", image)
+		assertChatError(t, err, ErrUnsupportedInput, "model")
+	})
+
+	t.Run("supported", func(t *testing.T) {
+		var sent []types.Message
+		mock := &MockProvider{
+			name:         "mock",
+			capabilities: provider.ProviderCapabilities{SupportVision: true},
+			completeFunc: func(_ context.Context, messages []types.Message, _ *provider.StreamOptions) (*provider.CompleteResponse, error) {
+				sent = messages
+				return &provider.CompleteResponse{Message: types.Message{Role: types.MessageRoleAssistant, Content: "a cat"}}, nil
+			},
+		}
+		ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+		if _, err := ag.ChatWithAttachments(context.Background(), "describe", image); err != nil {
+			t.Fatalf("chat: %v", err)
+		}
+
+		found := false
+		for _, msg := range sent {
+			for _, block := range msg.ContentBlocks {
+				if img, ok := block.(*types.ImageContent); ok && img.Source == image.Source {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Error("image should be forwarded to the provider")
+		}
+	})
+}
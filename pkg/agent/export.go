@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// AgentSnapshotVersion 当前导出格式版本
+const AgentSnapshotVersion = "1"
+
+// AgentSnapshot Agent 完整状态快照
+// 用于在不同 Store（如 JSONStore -> SQLite）或不同主机之间迁移 Agent。
+type AgentSnapshot struct {
+	Version     string                 `json:"version"`
+	ExportedAt  time.Time              `json:"exported_at"`
+	Info        types.AgentInfo        `json:"info"`
+	Config      *types.AgentConfig     `json:"config"`
+	Messages    []types.Message        `json:"messages"`
+	ToolRecords []types.ToolCallRecord `json:"tool_records"`
+	Todos       any                    `json:"todos,omitempty"`
+	Usage       types.TokenUsage       `json:"usage"`
+
+	// Tools 导出时 Agent 可用的工具名（用于导入时检查缺失工具）
+	Tools []string `json:"tools"`
+}
+
+// Export 导出 Agent 的完整状态
+func (a *Agent) Export() (*AgentSnapshot, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.config == nil {
+		return nil, errors.New("agent config is nil")
+	}
+
+	config := *a.config
+	config.CanUseTool = nil
+	config.ModelConfig = redactModelConfig(a.config.ModelConfig)
+
+	messages := make([]types.Message, len(a.messages))
+	copy(messages, a.messages)
+
+	records := make([]types.ToolCallRecord, 0, len(a.toolRecords))
+	for _, record := range a.toolRecords {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+
+	// Todos 只保存在 Store 中，读取失败（如尚未创建）时忽略
+	todos, _ := a.deps.Store.LoadTodos(context.Background(), a.id)
+
+	model := ""
+	if a.config.ModelConfig != nil {
+		model = a.config.ModelConfig.Model
+	}
+
+	return &AgentSnapshot{
+		Version:    AgentSnapshotVersion,
+		ExportedAt: time.Now(),
+		Info: types.AgentInfo{
+			ID:            a.id,
+			AgentID:       a.id,
+			TemplateID:    a.template.ID,
+			Model:         model,
+			CreatedAt:     a.createdAt,
+			UpdatedAt:     time.Now(),
+			State:         types.AgentState(a.state),
+			StepCount:     a.stepCount,
+			Cursor:        int(a.eventBus.GetCursor()),
+			MessageCount:  len(a.messages),
+			Lineage:       []string{},
+			ConfigVersion: "v1.0.0",
+			Metadata:      a.config.Metadata,
+		},
+		Config:      &config,
+		Messages:    messages,
+		ToolRecords: records,
+		Todos:       todos,
+		Usage:       a.usage,
+		Tools:       slices.Sorted(maps.Keys(a.toolMap)),
+	}, nil
+}
+
+// redactModelConfig 复制模型配置并清除密钥，快照可能离开当前主机
+func redactModelConfig(config *types.ModelConfig) *types.ModelConfig {
+	if config == nil {
+		return nil
+	}
+	redacted := *config
+	redacted.APIKey = ""
+	redacted.BaseURLs = slices.Clone(config.BaseURLs)
+	if config.Retry != nil {
+		retry := *config.Retry
+		redacted.Retry = &retry
+	}
+	return &redacted
+}
+
+// modelAPIKeyFromEnv 读取 Provider 对应的 API Key 环境变量，如 anthropic -> ANTHROPIC_API_KEY
+func modelAPIKeyFromEnv(providerName string) string {
+	if providerName == "" {
+		return ""
+	}
+	name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(providerName))
+	return os.Getenv(name + "_API_KEY")
+}
+
+// Import 将快照恢复到 deps.Store 中并返回可用的 Agent
+// 快照不包含 API Key：调用方可以在导入前设置 snapshot.Config.ModelConfig.APIKey，
+// 未设置时从 Provider 对应的环境变量（如 ANTHROPIC_API_KEY）读取。
+// 快照中存在但在当前环境不可用的工具会记录警告，不会导致导入失败。
+func Import(ctx context.Context, snapshot *AgentSnapshot, deps *Dependencies) (*Agent, error) {
+	if snapshot == nil {
+		return nil, errors.New("snapshot is nil")
+	}
+	if snapshot.Version != AgentSnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %q (expected %q)", snapshot.Version, AgentSnapshotVersion)
+	}
+	if snapshot.Config == nil {
+		return nil, errors.New("snapshot config is required")
+	}
+
+	agentID := snapshot.Info.AgentID
+	if agentID == "" {
+		agentID = snapshot.Config.AgentID
+	}
+	if agentID == "" {
+		return nil, errors.New("snapshot agent id is required")
+	}
+
+	// 先写入 Store，Create 时 initialize 会从 Store 加载
+	if err := deps.Store.SaveMessages(ctx, agentID, snapshot.Messages); err != nil {
+		return nil, fmt.Errorf("import messages: %w", err)
+	}
+	if err := deps.Store.SaveToolCallRecords(ctx, agentID, snapshot.ToolRecords); err != nil {
+		return nil, fmt.Errorf("import tool records: %w", err)
+	}
+	if snapshot.Todos != nil {
+		if err := deps.Store.SaveTodos(ctx, agentID, snapshot.Todos); err != nil {
+			return nil, fmt.Errorf("import todos: %w", err)
+		}
+	}
+
+	config := *snapshot.Config
+	config.AgentID = agentID
+	if snapshot.Config.ModelConfig != nil {
+		model := *snapshot.Config.ModelConfig
+		if model.APIKey == "" {
+			model.APIKey = modelAPIKeyFromEnv(model.Provider)
+		}
+		config.ModelConfig = &model
+	}
+
+	ag, err := Create(ctx, &config, deps)
+	if err != nil {
+		return nil, fmt.Errorf("create agent: %w", err)
+	}
+
+	ag.mu.Lock()
+	ag.stepCount = snapshot.Info.StepCount
+	ag.usage = snapshot.Usage
+	if !snapshot.Info.CreatedAt.IsZero() {
+		ag.createdAt = snapshot.Info.CreatedAt
+	}
+	messageCount := len(ag.messages)
+	missing := make([]string, 0)
+	for _, name := range snapshot.Tools {
+		if _, ok := ag.toolMap[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	ag.mu.Unlock()
+
+	if len(missing) > 0 {
+		agentLog.Warn(ctx, "imported agent is missing tools", map[string]any{
+			"agent_id": agentID,
+			"missing":  strings.Join(missing, ","),
+		})
+	}
+
+	info := snapshot.Info
+	info.ID = agentID
+	info.AgentID = agentID
+	info.UpdatedAt = time.Now()
+	info.MessageCount = messageCount
+	if err := deps.Store.SaveInfo(ctx, agentID, info); err != nil {
+		return nil, fmt.Errorf("import info: %w", err)
+	}
+
+	return ag, nil
+}
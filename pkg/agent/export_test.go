@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgentExportImport_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDeps(t)
+
+	src, err := Create(ctx, &types.AgentConfig{
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	src.mu.Lock()
+	src.messages = []types.Message{
+		{Role: types.RoleUser, Content: "hello"},
+		{Role: types.RoleAssistant, Content: "hi there"},
+	}
+	src.stepCount = 3
+	src.usage = types.TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}
+	src.mu.Unlock()
+
+	snapshot, err := src.Export()
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// 快照需要能够序列化后跨主机传输，不能带出 API Key
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	if strings.Contains(string(data), "test-key") {
+		t.Errorf("snapshot leaks the API key: %s", data)
+	}
+	if src.config.ModelConfig.APIKey != "test-key" {
+		t.Errorf("Export must not modify the agent config, got key %q", src.config.ModelConfig.APIKey)
+	}
+	var decoded AgentSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+
+	// 导入到另一个 Store，API Key 从目标环境读取
+	t.Setenv("ANTHROPIC_API_KEY", "target-key")
+	targetStore, err := store.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	targetDeps := setupTestDeps(t)
+	targetDeps.Store = targetStore
+
+	dst, err := Import(ctx, &decoded, targetDeps)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	if dst.ID() != src.ID() {
+		t.Errorf("Expected agent id %s, got %s", src.ID(), dst.ID())
+	}
+	if got := dst.Status().StepCount; got != 3 {
+		t.Errorf("Expected step count 3, got %d", got)
+	}
+	if len(dst.messages) != 2 || dst.messages[1].Content != "hi there" {
+		t.Errorf("Expected message history to be preserved, got %+v", dst.messages)
+	}
+	if dst.config.ModelConfig.APIKey != "target-key" {
+		t.Errorf("Expected API key from environment, got %q", dst.config.ModelConfig.APIKey)
+	}
+	if decoded.Config.ModelConfig.APIKey != "" {
+		t.Error("Import must not modify the snapshot")
+	}
+	if dst.usage.TotalTokens != 15 {
+		t.Errorf("Expected usage to be preserved, got %+v", dst.usage)
+	}
+
+	stored, err := targetStore.LoadMessages(ctx, src.ID())
+	if err != nil || len(stored) != 2 {
+		t.Errorf("Expected messages persisted in target store, got %d (err=%v)", len(stored), err)
+	}
+	info, err := targetStore.LoadInfo(ctx, src.ID())
+	if err != nil || info.StepCount != 3 {
+		t.Errorf("Expected info persisted with step count, got %+v (err=%v)", info, err)
+	}
+}
+
+func TestAgentImport_RejectsUnknownVersion(t *testing.T) {
+	_, err := Import(context.Background(), &AgentSnapshot{Version: "99", Config: &types.AgentConfig{AgentID: "x"}}, setupTestDeps(t))
+	if err == nil {
+		t.Fatal("Expected error for unsupported snapshot version")
+	}
+}
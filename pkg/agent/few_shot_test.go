@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/middleware"
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// recordingFewShotRetriever 记录检索时排除的会话 ID
+type recordingFewShotRetriever struct {
+	mu       sync.Mutex
+	sessions []string
+}
+
+func (r *recordingFewShotRetriever) Retrieve(ctx context.Context, query middleware.FewShotQuery) ([]middleware.FewShotExample, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = append(r.sessions, query.ExcludeSessionID)
+	return nil, nil
+}
+
+func TestAgent_FewShotReceivesSessionID(t *testing.T) {
+	mock := &MockProvider{
+		name: "mock",
+		streamFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+			ch := make(chan provider.StreamChunk, 1)
+			ch <- provider.StreamChunk{Type: "text", TextDelta: "answer"}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, "", mock, false)
+	retriever := &recordingFewShotRetriever{}
+	fewShot, err := middleware.NewFewShotMiddleware(&middleware.FewShotMiddlewareConfig{Retriever: retriever})
+	if err != nil {
+		t.Fatalf("NewFewShotMiddleware: %v", err)
+	}
+	ag.middlewareStack = middleware.NewStack([]middleware.Middleware{fewShot})
+
+	if _, err := ag.Chat(context.Background(), "hello"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, err := StreamCollect(ag.Stream(context.Background(), "again")); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	retriever.mu.Lock()
+	defer retriever.mu.Unlock()
+	if len(retriever.sessions) != 2 {
+		t.Fatalf("expected a retrieval for Chat and Stream, got %v", retriever.sessions)
+	}
+	for _, sessionID := range retriever.sessions {
+		if sessionID != ag.ID() {
+			t.Errorf("few-shot retrieval should exclude the agent session %q, got %q", ag.ID(), sessionID)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/astercloud/aster/pkg/middleware"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// historyCompaction 一次模型调用中由中间件（如 summarization）请求的历史压缩
+type historyCompaction struct {
+	keepFromID string
+	summary    []types.Message
+}
+
+// withHistoryCompactor 向请求注入压缩回调，返回记录压缩请求的指针
+// 回调只记录最后一次请求，模型调用结束后由 applyHistoryCompaction 应用
+func withHistoryCompactor(req *middleware.ModelRequest) *historyCompaction {
+	pending := &historyCompaction{}
+	req.Metadata[middleware.MetadataKeyHistoryCompactor] = middleware.HistoryCompactorFunc(func(keepFromID string, summary []types.Message) {
+		pending.keepFromID = keepFromID
+		pending.summary = summary
+	})
+	return pending
+}
+
+// applyHistoryCompaction 用摘要替换 keepFromID 之前的消息，并同步到 Store
+// 之后重启加载到的历史与发送给模型的历史一致
+func (a *Agent) applyHistoryCompaction(ctx context.Context, compaction *historyCompaction) {
+	if compaction == nil || compaction.keepFromID == "" || len(compaction.summary) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	idx := a.messageIndex(compaction.keepFromID)
+	if idx <= 0 {
+		// 保留的消息已不在历史中（如已被修剪），或前面没有可压缩的消息
+		return
+	}
+
+	summary := append([]types.Message(nil), compaction.summary...)
+	assignMessageIDs(summary)
+	compacted := append(summary, a.messages[idx:]...)
+
+	var err error
+	if a.savedMessages >= idx {
+		// 被替换的消息都已持久化，Store 侧原子替换
+		err = a.deps.Store.CompactMessages(ctx, a.id, idx, summary)
+		if err == nil {
+			a.savedMessages = a.savedMessages - idx + len(summary)
+		}
+		a.messages = compacted
+	} else {
+		a.messages = compacted
+		err = a.saveAllMessages(ctx)
+	}
+	if err != nil {
+		// 内存中已压缩，下次保存时整体重写
+		a.savedMessages = -1
+		agentLog.Warn(ctx, "failed to persist compacted history", map[string]any{"agent_id": a.id, "error": err})
+		return
+	}
+
+	agentLog.Info(ctx, "history compacted", map[string]any{"agent_id": a.id, "replaced": idx, "remaining": len(a.messages)})
+}
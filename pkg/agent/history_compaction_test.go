@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/middleware"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_HistoryCompactionPersists(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDeps(t)
+	config := &types.AgentConfig{
+		AgentID:    "compacted-agent",
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}
+
+	ag, err := Create(ctx, config, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ag.provider = &MockProvider{name: "mock"}
+	for _, text := range []string{"first", "second"} {
+		if _, err := ag.Chat(ctx, text); err != nil {
+			t.Fatalf("chat: %v", err)
+		}
+	}
+	messages := ag.Messages()
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(messages))
+	}
+
+	// 模拟 summarization 中间件在模型调用中请求压缩
+	req := &middleware.ModelRequest{Metadata: map[string]any{}}
+	compaction := withHistoryCompactor(req)
+	req.CompactHistory(messages[2].ID, []types.Message{
+		{Role: types.MessageRoleUser, Content: "summary of first turn"},
+		{Role: types.MessageRoleAssistant, Content: "understood"},
+	})
+	ag.applyHistoryCompaction(ctx, compaction)
+
+	compacted := ag.Messages()
+	if len(compacted) != 4 || compacted[0].Content != "summary of first turn" || compacted[2].ID != messages[2].ID {
+		t.Fatalf("unexpected compacted history: %+v", compacted)
+	}
+	if compacted[0].ID == "" || compacted[1].ID == "" {
+		t.Error("summary messages should get ids")
+	}
+	_ = ag.Close()
+
+	// 重启后加载压缩后的历史
+	reloaded, err := Create(ctx, config, deps)
+	if err != nil {
+		t.Fatalf("Failed to reload agent: %v", err)
+	}
+	defer func() { _ = reloaded.Close() }()
+
+	restored := reloaded.Messages()
+	if len(restored) != len(compacted) {
+		t.Fatalf("expected %d restored messages, got %d", len(compacted), len(restored))
+	}
+	for i := range compacted {
+		if restored[i].ID != compacted[i].ID {
+			t.Errorf("message %d: expected id %q, got %q", i, compacted[i].ID, restored[i].ID)
+		}
+	}
+}
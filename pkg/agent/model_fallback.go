@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/astercloud/aster/pkg/logging"
@@ -27,10 +31,28 @@ type ModelFallback struct {
 	// Priority 优先级（数字越小优先级越高）
 	Priority int
 
+	// Weight 权重，仅 FallbackSelectionWeighted 模式使用，<= 0 时按 1 计算
+	Weight int
+
 	// provider 缓存的 Provider 实例
 	provider provider.Provider
+
+	// health 后台健康检查状态
+	health ModelHealthState
 }
 
+// FallbackSelectionMode 降级管理器的模型选择模式
+type FallbackSelectionMode string
+
+const (
+	// FallbackSelectionPriority 始终优先使用优先级最高的可用模型（默认）
+	FallbackSelectionPriority FallbackSelectionMode = "priority"
+	// FallbackSelectionWeighted 按 Weight 在可用模型间随机分配请求
+	FallbackSelectionWeighted FallbackSelectionMode = "weighted_random"
+	// FallbackSelectionRoundRobin 在可用模型间轮询分配请求
+	FallbackSelectionRoundRobin FallbackSelectionMode = "round_robin"
+)
+
 // ModelFallbackManager 模型降级管理器
 type ModelFallbackManager struct {
 	// fallbacks 降级模型列表（按优先级排序）
@@ -42,8 +64,21 @@ type ModelFallbackManager struct {
 	// currentIndex 当前使用的模型索引
 	currentIndex int
 
+	// mode 模型选择模式
+	mode FallbackSelectionMode
+
+	// roundRobin 轮询计数
+	roundRobin atomic.Uint64
+
 	// stats 统计信息
 	stats *FallbackStats
+
+	// mu 保护模型启用状态、健康状态、currentIndex 和 stats（后台健康检查与请求并发访问）
+	mu sync.RWMutex
+
+	// healthCancel 停止后台健康检查
+	healthCancel context.CancelFunc
+	healthDone   chan struct{}
 }
 
 // FallbackStats 降级统计信息
@@ -54,6 +89,9 @@ type FallbackStats struct {
 	FallbackCount    int64
 	ModelUsageCount  map[string]int64
 	LastFallbackTime time.Time
+
+	// ModelHealth 各模型的健康检查状态（key 为 provider/model），未启用健康检查时为空
+	ModelHealth map[string]ModelHealthState
 }
 
 // NewModelFallbackManager 创建模型降级管理器
@@ -94,6 +132,7 @@ func NewModelFallbackManager(fallbacks []*ModelFallback, deps *Dependencies) (*M
 		fallbacks:    sortedFallbacks,
 		deps:         deps,
 		currentIndex: 0,
+		mode:         FallbackSelectionPriority,
 		stats: &FallbackStats{
 			ModelUsageCount: make(map[string]int64),
 		},
@@ -106,13 +145,16 @@ func (m *ModelFallbackManager) Complete(
 	messages []types.Message,
 	opts *provider.StreamOptions,
 ) (*provider.CompleteResponse, error) {
-	m.stats.TotalRequests++
+	m.recordRequest()
 
 	var lastErr error
 
-	// 遍历所有启用的模型
-	for i, fb := range m.fallbacks {
-		if !fb.Enabled {
+	// 按选择模式遍历所有启用的模型
+	order := m.selectionOrder()
+	for n, i := range order {
+		fb := m.fallbacks[i]
+		prov, enabled := m.enabledProvider(fb)
+		if !enabled {
 			continue
 		}
 
@@ -133,12 +175,10 @@ func (m *ModelFallbackManager) Complete(
 			}
 
 			// 执行请求
-			resp, err := fb.provider.Complete(ctx, messages, opts)
+			resp, err := prov.Complete(ctx, messages, opts)
 			if err == nil {
 				// 成功
-				m.stats.SuccessRequests++
-				m.stats.ModelUsageCount[modelKey]++
-				m.currentIndex = i
+				m.recordSuccess(i, modelKey)
 
 				fallbackLog.Debug(ctx, "success with model", map[string]any{"model": modelKey, "retry": retry})
 				return resp, nil
@@ -149,15 +189,14 @@ func (m *ModelFallbackManager) Complete(
 		}
 
 		// 所有重试都失败，尝试下一个模型
-		if i < len(m.fallbacks)-1 {
-			m.stats.FallbackCount++
-			m.stats.LastFallbackTime = time.Now()
+		if n < len(order)-1 {
+			m.recordFallback()
 			fallbackLog.Info(ctx, "falling back to next model", map[string]any{"from_model": modelKey})
 		}
 	}
 
 	// 所有模型都失败
-	m.stats.FailedRequests++
+	m.recordFailure()
 	return nil, fmt.Errorf("all models failed, last error: %w", lastErr)
 }
 
@@ -167,13 +206,16 @@ func (m *ModelFallbackManager) Stream(
 	messages []types.Message,
 	opts *provider.StreamOptions,
 ) (<-chan provider.StreamChunk, error) {
-	m.stats.TotalRequests++
+	m.recordRequest()
 
 	var lastErr error
 
-	// 遍历所有启用的模型
-	for i, fb := range m.fallbacks {
-		if !fb.Enabled {
+	// 按选择模式遍历所有启用的模型
+	order := m.selectionOrder()
+	for n, i := range order {
+		fb := m.fallbacks[i]
+		prov, enabled := m.enabledProvider(fb)
+		if !enabled {
 			continue
 		}
 
@@ -194,12 +236,10 @@ func (m *ModelFallbackManager) Stream(
 			}
 
 			// 执行流式请求
-			stream, err := fb.provider.Stream(ctx, messages, opts)
+			stream, err := prov.Stream(ctx, messages, opts)
 			if err == nil {
 				// 成功
-				m.stats.SuccessRequests++
-				m.stats.ModelUsageCount[modelKey]++
-				m.currentIndex = i
+				m.recordSuccess(i, modelKey)
 
 				fallbackLog.Debug(ctx, "success with model (stream)", map[string]any{"model": modelKey, "retry": retry})
 				return stream, nil
@@ -210,20 +250,109 @@ func (m *ModelFallbackManager) Stream(
 		}
 
 		// 所有重试都失败，尝试下一个模型
-		if i < len(m.fallbacks)-1 {
-			m.stats.FallbackCount++
-			m.stats.LastFallbackTime = time.Now()
+		if n < len(order)-1 {
+			m.recordFallback()
 			fallbackLog.Info(ctx, "falling back to next model (stream)", map[string]any{"from_model": modelKey})
 		}
 	}
 
 	// 所有模型都失败
-	m.stats.FailedRequests++
+	m.recordFailure()
 	return nil, fmt.Errorf("all models failed (stream), last error: %w", lastErr)
 }
 
+// WithSelectionMode 设置模型选择模式。
+// 非 Priority 模式下请求按模式分配到首个尝试的模型，该模型失败后仍按优先级依次降级。
+func (m *ModelFallbackManager) WithSelectionMode(mode FallbackSelectionMode) *ModelFallbackManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mode = mode
+	return m
+}
+
+// selectionOrder 返回本次请求尝试模型的顺序（m.fallbacks 的索引）
+func (m *ModelFallbackManager) selectionOrder() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order := make([]int, 0, len(m.fallbacks))
+	var candidates []int
+	for i, fb := range m.fallbacks {
+		order = append(order, i)
+		// 已知不健康的模型不参与分配，仍可作为降级候选
+		if fb.Enabled && (fb.health.LastCheck.IsZero() || fb.health.Healthy) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) < 2 {
+		return order
+	}
+
+	var first int
+	switch m.mode {
+	case FallbackSelectionRoundRobin:
+		first = candidates[(m.roundRobin.Add(1)-1)%uint64(len(candidates))]
+	case FallbackSelectionWeighted:
+		total := 0
+		for _, i := range candidates {
+			total += fallbackWeight(m.fallbacks[i])
+		}
+		r := rand.IntN(total)
+		for _, i := range candidates {
+			if r -= fallbackWeight(m.fallbacks[i]); r < 0 {
+				first = i
+				break
+			}
+		}
+	default:
+		return order
+	}
+
+	// 选中的模型排在最前，其余保持优先级顺序
+	order = append(order[:first], order[first+1:]...)
+	return append([]int{first}, order...)
+}
+
+// fallbackWeight 模型的有效权重
+func fallbackWeight(fb *ModelFallback) int {
+	return max(fb.Weight, 1)
+}
+
+// recordRequest 记录一次请求
+func (m *ModelFallbackManager) recordRequest() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.TotalRequests++
+}
+
+// recordSuccess 记录成功的请求并切换当前模型
+func (m *ModelFallbackManager) recordSuccess(index int, modelKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.SuccessRequests++
+	m.stats.ModelUsageCount[modelKey]++
+	m.currentIndex = index
+}
+
+// recordFallback 记录一次降级
+func (m *ModelFallbackManager) recordFallback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.FallbackCount++
+	m.stats.LastFallbackTime = time.Now()
+}
+
+// recordFailure 记录所有模型都失败的请求
+func (m *ModelFallbackManager) recordFailure() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.FailedRequests++
+}
+
 // GetCurrentProvider 获取当前使用的 Provider
 func (m *ModelFallbackManager) GetCurrentProvider() provider.Provider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	if m.currentIndex >= 0 && m.currentIndex < len(m.fallbacks) {
 		return m.fallbacks[m.currentIndex].provider
 	}
@@ -232,7 +361,35 @@ func (m *ModelFallbackManager) GetCurrentProvider() provider.Provider {
 
 // GetStats 获取统计信息
 func (m *ModelFallbackManager) GetStats() *FallbackStats {
-	return m.stats
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := *m.stats
+	stats.ModelUsageCount = maps.Clone(m.stats.ModelUsageCount)
+	for _, fb := range m.fallbacks {
+		if fb.health.LastCheck.IsZero() {
+			continue
+		}
+		if stats.ModelHealth == nil {
+			stats.ModelHealth = make(map[string]ModelHealthState)
+		}
+		stats.ModelHealth[fmt.Sprintf("%s/%s", fb.Config.Provider, fb.Config.Model)] = fb.health
+	}
+	return &stats
+}
+
+// isEnabled 模型当前是否启用
+func (m *ModelFallbackManager) isEnabled(fb *ModelFallback) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fb.Enabled
+}
+
+// enabledProvider 返回模型的 Provider 及当前是否启用
+func (m *ModelFallbackManager) enabledProvider(fb *ModelFallback) (provider.Provider, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fb.provider, fb.Enabled && fb.provider != nil
 }
 
 // EnableModel 启用指定模型
@@ -242,6 +399,8 @@ func (m *ModelFallbackManager) EnableModel(provider, model string) error {
 	for _, fb := range m.fallbacks {
 		fbKey := fmt.Sprintf("%s/%s", fb.Config.Provider, fb.Config.Model)
 		if fbKey == modelKey {
+			m.mu.Lock()
+			defer m.mu.Unlock()
 			if !fb.Enabled && fb.provider == nil {
 				// 需要重新创建 Provider
 				prov, err := m.deps.ProviderFactory.Create(fb.Config)
@@ -251,6 +410,7 @@ func (m *ModelFallbackManager) EnableModel(provider, model string) error {
 				fb.provider = prov
 			}
 			fb.Enabled = true
+			fb.health.AutoDisabled = false
 			fallbackLog.Info(context.Background(), "enabled model", map[string]any{"model": modelKey})
 			return nil
 		}
@@ -266,7 +426,10 @@ func (m *ModelFallbackManager) DisableModel(provider, model string) error {
 	for _, fb := range m.fallbacks {
 		fbKey := fmt.Sprintf("%s/%s", fb.Config.Provider, fb.Config.Model)
 		if fbKey == modelKey {
+			m.mu.Lock()
 			fb.Enabled = false
+			fb.health.AutoDisabled = false
+			m.mu.Unlock()
 			fallbackLog.Info(context.Background(), "disabled model", map[string]any{"model": modelKey})
 			return nil
 		}
@@ -279,13 +442,17 @@ func (m *ModelFallbackManager) DisableModel(provider, model string) error {
 func (m *ModelFallbackManager) ListModels() []map[string]any {
 	models := make([]map[string]any, 0, len(m.fallbacks))
 
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	for i, fb := range m.fallbacks {
 		modelKey := fmt.Sprintf("%s/%s", fb.Config.Provider, fb.Config.Model)
 		models = append(models, map[string]any{
 			"provider":    fb.Config.Provider,
 			"model":       fb.Config.Model,
 			"enabled":     fb.Enabled,
+			"healthy":     fb.health.LastCheck.IsZero() || fb.health.Healthy,
 			"priority":    fb.Priority,
+			"weight":      fallbackWeight(fb),
 			"max_retries": fb.MaxRetries,
 			"is_current":  i == m.currentIndex,
 			"usage_count": m.stats.ModelUsageCount[modelKey],
@@ -297,6 +464,8 @@ func (m *ModelFallbackManager) ListModels() []map[string]any {
 
 // ResetStats 重置统计信息
 func (m *ModelFallbackManager) ResetStats() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.stats = &FallbackStats{
 		ModelUsageCount: make(map[string]int64),
 	}
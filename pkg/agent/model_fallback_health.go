@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+)
+
+// FallbackHealthConfig 降级模型后台健康检查配置
+type FallbackHealthConfig struct {
+	// Interval 检查间隔，默认 30s
+	Interval time.Duration
+
+	// FailureThreshold 连续失败多少次后自动禁用模型，默认 3
+	FailureThreshold int
+
+	// Cooldown 自动禁用后等待多久再探测恢复，默认 1min
+	Cooldown time.Duration
+
+	// Timeout 单次探测超时，默认 10s
+	Timeout time.Duration
+}
+
+// withDefaults 填充未设置的配置项
+func (c FallbackHealthConfig) withDefaults() FallbackHealthConfig {
+	if c.Interval <= 0 {
+		c.Interval = 30 * time.Second
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 3
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = time.Minute
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	return c
+}
+
+// ModelHealthState 模型健康检查状态
+type ModelHealthState struct {
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	AutoDisabled        bool      `json:"auto_disabled"` // 是否因健康检查失败被自动禁用
+	DisabledUntil       time.Time `json:"disabled_until,omitzero"`
+	LastCheck           time.Time `json:"last_check"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// StartHealthCheck 启动后台健康检查。
+// 定期用最小请求探测已启用的模型，连续失败达到阈值后自动禁用，冷却期过后探测成功则自动恢复；
+// 手动禁用的模型不会被探测或恢复。探测与请求使用各自的 Provider 调用，不会中断进行中的请求。
+func (m *ModelFallbackManager) StartHealthCheck(config FallbackHealthConfig) {
+	config = config.withDefaults()
+	m.StopHealthCheck()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	m.mu.Lock()
+	m.healthCancel, m.healthDone = cancel, done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.CheckHealth(ctx, config)
+			}
+		}
+	}()
+}
+
+// StopHealthCheck 停止后台健康检查并等待当前一轮检查结束
+func (m *ModelFallbackManager) StopHealthCheck() {
+	m.mu.Lock()
+	cancel, done := m.healthCancel, m.healthDone
+	m.healthCancel, m.healthDone = nil, nil
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// CheckHealth 立即执行一轮健康检查
+func (m *ModelFallbackManager) CheckHealth(ctx context.Context, config FallbackHealthConfig) {
+	config = config.withDefaults()
+	for _, fb := range m.fallbacks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.RLock()
+		prov := fb.provider
+		due := fb.Enabled || (fb.health.AutoDisabled && !time.Now().Before(fb.health.DisabledUntil))
+		m.mu.RUnlock()
+		if prov == nil || !due {
+			continue
+		}
+
+		// 探测在锁外进行，避免阻塞请求
+		pingCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		err := provider.Ping(pingCtx, prov)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.recordHealth(ctx, fb, err, config)
+	}
+}
+
+// recordHealth 记录探测结果并按需自动禁用或恢复模型
+func (m *ModelFallbackManager) recordHealth(ctx context.Context, fb *ModelFallback, err error, config FallbackHealthConfig) {
+	modelKey := fmt.Sprintf("%s/%s", fb.Config.Provider, fb.Config.Model)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := &fb.health
+	health.LastCheck = now
+	if err == nil {
+		health.Healthy = true
+		health.ConsecutiveFailures = 0
+		health.LastError = ""
+		if health.AutoDisabled {
+			health.AutoDisabled = false
+			health.DisabledUntil = time.Time{}
+			fb.Enabled = true
+			fallbackLog.Info(ctx, "model recovered, re-enabled", map[string]any{"model": modelKey})
+		}
+		return
+	}
+
+	health.Healthy = false
+	health.ConsecutiveFailures++
+	health.LastError = err.Error()
+
+	if health.AutoDisabled {
+		// 冷却后探测仍失败，继续禁用
+		health.DisabledUntil = now.Add(config.Cooldown)
+		return
+	}
+	if !fb.Enabled || health.ConsecutiveFailures < config.FailureThreshold {
+		return
+	}
+
+	// 保留至少一个启用的模型，避免所有请求直接失败
+	for _, other := range m.fallbacks {
+		if other != fb && other.Enabled {
+			fb.Enabled = false
+			health.AutoDisabled = true
+			health.DisabledUntil = now.Add(config.Cooldown)
+			fallbackLog.Warn(ctx, "model auto-disabled after failed health checks", map[string]any{
+				"model":    modelKey,
+				"failures": health.ConsecutiveFailures,
+				"error":    err,
+			})
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// newHealthTestManager 创建主模型健康状态可控的降级管理器
+func newHealthTestManager(t *testing.T, primaryDown *atomic.Bool) *ModelFallbackManager {
+	t.Helper()
+	factory := NewMockProviderFactory()
+	factory.SetProvider("openai/gpt-4", &MockProvider{
+		name: "openai/gpt-4",
+		completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+			if primaryDown.Load() {
+				return nil, errors.New("service unavailable")
+			}
+			return &provider.CompleteResponse{Message: types.Message{Role: "assistant", Content: "primary"}}, nil
+		},
+	})
+	factory.SetProvider("anthropic/claude-3", &MockProvider{name: "anthropic/claude-3"})
+
+	manager, err := NewModelFallbackManager([]*ModelFallback{
+		{Config: &types.ModelConfig{Provider: "openai", Model: "gpt-4"}, Enabled: true, Priority: 1},
+		{Config: &types.ModelConfig{Provider: "anthropic", Model: "claude-3"}, Enabled: true, Priority: 2},
+	}, &Dependencies{ProviderFactory: factory})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	return manager
+}
+
+func TestModelFallbackManager_HealthCheckAutoDisableAndRecover(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	manager := newHealthTestManager(t, &primaryDown)
+	config := FallbackHealthConfig{FailureThreshold: 2, Cooldown: 20 * time.Millisecond}
+	ctx := context.Background()
+
+	manager.CheckHealth(ctx, config)
+	if !manager.isEnabled(manager.fallbacks[0]) {
+		t.Fatal("model should stay enabled below the failure threshold")
+	}
+
+	manager.CheckHealth(ctx, config)
+	health := manager.GetStats().ModelHealth["openai/gpt-4"]
+	if manager.isEnabled(manager.fallbacks[0]) || !health.AutoDisabled || health.ConsecutiveFailures != 2 {
+		t.Fatalf("model should be auto-disabled after repeated failures, got %+v", health)
+	}
+	if !manager.GetStats().ModelHealth["anthropic/claude-3"].Healthy {
+		t.Error("healthy model should be reported as healthy")
+	}
+
+	// 请求直接使用备用模型
+	resp, err := manager.Complete(ctx, []types.Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil || resp.Message.Content != "mock response from anthropic/claude-3" {
+		t.Fatalf("expected fallback response, got %v, %v", resp, err)
+	}
+
+	// 冷却期内不探测也不恢复
+	primaryDown.Store(false)
+	manager.CheckHealth(ctx, config)
+	if manager.isEnabled(manager.fallbacks[0]) {
+		t.Fatal("model should not be re-enabled during cooldown")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	manager.CheckHealth(ctx, config)
+	health = manager.GetStats().ModelHealth["openai/gpt-4"]
+	if !manager.isEnabled(manager.fallbacks[0]) || health.AutoDisabled || !health.Healthy {
+		t.Errorf("recovered model should be re-enabled, got %+v", health)
+	}
+}
+
+func TestModelFallbackManager_HealthCheckSkipsManuallyDisabled(t *testing.T) {
+	var primaryDown atomic.Bool
+	manager := newHealthTestManager(t, &primaryDown)
+
+	if err := manager.DisableModel("openai", "gpt-4"); err != nil {
+		t.Fatal(err)
+	}
+	manager.CheckHealth(context.Background(), FallbackHealthConfig{Cooldown: time.Nanosecond})
+	if manager.isEnabled(manager.fallbacks[0]) {
+		t.Error("health check should not re-enable manually disabled models")
+	}
+}
+
+func TestModelFallbackManager_HealthCheckKeepsLastModel(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	manager := newHealthTestManager(t, &primaryDown)
+	if err := manager.DisableModel("anthropic", "claude-3"); err != nil {
+		t.Fatal(err)
+	}
+
+	config := FallbackHealthConfig{FailureThreshold: 1}
+	manager.CheckHealth(context.Background(), config)
+	if !manager.isEnabled(manager.fallbacks[0]) {
+		t.Error("the last enabled model should not be auto-disabled")
+	}
+	if manager.GetStats().ModelHealth["openai/gpt-4"].Healthy {
+		t.Error("failed model should still be reported as unhealthy")
+	}
+}
+
+func TestModelFallbackManager_StartStopHealthCheck(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	manager := newHealthTestManager(t, &primaryDown)
+
+	manager.StartHealthCheck(FallbackHealthConfig{Interval: 5 * time.Millisecond, FailureThreshold: 2, Cooldown: time.Hour})
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.isEnabled(manager.fallbacks[0]) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	manager.StopHealthCheck()
+
+	if manager.isEnabled(manager.fallbacks[0]) {
+		t.Fatal("background health check should auto-disable the failing model")
+	}
+	checked := manager.GetStats().ModelHealth["openai/gpt-4"].LastCheck
+	time.Sleep(20 * time.Millisecond)
+	if manager.GetStats().ModelHealth["openai/gpt-4"].LastCheck != checked {
+		t.Error("health check should not run after StopHealthCheck")
+	}
+}
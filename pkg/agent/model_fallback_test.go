@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -600,3 +601,139 @@ func TestModelFallbackManager_ContextCancellation(t *testing.T) {
 		t.Errorf("Expected context deadline error, got: %v", err)
 	}
 }
+
+// newSelectionTestManager 创建三个均可用模型的降级管理器
+func newSelectionTestManager(t *testing.T, weights ...int) *ModelFallbackManager {
+	t.Helper()
+	names := []string{"gpt-4", "claude-3", "deepseek"}
+	fallbacks := make([]*ModelFallback, len(weights))
+	for i, weight := range weights {
+		fallbacks[i] = &ModelFallback{
+			Config:   &types.ModelConfig{Provider: "mock", Model: names[i]},
+			Enabled:  true,
+			Priority: i + 1,
+			Weight:   weight,
+		}
+	}
+	manager, err := NewModelFallbackManager(fallbacks, &Dependencies{ProviderFactory: NewMockProviderFactory()})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	return manager
+}
+
+func TestModelFallbackManager_RoundRobin(t *testing.T) {
+	manager := newSelectionTestManager(t, 0, 0, 0).WithSelectionMode(FallbackSelectionRoundRobin)
+	if err := manager.DisableModel("mock", "deepseek"); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []types.Message{{Role: "user", Content: "Hello"}}
+	for range 6 {
+		if _, err := manager.Complete(context.Background(), messages, nil); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+
+	usage := manager.GetStats().ModelUsageCount
+	if usage["mock/gpt-4"] != 3 || usage["mock/claude-3"] != 3 || usage["mock/deepseek"] != 0 {
+		t.Errorf("expected even distribution across enabled models, got %v", usage)
+	}
+}
+
+func TestModelFallbackManager_WeightedRandom(t *testing.T) {
+	manager := newSelectionTestManager(t, 70, 30).WithSelectionMode(FallbackSelectionWeighted)
+
+	messages := []types.Message{{Role: "user", Content: "Hello"}}
+	const total = 2000
+	for range total {
+		if _, err := manager.Stream(context.Background(), messages, nil); err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+	}
+
+	usage := manager.GetStats().ModelUsageCount
+	share := float64(usage["mock/gpt-4"]) / total
+	if share < 0.62 || share > 0.78 {
+		t.Errorf("expected ~70%% of requests on gpt-4, got %.2f (%v)", share, usage)
+	}
+	if usage["mock/gpt-4"]+usage["mock/claude-3"] != total {
+		t.Errorf("usage count should cover every request, got %v", usage)
+	}
+}
+
+func TestModelFallbackManager_WeightedFallsBackOnFailure(t *testing.T) {
+	factory := NewMockProviderFactory()
+	factory.SetProvider("mock/gpt-4", &MockProvider{name: "mock/gpt-4", shouldFail: true, failCount: 1000})
+	fallbacks := []*ModelFallback{
+		{Config: &types.ModelConfig{Provider: "mock", Model: "gpt-4"}, Enabled: true, Priority: 1, Weight: 1},
+		{Config: &types.ModelConfig{Provider: "mock", Model: "claude-3"}, Enabled: true, Priority: 2, Weight: 1},
+	}
+	manager, err := NewModelFallbackManager(fallbacks, &Dependencies{ProviderFactory: factory})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	manager.WithSelectionMode(FallbackSelectionWeighted)
+
+	for range 20 {
+		resp, err := manager.Complete(context.Background(), []types.Message{{Role: "user", Content: "Hello"}}, nil)
+		if err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+		if resp.Message.Content != "mock response from mock/claude-3" {
+			t.Errorf("expected fallback to claude-3, got %s", resp.Message.Content)
+		}
+	}
+}
+
+// TestModelFallbackManager_ConcurrentRequests 并发请求与读取统计，需配合 -race 运行
+func TestModelFallbackManager_ConcurrentRequests(t *testing.T) {
+	manager, err := NewModelFallbackManager([]*ModelFallback{
+		{Config: &types.ModelConfig{Provider: "openai", Model: "gpt-4"}, Enabled: true, Priority: 1},
+		{Config: &types.ModelConfig{Provider: "anthropic", Model: "claude-3"}, Enabled: true, Priority: 2},
+	}, &Dependencies{ProviderFactory: NewMockProviderFactory()})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	manager.WithSelectionMode(FallbackSelectionRoundRobin)
+
+	ctx := context.Background()
+	messages := []types.Message{{Role: "user", Content: "Hello"}}
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := manager.Complete(ctx, messages, nil); err != nil {
+				t.Errorf("Complete failed: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			stream, err := manager.Stream(ctx, messages, nil)
+			if err != nil {
+				t.Errorf("Stream failed: %v", err)
+				return
+			}
+			for range stream {
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = manager.GetStats()
+			_ = manager.ListModels()
+			_ = manager.GetCurrentProvider()
+			_ = manager.DisableModel("anthropic", "claude-3")
+			_ = manager.EnableModel("anthropic", "claude-3")
+		}()
+	}
+	wg.Wait()
+
+	stats := manager.GetStats()
+	if stats.TotalRequests != 20 || stats.SuccessRequests != 20 {
+		t.Errorf("Expected 20 successful requests, got total=%d success=%d", stats.TotalRequests, stats.SuccessRequests)
+	}
+	if usage := stats.ModelUsageCount["openai/gpt-4"] + stats.ModelUsageCount["anthropic/claude-3"]; usage != 20 {
+		t.Errorf("Expected usage count 20, got %d", usage)
+	}
+}
@@ -2,14 +2,46 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/astercloud/aster/pkg/store"
 	"github.com/astercloud/aster/pkg/types"
 )
 
+// planCollection 计划在 Store 中的集合名
+const planCollection = "plans"
+
+// ErrNoPendingPlan 没有等待审批的计划
+var ErrNoPendingPlan = errors.New("no plan awaiting approval")
+
+// PlanStatus 计划状态
+type PlanStatus string
+
+const (
+	PlanStatusPending  PlanStatus = "pending_approval"
+	PlanStatusApproved PlanStatus = "approved"
+	PlanStatusRejected PlanStatus = "rejected"
+)
+
+// Plan Plan 模式产出的结构化计划
+type Plan struct {
+	ID        string     `json:"id"`
+	FilePath  string     `json:"file_path,omitempty"`
+	Content   string     `json:"content"`
+	Steps     []string   `json:"steps,omitempty"`
+	Rationale string     `json:"rationale,omitempty"`
+	Status    PlanStatus `json:"status"`
+	Feedback  string     `json:"feedback,omitempty"` // 拒绝时的用户反馈
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
 // PlanModeState Plan 模式状态
 type PlanModeState struct {
 	Active       bool             // 是否处于 Plan 模式
@@ -33,6 +65,10 @@ type PlanConstraints struct {
 type PlanModeManager struct {
 	mu    sync.RWMutex
 	state *PlanModeState
+	plan  *Plan
+
+	// onProposed 计划提交后的回调（由 Agent 设置，用于持久化和发送事件）
+	onProposed func(plan *Plan)
 }
 
 // NewPlanModeManager 创建 Plan 模式管理器
@@ -49,7 +85,11 @@ func NewPlanModeManager() *PlanModeManager {
 func (m *PlanModeManager) EnterPlanMode(planID, planFilePath, reason string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.state = newPlanModeState(planID, planFilePath, reason)
+}
 
+// newPlanModeState 创建激活的 Plan 模式状态
+func newPlanModeState(planID, planFilePath, reason string) *PlanModeState {
 	// 定义 Plan 模式允许的工具
 	allowedTools := map[string]bool{
 		"Read":            true,
@@ -63,7 +103,7 @@ func (m *PlanModeManager) EnterPlanMode(planID, planFilePath, reason string) {
 		"Task":            true, // 允许启动 Explore 子代理
 	}
 
-	m.state = &PlanModeState{
+	return &PlanModeState{
 		Active:       true,
 		PlanID:       planID,
 		PlanFilePath: planFilePath,
@@ -95,6 +135,83 @@ func (m *PlanModeManager) ExitPlanMode() {
 	}
 }
 
+// ProposePlan 提交计划并等待审批
+// 审批前保持 Plan 模式的工具约束；steps 为空时从计划内容的列表项中提取。
+func (m *PlanModeManager) ProposePlan(planID, planFilePath, content string, steps []string, rationale string) {
+	if len(steps) == 0 {
+		steps = extractPlanSteps(content)
+	}
+
+	m.mu.Lock()
+	if !m.state.Active {
+		m.state = newPlanModeState(planID, planFilePath, "plan proposed")
+	}
+	now := time.Now()
+	m.plan = &Plan{
+		ID:        planID,
+		FilePath:  planFilePath,
+		Content:   content,
+		Steps:     steps,
+		Rationale: rationale,
+		Status:    PlanStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	plan := *m.plan
+	onProposed := m.onProposed
+	m.mu.Unlock()
+
+	if onProposed != nil {
+		onProposed(&plan)
+	}
+}
+
+// GetPlan 获取当前计划的副本，没有计划时返回 nil
+func (m *PlanModeManager) GetPlan() *Plan {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.plan == nil {
+		return nil
+	}
+	plan := *m.plan
+	plan.Steps = slices.Clone(m.plan.Steps)
+	return &plan
+}
+
+// decidePlan 更新等待审批的计划状态，批准时退出 Plan 模式
+func (m *PlanModeManager) decidePlan(status PlanStatus, feedback string) (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.plan == nil || m.plan.Status != PlanStatusPending {
+		return nil, ErrNoPendingPlan
+	}
+	m.plan.Status = status
+	m.plan.Feedback = feedback
+	m.plan.UpdatedAt = time.Now()
+	if status == PlanStatusApproved {
+		m.state = &PlanModeState{
+			Active:       false,
+			AllowedTools: make(map[string]bool),
+		}
+	}
+
+	plan := *m.plan
+	return &plan, nil
+}
+
+// restorePlan 恢复持久化的计划，未批准的计划重新进入 Plan 模式
+func (m *PlanModeManager) restorePlan(plan *Plan) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if plan.Status != PlanStatusApproved {
+		m.state = newPlanModeState(plan.ID, plan.FilePath, "restored plan")
+	}
+	m.plan = plan
+}
+
 // IsActive 检查是否处于 Plan 模式
 func (m *PlanModeManager) IsActive() bool {
 	m.mu.RLock()
@@ -127,6 +244,11 @@ func (m *PlanModeManager) ValidateToolCall(toolName string, input map[string]any
 		return true, ""
 	}
 
+	// 计划等待审批时只允许只读工具和重新提交计划
+	if m.plan != nil && m.plan.Status == PlanStatusPending && (toolName == "Write" || toolName == "Task") {
+		return false, "Plan is awaiting user approval; wait for approval before making changes"
+	}
+
 	// 检查工具是否在白名单中
 	if !m.state.AllowedTools[toolName] {
 		return false, fmt.Sprintf("Tool '%s' is not allowed in Plan Mode. Allowed tools: %s",
@@ -192,6 +314,20 @@ func (m *PlanModeManager) getAllowedToolsList() string {
 	return strings.Join(tools, ", ")
 }
 
+// planStepPattern 匹配 Markdown 有序/无序列表项
+var planStepPattern = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*])\s+(.+)$`)
+
+// extractPlanSteps 从计划内容中提取列表项作为步骤
+func extractPlanSteps(content string) []string {
+	var steps []string
+	for line := range strings.SplitSeq(content, "\n") {
+		if match := planStepPattern.FindStringSubmatch(line); match != nil {
+			steps = append(steps, strings.TrimSpace(match[1]))
+		}
+	}
+	return steps
+}
+
 // ToolCallDeniedError 工具调用被拒绝错误
 type ToolCallDeniedError struct {
 	ToolName string
@@ -249,3 +385,76 @@ func (a *Agent) GetPlanModeState() *PlanModeState {
 	}
 	return a.planMode.GetState()
 }
+
+// GetPlan 获取 Plan 模式产出的当前计划，没有计划时返回 nil
+func (a *Agent) GetPlan() *Plan {
+	if a.planMode == nil {
+		return nil
+	}
+	return a.planMode.GetPlan()
+}
+
+// ApprovePlan 批准等待审批的计划并退出 Plan 模式，之后 Agent 可以执行计划
+func (a *Agent) ApprovePlan() error {
+	return a.decidePlan(PlanStatusApproved, "")
+}
+
+// RejectPlan 拒绝等待审批的计划，Agent 保持 Plan 模式
+// feedback 会记录在计划中，可随下一条消息发给 Agent 以修订计划。
+func (a *Agent) RejectPlan(feedback string) error {
+	return a.decidePlan(PlanStatusRejected, feedback)
+}
+
+func (a *Agent) decidePlan(status PlanStatus, feedback string) error {
+	if a.planMode == nil {
+		return ErrNoPendingPlan
+	}
+	plan, err := a.planMode.decidePlan(status, feedback)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	a.savePlan(ctx, plan)
+	a.eventBus.EmitControl(&types.ControlPlanDecidedEvent{
+		PlanID:   plan.ID,
+		Decision: string(status),
+		Feedback: feedback,
+	})
+	agentLog.Info(ctx, "plan decided", map[string]any{
+		"agent_id": a.id,
+		"plan_id":  plan.ID,
+		"decision": string(status),
+	})
+	return nil
+}
+
+// onPlanProposed 持久化计划并通知审批方
+func (a *Agent) onPlanProposed(plan *Plan) {
+	a.savePlan(context.Background(), plan)
+	a.eventBus.EmitControl(&types.ControlPlanProposedEvent{
+		PlanID:    plan.ID,
+		Content:   plan.Content,
+		Steps:     plan.Steps,
+		Rationale: plan.Rationale,
+	})
+}
+
+// savePlan 持久化计划，使其在重启后仍可审批
+func (a *Agent) savePlan(ctx context.Context, plan *Plan) {
+	if err := a.deps.Store.Set(ctx, planCollection, a.id, plan); err != nil {
+		agentLog.Warn(ctx, "failed to save plan", map[string]any{"agent_id": a.id, "plan_id": plan.ID, "error": err})
+	}
+}
+
+// loadPlan 恢复持久化的计划
+func (a *Agent) loadPlan(ctx context.Context) {
+	var plan Plan
+	if err := a.deps.Store.Get(ctx, planCollection, a.id, &plan); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			agentLog.Warn(ctx, "failed to load plan", map[string]any{"agent_id": a.id, "error": err})
+		}
+		return
+	}
+	a.planMode.restorePlan(&plan)
+}
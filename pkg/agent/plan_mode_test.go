@@ -1,7 +1,14 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
 )
 
 func TestPlanModeManager_EnterAndExit(t *testing.T) {
@@ -147,3 +154,156 @@ func TestPlanModeManager_GetState_ReturnsCopy(t *testing.T) {
 		t.Error("GetState should return a copy, not a reference")
 	}
 }
+
+// newPlanTestAgent 创建带 ExitPlanMode 工具的非流式测试 Agent
+func newPlanTestAgent(t *testing.T, deps *Dependencies, agentID string, mock *MockProvider) *Agent {
+	t.Helper()
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		AgentID:    agentID,
+		TemplateID: "test-template",
+		Tools:      []string{"Read", "Write", "ExitPlanMode"},
+		ModelConfig: &types.ModelConfig{
+			Provider:      "anthropic",
+			Model:         "claude-sonnet-4-5",
+			APIKey:        "test-key",
+			ExecutionMode: types.ExecutionModeNonStreaming,
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:           types.SandboxKindMock,
+			WorkDir:        "/tmp/test",
+			PermissionMode: types.SandboxPermissionBypass,
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	t.Cleanup(func() { _ = ag.Close() })
+
+	if mock != nil {
+		ag.provider = mock
+	}
+	return ag
+}
+
+// planScriptProvider 根据最后一条消息决定响应，并记录收到的工具结果
+// 用户消息映射到预设工具调用，其余情况返回纯文本结束本轮
+func planScriptProvider(replies map[string]*types.ToolUseBlock, results *[]*types.ToolResultBlock) *MockProvider {
+	return &MockProvider{name: "mock", completeFunc: func(_ context.Context, messages []types.Message, _ *provider.StreamOptions) (*provider.CompleteResponse, error) {
+		var reply *types.ToolUseBlock
+		last := messages[len(messages)-1]
+		for _, block := range last.ContentBlocks {
+			switch b := block.(type) {
+			case *types.ToolResultBlock:
+				*results = append(*results, b)
+				reply = replies[b.ToolUseID]
+			case *types.TextBlock:
+				reply = replies[b.Text]
+			}
+		}
+		if reply == nil {
+			reply = replies[last.Content]
+		}
+
+		if reply != nil {
+			return &provider.CompleteResponse{Message: types.Message{
+				Role:          types.MessageRoleAssistant,
+				ContentBlocks: []types.ContentBlock{reply},
+			}}, nil
+		}
+		return &provider.CompleteResponse{Message: types.Message{Role: types.MessageRoleAssistant, Content: "ok"}}, nil
+	}}
+}
+
+func TestAgent_PlanRequiresApproval(t *testing.T) {
+	deps := setupTestDeps(t)
+	writeCall := func(id string) *types.ToolUseBlock {
+		return &types.ToolUseBlock{ID: id, Name: "Write", Input: map[string]any{"file_path": "main.go", "content": "package main"}}
+	}
+
+	var results []*types.ToolResultBlock
+	mock := planScriptProvider(map[string]*types.ToolUseBlock{
+		"add a main package": {ID: "plan-1", Name: "ExitPlanMode", Input: map[string]any{"plan": "# Plan\n1. Create main.go\n2. Run tests", "rationale": "smallest change"}},
+		"plan-1":             writeCall("write-early"),
+		"go ahead":           writeCall("write-approved"),
+	}, &results)
+
+	ag := newPlanTestAgent(t, deps, "plan-agent", mock)
+	control := ag.Subscribe([]types.AgentChannel{types.ChannelControl}, nil)
+	ag.EnterPlanMode("p1", ".aster/plans/p1.md", "testing")
+
+	if _, err := ag.Chat(context.Background(), "add a main package"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	plan := ag.GetPlan()
+	if plan == nil || plan.Status != PlanStatusPending || plan.Rationale != "smallest change" {
+		t.Fatalf("expected pending plan, got %+v", plan)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0] != "Create main.go" {
+		t.Errorf("unexpected plan steps: %v", plan.Steps)
+	}
+	if !ag.IsInPlanMode() {
+		t.Error("agent should stay in plan mode until the plan is approved")
+	}
+	if len(results) < 2 || !results[1].IsError || !strings.Contains(results[1].Content, "awaiting user approval") {
+		t.Fatalf("write before approval should be rejected, got %+v", results)
+	}
+
+	select {
+	case env := <-control:
+		if proposed, ok := env.Event.(*types.ControlPlanProposedEvent); !ok || len(proposed.Steps) != 2 {
+			t.Errorf("expected plan_proposed event, got %T", env.Event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected plan_proposed event")
+	}
+
+	// 重启后计划仍在等待审批
+	restored := newPlanTestAgent(t, deps, "plan-agent", nil)
+	if p := restored.GetPlan(); p == nil || p.Status != PlanStatusPending || !restored.IsInPlanMode() {
+		t.Fatalf("expected persisted pending plan after restart, got %+v", p)
+	}
+
+	if err := ag.ApprovePlan(); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if err := ag.ApprovePlan(); !errors.Is(err, ErrNoPendingPlan) {
+		t.Errorf("expected ErrNoPendingPlan on second approval, got %v", err)
+	}
+	if ag.IsInPlanMode() || ag.GetPlan().Status != PlanStatusApproved {
+		t.Error("approval should exit plan mode")
+	}
+
+	if _, err := ag.Chat(context.Background(), "go ahead"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	last := results[len(results)-1]
+	if last.ToolUseID != "write-approved" || last.IsError {
+		t.Errorf("write after approval should succeed, got %+v", last)
+	}
+}
+
+func TestAgent_RejectPlanKeepsPlanMode(t *testing.T) {
+	ag := newPlanTestAgent(t, setupTestDeps(t), "", nil)
+
+	if err := ag.RejectPlan("no"); !errors.Is(err, ErrNoPendingPlan) {
+		t.Errorf("expected ErrNoPendingPlan without a plan, got %v", err)
+	}
+
+	ag.planMode.ProposePlan("p2", "", "- step one", nil, "")
+	if err := ag.RejectPlan("split step one"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	plan := ag.GetPlan()
+	if plan.Status != PlanStatusRejected || plan.Feedback != "split step one" {
+		t.Errorf("unexpected rejected plan: %+v", plan)
+	}
+	if !ag.IsInPlanMode() {
+		t.Error("rejected plan should keep the agent in plan mode")
+	}
+	if allowed, _ := ag.planMode.ValidateToolCall("Bash", nil); allowed {
+		t.Error("Bash should stay blocked after rejection")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/types"
+	"github.com/google/uuid"
 )
 
 var procLog = logging.ForComponent("AgentProcessor")
@@ -24,12 +25,19 @@ func (a *Agent) processMessages(ctx context.Context) {
 	a.mu.Lock()
 	if a.state != types.AgentStateReady {
 		procLog.Warn(ctx, "agent not ready, skipping", map[string]any{"agent_id": a.id, "state": a.state})
+		a.pendingInput = true
 		a.mu.Unlock()
 		return // 已经在处理中
 	}
 	a.state = types.AgentStateWorking
 	a.iterationCount = 0          // 重置迭代计数
 	a.initialThinkingSent = false // 重置初始思考事件标志，允许新用户消息触发新的"任务规划"
+	a.turnRetries = 0
+	a.retryNudge = ""
+	a.runID = newRunID()
+	a.toolCallSeq = 0
+	a.runUsage = types.TokenUsage{}
+	a.runModelCalls = 0
 	initialMsgCount := len(a.messages)
 	procLog.Info(ctx, "agent state changed to working", map[string]any{"agent_id": a.id, "message_count": initialMsgCount})
 	a.mu.Unlock()
@@ -37,14 +45,11 @@ func (a *Agent) processMessages(ctx context.Context) {
 	defer func() {
 		a.mu.Lock()
 		a.state = types.AgentStateReady
-		// 检查是否有新的用户消息需要处理
-		// 只有当最后一条消息是用户消息时才需要重新处理
-		// （避免 assistant 响应触发无限循环）
-		hasNewUserMessage := false
-		if len(a.messages) > initialMsgCount {
-			lastMsg := a.messages[len(a.messages)-1]
-			hasNewUserMessage = lastMsg.Role == types.MessageRoleUser
-		}
+		// 检查运行期间是否有新的用户消息需要处理
+		// 只看运行期间被跳过的输入，而不是最后一条消息的角色：
+		// 工具结果同样以 user 角色保存，运行因错误停在工具结果之后时不能再次触发
+		hasNewUserMessage := a.pendingInput
+		a.pendingInput = false
 		a.mu.Unlock()
 
 		// 如果有新的用户消息，重新触发处理
@@ -69,6 +74,9 @@ func (a *Agent) processMessages(ctx context.Context) {
 	// 调用模型
 	if err := a.runModelStep(ctx); err != nil {
 		procLog.Error(ctx, "runModelStep failed", map[string]any{"agent_id": a.id, "error": err.Error()})
+		a.mu.Lock()
+		a.lastErr = err
+		a.mu.Unlock()
 		a.eventBus.EmitMonitor(&types.MonitorErrorEvent{
 			Severity: "error",
 			Phase:    "model",
@@ -76,12 +84,28 @@ func (a *Agent) processMessages(ctx context.Context) {
 		})
 	}
 
+	a.mu.RLock()
+	succeeded := a.lastErr == nil
+	a.mu.RUnlock()
+	if succeeded {
+		a.updateTitleAsync(ctx)
+	}
+
 	procLog.Info(ctx, "runModelStep completed, sending done event", map[string]any{"agent_id": a.id})
 
+	// 发送本次运行的累计用量
+	a.emitRunUsage()
+
 	// 发送完成事件
+	doneReason := "completed"
+	a.mu.RLock()
+	if errors.Is(a.lastErr, ErrCancelled) {
+		doneReason = "interrupted"
+	}
+	a.mu.RUnlock()
 	a.eventBus.EmitProgress(&types.ProgressDoneEvent{
 		Step:   a.stepCount,
-		Reason: "completed",
+		Reason: doneReason,
 	})
 
 	// 发送状态变更事件
@@ -143,6 +167,7 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 	currentSystemPrompt := a.template.SystemPrompt
 	messages := a.messages // 复制当前消息列表
 	a.mu.RUnlock()
+	messages = a.withRetryNudge(messages)
 
 	if !hasManual && toolMapSize > 0 {
 		procLog.Debug(ctx, "manual not found, injecting", map[string]any{"agent_id": a.id, "tool_map_size": toolMapSize})
@@ -171,7 +196,7 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 			Messages:     messages,
 			SystemPrompt: currentSystemPrompt,
 			Tools:        nil, // TODO: 转换 toolMap 为 []tools.Tool
-			Metadata:     make(map[string]any),
+			Metadata:     a.modelRequestMetadata(),
 		}
 
 		// 注入 EventEmitter，让中间件可以发送事件
@@ -188,6 +213,7 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 				a.eventBus.EmitMonitor(event)
 			}
 		})
+		compaction := withHistoryCompactor(req)
 
 		// 定义 finalHandler: 实际调用 Provider
 		finalHandler := func(ctx context.Context, req *middleware.ModelRequest) (*middleware.ModelResponse, error) {
@@ -196,13 +222,17 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 				Tools:     toolSchemas,
 				MaxTokens: 32000, // Claude 4 Sonnet/Opus 最大支持 64000 output tokens
 				System:    req.SystemPrompt,
+
+				CoalesceWindow: a.streamCoalesceWindow(),
 			}
+			req.ApplyStreamOptions(streamOpts)
 
 			stream, err := a.provider.Stream(ctx, req.Messages, streamOpts)
 			if err != nil {
 				procLog.Error(ctx, "provider.Stream failed", map[string]any{"agent_id": a.id, "error": err.Error()})
 				return nil, fmt.Errorf("stream model: %w", err)
 			}
+			stream = provider.CoalesceStream(ctx, stream, streamOpts)
 			procLog.Info(ctx, "provider.Stream returned, processing response", map[string]any{"agent_id": a.id})
 
 			// 处理流式响应
@@ -220,6 +250,7 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 		// 通过 middleware stack 执行
 		procLog.Info(ctx, "calling middlewareStack.ExecuteModelCall", map[string]any{"agent_id": a.id})
 		resp, err := a.middlewareStack.ExecuteModelCall(ctx, req, finalHandler)
+		a.applyHistoryCompaction(ctx, compaction)
 		if err != nil {
 			procLog.Error(ctx, "middlewareStack.ExecuteModelCall failed", map[string]any{"agent_id": a.id, "error": err.Error()})
 			modelErr = err
@@ -233,13 +264,15 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 			Tools:     toolSchemas,
 			MaxTokens: 32000, // Claude 4 Sonnet/Opus 最大支持 64000 output tokens
 			System:    currentSystemPrompt,
+
+			CoalesceWindow: a.streamCoalesceWindow(),
 		}
 
 		stream, err := a.provider.Stream(ctx, messages, streamOpts)
 		if err != nil {
 			modelErr = err
 		} else {
-			assistantMessage, err = a.handleStreamResponse(ctx, stream)
+			assistantMessage, err = a.handleStreamResponse(ctx, provider.CoalesceStream(ctx, stream, streamOpts))
 			if err != nil {
 				modelErr = err
 			}
@@ -248,12 +281,17 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 
 	// 处理模型调用错误
 	if modelErr != nil {
-		return fmt.Errorf("model call: %w", modelErr)
+		return classifyModelError(fmt.Errorf("model call: %w", modelErr))
+	}
+
+	// 输出为空或工具调用无效时重试本轮
+	if a.shouldRetryTurn(ctx, assistantMessage) {
+		return a.runModelStep(ctx)
 	}
 
 	// 保存助手消息
 	a.mu.Lock()
-	a.messages = append(a.messages, assistantMessage)
+	a.appendMessages(assistantMessage)
 
 	// ✅ 修复：保存前在内存中修剪，避免 Store 出现超限状态
 	if a.shouldTrimMessages() {
@@ -264,10 +302,11 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 			"actual_count": len(a.messages),
 		})
 	}
-	a.mu.Unlock()
 
-	// 持久化（已修剪的消息）
-	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
+	// 持久化（仅追加新消息）
+	err := a.persistMessages(ctx)
+	a.mu.Unlock()
+	if err != nil {
 		return fmt.Errorf("save messages: %w", err)
 	}
 
@@ -297,14 +336,37 @@ func (a *Agent) runModelStep(ctx context.Context) error {
 func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock) error {
 	toolResults := make([]types.ContentBlock, 0, len(toolUses))
 
+	// 多个工具调用时先让中间件统一处理（如批量人工审核）
+	// 会被预检查拦截的调用不参与批量处理，批量处理的状态在这批工具结束后清除
+	if a.middlewareStack != nil && len(toolUses) > 1 {
+		reqs := make([]*middleware.ToolCallRequest, 0, len(toolUses))
+		for _, tu := range toolUses {
+			if a.toolUseBlocked(ctx, tu) {
+				continue
+			}
+			reqs = append(reqs, &middleware.ToolCallRequest{ToolUseID: tu.ID, ToolName: tu.Name, ToolInput: tu.Input})
+		}
+		defer a.middlewareStack.FinishToolBatch(ctx, reqs)
+		if err := a.middlewareStack.PrepareToolBatch(ctx, reqs); err != nil {
+			if ctx.Err() != nil {
+				return newChatError(ErrCancelled, "tool", ctx.Err())
+			}
+			procLog.Warn(ctx, "prepare tool batch failed", map[string]any{"agent_id": a.id, "error": err})
+		}
+	}
+
+	var toolErr error
 	for _, tu := range toolUses {
-		result := a.executeSingleTool(ctx, tu)
+		result, err := a.executeSingleTool(ctx, tu)
 		toolResults = append(toolResults, result)
+		if err != nil && toolErr == nil {
+			toolErr = err
+		}
 	}
 
 	// 保存工具结果
 	a.mu.Lock()
-	a.messages = append(a.messages, types.Message{
+	a.appendMessages(types.Message{
 		Role:          types.MessageRoleUser,
 		ContentBlocks: toolResults,
 	})
@@ -320,11 +382,12 @@ func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock
 	}
 
 	a.stepCount++
-	a.mu.Unlock()
 
-	// 持久化（已修剪的消息）
-	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
-		return fmt.Errorf("save messages: %w", err)
+	// 持久化（仅追加新消息）
+	err := a.persistMessages(ctx)
+	a.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("save tool results: %w", err)
 	}
 
 	// 持久化工具记录
@@ -336,6 +399,11 @@ func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock
 		return fmt.Errorf("save tool records: %w", err)
 	}
 
+	// 工具返回不可恢复的错误时停止运行，结果已保存，之后的对话可以继续
+	if toolErr != nil {
+		return newChatError(ErrToolFailed, "tool", toolErr)
+	}
+
 	// 检查迭代限制（防止无限循环）
 	a.mu.Lock()
 	a.iterationCount++
@@ -362,7 +430,7 @@ func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock
 		select {
 		case decision := <-a.iterationContinueCh:
 			if !decision {
-				return fmt.Errorf("iteration stopped by user after %d iterations", currentIter)
+				return newChatError(ErrRunLimit, "run", fmt.Errorf("iteration stopped by user after %d iterations", currentIter))
 			}
 			// 用户确认继续，重置迭代计数并继续
 			a.mu.Lock()
@@ -370,7 +438,7 @@ func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock
 			a.mu.Unlock()
 			procLog.Info(ctx, "user confirmed to continue, resetting iteration count", map[string]any{"agent_id": a.id})
 		case <-ctx.Done():
-			return ctx.Err()
+			return newChatError(ErrCancelled, "run", ctx.Err())
 		}
 	}
 	procLog.Debug(ctx, "streaming iteration", map[string]any{"agent_id": a.id, "iteration": currentIter, "max": maxIter})
@@ -379,8 +447,27 @@ func (a *Agent) executeTools(ctx context.Context, toolUses []*types.ToolUseBlock
 	return a.runModelStep(ctx)
 }
 
+// toolUseBlocked 工具调用是否会被 executeSingleTool 的预检查（参数解析、工具策略、Plan 模式）直接拦截
+func (a *Agent) toolUseBlocked(ctx context.Context, tu *types.ToolUseBlock) bool {
+	if parseError, ok := tu.Input["__parse_error__"].(bool); ok && parseError {
+		return true
+	}
+	if allowed, _ := a.checkToolPolicy(ctx, tu.Name, tu.Input); !allowed {
+		return true
+	}
+	if a.planMode != nil && a.planMode.IsActive() {
+		if allowed, _ := a.planMode.ValidateToolCall(tu.Name, tu.Input); !allowed {
+			return true
+		}
+	}
+	return false
+}
+
 // executeSingleTool 执行单个工具
-func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) types.ContentBlock {
+// 工具失败时结果以错误形式交给模型；只有工具返回 tools.ErrFatal 时才返回 error，调用方据此停止运行。
+func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) (types.ContentBlock, error) {
+	callID := a.nextToolCallID()
+
 	// 检查工具输入是否有解析错误（流式响应被截断等情况）
 	if parseError, ok := tu.Input["__parse_error__"].(bool); ok && parseError {
 		errorMsg := "工具参数解析失败"
@@ -389,12 +476,12 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 		}
 		procLog.Error(ctx, "tool input parse error detected", map[string]any{
 			"tool":  tu.Name,
-			"id":    tu.ID,
+			"id":    callID,
 			"error": errorMsg,
 		})
 		a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
 			Call: types.ToolCallSnapshot{
-				ID:        tu.ID,
+				ID:        callID,
 				Name:      tu.Name,
 				State:     types.ToolCallStateFailed,
 				Arguments: tu.Input,
@@ -405,7 +492,30 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 			ToolUseID: tu.ID,
 			Content:   fmt.Sprintf(`{"ok":false,"error":"%s","hint":"请重新调用工具，确保提供完整的参数"}`, errorMsg),
 			IsError:   true,
-		}
+		}, nil
+	}
+
+	// 运行时工具策略检查：拒绝时把结果返回给模型，而不是中断运行
+	if allowed, reason := a.checkToolPolicy(ctx, tu.Name, tu.Input); !allowed {
+		procLog.Info(ctx, "tool call rejected by tool policy", map[string]any{
+			"tool":   tu.Name,
+			"id":     callID,
+			"reason": reason,
+		})
+		a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
+			Call: types.ToolCallSnapshot{
+				ID:        callID,
+				Name:      tu.Name,
+				State:     types.ToolCallStateFailed,
+				Arguments: tu.Input,
+			},
+			Error: "tool not permitted: " + reason,
+		})
+		return &types.ToolResultBlock{
+			ToolUseID: tu.ID,
+			Content:   toolNotPermittedContent(tu.Name, reason),
+			IsError:   true,
+		}, nil
 	}
 
 	// Plan 模式检查：验证工具调用是否允许
@@ -415,7 +525,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 			errorMsg := "Plan Mode restriction: " + reason
 			a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
 				Call: types.ToolCallSnapshot{
-					ID:        tu.ID,
+					ID:        callID,
 					Name:      tu.Name,
 					State:     types.ToolCallStateFailed,
 					Arguments: tu.Input,
@@ -426,14 +536,14 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 				ToolUseID: tu.ID,
 				Content:   fmt.Sprintf(`{"ok":false,"error":"%s","plan_mode":true}`, errorMsg),
 				IsError:   true,
-			}
+			}, nil
 		}
 	}
 
 	// 权限检查
 	if a.permissionInspector != nil {
 		call := &types.ToolCallSnapshot{
-			ID:        tu.ID,
+			ID:        callID,
 			Name:      tu.Name,
 			Arguments: tu.Input,
 		}
@@ -442,7 +552,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 			errorMsg := fmt.Sprintf("Permission check error: %v", err)
 			a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
 				Call: types.ToolCallSnapshot{
-					ID:        tu.ID,
+					ID:        callID,
 					Name:      tu.Name,
 					State:     types.ToolCallStateFailed,
 					Arguments: tu.Input,
@@ -453,7 +563,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 				ToolUseID: tu.ID,
 				Content:   fmt.Sprintf(`{"ok":false,"error":"%s"}`, errorMsg),
 				IsError:   true,
-			}
+			}, nil
 		}
 
 		if checkResult != nil {
@@ -467,13 +577,13 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 					// 创建等待 channel
 					decisionCh := make(chan string, 1)
 					a.mu.Lock()
-					a.pendingPermissions[tu.ID] = decisionCh
+					a.pendingPermissions[callID] = decisionCh
 					a.mu.Unlock()
 
 					// 发送权限请求事件到 Control Channel
 					a.eventBus.EmitControl(&types.ControlPermissionRequiredEvent{
 						Call: types.ToolCallSnapshot{
-							ID:        tu.ID,
+							ID:        callID,
 							Name:      tu.Name,
 							Arguments: tu.Input,
 						},
@@ -484,7 +594,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 					case decision := <-decisionCh:
 						// 清理 pending map
 						a.mu.Lock()
-						delete(a.pendingPermissions, tu.ID)
+						delete(a.pendingPermissions, callID)
 						a.mu.Unlock()
 
 						if decision != "approved" {
@@ -494,27 +604,27 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 								ToolUseID: tu.ID,
 								Content:   fmt.Sprintf(`{"ok":false,"error":"%s"}`, errorMsg),
 								IsError:   true,
-							}
+							}, nil
 						}
 						// 用户批准，继续执行工具（跳出权限检查）
 					case <-ctx.Done():
 						// 上下文取消
 						a.mu.Lock()
-						delete(a.pendingPermissions, tu.ID)
+						delete(a.pendingPermissions, callID)
 						a.mu.Unlock()
 						errorMsg := "Permission request canceled"
 						return &types.ToolResultBlock{
 							ToolUseID: tu.ID,
 							Content:   fmt.Sprintf(`{"ok":false,"error":"%s"}`, errorMsg),
 							IsError:   true,
-						}
+						}, nil
 					}
 				} else {
 					// 直接拒绝（NeedsApproval 为 false）
 					errorMsg := fmt.Sprintf("Permission denied: %s (decided by: %s)", checkResult.Message, checkResult.DecidedBy)
 					a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
 						Call: types.ToolCallSnapshot{
-							ID:        tu.ID,
+							ID:        callID,
 							Name:      tu.Name,
 							State:     types.ToolCallStateFailed,
 							Arguments: tu.Input,
@@ -525,16 +635,17 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 						ToolUseID: tu.ID,
 						Content:   fmt.Sprintf(`{"ok":false,"error":"%s"}`, errorMsg),
 						IsError:   true,
-					}
+					}, nil
 				}
 			}
 		}
 	}
 
 	// 创建工具调用记录
-	record := tools.NewToolCallRecord(tu.ID, tu.Name, tu.Input).Build()
+	record := tools.NewToolCallRecord(callID, tu.Name, tu.Input).Build()
+	record.ToolUseID = tu.ID
 	a.mu.Lock()
-	a.toolRecords[tu.ID] = record
+	a.toolRecords[callID] = record
 	a.mu.Unlock()
 
 	// 获取工具
@@ -542,10 +653,10 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 	if !ok {
 		// 工具未找到
 		errorMsg := "tool not found: " + tu.Name
-		a.updateToolRecord(tu.ID, types.ToolCallStateFailed, errorMsg)
+		a.updateToolRecord(callID, types.ToolCallStateFailed, errorMsg)
 		a.eventBus.EmitProgress(&types.ProgressToolErrorEvent{
 			Call: types.ToolCallSnapshot{
-				ID:        tu.ID,
+				ID:        callID,
 				Name:      tu.Name,
 				State:     types.ToolCallStateFailed,
 				Arguments: tu.Input,
@@ -555,7 +666,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 		return &types.ToolResultBlock{
 			ToolUseID: tu.ID,
 			IsError:   true,
-		}
+		}, nil
 	}
 
 	startTime := time.Now()
@@ -585,28 +696,29 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 	a.setBreakpoint(types.BreakpointPreTool)
 
 	// 执行工具
-	a.updateToolRecord(tu.ID, types.ToolCallStateExecuting, "")
+	a.updateToolRecord(callID, types.ToolCallStateExecuting, "")
 	a.setBreakpoint(types.BreakpointToolExecuting)
 
 	// 构建工具执行上下文，包含必要的服务注入
 	toolCtx := a.buildToolContext(ctx)
-	toolCtx.Reporter = a.makeToolReporter(tu.ID, tu.Name)
+	toolCtx.CallID = callID
+	toolCtx.Reporter = a.makeToolReporter(callID, tu.Name)
 
 	// 兼容旧版 Emit 回调
 	toolCtx.Emit = func(eventType string, data any) {
 		switch eventType {
 		case "progress":
 			if p, ok := data.(float64); ok {
-				a.handleToolProgress(tu.ID, tu.Name, p, "", 0, 0, nil, 0)
+				a.handleToolProgress(callID, tu.Name, p, "", 0, 0, nil, 0)
 			}
 		case "intermediate":
-			a.handleToolIntermediate(tu.ID, tu.Name, "", data)
+			a.handleToolIntermediate(callID, tu.Name, "", data)
 		}
 	}
 
 	if isInterruptible {
-		a.registerRunningTool(tu.ID, interruptible)
-		defer a.unregisterRunningTool(tu.ID)
+		a.registerRunningTool(callID, interruptible)
+		defer a.unregisterRunningTool(callID)
 	}
 
 	// 通过 Middleware Stack 执行工具 (Phase 6C)
@@ -627,8 +739,8 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 				// pausable and cancelable assignments removed (ineffectual)
 			}
 			if isInterruptible {
-				a.registerRunningTool(tu.ID, interruptible)
-				defer a.unregisterRunningTool(tu.ID)
+				a.registerRunningTool(callID, interruptible)
+				defer a.unregisterRunningTool(callID)
 			}
 
 			ticker := time.NewTicker(1 * time.Second)
@@ -642,7 +754,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 				}
 
 				// 推送进度事件
-				a.handleToolProgress(tu.ID, tu.Name, status.Progress, "", 0, 0, status.Metadata, 0)
+				a.handleToolProgress(callID, tu.Name, status.Progress, "", 0, 0, status.Metadata, 0)
 
 				// 终态处理
 				if status.State.IsTerminal() {
@@ -672,14 +784,14 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 						}
 						if status.State == tools.TaskStateCancelled {
 							a.eventBus.EmitProgress(&types.ProgressToolCancelledEvent{
-								Call:   a.snapshotToolCall(tu.ID),
+								Call:   a.snapshotToolCall(callID),
 								Reason: "canceled",
 							})
 						}
 					}
 					// 更新记录时间
 					a.mu.Lock()
-					if rec, ok := a.toolRecords[tu.ID]; ok {
+					if rec, ok := a.toolRecords[callID]; ok {
 						rec.StartTime = status.StartTime
 						if status.EndTime != nil {
 							rec.CompletedAt = status.EndTime
@@ -696,7 +808,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 					execResult = &tools.ExecuteResult{Success: false, Error: ctx.Err()}
 					_ = lrTool.Cancel(context.Background(), taskID)
 					a.eventBus.EmitProgress(&types.ProgressToolCancelledEvent{
-						Call:   a.snapshotToolCall(tu.ID),
+						Call:   a.snapshotToolCall(callID),
 						Reason: "canceled",
 					})
 					goto longRunningDone
@@ -710,7 +822,8 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 	} else if a.middlewareStack != nil {
 		// 使用 middleware stack
 		req := &middleware.ToolCallRequest{
-			ToolCallID: tu.ID,
+			ToolCallID: callID,
+			ToolUseID:  tu.ID,
 			ToolName:   tu.Name,
 			ToolInput:  tu.Input,
 			Tool:       tool,
@@ -741,8 +854,11 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 				Success: false,
 				Error:   err,
 			}
+		} else if result, ok := resp.Result.(*tools.ExecuteResult); ok {
+			execResult = result
 		} else {
-			execResult = resp.Result.(*tools.ExecuteResult)
+			// 中间件未执行工具而是直接给出结果（如人工审核拒绝）
+			execResult = middlewareToolResult(resp.Result)
 		}
 	} else {
 		// 没有 middleware, 直接执行
@@ -758,42 +874,42 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 
 	// 更新记录
 	if execResult.Success {
-		a.updateToolRecord(tu.ID, types.ToolCallStateCompleted, "")
+		a.updateToolRecord(callID, types.ToolCallStateCompleted, "")
 		a.mu.Lock()
-		a.toolRecords[tu.ID].Result = execResult.Output
+		a.toolRecords[callID].Result = execResult.Output
 		if execResult.StartedAt.IsZero() {
-			a.toolRecords[tu.ID].StartedAt = &startTime
+			a.toolRecords[callID].StartedAt = &startTime
 		} else {
-			a.toolRecords[tu.ID].StartedAt = &execResult.StartedAt
+			a.toolRecords[callID].StartedAt = &execResult.StartedAt
 		}
 		if !execResult.EndedAt.IsZero() {
-			a.toolRecords[tu.ID].CompletedAt = &execResult.EndedAt
+			a.toolRecords[callID].CompletedAt = &execResult.EndedAt
 		} else {
-			a.toolRecords[tu.ID].CompletedAt = &endTime
+			a.toolRecords[callID].CompletedAt = &endTime
 		}
 		durationMs := execResult.DurationMs
 		if durationMs == 0 && !execResult.EndedAt.IsZero() {
 			durationMs = execResult.EndedAt.Sub(execResult.StartedAt).Milliseconds()
 		}
-		a.toolRecords[tu.ID].DurationMs = &durationMs
-		a.toolRecords[tu.ID].Progress = 1
+		a.toolRecords[callID].DurationMs = &durationMs
+		a.toolRecords[callID].Progress = 1
 		a.mu.Unlock()
 	} else {
 		errorMsg := ""
 		if execResult.Error != nil {
 			errorMsg = execResult.Error.Error()
 		}
-		a.updateToolRecord(tu.ID, types.ToolCallStateFailed, errorMsg)
+		a.updateToolRecord(callID, types.ToolCallStateFailed, errorMsg)
 	}
 
 	// 发送工具结束事件
 	a.mu.RLock()
-	finalRecord := a.toolRecords[tu.ID]
+	finalRecord := a.toolRecords[callID]
 	a.mu.RUnlock()
 
 	a.eventBus.EmitProgress(&types.ProgressToolEndEvent{
 		Call: types.ToolCallSnapshot{
-			ID:         tu.ID,
+			ID:         callID,
 			Name:       tu.Name,
 			State:      finalRecord.State,
 			Arguments:  finalRecord.Input,
@@ -816,18 +932,44 @@ func (a *Agent) executeSingleTool(ctx context.Context, tu *types.ToolUseBlock) t
 			ToolUseID: tu.ID,
 			Content:   fmt.Sprintf("%v", execResult.Output),
 			IsError:   false,
-		}
+		}, nil
 	} else {
 		errorMsg := ""
+		var fatalErr error
 		if execResult.Error != nil {
 			errorMsg = execResult.Error.Error()
+			if errors.Is(execResult.Error, tools.ErrFatal) {
+				fatalErr = fmt.Errorf("tool %s: %w", tu.Name, execResult.Error)
+			}
 		}
 		return &types.ToolResultBlock{
 			ToolUseID: tu.ID,
 			Content:   fmt.Sprintf(`{"ok":false,"error":"%s"}`, errorMsg),
 			IsError:   true,
+		}, fatalErr
+	}
+}
+
+// middlewareToolResult 把中间件直接给出的结果（未执行工具时）转换为执行结果
+// {"ok": false, ...} 形式的结果视为失败，取 message / error 作为错误信息。
+func middlewareToolResult(result any) *tools.ExecuteResult {
+	now := time.Now()
+	if m, ok := result.(map[string]any); ok {
+		if okValue, exists := m["ok"].(bool); exists && !okValue {
+			msg, _ := m["message"].(string)
+			if msg == "" {
+				msg, _ = m["error"].(string)
+			}
+			if msg == "" {
+				msg = "tool call rejected by middleware"
+			}
+			return &tools.ExecuteResult{Success: false, Output: m, Error: errors.New(msg), StartedAt: now, EndedAt: now}
+		}
+		if data, err := json.Marshal(m); err == nil {
+			return &tools.ExecuteResult{Success: true, Output: string(data), StartedAt: now, EndedAt: now}
 		}
 	}
+	return &tools.ExecuteResult{Success: true, Output: result, StartedAt: now, EndedAt: now}
 }
 
 // setBreakpoint 设置断点
@@ -869,10 +1011,19 @@ func (a *Agent) updateToolRecord(id string, state types.ToolCallState, errorMsg
 	})
 }
 
+// modelRequestMetadata 模型请求的初始 Metadata，携带会话标识供中间件按会话处理（如 FewShot 示例轮换）
+// Agent 的消息历史按 Agent ID 持久化，会话 ID 即 Agent ID。
+func (a *Agent) modelRequestMetadata() map[string]any {
+	return map[string]any{
+		middleware.MetadataKeySessionID: a.id,
+	}
+}
+
 // handleStreamResponse 处理流式响应(Phase 6C - 提取为独立方法以支持Middleware)
 func (a *Agent) handleStreamResponse(ctx context.Context, stream <-chan provider.StreamChunk) (types.Message, error) {
 	assistantContent := make([]types.ContentBlock, 0)
 	currentBlockIndex := -1
+	var streamUsage *provider.TokenUsage // 部分 Provider 每个块都携带累计用量，流结束后只记录最后一次
 	textBuffers := make(map[int]string)
 	inputJSONBuffers := make(map[int]string)
 	reasoningStarted := false           // 追踪是否已发送思考开始事件
@@ -903,15 +1054,38 @@ func (a *Agent) handleStreamResponse(ctx context.Context, stream <-chan provider
 		procLog.Debug(ctx, "sent initial task planning event", map[string]any{"step": a.stepCount})
 	}
 
-	for chunk := range stream {
+	for {
+		// 上下文取消（停止条件、护栏、用户中断）时立即停止消费流
+		var chunk provider.StreamChunk
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return a.abortStreamResponse(ctx, stream, assistantContent, reasoningStarted)
+		case chunk, ok = <-stream:
+		}
+		if !ok {
+			break
+		}
+
 		// 调试：打印收到的每个 chunk
 		procLog.Debug(ctx, "received stream chunk", map[string]any{
 			"type":  chunk.Type,
 			"index": chunk.Index,
 			"delta": fmt.Sprintf("%+v", chunk.Delta),
 		})
+		a.emitModelEndpoint(chunk.Endpoint)
 
 		switch chunk.Type {
+		// 请求超时（ModelConfig.RequestTimeout），Provider 已关闭流
+		case string(provider.ChunkTypeError):
+			if err := provider.TimeoutErrorOf(chunk); err != nil {
+				go func() {
+					for range stream {
+					}
+				}()
+				return types.Message{}, newChatError(ErrCancelled, "model", err)
+			}
+
 		// 处理 reasoning_delta (DeepSeek Reasoner 模型的思考过程)
 		case "reasoning_delta":
 			if delta, ok := chunk.Delta.(map[string]any); ok {
@@ -1102,11 +1276,7 @@ func (a *Agent) handleStreamResponse(ctx context.Context, stream <-chan provider
 
 		case "message_delta":
 			if chunk.Usage != nil {
-				a.eventBus.EmitMonitor(&types.MonitorTokenUsageEvent{
-					InputTokens:  chunk.Usage.InputTokens,
-					OutputTokens: chunk.Usage.OutputTokens,
-					TotalTokens:  chunk.Usage.InputTokens + chunk.Usage.OutputTokens,
-				})
+				streamUsage = chunk.Usage
 			}
 
 		// OpenAI 兼容格式：处理 text 类型（来自 OpenRouter、DeepSeek 等）
@@ -1191,14 +1361,13 @@ func (a *Agent) handleStreamResponse(ctx context.Context, stream <-chan provider
 		// OpenAI 兼容格式：处理 usage 类型
 		case "usage":
 			if chunk.Usage != nil {
-				a.eventBus.EmitMonitor(&types.MonitorTokenUsageEvent{
-					InputTokens:  chunk.Usage.InputTokens,
-					OutputTokens: chunk.Usage.OutputTokens,
-					TotalTokens:  chunk.Usage.InputTokens + chunk.Usage.OutputTokens,
-				})
+				streamUsage = chunk.Usage
 			}
 		}
 	}
+	if streamUsage != nil {
+		a.recordTokenUsage(streamUsage)
+	}
 
 	// 流式响应结束后，解析所有累积的工具输入
 	if len(inputJSONBuffers) > 0 {
@@ -1253,6 +1422,40 @@ func (a *Agent) handleStreamResponse(ctx context.Context, stream <-chan provider
 	}, nil
 }
 
+// abortStreamResponse 流式响应被取消时丢弃未完成的工具调用，返回已生成的文本和取消错误。
+// 上游流在后台继续排空，避免 Provider 的发送协程阻塞。
+func (a *Agent) abortStreamResponse(ctx context.Context, stream <-chan provider.StreamChunk, content []types.ContentBlock, reasoningStarted bool) (types.Message, error) {
+	go func() {
+		for range stream {
+		}
+	}()
+
+	partial := make([]types.ContentBlock, 0, len(content))
+	for _, block := range content {
+		switch b := block.(type) {
+		case *types.TextBlock:
+			partial = append(partial, b)
+		case *types.ToolUseBlock:
+			procLog.Info(ctx, "discarding partial tool call from canceled stream", map[string]any{"agent_id": a.id, "tool": b.Name, "id": b.ID})
+			a.eventBus.EmitProgress(&types.ProgressToolCancelledEvent{
+				Call:   types.ToolCallSnapshot{ID: b.ID, Name: b.Name, State: types.ToolCallStateCancelled},
+				Reason: "stream canceled",
+			})
+		}
+	}
+
+	if reasoningStarted {
+		a.eventBus.EmitProgress(&types.ProgressThinkChunkEndEvent{
+			Step: a.stepCount,
+		})
+	}
+
+	return types.Message{
+		Role:          types.MessageRoleAssistant,
+		ContentBlocks: partial,
+	}, newChatError(ErrCancelled, "model", ctx.Err())
+}
+
 // runNonStreamingStep 非流式执行模型步骤（快速模式）
 func (a *Agent) runNonStreamingStep(ctx context.Context) error {
 	// 准备工具Schema（包含使用示例）
@@ -1287,6 +1490,7 @@ func (a *Agent) runNonStreamingStep(ctx context.Context) error {
 	copy(messages, a.messages)
 	currentSystemPrompt := a.template.SystemPrompt
 	a.mu.RUnlock()
+	messages = a.withRetryNudge(messages)
 
 	// 创建Provider选项
 	streamOpts := &provider.StreamOptions{
@@ -1301,12 +1505,21 @@ func (a *Agent) runNonStreamingStep(ctx context.Context) error {
 	// 调用Complete API（非流式）
 	response, err := a.provider.Complete(ctx, messages, streamOpts)
 	if err != nil {
-		return fmt.Errorf("complete call failed: %w", err)
+		return classifyModelError(fmt.Errorf("complete call failed: %w", err))
+	}
+	if response.Usage != nil {
+		a.recordTokenUsage(response.Usage)
+	}
+	a.emitModelEndpoint(response.Endpoint)
+
+	// 输出为空或工具调用无效时重试本轮
+	if a.shouldRetryTurn(ctx, response.Message) {
+		return a.runNonStreamingStep(ctx)
 	}
 
 	// 添加响应消息
 	a.mu.Lock()
-	a.messages = append(a.messages, response.Message)
+	a.appendMessages(response.Message)
 	a.mu.Unlock()
 
 	// 提取工具调用从ContentBlocks
@@ -1340,7 +1553,7 @@ func (a *Agent) runNonStreamingStep(ctx context.Context) error {
 			procLog.Error(ctx, "iteration limit exceeded in non-streaming mode", map[string]any{
 				"agent_id": a.id, "iteration": currentIter, "max": maxIter,
 			})
-			return fmt.Errorf("iteration limit exceeded: %d > %d", currentIter, maxIter)
+			return newChatError(ErrRunLimit, "run", fmt.Errorf("iteration limit exceeded: %d > %d", currentIter, maxIter))
 		}
 
 		// 递归调用继续处理
@@ -1357,6 +1570,53 @@ func (a *Agent) runNonStreamingStep(ctx context.Context) error {
 	return nil
 }
 
+// newMessageID 生成消息 ID
+func newMessageID() string {
+	return "msg-" + uuid.New().String()
+}
+
+// assignMessageIDs 为缺少 ID 的消息分配 ID
+func assignMessageIDs(messages []types.Message) {
+	for i := range messages {
+		if messages[i].ID == "" {
+			messages[i].ID = newMessageID()
+		}
+	}
+}
+
+// appendMessages 分配消息 ID 后追加到历史，调用方负责加锁和持久化
+func (a *Agent) appendMessages(messages ...types.Message) {
+	assignMessageIDs(messages)
+	a.messages = append(a.messages, messages...)
+}
+
+// persistMessages 将尚未持久化的消息追加到 Store，避免每轮重写整个历史
+// 内存中发生过修剪时同步修剪 Store；调用方负责加锁
+func (a *Agent) persistMessages(ctx context.Context) error {
+	if a.savedMessages < 0 || a.savedMessages > len(a.messages) {
+		return a.saveAllMessages(ctx)
+	}
+
+	if err := a.deps.Store.AppendMessages(ctx, a.id, a.messages[a.savedMessages:]); err != nil {
+		return err
+	}
+	a.savedMessages = len(a.messages)
+
+	if a.shouldTrimMessages() && len(a.messages) >= a.config.Store.MaxMessages {
+		return a.deps.Store.TrimMessages(ctx, a.id, a.config.Store.MaxMessages)
+	}
+	return nil
+}
+
+// saveAllMessages 整体重写消息历史；调用方负责加锁
+func (a *Agent) saveAllMessages(ctx context.Context) error {
+	if err := a.deps.Store.SaveMessages(ctx, a.id, a.messages); err != nil {
+		return err
+	}
+	a.savedMessages = len(a.messages)
+	return nil
+}
+
 // shouldTrimMessages 检查是否应该修剪消息
 func (a *Agent) shouldTrimMessages() bool {
 	return a.config.Store != nil &&
@@ -1370,6 +1630,52 @@ func (a *Agent) trimMessagesInMemory(messages []types.Message, maxMessages int)
 	if len(messages) <= maxMessages {
 		return messages
 	}
+	// 被丢弃的消息中已持久化的部分不再计入，Store 侧由 persistMessages 修剪
+	if a.savedMessages > 0 {
+		a.savedMessages = max(a.savedMessages-(len(messages)-maxMessages), 0)
+	}
 	// 保留最近的 maxMessages 条消息
 	return messages[len(messages)-maxMessages:]
 }
+
+// recordTokenUsage 累计 Token 使用量（Agent 累计和当前运行累计）并发送监控事件
+func (a *Agent) recordTokenUsage(usage *provider.TokenUsage) {
+	a.mu.Lock()
+	addTokenUsage(&a.usage, usage)
+	addTokenUsage(&a.runUsage, usage)
+	a.runModelCalls++
+	a.mu.Unlock()
+
+	a.eventBus.EmitMonitor(&types.MonitorTokenUsageEvent{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.InputTokens + usage.OutputTokens,
+	})
+}
+
+// emitModelEndpoint 发送模型请求实际使用端点的监控事件，未记录端点时忽略
+func (a *Agent) emitModelEndpoint(endpoint string) {
+	if endpoint == "" {
+		return
+	}
+	a.eventBus.EmitMonitor(&types.MonitorModelEndpointEvent{Step: a.stepCount, Endpoint: endpoint})
+}
+
+// emitRunUsage 发送当前运行累计用量的监控事件
+func (a *Agent) emitRunUsage() {
+	a.mu.RLock()
+	runUsage := &types.MonitorRunUsageEvent{RunID: a.runID, ModelCalls: a.runModelCalls, Usage: a.runUsage}
+	a.mu.RUnlock()
+	a.eventBus.EmitMonitor(runUsage)
+}
+
+// addTokenUsage 将一次模型调用的用量累加到 total
+func addTokenUsage(total *types.TokenUsage, usage *provider.TokenUsage) {
+	total.InputTokens += int(usage.InputTokens)
+	total.OutputTokens += int(usage.OutputTokens)
+	total.TotalTokens += int(usage.InputTokens + usage.OutputTokens)
+	total.ReasoningTokens += int(usage.ReasoningTokens)
+	total.CachedTokens += int(usage.CachedTokens)
+	total.CacheCreationTokens += int(usage.CacheCreationTokens)
+	total.CacheReadTokens += int(usage.CacheReadTokens)
+}
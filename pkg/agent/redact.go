@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// redactToolEvent 掩码工具事件中参数、结果和错误信息里的密钥
+// 事件会进入时间线、订阅者和追踪，参数与工具执行共用同一个 map，因此返回副本而不修改原事件。
+func redactToolEvent(masker sandbox.SecretMasker, event any) any {
+	switch e := event.(type) {
+	case *types.ProgressToolStartEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		return &masked
+	case *types.ProgressToolEndEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		return &masked
+	case *types.ProgressToolErrorEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		masked.Error = masker.MaskSecrets(e.Error)
+		return &masked
+	case *types.ProgressToolCancelledEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		return &masked
+	case *types.ProgressToolProgressEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		masked.Message = masker.MaskSecrets(e.Message)
+		return &masked
+	case *types.ProgressToolIntermediateEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		masked.Data = sandbox.MaskValue(masker, e.Data)
+		return &masked
+	case *types.ControlPermissionRequiredEvent:
+		masked := *e
+		masked.Call = redactToolCall(masker, e.Call)
+		return &masked
+	}
+	return event
+}
+
+// redactToolCall 返回掩码后的工具调用快照
+func redactToolCall(masker sandbox.SecretMasker, call types.ToolCallSnapshot) types.ToolCallSnapshot {
+	if call.Arguments != nil {
+		call.Arguments = sandbox.MaskValue(masker, call.Arguments).(map[string]any)
+	}
+	call.Result = sandbox.MaskValue(masker, call.Result)
+	call.Error = masker.MaskSecrets(call.Error)
+	return call
+}
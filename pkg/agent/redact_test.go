@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/dashboard"
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_ToolEventsMaskSandboxSecrets(t *testing.T) {
+	const secretValue = "sk-event-secret"
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:           types.SandboxKindLocal,
+			WorkDir:        t.TempDir(),
+			PermissionMode: types.SandboxPermissionBypass,
+			Secrets:        []types.SandboxSecret{{Name: "ASTER_TEST_API_KEY", Value: secretValue}},
+		},
+	}, setupTestDeps(t))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	t.Cleanup(func() { _ = ag.Close() })
+
+	probe := &countingTool{name: "Probe"}
+	ag.toolMap[probe.Name()] = probe
+	events := ag.Subscribe([]types.AgentChannel{types.ChannelProgress}, nil)
+	defer ag.Unsubscribe(events)
+
+	input := map[string]any{"header": "Authorization: Bearer " + secretValue}
+	if _, err := ag.executeSingleTool(context.Background(), &types.ToolUseBlock{ID: "call-1", Name: "Probe", Input: input}); err != nil {
+		t.Fatalf("executeSingleTool failed: %v", err)
+	}
+	if input["header"] != "Authorization: Bearer "+secretValue {
+		t.Errorf("tool input must not be modified by event masking, got %v", input["header"])
+	}
+
+	var envelopes []types.AgentEventEnvelope
+	var sawStart bool
+	for len(events) > 0 {
+		env := <-events
+		envelopes = append(envelopes, env)
+		if evt, ok := env.Event.(*types.ProgressToolStartEvent); ok {
+			sawStart = true
+			if evt.Call.Arguments["header"] != "Authorization: Bearer "+sandbox.SecretMask {
+				t.Errorf("tool:start arguments should be masked, got %v", evt.Call.Arguments)
+			}
+		}
+	}
+	if !sawStart {
+		t.Fatal("expected tool:start event")
+	}
+
+	// 追踪由事件构建，序列化后不应包含密钥
+	trace := dashboard.NewTraceBuilder().BuildFromEvents(envelopes)
+	data, err := json.Marshal(map[string]any{"events": envelopes, "trace": trace})
+	if err != nil {
+		t.Fatalf("marshal events: %v", err)
+	}
+	if strings.Contains(string(data), secretValue) {
+		t.Errorf("secret leaked into events or trace: %s", data)
+	}
+}
@@ -37,7 +37,7 @@ func (a *Agent) ProcessRecovery(ctx context.Context, message string, workDir str
 
 	// 检查是否是恢复的会话（有历史消息且 Agent 是刚创建的）
 	// 判断标准：Agent 创建时间在最近 5 秒内，但有历史消息
-	isResumed := sessionState.HasHistory && time.Since(a.createdAt) < 5*time.Second
+	isResumed := sessionState.HasHistory && a.clock.Now().Sub(a.createdAt) < 5*time.Second
 	sessionState.IsResumed = isResumed
 
 	if !isResumed {
@@ -81,7 +81,7 @@ func (a *Agent) IsResumedSession() bool {
 	defer a.mu.RUnlock()
 
 	// 判断标准：Agent 创建时间在最近 5 秒内，但有历史消息
-	return len(a.messages) > 0 && time.Since(a.createdAt) < 5*time.Second
+	return len(a.messages) > 0 && a.clock.Now().Sub(a.createdAt) < 5*time.Second
 }
 
 // GetWorkDir 获取工作目录
@@ -91,3 +91,72 @@ func (a *Agent) GetWorkDir() string {
 	}
 	return ""
 }
+
+// titleUpdateTimeout 后台生成会话标题的超时时间
+const titleUpdateTimeout = 30 * time.Second
+
+// updateTitleAsync 在后台更新会话标题，不阻塞本轮运行结束
+// 使用独立的超时，不随运行上下文取消；同一时间只生成一次，Agent 关闭时取消
+func (a *Agent) updateTitleAsync(ctx context.Context) {
+	if a.deps.TitleGenerator == nil {
+		return
+	}
+	select {
+	case <-a.stopCh:
+		return
+	default:
+	}
+	if !a.titleRunning.CompareAndSwap(false, true) {
+		return
+	}
+
+	a.titleWG.Add(1)
+	go func() {
+		defer a.titleWG.Done()
+		defer a.titleRunning.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), titleUpdateTimeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-a.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		a.updateTitle(ctx)
+	}()
+}
+
+// updateTitle 按配置自动生成或更新会话标题，失败只记录日志
+func (a *Agent) updateTitle(ctx context.Context) {
+	if a.deps.TitleGenerator == nil {
+		return
+	}
+
+	a.mu.RLock()
+	messages := append([]types.Message(nil), a.messages...)
+	a.mu.RUnlock()
+
+	info, err := a.deps.Store.LoadInfo(ctx, a.id)
+	if err != nil {
+		sessionLog.Warn(ctx, "load agent info for title failed", map[string]any{"agent_id": a.id, "error": err.Error()})
+		return
+	}
+
+	updated, err := a.deps.TitleGenerator.Update(ctx, info, messages)
+	if err != nil {
+		sessionLog.Warn(ctx, "generate session title failed", map[string]any{"agent_id": a.id, "error": err.Error()})
+		return
+	}
+	if !updated {
+		return
+	}
+
+	info.MessageCount = len(messages)
+	info.UpdatedAt = a.clock.Now()
+	if err := a.deps.Store.SaveInfo(ctx, a.id, *info); err != nil {
+		sessionLog.Warn(ctx, "save session title failed", map[string]any{"agent_id": a.id, "error": err.Error()})
+	}
+}
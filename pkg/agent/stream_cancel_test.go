@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// stalledToolCallStream 流出半个工具调用后挂起，直到 release 关闭
+func stalledToolCallStream(started chan<- struct{}, release <-chan struct{}) func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+	return func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		ch := make(chan provider.StreamChunk)
+		go func() {
+			defer close(ch)
+			ch <- provider.StreamChunk{Type: "content_block_start", Index: 0, Delta: map[string]any{"type": "text"}}
+			ch <- provider.StreamChunk{Type: "content_block_delta", Index: 0, Delta: map[string]any{"type": "text_delta", "text": "Let me read it."}}
+			ch <- provider.StreamChunk{Type: "content_block_stop", Index: 0}
+			ch <- provider.StreamChunk{Type: "content_block_start", Index: 1, Delta: map[string]any{"type": "tool_use", "id": "call-1", "name": "Read"}}
+			ch <- provider.StreamChunk{Type: "content_block_delta", Index: 1, Delta: map[string]any{"type": "input_json_delta", "partial_json": `{"path": "/et`}}
+			close(started)
+			<-release // 模拟不响应取消的 Provider
+		}()
+		return ch, nil
+	}
+}
+
+func TestChat_CancelMidToolCallStream(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock", streamFunc: stalledToolCallStream(started, release)}, false)
+	events := ag.Subscribe([]types.AgentChannel{types.ChannelProgress}, nil)
+	defer ag.Unsubscribe(events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if _, err := ag.Chat(ctx, "read /etc/hosts"); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected ErrCancelled, got %v", err)
+	}
+
+	// 处理协程应在流挂起的情况下及时退出
+	deadline := time.Now().Add(2 * time.Second)
+	for ag.Status().State != types.AgentStateReady {
+		if time.Now().After(deadline) {
+			t.Fatal("agent did not stop consuming the stream after cancellation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ag.mu.RLock()
+	lastErr := ag.lastErr
+	toolRecords := len(ag.toolRecords)
+	var toolUses int
+	for _, msg := range ag.messages {
+		for _, block := range msg.ContentBlocks {
+			if _, ok := block.(*types.ToolUseBlock); ok {
+				toolUses++
+			}
+		}
+	}
+	ag.mu.RUnlock()
+
+	assertChatError(t, lastErr, ErrCancelled, "model")
+	if toolRecords != 0 || toolUses != 0 {
+		t.Errorf("partial tool call must not be recorded or executed, got %d records and %d tool uses", toolRecords, toolUses)
+	}
+
+	var discarded, interrupted bool
+	for len(events) > 0 {
+		env := <-events
+		switch evt := env.Event.(type) {
+		case *types.ProgressToolStartEvent:
+			t.Errorf("tool %s must not start", evt.Call.Name)
+		case *types.ProgressToolCancelledEvent:
+			discarded = evt.Call.ID == "call-1"
+		case *types.ProgressDoneEvent:
+			interrupted = evt.Reason == "interrupted"
+		}
+	}
+	if !discarded {
+		t.Error("expected tool:canceled event for the partial tool call")
+	}
+	if !interrupted {
+		t.Error("expected done event with reason interrupted")
+	}
+}
+
+// stalledOpenAIToolCallStream 以 OpenAI 格式流出半个工具调用后挂起，直到 release 关闭
+func stalledOpenAIToolCallStream(started chan<- struct{}, release <-chan struct{}) func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+	return func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		ch := make(chan provider.StreamChunk)
+		go func() {
+			defer close(ch)
+			ch <- provider.StreamChunk{Type: "tool_call", ToolCall: &provider.ToolCallDelta{Index: 0, ID: "call-1", Name: "Shell", ArgumentsDelta: `{"command": "rm`}}
+			close(started)
+			<-release
+		}()
+		return ch, nil
+	}
+}
+
+func TestStream_CancelMidToolCallStream(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock", streamFunc: stalledOpenAIToolCallStream(started, release)}, false)
+	ag.middlewareStack = nil
+	shell := &countingTool{name: "Shell"}
+	ag.toolMap[shell.Name()] = shell
+	events := ag.Subscribe([]types.AgentChannel{types.ChannelProgress}, nil)
+	defer ag.Unsubscribe(events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := StreamCollect(ag.Stream(ctx, "clean up"))
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrCancelled) {
+			t.Fatalf("expected ErrCancelled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not stop consuming the provider after cancellation")
+	}
+
+	if n := shell.calls.Load(); n != 0 {
+		t.Errorf("partial tool call must not execute, ran %d times", n)
+	}
+	ag.mu.RLock()
+	lastErr := ag.lastErr
+	ag.mu.RUnlock()
+	assertChatError(t, lastErr, ErrCancelled, "model")
+
+	var discarded bool
+	for len(events) > 0 {
+		env := <-events
+		if evt, ok := env.Event.(*types.ProgressToolCancelledEvent); ok && evt.Call.ID == "call-1" {
+			discarded = true
+		}
+	}
+	if !discarded {
+		t.Error("expected tool:canceled event for the partial tool call")
+	}
+}
+
+func TestStream_DropsToolCallWithTruncatedArguments(t *testing.T) {
+	mock := &MockProvider{
+		name: "mock",
+		streamFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+			ch := make(chan provider.StreamChunk, 1)
+			ch <- provider.StreamChunk{Type: "tool_call", ToolCall: &provider.ToolCallDelta{Index: 0, ID: "call-1", Name: "Shell", ArgumentsDelta: `{"command": "rm`}}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, "", mock, false)
+	ag.middlewareStack = nil
+	shell := &countingTool{name: "Shell"}
+	ag.toolMap[shell.Name()] = shell
+
+	if _, err := StreamCollect(ag.Stream(context.Background(), "clean up")); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if n := shell.calls.Load(); n != 0 {
+		t.Errorf("tool call with truncated arguments must not execute, ran %d times", n)
+	}
+}
@@ -102,8 +102,15 @@ func (a *Agent) Stream(ctx context.Context, message string, opts ...Option) *str
 
 		// 5. 入队消息
 		a.mu.Lock()
-		a.messages = append(a.messages, userMsg)
+		a.appendMessages(userMsg)
+		a.lastErr = nil
+		a.runID = newRunID()
+		a.toolCallSeq = 0
+		a.runUsage = types.TokenUsage{}
+		a.runModelCalls = 0
 		a.mu.Unlock()
+		// 无论正常结束、出错还是客户端取消，都发送本次运行的累计用量
+		defer a.emitRunUsage()
 
 		// 6. 持久化消息
 		if err := a.persistMessage(ctx, &userMsg); err != nil {
@@ -134,7 +141,9 @@ func (a *Agent) Stream(ctx context.Context, message string, opts ...Option) *str
 		for {
 			select {
 			case <-ctx.Done():
-				writer.Send(nil, ctx.Err())
+				err := newChatError(ErrCancelled, "run", ctx.Err())
+				a.setLastErr(err)
+				writer.Send(nil, err)
 				return
 			default:
 			}
@@ -142,6 +151,7 @@ func (a *Agent) Stream(ctx context.Context, message string, opts ...Option) *str
 			// 执行流式模型推理
 			done, err := a.runModelStepStreaming(ctx, writer)
 			if err != nil {
+				a.setLastErr(err)
 				writer.Send(nil, fmt.Errorf("model step: %w", err))
 				return
 			}
@@ -263,6 +273,13 @@ type streamConfig struct {
 // Option 流式执行选项
 type Option func(*streamConfig)
 
+// setLastErr 记录运行失败的原因，与 Chat 路径一致，取消时为 ErrCancelled
+func (a *Agent) setLastErr(err error) {
+	a.mu.Lock()
+	a.lastErr = err
+	a.mu.Unlock()
+}
+
 // validateMessage 验证消息
 func (a *Agent) validateMessage(message string) error {
 	if message == "" {
@@ -329,8 +346,9 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 			Messages:     messages,
 			SystemPrompt: a.template.SystemPrompt,
 			Tools:        toolList,
-			Metadata:     make(map[string]any),
+			Metadata:     a.modelRequestMetadata(),
 		}
+		compaction := withHistoryCompactor(req)
 
 		// 创建适配器处理provider调用
 		finalHandler := func(ctx context.Context, req *middleware.ModelRequest) (*middleware.ModelResponse, error) {
@@ -349,7 +367,10 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 				Tools:       toolSchemas,
 				System:      req.SystemPrompt,
 				Temperature: 0.7,
+
+				CoalesceWindow: a.streamCoalesceWindow(),
 			}
+			req.ApplyStreamOptions(streamOpts)
 
 			// 调用Provider - 使用Stream方法支持流式响应
 			streamLog.Debug(ctx, "calling provider.Stream() for middleware", nil)
@@ -357,6 +378,7 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 			if err != nil {
 				return nil, err
 			}
+			chunkCh = provider.CoalesceStream(ctx, chunkCh, streamOpts)
 
 			// 直接将流式响应发送到WebSocket，但这里先收集用于兼容旧逻辑
 			streamLog.Debug(ctx, "starting to collect chunks from provider", nil)
@@ -364,8 +386,34 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 			var contentBlocks []types.ContentBlock
 
 			var reasoningContent strings.Builder
-			for chunk := range chunkCh {
+			var streamUsage *provider.TokenUsage
+			for {
+				// 上下文取消时立即停止消费流，已收集的内容丢弃
+				var chunk provider.StreamChunk
+				var ok bool
+				select {
+				case <-ctx.Done():
+					drainStream(chunkCh)
+					if streamUsage != nil {
+						a.recordTokenUsage(streamUsage)
+					}
+					return nil, newChatError(ErrCancelled, "model", ctx.Err())
+				case chunk, ok = <-chunkCh:
+				}
+				if !ok {
+					break
+				}
+
 				streamLog.Debug(ctx, "middleware chunk", map[string]any{"type": chunk.Type, "text_delta": truncate(chunk.TextDelta, 30)})
+				a.emitModelEndpoint(chunk.Endpoint)
+
+				if err := provider.TimeoutErrorOf(chunk); err != nil {
+					return nil, err
+				}
+				// 用量可能在多个块中累计上报，只取最后一次
+				if chunk.Usage != nil {
+					streamUsage = chunk.Usage
+				}
 
 				switch chunk.Type {
 				// OpenAI 兼容格式 - 直接文本类型
@@ -397,6 +445,9 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 					}
 				}
 			}
+			if streamUsage != nil {
+				a.recordTokenUsage(streamUsage)
+			}
 			if reasoningContent.Len() > 0 {
 				streamLog.Debug(ctx, "total reasoning content", map[string]any{"chars": reasoningContent.Len()})
 			}
@@ -412,6 +463,7 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 		}
 
 		resp, err = a.middlewareStack.ExecuteModelCall(ctx, req, finalHandler)
+		a.applyHistoryCompaction(ctx, compaction)
 	} else {
 		streamLog.Debug(ctx, "using direct provider call (no middleware)", nil)
 		// 转换工具定义
@@ -442,11 +494,53 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 		var toolCalls []types.ToolCall
 		var currentToolCall *types.ToolCall
 		var argumentsBuilder strings.Builder
+		// OpenAI 兼容格式的工具调用按 index 累积参数
+		openAICalls := make(map[int]*types.ToolCall)
+		openAIArgs := make(map[int]*strings.Builder)
+		var openAIOrder []int
+
+		var streamUsage *provider.TokenUsage
+		// 客户端中途取消时同样计入已消耗的用量
+		defer func() {
+			if streamUsage != nil {
+				a.recordTokenUsage(streamUsage)
+			}
+		}()
 
 		streamLog.Debug(ctx, "starting to process stream response", nil)
 
-		for chunk := range chunkCh {
+		for {
+			// 上下文取消（停止条件、护栏、用户中断）时立即停止消费流，丢弃未完成的工具调用
+			var chunk provider.StreamChunk
+			var ok bool
+			select {
+			case <-ctx.Done():
+				drainStream(chunkCh)
+				partial := make([]types.ToolCall, 0, len(openAIOrder)+1)
+				if currentToolCall != nil {
+					partial = append(partial, *currentToolCall)
+				}
+				for _, index := range openAIOrder {
+					partial = append(partial, *openAICalls[index])
+				}
+				a.discardPartialToolCalls(ctx, partial, "stream canceled")
+				return false, newChatError(ErrCancelled, "model", ctx.Err())
+			case chunk, ok = <-chunkCh:
+			}
+			if !ok {
+				break
+			}
+
 			streamLog.Debug(ctx, "processing chunk", map[string]any{"type": chunk.Type, "index": chunk.Index, "text_delta": truncate(chunk.TextDelta, 50)})
+			a.emitModelEndpoint(chunk.Endpoint)
+
+			if err := provider.TimeoutErrorOf(chunk); err != nil {
+				return false, err
+			}
+			// 用量可能在多个块中累计上报，只取最后一次
+			if chunk.Usage != nil {
+				streamUsage = chunk.Usage
+			}
 
 			switch chunk.Type {
 			// OpenAI 兼容格式 - 直接文本类型
@@ -547,26 +641,58 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 						}
 					}
 				}
+			// OpenAI 兼容格式 - 工具调用（完整或分片）
+			case "tool_call":
+				if tc := chunk.ToolCall; tc != nil {
+					call, ok := openAICalls[tc.Index]
+					if !ok {
+						call = &types.ToolCall{}
+						openAICalls[tc.Index] = call
+						openAIArgs[tc.Index] = &strings.Builder{}
+						openAIOrder = append(openAIOrder, tc.Index)
+					}
+					if call.ID == "" {
+						call.ID = tc.ID
+					}
+					if call.Name == "" {
+						call.Name = tc.Name
+					}
+					openAIArgs[tc.Index].WriteString(tc.ArgumentsDelta)
+				}
 			case "message_delta":
 				// 消息结束，处理完整的工具调用
 				if currentToolCall != nil && argumentsBuilder.Len() > 0 {
 					argsStr := argumentsBuilder.String()
 					streamLog.Debug(ctx, "tool call completed", map[string]any{"args": truncate(argsStr, 200)})
 
-					// 解析JSON参数
+					// 解析JSON参数，参数不完整的工具调用直接丢弃，不以空参数执行
 					var input map[string]any
 					if err := json.Unmarshal([]byte(argsStr), &input); err != nil {
 						streamLog.Warn(ctx, "failed to parse tool arguments", map[string]any{"error": err})
-						input = make(map[string]any)
+						a.discardPartialToolCalls(ctx, []types.ToolCall{*currentToolCall}, "invalid tool arguments")
+					} else {
+						currentToolCall.Arguments = input
+						toolCalls = append(toolCalls, *currentToolCall)
 					}
-
-					currentToolCall.Arguments = input
-					toolCalls = append(toolCalls, *currentToolCall)
 					currentToolCall = nil
 				}
 			}
 		}
 
+		for _, index := range openAIOrder {
+			call := openAICalls[index]
+			input := make(map[string]any)
+			if args := openAIArgs[index].String(); strings.TrimSpace(args) != "" {
+				if err := json.Unmarshal([]byte(args), &input); err != nil {
+					streamLog.Warn(ctx, "failed to parse tool arguments", map[string]any{"tool": call.Name, "error": err})
+					a.discardPartialToolCalls(ctx, []types.ToolCall{*call}, "invalid tool arguments")
+					continue
+				}
+			}
+			call.Arguments = input
+			toolCalls = append(toolCalls, *call)
+		}
+
 		// 构建最终消息
 		assistantMessage.ContentBlocks = contentBlocks
 		assistantMessage.Role = types.RoleAssistant
@@ -586,7 +712,7 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 
 	// 3. 处理响应
 	a.mu.Lock()
-	a.messages = append(a.messages, resp.Message)
+	a.appendMessages(resp.Message)
 	a.mu.Unlock()
 
 	// 4. 检查是否有工具调用
@@ -638,51 +764,63 @@ func (a *Agent) runModelStepStreaming(ctx context.Context, writer *stream.Writer
 }
 
 // executeToolCalls 执行工具调用
+// 与 Chat 路径一样逐个经过 executeSingleTool，工具策略、Plan 模式、权限检查和工具中间件同样生效
 func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []types.ToolCall) error {
 	results := make([]types.Message, len(toolCalls))
 
+	var toolErr error
 	for i, call := range toolCalls {
-		tool, ok := a.toolMap[call.Name]
-		if !ok {
-			results[i] = types.Message{
-				Role:       types.RoleTool,
-				ToolCallID: call.ID,
-				Content:    fmt.Sprintf("Error: tool '%s' not found", call.Name),
-			}
-			continue
+		block, err := a.executeSingleTool(ctx, &types.ToolUseBlock{
+			ID:    call.ID,
+			Name:  call.Name,
+			Input: call.Arguments,
+		})
+		if err != nil && toolErr == nil {
+			toolErr = err
 		}
 
-		// 执行工具
-		req := &tools.ExecuteRequest{
-			Tool:    tool,
-			Input:   call.Arguments,
-			Context: a.buildToolContext(ctx),
-		}
-		execResult := a.executor.Execute(ctx, req)
-		if execResult.Error != nil {
-			results[i] = types.Message{
-				Role:       types.RoleTool,
-				ToolCallID: call.ID,
-				Content:    fmt.Sprintf("Error: %v", execResult.Error),
-			}
-			continue
+		result := block.(*types.ToolResultBlock)
+		if result.IsError && result.Content == "" {
+			result.Content = fmt.Sprintf("Error: tool '%s' not found", call.Name)
 		}
-
 		results[i] = types.Message{
 			Role:       types.RoleTool,
 			ToolCallID: call.ID,
-			Content:    fmt.Sprint(execResult.Output),
+			Content:    result.Content,
 		}
 	}
 
 	// 追加工具结果到消息历史
 	a.mu.Lock()
-	a.messages = append(a.messages, results...)
+	a.appendMessages(results...)
 	a.mu.Unlock()
 
+	// 工具返回不可恢复的错误时停止运行，结果已保存
+	if toolErr != nil {
+		return newChatError(ErrToolFailed, "tool", toolErr)
+	}
 	return nil
 }
 
+// discardPartialToolCalls 丢弃未完整接收的工具调用并通知前端，这些调用不会被执行
+func (a *Agent) discardPartialToolCalls(ctx context.Context, calls []types.ToolCall, reason string) {
+	for _, call := range calls {
+		streamLog.Info(ctx, "discarding partial tool call", map[string]any{"agent_id": a.id, "tool": call.Name, "id": call.ID, "reason": reason})
+		a.eventBus.EmitProgress(&types.ProgressToolCancelledEvent{
+			Call:   types.ToolCallSnapshot{ID: call.ID, Name: call.Name, State: types.ToolCallStateCancelled},
+			Reason: reason,
+		})
+	}
+}
+
+// drainStream 在后台排空被放弃的上游流，避免 Provider 的发送协程阻塞
+func drainStream(ch <-chan provider.StreamChunk) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
+
 // getToolsForProvider 获取 Provider 格式的工具定义
 func (a *Agent) getToolsForProvider() []types.ToolDefinition {
 	tools := make([]types.ToolDefinition, 0, len(a.toolMap))
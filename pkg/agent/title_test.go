@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/session"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestAgent_GeneratesSessionTitle(t *testing.T) {
+	deps := setupTestDeps(t)
+	titleMock := &MockProvider{name: "title", completeFunc: func(_ context.Context, messages []types.Message, _ *provider.StreamOptions) (*provider.CompleteResponse, error) {
+		if !strings.Contains(messages[0].GetContent(), "flaky login test") {
+			t.Errorf("title prompt should include the conversation, got %q", messages[0].GetContent())
+		}
+		return &provider.CompleteResponse{Message: types.Message{
+			Role:    types.MessageRoleAssistant,
+			Content: `{"title": "Fix flaky login test", "tags": ["testing", "auth"]}`,
+		}}, nil
+	}}
+	deps.TitleGenerator = session.NewTitleGenerator(session.TitleConfig{Provider: titleMock, EveryNTurns: 2})
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		AgentID:    "title-agent",
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	defer func() { _ = ag.Close() }()
+	ag.provider = &MockProvider{name: "mock"}
+
+	if _, err := ag.Chat(context.Background(), "Why does the flaky login test fail on CI?"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	ag.titleWG.Wait()
+
+	info, err := deps.Store.LoadInfo(context.Background(), ag.ID())
+	if err != nil {
+		t.Fatalf("load info: %v", err)
+	}
+	title, tags := session.TitleFromInfo(info)
+	if title != "Fix flaky login test" {
+		t.Errorf("expected generated title to be stored, got %q", title)
+	}
+	if len(tags) != 2 || tags[0] != "testing" || tags[1] != "auth" {
+		t.Errorf("expected stored tags [testing auth], got %v", tags)
+	}
+}
+
+func TestAgent_TitleGenerationDoesNotBlockRun(t *testing.T) {
+	deps := setupTestDeps(t)
+	started := make(chan struct{})
+	titleMock := &MockProvider{name: "title", completeFunc: func(ctx context.Context, _ []types.Message, _ *provider.StreamOptions) (*provider.CompleteResponse, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	deps.TitleGenerator = session.NewTitleGenerator(session.TitleConfig{Provider: titleMock, EveryNTurns: 2})
+
+	ag, err := Create(context.Background(), &types.AgentConfig{
+		AgentID:    "slow-title-agent",
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind:    types.SandboxKindMock,
+			WorkDir: "/tmp/test",
+		},
+	}, deps)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ag.provider = &MockProvider{name: "mock"}
+
+	// 标题生成一直阻塞，Chat 仍然返回
+	if _, err := ag.Chat(context.Background(), "Why does the flaky login test fail on CI?"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	<-started
+
+	// 关闭 Agent 时取消后台的标题生成
+	closed := make(chan struct{})
+	go func() {
+		_ = ag.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close should cancel the pending title generation")
+	}
+}
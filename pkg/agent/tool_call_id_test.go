@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// callIDProbeTool 记录 ToolContext.CallID 的测试工具
+type callIDProbeTool struct {
+	seen []string
+}
+
+func (t *callIDProbeTool) Name() string                { return "Probe" }
+func (t *callIDProbeTool) Description() string         { return "records its call id" }
+func (t *callIDProbeTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (t *callIDProbeTool) Prompt() string              { return "" }
+
+func (t *callIDProbeTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	t.seen = append(t.seen, tc.CallID)
+	return map[string]any{"ok": true}, nil
+}
+
+func TestAgent_ToolCallIDCorrelation(t *testing.T) {
+	var results []*types.ToolResultBlock
+	mock := planScriptProvider(map[string]*types.ToolUseBlock{
+		"probe twice":   {ID: "toolu_model_1", Name: "Probe", Input: map[string]any{}},
+		"toolu_model_1": {ID: "toolu_model_2", Name: "Probe", Input: map[string]any{}},
+	}, &results)
+
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	probe := &callIDProbeTool{}
+	ag.toolMap[probe.Name()] = probe
+	progress := ag.Subscribe([]types.AgentChannel{types.ChannelProgress}, nil)
+
+	if _, err := ag.Chat(context.Background(), "probe twice"); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	if len(probe.seen) != 2 {
+		t.Fatalf("expected 2 tool calls, got %v", probe.seen)
+	}
+	pattern := regexp.MustCompile(`^run-[0-9a-f]{12}-tc-(\d+)$`)
+	first := pattern.FindStringSubmatch(probe.seen[0])
+	if first == nil || first[1] != "1" {
+		t.Fatalf("unexpected call id %q", probe.seen[0])
+	}
+	if want := probe.seen[0][:len(probe.seen[0])-1] + "2"; probe.seen[1] != want {
+		t.Errorf("expected monotonic id %q, got %q", want, probe.seen[1])
+	}
+	callID := probe.seen[0]
+
+	var startID, endID string
+	timeout := time.After(time.Second)
+	for startID == "" || endID == "" {
+		select {
+		case env := <-progress:
+			switch e := env.Event.(type) {
+			case *types.ProgressToolStartEvent:
+				if startID == "" {
+					startID = e.Call.ID
+				}
+			case *types.ProgressToolEndEvent:
+				if endID == "" {
+					endID = e.Call.ID
+				}
+			}
+		case <-timeout:
+			t.Fatalf("missing tool events, start=%q end=%q", startID, endID)
+		}
+	}
+	if startID != callID || endID != callID {
+		t.Errorf("events should carry call id %q, got start=%q end=%q", callID, startID, endID)
+	}
+
+	records, err := ag.deps.Store.LoadToolCallRecords(context.Background(), ag.ID())
+	if err != nil {
+		t.Fatalf("load records: %v", err)
+	}
+	var record *types.ToolCallRecord
+	for i := range records {
+		if records[i].ID == callID {
+			record = &records[i]
+		}
+	}
+	if record == nil || record.ToolUseID != "toolu_model_1" {
+		t.Errorf("expected persisted record %q linked to model id, got %+v", callID, record)
+	}
+
+	// 工具结果仍使用模型返回的 ID，保证与消息历史配对
+	if len(results) == 0 || results[0].ToolUseID != "toolu_model_1" {
+		t.Errorf("tool result should keep the model tool_use id, got %+v", results)
+	}
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// ToolPredicate 工具调用判定函数
+// 返回 false 时拒绝本次调用，reason 会作为拒绝原因返回给模型
+type ToolPredicate func(ctx context.Context, toolName string, input map[string]any) (allowed bool, reason string)
+
+// ToolPolicy 运行时工具策略
+// 在每次工具执行前评估，可在对话过程中随时替换，用于逐步收紧权限
+// （例如在高风险步骤后禁用 Bash），无需重建 Agent。
+//
+// 评估顺序: Deny -> Allow -> Predicates
+type ToolPolicy struct {
+	// Allow 允许的工具列表，为空表示不限制
+	Allow []string
+
+	// Deny 拒绝的工具列表，优先级高于 Allow
+	Deny []string
+
+	// Predicates 按工具名注册的判定函数，"*" 对所有工具生效
+	Predicates map[string]ToolPredicate
+}
+
+// NewToolPolicy 创建空的工具策略（允许所有工具）
+func NewToolPolicy() *ToolPolicy {
+	return &ToolPolicy{
+		Predicates: make(map[string]ToolPredicate),
+	}
+}
+
+// WithAllow 设置允许列表
+func (p *ToolPolicy) WithAllow(names ...string) *ToolPolicy {
+	p.Allow = append(p.Allow, names...)
+	return p
+}
+
+// WithDeny 添加拒绝的工具
+func (p *ToolPolicy) WithDeny(names ...string) *ToolPolicy {
+	p.Deny = append(p.Deny, names...)
+	return p
+}
+
+// WithPredicate 为工具注册判定函数，toolName 为 "*" 时对所有工具生效
+func (p *ToolPolicy) WithPredicate(toolName string, predicate ToolPredicate) *ToolPolicy {
+	if p.Predicates == nil {
+		p.Predicates = make(map[string]ToolPredicate)
+	}
+	p.Predicates[toolName] = predicate
+	return p
+}
+
+// Check 检查工具调用是否被允许
+func (p *ToolPolicy) Check(ctx context.Context, toolName string, input map[string]any) (bool, string) {
+	if p == nil {
+		return true, ""
+	}
+
+	if slices.Contains(p.Deny, toolName) {
+		return false, "tool is in the deny list"
+	}
+
+	if len(p.Allow) > 0 && !slices.Contains(p.Allow, toolName) {
+		return false, "tool is not in the allow list"
+	}
+
+	for _, key := range []string{"*", toolName} {
+		predicate, ok := p.Predicates[key]
+		if !ok || predicate == nil {
+			continue
+		}
+		if allowed, reason := predicate(ctx, toolName, input); !allowed {
+			if reason == "" {
+				reason = "rejected by tool policy"
+			}
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
+
+// SetToolPolicy 设置运行时工具策略，传入 nil 取消限制
+// 新策略对之后的所有工具调用生效（包括当前对话中尚未执行的调用）
+func (a *Agent) SetToolPolicy(policy *ToolPolicy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.toolPolicy = policy
+}
+
+// GetToolPolicy 获取当前工具策略
+func (a *Agent) GetToolPolicy() *ToolPolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.toolPolicy
+}
+
+// checkToolPolicy 根据当前工具策略检查工具调用
+func (a *Agent) checkToolPolicy(ctx context.Context, toolName string, input map[string]any) (bool, string) {
+	return a.GetToolPolicy().Check(ctx, toolName, input)
+}
+
+// toolNotPermittedContent 构建返回给模型的拒绝结果，便于模型调整后续行动
+func toolNotPermittedContent(toolName, reason string) string {
+	data, _ := json.Marshal(map[string]any{
+		"ok":          false,
+		"error":       "tool not permitted: " + toolName,
+		"reason":      reason,
+		"tool_policy": true,
+		"hint":        "该工具当前被策略禁用，请改用其他可用工具完成任务",
+	})
+	return string(data)
+}
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func newToolPolicyTestAgent(t *testing.T) *Agent {
+	t.Helper()
+
+	config := &types.AgentConfig{
+		TemplateID: "test-template",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "test-key",
+		},
+		Tools: []string{"Bash", "Read"},
+		Sandbox: &types.SandboxConfig{
+			Kind:           types.SandboxKindMock,
+			WorkDir:        "/tmp/test",
+			PermissionMode: types.SandboxPermissionBypass,
+		},
+	}
+
+	ag, err := Create(context.Background(), config, setupTestDeps(t))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	t.Cleanup(func() { _ = ag.Close() })
+	return ag
+}
+
+func runPolicyToolCall(t *testing.T, ag *Agent, id, name string, input map[string]any) *types.ToolResultBlock {
+	t.Helper()
+	block, err := ag.executeSingleTool(context.Background(), &types.ToolUseBlock{ID: id, Name: name, Input: input})
+	result, ok := block.(*types.ToolResultBlock)
+	if err != nil || !ok {
+		t.Fatalf("expected ToolResultBlock for %s", name)
+	}
+	return result
+}
+
+func isPolicyRejection(result *types.ToolResultBlock) bool {
+	return result.IsError && strings.Contains(result.Content, "tool not permitted")
+}
+
+func TestAgent_SetToolPolicy_DisableBashMidConversation(t *testing.T) {
+	ag := newToolPolicyTestAgent(t)
+	bashInput := map[string]any{"command": "echo hello"}
+	readInput := map[string]any{"file_path": "/tmp/test/a.txt"}
+
+	// 策略生效前 Bash 可以执行
+	if result := runPolicyToolCall(t, ag, "call-1", "Bash", bashInput); isPolicyRejection(result) {
+		t.Fatalf("Bash should be permitted before policy is set: %v", result.Content)
+	}
+
+	ag.SetToolPolicy(NewToolPolicy().WithDeny("Bash"))
+
+	result := runPolicyToolCall(t, ag, "call-2", "Bash", bashInput)
+	if !isPolicyRejection(result) {
+		t.Fatalf("Bash should be refused after policy is set, got: %v", result.Content)
+	}
+	if !strings.Contains(result.Content, `"tool_policy":true`) {
+		t.Errorf("rejection should be marked as tool policy result, got: %s", result.Content)
+	}
+
+	if result := runPolicyToolCall(t, ag, "call-3", "Read", readInput); isPolicyRejection(result) {
+		t.Errorf("Read should still be permitted, got: %v", result.Content)
+	}
+
+	if _, err := ag.ExecuteToolDirect(context.Background(), "Bash", bashInput); err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Errorf("ExecuteToolDirect should also respect the policy, got err=%v", err)
+	}
+
+	// 取消策略后恢复
+	ag.SetToolPolicy(nil)
+	if result := runPolicyToolCall(t, ag, "call-4", "Bash", bashInput); isPolicyRejection(result) {
+		t.Errorf("Bash should be permitted after policy is cleared: %v", result.Content)
+	}
+}
+
+func TestToolPolicy_Check(t *testing.T) {
+	ctx := context.Background()
+
+	policy := NewToolPolicy().
+		WithAllow("Read", "Bash").
+		WithPredicate("Bash", func(ctx context.Context, toolName string, input map[string]any) (bool, string) {
+			cmd, _ := input["command"].(string)
+			if strings.Contains(cmd, "rm ") {
+				return false, "destructive command"
+			}
+			return true, ""
+		})
+
+	if ok, _ := policy.Check(ctx, "Read", nil); !ok {
+		t.Error("Read should be allowed")
+	}
+	if ok, _ := policy.Check(ctx, "Write", nil); ok {
+		t.Error("Write is not in the allow list")
+	}
+	if ok, _ := policy.Check(ctx, "Bash", map[string]any{"command": "ls"}); !ok {
+		t.Error("safe Bash command should be allowed")
+	}
+	if ok, reason := policy.Check(ctx, "Bash", map[string]any{"command": "rm -rf build"}); ok || reason != "destructive command" {
+		t.Errorf("destructive Bash command should be rejected, got ok=%v reason=%q", ok, reason)
+	}
+
+	policy.WithDeny("Read")
+	if ok, _ := policy.Check(ctx, "Read", nil); ok {
+		t.Error("deny list should take precedence over allow list")
+	}
+
+	var nilPolicy *ToolPolicy
+	if ok, _ := nilPolicy.Check(ctx, "Anything", nil); !ok {
+		t.Error("nil policy should allow everything")
+	}
+}
+
+// countingTool 记录执行次数的测试工具
+type countingTool struct {
+	name  string
+	calls atomic.Int32
+}
+
+func (t *countingTool) Name() string                { return t.name }
+func (t *countingTool) Description() string         { return "counts its executions" }
+func (t *countingTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (t *countingTool) Prompt() string              { return "" }
+
+func (t *countingTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	t.calls.Add(1)
+	return "ok", nil
+}
+
+func TestAgent_SetToolPolicy_Stream(t *testing.T) {
+	var calls atomic.Int32
+	mock := &MockProvider{
+		name: "mock",
+		streamFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+			ch := make(chan provider.StreamChunk, 2)
+			if calls.Add(1) == 1 {
+				ch <- provider.StreamChunk{Type: "tool_call", ToolCall: &provider.ToolCallDelta{Index: 0, ID: "call-1", Name: "Shell", ArgumentsDelta: `{"command":"rm -rf build"}`}}
+				ch <- provider.StreamChunk{Type: "tool_call", ToolCall: &provider.ToolCallDelta{Index: 1, ID: "call-2", Name: "Lookup", ArgumentsDelta: `{}`}}
+			} else {
+				ch <- provider.StreamChunk{Type: "text", TextDelta: "done"}
+			}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, "", mock, false)
+	ag.middlewareStack = nil
+	shell := &countingTool{name: "Shell"}
+	lookup := &countingTool{name: "Lookup"}
+	ag.toolMap[shell.Name()] = shell
+	ag.toolMap[lookup.Name()] = lookup
+	ag.SetToolPolicy(NewToolPolicy().WithDeny("Shell"))
+
+	if _, err := StreamCollect(ag.Stream(context.Background(), "clean up")); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	if n := shell.calls.Load(); n != 0 {
+		t.Errorf("denied tool must not execute, ran %d times", n)
+	}
+	if n := lookup.calls.Load(); n != 1 {
+		t.Errorf("permitted tool should execute once, ran %d times", n)
+	}
+
+	ag.mu.RLock()
+	defer ag.mu.RUnlock()
+	var refused bool
+	for _, msg := range ag.messages {
+		if msg.Role == types.RoleTool && msg.ToolCallID == "call-1" {
+			refused = strings.Contains(msg.Content, "tool not permitted")
+		}
+	}
+	if !refused {
+		t.Error("expected tool not permitted result for the denied call")
+	}
+}
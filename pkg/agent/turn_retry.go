@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// 整轮重试原因
+const (
+	turnRetryEmptyOutput     = "empty_output"
+	turnRetryInvalidToolCall = "invalid_tool_call"
+)
+
+// turnRetryNudges 重试时追加给模型的提示
+var turnRetryNudges = map[string]string{
+	turnRetryEmptyOutput:     "Your previous response was empty. Please respond to the request above.",
+	turnRetryInvalidToolCall: "Your previous response contained a tool call with invalid arguments that could not be parsed. Please respond again with valid JSON arguments.",
+}
+
+// invalidOutputReason 判断助手消息是否需要整轮重试，有效时返回空字符串
+func invalidOutputReason(msg types.Message) string {
+	hasToolUse := false
+	for _, block := range msg.ContentBlocks {
+		if tu, ok := block.(*types.ToolUseBlock); ok {
+			hasToolUse = true
+			if parseErr, _ := tu.Input["__parse_error__"].(bool); parseErr {
+				return turnRetryInvalidToolCall
+			}
+		}
+	}
+	if hasToolUse || strings.TrimSpace(msg.Content) != "" {
+		return ""
+	}
+	for _, block := range msg.ContentBlocks {
+		if tb, ok := block.(*types.TextBlock); ok && strings.TrimSpace(tb.Text) != "" {
+			return ""
+		}
+	}
+	return turnRetryEmptyOutput
+}
+
+// maxTurnRetries 返回整轮重试上限（未配置时为 0，不重试）
+func (a *Agent) maxTurnRetries() int {
+	if a.config == nil || a.config.RunLimits == nil {
+		return 0
+	}
+	return a.config.RunLimits.MaxTurnRetries
+}
+
+// shouldRetryTurn 检查模型输出，无效且未超过重试上限时记录重试并返回 true。
+// 重试时丢弃该次输出，下一次模型调用会追加提示消息。
+func (a *Agent) shouldRetryTurn(ctx context.Context, msg types.Message) bool {
+	reason := invalidOutputReason(msg)
+
+	a.mu.Lock()
+	if reason == "" {
+		a.turnRetries = 0
+		a.mu.Unlock()
+		return false
+	}
+	maxRetries := a.maxTurnRetries()
+	if a.turnRetries >= maxRetries {
+		a.turnRetries = 0
+		a.mu.Unlock()
+		return false
+	}
+	a.turnRetries++
+	attempt := a.turnRetries
+	a.retryNudge = turnRetryNudges[reason]
+	a.mu.Unlock()
+
+	procLog.Warn(ctx, "invalid model output, retrying turn", map[string]any{
+		"agent_id": a.id, "reason": reason, "attempt": attempt, "max_retries": maxRetries,
+	})
+	a.eventBus.EmitMonitor(&types.MonitorTurnRetryEvent{
+		Attempt:    attempt,
+		MaxRetries: maxRetries,
+		Reason:     reason,
+	})
+	return true
+}
+
+// withRetryNudge 存在待发送的重试提示时，返回追加了提示的消息副本
+func (a *Agent) withRetryNudge(messages []types.Message) []types.Message {
+	a.mu.Lock()
+	nudge := a.retryNudge
+	a.retryNudge = ""
+	a.mu.Unlock()
+
+	if nudge == "" {
+		return messages
+	}
+	return append(slices.Clip(messages), types.Message{
+		Role:          types.MessageRoleUser,
+		ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: nudge}},
+	})
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// emptyThenText 第一次返回空流，之后返回文本，并记录每次调用的最后一条消息
+func emptyThenText(calls *atomic.Int32, lastMessages *[]string) func(context.Context, []types.Message, *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+	return func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+		n := calls.Add(1)
+		last := messages[len(messages)-1]
+		*lastMessages = append(*lastMessages, last.GetContent())
+
+		ch := make(chan provider.StreamChunk, 1)
+		if n > 1 {
+			ch <- provider.StreamChunk{Type: "text", TextDelta: "final answer"}
+		}
+		close(ch)
+		return ch, nil
+	}
+}
+
+func TestChat_RetriesEmptyOutput(t *testing.T) {
+	var calls atomic.Int32
+	var lastMessages []string
+	ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock", streamFunc: emptyThenText(&calls, &lastMessages)}, false)
+	ag.config.RunLimits = &types.RunLimits{MaxTurnRetries: 2}
+
+	events := ag.Subscribe([]types.AgentChannel{types.ChannelMonitor}, nil)
+	defer ag.Unsubscribe(events)
+
+	result, err := ag.Chat(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if result.Text != "final answer" {
+		t.Errorf("expected final answer, got %q", result.Text)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 1 retry (2 calls), got %d calls", got)
+	}
+	if !strings.Contains(lastMessages[1], "previous response was empty") {
+		t.Errorf("retry should append a nudge, got %q", lastMessages[1])
+	}
+
+	// 空输出和提示都不进入会话历史
+	ag.mu.RLock()
+	for _, msg := range ag.messages {
+		if strings.Contains(msg.GetContent(), "previous response was empty") {
+			t.Error("nudge must not be persisted in history")
+		}
+	}
+	assistantCount := 0
+	for _, msg := range ag.messages {
+		if msg.Role == types.MessageRoleAssistant {
+			assistantCount++
+		}
+	}
+	ag.mu.RUnlock()
+	if assistantCount != 1 {
+		t.Errorf("expected only the valid assistant message in history, got %d", assistantCount)
+	}
+
+	// 重试记录到 Monitor 事件
+	found := false
+	for len(events) > 0 && !found {
+		env := <-events
+		if evt, ok := env.Event.(*types.MonitorTurnRetryEvent); ok {
+			found = evt.Attempt == 1 && evt.Reason == "empty_output"
+		}
+	}
+	if !found {
+		t.Error("expected turn_retry monitor event")
+	}
+}
+
+func TestChat_RetriesInvalidToolCallNonStreaming(t *testing.T) {
+	var calls atomic.Int32
+	mock := &MockProvider{
+		name: "mock",
+		completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+			if calls.Add(1) == 1 {
+				return &provider.CompleteResponse{Message: types.Message{
+					Role: types.MessageRoleAssistant,
+					ContentBlocks: []types.ContentBlock{&types.ToolUseBlock{
+						ID: "call-1", Name: "Read", Input: map[string]any{"__parse_error__": true, "__error_message__": "truncated arguments"},
+					}},
+				}}, nil
+			}
+			return &provider.CompleteResponse{Message: types.Message{
+				Role:          types.MessageRoleAssistant,
+				ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "done"}},
+			}}, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	ag.config.RunLimits = &types.RunLimits{MaxTurnRetries: 1}
+
+	result, err := ag.Chat(context.Background(), "read the file")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if result.Text != "done" || calls.Load() != 2 {
+		t.Errorf("expected one retry and final text, got %q after %d calls", result.Text, calls.Load())
+	}
+}
+
+func TestChat_NoRetryWithoutRunLimits(t *testing.T) {
+	var calls atomic.Int32
+	var lastMessages []string
+	ag := newChatErrorTestAgent(t, "", &MockProvider{name: "mock", streamFunc: emptyThenText(&calls, &lastMessages)}, false)
+
+	if _, err := ag.Chat(context.Background(), "hello"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("retry is opt-in, expected 1 call, got %d", got)
+	}
+}
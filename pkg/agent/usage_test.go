@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// findRunUsageEvent 从已缓冲的 Monitor 事件中查找 run_usage 事件
+func findRunUsageEvent(events <-chan types.AgentEventEnvelope) *types.MonitorRunUsageEvent {
+	for len(events) > 0 {
+		env := <-events
+		if evt, ok := env.Event.(*types.MonitorRunUsageEvent); ok {
+			return evt
+		}
+	}
+	return nil
+}
+
+func TestChat_AccumulatesUsageStreaming(t *testing.T) {
+	var calls atomic.Int32
+	mock := &MockProvider{
+		name: "mock",
+		streamFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+			ch := make(chan provider.StreamChunk, 4)
+			if calls.Add(1) == 1 {
+				// 空输出触发整轮重试，但消耗的 Token 仍计入
+				ch <- provider.StreamChunk{Type: "message_delta", Usage: &provider.TokenUsage{InputTokens: 10, OutputTokens: 5, CacheReadTokens: 3}}
+			} else {
+				// 每个块携带累计用量（如 Gemini），只计最后一次
+				ch <- provider.StreamChunk{Type: "usage", Usage: &provider.TokenUsage{InputTokens: 20, OutputTokens: 1}}
+				ch <- provider.StreamChunk{Type: "text", TextDelta: "final answer"}
+				ch <- provider.StreamChunk{Type: "usage", Usage: &provider.TokenUsage{InputTokens: 20, OutputTokens: 4}}
+			}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, "", mock, false)
+	ag.config.RunLimits = &types.RunLimits{MaxTurnRetries: 1}
+
+	events := ag.Subscribe([]types.AgentChannel{types.ChannelMonitor}, nil)
+	defer ag.Unsubscribe(events)
+
+	result, err := ag.Chat(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	want := types.TokenUsage{InputTokens: 30, OutputTokens: 9, TotalTokens: 39, CacheReadTokens: 3}
+	if result.Usage == nil || *result.Usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, result.Usage)
+	}
+
+	evt := findRunUsageEvent(events)
+	if evt == nil {
+		t.Fatal("expected run_usage monitor event")
+	}
+	if evt.Usage != want || evt.ModelCalls != 2 || evt.RunID == "" {
+		t.Errorf("unexpected run_usage event %+v", evt)
+	}
+
+	// 下一次 Chat 重新计数，Agent 累计用量保留
+	if result, err = ag.Chat(context.Background(), "again"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if result.Usage.InputTokens != 20 || result.Usage.OutputTokens != 4 {
+		t.Errorf("run usage should reset per Chat, got %+v", result.Usage)
+	}
+	ag.mu.RLock()
+	total := ag.usage
+	ag.mu.RUnlock()
+	if total.InputTokens != 50 || total.OutputTokens != 13 {
+		t.Errorf("agent usage should accumulate across runs, got %+v", total)
+	}
+}
+
+func TestChat_AccumulatesUsageNonStreaming(t *testing.T) {
+	var calls atomic.Int32
+	mock := &MockProvider{
+		name: "mock",
+		completeFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+			if calls.Add(1) == 1 {
+				return &provider.CompleteResponse{
+					Message: types.Message{Role: types.MessageRoleAssistant},
+					Usage:   &provider.TokenUsage{InputTokens: 7, OutputTokens: 2, CacheCreationTokens: 100},
+				}, nil
+			}
+			return &provider.CompleteResponse{
+				Message: types.Message{Role: types.MessageRoleAssistant, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "done"}}},
+				Usage:   &provider.TokenUsage{InputTokens: 9, OutputTokens: 3, ReasoningTokens: 1},
+			}, nil
+		},
+	}
+	ag := newChatErrorTestAgent(t, types.ExecutionModeNonStreaming, mock, false)
+	ag.config.RunLimits = &types.RunLimits{MaxTurnRetries: 1}
+
+	result, err := ag.Chat(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	want := types.TokenUsage{InputTokens: 16, OutputTokens: 5, TotalTokens: 21, ReasoningTokens: 1, CacheCreationTokens: 100}
+	if result.Usage == nil || *result.Usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, result.Usage)
+	}
+}
+
+func TestStream_AccumulatesUsage(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		useMiddleware bool
+	}{
+		{"middleware", true},
+		{"direct", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockProvider{
+				name: "mock",
+				streamFunc: func(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (<-chan provider.StreamChunk, error) {
+					ch := make(chan provider.StreamChunk, 3)
+					ch <- provider.StreamChunk{Type: "usage", Usage: &provider.TokenUsage{InputTokens: 12, OutputTokens: 1}}
+					ch <- provider.StreamChunk{Type: "text", TextDelta: "streamed answer"}
+					ch <- provider.StreamChunk{Type: "usage", Usage: &provider.TokenUsage{InputTokens: 12, OutputTokens: 6, CachedTokens: 2}}
+					close(ch)
+					return ch, nil
+				},
+			}
+			ag := newChatErrorTestAgent(t, "", mock, false)
+			if !tt.useMiddleware {
+				ag.middlewareStack = nil
+			}
+
+			events := ag.Subscribe([]types.AgentChannel{types.ChannelMonitor}, nil)
+			defer ag.Unsubscribe(events)
+
+			if _, err := StreamCollect(ag.Stream(context.Background(), "hello")); err != nil {
+				t.Fatalf("Stream failed: %v", err)
+			}
+
+			want := types.TokenUsage{InputTokens: 12, OutputTokens: 6, TotalTokens: 18, CachedTokens: 2}
+			evt := findRunUsageEvent(events)
+			if evt == nil {
+				t.Fatal("expected run_usage monitor event")
+			}
+			if evt.Usage != want || evt.ModelCalls != 1 || evt.RunID == "" {
+				t.Errorf("unexpected run_usage event %+v", evt)
+			}
+			ag.mu.RLock()
+			total := ag.usage
+			ag.mu.RUnlock()
+			if total != want {
+				t.Errorf("expected agent usage %+v, got %+v", want, total)
+			}
+		})
+	}
+}
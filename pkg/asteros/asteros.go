@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -126,6 +127,8 @@ func (os *AsterOS) initRouter() {
 			rooms.POST("/:id/join", os.handleRoomJoin)
 			rooms.POST("/:id/leave", os.handleRoomLeave)
 			rooms.GET("/:id/members", os.handleRoomMembers)
+			rooms.GET("/:id/history", os.handleRoomHistory)
+			rooms.GET("/:id/stream", os.handleRoomStream)
 		}
 
 		// Workflow 路由
@@ -152,6 +155,18 @@ func (os *AsterOS) RegisterAgent(id string, ag *agent.Agent) error {
 
 // RegisterRoom 注册 Room
 func (os *AsterOS) RegisterRoom(id string, r *core.Room) error {
+	// 挂载持久化会话记录
+	if os.opts.RoomTranscriptDir != "" && r.Transcript() == nil {
+		if id != filepath.Base(id) {
+			return fmt.Errorf("invalid room id for transcript: %s", id)
+		}
+		transcript, err := core.NewFileRoomTranscript(filepath.Join(os.opts.RoomTranscriptDir, id+".jsonl"))
+		if err != nil {
+			return err
+		}
+		r.WithTranscript(transcript)
+	}
+
 	// 注册到 Registry
 	if err := os.registry.RegisterRoom(id, r); err != nil {
 		return err
@@ -280,6 +295,11 @@ func (os *AsterOS) Name() string {
 	return os.opts.Name
 }
 
+// APIPrefix 获取 API 路径前缀
+func (os *AsterOS) APIPrefix() string {
+	return os.opts.APIPrefix
+}
+
 // Router 获取 Gin Router
 func (os *AsterOS) Router() *gin.Engine {
 	return os.router
@@ -2,10 +2,15 @@ package asteros
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/astercloud/aster/pkg/core"
 	"github.com/gin-gonic/gin"
 )
 
@@ -227,6 +232,142 @@ func (os *AsterOS) handleRoomMembers(c *gin.Context) {
 	})
 }
 
+// 历史分页默认值
+const (
+	defaultRoomHistoryLimit = 50
+	maxRoomHistoryLimit     = 500
+)
+
+// handleRoomHistory 分页获取 Room 消息历史
+// 查询参数: offset (默认 0), limit (默认 50, 最大 500)
+func (os *AsterOS) handleRoomHistory(c *gin.Context) {
+	roomID := c.Param("id")
+
+	// 获取 Room
+	room, exists := os.registry.GetRoom(roomID)
+	if !exists {
+		c.JSON(404, gin.H{"error": "room not found"})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(400, gin.H{"error": "invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRoomHistoryLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(400, gin.H{"error": "invalid limit"})
+		return
+	}
+	limit = min(limit, maxRoomHistoryLimit)
+
+	messages, total := room.HistoryPage(offset, limit)
+
+	c.JSON(200, gin.H{
+		"messages": messages,
+		"total":    total,
+		"offset":   offset,
+		"limit":    limit,
+	})
+}
+
+// roomStreamKeepAlive SSE 心跳间隔
+const roomStreamKeepAlive = 15 * time.Second
+
+// handleRoomStream 以 SSE 推送 Room 的实时消息
+// 支持 Last-Event-ID 请求头或 after 查询参数，先补发该 ID 之后的历史消息。
+func (os *AsterOS) handleRoomStream(c *gin.Context) {
+	roomID := c.Param("id")
+
+	// 获取 Room
+	room, exists := os.registry.GetRoom(roomID)
+	if !exists {
+		c.JSON(404, gin.H{"error": "room not found"})
+		return
+	}
+
+	afterParam := c.GetHeader("Last-Event-ID")
+	if afterParam == "" {
+		afterParam = c.Query("after")
+	}
+	var afterID int64 = -1
+	if afterParam != "" {
+		id, err := strconv.ParseInt(afterParam, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid after"})
+			return
+		}
+		afterID = id
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(500, gin.H{"error": "streaming unsupported"})
+		return
+	}
+
+	// 先订阅再补发历史，避免遗漏两者之间的消息
+	messages, cancel := room.Subscribe(64)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream; charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(200)
+
+	lastID := afterID
+	writeMessage := func(msg core.RoomMessage) error {
+		if msg.ID <= lastID {
+			return nil
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: message\ndata: %s\n\n", msg.ID, data); err != nil {
+			return err
+		}
+		lastID = msg.ID
+		flusher.Flush()
+		return nil
+	}
+
+	if afterID >= 0 {
+		for _, msg := range room.HistorySince(afterID) {
+			if err := writeMessage(msg); err != nil {
+				return
+			}
+		}
+	} else {
+		// 仅推送新消息
+		flusher.Flush()
+	}
+
+	ticker := time.NewTicker(roomStreamKeepAlive)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if err := writeMessage(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 // handleListWorkflows 列出所有 Workflows
 func (os *AsterOS) handleListWorkflows(c *gin.Context) {
 	workflows := os.registry.ListWorkflows()
@@ -0,0 +1,138 @@
+package asteros
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/core"
+)
+
+// TestRoomHistoryAndStream 测试 Room 发送、历史分页与 SSE 实时推送
+func TestRoomHistoryAndStream(t *testing.T) {
+	deps := createTestDependencies(t)
+	pool := core.NewPool(&core.PoolOptions{
+		Dependencies: deps,
+		MaxAgents:    5,
+	})
+	defer func() { _ = pool.Shutdown() }()
+
+	transcriptDir := t.TempDir()
+	os, err := New(&Options{
+		Name:              "TestOS",
+		Port:              8080,
+		Pool:              pool,
+		EnableAuth:        true,
+		APIKey:            "secret",
+		RoomTranscriptDir: transcriptDir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AsterOS: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := pool.Create(ctx, createTestAgentConfig("agent-1")); err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	room := core.NewRoom(pool)
+	if err := room.Join("alice", "agent-1"); err != nil {
+		t.Fatalf("Failed to join room: %v", err)
+	}
+	if err := os.RegisterRoom("team", room); err != nil {
+		t.Fatalf("Failed to register room: %v", err)
+	}
+
+	srv := httptest.NewServer(os.Router())
+	defer srv.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		return resp
+	}
+
+	// 未认证请求被拒绝
+	resp, err := http.Get(srv.URL + "/rooms/team/history")
+	if err != nil {
+		t.Fatalf("GET history failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without API key, got %d", resp.StatusCode)
+	}
+
+	// 打开 SSE 流
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	streamReq, _ := http.NewRequestWithContext(streamCtx, http.MethodGet, srv.URL+"/rooms/team/stream", nil)
+	streamReq.Header.Set("Authorization", "Bearer secret")
+	stream, err := http.DefaultClient.Do(streamReq)
+	if err != nil {
+		t.Fatalf("GET stream failed: %v", err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+	if ct := stream.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Unexpected content type %q", ct)
+	}
+
+	// 发送消息
+	for _, text := range []string{"hello", "world"} {
+		resp := do(http.MethodPost, "/rooms/team/say", `{"from":"alice","text":"`+text+`"}`)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Say returned %d", resp.StatusCode)
+		}
+	}
+
+	// 流中收到消息
+	reader := bufio.NewReader(stream.Body)
+	var streamed core.RoomMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &streamed); err != nil {
+				t.Fatalf("Invalid stream payload: %v", err)
+			}
+			break
+		}
+	}
+	if streamed.From != "alice" || streamed.Text != "hello" || streamed.ID != 1 {
+		t.Errorf("Unexpected streamed message: %+v", streamed)
+	}
+
+	// 分页读取历史
+	resp = do(http.MethodGet, "/rooms/team/history?offset=1&limit=1", "")
+	defer func() { _ = resp.Body.Close() }()
+	var page struct {
+		Messages []core.RoomMessage `json:"messages"`
+		Total    int                `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode history: %v", err)
+	}
+	if page.Total != 2 || len(page.Messages) != 1 || page.Messages[0].Text != "world" {
+		t.Errorf("Unexpected history page: %+v", page)
+	}
+
+	// 历史已持久化，重建的 Room 可以读取
+	transcript, err := core.NewFileRoomTranscript(transcriptDir + "/team.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to open transcript: %v", err)
+	}
+	if history := core.NewRoom(pool).WithTranscript(transcript).GetHistory(); len(history) != 2 {
+		t.Errorf("Expected 2 persisted messages, got %d", len(history))
+	}
+}
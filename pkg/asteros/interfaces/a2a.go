@@ -20,7 +20,14 @@ type A2AInterfaceOptions struct {
 	EnableLogging bool
 
 	// EnableDiscovery 是否启用服务发现
+	// 启用后在 AsterOS 路由上注册 GET /a2a/agents 和 GET /a2a/agents/:id
 	EnableDiscovery bool
+
+	// BaseURL Agent Card 中 url 字段的前缀，例如 "https://agents.example.com"
+	BaseURL string
+
+	// CardBuilder 自定义 Agent Card 生成（可选），返回 nil 时该 Agent 不可被发现
+	CardBuilder AgentCardBuilder
 }
 
 // A2AInterface Agent-to-Agent Interface
@@ -56,7 +63,13 @@ func NewA2AInterface(opts *A2AInterfaceOptions) *A2AInterface {
 
 // Start 启动 A2A Interface
 func (i *A2AInterface) Start(ctx context.Context, os *asteros.AsterOS) error {
+	i.mu.Lock()
 	i.os = os
+	i.mu.Unlock()
+
+	if i.opts.EnableDiscovery {
+		i.registerDiscoveryRoutes(os.Router().Group(os.APIPrefix()))
+	}
 
 	// TODO: 启动 gRPC 服务器
 	// 这里需要实现完整的 gRPC 服务器和 Agent-to-Agent 协议
@@ -0,0 +1,279 @@
+package interfaces
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/a2a"
+	"github.com/astercloud/aster/pkg/agent"
+	"github.com/gin-gonic/gin"
+)
+
+// 发现接口分页默认值
+const (
+	defaultDiscoveryLimit = 50
+	maxDiscoveryLimit     = 500
+)
+
+// AgentCardBuilder 根据 Agent 生成 Agent Card
+// id 为 Agent 在 AsterOS Registry 或 Pool 中的 ID
+type AgentCardBuilder func(id string, ag *agent.Agent) *a2a.AgentCard
+
+// AgentQuery Agent 发现查询条件
+// 同一类条件的多个值需要全部满足，空值表示不过滤
+type AgentQuery struct {
+	// Skills 技能 ID 或名称
+	Skills []string
+
+	// Tags 技能标签，匹配任一技能的标签即可
+	Tags []string
+
+	// Capabilities 能力名称: streaming, pushNotifications, stateTransitionHistory
+	Capabilities []string
+
+	// Offset 跳过的条数
+	Offset int
+
+	// Limit 返回的最大条数，默认 50，最大 500
+	Limit int
+}
+
+// AgentDirectory Agent 发现结果
+type AgentDirectory struct {
+	Agents []*a2a.AgentCard `json:"agents"`
+	Total  int              `json:"total"` // 过滤后、分页前的总数
+	Offset int              `json:"offset"`
+	Limit  int              `json:"limit"`
+}
+
+// DiscoverAgents 按条件列出已注册 Agent 的 Agent Card，按名称排序
+// 启动后每次查询都读取 AsterOS Registry 和 Pool 的当前状态，注销或移除的 Agent 随即消失。
+func (i *A2AInterface) DiscoverAgents(query *AgentQuery) *AgentDirectory {
+	if query == nil {
+		query = &AgentQuery{}
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultDiscoveryLimit
+	}
+	limit = min(limit, maxDiscoveryLimit)
+	offset := max(query.Offset, 0)
+
+	cards := make([]*a2a.AgentCard, 0)
+	for id, ag := range i.liveAgents() {
+		card := i.buildCard(id, ag)
+		if card != nil && matchAgentCard(card, query) {
+			cards = append(cards, card)
+		}
+	}
+	sort.Slice(cards, func(a, b int) bool {
+		return cards[a].Name < cards[b].Name
+	})
+
+	start := min(offset, len(cards))
+	end := start + min(limit, len(cards)-start)
+	return &AgentDirectory{
+		Agents: cards[start:end],
+		Total:  len(cards),
+		Offset: offset,
+		Limit:  limit,
+	}
+}
+
+// GetAgentCard 获取单个 Agent 的 Agent Card
+func (i *A2AInterface) GetAgentCard(id string) (*a2a.AgentCard, bool) {
+	ag, exists := i.liveAgents()[id]
+	if !exists {
+		return nil, false
+	}
+	card := i.buildCard(id, ag)
+	return card, card != nil
+}
+
+// liveAgents 返回当前可发现的 Agent
+// 启动前只有通过 OnAgentRegistered 收到的 Agent；启动后合并 Registry 和 Pool，
+// 同一个 Agent 同时存在时使用 Registry 中的 ID。
+func (i *A2AInterface) liveAgents() map[string]*agent.Agent {
+	i.mu.RLock()
+	os := i.os
+	if os == nil {
+		agents := maps.Clone(i.agents)
+		i.mu.RUnlock()
+		return agents
+	}
+	i.mu.RUnlock()
+
+	agents := make(map[string]*agent.Agent)
+	seen := make(map[*agent.Agent]bool)
+	registry := os.Registry()
+	for _, id := range registry.ListAgents() {
+		if ag, exists := registry.GetAgent(id); exists {
+			agents[id] = ag
+			seen[ag] = true
+		}
+	}
+	if pool := os.Pool(); pool != nil {
+		_ = pool.ForEach(func(id string, ag *agent.Agent) error {
+			if _, exists := agents[id]; !exists && !seen[ag] {
+				agents[id] = ag
+			}
+			return nil
+		})
+	}
+	return agents
+}
+
+func (i *A2AInterface) buildCard(id string, ag *agent.Agent) *a2a.AgentCard {
+	if i.opts.CardBuilder != nil {
+		return i.opts.CardBuilder(id, ag)
+	}
+	return i.defaultCard(id, ag)
+}
+
+// defaultCard 从 Agent 配置生成 Agent Card
+// Metadata 中的 description (string) 和 tags ([]string) 分别作为描述和 chat 技能的标签，
+// SkillsPackage 中启用的技能各自作为一个技能列出。
+func (i *A2AInterface) defaultCard(id string, ag *agent.Agent) *a2a.AgentCard {
+	config := ag.Config()
+
+	description, _ := config.Metadata["description"].(string)
+	if description == "" {
+		description = "Aster AI Agent: " + id
+	}
+	version := config.TemplateVersion
+	if version == "" {
+		version = "1.0"
+	}
+
+	tags := metadataStrings(config.Metadata["tags"])
+	if config.TemplateID != "" && !slices.Contains(tags, config.TemplateID) {
+		tags = append(tags, config.TemplateID)
+	}
+	skills := []a2a.Skill{
+		{
+			ID:          "chat",
+			Name:        "chat",
+			Description: "General conversation and assistance",
+			Tags:        tags,
+		},
+	}
+	if config.SkillsPackage != nil {
+		for _, name := range config.SkillsPackage.EnabledSkills {
+			skills = append(skills, a2a.Skill{ID: name, Name: name, Tags: []string{"skill"}})
+		}
+	}
+
+	return &a2a.AgentCard{
+		Name:        id,
+		Description: description,
+		URL:         strings.TrimSuffix(i.opts.BaseURL, "/") + "/a2a/" + id,
+		Provider: a2a.Provider{
+			Organization: "Aster",
+			URL:          "https://github.com/astercloud/aster",
+		},
+		Version: version,
+		Capabilities: a2a.Capabilities{
+			Streaming: true,
+		},
+		DefaultInputModes:  []string{"text"},
+		DefaultOutputModes: []string{"text"},
+		Skills:             skills,
+	}
+}
+
+// matchAgentCard 检查 Agent Card 是否满足查询条件，比较时忽略大小写
+func matchAgentCard(card *a2a.AgentCard, query *AgentQuery) bool {
+	for _, want := range query.Skills {
+		if !slices.ContainsFunc(card.Skills, func(skill a2a.Skill) bool {
+			return strings.EqualFold(skill.ID, want) || strings.EqualFold(skill.Name, want)
+		}) {
+			return false
+		}
+	}
+	for _, want := range query.Tags {
+		if !slices.ContainsFunc(card.Skills, func(skill a2a.Skill) bool {
+			return slices.ContainsFunc(skill.Tags, func(tag string) bool {
+				return strings.EqualFold(tag, want)
+			})
+		}) {
+			return false
+		}
+	}
+	for _, want := range query.Capabilities {
+		if !hasCapability(card.Capabilities, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasCapability(capabilities a2a.Capabilities, name string) bool {
+	switch strings.ToLower(name) {
+	case "streaming":
+		return capabilities.Streaming
+	case "pushnotifications":
+		return capabilities.PushNotifications
+	case "statetransitionhistory":
+		return capabilities.StateTransitionHistory
+	}
+	return false
+}
+
+// metadataStrings 读取 []string 或 []any 形式的元数据
+func metadataStrings(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return slices.Clone(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// registerDiscoveryRoutes 注册 Agent 发现端点
+//
+//	GET {APIPrefix}/a2a/agents       列出 Agent Card，查询参数: skill, tag, capability (可重复), offset, limit
+//	GET {APIPrefix}/a2a/agents/:id   获取单个 Agent Card
+func (i *A2AInterface) registerDiscoveryRoutes(router gin.IRoutes) {
+	router.GET("/a2a/agents", i.handleDiscoverAgents)
+	router.GET("/a2a/agents/:id", i.handleGetAgentCard)
+}
+
+func (i *A2AInterface) handleDiscoverAgents(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(400, gin.H{"error": "invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDiscoveryLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(400, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	c.JSON(200, i.DiscoverAgents(&AgentQuery{
+		Skills:       c.QueryArray("skill"),
+		Tags:         c.QueryArray("tag"),
+		Capabilities: c.QueryArray("capability"),
+		Offset:       offset,
+		Limit:        limit,
+	}))
+}
+
+func (i *A2AInterface) handleGetAgentCard(c *gin.Context) {
+	card, exists := i.GetAgentCard(c.Param("id"))
+	if !exists {
+		c.JSON(404, gin.H{"error": "agent not found"})
+		return
+	}
+	c.JSON(200, card)
+}
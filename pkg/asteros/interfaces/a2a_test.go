@@ -0,0 +1,249 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/a2a"
+	"github.com/astercloud/aster/pkg/agent"
+	"github.com/astercloud/aster/pkg/asteros"
+	"github.com/astercloud/aster/pkg/core"
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// 创建测试依赖
+func createTestDependencies(t *testing.T) *agent.Dependencies {
+	jsonStore, err := store.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	templateRegistry := agent.NewTemplateRegistry()
+	templateRegistry.Register(&types.AgentTemplateDefinition{
+		ID:           "test-agent",
+		SystemPrompt: "You are a test agent",
+		Model:        "claude-sonnet-4-5",
+		Tools:        []any{},
+	})
+
+	return &agent.Dependencies{
+		Store:            jsonStore,
+		SandboxFactory:   sandbox.NewFactory(),
+		ToolRegistry:     tools.NewRegistry(),
+		ProviderFactory:  &provider.AnthropicFactory{},
+		TemplateRegistry: templateRegistry,
+	}
+}
+
+// 创建带标签的测试 Agent 配置
+func createTestAgentConfig(agentID string, tags ...string) *types.AgentConfig {
+	return &types.AgentConfig{
+		AgentID:    agentID,
+		TemplateID: "test-agent",
+		ModelConfig: &types.ModelConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			APIKey:   "sk-test-key-for-unit-tests",
+		},
+		Sandbox: &types.SandboxConfig{
+			Kind: types.SandboxKindMock,
+		},
+		Metadata: map[string]any{
+			"description": "Agent " + agentID,
+			"tags":        tags,
+		},
+	}
+}
+
+// TestA2AInterfaceDiscovery 测试 Agent 发现的过滤、分页和实时更新
+func TestA2AInterfaceDiscovery(t *testing.T) {
+	ctx := context.Background()
+	pool := core.NewPool(&core.PoolOptions{
+		Dependencies: createTestDependencies(t),
+		MaxAgents:    5,
+	})
+	defer func() { _ = pool.Shutdown() }()
+
+	os, err := asteros.New(&asteros.Options{
+		Name:      "TestOS",
+		Port:      8080,
+		Pool:      pool,
+		APIPrefix: "/api",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create AsterOS: %v", err)
+	}
+
+	iface := NewA2AInterface(&A2AInterfaceOptions{
+		EnableDiscovery: true,
+		BaseURL:         "https://agents.example.com/",
+	})
+	if err := os.AddInterface(iface); err != nil {
+		t.Fatalf("Failed to add interface: %v", err)
+	}
+	if err := iface.Start(ctx, os); err != nil {
+		t.Fatalf("Failed to start interface: %v", err)
+	}
+
+	// writer 和 coder 通过 RegisterAgent 注册，helper 只存在于 Pool 中
+	for _, config := range []*types.AgentConfig{
+		createTestAgentConfig("writer", "writing"),
+		createTestAgentConfig("coder", "code", "review"),
+		createTestAgentConfig("helper"),
+	} {
+		ag, err := pool.Create(ctx, config)
+		if err != nil {
+			t.Fatalf("Failed to create agent: %v", err)
+		}
+		if config.AgentID != "helper" {
+			if err := os.RegisterAgent(config.AgentID, ag); err != nil {
+				t.Fatalf("Failed to register agent: %v", err)
+			}
+		}
+	}
+
+	srv := httptest.NewServer(os.Router())
+	defer srv.Close()
+
+	list := func(query string) *AgentDirectory {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/a2a/agents" + query)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", query, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != 200 {
+			t.Fatalf("GET %s: expected 200, got %d", query, resp.StatusCode)
+		}
+		var dir AgentDirectory
+		if err := json.NewDecoder(resp.Body).Decode(&dir); err != nil {
+			t.Fatalf("Failed to decode directory: %v", err)
+		}
+		return &dir
+	}
+	names := func(dir *AgentDirectory) []string {
+		result := make([]string, len(dir.Agents))
+		for i, card := range dir.Agents {
+			result[i] = card.Name
+		}
+		return result
+	}
+
+	dir := list("")
+	if dir.Total != 3 || len(dir.Agents) != 3 {
+		t.Fatalf("Expected 3 agents, got total=%d %v", dir.Total, names(dir))
+	}
+	coder := dir.Agents[0]
+	if coder.Name != "coder" || coder.Description != "Agent coder" {
+		t.Errorf("Unexpected first card: %+v", coder)
+	}
+	if coder.URL != "https://agents.example.com/a2a/coder" {
+		t.Errorf("Unexpected card URL: %s", coder.URL)
+	}
+
+	// 按标签、技能和能力过滤
+	if got := names(list("?tag=review")); len(got) != 1 || got[0] != "coder" {
+		t.Errorf("Expected [coder] for tag=review, got %v", got)
+	}
+	if got := names(list("?tag=test-agent&tag=WRITING")); len(got) != 1 || got[0] != "writer" {
+		t.Errorf("Expected [writer] for tag=test-agent&tag=WRITING, got %v", got)
+	}
+	if got := list("?skill=chat&capability=streaming"); got.Total != 3 {
+		t.Errorf("Expected 3 streaming chat agents, got %d", got.Total)
+	}
+	if got := list("?capability=pushNotifications"); got.Total != 0 {
+		t.Errorf("Expected no agents with push notifications, got %d", got.Total)
+	}
+
+	// 分页
+	page := list("?offset=1&limit=1")
+	if page.Total != 3 || len(page.Agents) != 1 || page.Agents[0].Name != "helper" {
+		t.Errorf("Unexpected page: total=%d %v", page.Total, names(page))
+	}
+	if page := list("?offset=10"); page.Total != 3 || len(page.Agents) != 0 {
+		t.Errorf("Expected empty page past the end, got %v", names(page))
+	}
+
+	// 从 Pool 移除后立即反映
+	if err := pool.Remove("helper"); err != nil {
+		t.Fatalf("Failed to remove agent: %v", err)
+	}
+	if got := names(list("")); len(got) != 2 {
+		t.Errorf("Expected 2 agents after pool removal, got %v", got)
+	}
+
+	// 单个 Agent Card
+	resp, err := http.Get(srv.URL + "/api/a2a/agents/writer")
+	if err != nil {
+		t.Fatalf("GET card failed: %v", err)
+	}
+	var card a2a.AgentCard
+	err = json.NewDecoder(resp.Body).Decode(&card)
+	_ = resp.Body.Close()
+	if err != nil || card.Name != "writer" {
+		t.Errorf("Unexpected card: %+v (%v)", card, err)
+	}
+
+	for path, status := range map[string]int{
+		"/api/a2a/agents/helper":   404,
+		"/api/a2a/agents?limit=0":  400,
+		"/api/a2a/agents?offset=x": 400,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("GET %s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+	}
+}
+
+// TestA2AInterfaceCardBuilder 测试自定义 Agent Card 和未启动时的发现
+func TestA2AInterfaceCardBuilder(t *testing.T) {
+	ctx := context.Background()
+	deps := createTestDependencies(t)
+
+	iface := NewA2AInterface(&A2AInterfaceOptions{
+		CardBuilder: func(id string, ag *agent.Agent) *a2a.AgentCard {
+			if id == "hidden" {
+				return nil
+			}
+			return &a2a.AgentCard{
+				Name:         id,
+				Capabilities: a2a.Capabilities{PushNotifications: true},
+				Skills:       []a2a.Skill{{ID: "translate", Name: "Translate"}},
+			}
+		},
+	})
+
+	for _, id := range []string{"translator", "hidden"} {
+		ag, err := agent.Create(ctx, createTestAgentConfig(id), deps)
+		if err != nil {
+			t.Fatalf("Failed to create agent: %v", err)
+		}
+		defer func() { _ = ag.Close() }()
+		if err := iface.OnAgentRegistered(ag); err != nil {
+			t.Fatalf("OnAgentRegistered failed: %v", err)
+		}
+	}
+
+	dir := iface.DiscoverAgents(&AgentQuery{
+		Skills:       []string{"translate"},
+		Capabilities: []string{"pushNotifications"},
+	})
+	if dir.Total != 1 || dir.Agents[0].Name != "translator" {
+		t.Errorf("Expected only translator, got %+v", dir)
+	}
+	if _, exists := iface.GetAgentCard("hidden"); exists {
+		t.Error("Expected hidden agent to be undiscoverable")
+	}
+}
@@ -24,6 +24,9 @@ type Options struct {
 	EnableAuth bool   // 是否启用认证，默认 false
 	APIKey     string // API Key（如果启用认证）
 
+	// Room 配置
+	RoomTranscriptDir string // Room 会话记录目录，设置后注册的 Room 历史持久化到 <dir>/<id>.jsonl
+
 	// 监控配置
 	EnableMetrics bool // 是否启用 Prometheus 指标，默认 true
 	EnableHealth  bool // 是否启用健康检查，默认 true
@@ -1,5 +1,7 @@
 package commands
 
+import "context"
+
 // CommandDefinition Slash Command 定义
 type CommandDefinition struct {
 	// 基础信息
@@ -22,4 +24,8 @@ type CommandDefinition struct {
 
 	// 提示词模板
 	PromptTemplate string // Markdown 内容
+
+	// Render 动态渲染提示词（可选），设置后忽略 PromptTemplate
+	// 用于由外部来源提供的命令，如 MCP Server 的 prompts
+	Render func(ctx context.Context, args map[string]string) (string, error)
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/sandbox"
@@ -24,6 +26,10 @@ type Executor struct {
 	sandbox      sandbox.Sandbox
 	provider     provider.Provider
 	capabilities provider.ProviderCapabilities
+
+	// 通过 Register 注册的命令，优先于命令目录中的同名命令
+	mu         sync.RWMutex
+	registered map[string]*CommandDefinition
 }
 
 // NewExecutor 创建执行器
@@ -33,9 +39,66 @@ func NewExecutor(config *ExecutorConfig) *Executor {
 		sandbox:      config.Sandbox,
 		provider:     config.Provider,
 		capabilities: config.Capabilities,
+		registered:   make(map[string]*CommandDefinition),
 	}
 }
 
+// Register 注册命令，已存在的同名命令会被替换
+func (e *Executor) Register(cmd *CommandDefinition) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registered[cmd.Name] = cmd
+}
+
+// Unregister 移除通过 Register 注册的命令
+func (e *Executor) Unregister(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.registered[name]; !ok {
+		return false
+	}
+	delete(e.registered, name)
+	return true
+}
+
+// List 列出所有可用命令（命令目录和已注册的命令，按名称排序）
+func (e *Executor) List(ctx context.Context) ([]string, error) {
+	var names []string
+	if e.loader != nil {
+		loaded, err := e.loader.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names = loaded
+	}
+
+	e.mu.RLock()
+	for name := range e.registered {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	e.mu.RUnlock()
+
+	slices.Sort(names)
+	return names, nil
+}
+
+// lookup 查找命令定义，已注册的命令优先
+func (e *Executor) lookup(ctx context.Context, commandName string) (*CommandDefinition, error) {
+	e.mu.RLock()
+	cmd, ok := e.registered[commandName]
+	e.mu.RUnlock()
+	if ok {
+		return cmd, nil
+	}
+	if e.loader == nil {
+		return nil, fmt.Errorf("command not found: %s", commandName)
+	}
+	return e.loader.Load(ctx, commandName)
+}
+
 // IsSlashCommand 检查消息是否为斜杠命令
 func (e *Executor) IsSlashCommand(message string) bool {
 	return strings.HasPrefix(strings.TrimSpace(message), "/")
@@ -44,7 +107,7 @@ func (e *Executor) IsSlashCommand(message string) bool {
 // Execute 执行命令
 func (e *Executor) Execute(ctx context.Context, commandName string, args map[string]string) (string, error) {
 	// 1. 加载命令定义
-	cmd, err := e.loader.Load(ctx, commandName)
+	cmd, err := e.lookup(ctx, commandName)
 	if err != nil {
 		return "", fmt.Errorf("load command: %w", err)
 	}
@@ -57,7 +120,14 @@ func (e *Executor) Execute(ctx context.Context, commandName string, args map[str
 	}
 
 	// 3. 渲染提示词
-	prompt := e.renderPrompt(cmd.PromptTemplate, args)
+	var prompt string
+	if cmd.Render != nil {
+		if prompt, err = cmd.Render(ctx, args); err != nil {
+			return "", fmt.Errorf("render command: %w", err)
+		}
+	} else {
+		prompt = e.renderPrompt(cmd.PromptTemplate, args)
+	}
 
 	// 4. 构建完整的命令消息
 	message := e.buildCommandMessage(cmd, prompt)
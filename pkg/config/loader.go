@@ -337,6 +337,9 @@ func mergeModelConfig(base, overlay *types.ModelConfig) {
 	if overlay.BaseURL != "" {
 		base.BaseURL = overlay.BaseURL
 	}
+	if len(overlay.BaseURLs) > 0 {
+		base.BaseURLs = overlay.BaseURLs
+	}
 	if overlay.ExecutionMode != "" {
 		base.ExecutionMode = overlay.ExecutionMode
 	}
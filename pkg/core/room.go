@@ -29,6 +29,13 @@ type Room struct {
 
 	// 消息历史 (可选)
 	history []RoomMessage
+	nextID  int64
+
+	// 持久化的会话记录 (可选)
+	transcript RoomTranscript
+
+	// 实时消息订阅者
+	subscribers map[chan RoomMessage]struct{}
 
 	// 提及正则表达式
 	mentionRegex *regexp.Regexp
@@ -36,6 +43,7 @@ type Room struct {
 
 // RoomMessage Room 消息记录
 type RoomMessage struct {
+	ID   int64    `json:"id"` // 递增序号，用于分页和断点续传
 	From string   `json:"from"`
 	To   []string `json:"to,omitempty"` // 空表示广播
 	Text string   `json:"text"`
@@ -48,10 +56,39 @@ func NewRoom(pool *Pool) *Room {
 		pool:         pool,
 		members:      make(map[string]string),
 		history:      make([]RoomMessage, 0),
+		subscribers:  make(map[chan RoomMessage]struct{}),
 		mentionRegex: regexp.MustCompile(`@(\w+)`),
 	}
 }
 
+// WithTranscript 设置持久化的会话记录，并从中恢复历史消息
+// 历史独立于成员 Agent 保存，成员重启后仍可读取。
+func (r *Room) WithTranscript(transcript RoomTranscript) *Room {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transcript = transcript
+	history, err := transcript.Load()
+	if err != nil {
+		roomLog.Warn(context.Background(), "failed to load room transcript", map[string]any{"error": err})
+		return r
+	}
+	r.history = history
+	for _, msg := range history {
+		if msg.ID > r.nextID {
+			r.nextID = msg.ID
+		}
+	}
+	return r
+}
+
+// Transcript 返回持久化的会话记录，未设置时为 nil
+func (r *Room) Transcript() RoomTranscript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.transcript
+}
+
 // Join 加入 Room
 func (r *Room) Join(name string, agentID string) error {
 	r.mu.Lock()
@@ -134,9 +171,7 @@ func (r *Room) Say(ctx context.Context, from string, text string) error {
 	r.mu.RUnlock()
 
 	// 记录到历史
-	r.mu.Lock()
-	r.history = append(r.history, msg)
-	r.mu.Unlock()
+	r.record(ctx, msg)
 
 	// 发送消息
 	for name, agentID := range targets {
@@ -176,9 +211,7 @@ func (r *Room) Broadcast(ctx context.Context, text string) error {
 		Sent: nowTimestamp(),
 	}
 
-	r.mu.Lock()
-	r.history = append(r.history, msg)
-	r.mu.Unlock()
+	r.record(ctx, msg)
 
 	// 发送消息
 	for _, agentID := range targets {
@@ -222,9 +255,7 @@ func (r *Room) SendTo(ctx context.Context, from string, to string, text string)
 		Sent: nowTimestamp(),
 	}
 
-	r.mu.Lock()
-	r.history = append(r.history, msg)
-	r.mu.Unlock()
+	r.record(ctx, msg)
 
 	// 获取 Agent 并发送
 	ag, exists := r.pool.Get(agentID)
@@ -285,11 +316,99 @@ func (r *Room) GetHistory() []RoomMessage {
 	return history
 }
 
+// HistoryPage 分页获取消息历史，返回当前页和消息总数
+func (r *Room) HistoryPage(offset, limit int) ([]RoomMessage, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := len(r.history)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total {
+		return []RoomMessage{}, total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	page := make([]RoomMessage, end-offset)
+	copy(page, r.history[offset:end])
+	return page, total
+}
+
+// HistorySince 获取 ID 大于 afterID 的消息
+func (r *Room) HistorySince(afterID int64) []RoomMessage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var messages []RoomMessage
+	for _, msg := range r.history {
+		if msg.ID > afterID {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Subscribe 订阅 Room 的实时消息，返回消息通道和取消订阅函数
+// 订阅者处理过慢时，缓冲区满后的消息会被丢弃
+func (r *Room) Subscribe(buffer int) (<-chan RoomMessage, func()) {
+	if buffer <= 0 {
+		buffer = 16
+	}
+	ch := make(chan RoomMessage, buffer)
+
+	r.mu.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subscribers, ch)
+			r.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
 // ClearHistory 清空消息历史
 func (r *Room) ClearHistory() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.history = make([]RoomMessage, 0)
+	if r.transcript != nil {
+		if err := r.transcript.Clear(); err != nil {
+			roomLog.Warn(context.Background(), "failed to clear room transcript", map[string]any{"error": err})
+		}
+	}
+}
+
+// record 记录消息到历史和会话记录，并推送给订阅者
+func (r *Room) record(ctx context.Context, msg RoomMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	msg.ID = r.nextID
+	r.history = append(r.history, msg)
+
+	if r.transcript != nil {
+		if err := r.transcript.Append(msg); err != nil {
+			roomLog.Warn(ctx, "failed to persist room message", map[string]any{"id": msg.ID, "error": err})
+		}
+	}
+
+	for ch := range r.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			roomLog.Warn(ctx, "room subscriber is slow, dropping message", map[string]any{"id": msg.ID})
+		}
+	}
 }
 
 // extractMentions 提取消息中的 @mentions
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RoomTranscript Room 会话记录的持久化接口
+type RoomTranscript interface {
+	// Append 追加一条消息
+	Append(msg RoomMessage) error
+	// Load 按顺序加载全部消息
+	Load() ([]RoomMessage, error)
+	// Clear 清空会话记录
+	Clear() error
+}
+
+// FileRoomTranscript 基于 JSONL 文件的会话记录
+type FileRoomTranscript struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileRoomTranscript 创建文件会话记录，目录不存在时自动创建
+func NewFileRoomTranscript(path string) (*FileRoomTranscript, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create transcript dir: %w", err)
+	}
+	return &FileRoomTranscript{path: path}, nil
+}
+
+// Append 追加一条消息
+func (t *FileRoomTranscript) Append(msg RoomMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal room message: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	return nil
+}
+
+// Load 按顺序加载全部消息，文件不存在时返回空列表
+func (t *FileRoomTranscript) Load() ([]RoomMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []RoomMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	messages := make([]RoomMessage, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var msg RoomMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("decode transcript line: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	return messages, nil
+}
+
+// Clear 清空会话记录
+func (t *FileRoomTranscript) Clear() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove transcript: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRoom_TranscriptSurvivesRestart 测试会话记录在 Room 重建后恢复
+func TestRoom_TranscriptSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rooms", "team.jsonl")
+	transcript, err := NewFileRoomTranscript(path)
+	if err != nil {
+		t.Fatalf("Failed to create transcript: %v", err)
+	}
+
+	ctx := context.Background()
+	room := NewRoom(nil).WithTranscript(transcript)
+	_ = room.Broadcast(ctx, "first")
+	_ = room.Broadcast(ctx, "second")
+
+	// 模拟重启：从同一文件重建 Room
+	reloaded, err := NewFileRoomTranscript(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen transcript: %v", err)
+	}
+	restored := NewRoom(nil).WithTranscript(reloaded)
+
+	history := restored.GetHistory()
+	if len(history) != 2 || history[0].Text != "first" || history[1].ID != 2 {
+		t.Fatalf("Unexpected restored history: %+v", history)
+	}
+
+	// 序号在恢复后继续递增
+	_ = restored.Broadcast(ctx, "third")
+	page, total := restored.HistoryPage(2, 10)
+	if total != 3 || len(page) != 1 || page[0].ID != 3 {
+		t.Errorf("Expected third message with ID 3, got %+v (total %d)", page, total)
+	}
+
+	restored.ClearHistory()
+	if msgs, _ := reloaded.Load(); len(msgs) != 0 {
+		t.Errorf("Expected transcript to be cleared, got %d messages", len(msgs))
+	}
+}
+
+// TestRoom_Subscribe 测试实时消息订阅
+func TestRoom_Subscribe(t *testing.T) {
+	room := NewRoom(nil)
+	messages, cancel := room.Subscribe(1)
+
+	_ = room.Broadcast(context.Background(), "hello")
+
+	select {
+	case msg := <-messages:
+		if msg.Text != "hello" || msg.ID != 1 {
+			t.Errorf("Unexpected message: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-messages; ok {
+		t.Error("Expected channel to be closed after cancel")
+	}
+	_ = room.Broadcast(context.Background(), "after cancel")
+}
+
+// TestRoom_HistoryPage 测试历史分页
+func TestRoom_HistoryPage(t *testing.T) {
+	room := NewRoom(nil)
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		_ = room.Broadcast(context.Background(), text)
+	}
+
+	page, total := room.HistoryPage(1, 2)
+	if total != 5 || len(page) != 2 || page[0].Text != "b" || page[1].Text != "c" {
+		t.Errorf("Unexpected page: %+v (total %d)", page, total)
+	}
+
+	page, _ = room.HistoryPage(10, 2)
+	if len(page) != 0 {
+		t.Errorf("Expected empty page past the end, got %d", len(page))
+	}
+
+	if since := room.HistorySince(3); len(since) != 2 || since[0].Text != "d" {
+		t.Errorf("Unexpected messages since 3: %+v", since)
+	}
+}
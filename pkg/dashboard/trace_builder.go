@@ -251,6 +251,20 @@ func (tb *TraceBuilder) buildSpanTree(events []types.AgentEventEnvelope) *TraceN
 				currentLLMSpan.Attributes["total_tokens"] = evt.TotalTokens
 			}
 
+		case *types.MonitorTurnRetryEvent: // Agent 以指针形式发出
+			// 记录整轮重试
+			if root.Attributes == nil {
+				root.Attributes = make(map[string]any)
+			}
+			retries, _ := root.Attributes["turn_retries"].(int)
+			root.Attributes["turn_retries"] = retries + 1
+			if currentLLMSpan != nil {
+				if currentLLMSpan.Attributes == nil {
+					currentLLMSpan.Attributes = make(map[string]any)
+				}
+				currentLLMSpan.Attributes["retry_reason"] = evt.Reason
+			}
+
 		case types.MonitorErrorEvent:
 			if evt.Severity == "error" {
 				hasError = true
@@ -286,6 +286,46 @@ func TestTraceBuilder_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestTraceBuilder_TurnRetry(t *testing.T) {
+	tb := NewTraceBuilder()
+
+	now := time.Now()
+	events := []types.AgentEventEnvelope{
+		{
+			Cursor:   1,
+			Bookmark: types.Bookmark{Cursor: 1, Timestamp: now.UnixMilli()},
+			Event: types.MonitorStepCompleteEvent{
+				Step:       1,
+				DurationMs: 100,
+			},
+		},
+		{
+			Cursor:   2,
+			Bookmark: types.Bookmark{Cursor: 2, Timestamp: now.Add(10 * time.Millisecond).UnixMilli()},
+			Event:    &types.MonitorTurnRetryEvent{Attempt: 1, MaxRetries: 2, Reason: "empty_output"},
+		},
+		{
+			Cursor:   3,
+			Bookmark: types.Bookmark{Cursor: 3, Timestamp: now.Add(20 * time.Millisecond).UnixMilli()},
+			Event:    &types.MonitorTurnRetryEvent{Attempt: 2, MaxRetries: 2, Reason: "invalid_tool_call"},
+		},
+	}
+
+	root := tb.buildSpanTree(events)
+
+	if root == nil {
+		t.Fatal("buildSpanTree() returned nil")
+	}
+
+	if got := root.Attributes["turn_retries"]; got != 2 {
+		t.Errorf("buildSpanTree() turn_retries = %v, want 2", got)
+	}
+
+	if got := root.Children[0].Attributes["retry_reason"]; got != "invalid_tool_call" {
+		t.Errorf("buildSpanTree() retry_reason = %v, want invalid_tool_call", got)
+	}
+}
+
 func TestTraceBuilder_EmptyEvents(t *testing.T) {
 	tb := NewTraceBuilder()
 
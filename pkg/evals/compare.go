@@ -0,0 +1,406 @@
+package evals
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// CompareMethod 显著性检验方法
+type CompareMethod string
+
+const (
+	CompareMethodTTest     CompareMethod = "paired_t_test" // 配对 t 检验
+	CompareMethodBootstrap CompareMethod = "bootstrap"     // 配对 bootstrap 重采样
+)
+
+// CompareConfig 模型对比配置
+type CompareConfig struct {
+	// Scorer 参与对比的评分器名称（结果中只有一个评分器时可为空）
+	Scorer string
+	// Alpha 显著性水平（默认: 0.05）
+	Alpha float64
+	// LowerIsBetter 分数越低越好（如幻觉率、毒性）
+	LowerIsBetter bool
+	// Method 检验方法（默认: 配对 t 检验）
+	Method CompareMethod
+	// BootstrapSamples bootstrap 重采样次数（默认: 10000）
+	BootstrapSamples int
+	// Seed bootstrap 随机种子（相同种子结果可复现）
+	Seed uint64
+}
+
+// ComparisonResult 模型对比结果
+// 差值均按 "A 相对 B 的改进" 计算：为正表示 A 更好（已考虑 LowerIsBetter）。
+type ComparisonResult struct {
+	Scorer string        `json:"scorer"`
+	Method CompareMethod `json:"method"`
+	Alpha  float64       `json:"alpha"`
+
+	// LowerIsBetter 分数越低越好
+	LowerIsBetter bool `json:"lower_is_better"`
+
+	// N 成功配对的测试用例数
+	N int `json:"n"`
+	// Unpaired 仅在一侧存在或出错而未参与对比的测试用例
+	Unpaired []string `json:"unpaired,omitempty"`
+
+	MeanA float64 `json:"mean_a"`
+	MeanB float64 `json:"mean_b"`
+
+	// MeanDiff A 相对 B 的平均改进
+	MeanDiff float64 `json:"mean_diff"`
+	// StdDev 配对差值的样本标准差
+	StdDev float64 `json:"std_dev"`
+	// TStatistic t 统计量（仅 t 检验）
+	TStatistic float64 `json:"t_statistic,omitempty"`
+	// PValue 双侧 p 值
+	PValue float64 `json:"p_value"`
+	// CILower/CIUpper 平均改进的 (1-Alpha) 置信区间
+	CILower float64 `json:"ci_lower"`
+	CIUpper float64 `json:"ci_upper"`
+
+	// Significant 差异是否显著（PValue < Alpha）
+	Significant bool `json:"significant"`
+	// Winner 显著更好的一方: "A"、"B"，不显著时为空
+	Winner string `json:"winner,omitempty"`
+}
+
+// ABeatsB A 是否显著优于 B
+func (r *ComparisonResult) ABeatsB() bool {
+	return r.Winner == "A"
+}
+
+// Summary 生成可读的对比摘要
+func (r *ComparisonResult) Summary() string {
+	var sb strings.Builder
+	direction := "higher is better"
+	if r.LowerIsBetter {
+		direction = "lower is better"
+	}
+	fmt.Fprintf(&sb, "Scorer %q (%s, n=%d): A=%.4f, B=%.4f\n", r.Scorer, r.Method, r.N, r.MeanA, r.MeanB)
+	fmt.Fprintf(&sb, "Improvement of A over B (%s): %+.4f, %.0f%% CI [%+.4f, %+.4f], p=%.4g\n",
+		direction, r.MeanDiff, (1-r.Alpha)*100, r.CILower, r.CIUpper, r.PValue)
+	if r.Significant {
+		fmt.Fprintf(&sb, "Result: %s is significantly better at alpha=%.2g", r.Winner, r.Alpha)
+	} else {
+		fmt.Fprintf(&sb, "Result: no significant difference at alpha=%.2g", r.Alpha)
+	}
+	if len(r.Unpaired) > 0 {
+		fmt.Fprintf(&sb, " (%d unpaired cases skipped)", len(r.Unpaired))
+	}
+	return sb.String()
+}
+
+// CompareModels 对比两个模型在同一数据集上的评估结果
+// 按 TestCaseID 配对，计算配对差值，并通过配对 t 检验或 bootstrap 判断差异是否显著。
+func CompareModels(resultsA, resultsB *BatchEvalResult, cfg *CompareConfig) (*ComparisonResult, error) {
+	if resultsA == nil || resultsB == nil {
+		return nil, errors.New("both results are required")
+	}
+	if cfg == nil {
+		cfg = &CompareConfig{}
+	}
+
+	alpha := cfg.Alpha
+	if alpha <= 0 || alpha >= 1 {
+		alpha = 0.05
+	}
+	method := cfg.Method
+	if method == "" {
+		method = CompareMethodTTest
+	}
+
+	scorer := cfg.Scorer
+	if scorer == "" {
+		names := scorerNames(resultsA)
+		if len(names) != 1 {
+			return nil, fmt.Errorf("scorer must be specified when results contain %d scorers", len(names))
+		}
+		scorer = names[0]
+	}
+
+	scoresA := scoresByCase(resultsA, scorer)
+	scoresB := scoresByCase(resultsB, scorer)
+
+	result := &ComparisonResult{Scorer: scorer, Method: method, Alpha: alpha, LowerIsBetter: cfg.LowerIsBetter}
+
+	var a, b, diffs []float64
+	for _, id := range caseIDs(resultsA, resultsB) {
+		va, okA := scoresA[id]
+		vb, okB := scoresB[id]
+		if !okA || !okB {
+			result.Unpaired = append(result.Unpaired, id)
+			continue
+		}
+		a = append(a, va)
+		b = append(b, vb)
+		diff := va - vb
+		if cfg.LowerIsBetter {
+			diff = -diff
+		}
+		diffs = append(diffs, diff)
+	}
+
+	result.N = len(diffs)
+	if result.N < 2 {
+		return nil, fmt.Errorf("need at least 2 paired cases for scorer %q, got %d", scorer, result.N)
+	}
+
+	result.MeanA = mean(a)
+	result.MeanB = mean(b)
+	result.MeanDiff = mean(diffs)
+	result.StdDev = stdDev(diffs, result.MeanDiff)
+
+	switch method {
+	case CompareMethodTTest:
+		pairedTTest(result, diffs)
+	case CompareMethodBootstrap:
+		samples := cfg.BootstrapSamples
+		if samples <= 0 {
+			samples = 10000
+		}
+		bootstrapTest(result, diffs, samples, cfg.Seed)
+	default:
+		return nil, fmt.Errorf("unknown compare method: %s", method)
+	}
+
+	result.Significant = result.PValue < alpha && result.MeanDiff != 0
+	if result.Significant {
+		result.Winner = "A"
+		if result.MeanDiff < 0 {
+			result.Winner = "B"
+		}
+	}
+
+	return result, nil
+}
+
+// pairedTTest 配对 t 检验
+func pairedTTest(result *ComparisonResult, diffs []float64) {
+	n := float64(len(diffs))
+	df := n - 1
+
+	if result.StdDev == 0 {
+		// 所有差值相同：差值非零即为确定性差异
+		result.CILower, result.CIUpper = result.MeanDiff, result.MeanDiff
+		result.PValue = 1
+		if result.MeanDiff != 0 {
+			result.PValue = 0
+			result.TStatistic = math.Copysign(math.Inf(1), result.MeanDiff)
+		}
+		return
+	}
+
+	se := result.StdDev / math.Sqrt(n)
+	result.TStatistic = result.MeanDiff / se
+	result.PValue = studentTTwoSided(result.TStatistic, df)
+
+	margin := studentTCritical(result.Alpha, df) * se
+	result.CILower = result.MeanDiff - margin
+	result.CIUpper = result.MeanDiff + margin
+}
+
+// bootstrapTest 配对 bootstrap：重采样差值均值，百分位置信区间 + 双侧 p 值
+func bootstrapTest(result *ComparisonResult, diffs []float64, samples int, seed uint64) {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	n := len(diffs)
+
+	means := make([]float64, samples)
+	for i := range means {
+		var sum float64
+		for range n {
+			sum += diffs[rng.IntN(n)]
+		}
+		means[i] = sum / float64(n)
+	}
+	slices.Sort(means)
+
+	result.CILower = percentile(means, result.Alpha/2)
+	result.CIUpper = percentile(means, 1-result.Alpha/2)
+
+	// 双侧 p 值：重采样均值落在 0 另一侧的比例 × 2
+	var below, above int
+	for _, m := range means {
+		if m <= 0 {
+			below++
+		}
+		if m >= 0 {
+			above++
+		}
+	}
+	p := 2 * float64(min(below, above)) / float64(samples)
+	result.PValue = math.Min(1, p)
+}
+
+// scorerNames 返回结果中出现的评分器名称（排序）
+func scorerNames(results *BatchEvalResult) []string {
+	seen := make(map[string]bool)
+	for _, r := range results.Results {
+		for _, s := range r.Scores {
+			seen[s.Name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// scoresByCase 按测试用例提取指定评分器的分数（跳过出错的用例）
+func scoresByCase(results *BatchEvalResult, scorer string) map[string]float64 {
+	scores := make(map[string]float64, len(results.Results))
+	for _, r := range results.Results {
+		if r.Error != "" {
+			continue
+		}
+		for _, s := range r.Scores {
+			if s.Name == scorer {
+				scores[r.TestCaseID] = s.Value
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// caseIDs 按出现顺序合并两侧的测试用例 ID
+func caseIDs(resultsA, resultsB *BatchEvalResult) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, results := range []*BatchEvalResult{resultsA, resultsB} {
+		for _, r := range results.Results {
+			if !seen[r.TestCaseID] {
+				seen[r.TestCaseID] = true
+				ids = append(ids, r.TestCaseID)
+			}
+		}
+	}
+	return ids
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stdDev 样本标准差（n-1）
+func stdDev(values []float64, m float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// percentile 已排序数据的分位数（线性插值）
+func percentile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// studentTTwoSided t 分布双侧 p 值: P(|T| >= |t|)
+func studentTTwoSided(t, df float64) float64 {
+	return regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// studentTCritical t 分布双侧临界值（二分求解 P(|T| >= t) = alpha）
+func studentTCritical(alpha, df float64) float64 {
+	lo, hi := 0.0, 1000.0
+	for range 200 {
+		mid := (lo + hi) / 2
+		if studentTTwoSided(mid, df) > alpha {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regularizedIncompleteBeta 正则化不完全 Beta 函数 I_x(a, b)（连分式展开）
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	// 连分式在 x < (a+1)/(a+b+2) 时收敛更快，否则使用对称关系
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(1-x, b, a)/b
+	}
+	return front * betaContinuedFraction(x, a, b) / a
+}
+
+// betaContinuedFraction Lentz 算法计算不完全 Beta 函数的连分式
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIter = 300
+		eps     = 1e-14
+		tiny    = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+
+		// 偶数项
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// 奇数项
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < eps {
+			break
+		}
+	}
+	return h
+}
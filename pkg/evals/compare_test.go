@@ -0,0 +1,97 @@
+package evals
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// syntheticResults 构造单评分器的批量结果
+func syntheticResults(scorer string, scores []float64) *BatchEvalResult {
+	result := &BatchEvalResult{}
+	for i, v := range scores {
+		result.Results = append(result.Results, &BatchResult{
+			TestCaseID: fmt.Sprintf("case-%d", i),
+			Scores:     []*ScoreResult{{Name: scorer, Value: v}},
+		})
+	}
+	return result
+}
+
+func TestCompareModels_SignificantDifference(t *testing.T) {
+	a := []float64{0.82, 0.91, 0.78, 0.88, 0.95, 0.84, 0.90, 0.86, 0.79, 0.93, 0.87, 0.89}
+	b := []float64{0.61, 0.72, 0.58, 0.70, 0.74, 0.66, 0.69, 0.71, 0.60, 0.75, 0.64, 0.68}
+
+	for _, method := range []CompareMethod{CompareMethodTTest, CompareMethodBootstrap} {
+		result, err := CompareModels(syntheticResults("accuracy", a), syntheticResults("accuracy", b), &CompareConfig{Method: method, Seed: 1})
+		if err != nil {
+			t.Fatalf("%s: compare: %v", method, err)
+		}
+		if !result.Significant || !result.ABeatsB() {
+			t.Errorf("%s: expected A significantly better, got %+v", method, result)
+		}
+		if result.CILower <= 0 || result.CIUpper < result.CILower {
+			t.Errorf("%s: expected positive CI, got [%f, %f]", method, result.CILower, result.CIUpper)
+		}
+		if !strings.Contains(result.Summary(), "A is significantly better") {
+			t.Errorf("%s: unexpected summary: %s", method, result.Summary())
+		}
+	}
+}
+
+func TestCompareModels_NearTie(t *testing.T) {
+	a := []float64{0.80, 0.75, 0.90, 0.62, 0.85, 0.70, 0.78, 0.88}
+	b := []float64{0.78, 0.79, 0.87, 0.66, 0.84, 0.69, 0.80, 0.86}
+
+	for _, method := range []CompareMethod{CompareMethodTTest, CompareMethodBootstrap} {
+		result, err := CompareModels(syntheticResults("accuracy", a), syntheticResults("accuracy", b), &CompareConfig{Method: method, Seed: 1})
+		if err != nil {
+			t.Fatalf("%s: compare: %v", method, err)
+		}
+		if result.Significant || result.Winner != "" {
+			t.Errorf("%s: expected not significant, got p=%f winner=%q", method, result.PValue, result.Winner)
+		}
+		if result.CILower > 0 || result.CIUpper < 0 {
+			t.Errorf("%s: expected CI to contain 0, got [%f, %f]", method, result.CILower, result.CIUpper)
+		}
+		if !strings.Contains(result.Summary(), "no significant difference") {
+			t.Errorf("%s: unexpected summary: %s", method, result.Summary())
+		}
+	}
+}
+
+func TestCompareModels_LowerIsBetter(t *testing.T) {
+	// 幻觉率：A 更低即更好
+	a := []float64{0.10, 0.05, 0.12, 0.08, 0.07, 0.11, 0.09, 0.06}
+	b := []float64{0.30, 0.28, 0.35, 0.25, 0.31, 0.29, 0.33, 0.27}
+
+	result, err := CompareModels(syntheticResults("hallucination", a), syntheticResults("hallucination", b), &CompareConfig{LowerIsBetter: true})
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if !result.ABeatsB() || result.MeanDiff <= 0 {
+		t.Errorf("expected A better with positive improvement, got %+v", result)
+	}
+}
+
+func TestStudentTTwoSided(t *testing.T) {
+	// 已知值: df=10 时双侧 5% 临界值约为 2.228
+	if p := studentTTwoSided(2.228, 10); math.Abs(p-0.05) > 1e-3 {
+		t.Errorf("expected p≈0.05, got %f", p)
+	}
+	if crit := studentTCritical(0.05, 10); math.Abs(crit-2.228) > 1e-3 {
+		t.Errorf("expected critical≈2.228, got %f", crit)
+	}
+}
+
+func TestCompareModels_Errors(t *testing.T) {
+	if _, err := CompareModels(nil, &BatchEvalResult{}, nil); err == nil {
+		t.Error("expected error for nil results")
+	}
+
+	one := syntheticResults("accuracy", []float64{0.5})
+	if _, err := CompareModels(one, one, nil); err == nil {
+		t.Error("expected error for fewer than 2 pairs")
+	}
+}
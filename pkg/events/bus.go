@@ -51,6 +51,9 @@ type EventBus struct {
 	controlHandlers map[string][]EventHandler
 	monitorHandlers map[string][]EventHandler
 
+	// 事件脱敏，在写入时间线和分发前调用
+	redact func(event any) any
+
 	// 清理 Worker
 	cleanupTicker *time.Ticker
 	cleanupDone   chan struct{}
@@ -184,11 +187,23 @@ func (eb *EventBus) Close() {
 	eb.bookmarks = nil
 }
 
+// SetRedactor 设置事件脱敏函数，返回值替代原事件写入时间线并分发给订阅者
+// 用于在事件离开 Agent 之前隐藏密钥等敏感数据，传入 nil 取消脱敏。
+func (eb *EventBus) SetRedactor(redact func(event any) any) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.redact = redact
+}
+
 // emit 发送事件到总线(内部方法)
 func (eb *EventBus) emit(channel types.AgentChannel, event any) types.AgentEventEnvelope {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
+	if eb.redact != nil {
+		event = eb.redact(event)
+	}
+
 	// 增加cursor
 	eb.cursor++
 
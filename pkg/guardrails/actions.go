@@ -0,0 +1,205 @@
+package guardrails
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+)
+
+var guardrailLog = logging.ForComponent("guardrails")
+
+// Detection 防护栏检测结果，传递给动作
+type Detection struct {
+	// GuardrailName 触发检测的防护栏名称
+	GuardrailName string `json:"guardrail_name"`
+
+	// Trigger 触发类型
+	Trigger CheckTrigger `json:"trigger"`
+
+	// Message 检测消息
+	Message string `json:"message"`
+
+	// Details 详细信息
+	Details map[string]any `json:"details,omitempty"`
+
+	// MaskedContent 防护栏给出的掩码内容（可能为空）
+	MaskedContent string `json:"-"`
+
+	// UserID 用户 ID
+	UserID string `json:"user_id,omitempty"`
+
+	// SessionID 会话 ID
+	SessionID string `json:"session_id,omitempty"`
+
+	err *GuardrailError
+}
+
+// newDetection 根据防护栏错误构造检测结果
+func newDetection(err *GuardrailError, input *GuardrailInput) *Detection {
+	return &Detection{
+		GuardrailName: err.GuardrailName,
+		Trigger:       err.Trigger,
+		Message:       err.Message,
+		Details:       err.Details,
+		MaskedContent: err.MaskedContent,
+		UserID:        input.UserID,
+		SessionID:     input.SessionID,
+		err:           err,
+	}
+}
+
+// Action 检测触发后执行的动作
+// 动作可以修改 input.Content；返回 error 会中止后续动作并拦截请求。
+type Action interface {
+	// Name 返回动作名称
+	Name() string
+
+	// Apply 执行动作
+	Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error
+}
+
+// LogAction 记录检测日志
+type LogAction struct{}
+
+// Log 创建日志动作
+func Log() *LogAction {
+	return &LogAction{}
+}
+
+func (a *LogAction) Name() string { return "log" }
+
+func (a *LogAction) Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error {
+	guardrailLog.Warn(ctx, "guardrail triggered", map[string]any{
+		"guardrail":  detection.GuardrailName,
+		"trigger":    string(detection.Trigger),
+		"message":    detection.Message,
+		"session_id": detection.SessionID,
+	})
+	return nil
+}
+
+// AlertAction 通过 Webhook 发送告警
+// 告警失败只记录日志，不影响请求。
+type AlertAction struct {
+	URL    string
+	Client *http.Client
+}
+
+// Alert 创建 Webhook 告警动作
+func Alert(webhookURL string) *AlertAction {
+	return &AlertAction{
+		URL:    webhookURL,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (a *AlertAction) Name() string { return "alert" }
+
+func (a *AlertAction) Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error {
+	if err := a.send(ctx, detection); err != nil {
+		guardrailLog.Warn(ctx, "guardrail alert failed", map[string]any{
+			"guardrail": detection.GuardrailName,
+			"url":       a.URL,
+			"error":     err.Error(),
+		})
+	}
+	return nil
+}
+
+func (a *AlertAction) send(ctx context.Context, detection *Detection) error {
+	body, err := json.Marshal(detection)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MaskAction 用防护栏给出的掩码内容替换输入
+type MaskAction struct{}
+
+// Mask 创建掩码动作
+func Mask() *MaskAction {
+	return &MaskAction{}
+}
+
+func (a *MaskAction) Name() string { return "mask" }
+
+func (a *MaskAction) Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error {
+	if detection.MaskedContent != "" {
+		input.Content = detection.MaskedContent
+	}
+	return nil
+}
+
+// BlockAction 拦截请求
+type BlockAction struct{}
+
+// Block 创建拦截动作
+func Block() *BlockAction {
+	return &BlockAction{}
+}
+
+func (a *BlockAction) Name() string { return "block" }
+
+func (a *BlockAction) Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error {
+	return detection.err
+}
+
+// TransformFunc 内容转换函数
+type TransformFunc func(ctx context.Context, content string, detection *Detection) (string, error)
+
+// TransformAction 使用自定义函数转换输入内容
+type TransformAction struct {
+	fn TransformFunc
+}
+
+// Transform 创建转换动作
+func Transform(fn TransformFunc) *TransformAction {
+	return &TransformAction{fn: fn}
+}
+
+func (a *TransformAction) Name() string { return "transform" }
+
+func (a *TransformAction) Apply(ctx context.Context, detection *Detection, input *GuardrailInput) error {
+	content, err := a.fn(ctx, input.Content, detection)
+	if err != nil {
+		return fmt.Errorf("guardrail transform failed: %w", err)
+	}
+	input.Content = content
+	return nil
+}
+
+// runActions 依次执行动作，返回第一个错误
+func runActions(ctx context.Context, actions []Action, detection *Detection, input *GuardrailInput) error {
+	for _, action := range actions {
+		if err := action.Apply(ctx, detection, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
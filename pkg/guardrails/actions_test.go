@@ -0,0 +1,110 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// keywordGuardrail 检测关键字的测试防护栏
+type keywordGuardrail struct {
+	keyword string
+}
+
+func (g *keywordGuardrail) Name() string        { return "keyword" }
+func (g *keywordGuardrail) Description() string { return "keyword test guardrail" }
+
+func (g *keywordGuardrail) Check(ctx context.Context, input *GuardrailInput) error {
+	if !strings.Contains(input.Content, g.keyword) {
+		return nil
+	}
+	return &GuardrailError{
+		GuardrailName: g.Name(),
+		Trigger:       CheckTriggerCustom,
+		Message:       "keyword detected",
+		Details:       map[string]any{"keyword": g.keyword},
+	}
+}
+
+func TestGuardrailChain_AlertButAllow(t *testing.T) {
+	var received Detection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	chain := NewGuardrailChain().AddWithActions(
+		NewPIIDetectionGuardrail(WithMaskPII(true)),
+		Log(), Alert(server.URL), Mask(),
+	)
+
+	input := &GuardrailInput{Content: "mail me at bob@example.com", SessionID: "sess-1"}
+	if err := chain.Check(context.Background(), input); err != nil {
+		t.Fatalf("expected request to proceed, got %v", err)
+	}
+
+	if received.GuardrailName != "PIIDetection" || received.Trigger != CheckTriggerPIIDetected {
+		t.Errorf("unexpected alert payload: %+v", received)
+	}
+	if received.SessionID != "sess-1" || received.Details["detected_pii"] == nil {
+		t.Errorf("alert should carry detection details, got %+v", received)
+	}
+	if strings.Contains(input.Content, "bob@example.com") {
+		t.Errorf("expected content to be masked, got %q", input.Content)
+	}
+}
+
+func TestGuardrailChain_TransformThenContinue(t *testing.T) {
+	var seen *Detection
+	chain := NewGuardrailChain().
+		AddWithActions(&keywordGuardrail{keyword: "secret"}, Transform(
+			func(ctx context.Context, content string, d *Detection) (string, error) {
+				seen = d
+				return strings.ReplaceAll(content, "secret", "[redacted]"), nil
+			})).
+		Add(&keywordGuardrail{keyword: "secret"})
+
+	input := &GuardrailInput{Content: "the secret plan"}
+	if err := chain.Check(context.Background(), input); err != nil {
+		t.Fatalf("expected request to proceed, got %v", err)
+	}
+
+	if input.Content != "the [redacted] plan" {
+		t.Errorf("expected transformed content, got %q", input.Content)
+	}
+	if seen == nil || seen.Details["keyword"] != "secret" {
+		t.Errorf("transform should receive detection details, got %+v", seen)
+	}
+}
+
+func TestGuardrailChain_BlockAction(t *testing.T) {
+	alerted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alerted = true
+	}))
+	defer server.Close()
+
+	chain := NewGuardrailChain().AddWithActions(&keywordGuardrail{keyword: "bad"}, Alert(server.URL), Block())
+
+	err := chain.Check(context.Background(), &GuardrailInput{Content: "bad input"})
+	var guardErr *GuardrailError
+	if !errors.As(err, &guardErr) || guardErr.GuardrailName != "keyword" {
+		t.Fatalf("expected guardrail error from block action, got %v", err)
+	}
+	if !alerted {
+		t.Error("expected alert to fire before block")
+	}
+
+	// 未配置动作的防护栏保持原有拦截行为
+	plain := NewGuardrailChain(&keywordGuardrail{keyword: "bad"})
+	if err := plain.Check(context.Background(), &GuardrailInput{Content: "bad input"}); err == nil {
+		t.Error("expected guardrail without actions to block")
+	}
+}
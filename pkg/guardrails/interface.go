@@ -81,21 +81,35 @@ const (
 )
 
 // GuardrailChain 防护栏链 - 依次执行多个防护栏
+// 为防护栏配置动作后，检测结果交给动作处理，只有 Block 动作才会拦截请求。
 type GuardrailChain struct {
 	guardrails []Guardrail
+	actions    map[string][]Action
 }
 
 // NewGuardrailChain 创建防护栏链
 func NewGuardrailChain(guardrails ...Guardrail) *GuardrailChain {
 	return &GuardrailChain{
 		guardrails: guardrails,
+		actions:    make(map[string][]Action),
 	}
 }
 
 // Check 依次执行所有防护栏检查
+// 动作可能修改 input.Content，后续防护栏和调用方使用修改后的内容。
 func (gc *GuardrailChain) Check(ctx context.Context, input *GuardrailInput) error {
 	for _, g := range gc.guardrails {
-		if err := g.Check(ctx, input); err != nil {
+		err := g.Check(ctx, input)
+		if err == nil {
+			continue
+		}
+
+		actions, ok := gc.actions[g.Name()]
+		guardErr, isGuardErr := err.(*GuardrailError)
+		if !ok || !isGuardErr {
+			return err
+		}
+		if err := runActions(ctx, actions, newDetection(guardErr, input), input); err != nil {
 			return err
 		}
 	}
@@ -108,6 +122,21 @@ func (gc *GuardrailChain) Add(guardrail Guardrail) *GuardrailChain {
 	return gc
 }
 
+// AddWithActions 添加防护栏并配置检测后依次执行的动作
+func (gc *GuardrailChain) AddWithActions(guardrail Guardrail, actions ...Action) *GuardrailChain {
+	gc.guardrails = append(gc.guardrails, guardrail)
+	return gc.WithActions(guardrail.Name(), actions...)
+}
+
+// WithActions 为指定名称的防护栏配置动作
+func (gc *GuardrailChain) WithActions(guardrailName string, actions ...Action) *GuardrailChain {
+	if gc.actions == nil {
+		gc.actions = make(map[string][]Action)
+	}
+	gc.actions[guardrailName] = actions
+	return gc
+}
+
 // Guardrails 获取所有防护栏
 func (gc *GuardrailChain) Guardrails() []Guardrail {
 	return gc.guardrails
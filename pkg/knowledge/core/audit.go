@@ -0,0 +1,65 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// 审计动作。
+const (
+	AuditActionIngest = "ingest"
+	AuditActionSearch = "search"
+)
+
+// AuditEntry 管线审计记录。
+type AuditEntry struct {
+	Time      time.Time
+	Action    string // ingest / search
+	Namespace string // 被拒绝的请求为空
+	Target    string // 文档 ID 或查询文本
+	Count     int    // 写入的分块数或返回的命中数
+	Error     string
+}
+
+// AuditLog 管线审计日志。
+type AuditLog interface {
+	Record(entry AuditEntry)
+}
+
+// MemoryAuditLog 内存审计日志，超过上限时丢弃最旧的记录。
+type MemoryAuditLog struct {
+	mu      sync.Mutex
+	limit   int
+	entries []AuditEntry
+}
+
+// NewMemoryAuditLog 创建内存审计日志，limit <= 0 时默认保留 1000 条。
+func NewMemoryAuditLog(limit int) *MemoryAuditLog {
+	if limit <= 0 {
+		limit = 1000
+	}
+	return &MemoryAuditLog{limit: limit}
+}
+
+// Record 记录一条审计日志。
+func (l *MemoryAuditLog) Record(entry AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.limit {
+		l.entries = l.entries[len(l.entries)-l.limit:]
+	}
+}
+
+// Entries 返回指定命名空间的审计记录，namespace 为空时返回全部。
+func (l *MemoryAuditLog) Entries(namespace string) []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]AuditEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		if namespace == "" || e.Namespace == namespace {
+			out = append(out, e)
+		}
+	}
+	return out
+}
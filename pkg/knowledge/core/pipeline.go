@@ -11,12 +11,21 @@ import (
 	"github.com/astercloud/aster/pkg/vector"
 )
 
+// ErrNamespaceRequired 严格命名空间模式下请求缺少显式命名空间。
+var ErrNamespaceRequired = errors.New("knowledge core: explicit namespace is required in strict mode")
+
 // PipelineConfig 轻量 RAG 管线配置。
 type PipelineConfig struct {
 	Store       vector.VectorStore
 	Embedder    vector.Embedder
 	Namespace   string
 	DefaultTopK int
+
+	// StrictNamespace 要求每次 ingest/search 显式指定命名空间，不回退到默认命名空间。
+	StrictNamespace bool
+
+	// Audit 可选的审计日志，记录每个命名空间的 ingest/search。
+	Audit AuditLog
 }
 
 // Pipeline 提供最小 ingest/search 能力，不依赖高级特性。
@@ -25,6 +34,8 @@ type Pipeline struct {
 	embedder  vector.Embedder
 	namespace string
 	defaultK  int
+	strict    bool
+	audit     AuditLog
 }
 
 // NewPipeline 创建管线实例。
@@ -47,11 +58,54 @@ func NewPipeline(cfg PipelineConfig) (*Pipeline, error) {
 		embedder:  cfg.Embedder,
 		namespace: ns,
 		defaultK:  cfg.DefaultTopK,
+		strict:    cfg.StrictNamespace,
+		audit:     cfg.Audit,
 	}, nil
 }
 
+// resolveNamespace 确定请求使用的命名空间；严格模式下不允许回退到默认值。
+func (p *Pipeline) resolveNamespace(explicit string) (string, error) {
+	if ns := strings.TrimSpace(explicit); ns != "" {
+		return ns, nil
+	}
+	if p.strict {
+		return "", ErrNamespaceRequired
+	}
+	return p.namespace, nil
+}
+
+// record 写入审计日志。
+func (p *Pipeline) record(action, namespace, target string, count int, err error) {
+	if p.audit == nil {
+		return
+	}
+	entry := AuditEntry{
+		Time:      time.Now(),
+		Action:    action,
+		Namespace: namespace,
+		Target:    target,
+		Count:     count,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	p.audit.Record(entry)
+}
+
 // Ingest 将文本切分并写入向量库。
 func (p *Pipeline) Ingest(ctx context.Context, req IngestRequest) ([]Chunk, error) {
+	ns, err := p.resolveNamespace(req.Namespace)
+	if err != nil {
+		p.record(AuditActionIngest, "", req.ID, 0, err)
+		return nil, err
+	}
+
+	chunks, err := p.ingest(ctx, ns, req)
+	p.record(AuditActionIngest, ns, req.ID, len(chunks), err)
+	return chunks, err
+}
+
+func (p *Pipeline) ingest(ctx context.Context, ns string, req IngestRequest) ([]Chunk, error) {
 	if strings.TrimSpace(req.Text) == "" {
 		return nil, errors.New("knowledge core: text is empty")
 	}
@@ -59,16 +113,11 @@ func (p *Pipeline) Ingest(ctx context.Context, req IngestRequest) ([]Chunk, erro
 	if id == "" {
 		id = fmt.Sprintf("doc-%d", time.Now().UnixNano())
 	}
-	ns := p.namespace
-	if strings.TrimSpace(req.Namespace) != "" {
-		ns = strings.TrimSpace(req.Namespace)
-	}
 
 	meta := make(map[string]any)
 	maps.Copy(meta, req.Metadata)
-	if ns != "" {
-		meta["namespace"] = ns
-	}
+	// 命名空间始终以解析结果为准，元数据中的同名字段不能覆盖
+	meta["namespace"] = ns
 
 	rawChunks := splitParagraphs(req.Text)
 	if len(rawChunks) == 0 {
@@ -115,7 +164,22 @@ func (p *Pipeline) Ingest(ctx context.Context, req IngestRequest) ([]Chunk, erro
 }
 
 // Search 执行向量检索。
+// 命名空间通过 metadata["namespace"] 指定；结果只包含该命名空间的分块，
+// 即使底层存储忽略命名空间或过滤条件过宽也不会返回其他命名空间的数据。
 func (p *Pipeline) Search(ctx context.Context, query string, topK int, metadata map[string]any) ([]SearchHit, error) {
+	explicit, _ := metadata["namespace"].(string)
+	ns, err := p.resolveNamespace(explicit)
+	if err != nil {
+		p.record(AuditActionSearch, "", query, 0, err)
+		return nil, err
+	}
+
+	hits, err := p.search(ctx, ns, query, topK, metadata)
+	p.record(AuditActionSearch, ns, query, len(hits), err)
+	return hits, err
+}
+
+func (p *Pipeline) search(ctx context.Context, ns, query string, topK int, metadata map[string]any) ([]SearchHit, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, errors.New("knowledge core: query is empty")
 	}
@@ -123,11 +187,10 @@ func (p *Pipeline) Search(ctx context.Context, query string, topK int, metadata
 		topK = p.defaultK
 	}
 
-	ns := p.namespace
-	if metadata != nil {
-		if override, ok := metadata["namespace"].(string); ok && strings.TrimSpace(override) != "" {
-			ns = strings.TrimSpace(override)
-		}
+	filter := make(map[string]any, len(metadata))
+	maps.Copy(filter, metadata)
+	if len(filter) > 0 {
+		filter["namespace"] = ns
 	}
 
 	vecs, err := p.embedder.EmbedText(ctx, []string{query})
@@ -142,7 +205,7 @@ func (p *Pipeline) Search(ctx context.Context, query string, topK int, metadata
 		Vector:    vecs[0],
 		TopK:      topK,
 		Namespace: ns,
-		Filter:    metadata,
+		Filter:    filter,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("vector query: %w", err)
@@ -150,6 +213,10 @@ func (p *Pipeline) Search(ctx context.Context, query string, topK int, metadata
 
 	out := make([]SearchHit, 0, len(hits))
 	for _, h := range hits {
+		// 租户隔离：丢弃不属于本命名空间的命中
+		if !p.inNamespace(h.Metadata, ns) {
+			continue
+		}
 		text := ""
 		if h.Metadata != nil {
 			if t, ok := h.Metadata["text"].(string); ok {
@@ -166,6 +233,15 @@ func (p *Pipeline) Search(ctx context.Context, query string, topK int, metadata
 	return out, nil
 }
 
+// inNamespace 判断命中是否属于 ns。
+// 没有命名空间元数据的分块（如直接写入向量库的旧数据）视为默认命名空间，严格模式下一律丢弃。
+func (p *Pipeline) inNamespace(metadata map[string]any, ns string) bool {
+	if hitNS, ok := metadata["namespace"].(string); ok && hitNS != "" {
+		return hitNS == ns
+	}
+	return !p.strict && ns == p.namespace
+}
+
 // splitParagraphs 进行简单段落切分。
 func splitParagraphs(text string) []string {
 	segs := strings.Split(text, "\n\n")
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/astercloud/aster/pkg/vector"
@@ -57,3 +59,121 @@ func TestPipeline_IngestEmpty(t *testing.T) {
 		t.Fatalf("expected error for empty text")
 	}
 }
+
+// leakyStore 忽略命名空间和过滤条件的存储，模拟实现不完整的向量库
+type leakyStore struct {
+	*vector.MemoryStore
+	docs []vector.Document
+}
+
+func (s *leakyStore) Upsert(ctx context.Context, docs []vector.Document) error {
+	s.docs = append(s.docs, docs...)
+	return nil
+}
+
+func (s *leakyStore) Query(ctx context.Context, q vector.Query) ([]vector.Hit, error) {
+	hits := make([]vector.Hit, 0, len(s.docs))
+	for _, d := range s.docs {
+		hits = append(hits, vector.Hit{ID: d.ID, Score: 1, Metadata: d.Metadata})
+	}
+	return hits, nil
+}
+
+func TestPipeline_StrictNamespace(t *testing.T) {
+	audit := NewMemoryAuditLog(0)
+	pipe, err := NewPipeline(PipelineConfig{
+		Store:           vector.NewMemoryStore(),
+		Embedder:        vector.NewMockEmbedder(8),
+		Namespace:       "shared",
+		StrictNamespace: true,
+		Audit:           audit,
+	})
+	if err != nil {
+		t.Fatalf("new pipeline: %v", err)
+	}
+
+	if _, err := pipe.Search(context.Background(), "anything", 3, map[string]any{"source": "test"}); !errors.Is(err, ErrNamespaceRequired) {
+		t.Fatalf("expected ErrNamespaceRequired for search, got %v", err)
+	}
+	if _, err := pipe.Ingest(context.Background(), IngestRequest{Text: "doc"}); !errors.Is(err, ErrNamespaceRequired) {
+		t.Fatalf("expected ErrNamespaceRequired for ingest, got %v", err)
+	}
+	if _, err := pipe.Ingest(context.Background(), IngestRequest{ID: "a1", Text: "doc", Namespace: "tenant-a"}); err != nil {
+		t.Fatalf("ingest with namespace: %v", err)
+	}
+
+	entries := audit.Entries("tenant-a")
+	if len(entries) != 1 || entries[0].Action != AuditActionIngest || entries[0].Count != 1 {
+		t.Errorf("unexpected audit entries for tenant-a: %+v", entries)
+	}
+	if rejected := audit.Entries(""); len(rejected) != 3 || rejected[0].Error == "" {
+		t.Errorf("expected rejected requests to be audited, got %+v", rejected)
+	}
+}
+
+func TestPipeline_TenantIsolation(t *testing.T) {
+	for name, store := range map[string]vector.VectorStore{
+		"memory": vector.NewMemoryStore(),
+		"leaky":  &leakyStore{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pipe, _ := NewPipeline(PipelineConfig{Store: store, Embedder: vector.NewMockEmbedder(8)})
+			ctx := context.Background()
+			for _, ns := range []string{"tenant-a", "tenant-b"} {
+				_, err := pipe.Ingest(ctx, IngestRequest{
+					ID:        ns + "-doc",
+					Text:      "quarterly revenue report",
+					Namespace: ns,
+					// 元数据中的命名空间不能覆盖请求命名空间
+					Metadata: map[string]any{"namespace": "tenant-a", "source": "shared"},
+				})
+				if err != nil {
+					t.Fatalf("ingest %s: %v", ns, err)
+				}
+			}
+
+			hits, err := pipe.Search(ctx, "quarterly revenue report", 10, map[string]any{"namespace": "tenant-a", "source": "shared"})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(hits) == 0 {
+				t.Fatal("expected tenant-a hits")
+			}
+			for _, h := range hits {
+				if !strings.HasPrefix(h.ID, "tenant-a-") {
+					t.Errorf("tenant-a search leaked chunk %s", h.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_HitsWithoutNamespace(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		store := &leakyStore{}
+		// 直接写入向量库、没有命名空间元数据的旧数据
+		store.docs = append(store.docs, vector.Document{ID: "legacy#0", Metadata: map[string]any{"text": "legacy doc"}})
+
+		pipe, _ := NewPipeline(PipelineConfig{Store: store, Embedder: vector.NewMockEmbedder(8), StrictNamespace: strict})
+		ctx := context.Background()
+		if _, err := pipe.Ingest(ctx, IngestRequest{ID: "tenant-a-doc", Text: "tenant doc", Namespace: "tenant-a"}); err != nil {
+			t.Fatalf("ingest: %v", err)
+		}
+
+		hits, err := pipe.Search(ctx, "doc", 10, map[string]any{"namespace": "default"})
+		if err != nil {
+			t.Fatalf("search default: %v", err)
+		}
+		if found := len(hits) == 1 && hits[0].ID == "legacy#0"; found == strict {
+			t.Errorf("strict=%v: unexpected default namespace hits %+v", strict, hits)
+		}
+
+		hits, err = pipe.Search(ctx, "doc", 10, map[string]any{"namespace": "tenant-a"})
+		if err != nil {
+			t.Fatalf("search tenant-a: %v", err)
+		}
+		if len(hits) != 1 || hits[0].ID != "tenant-a-doc#0" {
+			t.Errorf("strict=%v: legacy chunk leaked into tenant-a: %+v", strict, hits)
+		}
+	}
+}
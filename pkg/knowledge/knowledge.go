@@ -174,6 +174,7 @@ type ManagerConfig struct {
 
 	// 轻量核心管线
 	UseCorePipeline bool `json:"use_core_pipeline"` // 启用轻量 ingest/search 管线
+	StrictNamespace bool `json:"strict_namespace"`  // 核心管线要求每次请求显式指定命名空间
 
 	// 可选策略注入
 	PIIStrategy   PIIStrategy   `json:"-"`
@@ -149,6 +149,8 @@ func NewManager(config *ManagerConfig) (Manager, error) {
 			Embedder:    config.Embedder,
 			Namespace:   config.Namespace,
 			DefaultTopK: config.MaxResults,
+
+			StrictNamespace: config.StrictNamespace,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("knowledge: init core pipeline: %w", err)
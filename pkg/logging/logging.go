@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -27,6 +29,14 @@ type LogRecord struct {
 	Fields    map[string]any `json:"fields,omitempty"`
 }
 
+// LogValuer 自定义字段写入日志时的取值（如去除图片数据等大体积内容）
+// 字段值或切片元素实现该接口时，transport 收到的是 LogValue 的结果
+type LogValuer interface {
+	LogValue() any
+}
+
+var logValuerType = reflect.TypeFor[LogValuer]()
+
 // Transport 日志输出通道接口
 // 设计参考: 常见的多目标日志管道实现, 但尽量保持简单
 type Transport interface {
@@ -77,7 +87,7 @@ func (l *Logger) log(ctx context.Context, level Level, msg string, fields map[st
 		Timestamp: time.Now(),
 		Level:     level,
 		Message:   msg,
-		Fields:    fields,
+		Fields:    logFields(fields),
 	}
 
 	l.mu.RLock()
@@ -88,6 +98,57 @@ func (l *Logger) log(ctx context.Context, level Level, msg string, fields map[st
 	}
 }
 
+// logFields 返回替换了 LogValuer 取值的字段，不修改调用方的 map
+func logFields(fields map[string]any) map[string]any {
+	var out map[string]any
+	for k, v := range fields {
+		lv, ok := logValue(v)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(fields)
+		}
+		out[k] = lv
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// logValue 返回 v 的日志取值，v（或其切片元素）未实现 LogValuer 时 ok 为 false
+func logValue(v any) (any, bool) {
+	if lv, ok := v.(LogValuer); ok {
+		return lv.LogValue(), true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return v, false
+	}
+	elem := rv.Type().Elem()
+	if elem.Kind() != reflect.Interface && !elem.Implements(logValuerType) {
+		return v, false
+	}
+
+	out := make([]any, rv.Len())
+	changed := false
+	for i := range out {
+		item := rv.Index(i).Interface()
+		if lv, ok := item.(LogValuer); ok {
+			out[i] = lv.LogValue()
+			changed = true
+		} else {
+			out[i] = item
+		}
+	}
+	if !changed {
+		return v, false
+	}
+	return out, true
+}
+
 func (l *Logger) enabled(level Level) bool {
 	// 简单的级别优先级比较: debug < info < warn < error
 	order := map[Level]int{
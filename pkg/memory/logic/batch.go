@@ -0,0 +1,159 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// BatchSaver 可选接口：支持在单个事务中批量保存 Memory 的存储
+// Manager.ProcessEvents 通过它一次性写入批处理结果，否则逐条 Save
+type BatchSaver interface {
+	SaveBatch(ctx context.Context, memories []*LogicMemory) error
+}
+
+// sqlExecer *sql.DB 与 *sql.Tx 的公共执行接口
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// BatchResult ProcessEvents 处理摘要
+type BatchResult struct {
+	// Events 处理的事件数
+	Events int
+
+	// Matched Matcher 识别出的候选 Memory 总数
+	Matched int
+
+	// Created 新建的 Memory 数
+	Created int
+
+	// Updated 更新的已有 Memory 数
+	Updated int
+
+	// Deduped 合并到批内同 Namespace+Key 候选中的数量（不单独写入）
+	Deduped int
+
+	// Rejected 因冲突策略被拒绝的候选数
+	Rejected int
+}
+
+// batchEntry 批内待写入的 Memory
+type batchEntry struct {
+	mem     *LogicMemory
+	created bool
+	before  float64
+}
+
+// ProcessEvents 批量处理事件（如回放会话日志）
+// 批内同 Namespace+Key 的候选先在内存中合并，与逐条 ProcessEvent 的置信度提升结果一致，
+// 最终每个 Key 只写入一次；存储实现 BatchSaver 时在单个事务中写入
+func (m *Manager) ProcessEvents(ctx context.Context, events []Event) (*BatchResult, error) {
+	result := &BatchResult{Events: len(events)}
+	if len(m.matchers) == 0 {
+		return result, nil
+	}
+
+	pending := make(map[string]*batchEntry)
+	var order []string
+	for _, event := range events {
+		for _, mem := range m.matchEvent(ctx, event) {
+			result.Matched++
+			key := makeKey(mem.Namespace, mem.Key)
+
+			if entry, ok := pending[key]; ok {
+				if err := m.mergeInto(entry.mem, mem); err != nil {
+					if !errors.Is(err, ErrMemoryConflict) {
+						return nil, err
+					}
+					result.Rejected++
+					continue
+				}
+				result.Deduped++
+				continue
+			}
+
+			entry, err := m.newBatchEntry(ctx, mem)
+			if err != nil {
+				if errors.Is(err, ErrMemoryConflict) {
+					result.Rejected++
+					continue
+				}
+				return nil, err
+			}
+			pending[key] = entry
+			order = append(order, key)
+		}
+	}
+
+	memories := make([]*LogicMemory, 0, len(order))
+	for _, key := range order {
+		entry := pending[key]
+		m.ensureEmbedding(ctx, entry.mem)
+		memories = append(memories, entry.mem)
+	}
+	if err := m.saveBatch(ctx, memories); err != nil {
+		return nil, err
+	}
+
+	for _, key := range order {
+		entry := pending[key]
+		if entry.created {
+			result.Created++
+			m.emit(MemoryEvent{
+				Type:      MemoryEventCreated,
+				Namespace: entry.mem.Namespace,
+				Key:       entry.mem.Key,
+				Memory:    entry.mem,
+			})
+			continue
+		}
+		result.Updated++
+		m.emit(MemoryEvent{
+			Type:            MemoryEventUpdated,
+			Namespace:       entry.mem.Namespace,
+			Key:             entry.mem.Key,
+			Memory:          entry.mem,
+			ConfidenceDelta: confidenceOf(entry.mem) - entry.before,
+		})
+	}
+	return result, nil
+}
+
+// newBatchEntry 为批内首次出现的 Key 创建待写入条目，已存在时合并到存储中的 Memory
+func (m *Manager) newBatchEntry(ctx context.Context, mem *LogicMemory) (*batchEntry, error) {
+	existing, err := m.getLive(ctx, mem.Namespace, mem.Key)
+	if err != nil && !errors.Is(err, ErrMemoryNotFound) {
+		return nil, err
+	}
+	if err == nil && existing != nil {
+		before := confidenceOf(existing)
+		if err := m.mergeInto(existing, mem); err != nil {
+			return nil, err
+		}
+		return &batchEntry{mem: existing, before: before}, nil
+	}
+
+	// 拷贝 Provenance，避免后续批内合并修改共享的默认溯源
+	if mem.Provenance != nil {
+		provenance := *mem.Provenance
+		mem.Provenance = &provenance
+	}
+	return &batchEntry{mem: mem, created: true}, nil
+}
+
+// saveBatch 批量写入，存储不支持事务时逐条保存
+func (m *Manager) saveBatch(ctx context.Context, memories []*LogicMemory) error {
+	if len(memories) == 0 {
+		return nil
+	}
+	if saver, ok := m.store.(BatchSaver); ok {
+		return saver.SaveBatch(ctx, memories)
+	}
+	for _, mem := range memories {
+		if err := m.store.Save(ctx, mem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
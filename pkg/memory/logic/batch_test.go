@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dataMatcher 根据事件数据生成新的候选 Memory
+type dataMatcher struct{}
+
+func (dataMatcher) MatchEvent(_ context.Context, event Event) ([]*LogicMemory, error) {
+	return []*LogicMemory{{
+		Namespace:  event.Source,
+		Type:       "preference",
+		Key:        event.Data["key"].(string),
+		Value:      event.Data["value"],
+		Provenance: &memory.MemoryProvenance{Confidence: 0.5, Sources: []string{event.Data["id"].(string)}},
+	}}, nil
+}
+
+func (dataMatcher) SupportedEventTypes() []string { return nil }
+
+// countingStore 统计批量写入与单条写入次数
+type countingStore struct {
+	*InMemoryStore
+	batches int
+	saves   int
+}
+
+func (s *countingStore) Save(ctx context.Context, mem *LogicMemory) error {
+	s.saves++
+	return s.InMemoryStore.Save(ctx, mem)
+}
+
+func (s *countingStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	s.batches++
+	return s.InMemoryStore.SaveBatch(ctx, memories)
+}
+
+func replayEvents() []Event {
+	newEvent := func(id, key, value string) Event {
+		return Event{Type: "user_message", Source: "user:1", Data: map[string]any{"id": id, "key": key, "value": value}}
+	}
+	return []Event{
+		newEvent("e1", "tone", "casual"),
+		newEvent("e2", "tone", "casual"),
+		newEvent("e3", "language", "go"),
+		newEvent("e4", "tone", "casual"),
+	}
+}
+
+func TestProcessEvents_MatchesSequentialProcessing(t *testing.T) {
+	ctx := context.Background()
+
+	sequential, err := NewManager(&ManagerConfig{Store: NewInMemoryStore(), Matchers: []PatternMatcher{dataMatcher{}}})
+	require.NoError(t, err)
+	for _, event := range replayEvents() {
+		require.NoError(t, sequential.ProcessEvent(ctx, event))
+	}
+
+	store := &countingStore{InMemoryStore: NewInMemoryStore()}
+	batch, err := NewManager(&ManagerConfig{Store: store, Matchers: []PatternMatcher{dataMatcher{}}})
+	require.NoError(t, err)
+	result, err := batch.ProcessEvents(ctx, replayEvents())
+	require.NoError(t, err)
+
+	assert.Equal(t, &BatchResult{Events: 4, Matched: 4, Created: 2, Deduped: 2}, result)
+	assert.Equal(t, 1, store.batches, "batch should be written in a single SaveBatch call")
+	assert.Zero(t, store.saves)
+
+	for _, key := range []string{"tone", "language"} {
+		want, err := sequential.store.Get(ctx, "user:1", key)
+		require.NoError(t, err)
+		got, err := store.Get(ctx, "user:1", key)
+		require.NoError(t, err)
+		assert.InDelta(t, want.Provenance.Confidence, got.Provenance.Confidence, 1e-9, key)
+		assert.Equal(t, want.Provenance.Sources, got.Provenance.Sources, key)
+		assert.Equal(t, want.AccessCount, got.AccessCount, key)
+	}
+
+	// 再次回放时更新已有 Memory
+	result, err = batch.ProcessEvents(ctx, replayEvents()[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	got, err := store.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.65, got.Provenance.Confidence, 1e-9)
+}
+
+func TestProcessEvents_RejectsConflicts(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{
+		Store:          NewInMemoryStore(),
+		Matchers:       []PatternMatcher{dataMatcher{}},
+		ConflictPolicy: ConflictPolicyReject,
+	})
+	require.NoError(t, err)
+
+	events := replayEvents()
+	events[1].Data["value"] = "formal"
+	result, err := manager.ProcessEvents(ctx, events)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rejected)
+	assert.Equal(t, 1, result.Deduped)
+
+	mem, err := manager.GetMemory(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.Equal(t, "casual", mem.Value)
+}
@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+)
+
+// ConflictPolicy 同一 Key 记录到矛盾 Value 时的处理策略
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyMerge 默认策略：按重复观察处理（覆盖 Value 并提升置信度），不记录冲突
+	ConflictPolicyMerge ConflictPolicy = ""
+
+	// ConflictPolicyKeepLatest 采用新 Value 和新置信度，旧 Value 记入冲突历史
+	ConflictPolicyKeepLatest ConflictPolicy = "keep_latest"
+
+	// ConflictPolicyKeepHighestConfidence 保留置信度更高的一方，另一方记入冲突历史
+	ConflictPolicyKeepHighestConfidence ConflictPolicy = "keep_highest_confidence"
+
+	// ConflictPolicyReject 拒绝矛盾的新 Value，返回 ErrMemoryConflict
+	ConflictPolicyReject ConflictPolicy = "reject"
+)
+
+// ErrMemoryConflict 新 Value 与已有 Memory 矛盾且策略为 Reject
+var ErrMemoryConflict = errors.New("logic memory conflict")
+
+// conflictsMetadataKey 冲突历史在 Metadata 中的键
+const conflictsMetadataKey = "_conflicts"
+
+// MemoryConflict 一对相互矛盾的 Memory（同 Key 不同 Value）
+type MemoryConflict struct {
+	// Namespace 命名空间
+	Namespace string
+
+	// Key Memory 键
+	Key string
+
+	// Current 当前生效的 Memory
+	Current *LogicMemory
+
+	// Conflicting 被替换或被拒绝保留的矛盾 Memory
+	Conflicting *LogicMemory
+}
+
+// DetectConflicts 返回命名空间内的冲突对
+// 冲突在记录时按 ConflictPolicy 检测并保存在 Memory 的冲突历史中，
+// 默认策略 ConflictPolicyMerge 不记录冲突
+func (m *Manager) DetectConflicts(ctx context.Context, namespace string) ([]MemoryConflict, error) {
+	memories, err := m.store.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []MemoryConflict
+	for _, mem := range memories {
+		for _, record := range conflictRecords(mem) {
+			conflicts = append(conflicts, MemoryConflict{
+				Namespace:   mem.Namespace,
+				Key:         mem.Key,
+				Current:     mem,
+				Conflicting: record.toMemory(mem),
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// resolveConflict 按策略处理矛盾的新 Memory，返回 true 表示已处理（不再走普通合并）
+func (m *Manager) resolveConflict(existing, incoming *LogicMemory) (bool, error) {
+	policy := m.config.ConflictPolicy
+	if policy == ConflictPolicyMerge || !valuesConflict(existing.Value, incoming.Value) {
+		return false, nil
+	}
+
+	switch policy {
+	case ConflictPolicyReject:
+		return true, fmt.Errorf("%w: %s/%s", ErrMemoryConflict, existing.Namespace, existing.Key)
+	case ConflictPolicyKeepHighestConfidence:
+		if confidenceOf(incoming) <= confidenceOf(existing) {
+			appendConflictRecord(existing, newConflictRecord(incoming))
+			existing.UpdatedAt = time.Now()
+			return true, nil
+		}
+	case ConflictPolicyKeepLatest:
+	default:
+		return false, fmt.Errorf("unknown conflict policy: %s", policy)
+	}
+
+	// 用新 Value 替换，旧 Value 记入冲突历史
+	appendConflictRecord(existing, newConflictRecord(existing))
+	existing.Value = incoming.Value
+	if incoming.Description != "" && incoming.Description != existing.Description {
+		existing.Description = incoming.Description
+		existing.Embedding = nil
+	}
+	if incoming.Provenance != nil {
+		provenance := *incoming.Provenance
+		existing.Provenance = &provenance
+	}
+	existing.UpdatedAt = time.Now()
+	existing.LastAccessed = existing.UpdatedAt
+	return true, nil
+}
+
+// valuesConflict 判断两个 Value 是否不同
+// 以 JSON 形式比较，避免存储往返后 int/float64、map 类型差异造成误判
+func valuesConflict(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return fmt.Sprint(a) != fmt.Sprint(b)
+	}
+	return !bytes.Equal(ja, jb)
+}
+
+// conflictRecord 冲突历史条目（以 JSON 兼容结构保存在 Metadata 中）
+type conflictRecord struct {
+	Value       any       `json:"value"`
+	Description string    `json:"description,omitempty"`
+	Confidence  float64   `json:"confidence"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+func newConflictRecord(mem *LogicMemory) conflictRecord {
+	return conflictRecord{
+		Value:       mem.Value,
+		Description: mem.Description,
+		Confidence:  confidenceOf(mem),
+		RecordedAt:  time.Now(),
+	}
+}
+
+// toMemory 将冲突历史还原为 Memory 快照
+func (r conflictRecord) toMemory(current *LogicMemory) *LogicMemory {
+	return &LogicMemory{
+		Namespace:   current.Namespace,
+		Scope:       current.Scope,
+		Type:        current.Type,
+		Key:         current.Key,
+		Value:       r.Value,
+		Description: r.Description,
+		Provenance:  &memory.MemoryProvenance{Confidence: r.Confidence},
+		CreatedAt:   r.RecordedAt,
+		UpdatedAt:   r.RecordedAt,
+	}
+}
+
+// appendConflictRecord 追加冲突历史
+func appendConflictRecord(mem *LogicMemory, record conflictRecord) {
+	records := append(conflictRecords(mem), record)
+	// 拷贝 Metadata，避免修改存储层共享的 map
+	metadata := maps.Clone(mem.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[conflictsMetadataKey] = records
+	mem.Metadata = metadata
+}
+
+// conflictRecords 读取冲突历史，兼容存储往返后的 []any 形式
+func conflictRecords(mem *LogicMemory) []conflictRecord {
+	raw, ok := mem.Metadata[conflictsMetadataKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	if records, ok := raw.([]conflictRecord); ok {
+		return append([]conflictRecord(nil), records...)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var records []conflictRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil
+	}
+	return records
+}
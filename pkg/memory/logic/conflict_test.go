@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordValue(t *testing.T, manager *Manager, key string, value any, confidence float64) error {
+	t.Helper()
+	return manager.RecordMemory(context.Background(), &LogicMemory{
+		Namespace:  "user:1",
+		Type:       "preference",
+		Key:        key,
+		Value:      value,
+		Provenance: &memory.MemoryProvenance{Confidence: confidence},
+	})
+}
+
+func TestValuesConflict(t *testing.T) {
+	assert.False(t, valuesConflict("casual", "casual"))
+	assert.True(t, valuesConflict("casual", "formal"))
+	assert.False(t, valuesConflict(true, true))
+	assert.True(t, valuesConflict(true, false))
+	assert.False(t, valuesConflict(3, 3.0), "numbers should compare across int/float64 after store round-trips")
+	assert.False(t, valuesConflict(map[string]any{"a": 1}, map[string]any{"a": float64(1)}))
+}
+
+func TestConflictPolicy_KeepLatest(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore(), ConflictPolicy: ConflictPolicyKeepLatest})
+	require.NoError(t, err)
+	events := manager.Subscribe("")
+
+	require.NoError(t, recordValue(t, manager, "writing_tone", "casual", 0.8))
+	require.NoError(t, recordValue(t, manager, "writing_tone", "formal", 0.6))
+
+	mem, err := manager.GetMemory(ctx, "user:1", "writing_tone")
+	require.NoError(t, err)
+	assert.Equal(t, "formal", mem.Value)
+	assert.Equal(t, 0.6, mem.Provenance.Confidence, "conflicting value should not be boosted")
+
+	conflicts, err := manager.DetectConflicts(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "writing_tone", conflicts[0].Key)
+	assert.Equal(t, "formal", conflicts[0].Current.Value)
+	assert.Equal(t, "casual", conflicts[0].Conflicting.Value)
+	assert.Equal(t, 0.8, conflicts[0].Conflicting.Provenance.Confidence)
+
+	assert.Equal(t, MemoryEventCreated, (<-events).Type)
+	assert.Equal(t, MemoryEventConflict, (<-events).Type)
+}
+
+func TestConflictPolicy_KeepHighestConfidence(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore(), ConflictPolicy: ConflictPolicyKeepHighestConfidence})
+	require.NoError(t, err)
+
+	require.NoError(t, recordValue(t, manager, "dark_mode", true, 0.8))
+	require.NoError(t, recordValue(t, manager, "dark_mode", false, 0.5))
+
+	mem, err := manager.GetMemory(ctx, "user:1", "dark_mode")
+	require.NoError(t, err)
+	assert.Equal(t, true, mem.Value)
+
+	require.NoError(t, recordValue(t, manager, "dark_mode", false, 0.9))
+	mem, err = manager.GetMemory(ctx, "user:1", "dark_mode")
+	require.NoError(t, err)
+	assert.Equal(t, false, mem.Value)
+
+	conflicts, err := manager.DetectConflicts(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, false, conflicts[0].Conflicting.Value)
+	assert.Equal(t, true, conflicts[1].Conflicting.Value)
+}
+
+func TestConflictPolicy_Reject(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore(), ConflictPolicy: ConflictPolicyReject})
+	require.NoError(t, err)
+
+	require.NoError(t, recordValue(t, manager, "writing_tone", "casual", 0.8))
+	require.NoError(t, recordValue(t, manager, "writing_tone", "casual", 0.8), "same value is not a conflict")
+	assert.ErrorIs(t, recordValue(t, manager, "writing_tone", "formal", 0.9), ErrMemoryConflict)
+
+	mem, err := manager.GetMemory(ctx, "user:1", "writing_tone")
+	require.NoError(t, err)
+	assert.Equal(t, "casual", mem.Value)
+}
+
+func TestConflictPolicy_DefaultMergeUnchanged(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+
+	require.NoError(t, recordValue(t, manager, "writing_tone", "casual", 0.8))
+	require.NoError(t, recordValue(t, manager, "writing_tone", "formal", 0.8))
+
+	mem, err := manager.GetMemory(ctx, "user:1", "writing_tone")
+	require.NoError(t, err)
+	assert.Equal(t, "formal", mem.Value)
+	assert.InDelta(t, 0.85, mem.Provenance.Confidence, 1e-9)
+
+	conflicts, err := manager.DetectConflicts(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
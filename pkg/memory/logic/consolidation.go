@@ -3,11 +3,13 @@ package logic
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/memory"
 )
 
 var consolidationLog = logging.ForComponent("ConsolidationEngine")
@@ -40,6 +42,9 @@ type ConsolidationConfig struct {
 
 	// MergeStrategy 合并策略
 	MergeStrategy MergeStrategy
+
+	// DryRun 仅预览合并结果，不调用存储的 Save/Delete
+	DryRun bool
 }
 
 // MergeStrategy 合并策略
@@ -54,6 +59,10 @@ const (
 
 	// MergeStrategyMergeDescriptions 合并描述
 	MergeStrategyMergeDescriptions MergeStrategy = "merge_descriptions"
+
+	// MergeStrategyWeightedAverage 按访问次数加权平均置信度并合并描述
+	// Value 取访问次数最多的成员（相同时取置信度更高者）
+	MergeStrategyWeightedAverage MergeStrategy = "weighted_average"
 )
 
 // SimilarityCalculator 相似度计算接口
@@ -159,6 +168,7 @@ func (e *ConsolidationEngine) SetSimilarityCalculator(calc SimilarityCalculator)
 func (e *ConsolidationEngine) Consolidate(ctx context.Context, namespace string) (*ConsolidationResult, error) {
 	result := &ConsolidationResult{
 		StartTime: time.Now(),
+		DryRun:    e.config.DryRun,
 	}
 
 	// 1. 获取所有 Memory
@@ -166,6 +176,7 @@ func (e *ConsolidationEngine) Consolidate(ctx context.Context, namespace string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list memories: %w", err)
 	}
+	memories = unexpired(memories, result.StartTime)
 
 	result.TotalMemories = len(memories)
 
@@ -194,15 +205,30 @@ func (e *ConsolidationEngine) Consolidate(ctx context.Context, namespace string)
 			continue
 		}
 
-		merged, deleted, err := e.mergeGroup(ctx, namespace, group)
+		memberIDs := make([]string, 0, len(group))
+		for _, mem := range group {
+			memberIDs = append(memberIDs, mem.ID)
+		}
+		if e.config.DryRun {
+			// 在副本上合并，避免修改存储层共享的对象
+			group = cloneGroup(group)
+		}
+
+		merged, deletedIDs, err := e.mergeGroup(ctx, namespace, group)
 		if err != nil {
 			consolidationLog.Warn(ctx, "failed to merge group", map[string]any{"error": err})
 			continue
 		}
 
 		result.MergedGroups++
-		result.DeletedMemories += deleted
+		result.DeletedMemories += len(deletedIDs)
 		result.MergedMemories = append(result.MergedMemories, merged)
+		result.Groups = append(result.Groups, ConsolidationGroup{
+			KeeperID:   merged.ID,
+			MemberIDs:  memberIDs,
+			DeletedIDs: deletedIDs,
+			Merged:     merged,
+		})
 	}
 
 	result.EndTime = time.Now()
@@ -280,10 +306,11 @@ func (e *ConsolidationEngine) findSimilarGroups(memories []*LogicMemory) [][]*Lo
 	return result
 }
 
-// mergeGroup 合并一组 Memory
-func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string, group []*LogicMemory) (*LogicMemory, int, error) {
+// mergeGroup 合并一组 Memory，返回合并结果和被删除的 Memory ID
+// DryRun 模式下只计算结果，被删除的 ID 为预计删除的 ID
+func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string, group []*LogicMemory) (*LogicMemory, []string, error) {
 	if len(group) == 0 {
-		return nil, 0, nil
+		return nil, nil, nil
 	}
 
 	// 根据策略选择保留的 Memory
@@ -295,6 +322,8 @@ func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string,
 		keeper = e.selectHighestConfidence(group)
 	case MergeStrategyMergeDescriptions:
 		keeper = e.mergeDescriptions(group)
+	case MergeStrategyWeightedAverage:
+		keeper = e.weightedAverage(group)
 	default:
 		keeper = e.selectHighestConfidence(group)
 	}
@@ -308,9 +337,9 @@ func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string,
 		// 合并 AccessCount
 		keeper.AccessCount += mem.AccessCount
 
-		// 合并 Sources
+		// 合并 Sources（取并集）
 		if keeper.Provenance != nil && mem.Provenance != nil {
-			keeper.Provenance.Sources = append(keeper.Provenance.Sources, mem.Provenance.Sources...)
+			keeper.Provenance.Sources = unionSources(keeper.Provenance.Sources, mem.Provenance.Sources)
 		}
 
 		// 合并 Metadata
@@ -327,13 +356,24 @@ func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string,
 	}
 
 	// 更新保留的 Memory
+	keeper.ExpiresAt = mergedExpiry(group)
 	keeper.UpdatedAt = time.Now()
+	if e.config.DryRun {
+		var deletedIDs []string
+		for _, mem := range group {
+			if mem.ID != keeper.ID {
+				deletedIDs = append(deletedIDs, mem.ID)
+			}
+		}
+		return keeper, deletedIDs, nil
+	}
+
 	if err := e.store.Save(ctx, keeper); err != nil {
-		return nil, 0, fmt.Errorf("failed to save merged memory: %w", err)
+		return nil, nil, fmt.Errorf("failed to save merged memory: %w", err)
 	}
 
 	// 删除其他 Memory
-	deleted := 0
+	var deletedIDs []string
 	for _, mem := range group {
 		if mem.ID == keeper.ID {
 			continue
@@ -343,10 +383,26 @@ func (e *ConsolidationEngine) mergeGroup(ctx context.Context, namespace string,
 			consolidationLog.Warn(ctx, "failed to delete merged memory", map[string]any{"key": mem.Key, "error": err})
 			continue
 		}
-		deleted++
+		deletedIDs = append(deletedIDs, mem.ID)
 	}
 
-	return keeper, deleted, nil
+	return keeper, deletedIDs, nil
+}
+
+// cloneGroup 深拷贝一组 Memory（Provenance、Sources 和 Metadata）
+func cloneGroup(group []*LogicMemory) []*LogicMemory {
+	cloned := make([]*LogicMemory, len(group))
+	for i, mem := range group {
+		copied := *mem
+		if mem.Provenance != nil {
+			provenance := *mem.Provenance
+			provenance.Sources = append([]string(nil), mem.Provenance.Sources...)
+			copied.Provenance = &provenance
+		}
+		copied.Metadata = maps.Clone(mem.Metadata)
+		cloned[i] = &copied
+	}
+	return cloned
 }
 
 // selectNewest 选择最新的 Memory
@@ -376,27 +432,66 @@ func (e *ConsolidationEngine) selectHighestConfidence(group []*LogicMemory) *Log
 func (e *ConsolidationEngine) mergeDescriptions(group []*LogicMemory) *LogicMemory {
 	// 选择置信度最高的作为基础
 	keeper := e.selectHighestConfidence(group)
+	keeper.Description = joinDescriptions(group)
+	return keeper
+}
 
-	// 合并描述
-	descriptions := make([]string, 0, len(group))
+// weightedAverage 以访问次数最多的 Memory 为基础（保留其 Value），
+// 置信度取组内按 AccessCount 加权的平均值（全部为 0 时等权平均），描述合并去重
+func (e *ConsolidationEngine) weightedAverage(group []*LogicMemory) *LogicMemory {
+	var weighted, totalWeight, sum float64
 	for _, mem := range group {
-		if mem.Description != "" {
-			descriptions = append(descriptions, mem.Description)
+		conf := confidenceOf(mem)
+		weighted += conf * float64(mem.AccessCount)
+		totalWeight += float64(mem.AccessCount)
+		sum += conf
+	}
+	confidence := sum / float64(len(group))
+	if totalWeight > 0 {
+		confidence = weighted / totalWeight
+	}
+
+	description := joinDescriptions(group)
+	sort.SliceStable(group, func(i, j int) bool {
+		if group[i].AccessCount != group[j].AccessCount {
+			return group[i].AccessCount > group[j].AccessCount
 		}
+		return confidenceOf(group[i]) > confidenceOf(group[j])
+	})
+	keeper := group[0]
+
+	if keeper.Provenance == nil {
+		keeper.Provenance = &memory.MemoryProvenance{}
 	}
+	keeper.Provenance.Confidence = confidence
+	keeper.Description = description
+	return keeper
+}
 
-	// 去重并合并
+// joinDescriptions 按出现顺序去重合并组内描述
+func joinDescriptions(group []*LogicMemory) string {
 	seen := make(map[string]bool)
-	uniqueDescs := make([]string, 0)
-	for _, desc := range descriptions {
-		if !seen[desc] {
-			seen[desc] = true
-			uniqueDescs = append(uniqueDescs, desc)
+	uniqueDescs := make([]string, 0, len(group))
+	for _, mem := range group {
+		if mem.Description != "" && !seen[mem.Description] {
+			seen[mem.Description] = true
+			uniqueDescs = append(uniqueDescs, mem.Description)
 		}
 	}
+	return strings.Join(uniqueDescs, "; ")
+}
 
-	keeper.Description = strings.Join(uniqueDescs, "; ")
-	return keeper
+// unionSources 合并来源列表并去重，保持原有顺序
+func unionSources(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]string, 0, len(a)+len(b))
+	for _, src := range append(append([]string{}, a...), b...) {
+		if !seen[src] {
+			seen[src] = true
+			result = append(result, src)
+		}
+	}
+	return result
 }
 
 // ConsolidationResult 合并结果
@@ -410,9 +505,15 @@ type ConsolidationResult struct {
 	// DeletedMemories 删除的 Memory 数量
 	DeletedMemories int
 
-	// MergedMemories 合并后的 Memory 列表
+	// MergedMemories 合并后的 Memory 列表（DryRun 时为待确认的合并预览）
 	MergedMemories []*LogicMemory
 
+	// Groups 每个合并组的明细
+	Groups []ConsolidationGroup
+
+	// DryRun 是否为预览模式（未修改存储）
+	DryRun bool
+
 	// StartTime 开始时间
 	StartTime time.Time
 
@@ -420,6 +521,21 @@ type ConsolidationResult struct {
 	EndTime time.Time
 }
 
+// ConsolidationGroup 单个合并组的明细
+type ConsolidationGroup struct {
+	// KeeperID 保留（合并目标）的 Memory ID
+	KeeperID string
+
+	// MemberIDs 组内全部 Memory ID
+	MemberIDs []string
+
+	// DeletedIDs 被删除（DryRun 时为将被删除）的 Memory ID
+	DeletedIDs []string
+
+	// Merged 合并后的 Memory
+	Merged *LogicMemory
+}
+
 // Duration 返回执行时长
 func (r *ConsolidationResult) Duration() time.Duration {
 	return r.EndTime.Sub(r.StartTime)
@@ -427,6 +543,10 @@ func (r *ConsolidationResult) Duration() time.Duration {
 
 // String 返回结果摘要
 func (r *ConsolidationResult) String() string {
-	return fmt.Sprintf("Consolidation: %d memories processed, %d groups merged, %d memories deleted in %v",
-		r.TotalMemories, r.MergedGroups, r.DeletedMemories, r.Duration())
+	label := "Consolidation"
+	if r.DryRun {
+		label = "Consolidation (dry run)"
+	}
+	return fmt.Sprintf("%s: %d memories processed, %d groups merged, %d memories deleted in %v",
+		label, r.TotalMemories, r.MergedGroups, r.DeletedMemories, r.Duration())
 }
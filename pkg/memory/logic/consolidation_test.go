@@ -224,3 +224,106 @@ func TestConsolidationResult(t *testing.T) {
 	assert.Contains(t, summary, "5 groups merged")
 	assert.Contains(t, summary, "10 memories deleted")
 }
+
+func TestConsolidationEngine_WeightedAverage(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	engine := NewConsolidationEngine(store, &ConsolidationConfig{
+		SimilarityThreshold:             0.5,
+		MinGroupSize:                    2,
+		MaxMergeCount:                   100,
+		PreserveHighConfidenceThreshold: 0.99,
+		MergeStrategy:                   MergeStrategyWeightedAverage,
+	})
+
+	memories := []*LogicMemory{
+		{
+			ID: "1", Namespace: "user:1", Type: "preference", Category: "writing",
+			Key: "tone_a", Value: "formal", Description: "User prefers formal tone",
+			AccessCount: 1,
+			Provenance:  &memory.MemoryProvenance{Confidence: 0.9, Sources: []string{"s1", "s2"}},
+		},
+		{
+			ID: "2", Namespace: "user:1", Type: "preference", Category: "writing",
+			Key: "tone_b", Value: "casual", Description: "User prefers casual tone",
+			AccessCount: 3,
+			Provenance:  &memory.MemoryProvenance{Confidence: 0.5, Sources: []string{"s2", "s3"}},
+		},
+	}
+	for _, mem := range memories {
+		require.NoError(t, store.Save(ctx, mem))
+	}
+
+	result, err := engine.Consolidate(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, result.MergedMemories, 1)
+
+	merged := result.MergedMemories[0]
+	// (0.9*1 + 0.5*3) / (1+3) = 0.6
+	assert.InDelta(t, 0.6, merged.Provenance.Confidence, 1e-9)
+	assert.Equal(t, "casual", merged.Value, "value comes from the most accessed member")
+	assert.Equal(t, "User prefers formal tone; User prefers casual tone", merged.Description)
+	assert.ElementsMatch(t, []string{"s1", "s2", "s3"}, merged.Provenance.Sources)
+	assert.Equal(t, 4, merged.AccessCount)
+}
+
+func TestConsolidationEngine_DryRun(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	config := &ConsolidationConfig{
+		SimilarityThreshold:             0.5,
+		MinGroupSize:                    2,
+		MaxMergeCount:                   100,
+		PreserveHighConfidenceThreshold: 0.99,
+		MergeStrategy:                   MergeStrategyMergeDescriptions,
+		DryRun:                          true,
+	}
+
+	for _, mem := range []*LogicMemory{
+		{
+			ID: "1", Namespace: "user:1", Type: "preference", Category: "writing",
+			Key: "tone_a", Description: "User prefers formal tone",
+			Provenance: &memory.MemoryProvenance{Confidence: 0.9, Sources: []string{"s1"}},
+		},
+		{
+			ID: "2", Namespace: "user:1", Type: "preference", Category: "writing",
+			Key: "tone_b", Description: "User prefers casual tone",
+			Provenance: &memory.MemoryProvenance{Confidence: 0.5, Sources: []string{"s2"}},
+		},
+	} {
+		require.NoError(t, store.Save(ctx, mem))
+	}
+
+	preview, err := NewConsolidationEngine(store, config).Consolidate(ctx, "user:1")
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, 2, preview.TotalMemories)
+	assert.Equal(t, 1, preview.MergedGroups)
+	assert.Equal(t, 1, preview.DeletedMemories)
+	require.Len(t, preview.Groups, 1)
+	assert.Equal(t, "1", preview.Groups[0].KeeperID)
+	assert.ElementsMatch(t, []string{"1", "2"}, preview.Groups[0].MemberIDs)
+	assert.Equal(t, []string{"2"}, preview.Groups[0].DeletedIDs)
+	require.Len(t, preview.MergedMemories, 1)
+	assert.Contains(t, preview.MergedMemories[0].Description, "casual")
+	assert.Contains(t, preview.String(), "dry run")
+
+	// 存储未被修改
+	remaining, err := store.List(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+	kept, err := store.Get(ctx, "user:1", "tone_a")
+	require.NoError(t, err)
+	assert.Equal(t, "User prefers formal tone", kept.Description)
+	assert.Equal(t, []string{"s1"}, kept.Provenance.Sources)
+
+	// 实际执行的结果与预览一致
+	config.DryRun = false
+	applied, err := NewConsolidationEngine(store, config).Consolidate(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, preview.DeletedMemories, applied.DeletedMemories)
+	assert.Equal(t, preview.Groups[0].DeletedIDs, applied.Groups[0].DeletedIDs)
+	remaining, err = store.List(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Len(t, remaining, 1)
+}
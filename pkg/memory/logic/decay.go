@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// ConfidenceInfo Memory 的原始置信度与衰减后的有效置信度
+type ConfidenceInfo struct {
+	// Raw 存储中的原始置信度
+	Raw float64
+
+	// Decayed 按 DecayHalfLife 衰减后的有效置信度（未启用衰减时等于 Raw）
+	Decayed float64
+
+	// Age 衰减计算使用的时长（距 CreatedAt / LastAccessed 中较新者）
+	Age time.Duration
+}
+
+// GetMemoryWithConfidence 获取单个 Memory，同时返回原始和衰减后的置信度
+// 返回的 Memory 保持原始置信度不变
+func (m *Manager) GetMemoryWithConfidence(ctx context.Context, namespace, key string) (*LogicMemory, ConfidenceInfo, error) {
+	mem, err := m.GetMemory(ctx, namespace, key)
+	if err != nil {
+		return nil, ConfidenceInfo{}, err
+	}
+	return mem, m.confidenceInfo(mem, time.Now()), nil
+}
+
+// EffectiveConfidence 计算 Memory 在当前时刻的有效置信度
+func (m *Manager) EffectiveConfidence(mem *LogicMemory) float64 {
+	return m.confidenceInfo(mem, time.Now()).Decayed
+}
+
+// confidenceInfo 计算 now 时刻的置信度信息
+func (m *Manager) confidenceInfo(mem *LogicMemory, now time.Time) ConfidenceInfo {
+	raw := confidenceOf(mem)
+	age := memoryAge(mem, now)
+	return ConfidenceInfo{
+		Raw:     raw,
+		Decayed: decayConfidence(raw, age, m.config.DecayHalfLife),
+		Age:     age,
+	}
+}
+
+// memoryAge Memory 距最近一次创建或访问的时长
+func memoryAge(mem *LogicMemory, now time.Time) time.Duration {
+	ref := mem.CreatedAt
+	if mem.LastAccessed.After(ref) {
+		ref = mem.LastAccessed
+	}
+	if ref.IsZero() || !now.After(ref) {
+		return 0
+	}
+	return now.Sub(ref)
+}
+
+// decayConfidence 按半衰期计算衰减后的置信度，halfLife <= 0 时不衰减
+func decayConfidence(confidence float64, age, halfLife time.Duration) float64 {
+	if halfLife <= 0 || age <= 0 {
+		return confidence
+	}
+	return confidence * math.Pow(0.5, float64(age)/float64(halfLife))
+}
@@ -0,0 +1,181 @@
+package logic
+
+import (
+	"context"
+	"path"
+	"time"
+)
+
+// MemoryEventType Memory 事件类型
+type MemoryEventType string
+
+const (
+	// MemoryEventCreated 新 Memory 被记录
+	MemoryEventCreated MemoryEventType = "created"
+
+	// MemoryEventUpdated 已有 Memory 被再次观察到并更新（置信度提升）
+	MemoryEventUpdated MemoryEventType = "updated"
+
+	// MemoryEventConsolidated 相似 Memory 被合并
+	MemoryEventConsolidated MemoryEventType = "consolidated"
+
+	// MemoryEventPruned Memory 被清理
+	MemoryEventPruned MemoryEventType = "pruned"
+
+	// MemoryEventConflict 记录到与已有 Memory 矛盾的 Value（按 ConflictPolicy 处理后发出）
+	MemoryEventConflict MemoryEventType = "conflict"
+)
+
+// defaultEventBufferSize 订阅通道默认缓冲大小
+const defaultEventBufferSize = 64
+
+// MemoryEvent Memory 变化事件
+type MemoryEvent struct {
+	// Type 事件类型
+	Type MemoryEventType
+
+	// Namespace 命名空间
+	Namespace string
+
+	// Key Memory 键
+	Key string
+
+	// Memory 事件发生后的 Memory 快照（Pruned 事件为被删除前的快照）
+	Memory *LogicMemory
+
+	// Conflicting 与 Memory 矛盾的新 Memory（仅 Conflict 事件）
+	Conflicting *LogicMemory
+
+	// ConfidenceDelta 置信度变化量（仅 Updated 事件）
+	ConfidenceDelta float64
+
+	// Count 影响的 Memory 数量
+	Count int
+
+	// Maintenance 维护周期结果（仅 Maintenance 事件）
+	Maintenance *MaintenanceReport
+
+	// Timestamp 事件时间
+	Timestamp time.Time
+}
+
+// PruneReporter 可选接口：支持返回被清理 Memory 的存储
+// Manager 通过它为每条被清理的 Memory 发出 Pruned 事件
+type PruneReporter interface {
+	PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error)
+}
+
+// memorySubscriber 单个订阅者
+type memorySubscriber struct {
+	pattern string
+	ch      chan MemoryEvent
+}
+
+// Subscribe 订阅 Memory 事件，namespacePattern 为命名空间 glob（如 "user:*"），
+// 空字符串表示订阅全部命名空间。
+//
+// 事件投递是非阻塞的：通道缓冲已满时丢弃最旧的事件，慢消费者不会阻塞 Memory 写入。
+func (m *Manager) Subscribe(namespacePattern string) <-chan MemoryEvent {
+	if namespacePattern == "" {
+		namespacePattern = "*"
+	}
+
+	size := m.config.EventBufferSize
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+
+	sub := &memorySubscriber{
+		pattern: namespacePattern,
+		ch:      make(chan MemoryEvent, size),
+	}
+
+	m.subMu.Lock()
+	m.subscribers = append(m.subscribers, sub)
+	m.subMu.Unlock()
+
+	return sub.ch
+}
+
+// Unsubscribe 取消订阅并关闭对应通道
+func (m *Manager) Unsubscribe(ch <-chan MemoryEvent) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for i, sub := range m.subscribers {
+		if sub.ch == ch {
+			m.subscribers = append(m.subscribers[:i], m.subscribers[i+1:]...)
+			close(sub.ch)
+			return
+		}
+	}
+}
+
+// closeSubscribers 关闭所有订阅通道
+func (m *Manager) closeSubscribers() {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for _, sub := range m.subscribers {
+		close(sub.ch)
+	}
+	m.subscribers = nil
+}
+
+// emit 向匹配的订阅者投递事件
+func (m *Manager) emit(event MemoryEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Memory != nil {
+		snapshot := *event.Memory
+		if snapshot.Provenance != nil {
+			provenance := *snapshot.Provenance
+			snapshot.Provenance = &provenance
+		}
+		event.Memory = &snapshot
+	}
+
+	// 持有写锁保证同一订阅者收到的事件顺序与发生顺序一致
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for _, sub := range m.subscribers {
+		if !matchNamespace(sub.pattern, event.Namespace) {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+			continue
+		default:
+		}
+
+		// 缓冲已满：丢弃最旧的事件后重试
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// matchNamespace 判断命名空间是否匹配 glob 模式
+func matchNamespace(pattern, namespace string) bool {
+	if pattern == "*" {
+		return true
+	}
+	matched, err := path.Match(pattern, namespace)
+	return err == nil && matched
+}
+
+// confidenceOf 返回 Memory 的置信度（无溯源信息时为 0）
+func confidenceOf(mem *LogicMemory) float64 {
+	if mem == nil || mem.Provenance == nil {
+		return 0
+	}
+	return mem.Provenance.Confidence
+}
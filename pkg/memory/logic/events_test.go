@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEvent(t *testing.T, ch <-chan MemoryEvent) MemoryEvent {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "channel closed unexpectedly")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for memory event")
+		return MemoryEvent{}
+	}
+}
+
+func TestManagerSubscribe(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+
+	events := manager.Subscribe("user:*")
+	other := manager.Subscribe("team:*")
+
+	newMemory := func() *LogicMemory {
+		return &LogicMemory{
+			Namespace: "user:alice",
+			Type:      "preference",
+			Key:       "tone",
+			Value:     "casual",
+			Provenance: &memory.MemoryProvenance{
+				SourceType: memory.SourceUserInput,
+				Confidence: 0.5,
+			},
+		}
+	}
+
+	require.NoError(t, manager.RecordMemory(ctx, newMemory()))
+	require.NoError(t, manager.RecordMemory(ctx, newMemory()))
+
+	pruned, err := manager.PruneMemories(ctx, PruneCriteria{MinConfidence: 0.9})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	created := receiveEvent(t, events)
+	assert.Equal(t, MemoryEventCreated, created.Type)
+	assert.Equal(t, "user:alice", created.Namespace)
+	assert.Equal(t, "tone", created.Key)
+
+	updated := receiveEvent(t, events)
+	assert.Equal(t, MemoryEventUpdated, updated.Type)
+	assert.InDelta(t, 0.05, updated.ConfidenceDelta, 1e-9)
+	assert.InDelta(t, 0.55, updated.Memory.Provenance.Confidence, 1e-9)
+
+	prunedEvent := receiveEvent(t, events)
+	assert.Equal(t, MemoryEventPruned, prunedEvent.Type)
+	assert.Equal(t, "tone", prunedEvent.Key)
+
+	// 不匹配的命名空间不会收到事件
+	assert.Empty(t, other)
+
+	manager.Unsubscribe(events)
+	_, ok := <-events
+	assert.False(t, ok, "channel should be closed after unsubscribe")
+	assert.Len(t, manager.subscribers, 1)
+}
+
+func TestManagerSubscribe_DropOldest(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore(), EventBufferSize: 2})
+	require.NoError(t, err)
+
+	events := manager.Subscribe("")
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, manager.RecordMemory(ctx, &LogicMemory{Namespace: "ns", Key: key}))
+	}
+
+	assert.Equal(t, "b", receiveEvent(t, events).Key)
+	assert.Equal(t, "c", receiveEvent(t, events).Key)
+	require.NoError(t, manager.Close())
+}
+
+// countingPruneStore 只支持 Prune 返回数量的存储
+type countingPruneStore struct {
+	LogicMemoryStore
+}
+
+func (s countingPruneStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	return s.LogicMemoryStore.Prune(ctx, criteria)
+}
+
+func TestManagerSubscribe_PrunedWithoutReporter(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: countingPruneStore{NewInMemoryStore()}})
+	require.NoError(t, err)
+
+	for _, mem := range []*LogicMemory{
+		{Namespace: "user:alice", Key: "tone", Value: "casual", Provenance: &memory.MemoryProvenance{Confidence: 0.3}},
+		{Namespace: "user:alice", Key: "lang", Value: "zh", Provenance: &memory.MemoryProvenance{Confidence: 0.95}},
+		{Namespace: "team:core", Key: "tone", Value: "formal", Provenance: &memory.MemoryProvenance{Confidence: 0.3}},
+	} {
+		require.NoError(t, manager.RecordMemory(ctx, mem))
+	}
+	events := manager.Subscribe("user:*")
+
+	pruned, err := manager.PruneMemories(ctx, PruneCriteria{MinConfidence: 0.9})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+
+	prunedEvent := receiveEvent(t, events)
+	assert.Equal(t, MemoryEventPruned, prunedEvent.Type)
+	assert.Equal(t, "user:alice", prunedEvent.Namespace)
+	assert.Equal(t, "tone", prunedEvent.Key)
+	assert.Empty(t, events)
+}
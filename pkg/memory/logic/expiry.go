@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RecordOption RecordMemory 的可选参数
+type RecordOption func(*LogicMemory)
+
+// WithTTL 设置 Memory 的存活时长，到期后不再被检索并会被清理
+// ttl <= 0 表示永不过期
+func WithTTL(ttl time.Duration) RecordOption {
+	return func(mem *LogicMemory) {
+		if ttl <= 0 {
+			mem.ExpiresAt = nil
+			return
+		}
+		expiresAt := time.Now().Add(ttl)
+		mem.ExpiresAt = &expiresAt
+	}
+}
+
+// WithExpiresAt 设置 Memory 的过期时间
+func WithExpiresAt(t time.Time) RecordOption {
+	return func(mem *LogicMemory) {
+		mem.ExpiresAt = &t
+	}
+}
+
+// IsExpired 判断 Memory 在 now 时刻是否已过期，未设置 ExpiresAt 时永不过期
+func (m *LogicMemory) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// getLive 获取未过期的 Memory，存储返回已过期的 Memory 时惰性删除并返回 ErrMemoryNotFound
+func (m *Manager) getLive(ctx context.Context, namespace, key string) (*LogicMemory, error) {
+	mem, err := m.store.Get(ctx, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if mem.IsExpired(time.Now()) {
+		m.deleteExpired(ctx, []*LogicMemory{mem})
+		return nil, ErrMemoryNotFound
+	}
+	return mem, nil
+}
+
+// dropExpired 过滤掉已过期的 Memory，并惰性删除
+// 存储层通常已过滤，这里兜底处理未感知 ExpiresAt 的自定义存储
+func (m *Manager) dropExpired(ctx context.Context, memories []*LogicMemory) []*LogicMemory {
+	now := time.Now()
+	live := memories[:0]
+	var expired []*LogicMemory
+	for _, mem := range memories {
+		if mem.IsExpired(now) {
+			expired = append(expired, mem)
+			continue
+		}
+		live = append(live, mem)
+	}
+	if len(expired) > 0 {
+		m.deleteExpired(ctx, expired)
+	}
+	return live
+}
+
+// deleteExpired 删除已过期的 Memory 并发出 Pruned 事件
+func (m *Manager) deleteExpired(ctx context.Context, memories []*LogicMemory) {
+	for _, mem := range memories {
+		// 删除失败时跳过，由下次检索或清理重试
+		if err := m.store.Delete(ctx, mem.Namespace, mem.Key); err != nil {
+			continue
+		}
+		m.emit(MemoryEvent{
+			Type:      MemoryEventPruned,
+			Namespace: mem.Namespace,
+			Key:       mem.Key,
+			Memory:    mem,
+		})
+	}
+}
+
+// mergedExpiry 合并后 Memory 的过期时间：任一成员永不过期则永不过期，否则取最晚者
+func mergedExpiry(group []*LogicMemory) *time.Time {
+	var latest *time.Time
+	for _, mem := range group {
+		if mem.ExpiresAt == nil {
+			return nil
+		}
+		if latest == nil || mem.ExpiresAt.After(*latest) {
+			t := *mem.ExpiresAt
+			latest = &t
+		}
+	}
+	return latest
+}
+
+// unexpired 返回 now 时刻未过期的 Memory
+func unexpired(memories []*LogicMemory, now time.Time) []*LogicMemory {
+	live := make([]*LogicMemory, 0, len(memories))
+	for _, mem := range memories {
+		if !mem.IsExpired(now) {
+			live = append(live, mem)
+		}
+	}
+	return live
+}
+
+// nullTime 将可选时间转换为 SQL 可空时间
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
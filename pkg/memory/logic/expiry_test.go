@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordMemory_WithTTL(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.RecordMemory(ctx, newTestMemory("user:1", "tone", "preference", 0.8), WithTTL(time.Hour)))
+	got, err := manager.GetMemory(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *got.ExpiresAt, 5*time.Second)
+
+	require.NoError(t, manager.RecordMemory(ctx, newTestMemory("user:1", "draft", "preference", 0.8), WithExpiresAt(time.Now().Add(-time.Minute))))
+
+	memories, err := manager.RetrieveMemories(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, memories, 1)
+	assert.Equal(t, "tone", memories[0].Key)
+
+	_, err = manager.GetMemory(ctx, "user:1", "draft")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+
+	// 过期 Memory 由清理任务删除
+	pruned, err := manager.PruneMemories(ctx, PruneCriteria{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.False(t, store.contains("user:1", "draft"))
+}
+
+func TestRecordMemory_ExpiredNotResurrected(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+	events := manager.Subscribe("")
+
+	expired := newTestMemory("user:1", "tone", "preference", 0.9)
+	expired.AccessCount = 7
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+	require.NoError(t, store.Save(ctx, expired))
+
+	require.NoError(t, manager.RecordMemory(ctx, newTestMemory("user:1", "tone", "preference", 0.5)))
+
+	got, err := manager.GetMemory(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.Nil(t, got.ExpiresAt)
+	assert.Equal(t, 0, got.AccessCount, "expired memory should not be merged into the new one")
+	assert.InDelta(t, 0.5, got.Provenance.Confidence, 1e-9)
+
+	var types []MemoryEventType
+	for len(events) > 0 {
+		types = append(types, (<-events).Type)
+	}
+	assert.Equal(t, []MemoryEventType{MemoryEventCreated}, types)
+}
+
+func TestRecordMemory_MergeRefreshesExpiry(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.RecordMemory(ctx, newTestMemory("user:1", "tone", "preference", 0.5), WithTTL(time.Minute)))
+	require.NoError(t, manager.RecordMemory(ctx, newTestMemory("user:1", "tone", "preference", 0.5), WithTTL(time.Hour)))
+
+	got, err := manager.GetMemory(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *got.ExpiresAt, 5*time.Second)
+}
+
+func TestPrune_RemovesExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+
+	past := time.Now().Add(-time.Second)
+	expired := newTestMemory("user:1", "old", "preference", 0.9)
+	expired.ExpiresAt = &past
+	require.NoError(t, store.Save(ctx, expired))
+	require.NoError(t, store.Save(ctx, newTestMemory("user:1", "kept", "preference", 0.9)))
+
+	_, err := store.Get(ctx, "user:1", "old")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+
+	pruned, err := store.Prune(ctx, PruneCriteria{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.False(t, store.contains("user:1", "old"))
+	assert.True(t, store.contains("user:1", "kept"))
+}
+
+func TestConsolidate_SkipsExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+
+	past := time.Now().Add(-time.Second)
+	expired := newTestMemory("user:1", "tone_formal", "preference", 0.9)
+	expired.ExpiresAt = &past
+	require.NoError(t, store.Save(ctx, expired))
+	require.NoError(t, store.Save(ctx, newTestMemory("user:1", "tone_casual", "preference", 0.8)))
+
+	engine := NewConsolidationEngine(store, &ConsolidationConfig{SimilarityThreshold: 0.5, MinGroupSize: 2})
+	result, err := engine.Consolidate(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TotalMemories)
+	assert.Zero(t, result.MergedGroups)
+}
+
+func TestMergedExpiry(t *testing.T) {
+	soon := time.Now().Add(time.Minute)
+	later := time.Now().Add(time.Hour)
+
+	assert.Nil(t, mergedExpiry([]*LogicMemory{{ExpiresAt: &soon}, {}}), "a permanent member keeps the merged memory permanent")
+	got := mergedExpiry([]*LogicMemory{{ExpiresAt: &soon}, {ExpiresAt: &later}})
+	require.NotNil(t, got)
+	assert.Equal(t, later, *got)
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// namespaceExportVersion 导出格式版本
+const namespaceExportVersion = 1
+
+// NamespaceExport 命名空间导出快照
+type NamespaceExport struct {
+	// Version 导出格式版本
+	Version int `json:"version"`
+
+	// Namespace 导出时的命名空间
+	Namespace string `json:"namespace"`
+
+	// ExportedAt 导出时间
+	ExportedAt time.Time `json:"exported_at"`
+
+	// Memories 命名空间内的全部 Memory
+	Memories []*LogicMemory `json:"memories"`
+}
+
+// ExportNamespace 将命名空间内的全部 Memory 导出为 JSON
+// 包含 Provenance、AccessCount 和时间戳，用于调试和跨存储迁移
+func (m *Manager) ExportNamespace(ctx context.Context, namespace string) ([]byte, error) {
+	if namespace == "" {
+		return nil, ErrInvalidNamespace
+	}
+
+	memories, err := m.store.List(ctx, namespace, WithOrderBy(OrderByCreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	if memories == nil {
+		memories = []*LogicMemory{}
+	}
+
+	return json.MarshalIndent(NamespaceExport{
+		Version:    namespaceExportVersion,
+		Namespace:  namespace,
+		ExportedAt: time.Now(),
+		Memories:   memories,
+	}, "", "  ")
+}
+
+// ImportNamespace 将 ExportNamespace 的结果导入到指定命名空间
+// merge 为 false 时直接覆盖同 Key 的 Memory；
+// merge 为 true 时同 Key 的已有 Memory 按 ConfidenceBoost 提升置信度并合并，而不是被替换。
+// UpdatedAt 由存储层在写入时刷新
+func (m *Manager) ImportNamespace(ctx context.Context, namespace string, data []byte, merge bool) error {
+	if namespace == "" {
+		return ErrInvalidNamespace
+	}
+
+	var snapshot NamespaceExport
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("decode namespace export: %w", err)
+	}
+	if snapshot.Version != namespaceExportVersion {
+		return fmt.Errorf("unsupported namespace export version: %d", snapshot.Version)
+	}
+
+	for _, mem := range snapshot.Memories {
+		if mem == nil || mem.Key == "" {
+			continue
+		}
+		mem.Namespace = namespace
+
+		if merge {
+			existing, err := m.store.Get(ctx, namespace, mem.Key)
+			if err == nil && existing != nil {
+				m.mergeMemory(existing, mem)
+				if err := m.store.Save(ctx, existing); err != nil {
+					return fmt.Errorf("import memory %s: %w", mem.Key, err)
+				}
+				continue
+			}
+			if err != nil && !errors.Is(err, ErrMemoryNotFound) {
+				return fmt.Errorf("import memory %s: %w", mem.Key, err)
+			}
+		}
+
+		if err := m.store.Save(ctx, mem); err != nil {
+			return fmt.Errorf("import memory %s: %w", mem.Key, err)
+		}
+	}
+	return nil
+}
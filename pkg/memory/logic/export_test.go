@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ExportImportNamespace(t *testing.T) {
+	ctx := context.Background()
+	source, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	require.NoError(t, source.store.Save(ctx, &LogicMemory{
+		ID:           "m1",
+		Namespace:    "user:1",
+		Scope:        ScopeUser,
+		Type:         "preference",
+		Key:          "writing_tone",
+		Value:        "casual",
+		Description:  "Prefers casual tone",
+		AccessCount:  7,
+		LastAccessed: created.Add(time.Hour),
+		CreatedAt:    created,
+		Provenance: &memory.MemoryProvenance{
+			SourceType: memory.SourceUserInput,
+			Confidence: 0.7,
+			Sources:    []string{"msg-1"},
+		},
+	}))
+	require.NoError(t, source.store.Save(ctx, &LogicMemory{
+		ID: "m2", Namespace: "user:2", Key: "other", Value: "x",
+	}))
+
+	data, err := source.ExportNamespace(ctx, "user:1")
+	require.NoError(t, err)
+
+	target, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+	require.NoError(t, target.ImportNamespace(ctx, "user:9", data, false))
+
+	imported, err := target.store.List(ctx, "user:9")
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	mem := imported[0]
+	assert.Equal(t, "writing_tone", mem.Key)
+	assert.Equal(t, "casual", mem.Value)
+	assert.Equal(t, 7, mem.AccessCount)
+	assert.True(t, mem.CreatedAt.Equal(created))
+	assert.True(t, mem.LastAccessed.Equal(created.Add(time.Hour)))
+	assert.Equal(t, 0.7, mem.Provenance.Confidence)
+	assert.Equal(t, []string{"msg-1"}, mem.Provenance.Sources)
+
+	// merge 模式下同 Key 提升置信度而不是覆盖
+	require.NoError(t, target.ImportNamespace(ctx, "user:9", data, true))
+	mem, err = target.store.Get(ctx, "user:9", "writing_tone")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, mem.Provenance.Confidence, 1e-9)
+
+	// 非 merge 模式覆盖回快照中的置信度
+	require.NoError(t, target.ImportNamespace(ctx, "user:9", data, false))
+	mem, err = target.store.Get(ctx, "user:9", "writing_tone")
+	require.NoError(t, err)
+	assert.Equal(t, 0.7, mem.Provenance.Confidence)
+
+	assert.Error(t, target.ImportNamespace(ctx, "user:9", []byte(`{"version":99}`), false))
+	assert.Error(t, target.ImportNamespace(ctx, "user:9", []byte(`not json`), false))
+}
@@ -0,0 +1,345 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+)
+
+var maintenanceLog = logging.ForComponent("LogicMemoryMaintenance")
+
+// MemoryEventMaintenance 后台维护周期完成（Count 为本周期影响的 Memory 数量）
+const MemoryEventMaintenance MemoryEventType = "maintenance"
+
+// ErrMaintenanceRunning 后台维护已在运行
+var ErrMaintenanceRunning = errors.New("logic memory maintenance already running")
+
+// MaintenanceConfig 后台维护配置
+type MaintenanceConfig struct {
+	// Namespaces 需要维护的命名空间
+	// 为空时每个周期从存储中枚举现有的命名空间，合并与清理同样按命名空间执行并受上限约束
+	Namespaces []string
+
+	// ConsolidateInterval 合并间隔（0 表示不自动合并）
+	ConsolidateInterval time.Duration
+
+	// Consolidation 合并配置（nil 使用合并引擎默认配置，MaxMergeCount 限制单个命名空间的合并量）
+	Consolidation *ConsolidationConfig
+
+	// PruneInterval 清理间隔（0 表示不自动清理）
+	PruneInterval time.Duration
+
+	// PruneCriteria 清理条件
+	PruneCriteria PruneCriteria
+
+	// MaxPrunePerCycle 每个周期每个命名空间最多清理的数量（默认 100）
+	MaxPrunePerCycle int
+
+	// MaxNamespacesPerCycle 每个周期最多处理的命名空间数，超出部分轮转到后续周期（默认全部）
+	MaxNamespacesPerCycle int
+
+	// Jitter 间隔随机抖动比例，避免多实例同时执行（默认 0.1，负数表示不抖动）
+	Jitter float64
+
+	// Metrics 指标收集（可选）
+	Metrics *Metrics
+
+	// OnPrune 每个清理周期结束后回调（可选），pruned 为本周期清理数量
+	OnPrune func(pruned int, err error)
+}
+
+// PruneSchedule 定时清理配置（ManagerConfig 中设置后由 NewManager 自动启动）
+type PruneSchedule struct {
+	// Interval 清理间隔（必需）
+	Interval time.Duration
+
+	// Criteria 清理条件
+	Criteria PruneCriteria
+
+	// Namespaces 需要清理的命名空间（为空时清理存储中的所有命名空间）
+	Namespaces []string
+
+	// OnPrune 每个周期结束后回调（可选）
+	OnPrune func(pruned int, err error)
+
+	// Metrics 指标收集（可选）
+	Metrics *Metrics
+}
+
+// maintenanceConfig 转换为后台维护配置
+func (s *PruneSchedule) maintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		Namespaces:    s.Namespaces,
+		PruneInterval: s.Interval,
+		PruneCriteria: s.Criteria,
+		Metrics:       s.Metrics,
+		OnPrune:       s.OnPrune,
+	}
+}
+
+// MaintenanceReport 单个维护周期的结果
+type MaintenanceReport struct {
+	Task       string // "consolidate" | "prune"
+	Namespaces []string
+	Merged     int // 合并的组数
+	Deleted    int // 合并或清理删除的 Memory 数量
+	Errors     int
+	StartedAt  time.Time
+	Duration   time.Duration
+}
+
+// maintenance 运行中的后台维护任务
+type maintenance struct {
+	config MaintenanceConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	cursor map[string]int // 任务 -> 命名空间轮转位置
+}
+
+// StartMaintenance 启动后台合并与清理任务
+// 维护与 RecordMemory/RetrieveMemories 并发执行时只使用存储的原子操作，
+// 每个周期结束时发出 MemoryEventMaintenance 事件。
+func (m *Manager) StartMaintenance(config MaintenanceConfig) error {
+	if config.ConsolidateInterval <= 0 && config.PruneInterval <= 0 {
+		return errors.New("at least one of consolidate or prune interval is required")
+	}
+	if config.MaxPrunePerCycle <= 0 {
+		config.MaxPrunePerCycle = 100
+	}
+	if config.Jitter == 0 {
+		config.Jitter = 0.1
+	}
+
+	m.maintMu.Lock()
+	defer m.maintMu.Unlock()
+	if m.maint != nil {
+		return ErrMaintenanceRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mt := &maintenance{config: config, cancel: cancel, cursor: make(map[string]int)}
+	m.maint = mt
+
+	if config.ConsolidateInterval > 0 {
+		mt.wg.Add(1)
+		go m.runMaintenanceLoop(ctx, mt, config.ConsolidateInterval, m.consolidateCycle)
+	}
+	if config.PruneInterval > 0 {
+		mt.wg.Add(1)
+		go m.runMaintenanceLoop(ctx, mt, config.PruneInterval, m.pruneCycle)
+	}
+	return nil
+}
+
+// StopMaintenance 停止后台维护并等待当前周期结束
+func (m *Manager) StopMaintenance() {
+	m.maintMu.Lock()
+	mt := m.maint
+	m.maint = nil
+	m.maintMu.Unlock()
+
+	if mt == nil {
+		return
+	}
+	mt.cancel()
+	mt.wg.Wait()
+}
+
+// runMaintenanceLoop 按带抖动的间隔重复执行维护周期
+func (m *Manager) runMaintenanceLoop(ctx context.Context, mt *maintenance, interval time.Duration, cycle func(context.Context, *maintenance) MaintenanceReport) {
+	defer mt.wg.Done()
+
+	timer := time.NewTimer(jittered(interval, mt.config.Jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		report := cycle(ctx, mt)
+		report.Duration = time.Since(report.StartedAt)
+		maintenanceLog.Debug(ctx, "maintenance cycle finished", map[string]any{
+			"task": report.Task, "namespaces": len(report.Namespaces), "merged": report.Merged,
+			"deleted": report.Deleted, "errors": report.Errors, "duration": report.Duration.String(),
+		})
+		m.emit(MemoryEvent{Type: MemoryEventMaintenance, Count: report.Deleted, Maintenance: &report})
+
+		timer.Reset(jittered(interval, mt.config.Jitter))
+	}
+}
+
+// consolidateCycle 合并本周期轮到的命名空间
+func (m *Manager) consolidateCycle(ctx context.Context, mt *maintenance) MaintenanceReport {
+	report := MaintenanceReport{Task: "consolidate", StartedAt: time.Now()}
+	namespaces, err := m.maintenanceNamespaces(ctx, mt)
+	if err != nil {
+		report.Errors++
+		maintenanceLog.Warn(ctx, "list namespaces failed", map[string]any{"error": err})
+		return report
+	}
+	report.Namespaces = mt.nextNamespaces(report.Task, namespaces)
+
+	for _, ns := range report.Namespaces {
+		if ctx.Err() != nil {
+			break
+		}
+		result, err := m.Consolidate(ctx, ns, mt.config.Consolidation)
+		if err != nil {
+			report.Errors++
+			maintenanceLog.Warn(ctx, "consolidation failed", map[string]any{"namespace": ns, "error": err})
+			continue
+		}
+		report.Merged += result.MergedGroups
+		report.Deleted += result.DeletedMemories
+		if mt.config.Metrics != nil {
+			mt.config.Metrics.RecordConsolidation(result.MergedGroups, result.DeletedMemories)
+		}
+	}
+	return report
+}
+
+// pruneCycle 按命名空间清理低价值 Memory，每个命名空间最多清理 MaxPrunePerCycle 条
+// 未指定命名空间时清理存储中枚举到的命名空间，已过期的 Memory 由存储统一清理。
+// 已有清理在进行（如手动 PruneMemories）时跳过本周期
+func (m *Manager) pruneCycle(ctx context.Context, mt *maintenance) MaintenanceReport {
+	report := MaintenanceReport{Task: "prune", StartedAt: time.Now()}
+
+	if !m.pruneMu.TryLock() {
+		maintenanceLog.Debug(ctx, "prune already running, skipping cycle", nil)
+		return report
+	}
+	defer m.pruneMu.Unlock()
+
+	var lastErr error
+	if len(mt.config.Namespaces) == 0 {
+		// 已过期的 Memory 不会出现在 List 结果中，由存储一次删除（零值条件只匹配已过期的 Memory）
+		count, err := m.pruneMemories(ctx, PruneCriteria{})
+		if err != nil {
+			lastErr = err
+			report.Errors++
+			maintenanceLog.Warn(ctx, "prune expired failed", map[string]any{"error": err})
+		}
+		report.Deleted += count
+	}
+
+	namespaces, err := m.maintenanceNamespaces(ctx, mt)
+	if err != nil {
+		lastErr = err
+		report.Errors++
+		maintenanceLog.Warn(ctx, "list namespaces failed", map[string]any{"error": err})
+	}
+	report.Namespaces = mt.nextNamespaces(report.Task, namespaces)
+	for _, ns := range report.Namespaces {
+		if ctx.Err() != nil {
+			break
+		}
+		count, err := m.pruneNamespace(ctx, ns, mt.config.PruneCriteria, mt.config.MaxPrunePerCycle)
+		if err != nil {
+			lastErr = err
+			report.Errors++
+			maintenanceLog.Warn(ctx, "prune failed", map[string]any{"namespace": ns, "error": err})
+		}
+		report.Deleted += count
+	}
+
+	if mt.config.Metrics != nil {
+		mt.config.Metrics.RecordPrune(report.Deleted)
+	}
+	if mt.config.OnPrune != nil {
+		mt.config.OnPrune(report.Deleted, lastErr)
+	}
+	return report
+}
+
+// pruneNamespace 清理单个命名空间内满足条件的 Memory，最多 limit 条
+// 删除前重新读取，跳过期间被 RecordMemory 更新而不再满足条件的 Memory
+func (m *Manager) pruneNamespace(ctx context.Context, namespace string, criteria PruneCriteria, limit int) (int, error) {
+	memories, err := m.store.List(ctx, namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, mem := range memories {
+		if pruned >= limit || ctx.Err() != nil {
+			break
+		}
+		if !criteria.Matches(mem, time.Now()) {
+			continue
+		}
+
+		current, err := m.store.Get(ctx, namespace, mem.Key)
+		if err != nil || !criteria.Matches(current, time.Now()) {
+			continue
+		}
+		if err := m.store.Delete(ctx, namespace, mem.Key); err != nil {
+			return pruned, err
+		}
+		pruned++
+		m.emit(MemoryEvent{
+			Type:      MemoryEventPruned,
+			Namespace: namespace,
+			Key:       mem.Key,
+			Memory:    current,
+			Count:     1,
+		})
+	}
+	return pruned, nil
+}
+
+// maintenanceNamespaces 返回需要维护的命名空间，未配置时从存储中枚举
+func (m *Manager) maintenanceNamespaces(ctx context.Context, mt *maintenance) ([]string, error) {
+	if len(mt.config.Namespaces) > 0 {
+		return mt.config.Namespaces, nil
+	}
+
+	memories, err := m.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0)
+	for _, mem := range memories {
+		namespaces = append(namespaces, mem.Namespace)
+	}
+	// 排序保证轮转位置在周期之间稳定
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces), nil
+}
+
+// nextNamespaces 返回任务本周期要处理的命名空间，超过上限时轮转
+func (mt *maintenance) nextNamespaces(task string, all []string) []string {
+	limit := mt.config.MaxNamespacesPerCycle
+	if limit <= 0 || limit >= len(all) {
+		return all
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	start := mt.cursor[task] % len(all)
+	selected := make([]string, 0, limit)
+	for i := range limit {
+		selected = append(selected, all[(start+i)%len(all)])
+	}
+	mt.cursor[task] = (start + limit) % len(all)
+	return selected
+}
+
+// jittered 在 interval 基础上增加 ±jitter 比例的随机抖动
+func jittered(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	delta := (rand.Float64()*2 - 1) * jitter * float64(interval)
+	return max(interval+time.Duration(delta), time.Millisecond)
+}
@@ -0,0 +1,216 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMaintenanceMemory(namespace, key string, confidence float64) *LogicMemory {
+	return &LogicMemory{
+		Namespace: namespace,
+		Scope:     ScopeUser,
+		Type:      "preference",
+		Key:       key,
+		Value:     key,
+		Provenance: &memory.MemoryProvenance{
+			SourceType: memory.SourceUserInput,
+			Confidence: confidence,
+		},
+	}
+}
+
+// waitMaintenance 等待指定任务的维护周期事件
+func waitMaintenance(t *testing.T, events <-chan MemoryEvent, task string) *MaintenanceReport {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-events:
+			if evt.Type == MemoryEventMaintenance && evt.Maintenance.Task == task {
+				return evt.Maintenance
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s cycle", task)
+			return nil
+		}
+	}
+}
+
+func TestStartMaintenance_PrunesLowConfidence(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	require.NoError(t, manager.RecordMemory(ctx, newMaintenanceMemory("user:1", "weak", 0.2)))
+	require.NoError(t, manager.RecordMemory(ctx, newMaintenanceMemory("user:1", "strong", 0.9)))
+
+	events := manager.Subscribe("")
+	metrics := NewMetrics()
+	require.NoError(t, manager.StartMaintenance(MaintenanceConfig{
+		Namespaces:    []string{"user:1"},
+		PruneInterval: 10 * time.Millisecond,
+		PruneCriteria: PruneCriteria{MinConfidence: 0.5},
+		Jitter:        -1,
+		Metrics:       metrics,
+	}))
+	assert.ErrorIs(t, manager.StartMaintenance(MaintenanceConfig{PruneInterval: time.Second}), ErrMaintenanceRunning)
+
+	report := waitMaintenance(t, events, "prune")
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, []string{"user:1"}, report.Namespaces)
+	manager.StopMaintenance()
+
+	_, err = store.Get(ctx, "user:1", "weak")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+	_, err = store.Get(ctx, "user:1", "strong")
+	assert.NoError(t, err)
+	assert.Positive(t, metrics.GetSnapshot().PruneTotal)
+}
+
+func TestStartMaintenance_ConcurrentWithLiveTraffic(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	events := manager.Subscribe("")
+	require.NoError(t, manager.StartMaintenance(MaintenanceConfig{
+		Namespaces:            []string{"user:1", "user:2", "user:3"},
+		ConsolidateInterval:   5 * time.Millisecond,
+		PruneInterval:         5 * time.Millisecond,
+		PruneCriteria:         PruneCriteria{MinConfidence: 0.5},
+		MaxPrunePerCycle:      2,
+		MaxNamespacesPerCycle: 1,
+	}))
+
+	var wg sync.WaitGroup
+	for w := range 3 {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			ns := fmt.Sprintf("user:%d", w+1)
+			for i := range 50 {
+				_ = manager.RecordMemory(ctx, newMaintenanceMemory(ns, fmt.Sprintf("k%d", i), float64(i%10)/10))
+				_, _ = manager.RetrieveMemories(ctx, ns)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// 每个周期最多处理一个命名空间、清理两条
+	report := waitMaintenance(t, events, "prune")
+	assert.Len(t, report.Namespaces, 1)
+	assert.LessOrEqual(t, report.Deleted, 2)
+	manager.StopMaintenance()
+}
+
+func TestStartMaintenance_RequiresInterval(t *testing.T) {
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	assert.Error(t, manager.StartMaintenance(MaintenanceConfig{}))
+	manager.StopMaintenance() // 未启动时为空操作
+}
+
+func TestPruneSchedule_StartsWithManager(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	require.NoError(t, store.Save(ctx, newMaintenanceMemory("user:1", "weak", 0.2)))
+	require.NoError(t, store.Save(ctx, newMaintenanceMemory("user:1", "strong", 0.9)))
+
+	pruned := make(chan int, 16)
+	manager, err := NewManager(&ManagerConfig{
+		Store: store,
+		PruneSchedule: &PruneSchedule{
+			Interval: 10 * time.Millisecond,
+			Criteria: PruneCriteria{MinConfidence: 0.5},
+			OnPrune: func(count int, err error) {
+				assert.NoError(t, err)
+				pruned <- count
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	select {
+	case count := <-pruned:
+		assert.Equal(t, 1, count)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for scheduled prune")
+	}
+	assert.ErrorIs(t, manager.StartMaintenance(MaintenanceConfig{PruneInterval: time.Second}), ErrMaintenanceRunning)
+
+	// Close 停止定时清理
+	require.NoError(t, manager.Close())
+	for len(pruned) > 0 {
+		<-pruned
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, pruned)
+
+	_, err = NewManager(&ManagerConfig{Store: NewInMemoryStore(), PruneSchedule: &PruneSchedule{}})
+	assert.Error(t, err)
+}
+
+func TestPruneSchedule_SkipsWhilePruneRunning(t *testing.T) {
+	manager, err := NewManager(&ManagerConfig{Store: NewInMemoryStore()})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	events := manager.Subscribe("")
+	var calls int
+	manager.pruneMu.Lock()
+	require.NoError(t, manager.StartMaintenance(MaintenanceConfig{
+		PruneInterval: 5 * time.Millisecond,
+		Jitter:        -1,
+		OnPrune:       func(int, error) { calls++ },
+	}))
+
+	// 持有清理锁期间的周期被跳过
+	waitMaintenance(t, events, "prune")
+	manager.StopMaintenance()
+	manager.pruneMu.Unlock()
+	assert.Zero(t, calls)
+}
+
+func TestStartMaintenance_DefaultNamespacesRespectCap(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	for _, ns := range []string{"user:2", "user:1"} {
+		for i := range 5 {
+			require.NoError(t, store.Save(ctx, newMaintenanceMemory(ns, fmt.Sprintf("weak%d", i), 0.2)))
+		}
+	}
+
+	events := manager.Subscribe("")
+	require.NoError(t, manager.StartMaintenance(MaintenanceConfig{
+		ConsolidateInterval: 10 * time.Millisecond,
+		PruneInterval:       10 * time.Millisecond,
+		PruneCriteria:       PruneCriteria{MinConfidence: 0.5},
+		MaxPrunePerCycle:    2,
+		Jitter:              -1,
+	}))
+
+	// 未配置命名空间时从存储中枚举，每个命名空间同样受清理上限约束
+	report := waitMaintenance(t, events, "prune")
+	assert.Equal(t, []string{"user:1", "user:2"}, report.Namespaces)
+	assert.Equal(t, 4, report.Deleted)
+
+	report = waitMaintenance(t, events, "consolidate")
+	assert.NotEmpty(t, report.Namespaces)
+	manager.StopMaintenance()
+}
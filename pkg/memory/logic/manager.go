@@ -3,10 +3,14 @@ package logic
 import (
 	"context"
 	"errors"
+	"fmt"
 	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/astercloud/aster/pkg/memory"
+	"github.com/astercloud/aster/pkg/vector"
 	"github.com/google/uuid"
 )
 
@@ -19,8 +23,25 @@ type Manager struct {
 	// matchers 模式匹配器列表（支持多个 Matcher 并行工作）
 	matchers []PatternMatcher
 
+	// matcherIndex 事件类型 -> 声明支持该类型的 Matcher 下标（按注册顺序）
+	matcherIndex map[string][]int
+
+	// wildcardMatchers 支持所有事件类型的 Matcher 下标（空列表或 "*"）
+	wildcardMatchers []int
+
 	// config 管理器配置
 	config *ManagerConfig
+
+	// subscribers Memory 事件订阅者
+	subMu       sync.Mutex
+	subscribers []*memorySubscriber
+
+	// maint 后台维护任务（StartMaintenance 启动）
+	maintMu sync.Mutex
+	maint   *maintenance
+
+	// pruneMu 保证同一时间只有一次清理
+	pruneMu sync.Mutex
 }
 
 // ManagerConfig Manager 配置
@@ -42,6 +63,27 @@ type ManagerConfig struct {
 
 	// ConfidenceBoost 每次重复出现的置信度提升（默认 0.05）
 	ConfidenceBoost float64
+
+	// EventBufferSize 每个事件订阅通道的缓冲大小（默认 64）
+	EventBufferSize int
+
+	// DecayHalfLife 置信度衰减半衰期（默认 0，不衰减）
+	// 设置后检索时的有效置信度为 stored * 0.5^(age/halflife)
+	DecayHalfLife time.Duration
+
+	// Embedder 向量生成器（可选），配置后记录时嵌入 Description 并支持 WithSemanticQuery
+	Embedder vector.Embedder
+
+	// ConflictPolicy 同一 Key 记录到矛盾 Value 时的处理策略（默认 ConflictPolicyMerge）
+	ConflictPolicy ConflictPolicy
+
+	// ScopePrecedence 作用域优先级（从高到低，用于 RetrieveWithScopeChain）
+	// 默认 DefaultScopePrecedence：session > user > global
+	ScopePrecedence []MemoryScope
+
+	// PruneSchedule 定时清理（可选），NewManager 时启动后台清理，Close 时停止
+	// 设置后不能再调用 StartMaintenance
+	PruneSchedule *PruneSchedule
 }
 
 // NewManager 创建 Logic Memory Manager
@@ -62,15 +104,28 @@ func NewManager(config *ManagerConfig) (*Manager, error) {
 		config.ConfidenceBoost = 0.05
 	}
 
-	return &Manager{
+	m := &Manager{
 		store:    config.Store,
 		matchers: config.Matchers,
 		config:   config,
-	}, nil
+	}
+	m.buildMatcherIndex()
+
+	if config.PruneSchedule != nil {
+		if err := m.StartMaintenance(config.PruneSchedule.maintenanceConfig()); err != nil {
+			return nil, fmt.Errorf("start prune schedule: %w", err)
+		}
+	}
+	return m, nil
 }
 
 // RecordMemory 主动记录 Memory（应用层手动调用）
-func (m *Manager) RecordMemory(ctx context.Context, memory *LogicMemory) error {
+// 可通过 WithTTL 设置过期时间
+func (m *Manager) RecordMemory(ctx context.Context, memory *LogicMemory, opts ...RecordOption) error {
+	for _, opt := range opts {
+		opt(memory)
+	}
+
 	// 设置 ID
 	if memory.ID == "" {
 		memory.ID = uuid.New().String()
@@ -81,16 +136,7 @@ func (m *Manager) RecordMemory(ctx context.Context, memory *LogicMemory) error {
 		memory.Provenance = m.config.DefaultProvenance
 	}
 
-	// 检查是否已存在
-	existing, err := m.store.Get(ctx, memory.Namespace, memory.Key)
-	if err == nil && existing != nil {
-		// 更新已有 Memory
-		m.mergeMemory(existing, memory)
-		return m.store.Save(ctx, existing)
-	}
-
-	// 创建新 Memory
-	return m.store.Save(ctx, memory)
+	return m.saveOrMerge(ctx, memory)
 }
 
 // ProcessEvent 处理事件，自动识别和记录 Memory（被动触发）
@@ -102,13 +148,24 @@ func (m *Manager) ProcessEvent(ctx context.Context, event Event) error {
 	}
 
 	// 1. 遍历所有 Matcher
-	var allMemories []*LogicMemory
-	for _, matcher := range m.matchers {
-		// 检查 Matcher 是否支持此事件类型
-		if !m.supportsEventType(matcher, event.Type) {
+	allMemories := m.matchEvent(ctx, event)
+
+	// 2. 保存或更新 Memory
+	for _, mem := range allMemories {
+		if err := m.saveOrMerge(ctx, mem); err != nil {
+			// TODO: 记录错误
 			continue
 		}
+	}
+
+	return nil
+}
 
+// matchEvent 使用所有支持该事件类型的 Matcher 识别候选 Memory，并补齐 ID 和默认溯源
+// 只调用在 SupportedEventTypes 中声明了该类型的 Matcher 和通配 Matcher
+func (m *Manager) matchEvent(ctx context.Context, event Event) []*LogicMemory {
+	var allMemories []*LogicMemory
+	for _, matcher := range m.matchersFor(event.Type) {
 		// 识别 Memory
 		memories, err := matcher.MatchEvent(ctx, event)
 		if err != nil {
@@ -120,7 +177,6 @@ func (m *Manager) ProcessEvent(ctx context.Context, event Event) error {
 		allMemories = append(allMemories, memories...)
 	}
 
-	// 2. 保存或更新 Memory
 	for _, mem := range allMemories {
 		// 设置 ID
 		if mem.ID == "" {
@@ -131,54 +187,94 @@ func (m *Manager) ProcessEvent(ctx context.Context, event Event) error {
 		if mem.Provenance == nil && m.config.DefaultProvenance != nil {
 			mem.Provenance = m.config.DefaultProvenance
 		}
+	}
+	return allMemories
+}
 
-		// 检查是否已存在
-		existing, err := m.store.Get(ctx, mem.Namespace, mem.Key)
-		if err == nil && existing != nil {
-			// 更新已有 Memory（提升置信度、累积证据）
-			m.mergeMemory(existing, mem)
-			if err := m.store.Save(ctx, existing); err != nil {
-				// TODO: 记录错误
-				continue
-			}
-		} else {
-			// 创建新 Memory
-			if err := m.store.Save(ctx, mem); err != nil {
-				// TODO: 记录错误
-				continue
-			}
+// saveOrMerge 保存新 Memory 或合并到已有 Memory，并发出 Created/Updated 事件
+func (m *Manager) saveOrMerge(ctx context.Context, mem *LogicMemory) error {
+	// 检查是否已存在（已过期的视为不存在，不会被合并复活）
+	existing, err := m.getLive(ctx, mem.Namespace, mem.Key)
+	if err == nil && existing != nil {
+		before := confidenceOf(existing)
+		if err := m.mergeInto(existing, mem); err != nil {
+			return err
+		}
+		m.ensureEmbedding(ctx, existing)
+		if err := m.store.Save(ctx, existing); err != nil {
+			return err
 		}
+		m.emit(MemoryEvent{
+			Type:            MemoryEventUpdated,
+			Namespace:       existing.Namespace,
+			Key:             existing.Key,
+			Memory:          existing,
+			ConfidenceDelta: confidenceOf(existing) - before,
+		})
+		return nil
 	}
 
+	// 创建新 Memory
+	m.ensureEmbedding(ctx, mem)
+	if err := m.store.Save(ctx, mem); err != nil {
+		return err
+	}
+	m.emit(MemoryEvent{
+		Type:      MemoryEventCreated,
+		Namespace: mem.Namespace,
+		Key:       mem.Key,
+		Memory:    mem,
+	})
 	return nil
 }
 
 // RetrieveMemories 检索 Memory（用于 Prompt 注入）
+// 启用 DecayHalfLife 时返回的 Provenance.Confidence 为衰减后的有效置信度，
+// WithMinConfidence 和 WithTopK 基于有效置信度生效；
+// 配置 Embedder 且使用 WithSemanticQuery 时按相似度排序并设置 Similarity
 func (m *Manager) RetrieveMemories(
 	ctx context.Context,
 	namespace string,
 	filters ...Filter,
 ) ([]*LogicMemory, error) {
 	// 检索 Memory
-	memories, err := m.store.List(ctx, namespace, filters...)
+	memories, err := m.retrieve(ctx, namespace, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.touchMemories(memories)
+	return memories, nil
+}
+
+// retrieve 检索 Memory，不更新访问计数
+func (m *Manager) retrieve(ctx context.Context, namespace string, filters ...Filter) ([]*LogicMemory, error) {
+	var memories []*LogicMemory
+	var err error
+	if m.config.DecayHalfLife > 0 || m.semanticEnabled(filters) {
+		memories, err = m.retrieveRanked(ctx, namespace, filters...)
+	} else {
+		memories, err = m.store.List(ctx, namespace, filters...)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return m.dropExpired(ctx, memories), nil
+}
 
-	// 更新访问计数（异步，不阻塞）
+// touchMemories 更新访问计数（异步，不阻塞）
+func (m *Manager) touchMemories(memories []*LogicMemory) {
 	go func() {
 		for _, mem := range memories {
 			// 忽略错误，访问计数不是关键操作
 			_ = m.store.IncrementAccessCount(context.Background(), mem.Namespace, mem.Key)
 		}
 	}()
-
-	return memories, nil
 }
 
 // GetMemory 获取单个 Memory
 func (m *Manager) GetMemory(ctx context.Context, namespace, key string) (*LogicMemory, error) {
-	memory, err := m.store.Get(ctx, namespace, key)
+	memory, err := m.getLive(ctx, namespace, key)
 	if err != nil {
 		return nil, err
 	}
@@ -202,15 +298,119 @@ func (m *Manager) GetStats(ctx context.Context, namespace string) (*MemoryStats,
 }
 
 // PruneMemories 清理低价值 Memory（定期任务）
+// 为每条被清理的 Memory 发出 Pruned 事件；存储未实现 PruneReporter 时，
+// 清理前列出满足条件的 Memory，清理后确认已删除的才发出事件。
 func (m *Manager) PruneMemories(ctx context.Context, criteria PruneCriteria) (int, error) {
-	return m.store.Prune(ctx, criteria)
+	m.pruneMu.Lock()
+	defer m.pruneMu.Unlock()
+	return m.pruneMemories(ctx, criteria)
+}
+
+// pruneMemories 执行清理，调用方需持有 pruneMu
+func (m *Manager) pruneMemories(ctx context.Context, criteria PruneCriteria) (int, error) {
+	if reporter, ok := m.store.(PruneReporter); ok {
+		pruned, err := reporter.PruneWithReport(ctx, criteria)
+		if err != nil {
+			return 0, err
+		}
+		for _, mem := range pruned {
+			m.emit(MemoryEvent{
+				Type:      MemoryEventPruned,
+				Namespace: mem.Namespace,
+				Key:       mem.Key,
+				Memory:    mem,
+				Count:     1,
+			})
+		}
+		return len(pruned), nil
+	}
+
+	// 存储无法返回被清理的 Memory 时，清理前记录满足条件的候选，清理后逐条确认
+	// List 不返回已过期的 Memory，它们计入清理数量但不发出事件；内置存储均实现了 PruneReporter
+	candidates, err := m.store.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	candidates = slices.DeleteFunc(candidates, func(mem *LogicMemory) bool {
+		return !criteria.Matches(mem, now)
+	})
+
+	count, err := m.store.Prune(ctx, criteria)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	for _, mem := range candidates {
+		if _, err := m.store.Get(ctx, mem.Namespace, mem.Key); !errors.Is(err, ErrMemoryNotFound) {
+			continue
+		}
+		m.emit(MemoryEvent{
+			Type:      MemoryEventPruned,
+			Namespace: mem.Namespace,
+			Key:       mem.Key,
+			Memory:    mem,
+			Count:     1,
+		})
+	}
+	return count, nil
+}
+
+// Consolidate 使用合并引擎合并命名空间内的相似 Memory，并为每个合并结果发出 Consolidated 事件
+// config 为 nil 时使用合并引擎的默认配置；DryRun 时不发出事件
+func (m *Manager) Consolidate(ctx context.Context, namespace string, config *ConsolidationConfig) (*ConsolidationResult, error) {
+	result, err := NewConsolidationEngine(m.store, config).Consolidate(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if result.DryRun {
+		return result, nil
+	}
+
+	for _, merged := range result.MergedMemories {
+		m.emit(MemoryEvent{
+			Type:      MemoryEventConsolidated,
+			Namespace: merged.Namespace,
+			Key:       merged.Key,
+			Memory:    merged,
+		})
+	}
+	return result, nil
 }
 
-// Close 关闭 Manager
+// Close 关闭 Manager，停止后台维护并关闭所有事件订阅通道
 func (m *Manager) Close() error {
+	m.StopMaintenance()
+	m.closeSubscribers()
 	return m.store.Close()
 }
 
+// mergeInto 将新观察到的 Memory 合并到已有 Memory
+// Value 矛盾时按 ConflictPolicy 处理并发出 Conflict 事件，否则提升置信度、累积证据
+func (m *Manager) mergeInto(existing, mem *LogicMemory) error {
+	resolved, err := m.resolveConflict(existing, mem)
+	if resolved {
+		m.emit(MemoryEvent{
+			Type:        MemoryEventConflict,
+			Namespace:   existing.Namespace,
+			Key:         existing.Key,
+			Memory:      existing,
+			Conflicting: mem,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if !resolved {
+		m.mergeMemory(existing, mem)
+	}
+	// 以最近一次记录的过期时间为准
+	existing.ExpiresAt = mem.ExpiresAt
+	return nil
+}
+
 // mergeMemory 合并新旧 Memory（提升置信度）
 // 这是一个简单的启发式实现，未来可以用 LLM 或 RL 优化
 func (m *Manager) mergeMemory(existing, new *LogicMemory) {
@@ -231,6 +431,7 @@ func (m *Manager) mergeMemory(existing, new *LogicMemory) {
 	// 3. 更新 Description（如果新的更详细）
 	if len(new.Description) > len(existing.Description) {
 		existing.Description = new.Description
+		existing.Embedding = nil
 	}
 
 	// 4. 合并 Value（简单覆盖，应用层可以自定义）
@@ -250,23 +451,42 @@ func (m *Manager) mergeMemory(existing, new *LogicMemory) {
 	existing.LastAccessed = time.Now()
 }
 
-// supportsEventType 检查 Matcher 是否支持指定的事件类型
-func (m *Manager) supportsEventType(matcher PatternMatcher, eventType string) bool {
-	supported := matcher.SupportedEventTypes()
-
-	// 空列表表示支持所有事件
-	if len(supported) == 0 {
-		return true
+// buildMatcherIndex 根据 SupportedEventTypes 建立事件类型索引
+// 空列表或包含 "*" 的 Matcher 视为通配，处理所有事件
+func (m *Manager) buildMatcherIndex() {
+	m.matcherIndex = make(map[string][]int)
+	for i, matcher := range m.matchers {
+		supported := matcher.SupportedEventTypes()
+		if len(supported) == 0 || slices.Contains(supported, "*") {
+			m.wildcardMatchers = append(m.wildcardMatchers, i)
+			continue
+		}
+		for _, eventType := range supported {
+			indexes := m.matcherIndex[eventType]
+			if !slices.Contains(indexes, i) {
+				m.matcherIndex[eventType] = append(indexes, i)
+			}
+		}
 	}
+}
 
-	// 检查是否支持通配符 "*"
-	for _, t := range supported {
-		if t == "*" || t == eventType {
-			return true
-		}
+// matchersFor 返回处理指定事件类型的 Matcher，保持注册顺序
+func (m *Manager) matchersFor(eventType string) []PatternMatcher {
+	typed := m.matcherIndex[eventType]
+	if len(typed) == 0 && len(m.wildcardMatchers) == 0 {
+		return nil
 	}
 
-	return false
+	indexes := make([]int, 0, len(typed)+len(m.wildcardMatchers))
+	indexes = append(indexes, typed...)
+	indexes = append(indexes, m.wildcardMatchers...)
+	slices.Sort(indexes)
+
+	result := make([]PatternMatcher, len(indexes))
+	for i, idx := range indexes {
+		result[i] = m.matchers[idx]
+	}
+	return result
 }
 
 // min 返回两个 float64 的最小值
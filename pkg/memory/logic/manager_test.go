@@ -477,3 +477,145 @@ func (m *testMatcher) MatchEvent(ctx context.Context, event Event) ([]*LogicMemo
 func (m *testMatcher) SupportedEventTypes() []string {
 	return m.supportedTypes
 }
+
+func TestRetrieveMemories_ConfidenceDecay(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store, DecayHalfLife: 30 * 24 * time.Hour})
+	require.NoError(t, err)
+
+	now := time.Now()
+	stale := now.Add(-60 * 24 * time.Hour)
+	require.NoError(t, store.Save(ctx, &LogicMemory{
+		ID: "old", Namespace: "user:1", Type: "preference", Key: "old",
+		CreatedAt: stale, LastAccessed: stale,
+		Provenance: &memory.MemoryProvenance{Confidence: 0.8},
+	}))
+	require.NoError(t, store.Save(ctx, &LogicMemory{
+		ID: "fresh", Namespace: "user:1", Type: "preference", Key: "fresh",
+		Provenance: &memory.MemoryProvenance{Confidence: 0.6},
+	}))
+
+	// 两个半衰期后 0.8 衰减为约 0.2，排在新 Memory 之后
+	retrieved, err := manager.RetrieveMemories(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, retrieved, 2)
+	assert.Equal(t, "fresh", retrieved[0].Key)
+	assert.InDelta(t, 0.2, retrieved[1].Provenance.Confidence, 0.01)
+
+	// 衰减先于 WithMinConfidence 过滤
+	retrieved, err = manager.RetrieveMemories(ctx, "user:1", WithMinConfidence(0.5))
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+	assert.Equal(t, "fresh", retrieved[0].Key)
+
+	mem, info, err := manager.GetMemoryWithConfidence(ctx, "user:1", "old")
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, mem.Provenance.Confidence, "stored confidence should not be modified")
+	assert.Equal(t, 0.8, info.Raw)
+	assert.InDelta(t, 0.2, info.Decayed, 0.01)
+}
+
+func TestRetrieveMemories_DecayDisabledByDefault(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	stale := time.Now().Add(-365 * 24 * time.Hour)
+	require.NoError(t, store.Save(ctx, &LogicMemory{
+		ID: "old", Namespace: "user:1", Key: "old",
+		CreatedAt: stale, LastAccessed: stale,
+		Provenance: &memory.MemoryProvenance{Confidence: 0.8},
+	}))
+
+	retrieved, err := manager.RetrieveMemories(ctx, "user:1", WithMinConfidence(0.5))
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+	assert.Equal(t, 0.8, retrieved[0].Provenance.Confidence)
+
+	_, info, err := manager.GetMemoryWithConfidence(ctx, "user:1", "old")
+	require.NoError(t, err)
+	assert.Equal(t, info.Raw, info.Decayed)
+}
+
+func TestRetrieveMemories_WithTypes(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	for _, mem := range []*LogicMemory{
+		{ID: "1", Namespace: "user:1", Type: "preference", Key: "tone", Provenance: &memory.MemoryProvenance{Confidence: 0.9}},
+		{ID: "2", Namespace: "user:1", Type: "preference", Key: "length", Provenance: &memory.MemoryProvenance{Confidence: 0.5}},
+		{ID: "3", Namespace: "user:1", Type: "behavior", Key: "clicks", Provenance: &memory.MemoryProvenance{Confidence: 0.95}},
+		{ID: "4", Namespace: "user:1", Type: "skill", Key: "go", Provenance: &memory.MemoryProvenance{Confidence: 0.8}},
+	} {
+		require.NoError(t, store.Save(ctx, mem))
+	}
+
+	keys := func(memories []*LogicMemory) []string {
+		var result []string
+		for _, mem := range memories {
+			result = append(result, mem.Key)
+		}
+		return result
+	}
+
+	preferences, err := manager.RetrieveMemories(ctx, "user:1", WithType("preference"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tone", "length"}, keys(preferences))
+
+	mixed, err := manager.RetrieveMemories(ctx, "user:1", WithType("preference", "skill"), WithMinConfidence(0.6))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tone", "go"}, keys(mixed))
+
+	top, err := manager.RetrieveMemories(ctx, "user:1", WithType("preference", "behavior"), WithTopK(2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clicks", "tone"}, keys(top))
+
+	all, err := manager.RetrieveMemories(ctx, "user:1", WithType())
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+// countingMatcher 记录 MatchEvent 调用的事件类型
+type countingMatcher struct {
+	types      []string
+	seen       []string
+	typeLookup int
+}
+
+func (m *countingMatcher) MatchEvent(_ context.Context, event Event) ([]*LogicMemory, error) {
+	m.seen = append(m.seen, event.Type)
+	return nil, nil
+}
+
+func (m *countingMatcher) SupportedEventTypes() []string {
+	m.typeLookup++
+	return m.types
+}
+
+func TestProcessEvent_DispatchesByEventType(t *testing.T) {
+	ctx := context.Background()
+	feedback := &countingMatcher{types: []string{"user_feedback"}}
+	messages := &countingMatcher{types: []string{"user_message", "assistant_message"}}
+	wildcard := &countingMatcher{}
+	star := &countingMatcher{types: []string{"*"}}
+
+	manager, err := NewManager(&ManagerConfig{
+		Store:    NewInMemoryStore(),
+		Matchers: []PatternMatcher{feedback, messages, wildcard, star},
+	})
+	require.NoError(t, err)
+
+	for _, eventType := range []string{"user_message", "user_feedback", "tool_result", "user_message"} {
+		require.NoError(t, manager.ProcessEvent(ctx, Event{Type: eventType}))
+	}
+
+	assert.Equal(t, []string{"user_feedback"}, feedback.seen)
+	assert.Equal(t, []string{"user_message", "user_message"}, messages.seen)
+	assert.Len(t, wildcard.seen, 4)
+	assert.Len(t, star.seen, 4)
+	assert.Equal(t, 1, feedback.typeLookup, "SupportedEventTypes should only be read when building the index")
+}
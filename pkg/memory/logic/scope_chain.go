@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultScopePrecedence 默认作用域优先级（从高到低）：会话级覆盖用户级，用户级覆盖全局
+var DefaultScopePrecedence = []MemoryScope{ScopeSession, ScopeUser, ScopeGlobal}
+
+// RetrieveWithScopeChain 跨多个命名空间检索并按 Key 合并（如 session:1, user:1, global）
+// 同一 Key 的多个 Memory 中，先按 ManagerConfig.ScopePrecedence 选择作用域优先级最高者，
+// 再按置信度，最后按 namespaces 中的顺序；不在优先级列表中的作用域排在最后。
+// filters 对每个命名空间生效，WithTopK 和排序在合并后执行。
+func (m *Manager) RetrieveWithScopeChain(ctx context.Context, namespaces []string, filters ...Filter) ([]*LogicMemory, error) {
+	opts := ApplyFilters(filters...)
+	perNamespace := append(append([]Filter{}, filters...), WithTopK(0))
+
+	rank := m.scopeRanks()
+	winners := make(map[string]*LogicMemory)
+	var keys []string
+	for _, ns := range namespaces {
+		memories, err := m.retrieve(ctx, ns, perNamespace...)
+		if err != nil {
+			return nil, err
+		}
+		for _, mem := range memories {
+			current, ok := winners[mem.Key]
+			if !ok {
+				keys = append(keys, mem.Key)
+			}
+			if !ok || shadows(mem, current, rank) {
+				winners[mem.Key] = mem
+			}
+		}
+	}
+
+	result := make([]*LogicMemory, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, winners[key])
+	}
+
+	if m.semanticEnabled(filters) {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Similarity > result[j].Similarity
+		})
+	} else {
+		sortMemories(result, opts.OrderBy)
+	}
+	if opts.MaxResults > 0 && len(result) > opts.MaxResults {
+		result = result[:opts.MaxResults]
+	}
+
+	m.touchMemories(result)
+	return result, nil
+}
+
+// scopeRanks 作用域 -> 优先级（数值越小优先级越高）
+func (m *Manager) scopeRanks() map[MemoryScope]int {
+	precedence := m.config.ScopePrecedence
+	if len(precedence) == 0 {
+		precedence = DefaultScopePrecedence
+	}
+	rank := make(map[MemoryScope]int, len(precedence))
+	for i, scope := range precedence {
+		if _, ok := rank[scope]; !ok {
+			rank[scope] = i
+		}
+	}
+	return rank
+}
+
+// shadows candidate 是否应覆盖 current：作用域优先，其次置信度
+// 两者相同时保留先出现（namespaces 中靠前）的 current
+func shadows(candidate, current *LogicMemory, rank map[MemoryScope]int) bool {
+	rc, rk := scopeRank(candidate.Scope, rank), scopeRank(current.Scope, rank)
+	if rc != rk {
+		return rc < rk
+	}
+	return confidenceOf(candidate) > confidenceOf(current)
+}
+
+func scopeRank(scope MemoryScope, rank map[MemoryScope]int) int {
+	if r, ok := rank[scope]; ok {
+		return r
+	}
+	return len(rank)
+}
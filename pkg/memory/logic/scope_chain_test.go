@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveScoped(t *testing.T, store LogicMemoryStore, namespace string, scope MemoryScope, key, value string, confidence float64) {
+	t.Helper()
+	require.NoError(t, store.Save(context.Background(), &LogicMemory{
+		ID:         namespace + "/" + key,
+		Namespace:  namespace,
+		Scope:      scope,
+		Type:       "preference",
+		Key:        key,
+		Value:      value,
+		Provenance: &memory.MemoryProvenance{Confidence: confidence},
+	}))
+}
+
+func TestRetrieveWithScopeChain(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	saveScoped(t, store, "global", ScopeGlobal, "tone", "neutral", 0.99)
+	saveScoped(t, store, "user:1", ScopeUser, "tone", "casual", 0.9)
+	saveScoped(t, store, "session:1", ScopeSession, "tone", "formal", 0.4)
+	saveScoped(t, store, "user:1", ScopeUser, "language", "go", 0.8)
+	saveScoped(t, store, "global", ScopeGlobal, "language", "python", 0.95)
+	saveScoped(t, store, "global", ScopeGlobal, "units", "metric", 0.7)
+
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	chain := []string{"global", "user:1", "session:1"}
+	memories, err := manager.RetrieveWithScopeChain(ctx, chain)
+	require.NoError(t, err)
+
+	values := make(map[string]any)
+	for _, mem := range memories {
+		values[mem.Key] = mem.Value
+	}
+	assert.Equal(t, map[string]any{"tone": "formal", "language": "go", "units": "metric"}, values,
+		"higher-precedence scopes shadow lower ones regardless of confidence")
+
+	top, err := manager.RetrieveWithScopeChain(ctx, chain, WithTopK(1))
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "language", top[0].Key)
+
+	// 自定义优先级：全局配置优先
+	custom, err := NewManager(&ManagerConfig{
+		Store:           store,
+		ScopePrecedence: []MemoryScope{ScopeGlobal, ScopeUser, ScopeSession},
+	})
+	require.NoError(t, err)
+	memories, err = custom.RetrieveWithScopeChain(ctx, chain, WithType("preference"))
+	require.NoError(t, err)
+	for _, mem := range memories {
+		assert.Equal(t, ScopeGlobal, mem.Scope, mem.Key)
+	}
+}
+
+func TestRetrieveWithScopeChain_ConfidenceBreaksTies(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	saveScoped(t, store, "team:1", ScopeUser, "tone", "casual", 0.6)
+	saveScoped(t, store, "user:1", ScopeUser, "tone", "formal", 0.8)
+
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	memories, err := manager.RetrieveWithScopeChain(ctx, []string{"team:1", "user:1"})
+	require.NoError(t, err)
+	require.Len(t, memories, 1)
+	assert.Equal(t, "formal", memories[0].Value)
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/astercloud/aster/pkg/vector"
+)
+
+// semanticEnabled 是否启用语义检索（需同时配置 Embedder 和查询文本）
+func (m *Manager) semanticEnabled(filters []Filter) bool {
+	return m.config.Embedder != nil && ApplyFilters(filters...).SemanticQuery != ""
+}
+
+// ensureEmbedding 为 Description 生成向量
+// 嵌入失败不阻塞保存，缺失的向量会在语义检索时补齐
+func (m *Manager) ensureEmbedding(ctx context.Context, mem *LogicMemory) {
+	if m.config.Embedder == nil || mem.Description == "" || len(mem.Embedding) > 0 {
+		return
+	}
+	vecs, err := m.config.Embedder.EmbedText(ctx, []string{mem.Description})
+	if err != nil || len(vecs) != 1 {
+		return
+	}
+	mem.Embedding = vecs[0]
+}
+
+// retrieveRanked 在进程内完成衰减、语义排序、置信度过滤和 TopK
+func (m *Manager) retrieveRanked(ctx context.Context, namespace string, filters ...Filter) ([]*LogicMemory, error) {
+	opts := ApplyFilters(filters...)
+	decay := m.config.DecayHalfLife > 0
+
+	// 数量限制在进程内排序后执行；启用衰减时置信度过滤也需基于有效置信度
+	storeFilters := append(append([]Filter{}, filters...), WithTopK(0))
+	if decay {
+		storeFilters = append(storeFilters, WithMinConfidence(0))
+	}
+	memories, err := m.store.List(ctx, namespace, storeFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]*LogicMemory, 0, len(memories))
+	for _, mem := range memories {
+		copied := *mem
+		if decay && mem.Provenance != nil {
+			// 拷贝后修改，避免影响存储层共享的 Provenance
+			provenance := *mem.Provenance
+			provenance.Confidence = decayConfidence(provenance.Confidence, memoryAge(mem, now), m.config.DecayHalfLife)
+			if provenance.Confidence < opts.MinConfidence {
+				continue
+			}
+			copied.Provenance = &provenance
+		}
+		result = append(result, &copied)
+	}
+
+	switch {
+	case m.config.Embedder != nil && opts.SemanticQuery != "":
+		if err := m.rankBySimilarity(ctx, opts.SemanticQuery, result); err != nil {
+			return nil, err
+		}
+	case decay && opts.OrderBy == OrderByConfidence:
+		sortMemories(result, opts.OrderBy)
+	}
+
+	if opts.MaxResults > 0 && len(result) > opts.MaxResults {
+		result = result[:opts.MaxResults]
+	}
+	return result, nil
+}
+
+// rankBySimilarity 计算与查询的余弦相似度并按相似度降序排序
+// 缺少向量的 Memory 会在此批量补齐（不回写存储）
+func (m *Manager) rankBySimilarity(ctx context.Context, query string, memories []*LogicMemory) error {
+	texts := []string{query}
+	var missing []*LogicMemory
+	for _, mem := range memories {
+		if len(mem.Embedding) == 0 && mem.Description != "" {
+			texts = append(texts, mem.Description)
+			missing = append(missing, mem)
+		}
+	}
+
+	vecs, err := m.config.Embedder.EmbedText(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(vecs) != len(texts) {
+		return fmt.Errorf("embedder returned %d vectors for %d texts", len(vecs), len(texts))
+	}
+	for i, mem := range missing {
+		mem.Embedding = vecs[i+1]
+	}
+
+	for _, mem := range memories {
+		mem.Similarity = vector.CosineSimilarity(vecs[0], mem.Embedding)
+	}
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].Similarity > memories[j].Similarity
+	})
+	return nil
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder 按关键词出现次数生成向量，便于断言相似度排序
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func (e *keywordEmbedder) EmbedText(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(e.keywords))
+		for j, kw := range e.keywords {
+			vec[j] = float32(strings.Count(strings.ToLower(text), kw))
+		}
+		result[i] = vec
+	}
+	return result, nil
+}
+
+func TestRetrieveMemories_SemanticQuery(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	embedder := &keywordEmbedder{keywords: []string{"coffee", "tea", "code"}}
+	manager, err := NewManager(&ManagerConfig{Store: store, Embedder: embedder})
+	require.NoError(t, err)
+
+	for key, desc := range map[string]string{
+		"drink":  "User drinks coffee every morning",
+		"review": "User prefers small code reviews",
+		"other":  "User likes tea with code",
+	} {
+		require.NoError(t, manager.RecordMemory(ctx, &LogicMemory{
+			Namespace:   "user:1",
+			Key:         key,
+			Description: desc,
+			Provenance:  &memory.MemoryProvenance{Confidence: 0.5},
+		}))
+	}
+
+	stored, err := store.Get(ctx, "user:1", "drink")
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.Embedding, "description should be embedded on record")
+
+	retrieved, err := manager.RetrieveMemories(ctx, "user:1", WithSemanticQuery("code style"), WithTopK(2))
+	require.NoError(t, err)
+	require.Len(t, retrieved, 2)
+	assert.Equal(t, "review", retrieved[0].Key)
+	assert.InDelta(t, 1.0, retrieved[0].Similarity, 1e-6)
+	assert.Equal(t, "other", retrieved[1].Key)
+	assert.Less(t, retrieved[1].Similarity, retrieved[0].Similarity)
+}
+
+func TestRetrieveMemories_SemanticQueryWithoutEmbedder(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.RecordMemory(ctx, &LogicMemory{
+		Namespace:   "user:1",
+		Key:         "drink",
+		Description: "User drinks coffee",
+		Provenance:  &memory.MemoryProvenance{Confidence: 0.5},
+	}))
+
+	retrieved, err := manager.RetrieveMemories(ctx, "user:1", WithSemanticQuery("code"))
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+	assert.Empty(t, retrieved[0].Embedding)
+	assert.Zero(t, retrieved[0].Similarity)
+}
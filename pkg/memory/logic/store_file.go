@@ -0,0 +1,469 @@
+package logic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFileCompactThreshold 默认压缩阈值：失效记录数超过此值且多于有效记录时重写文件
+const DefaultFileCompactThreshold = 256
+
+// fileStoreExt 命名空间日志文件扩展名
+const fileStoreExt = ".jsonl"
+
+// FileStore 基于文件的持久化存储（介于内存存储和 Redis 之间的简单方案）
+// 每个命名空间对应 dir 下一个追加写的 JSONL 日志文件：Save/IncrementAccessCount 追加 put 记录，
+// Delete/Prune 追加 del 记录；首次访问命名空间时回放日志加载到内存。
+// 失效记录（被覆盖或删除）过多时自动压缩，用当前数据重写文件。
+type FileStore struct {
+	dir              string
+	compactThreshold int
+
+	// mu 保护加载状态和文件写入
+	mu     sync.Mutex
+	cache  *InMemoryStore
+	loaded map[string]*fileNamespace
+	closed bool
+}
+
+// fileNamespace 已加载命名空间的日志状态
+type fileNamespace struct {
+	// records 日志文件中的记录数（含失效记录）
+	records int
+}
+
+// fileRecord 日志记录
+type fileRecord struct {
+	Op     string       `json:"op"` // "put" | "del"
+	Key    string       `json:"key,omitempty"`
+	Memory *LogicMemory `json:"memory,omitempty"`
+}
+
+// NewFileStore 创建文件存储，dir 不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("dir is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &FileStore{
+		dir:              dir,
+		compactThreshold: DefaultFileCompactThreshold,
+		cache:            NewInMemoryStore(),
+		loaded:           make(map[string]*fileNamespace),
+	}, nil
+}
+
+// SetCompactThreshold 设置压缩阈值（<= 0 恢复默认值）
+func (s *FileStore) SetCompactThreshold(threshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if threshold <= 0 {
+		threshold = DefaultFileCompactThreshold
+	}
+	s.compactThreshold = threshold
+}
+
+// Save 保存或更新 Memory，写入日志并 fsync 后返回
+func (s *FileStore) Save(ctx context.Context, memory *LogicMemory) error {
+	return s.SaveBatch(ctx, []*LogicMemory{memory})
+}
+
+// SaveBatch 保存多条 Memory，每个命名空间一次追加写
+// 追加写成功后才更新缓存，写入失败的命名空间在缓存中保持原状
+func (s *FileStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	byNamespace := make(map[string][]fileRecord)
+	var order []string
+	now := time.Now()
+	for _, memory := range memories {
+		if memory.Namespace == "" {
+			return ErrInvalidNamespace
+		}
+		if err := s.ensureLoaded(memory.Namespace); err != nil {
+			return err
+		}
+
+		// 与 InMemoryStore.Save 相同的时间戳规则
+		if !s.cache.contains(memory.Namespace, memory.Key) && memory.CreatedAt.IsZero() {
+			memory.CreatedAt = now
+		}
+		memory.UpdatedAt = now
+		if memory.LastAccessed.IsZero() {
+			memory.LastAccessed = now
+		}
+
+		if _, ok := byNamespace[memory.Namespace]; !ok {
+			order = append(order, memory.Namespace)
+		}
+		stored := *memory
+		byNamespace[memory.Namespace] = append(byNamespace[memory.Namespace], fileRecord{Op: "put", Memory: &stored})
+	}
+
+	for _, ns := range order {
+		if err := s.appendRecords(ns, byNamespace[ns]); err != nil {
+			return err
+		}
+		for _, record := range byNamespace[ns] {
+			s.cache.restore(record.Memory)
+		}
+	}
+	return nil
+}
+
+// Get 获取单个 Memory
+func (s *FileStore) Get(ctx context.Context, namespace, key string) (*LogicMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	if err := s.ensureLoaded(namespace); err != nil {
+		return nil, err
+	}
+	return s.cache.Get(ctx, namespace, key)
+}
+
+// Delete 删除 Memory
+func (s *FileStore) Delete(ctx context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	if err := s.ensureLoaded(namespace); err != nil {
+		return err
+	}
+	if !s.cache.contains(namespace, key) {
+		return nil
+	}
+	if err := s.appendRecords(namespace, []fileRecord{{Op: "del", Key: key}}); err != nil {
+		return err
+	}
+	return s.cache.Delete(ctx, namespace, key)
+}
+
+// List 列出符合条件的 Memory（namespace 为空时加载全部命名空间）
+func (s *FileStore) List(ctx context.Context, namespace string, filters ...Filter) ([]*LogicMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	if err := s.ensureScope(namespace); err != nil {
+		return nil, err
+	}
+	return s.cache.List(ctx, namespace, filters...)
+}
+
+// SearchByType 按类型搜索
+func (s *FileStore) SearchByType(ctx context.Context, namespace, memoryType string) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithType(memoryType))
+}
+
+// SearchByScope 按作用域搜索
+func (s *FileStore) SearchByScope(ctx context.Context, namespace string, scope MemoryScope) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithScope(scope))
+}
+
+// GetTopK 获取 TopK Memory
+func (s *FileStore) GetTopK(ctx context.Context, namespace string, k int, orderBy OrderBy) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithTopK(k), WithOrderBy(orderBy))
+}
+
+// IncrementAccessCount 增加访问计数
+func (s *FileStore) IncrementAccessCount(ctx context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	if err := s.ensureLoaded(namespace); err != nil {
+		return err
+	}
+	if err := s.cache.IncrementAccessCount(ctx, namespace, key); err != nil {
+		return err
+	}
+	memory, err := s.cache.Get(ctx, namespace, key)
+	if err != nil {
+		return err
+	}
+	return s.appendRecords(namespace, []fileRecord{{Op: "put", Memory: memory}})
+}
+
+// GetStats 获取统计信息
+func (s *FileStore) GetStats(ctx context.Context, namespace string) (*MemoryStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	if err := s.ensureScope(namespace); err != nil {
+		return nil, err
+	}
+	return s.cache.GetStats(ctx, namespace)
+}
+
+// Prune 清理低价值 Memory
+func (s *FileStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	pruned, err := s.PruneWithReport(ctx, criteria)
+	return len(pruned), err
+}
+
+// PruneWithReport 清理低价值 Memory，并返回被清理的 Memory
+func (s *FileStore) PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	if err := s.ensureScope(""); err != nil {
+		return nil, err
+	}
+
+	pruned, err := s.cache.PruneWithReport(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	byNamespace := make(map[string][]fileRecord)
+	for _, memory := range pruned {
+		byNamespace[memory.Namespace] = append(byNamespace[memory.Namespace], fileRecord{Op: "del", Key: memory.Key})
+	}
+	for ns, records := range byNamespace {
+		if err := s.appendRecords(ns, records); err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// Compact 用当前数据重写命名空间的日志文件，去除失效记录
+func (s *FileStore) Compact(ctx context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	if err := s.ensureLoaded(namespace); err != nil {
+		return err
+	}
+	return s.compact(namespace)
+}
+
+// Close 关闭存储（所有写入已同步落盘）
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.cache.Close()
+}
+
+// ensureScope namespace 为空时加载目录下全部命名空间
+func (s *FileStore) ensureScope(namespace string) error {
+	if namespace != "" {
+		return s.ensureLoaded(namespace)
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return NewStoreError("QUERY_ERROR", "failed to read store dir", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileStoreExt) {
+			continue
+		}
+		ns, err := url.QueryUnescape(strings.TrimSuffix(name, fileStoreExt))
+		if err != nil {
+			continue
+		}
+		if err := s.ensureLoaded(ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureLoaded 首次访问命名空间时回放日志，调用方需持有 mu
+// 末尾不完整的记录（写入中途崩溃）会被丢弃并立即压缩文件
+func (s *FileStore) ensureLoaded(namespace string) error {
+	if _, ok := s.loaded[namespace]; ok {
+		return nil
+	}
+
+	state := &fileNamespace{}
+	f, err := os.Open(s.namespacePath(namespace))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.loaded[namespace] = state
+			return nil
+		}
+		return NewStoreError("QUERY_ERROR", "failed to open namespace file", err)
+	}
+	defer f.Close()
+
+	truncated := false
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var record fileRecord
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				truncated = true
+				break
+			}
+			return NewStoreError("UNMARSHAL_ERROR", "corrupted namespace file "+namespace, err)
+		}
+		state.records++
+
+		switch record.Op {
+		case "put":
+			if record.Memory != nil {
+				s.cache.restore(record.Memory)
+			}
+		case "del":
+			_ = s.cache.Delete(context.Background(), namespace, record.Key)
+		}
+	}
+
+	s.loaded[namespace] = state
+	if truncated {
+		// 立即重写，避免后续追加写接在不完整的记录之后
+		return s.compact(namespace)
+	}
+	return nil
+}
+
+// appendRecords 追加日志记录并同步落盘，失效记录过多时压缩
+func (s *FileStore) appendRecords(namespace string, records []fileRecord) error {
+	var buf strings.Builder
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return NewStoreError("MARSHAL_ERROR", "failed to marshal memory", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(s.namespacePath(namespace), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to open namespace file", err)
+	}
+	if _, err := f.WriteString(buf.String()); err != nil {
+		_ = f.Close()
+		return NewStoreError("SAVE_ERROR", "failed to append namespace file", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return NewStoreError("SAVE_ERROR", "failed to sync namespace file", err)
+	}
+	if err := f.Close(); err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to close namespace file", err)
+	}
+
+	state := s.loaded[namespace]
+	state.records += len(records)
+
+	live, err := s.cache.List(context.Background(), namespace)
+	if err != nil {
+		return err
+	}
+	garbage := state.records - len(live)
+	if garbage > s.compactThreshold && garbage > len(live) {
+		return s.compact(namespace)
+	}
+	return nil
+}
+
+// compact 写入临时文件后原子替换，调用方需持有 mu
+func (s *FileStore) compact(namespace string) error {
+	live, err := s.cache.List(context.Background(), namespace, WithOrderBy(OrderByCreatedAt))
+	if err != nil {
+		return err
+	}
+
+	path := s.namespacePath(namespace)
+	if len(live) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return NewStoreError("SAVE_ERROR", "failed to remove namespace file", err)
+		}
+		s.loaded[namespace].records = 0
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".compact-*")
+	if err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to create compaction file", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, memory := range live {
+		if err := encoder.Encode(fileRecord{Op: "put", Memory: memory}); err != nil {
+			_ = tmp.Close()
+			return NewStoreError("MARSHAL_ERROR", "failed to marshal memory", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tmp.Close()
+		return NewStoreError("SAVE_ERROR", "failed to write compaction file", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return NewStoreError("SAVE_ERROR", "failed to sync compaction file", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to close compaction file", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to replace namespace file", err)
+	}
+
+	s.loaded[namespace].records = len(live)
+	return nil
+}
+
+// namespacePath 命名空间对应的日志文件路径（命名空间经 URL 转义，如 user:1 -> user%3A1.jsonl）
+func (s *FileStore) namespacePath(namespace string) string {
+	return filepath.Join(s.dir, url.QueryEscape(namespace)+fileStoreExt)
+}
+
+// 确保 FileStore 实现 LogicMemoryStore 接口
+var _ LogicMemoryStore = (*FileStore)(nil)
+
+var _ PruneReporter = (*FileStore)(nil)
+
+var _ BatchSaver = (*FileStore)(nil)
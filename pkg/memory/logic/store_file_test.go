@@ -0,0 +1,177 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileMemory(namespace, key string, confidence float64) *LogicMemory {
+	return &LogicMemory{
+		ID:         namespace + "/" + key,
+		Namespace:  namespace,
+		Scope:      ScopeUser,
+		Type:       "preference",
+		Key:        key,
+		Value:      map[string]any{"v": key},
+		Provenance: &memory.MemoryProvenance{Confidence: confidence, Sources: []string{"src"}},
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestFileStore_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, newFileMemory("user:1", "tone", 0.8)))
+	require.NoError(t, store.Save(ctx, newFileMemory("user:1", "language", 0.6)))
+	require.NoError(t, store.Save(ctx, newFileMemory("user:2", "tone", 0.5)))
+	require.NoError(t, store.IncrementAccessCount(ctx, "user:1", "tone"))
+	require.NoError(t, store.Delete(ctx, "user:1", "language"))
+	saved, err := store.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "user%3A1.jsonl"))
+	require.NoError(t, err, "each namespace should be persisted to its own file")
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.AccessCount)
+	assert.Equal(t, 0.8, got.Provenance.Confidence)
+	assert.Equal(t, []string{"src"}, got.Provenance.Sources)
+	assert.Equal(t, map[string]any{"v": "tone"}, got.Value)
+	assert.True(t, got.UpdatedAt.Equal(saved.UpdatedAt), "timestamps should be restored as persisted")
+
+	_, err = reopened.Get(ctx, "user:1", "language")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+
+	all, err := reopened.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	stats, err := reopened.GetStats(ctx, "user:2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalCount)
+}
+
+func TestFileStore_CompactsAfterDeletes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+	store.SetCompactThreshold(10)
+
+	for i := range 20 {
+		require.NoError(t, store.Save(ctx, newFileMemory("user:1", fmt.Sprintf("k%d", i), 0.5)))
+	}
+	path := filepath.Join(dir, "user%3A1.jsonl")
+	assert.Equal(t, 20, countLines(t, path))
+
+	for i := range 18 {
+		require.NoError(t, store.Delete(ctx, "user:1", fmt.Sprintf("k%d", i)))
+	}
+	// 失效记录超过阈值后重写为仅包含有效记录
+	assert.Less(t, countLines(t, path), 20)
+
+	require.NoError(t, store.Compact(ctx, "user:1"))
+	assert.Equal(t, 2, countLines(t, path))
+
+	remaining, err := store.List(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+}
+
+func TestFileStore_IgnoresTruncatedTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, newFileMemory("user:1", "tone", 0.8)))
+	require.NoError(t, store.Close())
+
+	// 模拟写入中途崩溃
+	path := filepath.Join(dir, "user%3A1.jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"put","memory":{"namespace":"user:1","key":"bro`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	_, err = reopened.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+
+	require.NoError(t, reopened.Save(ctx, newFileMemory("user:1", "language", 0.6)))
+	again, err := NewFileStore(dir)
+	require.NoError(t, err)
+	defer again.Close()
+	memories, err := again.List(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Len(t, memories, 2)
+}
+
+func TestFileStore_WithManager(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	manager, err := NewManager(&ManagerConfig{Store: store})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	old := newFileMemory("user:1", "stale", 0.1)
+	old.LastAccessed = time.Now().Add(-48 * time.Hour)
+	require.NoError(t, manager.RecordMemory(ctx, old))
+	require.NoError(t, manager.RecordMemory(ctx, newFileMemory("user:1", "fresh", 0.9)))
+
+	count, err := manager.PruneMemories(ctx, PruneCriteria{MinConfidence: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestFileStore_FailedWriteLeavesCacheUnchanged(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.Save(ctx, newFileMemory("user:1", "tone", 0.5)))
+
+	// 日志文件无法写入时，保存与删除都不应改变缓存
+	path := store.namespacePath("user:1")
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Mkdir(path, 0755))
+
+	updated := newFileMemory("user:1", "tone", 0.9)
+	assert.Error(t, store.SaveBatch(ctx, []*LogicMemory{updated, newFileMemory("user:1", "lang", 0.9)}))
+	assert.Error(t, store.Delete(ctx, "user:1", "tone"))
+
+	got, err := store.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, got.Provenance.Confidence, 1e-9)
+	_, err = store.Get(ctx, "user:1", "lang")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+}
@@ -34,7 +34,32 @@ func (s *InMemoryStore) Save(ctx context.Context, memory *LogicMemory) error {
 	if s.closed {
 		return ErrStoreClosed
 	}
+	return s.saveLocked(memory)
+}
+
+// SaveBatch 在一次加锁内保存多条 Memory，任一条无效时不写入任何记录
+func (s *InMemoryStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	for _, memory := range memories {
+		if memory.Namespace == "" {
+			return ErrInvalidNamespace
+		}
+	}
+	for _, memory := range memories {
+		if err := s.saveLocked(memory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// saveLocked 保存单条 Memory，调用方需持有写锁
+func (s *InMemoryStore) saveLocked(memory *LogicMemory) error {
 	if memory.Namespace == "" {
 		return ErrInvalidNamespace
 	}
@@ -61,7 +86,25 @@ func (s *InMemoryStore) Save(ctx context.Context, memory *LogicMemory) error {
 	return nil
 }
 
-// Get 获取单个 Memory
+// restore 原样写入 Memory（保留 UpdatedAt 等时间戳），用于从持久化数据恢复
+func (s *InMemoryStore) restore(memory *LogicMemory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *memory
+	s.memories[makeKey(memory.Namespace, memory.Key)] = &stored
+}
+
+// contains 判断 Key 是否存在（包括已过期未清理的 Memory）
+func (s *InMemoryStore) contains(namespace, key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.memories[makeKey(namespace, key)]
+	return exists
+}
+
+// Get 获取单个 Memory（已过期的视为不存在）
 func (s *InMemoryStore) Get(ctx context.Context, namespace, key string) (*LogicMemory, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -72,7 +115,7 @@ func (s *InMemoryStore) Get(ctx context.Context, namespace, key string) (*LogicM
 
 	storeKey := makeKey(namespace, key)
 	memory, exists := s.memories[storeKey]
-	if !exists {
+	if !exists || memory.IsExpired(time.Now()) {
 		return nil, ErrMemoryNotFound
 	}
 
@@ -105,6 +148,7 @@ func (s *InMemoryStore) List(ctx context.Context, namespace string, filters ...F
 	}
 
 	opts := ApplyFilters(filters...)
+	now := time.Now()
 
 	var result []*LogicMemory
 	for _, memory := range s.memories {
@@ -113,8 +157,13 @@ func (s *InMemoryStore) List(ctx context.Context, namespace string, filters ...F
 			continue
 		}
 
+		// 过滤已过期
+		if memory.IsExpired(now) {
+			continue
+		}
+
 		// 过滤类型
-		if opts.Type != "" && memory.Type != opts.Type {
+		if !opts.MatchesType(memory.Type) {
 			continue
 		}
 
@@ -224,46 +273,35 @@ func (s *InMemoryStore) GetStats(ctx context.Context, namespace string) (*Memory
 
 // Prune 清理低价值 Memory
 func (s *InMemoryStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	pruned, err := s.PruneWithReport(ctx, criteria)
+	return len(pruned), err
+}
+
+// PruneWithReport 清理低价值 Memory，并返回被清理的 Memory
+func (s *InMemoryStore) PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.closed {
-		return 0, ErrStoreClosed
+		return nil, ErrStoreClosed
 	}
 
 	var toDelete []string
 	now := time.Now()
 
 	for storeKey, memory := range s.memories {
-		shouldPrune := false
-
-		// 置信度过低
-		if memory.Provenance != nil && memory.Provenance.Confidence < criteria.MinConfidence {
-			shouldPrune = true
-		}
-
-		// 太久未访问
-		if criteria.SinceLastAccess > 0 && now.Sub(memory.LastAccessed) > criteria.SinceLastAccess {
-			shouldPrune = true
-		}
-
-		// 访问次数过少且年龄过大
-		if criteria.MinAccessCount > 0 && criteria.MaxAge > 0 {
-			if memory.AccessCount < criteria.MinAccessCount && now.Sub(memory.CreatedAt) > criteria.MaxAge {
-				shouldPrune = true
-			}
-		}
-
-		if shouldPrune {
+		if criteria.Matches(memory, now) {
 			toDelete = append(toDelete, storeKey)
 		}
 	}
 
+	pruned := make([]*LogicMemory, 0, len(toDelete))
 	for _, key := range toDelete {
+		pruned = append(pruned, s.memories[key])
 		delete(s.memories, key)
 	}
 
-	return len(toDelete), nil
+	return pruned, nil
 }
 
 // Close 关闭存储
@@ -307,3 +345,7 @@ func sortMemories(memories []*LogicMemory, orderBy OrderBy) {
 
 // 确保 InMemoryStore 实现 LogicMemoryStore 接口
 var _ LogicMemoryStore = (*InMemoryStore)(nil)
+
+var _ PruneReporter = (*InMemoryStore)(nil)
+
+var _ BatchSaver = (*InMemoryStore)(nil)
@@ -82,6 +82,7 @@ func (s *MySQLStore) migrate() error {
 			access_count INT DEFAULT 0,
 			last_accessed TIMESTAMP NULL,
 			metadata JSON,
+			expires_at TIMESTAMP NULL,
 			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 			UNIQUE KEY idx_namespace_key (namespace, `+"`key`"+`),
@@ -89,11 +90,25 @@ func (s *MySQLStore) migrate() error {
 			KEY idx_namespace_type (namespace, type),
 			KEY idx_scope (scope),
 			KEY idx_confidence (confidence),
-			KEY idx_last_accessed (last_accessed)
+			KEY idx_last_accessed (last_accessed),
+			KEY idx_expires_at (expires_at)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
 	`, s.tableName)
 
-	_, err := s.db.Exec(query)
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// 为旧版本创建的表补充 expires_at 列
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = 'expires_at'
+	`, s.tableName).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN expires_at TIMESTAMP NULL, ADD KEY idx_expires_at (expires_at)", s.tableName))
 	return err
 }
 
@@ -102,7 +117,33 @@ func (s *MySQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 	if s.closed {
 		return ErrStoreClosed
 	}
+	return s.save(ctx, s.db, mem)
+}
+
+// SaveBatch 在单个事务中保存多条 Memory，任一条失败时整体回滚
+func (s *MySQLStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return NewStoreError("TX_ERROR", "failed to begin transaction", err)
+	}
+	for _, mem := range memories {
+		if err := s.save(ctx, tx, mem); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return NewStoreError("TX_ERROR", "failed to commit transaction", err)
+	}
+	return nil
+}
 
+// save 使用给定的执行器（连接池或事务）保存单条 Memory
+func (s *MySQLStore) save(ctx context.Context, exec sqlExecer, mem *LogicMemory) error {
 	if mem.Namespace == "" {
 		return ErrInvalidNamespace
 	}
@@ -155,11 +196,11 @@ func (s *MySQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 		INSERT INTO %s (
 			id, namespace, scope, type, category, `+"`key`"+`, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		) VALUES (
 			?, ?, ?, ?, ?, ?, ?, ?,
 			?, ?, ?, ?, ?, ?,
-			?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?
 		)
 		ON DUPLICATE KEY UPDATE
 			scope = VALUES(scope),
@@ -176,13 +217,14 @@ func (s *MySQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 			access_count = VALUES(access_count),
 			last_accessed = VALUES(last_accessed),
 			metadata = VALUES(metadata),
+			expires_at = VALUES(expires_at),
 			updated_at = VALUES(updated_at)
 	`, s.tableName)
 
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = exec.ExecContext(ctx, query,
 		mem.ID, mem.Namespace, mem.Scope, mem.Type, mem.Category, mem.Key, valueJSON, mem.Description,
 		sourceType, confidence, sourcesJSON, provenanceCreatedAt, provenanceUpdatedAt, provenanceVersion,
-		mem.AccessCount, mem.LastAccessed, metadataJSON, mem.CreatedAt, mem.UpdatedAt,
+		mem.AccessCount, mem.LastAccessed, metadataJSON, nullTime(mem.ExpiresAt), mem.CreatedAt, mem.UpdatedAt,
 	)
 
 	if err != nil {
@@ -201,12 +243,12 @@ func (s *MySQLStore) Get(ctx context.Context, namespace, key string) (*LogicMemo
 	query := fmt.Sprintf(`
 		SELECT id, namespace, scope, type, category, `+"`key`"+`, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		FROM %s
-		WHERE namespace = ? AND `+"`key`"+` = ?
+		WHERE namespace = ? AND `+"`key`"+` = ? AND (expires_at IS NULL OR expires_at > ?)
 	`, s.tableName)
 
-	row := s.db.QueryRowContext(ctx, query, namespace, key)
+	row := s.db.QueryRowContext(ctx, query, namespace, key, time.Now())
 	return s.scanMemory(row)
 }
 
@@ -233,21 +275,23 @@ func (s *MySQLStore) List(ctx context.Context, namespace string, filters ...Filt
 	query := fmt.Sprintf(`
 		SELECT id, namespace, scope, type, category, `+"`key`"+`, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		FROM %s
-		WHERE 1=1
+		WHERE (expires_at IS NULL OR expires_at > ?)
 	`, s.tableName)
 
-	args := []any{}
+	args := []any{time.Now()}
 
 	if namespace != "" {
 		query += " AND namespace = ?"
 		args = append(args, namespace)
 	}
 
-	if opts.Type != "" {
-		query += " AND type = ?"
-		args = append(args, opts.Type)
+	if types := opts.TypeSet(); len(types) > 0 {
+		query += " AND type IN (?" + strings.Repeat(", ?", len(types)-1) + ")"
+		for _, t := range types {
+			args = append(args, t)
+		}
 	}
 
 	if opts.Scope != "" {
@@ -414,13 +458,20 @@ func (s *MySQLStore) GetStats(ctx context.Context, namespace string) (*MemorySta
 
 // Prune 清理低价值 Memory
 func (s *MySQLStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	pruned, err := s.PruneWithReport(ctx, criteria)
+	return len(pruned), err
+}
+
+// PruneWithReport 清理低价值 Memory，并返回被清理的 Memory（包括已过期的）
+// MySQL 不支持 DELETE ... RETURNING，在事务中锁定并读取满足条件的行后按 ID 删除
+func (s *MySQLStore) PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error) {
 	if s.closed {
-		return 0, ErrStoreClosed
+		return nil, ErrStoreClosed
 	}
 
-	// 构建条件
-	conditions := []string{}
-	args := []any{}
+	// 构建条件，已过期的 Memory 总是被清理
+	conditions := []string{"(expires_at IS NOT NULL AND expires_at <= ?)"}
+	args := []any{time.Now()}
 
 	if criteria.MinConfidence > 0 {
 		conditions = append(conditions, "confidence < ?")
@@ -437,24 +488,55 @@ func (s *MySQLStore) Prune(ctx context.Context, criteria PruneCriteria) (int, er
 		args = append(args, criteria.MinAccessCount, time.Now().Add(-criteria.MaxAge))
 	}
 
-	if len(conditions) == 0 {
-		return 0, nil
-	}
+	query := fmt.Sprintf(`
+		SELECT id, namespace, scope, type, category, `+"`key`"+`, value, description,
+			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
+		FROM %s
+		WHERE %s
+		FOR UPDATE
+	`, s.tableName, strings.Join(conditions, " OR "))
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s", s.tableName, conditions[0])
-	var querySb443 strings.Builder
-	for i := 1; i < len(conditions); i++ {
-		querySb443.WriteString(" OR " + conditions[i])
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewStoreError("TX_ERROR", "failed to begin transaction", err)
 	}
-	query += querySb443.String()
+	defer func() { _ = tx.Rollback() }()
 
-	result, err := s.db.ExecContext(ctx, query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return 0, NewStoreError("DELETE_ERROR", "failed to prune memories", err)
+		return nil, NewStoreError("QUERY_ERROR", "failed to list prunable memories", err)
+	}
+	var pruned []*LogicMemory
+	for rows.Next() {
+		mem, err := s.scanMemoryFromRows(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		pruned = append(pruned, mem)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, NewStoreError("QUERY_ERROR", "failed to list prunable memories", err)
+	}
+	if len(pruned) == 0 {
+		return nil, nil
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	return int(rowsAffected), nil
+	ids := make([]any, len(pruned))
+	for i, mem := range pruned {
+		ids[i] = mem.ID
+	}
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE id IN (?%s)", s.tableName, strings.Repeat(", ?", len(ids)-1))
+	if _, err := tx.ExecContext(ctx, deleteQuery, ids...); err != nil {
+		return nil, NewStoreError("DELETE_ERROR", "failed to prune memories", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, NewStoreError("TX_ERROR", "failed to commit transaction", err)
+	}
+
+	return pruned, nil
 }
 
 // Close 关闭存储
@@ -473,12 +555,12 @@ func (s *MySQLStore) scanMemory(row *sql.Row) (*LogicMemory, error) {
 	var provenanceVersion int
 	var category sql.NullString
 	var description sql.NullString
-	var lastAccessed sql.NullTime
+	var lastAccessed, expiresAt sql.NullTime
 
 	err := row.Scan(
 		&mem.ID, &mem.Namespace, &mem.Scope, &mem.Type, &category, &mem.Key, &valueJSON, &description,
 		&sourceType, &confidence, &sourcesJSON, &provenanceCreatedAt, &provenanceUpdatedAt, &provenanceVersion,
-		&mem.AccessCount, &lastAccessed, &metadataJSON, &mem.CreatedAt, &mem.UpdatedAt,
+		&mem.AccessCount, &lastAccessed, &metadataJSON, &expiresAt, &mem.CreatedAt, &mem.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -510,6 +592,9 @@ func (s *MySQLStore) scanMemory(row *sql.Row) (*LogicMemory, error) {
 	if lastAccessed.Valid {
 		mem.LastAccessed = lastAccessed.Time
 	}
+	if expiresAt.Valid {
+		mem.ExpiresAt = &expiresAt.Time
+	}
 
 	// 构建 Provenance
 	if sourceType.Valid {
@@ -545,12 +630,12 @@ func (s *MySQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, error) {
 	var provenanceVersion int
 	var category sql.NullString
 	var description sql.NullString
-	var lastAccessed sql.NullTime
+	var lastAccessed, expiresAt sql.NullTime
 
 	err := rows.Scan(
 		&mem.ID, &mem.Namespace, &mem.Scope, &mem.Type, &category, &mem.Key, &valueJSON, &description,
 		&sourceType, &confidence, &sourcesJSON, &provenanceCreatedAt, &provenanceUpdatedAt, &provenanceVersion,
-		&mem.AccessCount, &lastAccessed, &metadataJSON, &mem.CreatedAt, &mem.UpdatedAt,
+		&mem.AccessCount, &lastAccessed, &metadataJSON, &expiresAt, &mem.CreatedAt, &mem.UpdatedAt,
 	)
 
 	if err != nil {
@@ -579,6 +664,9 @@ func (s *MySQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, error) {
 	if lastAccessed.Valid {
 		mem.LastAccessed = lastAccessed.Time
 	}
+	if expiresAt.Valid {
+		mem.ExpiresAt = &expiresAt.Time
+	}
 
 	// 构建 Provenance
 	if sourceType.Valid {
@@ -606,3 +694,7 @@ func (s *MySQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, error) {
 
 // 确保 MySQLStore 实现 LogicMemoryStore 接口
 var _ LogicMemoryStore = (*MySQLStore)(nil)
+
+var _ BatchSaver = (*MySQLStore)(nil)
+
+var _ PruneReporter = (*MySQLStore)(nil)
@@ -82,6 +82,7 @@ func (s *PostgreSQLStore) migrate() error {
 			access_count INT DEFAULT 0,
 			last_accessed TIMESTAMP,
 			metadata JSONB DEFAULT '{}',
+			expires_at TIMESTAMP,
 			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
 			UNIQUE(namespace, key)
@@ -92,11 +93,16 @@ func (s *PostgreSQLStore) migrate() error {
 		CREATE INDEX IF NOT EXISTS idx_%s_scope ON %s(scope);
 		CREATE INDEX IF NOT EXISTS idx_%s_confidence ON %s(confidence);
 		CREATE INDEX IF NOT EXISTS idx_%s_last_accessed ON %s(last_accessed);
+
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+		CREATE INDEX IF NOT EXISTS idx_%s_expires_at ON %s(expires_at);
 	`, s.tableName,
 		s.tableName, s.tableName,
 		s.tableName, s.tableName,
 		s.tableName, s.tableName,
 		s.tableName, s.tableName,
+		s.tableName, s.tableName,
+		s.tableName,
 		s.tableName, s.tableName)
 
 	_, err := s.db.Exec(query)
@@ -108,7 +114,33 @@ func (s *PostgreSQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 	if s.closed {
 		return ErrStoreClosed
 	}
+	return s.save(ctx, s.db, mem)
+}
+
+// SaveBatch 在单个事务中保存多条 Memory，任一条失败时整体回滚
+func (s *PostgreSQLStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return NewStoreError("TX_ERROR", "failed to begin transaction", err)
+	}
+	for _, mem := range memories {
+		if err := s.save(ctx, tx, mem); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return NewStoreError("TX_ERROR", "failed to commit transaction", err)
+	}
+	return nil
+}
 
+// save 使用给定的执行器（连接池或事务）保存单条 Memory
+func (s *PostgreSQLStore) save(ctx context.Context, exec sqlExecer, mem *LogicMemory) error {
 	if mem.Namespace == "" {
 		return ErrInvalidNamespace
 	}
@@ -161,11 +193,11 @@ func (s *PostgreSQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 		INSERT INTO %s (
 			id, namespace, scope, type, category, key, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8,
 			$9, $10, $11, $12, $13, $14,
-			$15, $16, $17, $18, $19
+			$15, $16, $17, $18, $19, $20
 		)
 		ON CONFLICT (namespace, key) DO UPDATE SET
 			scope = EXCLUDED.scope,
@@ -182,13 +214,14 @@ func (s *PostgreSQLStore) Save(ctx context.Context, mem *LogicMemory) error {
 			access_count = EXCLUDED.access_count,
 			last_accessed = EXCLUDED.last_accessed,
 			metadata = EXCLUDED.metadata,
+			expires_at = EXCLUDED.expires_at,
 			updated_at = EXCLUDED.updated_at
 	`, s.tableName)
 
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = exec.ExecContext(ctx, query,
 		mem.ID, mem.Namespace, mem.Scope, mem.Type, mem.Category, mem.Key, valueJSON, mem.Description,
 		sourceType, confidence, sourcesJSON, provenanceCreatedAt, provenanceUpdatedAt, provenanceVersion,
-		mem.AccessCount, mem.LastAccessed, metadataJSON, mem.CreatedAt, mem.UpdatedAt,
+		mem.AccessCount, mem.LastAccessed, metadataJSON, nullTime(mem.ExpiresAt), mem.CreatedAt, mem.UpdatedAt,
 	)
 
 	if err != nil {
@@ -207,12 +240,12 @@ func (s *PostgreSQLStore) Get(ctx context.Context, namespace, key string) (*Logi
 	query := fmt.Sprintf(`
 		SELECT id, namespace, scope, type, category, key, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		FROM %s
-		WHERE namespace = $1 AND key = $2
+		WHERE namespace = $1 AND key = $2 AND (expires_at IS NULL OR expires_at > $3)
 	`, s.tableName)
 
-	row := s.db.QueryRowContext(ctx, query, namespace, key)
+	row := s.db.QueryRowContext(ctx, query, namespace, key, time.Now())
 	return s.scanMemory(row)
 }
 
@@ -239,13 +272,13 @@ func (s *PostgreSQLStore) List(ctx context.Context, namespace string, filters ..
 	query := fmt.Sprintf(`
 		SELECT id, namespace, scope, type, category, key, value, description,
 			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
-			access_count, last_accessed, metadata, created_at, updated_at
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
 		FROM %s
-		WHERE 1=1
+		WHERE (expires_at IS NULL OR expires_at > $1)
 	`, s.tableName)
 
-	args := []any{}
-	argIndex := 1
+	args := []any{time.Now()}
+	argIndex := 2
 
 	if namespace != "" {
 		query += fmt.Sprintf(" AND namespace = $%d", argIndex)
@@ -253,10 +286,14 @@ func (s *PostgreSQLStore) List(ctx context.Context, namespace string, filters ..
 		argIndex++
 	}
 
-	if opts.Type != "" {
-		query += fmt.Sprintf(" AND type = $%d", argIndex)
-		args = append(args, opts.Type)
-		argIndex++
+	if types := opts.TypeSet(); len(types) > 0 {
+		placeholders := make([]string, len(types))
+		for i, t := range types {
+			placeholders[i] = fmt.Sprintf("$%d", argIndex)
+			args = append(args, t)
+			argIndex++
+		}
+		query += " AND type IN (" + strings.Join(placeholders, ", ") + ")"
 	}
 
 	if opts.Scope != "" {
@@ -424,15 +461,20 @@ func (s *PostgreSQLStore) GetStats(ctx context.Context, namespace string) (*Memo
 
 // Prune 清理低价值 Memory
 func (s *PostgreSQLStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	pruned, err := s.PruneWithReport(ctx, criteria)
+	return len(pruned), err
+}
+
+// PruneWithReport 清理低价值 Memory，并返回被清理的 Memory（包括已过期的）
+func (s *PostgreSQLStore) PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error) {
 	if s.closed {
-		return 0, ErrStoreClosed
+		return nil, ErrStoreClosed
 	}
 
-	args := []any{}
-	argIndex := 1
-
-	// 构建 OR 条件
-	conditions := []string{}
+	// 构建 OR 条件，已过期的 Memory 总是被清理
+	conditions := []string{"(expires_at IS NOT NULL AND expires_at <= $1)"}
+	args := []any{time.Now()}
+	argIndex := 2
 
 	if criteria.MinConfidence > 0 {
 		conditions = append(conditions, fmt.Sprintf("confidence < $%d", argIndex))
@@ -452,29 +494,31 @@ func (s *PostgreSQLStore) Prune(ctx context.Context, criteria PruneCriteria) (in
 		// argIndex += 2 不需要，后续没有使用
 	}
 
-	if len(conditions) == 0 {
-		return 0, nil
-	}
-
-	// 构建查询
+	// 构建查询，RETURNING 返回被删除的行
 	query := fmt.Sprintf(`
 		DELETE FROM %s
 		WHERE %s
-	`, s.tableName, conditions[0])
+		RETURNING id, namespace, scope, type, category, key, value, description,
+			source_type, confidence, sources, provenance_created_at, provenance_updated_at, provenance_version,
+			access_count, last_accessed, metadata, expires_at, created_at, updated_at
+	`, s.tableName, strings.Join(conditions, " OR "))
 
-	var querySb463 strings.Builder
-	for i := 1; i < len(conditions); i++ {
-		querySb463.WriteString(" OR " + conditions[i])
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, NewStoreError("DELETE_ERROR", "failed to prune memories", err)
 	}
-	query += querySb463.String()
+	defer func() { _ = rows.Close() }()
 
-	result, err := s.db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return 0, NewStoreError("DELETE_ERROR", "failed to prune memories", err)
+	var pruned []*LogicMemory
+	for rows.Next() {
+		mem, err := s.scanMemoryFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, mem)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	return int(rowsAffected), nil
+	return pruned, rows.Err()
 }
 
 // Close 关闭存储
@@ -494,12 +538,12 @@ func (s *PostgreSQLStore) scanMemory(row *sql.Row) (*LogicMemory, error) {
 	var provenanceVersion int
 	var category sql.NullString
 	var description sql.NullString
-	var lastAccessed sql.NullTime
+	var lastAccessed, expiresAt sql.NullTime
 
 	err := row.Scan(
 		&mem.ID, &mem.Namespace, &mem.Scope, &mem.Type, &category, &mem.Key, &valueJSON, &description,
 		&sourceType, &confidence, &sourcesJSON, &provenanceCreatedAt, &provenanceUpdatedAt, &provenanceVersion,
-		&mem.AccessCount, &lastAccessed, &metadataJSON, &mem.CreatedAt, &mem.UpdatedAt,
+		&mem.AccessCount, &lastAccessed, &metadataJSON, &expiresAt, &mem.CreatedAt, &mem.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -531,6 +575,9 @@ func (s *PostgreSQLStore) scanMemory(row *sql.Row) (*LogicMemory, error) {
 	if lastAccessed.Valid {
 		mem.LastAccessed = lastAccessed.Time
 	}
+	if expiresAt.Valid {
+		mem.ExpiresAt = &expiresAt.Time
+	}
 
 	// 构建 Provenance
 	if sourceType.Valid {
@@ -566,12 +613,12 @@ func (s *PostgreSQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, erro
 	var provenanceVersion int
 	var category sql.NullString
 	var description sql.NullString
-	var lastAccessed sql.NullTime
+	var lastAccessed, expiresAt sql.NullTime
 
 	err := rows.Scan(
 		&mem.ID, &mem.Namespace, &mem.Scope, &mem.Type, &category, &mem.Key, &valueJSON, &description,
 		&sourceType, &confidence, &sourcesJSON, &provenanceCreatedAt, &provenanceUpdatedAt, &provenanceVersion,
-		&mem.AccessCount, &lastAccessed, &metadataJSON, &mem.CreatedAt, &mem.UpdatedAt,
+		&mem.AccessCount, &lastAccessed, &metadataJSON, &expiresAt, &mem.CreatedAt, &mem.UpdatedAt,
 	)
 
 	if err != nil {
@@ -600,6 +647,9 @@ func (s *PostgreSQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, erro
 	if lastAccessed.Valid {
 		mem.LastAccessed = lastAccessed.Time
 	}
+	if expiresAt.Valid {
+		mem.ExpiresAt = &expiresAt.Time
+	}
 
 	// 构建 Provenance
 	if sourceType.Valid {
@@ -627,3 +677,7 @@ func (s *PostgreSQLStore) scanMemoryFromRows(rows *sql.Rows) (*LogicMemory, erro
 
 // 确保 PostgreSQLStore 实现 LogicMemoryStore 接口
 var _ LogicMemoryStore = (*PostgreSQLStore)(nil)
+
+var _ BatchSaver = (*PostgreSQLStore)(nil)
+
+var _ PruneReporter = (*PostgreSQLStore)(nil)
@@ -0,0 +1,494 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix Redis 键默认前缀
+const DefaultRedisKeyPrefix = "aster:logic:"
+
+// Redis 哈希字段
+// Memory 本体以 JSON 存在 data 字段，访问统计单独存放以便原子更新
+const (
+	redisFieldData         = "data"
+	redisFieldAccessCount  = "access_count"
+	redisFieldLastAccessed = "last_accessed"
+)
+
+// incrementAccessScript 原子地增加访问计数并更新最后访问时间，Memory 不存在时返回 0
+var incrementAccessScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], 'access_count', 1)
+redis.call('HSET', KEYS[1], 'last_accessed', ARGV[1])
+return 1
+`)
+
+// RedisStore Redis 存储实现，支持多实例共享
+// 键布局（prefix 默认 "aster:logic:"）：
+//   - {prefix}{len(namespace)}:{namespace}:{key}  Hash，data 为 LogicMemory JSON，另含 access_count/last_accessed
+//     （namespace 带长度前缀，含 ":" 的 namespace 与 key 组合不会冲突）
+//   - {prefix}_idx:{namespace}   ZSet，成员为 key，分数为置信度，用于服务端置信度过滤和 TopK
+//   - {prefix}_namespaces        Set，所有出现过的 namespace
+//
+// 以 "_" 开头的 namespace 保留给索引使用。
+type RedisStore struct {
+	client     redis.UniversalClient
+	prefix     string
+	ownsClient bool
+	closed     atomic.Bool
+}
+
+// RedisStoreConfig Redis 存储配置
+type RedisStoreConfig struct {
+	// Client 复用已有的 Redis 客户端（可选），设置后忽略连接参数，Close 时不会关闭该客户端
+	Client redis.UniversalClient
+
+	// Addr Redis 地址，格式 "host:port"
+	Addr string
+
+	// Password 密码
+	Password string
+
+	// DB 数据库编号
+	DB int
+
+	// KeyPrefix 键前缀（默认 "aster:logic:"）
+	KeyPrefix string
+
+	// PoolSize 连接池大小（默认使用 go-redis 默认值）
+	PoolSize int
+
+	// MinIdleConns 最小空闲连接数
+	MinIdleConns int
+}
+
+// NewRedisStore 创建 Redis 存储
+func NewRedisStore(config *RedisStoreConfig) (*RedisStore, error) {
+	if config == nil {
+		return nil, errors.New("config is required")
+	}
+
+	client := config.Client
+	ownsClient := false
+	if client == nil {
+		if config.Addr == "" {
+			return nil, errors.New("redis addr is required")
+		}
+		client = redis.NewClient(&redis.Options{
+			Addr:         config.Addr,
+			Password:     config.Password,
+			DB:           config.DB,
+			PoolSize:     config.PoolSize,
+			MinIdleConns: config.MinIdleConns,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+		})
+		ownsClient = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		if ownsClient {
+			_ = client.Close()
+		}
+		return nil, fmt.Errorf("redis ping failed: %w", err)
+	}
+
+	prefix := config.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+
+	return &RedisStore{
+		client:     client,
+		prefix:     prefix,
+		ownsClient: ownsClient,
+	}, nil
+}
+
+// memoryKey Memory 键
+func (s *RedisStore) memoryKey(namespace, key string) string {
+	return s.prefix + strconv.Itoa(len(namespace)) + ":" + namespace + ":" + key
+}
+
+// indexKey 置信度索引键
+func (s *RedisStore) indexKey(namespace string) string {
+	return s.prefix + "_idx:" + namespace
+}
+
+// namespacesKey namespace 集合键
+func (s *RedisStore) namespacesKey() string {
+	return s.prefix + "_namespaces"
+}
+
+// Save 保存或更新 Memory
+func (s *RedisStore) Save(ctx context.Context, mem *LogicMemory) error {
+	return s.SaveBatch(ctx, []*LogicMemory{mem})
+}
+
+// SaveBatch 在一个 MULTI/EXEC 事务中保存多条 Memory
+func (s *RedisStore) SaveBatch(ctx context.Context, memories []*LogicMemory) error {
+	if s.closed.Load() {
+		return ErrStoreClosed
+	}
+
+	pipe := s.client.TxPipeline()
+	now := time.Now()
+	for _, mem := range memories {
+		if mem.Namespace == "" || strings.HasPrefix(mem.Namespace, "_") {
+			return ErrInvalidNamespace
+		}
+
+		if mem.CreatedAt.IsZero() {
+			mem.CreatedAt = now
+		}
+		mem.UpdatedAt = now
+		if mem.LastAccessed.IsZero() {
+			mem.LastAccessed = now
+		}
+
+		data, err := json.Marshal(mem)
+		if err != nil {
+			return NewStoreError("MARSHAL_ERROR", "failed to marshal memory", err)
+		}
+
+		memKey := s.memoryKey(mem.Namespace, mem.Key)
+		pipe.HSet(ctx, memKey,
+			redisFieldData, data,
+			redisFieldAccessCount, mem.AccessCount,
+			redisFieldLastAccessed, mem.LastAccessed.Format(time.RFC3339Nano),
+		)
+		// 过期由 Redis 自动删除，覆盖保存时清除旧的过期时间
+		if mem.ExpiresAt != nil {
+			pipe.ExpireAt(ctx, memKey, *mem.ExpiresAt)
+		} else {
+			pipe.Persist(ctx, memKey)
+		}
+		pipe.ZAdd(ctx, s.indexKey(mem.Namespace), redis.Z{Score: confidenceOf(mem), Member: mem.Key})
+		pipe.SAdd(ctx, s.namespacesKey(), mem.Namespace)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return NewStoreError("SAVE_ERROR", "failed to save memory", err)
+	}
+	return nil
+}
+
+// Get 获取单个 Memory
+func (s *RedisStore) Get(ctx context.Context, namespace, key string) (*LogicMemory, error) {
+	if s.closed.Load() {
+		return nil, ErrStoreClosed
+	}
+
+	values, err := s.client.HMGet(ctx, s.memoryKey(namespace, key),
+		redisFieldData, redisFieldAccessCount, redisFieldLastAccessed).Result()
+	if err != nil {
+		return nil, NewStoreError("QUERY_ERROR", "failed to get memory", err)
+	}
+	mem, err := decodeRedisMemory(values)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil || mem.IsExpired(time.Now()) {
+		return nil, ErrMemoryNotFound
+	}
+	return mem, nil
+}
+
+// Delete 删除 Memory
+func (s *RedisStore) Delete(ctx context.Context, namespace, key string) error {
+	if s.closed.Load() {
+		return ErrStoreClosed
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, s.memoryKey(namespace, key))
+	pipe.ZRem(ctx, s.indexKey(namespace), key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return NewStoreError("DELETE_ERROR", "failed to delete memory", err)
+	}
+	return nil
+}
+
+// List 列出符合条件的 Memory
+// 置信度过滤和按置信度排序的 TopK 在服务端通过索引完成（跳过已过期的 Memory 后补足 TopK），
+// 类型、作用域过滤和其他排序在进程内完成。
+func (s *RedisStore) List(ctx context.Context, namespace string, filters ...Filter) ([]*LogicMemory, error) {
+	if s.closed.Load() {
+		return nil, ErrStoreClosed
+	}
+
+	opts := ApplyFilters(filters...)
+
+	namespaces := []string{namespace}
+	if namespace == "" {
+		all, err := s.client.SMembers(ctx, s.namespacesKey()).Result()
+		if err != nil {
+			return nil, NewStoreError("QUERY_ERROR", "failed to list namespaces", err)
+		}
+		namespaces = all
+	}
+
+	// 只有索引能完全决定结果时才在服务端截断 TopK
+	serverTopK := namespace != "" && opts.MaxResults > 0 &&
+		opts.OrderBy == OrderByConfidence && len(opts.TypeSet()) == 0 && opts.Scope == ""
+
+	limit := 0
+	if serverTopK {
+		limit = opts.MaxResults
+	}
+
+	var result []*LogicMemory
+	for _, ns := range namespaces {
+		memories, err := s.loadNamespace(ctx, ns, opts.MinConfidence, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, mem := range memories {
+			if !opts.MatchesType(mem.Type) {
+				continue
+			}
+			if opts.Scope != "" && mem.Scope != opts.Scope {
+				continue
+			}
+			result = append(result, mem)
+		}
+	}
+
+	sortMemories(result, opts.OrderBy)
+	if opts.MaxResults > 0 && len(result) > opts.MaxResults {
+		result = result[:opts.MaxResults]
+	}
+	return result, nil
+}
+
+// SearchByType 按类型搜索
+func (s *RedisStore) SearchByType(ctx context.Context, namespace, memoryType string) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithType(memoryType))
+}
+
+// SearchByScope 按作用域搜索
+func (s *RedisStore) SearchByScope(ctx context.Context, namespace string, scope MemoryScope) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithScope(scope))
+}
+
+// GetTopK 获取 TopK Memory
+func (s *RedisStore) GetTopK(ctx context.Context, namespace string, k int, orderBy OrderBy) ([]*LogicMemory, error) {
+	return s.List(ctx, namespace, WithTopK(k), WithOrderBy(orderBy))
+}
+
+// IncrementAccessCount 原子地增加访问计数并更新最后访问时间
+func (s *RedisStore) IncrementAccessCount(ctx context.Context, namespace, key string) error {
+	if s.closed.Load() {
+		return ErrStoreClosed
+	}
+
+	found, err := incrementAccessScript.Run(ctx, s.client,
+		[]string{s.memoryKey(namespace, key)}, time.Now().Format(time.RFC3339Nano)).Int()
+	if err != nil {
+		return NewStoreError("UPDATE_ERROR", "failed to increment access count", err)
+	}
+	if found == 0 {
+		return ErrMemoryNotFound
+	}
+	return nil
+}
+
+// GetStats 获取统计信息
+func (s *RedisStore) GetStats(ctx context.Context, namespace string) (*MemoryStats, error) {
+	memories, err := s.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &MemoryStats{
+		CountByType:  make(map[string]int),
+		CountByScope: make(map[MemoryScope]int),
+	}
+	var totalConfidence float64
+	for _, mem := range memories {
+		stats.TotalCount++
+		stats.CountByType[mem.Type]++
+		stats.CountByScope[mem.Scope]++
+		totalConfidence += confidenceOf(mem)
+		if mem.UpdatedAt.After(stats.LastUpdated) {
+			stats.LastUpdated = mem.UpdatedAt
+		}
+	}
+	if stats.TotalCount > 0 {
+		stats.AverageConfidence = totalConfidence / float64(stats.TotalCount)
+	}
+	return stats, nil
+}
+
+// Prune 清理低价值 Memory
+func (s *RedisStore) Prune(ctx context.Context, criteria PruneCriteria) (int, error) {
+	pruned, err := s.PruneWithReport(ctx, criteria)
+	return len(pruned), err
+}
+
+// PruneWithReport 清理低价值 Memory，并返回被清理的 Memory
+func (s *RedisStore) PruneWithReport(ctx context.Context, criteria PruneCriteria) ([]*LogicMemory, error) {
+	memories, err := s.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var pruned []*LogicMemory
+	for _, mem := range memories {
+		if !criteria.Matches(mem, now) {
+			continue
+		}
+		if err := s.Delete(ctx, mem.Namespace, mem.Key); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, mem)
+	}
+	return pruned, nil
+}
+
+// Close 关闭存储，仅关闭由存储自行创建的客户端
+func (s *RedisStore) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if s.ownsClient {
+		return s.client.Close()
+	}
+	return nil
+}
+
+// loadNamespace 通过置信度索引加载 namespace 下未过期的 Memory
+// limit > 0 时按置信度降序取前 limit 条，索引中已过期的 key 被跳过并继续读取后续的 key
+func (s *RedisStore) loadNamespace(ctx context.Context, namespace string, minConfidence float64, limit int) ([]*LogicMemory, error) {
+	var memories []*LogicMemory
+	var stale []any
+	offset := 0
+	for {
+		count := 0
+		if limit > 0 {
+			count = limit - len(memories)
+		}
+		keys, err := s.indexedKeys(ctx, namespace, minConfidence, offset, count)
+		if err != nil {
+			return nil, err
+		}
+		loaded, expired, err := s.loadMemories(ctx, namespace, keys)
+		if err != nil {
+			return nil, err
+		}
+		memories = append(memories, loaded...)
+		stale = append(stale, expired...)
+		offset += len(keys)
+
+		if count == 0 || len(keys) < count || len(memories) >= limit {
+			break
+		}
+	}
+
+	if len(stale) > 0 {
+		// 索引清理失败不影响读取，下次加载时重试
+		_ = s.client.ZRem(ctx, s.indexKey(namespace), stale...).Err()
+	}
+	return memories, nil
+}
+
+// indexedKeys 通过置信度索引获取 namespace 下的 key
+// count > 0 时按置信度降序从 offset 开始取 count 个，否则返回全部
+func (s *RedisStore) indexedKeys(ctx context.Context, namespace string, minConfidence float64, offset, count int) ([]string, error) {
+	rangeBy := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if minConfidence > 0 {
+		rangeBy.Min = strconv.FormatFloat(minConfidence, 'f', -1, 64)
+	}
+
+	var keys []string
+	var err error
+	if count > 0 {
+		rangeBy.Offset = int64(offset)
+		rangeBy.Count = int64(count)
+		keys, err = s.client.ZRevRangeByScore(ctx, s.indexKey(namespace), rangeBy).Result()
+	} else {
+		keys, err = s.client.ZRangeByScore(ctx, s.indexKey(namespace), rangeBy).Result()
+	}
+	if err != nil {
+		return nil, NewStoreError("QUERY_ERROR", "failed to query confidence index", err)
+	}
+	return keys, nil
+}
+
+// loadMemories 批量加载 Memory，返回未过期的 Memory 以及已过期或已不存在、需要从索引中移除的 key
+func (s *RedisStore) loadMemories(ctx context.Context, namespace string, keys []string) ([]*LogicMemory, []any, error) {
+	if len(keys) == 0 {
+		return nil, nil, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HMGet(ctx, s.memoryKey(namespace, key),
+			redisFieldData, redisFieldAccessCount, redisFieldLastAccessed)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, NewStoreError("QUERY_ERROR", "failed to load memories", err)
+	}
+
+	now := time.Now()
+	memories := make([]*LogicMemory, 0, len(keys))
+	var stale []any
+	for i, cmd := range cmds {
+		mem, err := decodeRedisMemory(cmd.Val())
+		if err != nil {
+			return nil, nil, err
+		}
+		if mem == nil || mem.IsExpired(now) {
+			stale = append(stale, keys[i])
+			continue
+		}
+		memories = append(memories, mem)
+	}
+	return memories, stale, nil
+}
+
+// decodeRedisMemory 解析 HMGET 结果（data, access_count, last_accessed），不存在时返回 nil
+func decodeRedisMemory(values []any) (*LogicMemory, error) {
+	if len(values) != 3 || values[0] == nil {
+		return nil, nil
+	}
+
+	data, _ := values[0].(string)
+	var mem LogicMemory
+	if err := json.Unmarshal([]byte(data), &mem); err != nil {
+		return nil, NewStoreError("UNMARSHAL_ERROR", "failed to unmarshal memory", err)
+	}
+	if s, ok := values[1].(string); ok {
+		if count, err := strconv.Atoi(s); err == nil {
+			mem.AccessCount = count
+		}
+	}
+	if s, ok := values[2].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			mem.LastAccessed = t
+		}
+	}
+	return &mem, nil
+}
+
+// 确保 RedisStore 实现 LogicMemoryStore 接口
+var _ LogicMemoryStore = (*RedisStore)(nil)
+
+var _ PruneReporter = (*RedisStore)(nil)
+
+var _ BatchSaver = (*RedisStore)(nil)
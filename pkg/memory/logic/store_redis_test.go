@@ -0,0 +1,232 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/memory"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// setupRedisStore 启动 Redis 容器并创建存储，Docker 不可用时跳过
+func setupRedisStore(t *testing.T) *RedisStore {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("Skipping Redis integration test in short mode")
+	}
+	if os.Getenv("SKIP_INTEGRATION_TESTS") != "" {
+		t.Skip("Skipping Redis integration test (SKIP_INTEGRATION_TESTS is set)")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker not available, skipping Redis integration test: %v", r)
+		}
+	}()
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Skipf("Failed to start Redis container (Docker may not be available): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Skipf("Failed to get container host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		t.Skipf("Failed to get container port: %v", err)
+	}
+
+	store, err := NewRedisStore(&RedisStoreConfig{
+		Addr:     fmt.Sprintf("%s:%s", host, port.Port()),
+		PoolSize: 5,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func newTestMemory(namespace, key, memType string, confidence float64) *LogicMemory {
+	return &LogicMemory{
+		ID:        key,
+		Namespace: namespace,
+		Scope:     ScopeUser,
+		Type:      memType,
+		Key:       key,
+		Value:     map[string]any{"items": []any{}},
+		Provenance: &memory.MemoryProvenance{
+			SourceType: memory.SourceUserInput,
+			Confidence: confidence,
+		},
+	}
+}
+
+func TestRedisStore_CRUDAndKeyLayout(t *testing.T) {
+	store := setupRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, newTestMemory("user:1", "tone", "preference", 0.8)))
+
+	exists, err := store.client.Exists(ctx, "aster:logic:6:user:1:tone").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exists, "memory should be stored under aster:logic:{len(namespace)}:{namespace}:{key}")
+
+	got, err := store.Get(ctx, "user:1", "tone")
+	require.NoError(t, err)
+	assert.Equal(t, "preference", got.Type)
+	assert.Equal(t, map[string]any{"items": []any{}}, got.Value)
+	assert.NotZero(t, got.CreatedAt)
+
+	require.NoError(t, store.Delete(ctx, "user:1", "tone"))
+	_, err = store.Get(ctx, "user:1", "tone")
+	assert.ErrorIs(t, err, ErrMemoryNotFound)
+
+	assert.ErrorIs(t, store.Save(ctx, newTestMemory("_idx", "x", "preference", 1)), ErrInvalidNamespace)
+}
+
+func TestRedisStore_ListFilters(t *testing.T) {
+	store := setupRedisStore(t)
+	ctx := context.Background()
+
+	for i, conf := range []float64{0.2, 0.5, 0.7, 0.9} {
+		memType := "preference"
+		if i%2 == 1 {
+			memType = "pattern"
+		}
+		require.NoError(t, store.Save(ctx, newTestMemory("user:2", fmt.Sprintf("k%d", i), memType, conf)))
+	}
+	require.NoError(t, store.Save(ctx, newTestMemory("user:3", "other", "preference", 0.99)))
+
+	top, err := store.List(ctx, "user:2", WithTopK(2), WithOrderBy(OrderByConfidence))
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "k3", top[0].Key)
+	assert.Equal(t, "k2", top[1].Key)
+
+	confident, err := store.List(ctx, "user:2", WithMinConfidence(0.6))
+	require.NoError(t, err)
+	assert.Len(t, confident, 2)
+
+	patterns, err := store.SearchByType(ctx, "user:2", "pattern")
+	require.NoError(t, err)
+	assert.Len(t, patterns, 2)
+
+	all, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+}
+
+func TestRedisStore_IncrementAccessCountAtomic(t *testing.T) {
+	store := setupRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, newTestMemory("user:4", "tone", "preference", 0.8)))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.IncrementAccessCount(ctx, "user:4", "tone"))
+		}()
+	}
+	wg.Wait()
+
+	got, err := store.Get(ctx, "user:4", "tone")
+	require.NoError(t, err)
+	assert.Equal(t, 20, got.AccessCount)
+	assert.WithinDuration(t, time.Now(), got.LastAccessed, 5*time.Second)
+	assert.ErrorIs(t, store.IncrementAccessCount(ctx, "user:4", "missing"), ErrMemoryNotFound)
+}
+
+func TestRedisStore_SharedClientAndPrefix(t *testing.T) {
+	base := setupRedisStore(t)
+	ctx := context.Background()
+
+	store, err := NewRedisStore(&RedisStoreConfig{Client: base.client, KeyPrefix: "tenant-a:"})
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, newTestMemory("user:5", "tone", "preference", 0.5)))
+
+	exists, err := base.client.Exists(ctx, "tenant-a:6:user:5:tone").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exists)
+
+	// 共享客户端在关闭存储后仍可用
+	require.NoError(t, store.Close())
+	assert.NoError(t, base.client.Ping(ctx).Err())
+}
+
+func TestRedisStore_TopKSkipsExpired(t *testing.T) {
+	store := setupRedisStore(t)
+	ctx := context.Background()
+
+	// 置信度最高的两条已过期，TopK 仍返回 k 条未过期的 Memory
+	past := time.Now().Add(-time.Minute)
+	for i, conf := range []float64{0.2, 0.5, 0.7, 0.9, 0.95} {
+		mem := newTestMemory("user:6", fmt.Sprintf("k%d", i), "preference", conf)
+		if i >= 3 {
+			mem.ExpiresAt = &past
+		}
+		require.NoError(t, store.Save(ctx, mem))
+	}
+
+	top, err := store.List(ctx, "user:6", WithTopK(2), WithOrderBy(OrderByConfidence))
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "k2", top[0].Key)
+	assert.Equal(t, "k1", top[1].Key)
+
+	remaining, err := store.client.ZCard(ctx, store.indexKey("user:6")).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), remaining, "expired keys should be removed from the index")
+}
+
+func TestRedisStore_MemoryKeyUnambiguous(t *testing.T) {
+	store := &RedisStore{prefix: DefaultRedisKeyPrefix}
+
+	// namespace 含 ":" 时不同的 namespace/key 组合不会映射到同一个键
+	assert.NotEqual(t, store.memoryKey("a:b", "c"), store.memoryKey("a", "b:c"))
+	assert.Equal(t, "aster:logic:3:a:b:c", store.memoryKey("a:b", "c"))
+}
+
+func TestRedisStore_ConcurrentClose(t *testing.T) {
+	// 不可达的地址：Close 与读取并发时只检查关闭标志的数据竞争
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer func() { _ = client.Close() }()
+	store := &RedisStore{client: client, prefix: DefaultRedisKeyPrefix}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.Close())
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = store.Get(ctx, "user:1", "tone")
+		}()
+	}
+	wg.Wait()
+	_, err := store.Get(ctx, "user:1", "tone")
+	assert.ErrorIs(t, err, ErrStoreClosed)
+}
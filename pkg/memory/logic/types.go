@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"slices"
 	"time"
 
 	"github.com/astercloud/aster/pkg/memory"
@@ -72,6 +73,15 @@ type LogicMemory struct {
 	// Metadata 扩展字段（应用层自定义）
 	Metadata map[string]any `json:"metadata,omitempty"`
 
+	// Embedding Description 的向量表示（配置 Embedder 时由 Manager 生成）
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	// Similarity 与语义查询的余弦相似度（仅 WithSemanticQuery 检索结果中设置）
+	Similarity float64 `json:"similarity,omitempty"`
+
+	// ExpiresAt 过期时间（为空表示永不过期），过期后不再被检索并会被清理
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
 	// CreatedAt 创建时间
 	CreatedAt time.Time `json:"created_at"`
 
@@ -103,6 +113,9 @@ type FilterOptions struct {
 	// Type 过滤类型
 	Type string
 
+	// Types 过滤类型列表（匹配任意一个，WithType 传入多个类型时设置）
+	Types []string
+
 	// Scope 过滤作用域
 	Scope MemoryScope
 
@@ -117,12 +130,23 @@ type FilterOptions struct {
 
 	// SinceLastAccess 最后访问时间过滤
 	SinceLastAccess time.Duration
+
+	// SemanticQuery 语义查询文本（需要 Manager 配置 Embedder）
+	SemanticQuery string
 }
 
-// WithType 按类型过滤
-func WithType(memoryType string) Filter {
+// WithType 按类型过滤，传入多个类型时匹配其中任意一个
+func WithType(types ...string) Filter {
 	return func(opts *FilterOptions) {
-		opts.Type = memoryType
+		opts.Type = ""
+		opts.Types = nil
+		switch len(types) {
+		case 0:
+		case 1:
+			opts.Type = types[0]
+		default:
+			opts.Types = append([]string(nil), types...)
+		}
 	}
 }
 
@@ -154,6 +178,14 @@ func WithOrderBy(orderBy OrderBy) Filter {
 	}
 }
 
+// WithSemanticQuery 按与查询文本的语义相似度排序
+// Manager 未配置 Embedder 时忽略，退化为普通检索
+func WithSemanticQuery(text string) Filter {
+	return func(opts *FilterOptions) {
+		opts.SemanticQuery = text
+	}
+}
+
 // OrderBy 排序方式
 type OrderBy string
 
@@ -183,6 +215,28 @@ type PruneCriteria struct {
 	SinceLastAccess time.Duration
 }
 
+// Matches 判断 Memory 是否满足清理条件
+func (c PruneCriteria) Matches(mem *LogicMemory, now time.Time) bool {
+	// 已过期
+	if mem.IsExpired(now) {
+		return true
+	}
+
+	// 置信度过低
+	if mem.Provenance != nil && mem.Provenance.Confidence < c.MinConfidence {
+		return true
+	}
+
+	// 太久未访问
+	if c.SinceLastAccess > 0 && now.Sub(mem.LastAccessed) > c.SinceLastAccess {
+		return true
+	}
+
+	// 访问次数过少且年龄过大
+	return c.MinAccessCount > 0 && c.MaxAge > 0 &&
+		mem.AccessCount < c.MinAccessCount && now.Sub(mem.CreatedAt) > c.MaxAge
+}
+
 // MemoryStats Logic Memory 统计信息
 type MemoryStats struct {
 	// TotalCount 总记忆数
@@ -201,6 +255,23 @@ type MemoryStats struct {
 	LastUpdated time.Time
 }
 
+// TypeSet 返回需要匹配的类型列表，为空表示不按类型过滤
+func (o *FilterOptions) TypeSet() []string {
+	if len(o.Types) > 0 {
+		return o.Types
+	}
+	if o.Type != "" {
+		return []string{o.Type}
+	}
+	return nil
+}
+
+// MatchesType 判断类型是否满足类型过滤条件
+func (o *FilterOptions) MatchesType(memoryType string) bool {
+	types := o.TypeSet()
+	return len(types) == 0 || slices.Contains(types, memoryType)
+}
+
 // ApplyFilters 应用过滤器到 FilterOptions
 func ApplyFilters(filters ...Filter) *FilterOptions {
 	opts := &FilterOptions{
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+)
+
+var auditLog = logging.ForComponent("AuditMiddleware")
+
+// redactedValue 脱敏后的占位值
+const redactedValue = "[REDACTED]"
+
+// defaultRedactKeys 默认脱敏的参数名（不区分大小写，包含即匹配）
+var defaultRedactKeys = []string{"password", "secret", "token", "api_key", "apikey", "authorization", "credential"}
+
+// AuditRecord 工具调用审计记录
+type AuditRecord struct {
+	Timestamp   time.Time      `json:"timestamp"`
+	AgentID     string         `json:"agent_id,omitempty"`
+	ToolCallID  string         `json:"tool_call_id,omitempty"`
+	ToolName    string         `json:"tool_name"`
+	Input       map[string]any `json:"input,omitempty"`
+	EditedInput map[string]any `json:"edited_input,omitempty"` // 人工审核编辑后的参数
+	Output      any            `json:"output,omitempty"`
+	Decision    DecisionType   `json:"decision,omitempty"` // 人工审核决策，未经审核时为空
+	Reason      string         `json:"reason,omitempty"`
+	TimedOut    bool           `json:"timed_out,omitempty"` // 审核超时，Decision 为默认决策
+	Error       string         `json:"error,omitempty"`
+	Duration    time.Duration  `json:"duration"`
+}
+
+// AuditSink 审计记录输出
+type AuditSink interface {
+	Write(record AuditRecord) error
+}
+
+// RedactFunc 写入前对工具参数脱敏，返回脱敏后的参数（不应修改传入的 map）
+type RedactFunc func(toolName string, input map[string]any) map[string]any
+
+// AuditMiddlewareConfig 审计中间件配置
+type AuditMiddlewareConfig struct {
+	// Sink 审计记录输出（必填）
+	Sink AuditSink
+
+	// AgentID 默认 Agent ID，工具上下文中带有 AgentID 时优先使用
+	AgentID string
+
+	// RedactKeys 需要脱敏的参数名（不区分大小写，包含即匹配），为空时使用默认列表
+	RedactKeys []string
+
+	// Redactors 额外的脱敏函数，在 RedactKeys 之后依次执行
+	Redactors []RedactFunc
+
+	// OmitOutput 不记录工具输出
+	OmitOutput bool
+}
+
+// AuditMiddleware 工具调用审计中间件
+// 记录每次工具调用的名称、参数、输出、审核决策和耗时。
+// 优先级 140，位于 HITL 中间件(150)外层，可以记录编辑后的参数和拒绝决策。
+type AuditMiddleware struct {
+	*BaseMiddleware
+
+	sink       AuditSink
+	agentID    string
+	redactKeys []string
+	redactors  []RedactFunc
+	omitOutput bool
+
+	// ownsSink 由注册表创建的输出随 Agent 停止关闭
+	ownsSink bool
+}
+
+// NewAuditMiddleware 创建审计中间件
+func NewAuditMiddleware(config *AuditMiddlewareConfig) (*AuditMiddleware, error) {
+	if config == nil || config.Sink == nil {
+		return nil, errors.New("audit sink is required")
+	}
+
+	redactKeys := config.RedactKeys
+	if len(redactKeys) == 0 {
+		redactKeys = defaultRedactKeys
+	}
+	lowered := make([]string, len(redactKeys))
+	for i, key := range redactKeys {
+		lowered[i] = strings.ToLower(key)
+	}
+
+	return &AuditMiddleware{
+		BaseMiddleware: NewBaseMiddleware("audit", 140),
+		sink:           config.Sink,
+		agentID:        config.AgentID,
+		redactKeys:     lowered,
+		redactors:      config.Redactors,
+		omitOutput:     config.OmitOutput,
+	}, nil
+}
+
+// WrapToolCall 执行工具调用并写入审计记录
+func (m *AuditMiddleware) WrapToolCall(ctx context.Context, req *ToolCallRequest, handler ToolCallHandler) (*ToolCallResponse, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	record := AuditRecord{
+		Timestamp:  start,
+		AgentID:    m.agentID,
+		ToolCallID: req.ToolCallID,
+		ToolName:   req.ToolName,
+		Input:      m.redact(req.ToolName, req.ToolInput),
+		Duration:   time.Since(start),
+	}
+	if req.Context != nil && req.Context.AgentID != "" {
+		record.AgentID = req.Context.AgentID
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if resp != nil {
+		if !m.omitOutput {
+			record.Output = resp.Result
+		}
+		if decision, ok := resp.Metadata[MetadataKeyReviewDecision].(Decision); ok {
+			record.Decision = decision.Type
+			record.Reason = decision.Reason
+			record.TimedOut = decision.TimedOut
+			if decision.Type == DecisionEdit {
+				record.EditedInput = m.redact(req.ToolName, decision.EditedInput)
+			}
+		}
+	}
+
+	// 审计写入失败不影响工具调用结果
+	if writeErr := m.sink.Write(record); writeErr != nil {
+		auditLog.Warn(ctx, "failed to write audit record", map[string]any{"tool": req.ToolName, "error": writeErr})
+	}
+
+	return resp, err
+}
+
+// OnAgentStop 关闭由中间件自身创建的审计输出
+func (m *AuditMiddleware) OnAgentStop(ctx context.Context, agentID string) error {
+	if closer, ok := m.sink.(io.Closer); ok && m.ownsSink {
+		return closer.Close()
+	}
+	return nil
+}
+
+// redact 按配置对参数脱敏，返回新的 map
+func (m *AuditMiddleware) redact(toolName string, input map[string]any) map[string]any {
+	if input == nil {
+		return nil
+	}
+	result, _ := m.redactValue(input).(map[string]any)
+	for _, redactor := range m.redactors {
+		result = redactor(toolName, result)
+	}
+	return result
+}
+
+// redactValue 递归复制值，匹配 redactKeys 的键替换为占位值
+func (m *AuditMiddleware) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			if m.isSensitiveKey(key) {
+				result[key] = redactedValue
+			} else {
+				result[key] = m.redactValue(item)
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = m.redactValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// isSensitiveKey 参数名是否需要脱敏
+func (m *AuditMiddleware) isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range m.redactKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONLFileSink 以 JSON Lines 格式追加写入文件的审计输出
+type JSONLFileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLFileSink 打开（或创建）审计日志文件，以追加方式写入
+func NewJSONLFileSink(path string) (*JSONLFileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	return &JSONLFileSink{file: file}, nil
+}
+
+// Write 写入一条审计记录
+func (s *JSONLFileSink) Write(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(data)
+	return err
+}
+
+// Close 关闭审计日志文件
+func (s *JSONLFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
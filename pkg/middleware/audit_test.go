@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+// memoryAuditSink 内存中的审计输出
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) Write(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// echoToolHandler 返回工具输入作为结果
+func echoToolHandler(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+	return &ToolCallResponse{Result: map[string]any{"ok": true, "input": req.ToolInput}}, nil
+}
+
+func newAuditTestStack(t *testing.T, sink AuditSink, decision Decision) *Stack {
+	t.Helper()
+	audit, err := NewAuditMiddleware(&AuditMiddlewareConfig{Sink: sink, AgentID: "agent-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hitl, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Bash": true},
+		ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+			return []Decision{decision}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStack([]Middleware{hitl, audit})
+}
+
+func TestAuditMiddleware_RecordsToolCall(t *testing.T) {
+	sink := &memoryAuditSink{}
+	stack := newAuditTestStack(t, sink, Decision{Type: DecisionApprove})
+
+	_, err := stack.ExecuteToolCall(context.Background(), &ToolCallRequest{
+		ToolCallID: "call-1",
+		ToolName:   "Read",
+		ToolInput:  map[string]any{"path": "a.txt"},
+		Context:    &tools.ToolContext{AgentID: "agent-ctx"},
+	}, echoToolHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.ToolName != "Read" || record.ToolCallID != "call-1" || record.AgentID != "agent-ctx" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if record.Decision != "" || record.Output == nil || record.Timestamp.IsZero() {
+		t.Errorf("unreviewed call should have output and no decision, got %+v", record)
+	}
+}
+
+func TestAuditMiddleware_CapturesReviewDecisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision Decision
+		check    func(t *testing.T, record AuditRecord)
+	}{
+		{
+			name:     "edit",
+			decision: Decision{Type: DecisionEdit, EditedInput: map[string]any{"command": "ls"}},
+			check: func(t *testing.T, record AuditRecord) {
+				if record.Input["command"] != "rm -rf /" || record.EditedInput["command"] != "ls" {
+					t.Errorf("expected original and edited input, got %+v / %+v", record.Input, record.EditedInput)
+				}
+			},
+		},
+		{
+			name:     "reject",
+			decision: Decision{Type: DecisionReject, Reason: "dangerous"},
+			check: func(t *testing.T, record AuditRecord) {
+				if record.Reason != "dangerous" {
+					t.Errorf("expected rejection reason, got %q", record.Reason)
+				}
+				if result, _ := record.Output.(map[string]any); result["rejected"] != true {
+					t.Errorf("expected rejected output, got %v", record.Output)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memoryAuditSink{}
+			stack := newAuditTestStack(t, sink, tt.decision)
+
+			_, err := stack.ExecuteToolCall(context.Background(), &ToolCallRequest{
+				ToolName:  "Bash",
+				ToolInput: map[string]any{"command": "rm -rf /"},
+			}, echoToolHandler)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sink.records) != 1 {
+				t.Fatalf("expected 1 audit record, got %d", len(sink.records))
+			}
+			record := sink.records[0]
+			if record.Decision != tt.decision.Type || record.AgentID != "agent-1" {
+				t.Errorf("unexpected record %+v", record)
+			}
+			tt.check(t, record)
+		})
+	}
+}
+
+func TestAuditMiddleware_Redaction(t *testing.T) {
+	sink := &memoryAuditSink{}
+	audit, err := NewAuditMiddleware(&AuditMiddlewareConfig{
+		Sink: sink,
+		Redactors: []RedactFunc{func(toolName string, input map[string]any) map[string]any {
+			input["url"] = "https://example.com/***"
+			return input
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := map[string]any{
+		"url":     "https://example.com/?q=1",
+		"API_KEY": "sk-123",
+		"headers": map[string]any{"Authorization": "Bearer abc", "Accept": "json"},
+	}
+	_, err = audit.WrapToolCall(context.Background(), &ToolCallRequest{ToolName: "HttpRequest", ToolInput: input}, func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+		return nil, errors.New("network down")
+	})
+	if err == nil {
+		t.Fatal("tool error should be returned unchanged")
+	}
+
+	record := sink.records[0]
+	headers, _ := record.Input["headers"].(map[string]any)
+	if record.Input["API_KEY"] != redactedValue || headers["Authorization"] != redactedValue || headers["Accept"] != "json" {
+		t.Errorf("sensitive keys should be redacted, got %+v", record.Input)
+	}
+	if record.Input["url"] != "https://example.com/***" {
+		t.Errorf("custom redactor should be applied, got %v", record.Input["url"])
+	}
+	if record.Error != "network down" {
+		t.Errorf("expected error to be recorded, got %q", record.Error)
+	}
+	if input["API_KEY"] != "sk-123" || input["url"] != "https://example.com/?q=1" {
+		t.Error("redaction must not modify the tool input")
+	}
+}
+
+func TestJSONLFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewJSONLFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Read", "Write"} {
+		if err := sink.Write(AuditRecord{ToolName: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		names = append(names, record.ToolName)
+	}
+	if len(names) != 2 || names[0] != "Read" || names[1] != "Write" {
+		t.Errorf("expected one JSON line per record, got %v", names)
+	}
+}
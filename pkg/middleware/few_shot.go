@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+var fewShotLog = logging.ForComponent("FewShotMiddleware")
+
+// FewShotExample 一条历史成功交互，作为 few-shot 示例注入
+type FewShotExample struct {
+	ID        string
+	SessionID string   // 来源会话，用于避免注入当前会话自身的轮次
+	Input     string   // 用户输入
+	Output    string   // 助手的成功回复
+	Tags      []string // 标签（如 "success"、logic memory 反馈标记）
+	Score     float64  // 检索相似度，由 Retriever 填充
+}
+
+// FewShotQuery 示例检索请求
+type FewShotQuery struct {
+	Text             string   // 当前用户输入
+	TopK             int      // 最多返回的示例数
+	Tags             []string // 示例必须包含的全部标签
+	ExcludeSessionID string   // 排除该会话的示例
+}
+
+// FewShotRetriever 检索与当前输入最相似的历史示例，结果按相似度降序
+type FewShotRetriever interface {
+	Retrieve(ctx context.Context, query FewShotQuery) ([]FewShotExample, error)
+}
+
+// FewShotMiddlewareConfig few-shot 中间件配置
+type FewShotMiddlewareConfig struct {
+	// Retriever 示例检索器（必需）
+	Retriever FewShotRetriever
+
+	// TopK 注入的最大示例数（默认 3）
+	TopK int
+
+	// MinScore 最低相似度，低于该值的示例不注入（默认 0.1）
+	MinScore float64
+
+	// MaxTokens 示例的 token 预算（默认 1000）
+	MaxTokens int
+
+	// RequiredTags 示例必须包含的标签，用于只注入已确认成功的交互
+	RequiredTags []string
+
+	// TokenCounter 自定义 token 计数器（默认按 4 字符 ≈ 1 token 估算）
+	TokenCounter TokenCounterFunc
+
+	// Priority 中间件优先级（默认 45，在 summarization 之后，避免示例被压缩）
+	Priority int
+}
+
+// FewShotMiddleware 自动注入相似的历史成功交互作为 few-shot 示例
+// 示例以 user/assistant 轮次的形式插入到当前用户消息之前。
+type FewShotMiddleware struct {
+	*BaseMiddleware
+	config *FewShotMiddlewareConfig
+}
+
+// NewFewShotMiddleware 创建 few-shot 中间件
+func NewFewShotMiddleware(config *FewShotMiddlewareConfig) (*FewShotMiddleware, error) {
+	if config == nil {
+		return nil, errors.New("few-shot config is required")
+	}
+	if config.Retriever == nil {
+		return nil, errors.New("few-shot retriever is required")
+	}
+
+	if config.TopK <= 0 {
+		config.TopK = 3
+	}
+	if config.MinScore <= 0 {
+		config.MinScore = 0.1
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = 1000
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = defaultTokenCounter
+	}
+	if config.Priority <= 0 {
+		config.Priority = 45
+	}
+
+	return &FewShotMiddleware{
+		BaseMiddleware: NewBaseMiddleware("few_shot", config.Priority),
+		config:         config,
+	}, nil
+}
+
+// WrapModelCall 检索相似示例并在当前用户消息前注入
+func (m *FewShotMiddleware) WrapModelCall(ctx context.Context, req *ModelRequest, handler ModelCallHandler) (*ModelResponse, error) {
+	index, query := lastUserText(req.Messages)
+	if index < 0 || query == "" {
+		return handler(ctx, req)
+	}
+
+	sessionID, _ := req.Metadata[MetadataKeySessionID].(string)
+	examples, err := m.config.Retriever.Retrieve(ctx, FewShotQuery{
+		Text:             query,
+		TopK:             m.config.TopK,
+		Tags:             m.config.RequiredTags,
+		ExcludeSessionID: sessionID,
+	})
+	if err != nil {
+		fewShotLog.Error(ctx, "failed to retrieve examples", map[string]any{"error": err.Error()})
+		// 继续执行，不因为示例检索失败而中断
+		return handler(ctx, req)
+	}
+
+	injected := m.buildExampleMessages(req.Messages, sessionID, examples)
+	if len(injected) == 0 {
+		return handler(ctx, req)
+	}
+
+	originalMessages := req.Messages
+	messages := make([]types.Message, 0, len(originalMessages)+len(injected))
+	messages = append(messages, originalMessages[:index]...)
+	messages = append(messages, injected...)
+	messages = append(messages, originalMessages[index:]...)
+	req.Messages = messages
+
+	fewShotLog.Debug(ctx, "injected examples", map[string]any{"count": len(injected) / 2})
+
+	resp, err := handler(ctx, req)
+
+	// 恢复原始消息，示例不进入会话历史
+	req.Messages = originalMessages
+	return resp, err
+}
+
+// buildExampleMessages 过滤示例并在 token 预算内转换为 user/assistant 轮次
+func (m *FewShotMiddleware) buildExampleMessages(current []types.Message, sessionID string, examples []FewShotExample) []types.Message {
+	// 当前会话中出现过的用户输入，避免把自身的轮次当作示例
+	seen := make(map[string]bool)
+	for _, msg := range current {
+		if msg.Role == types.MessageRoleUser {
+			if text := strings.TrimSpace(msg.GetContent()); text != "" {
+				seen[text] = true
+			}
+		}
+	}
+
+	var messages []types.Message
+	budget := m.config.MaxTokens
+	count := 0
+	for _, ex := range examples {
+		if count >= m.config.TopK {
+			break
+		}
+		if ex.Score < m.config.MinScore || ex.Input == "" || ex.Output == "" {
+			continue
+		}
+		if sessionID != "" && ex.SessionID == sessionID {
+			continue
+		}
+		if seen[strings.TrimSpace(ex.Input)] {
+			continue
+		}
+
+		pair := []types.Message{
+			{Role: types.MessageRoleUser, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: ex.Input}}},
+			{Role: types.MessageRoleAssistant, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: ex.Output}}},
+		}
+		tokens := m.config.TokenCounter(pair)
+		if tokens > budget {
+			continue
+		}
+		budget -= tokens
+		messages = append(messages, pair...)
+		count++
+	}
+	return messages
+}
+
+// lastUserText 返回最后一条含文本的用户消息位置及其文本（跳过工具结果消息）
+func lastUserText(messages []types.Message) (int, string) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != types.MessageRoleUser {
+			continue
+		}
+		if text := strings.TrimSpace(msg.GetContent()); text != "" {
+			return i, text
+		}
+	}
+	return -1, ""
+}
+
+// InMemoryFewShotStore 基于词汇相似度（Jaccard）的内存示例库
+type InMemoryFewShotStore struct {
+	mu       sync.RWMutex
+	examples []FewShotExample
+}
+
+// NewInMemoryFewShotStore 创建内存示例库
+func NewInMemoryFewShotStore() *InMemoryFewShotStore {
+	return &InMemoryFewShotStore{}
+}
+
+// Add 添加成功交互示例
+func (s *InMemoryFewShotStore) Add(examples ...FewShotExample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.examples = append(s.examples, examples...)
+}
+
+// Retrieve 实现 FewShotRetriever 接口
+func (s *InMemoryFewShotStore) Retrieve(_ context.Context, query FewShotQuery) ([]FewShotExample, error) {
+	queryTokens := fewShotTokens(query.Text)
+
+	s.mu.RLock()
+	results := make([]FewShotExample, 0, len(s.examples))
+	for _, ex := range s.examples {
+		if query.ExcludeSessionID != "" && ex.SessionID == query.ExcludeSessionID {
+			continue
+		}
+		if !hasAllTags(ex.Tags, query.Tags) {
+			continue
+		}
+		ex.Score = jaccardSimilarity(queryTokens, fewShotTokens(ex.Input))
+		if ex.Score > 0 {
+			results = append(results, ex)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if query.TopK > 0 && len(results) > query.TopK {
+		results = results[:query.TopK]
+	}
+	return results, nil
+}
+
+// hasAllTags 判断 tags 是否包含全部 required
+func hasAllTags(tags, required []string) bool {
+	for _, tag := range required {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// fewShotTokens 将文本拆分为小写词汇集合
+func fewShotTokens(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) >= 2 {
+			tokens[word] = true
+		}
+	}
+	return tokens
+}
+
+// jaccardSimilarity 计算两个词汇集合的 Jaccard 相似度
+func jaccardSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for token := range a {
+		if b[token] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFewShotStore() *InMemoryFewShotStore {
+	store := NewInMemoryFewShotStore()
+	store.Add(
+		FewShotExample{
+			ID:     "deploy",
+			Input:  "How do I deploy the service to the staging cluster?",
+			Output: "Run make deploy ENV=staging.",
+			Tags:   []string{"success"},
+		},
+		FewShotExample{
+			ID:     "sql",
+			Input:  "Write a SQL query that counts orders per customer",
+			Output: "SELECT customer_id, COUNT(*) FROM orders GROUP BY customer_id;",
+			Tags:   []string{"success"},
+		},
+		FewShotExample{
+			ID:     "untagged",
+			Input:  "Deploy the service to the production cluster",
+			Output: "kubectl apply -f prod.yaml",
+		},
+	)
+	return store
+}
+
+func TestFewShotMiddleware_InjectsMostSimilarExample(t *testing.T) {
+	mw, err := NewFewShotMiddleware(&FewShotMiddlewareConfig{
+		Retriever:    newFewShotStore(),
+		TopK:         1,
+		RequiredTags: []string{"success"},
+	})
+	require.NoError(t, err)
+
+	original := []types.Message{
+		{Role: types.MessageRoleUser, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "deploy the billing service to the staging cluster"}}},
+	}
+	req := &ModelRequest{Messages: original, Metadata: map[string]any{}}
+
+	var seen []types.Message
+	_, err = mw.WrapModelCall(context.Background(), req, func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		seen = req.Messages
+		return &ModelResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, seen, 3)
+	assert.Equal(t, types.MessageRoleUser, seen[0].Role)
+	assert.Contains(t, seen[0].GetContent(), "staging cluster")
+	assert.Equal(t, types.MessageRoleAssistant, seen[1].Role)
+	assert.Equal(t, "Run make deploy ENV=staging.", seen[1].GetContent())
+	assert.Equal(t, "deploy the billing service to the staging cluster", seen[2].GetContent())
+
+	// 示例不进入会话历史
+	assert.Len(t, req.Messages, 1)
+}
+
+func TestFewShotMiddleware_SkipsOwnTurnsAndRespectsBudget(t *testing.T) {
+	store := newFewShotStore()
+	store.Add(FewShotExample{
+		ID:        "same-session",
+		SessionID: "sess-1",
+		Input:     "deploy the billing service to the staging cluster now",
+		Output:    "done",
+		Tags:      []string{"success"},
+	})
+
+	mw, err := NewFewShotMiddleware(&FewShotMiddlewareConfig{Retriever: store, TopK: 3})
+	require.NoError(t, err)
+
+	messages := []types.Message{
+		{Role: types.MessageRoleUser, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "Deploy the service to the production cluster"}}},
+		{Role: types.MessageRoleAssistant, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "ok"}}},
+		{Role: types.MessageRoleUser, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: "deploy the billing service to the staging cluster"}}},
+	}
+	req := &ModelRequest{Messages: messages, Metadata: map[string]any{"session_id": "sess-1"}}
+
+	var seen []types.Message
+	_, err = mw.WrapModelCall(context.Background(), req, func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		seen = req.Messages
+		return &ModelResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	for _, msg := range seen[:len(seen)-1] {
+		assert.NotEqual(t, "done", msg.GetContent(), "same-session example must not be injected")
+	}
+	injected := len(seen) - len(messages)
+	assert.Equal(t, 2, injected, "only the staging example qualifies; the production one is the conversation's own turn")
+
+	// 预算不足时不注入
+	mw, err = NewFewShotMiddleware(&FewShotMiddlewareConfig{Retriever: store, MaxTokens: 5})
+	require.NoError(t, err)
+	_, err = mw.WrapModelCall(context.Background(), req, func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		seen = req.Messages
+		return &ModelResponse{}, nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, seen, len(messages))
+}
+
+func TestInMemoryFewShotStore_Retrieve(t *testing.T) {
+	results, err := newFewShotStore().Retrieve(context.Background(), FewShotQuery{
+		Text: "sql query to count orders by customer",
+		TopK: 2,
+		Tags: []string{"success"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "sql", results[0].ID)
+	for _, r := range results {
+		assert.False(t, strings.HasPrefix(r.ID, "untagged"))
+	}
+}
@@ -2,7 +2,13 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/astercloud/aster/pkg/logging"
 )
@@ -36,18 +42,69 @@ type Decision struct {
 	Type        DecisionType   // 决策类型
 	EditedInput map[string]any // 编辑后的参数(仅 type=edit 时有效)
 	Reason      string         // 决策理由(可选)
+	TimedOut    bool           // 是否因审核超时而采用默认决策
 }
 
 // ReviewRequest 审核请求
 type ReviewRequest struct {
 	ActionRequests []ActionRequest   // 待审核的操作列表
 	ReviewConfigs  []InterruptConfig // 每个操作的审核配置
+	Deadline       time.Time         // 审核截止时间，零值表示不限时
 }
 
 // ApprovalHandler 人工审核处理器
 // 用于获取人工决策
 type ApprovalHandler func(ctx context.Context, request *ReviewRequest) ([]Decision, error)
 
+// AutoApproveRule 声明式自动审核规则
+// 匹配的操作直接按 Decision 处理，不再调用 ApprovalHandler。
+// Pattern 与 Allowlist 满足其一即匹配；两者都为空时匹配该工具的所有调用。
+// approve 规则的 Pattern 必须匹配整个字段值，且 Field 为 "command" 时含 shell 元字符的值不会匹配；
+// reject 规则的 Pattern 匹配字段值的任意部分。
+type AutoApproveRule struct {
+	Tool      string       // 工具名称，"*" 匹配所有需要审核的工具
+	Field     string       // 匹配的输入字段，如 Bash 的 "command"
+	Pattern   string       // 字段值需匹配的正则表达式
+	Allowlist []string     // 字段值精确匹配的白名单
+	Decision  DecisionType // approve 或 reject，默认 approve
+	Reason    string       // 决策理由(可选)
+
+	pattern *regexp.Regexp
+}
+
+// matches 规则是否匹配该操作
+func (r *AutoApproveRule) matches(toolName string, input map[string]any) bool {
+	if r.Tool != "*" && r.Tool != toolName {
+		return false
+	}
+	if r.pattern == nil && len(r.Allowlist) == 0 {
+		return true
+	}
+
+	raw, ok := input[r.Field]
+	if !ok {
+		return false
+	}
+	value, ok := raw.(string)
+	if !ok {
+		value = fmt.Sprint(raw)
+	}
+	if slices.Contains(r.Allowlist, value) {
+		return true
+	}
+	if r.pattern == nil {
+		return false
+	}
+	// 防止 "ls; rm -rf /" 这类拼接命令借只读命令的规则被自动批准
+	if r.Decision == DecisionApprove && r.Field == "command" && strings.ContainsAny(value, shellMetacharacters) {
+		return false
+	}
+	return r.pattern.MatchString(value)
+}
+
+// shellMetacharacters 可以在一条命令中拼接、替换或重定向其他命令的字符
+const shellMetacharacters = ";&|`$()<>\n\r\\"
+
 // HumanInTheLoopMiddlewareConfig HITL 中间件配置
 type HumanInTheLoopMiddlewareConfig struct {
 	// InterruptOn 配置哪些工具需要审核
@@ -64,6 +121,17 @@ type HumanInTheLoopMiddlewareConfig struct {
 
 	// DefaultAllowedDecisions 默认允许的决策类型
 	DefaultAllowedDecisions []DecisionType
+
+	// ApprovalTimeout 等待 ApprovalHandler 决策的最长时间，0 表示不限时
+	ApprovalTimeout time.Duration
+
+	// TimeoutDecision 审核超时后采用的决策(approve/reject)，默认 reject
+	TimeoutDecision DecisionType
+
+	// AutoApproveRules 自动审核规则，在 ApprovalHandler 之前评估
+	// 只作用于 InterruptOn 中需要审核的工具；reject 规则优先于 approve 规则，
+	// 同类规则按顺序取第一个匹配，未匹配的操作交给 ApprovalHandler。
+	AutoApproveRules []AutoApproveRule
 }
 
 // HumanInTheLoopMiddleware 人工审核中间件
@@ -78,6 +146,20 @@ type HumanInTheLoopMiddleware struct {
 	interruptConfigs        map[string]*InterruptConfig
 	approvalHandler         ApprovalHandler
 	defaultAllowedDecisions []DecisionType
+	autoRules               []AutoApproveRule
+	approvalTimeout         time.Duration
+	timeoutDecision         DecisionType
+
+	// batchDecisions 批量审核得到、尚未执行的决策，key 见 batchKey
+	batchMu        sync.Mutex
+	batchDecisions map[string]batchDecision
+}
+
+// batchDecision 暂存的批量审核决策，记录审核时的工具名称和输入
+type batchDecision struct {
+	decision Decision
+	toolName string
+	input    string
 }
 
 // NewHumanInTheLoopMiddleware 创建 HITL 中间件
@@ -99,6 +181,48 @@ func NewHumanInTheLoopMiddleware(config *HumanInTheLoopMiddlewareConfig) (*Human
 		defaultAllowedDecisions: defaultAllowedDecisions,
 	}
 
+	switch config.TimeoutDecision {
+	case "":
+		m.timeoutDecision = DecisionReject
+	case DecisionApprove, DecisionReject:
+		m.timeoutDecision = config.TimeoutDecision
+	default:
+		return nil, fmt.Errorf("unsupported timeout decision %q", config.TimeoutDecision)
+	}
+	m.approvalTimeout = config.ApprovalTimeout
+
+	// 编译自动审核规则，reject 规则排在前面
+	for _, rule := range config.AutoApproveRules {
+		switch rule.Decision {
+		case "":
+			rule.Decision = DecisionApprove
+		case DecisionApprove, DecisionReject:
+		default:
+			return nil, fmt.Errorf("auto approve rule for %q: unsupported decision %q", rule.Tool, rule.Decision)
+		}
+		if rule.Pattern != "" {
+			pattern := rule.Pattern
+			if rule.Decision == DecisionApprove {
+				pattern = "^(?:" + pattern + ")$"
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("auto approve rule for %q: invalid pattern: %w", rule.Tool, err)
+			}
+			rule.pattern = re
+		}
+		m.autoRules = append(m.autoRules, rule)
+	}
+	slices.SortStableFunc(m.autoRules, func(a, b AutoApproveRule) int {
+		if a.Decision == b.Decision {
+			return 0
+		}
+		if a.Decision == DecisionReject {
+			return -1
+		}
+		return 1
+	})
+
 	// 解析 InterruptOn 配置
 	if config.InterruptOn != nil {
 		for toolName, cfg := range config.InterruptOn {
@@ -175,6 +299,11 @@ func (m *HumanInTheLoopMiddleware) WrapToolCall(ctx context.Context, req *ToolCa
 		return handler(ctx, req)
 	}
 
+	// 批量审核已给出决策
+	if decision, ok := m.takeBatchDecision(req); ok {
+		return m.applyDecision(ctx, req, decision, handler)
+	}
+
 	hitlLog.Info(ctx, "tool requires approval", map[string]any{"tool": req.ToolName})
 
 	// 构建审核请求
@@ -190,8 +319,19 @@ func (m *HumanInTheLoopMiddleware) WrapToolCall(ctx context.Context, req *ToolCa
 		ReviewConfigs: []InterruptConfig{*interruptCfg},
 	}
 
-	// 获取人工决策
-	decisions, err := m.getApproval(ctx, reviewRequest)
+	// 获取决策：自动审核规则优先，未匹配时请求人工决策
+	var decisions []Decision
+	var err error
+	if decision, ok := m.autoDecision(req.ToolName, req.ToolInput); ok {
+		hitlLog.Info(ctx, "tool auto-reviewed by rule", map[string]any{"tool": req.ToolName, "decision": decision.Type})
+		decisions = []Decision{decision}
+	} else {
+		decisions, err = m.getApproval(ctx, reviewRequest)
+	}
+	if ctx.Err() != nil {
+		// Agent 取消时直接返回，不再执行工具
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		return &ToolCallResponse{
 			Result: map[string]any{
@@ -210,20 +350,24 @@ func (m *HumanInTheLoopMiddleware) WrapToolCall(ctx context.Context, req *ToolCa
 		}, nil
 	}
 
-	decision := decisions[0]
+	return m.applyDecision(ctx, req, decisions[0], handler)
+}
 
-	// 处理决策
+// applyDecision 按决策执行、编辑后执行或拒绝工具调用
+func (m *HumanInTheLoopMiddleware) applyDecision(ctx context.Context, req *ToolCallRequest, decision Decision, handler ToolCallHandler) (*ToolCallResponse, error) {
 	switch decision.Type {
 	case DecisionApprove:
 		hitlLog.Info(ctx, "tool approved", map[string]any{"tool": req.ToolName})
-		return handler(ctx, req)
+		resp, err := handler(ctx, req)
+		return attachReviewDecision(resp, decision), err
 
 	case DecisionEdit:
 		hitlLog.Info(ctx, "tool approved with edited input", map[string]any{"tool": req.ToolName})
 		// 使用编辑后的参数
 		editedReq := *req
 		editedReq.ToolInput = decision.EditedInput
-		return handler(ctx, &editedReq)
+		resp, err := handler(ctx, &editedReq)
+		return attachReviewDecision(resp, decision), err
 
 	case DecisionReject:
 		hitlLog.Info(ctx, "tool rejected", map[string]any{"tool": req.ToolName, "reason": decision.Reason})
@@ -234,6 +378,7 @@ func (m *HumanInTheLoopMiddleware) WrapToolCall(ctx context.Context, req *ToolCa
 				"reason":   decision.Reason,
 				"message":  "Tool execution rejected by human reviewer: " + decision.Reason,
 			},
+			Metadata: map[string]any{MetadataKeyReviewDecision: decision},
 		}, nil
 
 	default:
@@ -246,10 +391,209 @@ func (m *HumanInTheLoopMiddleware) WrapToolCall(ctx context.Context, req *ToolCa
 	}
 }
 
+// attachReviewDecision 在工具响应的 Metadata 中记录审核决策，供外层中间件（如审计）读取
+func attachReviewDecision(resp *ToolCallResponse, decision Decision) *ToolCallResponse {
+	if resp == nil {
+		return nil
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata[MetadataKeyReviewDecision] = decision
+	return resp
+}
+
+// PrepareToolBatch 在执行一批工具调用前统一审核，实现 ToolBatchPreparer。
+// 需要人工审核的操作合并为一个 ReviewRequest，ApprovalHandler 可以返回一个决策
+// (批准全部/拒绝全部)，或按 ActionRequests 下标逐一对齐的决策列表。
+// 决策暂存到对应的工具调用执行时应用：只有批准(或编辑)的工具会执行，被拒绝的工具返回拒绝结果。
+// 审核失败时返回错误，这些操作在执行时回退为逐个审核。
+// 调用方需在这批工具执行结束后调用 FinishToolBatch，清除未被使用的决策。
+func (m *HumanInTheLoopMiddleware) PrepareToolBatch(ctx context.Context, reqs []*ToolCallRequest) error {
+	var pending []*ToolCallRequest
+	review := &ReviewRequest{}
+	for _, req := range reqs {
+		interruptCfg, needsApproval := m.interruptConfigs[req.ToolName]
+		if !needsApproval {
+			continue
+		}
+		if decision, ok := m.autoDecision(req.ToolName, req.ToolInput); ok {
+			m.storeBatchDecision(req, decision)
+			continue
+		}
+		pending = append(pending, req)
+		review.ActionRequests = append(review.ActionRequests, ActionRequest{
+			ToolCallID: req.ToolCallID,
+			ToolName:   req.ToolName,
+			Input:      req.ToolInput,
+			Message:    interruptCfg.Message,
+		})
+		review.ReviewConfigs = append(review.ReviewConfigs, *interruptCfg)
+	}
+	if len(pending) < 2 {
+		// 单个操作沿用逐个审核流程
+		return nil
+	}
+
+	hitlLog.Info(ctx, "tool batch requires approval", map[string]any{"actions": len(pending)})
+	decisions, err := m.getApproval(ctx, review)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("batch approval request failed: %w", err)
+	}
+	decisions, err = alignBatchDecisions(decisions, len(pending))
+	if err != nil {
+		return err
+	}
+
+	for i, req := range pending {
+		m.storeBatchDecision(req, decisions[i])
+	}
+	return nil
+}
+
+// FinishToolBatch 清除这批工具调用中未被使用的决策，实现 ToolBatchPreparer。
+// 工具在执行前被其他检查拦截时决策不会被取出，清除后不会被之后复用同一 ID 的调用误用。
+func (m *HumanInTheLoopMiddleware) FinishToolBatch(ctx context.Context, reqs []*ToolCallRequest) {
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+	for _, req := range reqs {
+		delete(m.batchDecisions, batchKey(req))
+	}
+}
+
+// alignBatchDecisions 把批量审核的决策对齐到每个操作
+// 单个决策(编辑除外)应用到全部操作，否则决策数量必须与操作数量一致。
+func alignBatchDecisions(decisions []Decision, n int) ([]Decision, error) {
+	switch {
+	case len(decisions) == n:
+		return decisions, nil
+	case len(decisions) == 1 && decisions[0].Type != DecisionEdit:
+		aligned := make([]Decision, n)
+		for i := range aligned {
+			aligned[i] = decisions[0]
+		}
+		return aligned, nil
+	default:
+		return nil, fmt.Errorf("expected 1 or %d decisions, got %d", n, len(decisions))
+	}
+}
+
+// batchKey 批量决策的索引键，优先使用模型返回的 tool_use ID
+func batchKey(req *ToolCallRequest) string {
+	if req.ToolUseID != "" {
+		return req.ToolUseID
+	}
+	return req.ToolCallID
+}
+
+// storeBatchDecision 暂存批量审核决策
+func (m *HumanInTheLoopMiddleware) storeBatchDecision(req *ToolCallRequest, decision Decision) {
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+	if m.batchDecisions == nil {
+		m.batchDecisions = make(map[string]batchDecision)
+	}
+	m.batchDecisions[batchKey(req)] = batchDecision{
+		decision: decision,
+		toolName: req.ToolName,
+		input:    inputFingerprint(req.ToolInput),
+	}
+}
+
+// takeBatchDecision 取出并删除工具调用的批量审核决策
+// 工具名称或输入与审核时不一致的决策作废，该调用回退为逐个审核。
+func (m *HumanInTheLoopMiddleware) takeBatchDecision(req *ToolCallRequest) (Decision, bool) {
+	m.batchMu.Lock()
+	defer m.batchMu.Unlock()
+	key := batchKey(req)
+	stored, ok := m.batchDecisions[key]
+	if !ok {
+		return Decision{}, false
+	}
+	delete(m.batchDecisions, key)
+	if stored.toolName != req.ToolName || stored.input != inputFingerprint(req.ToolInput) {
+		return Decision{}, false
+	}
+	return stored.decision, true
+}
+
+// inputFingerprint 工具输入的规范化表示，JSON 编码时 map 键有序
+func inputFingerprint(input map[string]any) string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Sprintf("%#v", input)
+	}
+	return string(data)
+}
+
+// autoDecision 按自动审核规则返回决策，未匹配任何规则时返回 false
+func (m *HumanInTheLoopMiddleware) autoDecision(toolName string, input map[string]any) (Decision, bool) {
+	for i := range m.autoRules {
+		rule := &m.autoRules[i]
+		if !rule.matches(toolName, input) {
+			continue
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("auto-%s by rule", rule.Decision)
+		}
+		return Decision{Type: rule.Decision, Reason: reason}, true
+	}
+	return Decision{}, false
+}
+
 // getApproval 获取人工审核决策
+// 配置了 ApprovalTimeout 时超时采用默认决策；ctx 取消时立即返回，不等待处理器结束。
 func (m *HumanInTheLoopMiddleware) getApproval(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
 	if m.approvalHandler != nil {
-		return m.approvalHandler(ctx, request)
+		handlerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var timeout <-chan time.Time
+		if m.approvalTimeout > 0 {
+			request.Deadline = time.Now().Add(m.approvalTimeout)
+			var deadlineCancel context.CancelFunc
+			handlerCtx, deadlineCancel = context.WithDeadline(handlerCtx, request.Deadline)
+			defer deadlineCancel()
+			timer := time.NewTimer(m.approvalTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		type result struct {
+			decisions []Decision
+			err       error
+		}
+		done := make(chan result, 1)
+		go func() {
+			decisions, err := m.approvalHandler(handlerCtx, request)
+			done <- result{decisions, err}
+		}()
+
+		select {
+		case r := <-done:
+			if r.err == nil || ctx.Err() != nil || handlerCtx.Err() == nil {
+				return r.decisions, r.err
+			}
+			// 处理器因审核截止时间返回错误，按超时处理
+		case <-timeout:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		hitlLog.Warn(ctx, "approval timed out, applying default decision", map[string]any{"timeout": m.approvalTimeout.String(), "decision": m.timeoutDecision})
+		decisions := make([]Decision, len(request.ActionRequests))
+		for i := range decisions {
+			decisions[i] = Decision{
+				Type:     m.timeoutDecision,
+				Reason:   fmt.Sprintf("no decision within %s", m.approvalTimeout),
+				TimedOut: true,
+			}
+		}
+		return decisions, nil
 	}
 
 	// 默认处理器: 自动批准所有请求
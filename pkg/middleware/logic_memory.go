@@ -65,6 +65,9 @@ type LogicMemoryMiddlewareConfig struct {
 	// MinConfidence 最低置信度阈值（默认 0.6）
 	MinConfidence float64
 
+	// InjectTypes 只注入这些类型的 Memory（如 "preference"，为空时不过滤）
+	InjectTypes []string
+
 	// AsyncCapture 是否异步捕获事件（默认 true）
 	// 异步模式不阻塞主流程，但可能丢失部分事件
 	AsyncCapture bool
@@ -192,6 +195,7 @@ func (m *LogicMemoryMiddleware) WrapModelCall(ctx context.Context, req *ModelReq
 
 	// 检索相关 Memory
 	memories, err := m.manager.RetrieveMemories(ctx, namespace,
+		logic.WithType(m.config.InjectTypes...),
 		logic.WithTopK(m.config.MaxMemories),
 		logic.WithMinConfidence(m.config.MinConfidence),
 		logic.WithOrderBy(logic.OrderByConfidence),
@@ -458,6 +462,7 @@ func (m *LogicMemoryMiddleware) GetConfig() map[string]any {
 		"enable_injection": m.config.EnableInjection,
 		"max_memories":     m.config.MaxMemories,
 		"min_confidence":   m.config.MinConfidence,
+		"inject_types":     m.config.InjectTypes,
 		"async_capture":    m.config.AsyncCapture,
 		"injection_point":  m.config.InjectionPoint,
 	}
@@ -111,6 +111,40 @@ func TestLogicMemoryMiddleware_WrapModelCall(t *testing.T) {
 		assert.Equal(t, "You are a helpful assistant.", req.SystemPrompt)
 	})
 
+	t.Run("inject only configured types", func(t *testing.T) {
+		require.NoError(t, store.Save(ctx, &logic.LogicMemory{
+			ID:          "mem-2",
+			Namespace:   "user:123",
+			Scope:       logic.ScopeUser,
+			Type:        "behavior",
+			Key:         "clicks",
+			Value:       "fast",
+			Description: "用户点击很快",
+			Provenance:  &memory.MemoryProvenance{Confidence: 0.9},
+		}))
+		defer func() { _ = store.Delete(ctx, "user:123", "clicks") }()
+
+		mw, err := NewLogicMemoryMiddleware(&LogicMemoryMiddlewareConfig{
+			Manager:         manager,
+			EnableInjection: true,
+			InjectTypes:     []string{"preference"},
+		})
+		require.NoError(t, err)
+
+		var capturedSystemPrompt string
+		_, err = mw.WrapModelCall(ctx, &ModelRequest{
+			SystemPrompt: "You are a helpful assistant.",
+			Metadata:     map[string]any{"user_id": "123"},
+		}, func(ctx context.Context, r *ModelRequest) (*ModelResponse, error) {
+			capturedSystemPrompt = r.SystemPrompt
+			return &ModelResponse{}, nil
+		})
+		require.NoError(t, err)
+
+		assert.Contains(t, capturedSystemPrompt, "口语化")
+		assert.NotContains(t, capturedSystemPrompt, "点击")
+	})
+
 	t.Run("skip injection when disabled", func(t *testing.T) {
 		mw, err := NewLogicMemoryMiddleware(&LogicMemoryMiddlewareConfig{
 			Manager:         manager,
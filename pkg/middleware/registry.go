@@ -279,10 +279,17 @@ func (r *Registry) registerBuiltin() {
 			}
 		}
 
+		var caps *provider.ProviderCapabilities
+		if config.Provider != nil {
+			c := config.Provider.Capabilities()
+			caps = &c
+		}
+
 		return NewStructuredOutputMiddleware(&StructuredOutputMiddlewareConfig{
-			Spec:       spec,
-			AllowError: true,
-			Priority:   65,
+			Spec:         spec,
+			AllowError:   true,
+			Priority:     65,
+			Capabilities: caps,
 		})
 	})
 
@@ -379,6 +386,46 @@ func (r *Registry) registerBuiltin() {
 		}), nil
 	})
 
+	// Audit Middleware (工具调用审计，写入 JSON Lines 文件)
+	r.Register("audit", func(config *MiddlewareFactoryConfig) (Middleware, error) {
+		path := "audit.jsonl"
+		var redactKeys []string
+		omitOutput := false
+
+		if config.CustomConfig != nil {
+			if p, ok := config.CustomConfig["path"].(string); ok && p != "" {
+				path = p
+			}
+			if keys, ok := config.CustomConfig["redact_keys"].([]any); ok {
+				for _, k := range keys {
+					if ks, ok := k.(string); ok {
+						redactKeys = append(redactKeys, ks)
+					}
+				}
+			}
+			if oo, ok := config.CustomConfig["omit_output"].(bool); ok {
+				omitOutput = oo
+			}
+		}
+
+		sink, err := NewJSONLFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		m, err := NewAuditMiddleware(&AuditMiddlewareConfig{
+			Sink:       sink,
+			AgentID:    config.AgentID,
+			RedactKeys: redactKeys,
+			OmitOutput: omitOutput,
+		})
+		if err != nil {
+			_ = sink.Close()
+			return nil, err
+		}
+		m.ownsSink = true
+		return m, nil
+	})
+
 	// Telemetry Middleware (OpenTelemetry GenAI Semantic Conventions)
 	r.Register("telemetry", func(config *MiddlewareFactoryConfig) (Middleware, error) {
 		// 默认配置
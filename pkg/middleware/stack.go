@@ -2,7 +2,11 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"sort"
+	"sync"
 
 	"github.com/astercloud/aster/pkg/tools"
 )
@@ -10,27 +14,79 @@ import (
 // Stack 中间件栈
 // 管理多个中间件,构建洋葱模型的调用链
 type Stack struct {
+	mu          sync.RWMutex
 	middlewares []Middleware
+	explicit    bool
+}
+
+// StackOption 中间件栈选项
+type StackOption func(*Stack)
+
+// WithExplicitOrder 严格按传入切片的顺序执行中间件,忽略 Priority()
+func WithExplicitOrder() StackOption {
+	return func(s *Stack) {
+		s.explicit = true
+	}
 }
 
 // NewStack 创建中间件栈
-func NewStack(middlewares []Middleware) *Stack {
-	// 按优先级排序(优先级数值越小越先执行)
-	sorted := make([]Middleware, len(middlewares))
-	copy(sorted, middlewares)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Priority() < sorted[j].Priority()
-	})
+// 默认按 Priority() 排序(数值越小越先执行),优先级相同时保持传入顺序;
+// 使用 WithExplicitOrder 时严格按传入顺序执行,显式顺序优先于 Priority()。
+func NewStack(middlewares []Middleware, opts ...StackOption) *Stack {
+	s := &Stack{middlewares: slices.Clone(middlewares)}
+	for _, opt := range opts {
+		opt(s)
+	}
 
-	return &Stack{
-		middlewares: sorted,
+	if !s.explicit {
+		sort.SliceStable(s.middlewares, func(i, j int) bool {
+			return s.middlewares[i].Priority() < s.middlewares[j].Priority()
+		})
 	}
+
+	return s
+}
+
+// InsertBefore 将中间件插入到名为 name 的中间件之前
+// 插入位置优先于 Priority()。
+func (s *Stack) InsertBefore(name string, m Middleware) error {
+	return s.insert(name, m, 0)
+}
+
+// InsertAfter 将中间件插入到名为 name 的中间件之后
+// 插入位置优先于 Priority()。
+func (s *Stack) InsertAfter(name string, m Middleware) error {
+	return s.insert(name, m, 1)
+}
+
+// insert 在名为 name 的中间件位置偏移 offset 处插入中间件
+func (s *Stack) insert(name string, m Middleware, offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := slices.IndexFunc(s.middlewares, func(existing Middleware) bool {
+		return existing.Name() == name
+	})
+	if idx < 0 {
+		return fmt.Errorf("middleware not found: %s", name)
+	}
+
+	// 写时复制,不影响正在执行的调用链
+	s.middlewares = slices.Insert(slices.Clone(s.middlewares), idx+offset, m)
+	return nil
+}
+
+// list 返回当前中间件列表的快照
+func (s *Stack) list() []Middleware {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.middlewares
 }
 
 // Tools 收集所有中间件提供的工具
 func (s *Stack) Tools() []tools.Tool {
 	var allTools []tools.Tool
-	for _, m := range s.middlewares {
+	for _, m := range s.list() {
 		if t := m.Tools(); t != nil {
 			allTools = append(allTools, t...)
 		}
@@ -45,9 +101,10 @@ func (s *Stack) ExecuteModelCall(
 	finalHandler ModelCallHandler,
 ) (*ModelResponse, error) {
 	// 构建中间件链
+	middlewares := s.list()
 	handler := finalHandler
-	for i := len(s.middlewares) - 1; i >= 0; i-- {
-		m := s.middlewares[i]
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		m := middlewares[i]
 		currentHandler := handler
 		handler = func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
 			return m.WrapModelCall(ctx, req, currentHandler)
@@ -64,9 +121,10 @@ func (s *Stack) ExecuteToolCall(
 	finalHandler ToolCallHandler,
 ) (*ToolCallResponse, error) {
 	// 构建中间件链
+	middlewares := s.list()
 	handler := finalHandler
-	for i := len(s.middlewares) - 1; i >= 0; i-- {
-		m := s.middlewares[i]
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		m := middlewares[i]
 		currentHandler := handler
 		handler = func(ctx context.Context, req *ToolCallRequest) (*ToolCallResponse, error) {
 			return m.WrapToolCall(ctx, req, currentHandler)
@@ -76,9 +134,47 @@ func (s *Stack) ExecuteToolCall(
 	return handler(ctx, req)
 }
 
+// ToolBatchPreparer 可以在一批工具调用执行前统一处理的中间件(可选接口)
+// 例如 HumanInTheLoopMiddleware 据此把多个待审核操作合并为一次审核。
+type ToolBatchPreparer interface {
+	PrepareToolBatch(ctx context.Context, reqs []*ToolCallRequest) error
+
+	// FinishToolBatch 在这批工具调用全部结束后调用，释放 PrepareToolBatch 保存的状态
+	FinishToolBatch(ctx context.Context, reqs []*ToolCallRequest)
+}
+
+// PrepareToolBatch 通知实现 ToolBatchPreparer 的中间件即将执行的一批工具调用
+// 之后每个调用仍需通过 ExecuteToolCall 执行；ctx 取消时立即返回。
+func (s *Stack) PrepareToolBatch(ctx context.Context, reqs []*ToolCallRequest) error {
+	var errs []error
+	for _, m := range s.list() {
+		preparer, ok := m.(ToolBatchPreparer)
+		if !ok {
+			continue
+		}
+		if err := preparer.PrepareToolBatch(ctx, reqs); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", m.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FinishToolBatch 通知实现 ToolBatchPreparer 的中间件这批工具调用已经结束
+// 无论每个调用是否真正执行都需要调用，通常与 PrepareToolBatch 配对 defer。
+func (s *Stack) FinishToolBatch(ctx context.Context, reqs []*ToolCallRequest) {
+	for _, m := range s.list() {
+		if preparer, ok := m.(ToolBatchPreparer); ok {
+			preparer.FinishToolBatch(ctx, reqs)
+		}
+	}
+}
+
 // OnAgentStart 通知所有中间件 Agent 启动
 func (s *Stack) OnAgentStart(ctx context.Context, agentID string) error {
-	for _, m := range s.middlewares {
+	for _, m := range s.list() {
 		if err := m.OnAgentStart(ctx, agentID); err != nil {
 			return err
 		}
@@ -89,8 +185,9 @@ func (s *Stack) OnAgentStart(ctx context.Context, agentID string) error {
 // OnAgentStop 通知所有中间件 Agent 停止
 func (s *Stack) OnAgentStop(ctx context.Context, agentID string) error {
 	// 逆序通知(LIFO)
-	for i := len(s.middlewares) - 1; i >= 0; i-- {
-		if err := s.middlewares[i].OnAgentStop(ctx, agentID); err != nil {
+	middlewares := s.list()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if err := middlewares[i].OnAgentStop(ctx, agentID); err != nil {
 			return err
 		}
 	}
@@ -99,5 +196,5 @@ func (s *Stack) OnAgentStop(ctx context.Context, agentID string) error {
 
 // Middlewares 返回中间件列表
 func (s *Stack) Middlewares() []Middleware {
-	return s.middlewares
+	return s.list()
 }
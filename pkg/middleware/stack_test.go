@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// orderMiddleware 记录模型调用、启动和停止顺序的测试中间件
+type orderMiddleware struct {
+	*BaseMiddleware
+	trace *[]string
+}
+
+func newOrderMiddleware(name string, priority int, trace *[]string) *orderMiddleware {
+	return &orderMiddleware{BaseMiddleware: NewBaseMiddleware(name, priority), trace: trace}
+}
+
+func (m *orderMiddleware) WrapModelCall(ctx context.Context, req *ModelRequest, handler ModelCallHandler) (*ModelResponse, error) {
+	*m.trace = append(*m.trace, m.Name())
+	return handler(ctx, req)
+}
+
+func (m *orderMiddleware) OnAgentStop(ctx context.Context, agentID string) error {
+	*m.trace = append(*m.trace, "stop:"+m.Name())
+	return nil
+}
+
+// runModelCall 执行一次模型调用并返回中间件执行顺序
+func runModelCall(t *testing.T, stack *Stack, trace *[]string) []string {
+	t.Helper()
+	*trace = nil
+	_, err := stack.ExecuteModelCall(context.Background(), &ModelRequest{}, func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		return &ModelResponse{}, nil
+	})
+	if err != nil {
+		t.Fatalf("ExecuteModelCall failed: %v", err)
+	}
+	return *trace
+}
+
+func TestStack_PriorityOrderIsStable(t *testing.T) {
+	var trace []string
+	stack := NewStack([]Middleware{
+		newOrderMiddleware("memory", 100, &trace),
+		newOrderMiddleware("guardrails", 100, &trace),
+		newOrderMiddleware("telemetry", 10, &trace),
+		newOrderMiddleware("audit", 100, &trace),
+	})
+
+	want := []string{"telemetry", "memory", "guardrails", "audit"}
+	for range 5 {
+		if got := runModelCall(t, stack, &trace); !slices.Equal(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestStack_ExplicitOrderOverridesPriority(t *testing.T) {
+	var trace []string
+	stack := NewStack([]Middleware{
+		newOrderMiddleware("guardrails", 500, &trace),
+		newOrderMiddleware("memory", 10, &trace),
+		newOrderMiddleware("telemetry", 100, &trace),
+	}, WithExplicitOrder())
+
+	want := []string{"guardrails", "memory", "telemetry"}
+	if got := runModelCall(t, stack, &trace); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	trace = nil
+	if err := stack.OnAgentStop(context.Background(), "agent"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"stop:telemetry", "stop:memory", "stop:guardrails"}; !slices.Equal(trace, want) {
+		t.Errorf("expected LIFO stop order %v, got %v", want, trace)
+	}
+}
+
+func TestStack_InsertBeforeAfter(t *testing.T) {
+	var trace []string
+	stack := NewStack([]Middleware{
+		newOrderMiddleware("telemetry", 10, &trace),
+		newOrderMiddleware("memory", 100, &trace),
+	})
+
+	if err := stack.InsertBefore("memory", newOrderMiddleware("guardrails", 900, &trace)); err != nil {
+		t.Fatalf("InsertBefore failed: %v", err)
+	}
+	if err := stack.InsertAfter("memory", newOrderMiddleware("audit", 0, &trace)); err != nil {
+		t.Fatalf("InsertAfter failed: %v", err)
+	}
+	if err := stack.InsertAfter("missing", newOrderMiddleware("x", 0, &trace)); err == nil {
+		t.Error("expected error for unknown middleware name")
+	}
+
+	want := []string{"telemetry", "guardrails", "memory", "audit"}
+	if got := runModelCall(t, stack, &trace); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if n := len(stack.Middlewares()); n != 4 {
+		t.Errorf("expected 4 middlewares, got %d", n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/structured"
 )
 
@@ -14,12 +15,17 @@ var soLog = logging.ForComponent("StructuredOutputMiddleware")
 // StructuredOutputMiddleware 在模型响应后尝试解析结构化输出，并将结果写入 Metadata。
 // - 若解析成功: Metadata["structured_data"] = 解析后的对象，Metadata["structured_raw_json"] = 原始 JSON 文本
 // - 若解析失败: 根据配置决定是否回退；错误记录在 Metadata["structured_error"]
+// 配置了 Capabilities 时，会通过 StrategySelector 选择 JSON 模式/工具强制/提示词机制并修改请求选项，
+// 选中的机制记录在 Metadata["structured_strategy"]。
 type StructuredOutputMiddleware struct {
 	*BaseMiddleware
 
 	spec       structured.OutputSpec
 	parser     structured.Parser
 	allowError bool
+
+	capabilities *provider.ProviderCapabilities
+	selector     *structured.StrategySelector
 }
 
 // StructuredOutputMiddlewareConfig 配置
@@ -28,6 +34,11 @@ type StructuredOutputMiddlewareConfig struct {
 	Parser     structured.Parser // 可选，默认 JSONParser
 	AllowError bool              // 解析失败时是否忽略错误并回退到原始文本
 	Priority   int               // 可选，默认 60
+
+	// Capabilities Provider 能力（可选），设置后按能力选择结构化输出机制
+	Capabilities *provider.ProviderCapabilities
+	// Selector 机制选择器（可选，默认 structured.NewStrategySelector()）
+	Selector *structured.StrategySelector
 }
 
 // NewStructuredOutputMiddleware 创建中间件实例
@@ -46,16 +57,39 @@ func NewStructuredOutputMiddleware(cfg *StructuredOutputMiddlewareConfig) (*Stru
 		priority = 60
 	}
 
+	selector := cfg.Selector
+	if selector == nil {
+		selector = structured.NewStrategySelector()
+	}
+
 	return &StructuredOutputMiddleware{
 		BaseMiddleware: NewBaseMiddleware("structured_output", priority),
 		spec:           cfg.Spec,
 		parser:         parser,
 		allowError:     cfg.AllowError || cfg.Spec.AllowTextBackup,
+		capabilities:   cfg.Capabilities,
+		selector:       selector,
 	}, nil
 }
 
+// Strategy 返回当前配置下选中的结构化输出机制
+func (m *StructuredOutputMiddleware) Strategy() structured.Strategy {
+	if !m.spec.Enabled || m.capabilities == nil {
+		return structured.StrategyNone
+	}
+	return m.selector.Select(*m.capabilities)
+}
+
 // WrapModelCall 尝试解析结构化输出
 func (m *StructuredOutputMiddleware) WrapModelCall(ctx context.Context, req *ModelRequest, handler ModelCallHandler) (*ModelResponse, error) {
+	strategy := m.Strategy()
+	if strategy != structured.StrategyNone {
+		caps := *m.capabilities
+		req.AddStreamOptions(func(opts *provider.StreamOptions) {
+			structured.Apply(strategy, caps, m.spec, opts)
+		})
+	}
+
 	resp, err := handler(ctx, req)
 	if err != nil || resp == nil {
 		return resp, err
@@ -65,23 +99,31 @@ func (m *StructuredOutputMiddleware) WrapModelCall(ctx context.Context, req *Mod
 		return resp, nil
 	}
 
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	if strategy != structured.StrategyNone {
+		resp.Metadata["structured_strategy"] = string(strategy)
+	}
+
 	content := resp.Message.GetContent()
+	if strategy == structured.StrategyToolMode {
+		if toolOutput, ok := structured.ExtractToolOutput(&resp.Message); ok {
+			content = toolOutput
+		}
+	}
+
 	result, parseErr := m.parser.Parse(ctx, content, m.spec)
 	if parseErr != nil {
 		if m.allowError {
-			soLog.Warn(ctx, "parse failed", map[string]any{"error": parseErr.Error()})
-			if resp.Metadata == nil {
-				resp.Metadata = make(map[string]any)
-			}
+			soLog.Warn(ctx, "parse failed", map[string]any{"error": parseErr.Error(), "strategy": string(strategy)})
 			resp.Metadata["structured_error"] = parseErr.Error()
 			return resp, nil
 		}
 		return resp, fmt.Errorf("structured output parse failed: %w", parseErr)
 	}
+	result.Strategy = strategy
 
-	if resp.Metadata == nil {
-		resp.Metadata = make(map[string]any)
-	}
 	resp.Metadata["structured_data"] = result.Data
 	resp.Metadata["structured_raw_json"] = result.RawJSON
 	resp.Metadata["structured_missing_fields"] = result.MissingFields
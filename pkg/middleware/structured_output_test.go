@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/structured"
 	"github.com/astercloud/aster/pkg/types"
 )
@@ -101,3 +102,39 @@ func TestStructuredOutputMiddleware_ErrorWhenDisallow(t *testing.T) {
 		t.Fatalf("expected error when parsing failed with AllowError=false")
 	}
 }
+
+func TestStructuredOutputMiddleware_ToolModeStrategy(t *testing.T) {
+	mw, err := NewStructuredOutputMiddleware(&StructuredOutputMiddlewareConfig{
+		Spec:         structured.OutputSpec{Enabled: true, RequiredFields: []string{"answer"}},
+		Capabilities: &provider.ProviderCapabilities{SupportToolCalling: true},
+	})
+	if err != nil {
+		t.Fatalf("create middleware: %v", err)
+	}
+
+	var sent provider.StreamOptions
+	handler := func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		req.ApplyStreamOptions(&sent)
+		return &ModelResponse{
+			Message: types.Message{ContentBlocks: []types.ContentBlock{
+				&types.ToolUseBlock{ID: "call_1", Name: structured.OutputToolName, Input: map[string]any{"answer": "yes"}},
+			}},
+		}, nil
+	}
+
+	resp, err := mw.WrapModelCall(context.Background(), &ModelRequest{}, handler)
+	if err != nil {
+		t.Fatalf("wrap call: %v", err)
+	}
+
+	if sent.ToolChoice == nil || sent.ToolChoice.Name != structured.OutputToolName {
+		t.Fatalf("expected forced output tool, got %+v", sent.ToolChoice)
+	}
+	if resp.Metadata["structured_strategy"] != string(structured.StrategyToolMode) {
+		t.Errorf("unexpected strategy: %v", resp.Metadata["structured_strategy"])
+	}
+	data, ok := resp.Metadata["structured_data"].(map[string]any)
+	if !ok || data["answer"] != "yes" {
+		t.Errorf("unexpected structured data: %v", resp.Metadata["structured_data"])
+	}
+}
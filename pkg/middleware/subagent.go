@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/store"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/tools/builtin"
 	"github.com/astercloud/aster/pkg/types"
@@ -45,16 +47,51 @@ type SubAgent interface {
 	Close() error
 }
 
+// StreamingSubAgent 支持增量输出的子代理（可选接口）
+// 异步执行时，发送到 progress 的内容会被缓冲，运行期间可通过 query_subagent 的 partial_output 获取。
+// 未实现该接口的子代理只在完成后返回最终输出。
+type StreamingSubAgent interface {
+	SubAgent
+
+	// ExecuteStream 执行任务并通过 progress 发送增量输出
+	// 实现方不应关闭 progress，返回后不应再向其发送
+	ExecuteStream(ctx context.Context, description string, parentContext map[string]any, progress chan<- string) (string, error)
+}
+
+// executeSubAgent 执行子代理，支持流式输出时将增量内容交给 onProgress
+func executeSubAgent(ctx context.Context, agent SubAgent, description string, parentContext map[string]any, onProgress func(chunk string)) (string, error) {
+	streaming, ok := agent.(StreamingSubAgent)
+	if !ok || onProgress == nil {
+		return agent.Execute(ctx, description, parentContext)
+	}
+
+	progress := make(chan string, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for chunk := range progress {
+			onProgress(chunk)
+		}
+	}()
+
+	output, err := streaming.ExecuteStream(ctx, description, parentContext, progress)
+	close(progress)
+	<-done
+	return output, err
+}
+
 // SubAgentMiddlewareConfig 子代理中间件配置
 type SubAgentMiddlewareConfig struct {
 	Specs                  []SubAgentSpec          // 子代理规格列表
 	Factory                SubAgentFactory         // 子代理工厂
-	Manager                builtin.SubagentManager // 子代理管理器（可选，默认使用 FileSubagentManager）
+	Manager                builtin.SubagentManager // 子代理管理器（可选，默认按模式选择进程或 goroutine 管理器）
 	EnableParallel         bool                    // 是否支持并行执行
 	EnableGeneralPurpose   bool                    // 是否启用通用子代理(默认 true)
 	EnableAsync            bool                    // 是否启用异步执行（默认 false）
 	EnableProcessIsolation bool                    // 是否启用进程级隔离（默认 false）
+	Process                *SubAgentProcessConfig  // 进程隔离配置（EnableProcessIsolation 时生效）
 	DefaultTimeout         time.Duration           // 默认超时时间（默认 1 小时）
+	TaskStore              store.Store             // 任务持久化存储（可选，重启后可继续查询和恢复任务）
 	ParentMiddlewareGetter func() []Middleware
 }
 
@@ -77,7 +114,15 @@ type SubAgentMiddleware struct {
 	enableAsync            bool
 	enableProcessIsolation bool
 	defaultTimeout         time.Duration
+	taskStore              store.Store
 	mu                     sync.RWMutex
+
+	// 进程隔离模式下子代理只在子进程中创建，父进程只保留名称
+	process   *processSubagentManager
+	specNames []string
+
+	// 启用管理器时包装为 manager，处理任务间依赖
+	scheduler *dependencyScheduler
 }
 
 // NewSubAgentMiddleware 创建子代理中间件
@@ -96,6 +141,11 @@ func NewSubAgentMiddleware(config *SubAgentMiddlewareConfig) (*SubAgentMiddlewar
 		enableAsync:            config.EnableAsync,
 		enableProcessIsolation: config.EnableProcessIsolation,
 		defaultTimeout:         defaultTimeout,
+		taskStore:              config.TaskStore,
+	}
+
+	if config.EnableProcessIsolation {
+		m.process = newProcessSubagentManager(m, config.Process)
 	}
 
 	// 创建或使用提供的管理器
@@ -103,30 +153,26 @@ func NewSubAgentMiddleware(config *SubAgentMiddlewareConfig) (*SubAgentMiddlewar
 	case config.Manager != nil:
 		m.manager = config.Manager
 	case config.EnableProcessIsolation:
-		m.manager = builtin.NewFileSubagentManager()
-		saLog.Info(context.Background(), "using FileSubagentManager", nil)
+		m.manager = m.process
+		saLog.Info(context.Background(), "using process-isolated manager", nil)
 	case config.EnableAsync:
 		m.manager = newGoroutineSubagentManager(m)
 		saLog.Info(context.Background(), "using in-memory async manager", nil)
 	}
+	if m.manager != nil {
+		m.scheduler = newDependencyScheduler(m, m.manager)
+		m.manager = m.scheduler
+	}
 
-	// 默认启用 general-purpose 子代理
-	specs := config.Specs
-	if config.EnableGeneralPurpose || (len(specs) == 0 && !config.EnableParallel) {
-		// 添加通用子代理规格
-		generalPurposeSpec := SubAgentSpec{
-			Name:        "general-purpose",
-			Description: "通用子代理,用于执行复杂、多步骤的隔离任务",
-			Prompt: `你是一个通用的 AI 助手,专注于执行复杂的、多步骤的任务。
-你有完整的工具集,可以独立完成被委托的任务。
-请仔细分析任务需求,制定计划并逐步执行。`,
-			InheritMiddlewares: true, // 继承父代理的中间件
+	specs := resolveSubAgentSpecs(config)
+	if m.process != nil {
+		for _, spec := range specs {
+			m.specNames = append(m.specNames, spec.Name)
 		}
-		specs = append([]SubAgentSpec{generalPurposeSpec}, specs...)
 	}
 
 	// 初始化子代理
-	if config.Factory != nil {
+	if config.Factory != nil && m.process == nil {
 		for _, spec := range specs {
 			agent, err := config.Factory(context.Background(), spec)
 			if err != nil {
@@ -138,10 +184,37 @@ func NewSubAgentMiddleware(config *SubAgentMiddlewareConfig) (*SubAgentMiddlewar
 		}
 	}
 
+	// 加载上次运行留下的任务
+	if m.taskStore != nil && m.manager != nil {
+		if err := m.restoreTasks(context.Background()); err != nil {
+			return nil, fmt.Errorf("restore subagent tasks: %w", err)
+		}
+	}
+
 	saLog.Info(context.Background(), "initialized", map[string]any{"subagents": len(m.agents)})
 	return m, nil
 }
 
+// resolveSubAgentSpecs 返回配置中的子代理规格，按需添加 general-purpose 子代理
+// 进程隔离模式下父子进程使用同一份配置解析，保证子代理名称一致
+func resolveSubAgentSpecs(config *SubAgentMiddlewareConfig) []SubAgentSpec {
+	// 默认启用 general-purpose 子代理
+	specs := config.Specs
+	if config.EnableGeneralPurpose || (len(specs) == 0 && !config.EnableParallel) {
+		// 添加通用子代理规格
+		generalPurposeSpec := SubAgentSpec{
+			Name:        "general-purpose",
+			Description: "通用子代理,用于执行复杂、多步骤的隔离任务",
+			Prompt: `你是一个通用的 AI 助手,专注于执行复杂的、多步骤的任务。
+你有完整的工具集,可以独立完成被委托的任务。
+请仔细分析任务需求,制定计划并逐步执行。`,
+			InheritMiddlewares: true, // 继承父代理的中间件
+		}
+		specs = append([]SubAgentSpec{generalPurposeSpec}, specs...)
+	}
+	return specs
+}
+
 // Tools 返回 task 工具和管理工具
 func (m *SubAgentMiddleware) Tools() []tools.Tool {
 	baseTools := []tools.Tool{
@@ -165,6 +238,10 @@ func (m *SubAgentMiddleware) Tools() []tools.Tool {
 
 // OnAgentStop 清理子代理
 func (m *SubAgentMiddleware) OnAgentStop(ctx context.Context, agentID string) error {
+	if m.process != nil {
+		m.process.stopAll()
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -192,6 +269,10 @@ func (m *SubAgentMiddleware) GetSubAgent(name string) (SubAgent, error) {
 
 // ListSubAgents 列出所有子代理
 func (m *SubAgentMiddleware) ListSubAgents() []string {
+	if m.process != nil {
+		return slices.Clone(m.specNames)
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -202,6 +283,11 @@ func (m *SubAgentMiddleware) ListSubAgents() []string {
 	return names
 }
 
+// hasSubAgent 是否存在指定名称的子代理
+func (m *SubAgentMiddleware) hasSubAgent(name string) bool {
+	return slices.Contains(m.ListSubAgents(), name)
+}
+
 // TaskTool task 工具实现
 type TaskTool struct {
 	middleware *SubAgentMiddleware
@@ -239,6 +325,15 @@ func (t *TaskTool) InputSchema() map[string]any {
 		"required": []string{"description", "subagent_type"},
 	}
 
+	// 启用管理器时支持任务依赖
+	if t.middleware.manager != nil {
+		schema["properties"].(map[string]any)["depends_on"] = map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": fmt.Sprintf("Task IDs that must complete before this task starts. Implies async; their outputs are passed in context.%s keyed by task_id.", DependencyOutputsKey),
+		}
+	}
+
 	// 如果启用异步执行，添加 async 参数
 	if t.middleware.enableAsync {
 		schema["properties"].(map[string]any)["async"] = map[string]any{
@@ -285,15 +380,57 @@ func (t *TaskTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 		timeout = time.Duration(timeoutVal) * time.Second
 	}
 
+	// 带依赖的任务总是在后台等待依赖完成后执行
+	dependsOn, err := parseDependsOnInput(input["depends_on"])
+	if err != nil {
+		return nil, err
+	}
+	if len(dependsOn) > 0 {
+		if t.middleware.scheduler == nil {
+			return nil, errors.New("depends_on requires async subagent execution to be enabled")
+		}
+		return t.executeAsync(ctx, subagentType, description, parentContext, timeout, dependsOn)
+	}
+
 	// 如果启用了管理器且请求异步执行
 	if t.middleware.manager != nil && async {
-		return t.executeAsync(ctx, subagentType, description, parentContext, timeout)
+		return t.executeAsync(ctx, subagentType, description, parentContext, timeout, nil)
+	}
+
+	// 进程隔离模式下同步任务同样在子进程中执行
+	if t.middleware.process != nil {
+		return t.executeProcess(ctx, subagentType, description, parentContext, timeout)
 	}
 
 	// 同步执行（原有逻辑）
 	return t.executeSync(ctx, subagentType, description, parentContext)
 }
 
+// executeProcess 在子进程中同步执行子代理
+func (t *TaskTool) executeProcess(ctx context.Context, subagentType, description string, parentContext map[string]any, timeout time.Duration) (any, error) {
+	saLog.Info(ctx, "delegating task to subagent process", map[string]any{"subagent": subagentType, "description": description})
+
+	result, err := t.middleware.process.run(ctx, &builtin.SubagentConfig{
+		Type:          subagentType,
+		Prompt:        description,
+		Timeout:       timeout,
+		ParentContext: parentContext,
+	})
+	if err != nil {
+		return map[string]any{
+			"ok":            false,
+			"error":         fmt.Sprintf("subagent execution failed: %v", err),
+			"subagent_type": subagentType,
+		}, nil
+	}
+
+	return map[string]any{
+		"ok":            true,
+		"subagent_type": subagentType,
+		"result":        result,
+	}, nil
+}
+
 // executeSync 同步执行子代理
 func (t *TaskTool) executeSync(ctx context.Context, subagentType, description string, parentContext map[string]any) (any, error) {
 	// 获取子代理
@@ -327,7 +464,7 @@ func (t *TaskTool) executeSync(ctx context.Context, subagentType, description st
 }
 
 // executeAsync 异步执行子代理
-func (t *TaskTool) executeAsync(ctx context.Context, subagentType, description string, parentContext map[string]any, timeout time.Duration) (any, error) {
+func (t *TaskTool) executeAsync(ctx context.Context, subagentType, description string, parentContext map[string]any, timeout time.Duration, dependsOn []string) (any, error) {
 	// 构建子代理配置
 	config := &builtin.SubagentConfig{
 		Type:          subagentType,
@@ -340,6 +477,28 @@ func (t *TaskTool) executeAsync(ctx context.Context, subagentType, description s
 		},
 	}
 
+	// 有依赖时等待依赖完成后再启动
+	if len(dependsOn) > 0 {
+		instance, err := t.middleware.scheduler.schedule(config, dependsOn)
+		if err != nil {
+			return map[string]any{
+				"ok":    false,
+				"error": fmt.Sprintf("failed to schedule subagent: %v", err),
+			}, nil
+		}
+
+		saLog.Info(ctx, "scheduled subagent with dependencies", map[string]any{"subagent": subagentType, "task_id": instance.ID, "depends_on": dependsOn})
+
+		return map[string]any{
+			"ok":            true,
+			"task_id":       instance.ID,
+			"subagent_type": subagentType,
+			"status":        instance.Status,
+			"depends_on":    dependsOn,
+			"message":       "SubAgent scheduled and will start after its dependencies complete. Use query_subagent to check status and get results.",
+		}, nil
+	}
+
 	// 启动子代理
 	instance, err := t.middleware.manager.StartSubagent(ctx, config)
 	if err != nil {
@@ -360,6 +519,29 @@ func (t *TaskTool) executeAsync(ctx context.Context, subagentType, description s
 	}, nil
 }
 
+// parseDependsOnInput 解析 depends_on 参数
+func parseDependsOnInput(value any) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("depends_on must be an array of task IDs")
+	}
+
+	dependsOn := make([]string, 0, len(items))
+	for _, item := range items {
+		taskID, ok := item.(string)
+		if !ok || taskID == "" || strings.Contains(taskID, ",") {
+			return nil, fmt.Errorf("invalid task ID in depends_on: %v", item)
+		}
+		if !slices.Contains(dependsOn, taskID) {
+			dependsOn = append(dependsOn, taskID)
+		}
+	}
+	return dependsOn, nil
+}
+
 func (t *TaskTool) Prompt() string {
 	// 获取可用的子代理列表
 	subagentTypes := t.middleware.ListSubAgents()
@@ -659,6 +841,11 @@ func (t *QuerySubagentTool) Execute(ctx context.Context, input map[string]any, t
 		response["output"] = instance.Output
 	}
 
+	// 运行中返回已缓冲的增量输出
+	if instance.Status == "running" && instance.PartialOutput != "" {
+		response["partial_output"] = instance.PartialOutput
+	}
+
 	// 添加错误信息（如果有）
 	if instance.Error != "" {
 		response["error"] = instance.Error
@@ -682,15 +869,18 @@ func (t *QuerySubagentTool) Prompt() string {
 使用场景：
 - 检查后台运行的子代理是否完成
 - 获取子代理的输出结果
+- 查看运行中子代理的阶段性进展（partial_output，仅支持流式输出的子代理）
 - 监控子代理的资源使用情况
 
 状态说明：
+- "pending": 等待依赖任务完成
 - "starting": 正在启动
 - "running": 正在运行
 - "completed": 已完成（成功）
 - "failed": 执行失败
 - "stopped": 已停止
 - "timeout": 超时
+- "interrupted": 进程重启时仍在运行，已中断（可通过 resume_subagent 重新执行）
 
 示例：
 query_subagent(task_id="subagent_1234567890")
@@ -79,26 +79,33 @@ func (gm *goroutineSubagentManager) StartSubagent(ctx context.Context, config *b
 	}
 	gm.instances[taskID] = instance
 	gm.cancels[taskID] = cancel
+	snapshot := cloneSubagentInstance(instance)
 	gm.mu.Unlock()
 
+	gm.middleware.persistTask(snapshot)
 	go gm.runSubagent(execCtx, taskID, subagent, &configCopy)
-	return instance, nil
+	return cloneSubagentInstance(snapshot), nil
 }
 
 func (gm *goroutineSubagentManager) ResumeSubagent(taskID string) (*builtin.SubagentInstance, error) {
 	gm.mu.RLock()
 	instance, exists := gm.instances[taskID]
+	var newConfig builtin.SubagentConfig
+	var status string
+	if exists {
+		newConfig = *instance.Config
+		status = instance.Status
+	}
 	gm.mu.RUnlock()
 
 	if !exists {
 		return nil, fmt.Errorf("subagent not found: %s", taskID)
 	}
 
-	if instance.Status == "running" {
-		return nil, fmt.Errorf("subagent cannot be resumed, current status: %s", instance.Status)
+	if status == "running" {
+		return nil, fmt.Errorf("subagent cannot be resumed, current status: %s", status)
 	}
 
-	newConfig := *instance.Config
 	newConfig.ID = ""
 
 	return gm.StartSubagent(context.Background(), &newConfig)
@@ -113,24 +120,20 @@ func (gm *goroutineSubagentManager) GetSubagent(taskID string) (*builtin.Subagen
 		return nil, fmt.Errorf("subagent not found: %s", taskID)
 	}
 
-	if instance.Status == "running" {
-		instance.Duration = time.Since(instance.StartTime)
-		instance.LastUpdate = time.Now()
-	}
-
-	return instance, nil
+	// 返回快照：运行中的实例会被执行协程并发更新
+	return cloneSubagentInstance(instance), nil
 }
 
 func (gm *goroutineSubagentManager) StopSubagent(taskID string) error {
 	gm.mu.Lock()
-	defer gm.mu.Unlock()
-
 	instance, exists := gm.instances[taskID]
 	if !exists {
+		gm.mu.Unlock()
 		return fmt.Errorf("subagent not found: %s", taskID)
 	}
 
 	if instance.Status != "running" {
+		gm.mu.Unlock()
 		return fmt.Errorf("subagent is not running, current status: %s", instance.Status)
 	}
 
@@ -144,7 +147,10 @@ func (gm *goroutineSubagentManager) StopSubagent(taskID string) error {
 	instance.Duration = now.Sub(instance.StartTime)
 	instance.LastUpdate = now
 	instance.Error = "subagent stopped by request"
+	snapshot := cloneSubagentInstance(instance)
+	gm.mu.Unlock()
 
+	gm.middleware.persistTask(snapshot)
 	return nil
 }
 
@@ -154,11 +160,7 @@ func (gm *goroutineSubagentManager) ListSubagents() ([]*builtin.SubagentInstance
 
 	list := make([]*builtin.SubagentInstance, 0, len(gm.instances))
 	for _, instance := range gm.instances {
-		if instance.Status == "running" {
-			instance.Duration = time.Since(instance.StartTime)
-			instance.LastUpdate = time.Now()
-		}
-		list = append(list, instance)
+		list = append(list, cloneSubagentInstance(instance))
 	}
 
 	return list, nil
@@ -185,6 +187,7 @@ func (gm *goroutineSubagentManager) CleanupSubagent(taskID string) error {
 		return fmt.Errorf("subagent not found: %s", taskID)
 	}
 	delete(gm.instances, taskID)
+	gm.middleware.forgetTask(taskID)
 	return nil
 }
 
@@ -195,14 +198,23 @@ func (gm *goroutineSubagentManager) runSubagent(ctx context.Context, taskID stri
 		gm.mu.Unlock()
 	}()
 
-	result, err := subagent.Execute(ctx, config.Prompt, config.ParentContext)
+	result, err := executeSubAgent(ctx, subagent, config.Prompt, config.ParentContext, func(chunk string) {
+		gm.appendPartialOutput(taskID, chunk)
+	})
 
+	if snapshot := gm.finishSubagent(ctx, taskID, result, err); snapshot != nil {
+		gm.middleware.persistTask(snapshot)
+	}
+}
+
+// finishSubagent 记录执行结果，返回需要持久化的实例快照
+func (gm *goroutineSubagentManager) finishSubagent(ctx context.Context, taskID, result string, err error) *builtin.SubagentInstance {
 	gm.mu.Lock()
 	defer gm.mu.Unlock()
 
 	instance, exists := gm.instances[taskID]
 	if !exists {
-		return
+		return nil
 	}
 
 	now := time.Now()
@@ -211,22 +223,43 @@ func (gm *goroutineSubagentManager) runSubagent(ctx context.Context, taskID stri
 	instance.EndTime = &now
 
 	if instance.Status == "stopped" {
-		return
+		return nil
 	}
 
-	if ctx.Err() == context.DeadlineExceeded {
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
 		instance.Status = "failed"
 		instance.Error = "subagent timeout"
-		return
-	}
-
-	if err != nil {
+	case err != nil:
 		instance.Status = "failed"
 		instance.Error = err.Error()
-		return
+	default:
+		instance.Status = "completed"
+		instance.Output = result
+		instance.ExitCode = 0
+	}
+	return cloneSubagentInstance(instance)
+}
+
+// appendPartialOutput 追加运行中子代理的增量输出
+func (gm *goroutineSubagentManager) appendPartialOutput(taskID, chunk string) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if instance, exists := gm.instances[taskID]; exists && instance.Status == "running" {
+		instance.PartialOutput += chunk
+		instance.LastUpdate = time.Now()
 	}
+}
 
-	instance.Status = "completed"
-	instance.Output = result
-	instance.ExitCode = 0
+// restoreSubagents 加载持久化的任务，已存在的任务不会被覆盖
+func (gm *goroutineSubagentManager) restoreSubagents(instances []*builtin.SubagentInstance) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	for _, instance := range instances {
+		if _, exists := gm.instances[instance.ID]; !exists {
+			gm.instances[instance.ID] = instance
+		}
+	}
 }
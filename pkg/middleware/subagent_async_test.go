@@ -372,3 +372,101 @@ func TestSubAgentMiddleware_SyncVsAsync(t *testing.T) {
 		assert.Less(t, duration.Milliseconds(), int64(100))
 	})
 }
+
+// streamingTestAgent 先发送增量输出，再等待放行或取消
+type streamingTestAgent struct {
+	*SimpleSubAgent
+	chunks  []string
+	release <-chan struct{}
+}
+
+func (a *streamingTestAgent) ExecuteStream(ctx context.Context, description string, parentContext map[string]any, progress chan<- string) (string, error) {
+	for _, chunk := range a.chunks {
+		progress <- chunk
+	}
+	select {
+	case <-a.release:
+		return "done: " + description, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// waitForPartialOutput 轮询直到运行中的子代理返回期望的增量输出
+func waitForPartialOutput(t *testing.T, mw *SubAgentMiddleware, taskID, expected string) {
+	t.Helper()
+	query := getTool[*QuerySubagentTool](t, mw, "query_subagent")
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		result, err := query.Execute(context.Background(), map[string]any{"task_id": taskID}, nil)
+		require.NoError(t, err)
+		data := result.(map[string]any)
+		require.Equal(t, "running", data["status"])
+		if data["partial_output"] == expected {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("subagent %s did not report partial output %q", taskID, expected)
+}
+
+// TestSubAgentMiddleware_PartialOutput 测试运行中返回增量输出
+func TestSubAgentMiddleware_PartialOutput(t *testing.T) {
+	release := make(chan struct{})
+	mw, err := NewSubAgentMiddleware(&SubAgentMiddlewareConfig{
+		Specs: []SubAgentSpec{{Name: "streamer"}, {Name: "plain"}},
+		Factory: func(ctx context.Context, spec SubAgentSpec) (SubAgent, error) {
+			if spec.Name == "plain" {
+				return NewSimpleSubAgent(spec.Name, "", func(ctx context.Context, description string, parentContext map[string]any) (string, error) {
+					<-release
+					return "plain done", nil
+				}), nil
+			}
+			return &streamingTestAgent{
+				SimpleSubAgent: NewSimpleSubAgent(spec.Name, "", nil),
+				chunks:         []string{"step 1\n", "step 2\n"},
+				release:        release,
+			}, nil
+		},
+		EnableAsync: true,
+	})
+	require.NoError(t, err)
+
+	taskTool := getTool[*TaskTool](t, mw, "task")
+	queryTool := getTool[*QuerySubagentTool](t, mw, "query_subagent")
+
+	start := func(subagentType string) string {
+		result, err := taskTool.Execute(context.Background(), map[string]any{
+			"description":   "research",
+			"subagent_type": subagentType,
+			"async":         true,
+		}, nil)
+		require.NoError(t, err)
+		return result.(map[string]any)["task_id"].(string)
+	}
+	streamID := start("streamer")
+	plainID := start("plain")
+
+	waitForPartialOutput(t, mw, streamID, "step 1\nstep 2\n")
+
+	// 不支持流式输出的子代理运行中没有 partial_output
+	result, err := queryTool.Execute(context.Background(), map[string]any{"task_id": plainID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "running", result.(map[string]any)["status"])
+	assert.NotContains(t, result.(map[string]any), "partial_output")
+
+	close(release)
+	for _, taskID := range []string{streamID, plainID} {
+		require.Eventually(t, func() bool {
+			instance, err := mw.manager.GetSubagent(taskID)
+			return err == nil && instance.Status == "completed"
+		}, 5*time.Second, 20*time.Millisecond)
+	}
+
+	// 完成后只返回最终输出
+	result, err = queryTool.Execute(context.Background(), map[string]any{"task_id": streamID}, nil)
+	require.NoError(t, err)
+	data := result.(map[string]any)
+	assert.Equal(t, "done: research", data["output"])
+	assert.NotContains(t, data, "partial_output")
+}
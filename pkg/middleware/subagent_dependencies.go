@@ -0,0 +1,366 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools/builtin"
+)
+
+// DependencyOutputsKey 依赖任务输出在子代理 parentContext 中的键，值为 task_id 到输出的映射
+const DependencyOutputsKey = "dependency_outputs"
+
+// subagentDependsOnKey 依赖任务 ID 在 SubagentConfig.Metadata 中的键（逗号分隔）
+const subagentDependsOnKey = "depends_on"
+
+// dependencyPollInterval 等待依赖任务时的轮询间隔
+const dependencyPollInterval = 50 * time.Millisecond
+
+// scheduledSubagent 等待依赖完成的任务
+type scheduledSubagent struct {
+	instance *builtin.SubagentInstance
+	cancel   context.CancelFunc
+}
+
+// dependencyScheduler 在 builtin.SubagentManager 之上支持任务依赖
+// 带依赖的任务先处于 pending 状态，所有依赖完成后注入依赖输出并交给底层管理器执行；
+// 任一依赖未成功完成时任务直接失败。其余操作透传给底层管理器。
+type dependencyScheduler struct {
+	middleware *SubAgentMiddleware
+	inner      builtin.SubagentManager
+
+	mu      sync.RWMutex
+	pending map[string]*scheduledSubagent
+}
+
+func newDependencyScheduler(mw *SubAgentMiddleware, inner builtin.SubagentManager) *dependencyScheduler {
+	return &dependencyScheduler{
+		middleware: mw,
+		inner:      inner,
+		pending:    make(map[string]*scheduledSubagent),
+	}
+}
+
+// schedule 登记带依赖的任务，依赖不存在或形成环时拒绝
+func (s *dependencyScheduler) schedule(config *builtin.SubagentConfig, dependsOn []string) (*builtin.SubagentInstance, error) {
+	if config == nil {
+		return nil, errors.New("subagent config cannot be nil")
+	}
+	if len(dependsOn) == 0 {
+		return s.inner.StartSubagent(context.Background(), config)
+	}
+
+	taskID := config.ID
+	if taskID == "" {
+		taskID = fmt.Sprintf("subagent_%d", time.Now().UnixNano())
+	}
+	for _, dep := range dependsOn {
+		if dep != taskID {
+			if _, err := s.GetSubagent(dep); err != nil {
+				return nil, fmt.Errorf("dependency not found: %s", dep)
+			}
+		}
+	}
+	if cycle := s.findCycle(taskID, dependsOn); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+
+	configCopy := *config
+	configCopy.ID = taskID
+	configCopy.Metadata = maps.Clone(config.Metadata)
+	if configCopy.Metadata == nil {
+		configCopy.Metadata = make(map[string]string)
+	}
+	configCopy.Metadata[subagentDependsOnKey] = strings.Join(dependsOn, ",")
+
+	now := time.Now()
+	instance := &builtin.SubagentInstance{
+		ID:         taskID,
+		Type:       configCopy.Type,
+		Status:     "pending",
+		Command:    "pending",
+		Config:     &configCopy,
+		StartTime:  now,
+		Metadata:   maps.Clone(configCopy.Metadata),
+		LastUpdate: now,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	if _, exists := s.pending[taskID]; exists {
+		s.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("subagent already exists: %s", taskID)
+	}
+	s.pending[taskID] = &scheduledSubagent{instance: instance, cancel: cancel}
+	snapshot := cloneSubagentInstance(instance)
+	s.mu.Unlock()
+
+	s.middleware.persistTask(snapshot)
+	go s.waitForDependencies(ctx, taskID, &configCopy, dependsOn)
+	return snapshot, nil
+}
+
+// findCycle 检查新任务依赖 dependsOn 后是否形成环，返回环上的任务 ID
+func (s *dependencyScheduler) findCycle(taskID string, dependsOn []string) []string {
+	visited := make(map[string]bool)
+	var visit func(id string, path []string) []string
+	visit = func(id string, path []string) []string {
+		path = append(path, id)
+		if id == taskID {
+			return path
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		for _, dep := range s.dependenciesOf(id) {
+			if cycle := visit(dep, path); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	for _, dep := range dependsOn {
+		if cycle := visit(dep, []string{taskID}); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// dependenciesOf 返回任务声明的依赖
+func (s *dependencyScheduler) dependenciesOf(taskID string) []string {
+	instance, err := s.GetSubagent(taskID)
+	if err != nil || instance.Config == nil {
+		return nil
+	}
+	return parseDependsOn(instance.Config.Metadata[subagentDependsOnKey])
+}
+
+// waitForDependencies 等待依赖完成后启动任务
+func (s *dependencyScheduler) waitForDependencies(ctx context.Context, taskID string, config *builtin.SubagentConfig, dependsOn []string) {
+	ticker := time.NewTicker(dependencyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		outputs, ready, err := s.collectDependencyOutputs(dependsOn)
+		if err != nil {
+			s.fail(taskID, err.Error())
+			return
+		}
+		if ready {
+			s.start(taskID, config, outputs)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectDependencyOutputs 检查依赖状态，全部完成时返回各依赖的输出
+func (s *dependencyScheduler) collectDependencyOutputs(dependsOn []string) (map[string]any, bool, error) {
+	outputs := make(map[string]any, len(dependsOn))
+	for _, dep := range dependsOn {
+		instance, err := s.GetSubagent(dep)
+		if err != nil {
+			return nil, false, fmt.Errorf("dependency not found: %s", dep)
+		}
+		switch instance.Status {
+		case "completed":
+			outputs[dep] = instance.Output
+		case "pending", "starting", "running":
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("dependency %s did not complete, status: %s", dep, instance.Status)
+		}
+	}
+	return outputs, true, nil
+}
+
+// start 注入依赖输出并交给底层管理器执行
+func (s *dependencyScheduler) start(taskID string, config *builtin.SubagentConfig, outputs map[string]any) {
+	s.mu.Lock()
+	entry, exists := s.pending[taskID]
+	if !exists || entry.instance.Status != "pending" {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	configCopy := *config
+	configCopy.ParentContext = maps.Clone(config.ParentContext)
+	if configCopy.ParentContext == nil {
+		configCopy.ParentContext = make(map[string]any)
+	}
+	configCopy.ParentContext[DependencyOutputsKey] = outputs
+
+	if _, err := s.inner.StartSubagent(context.Background(), &configCopy); err != nil {
+		s.fail(taskID, fmt.Sprintf("failed to start subagent: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	stopped := s.pending[taskID] != nil && s.pending[taskID].instance.Status != "pending"
+	delete(s.pending, taskID)
+	s.mu.Unlock()
+
+	// 启动期间被停止
+	if stopped {
+		_ = s.inner.StopSubagent(taskID)
+		return
+	}
+	saLog.Info(context.Background(), "started subagent after dependencies", map[string]any{"task_id": taskID})
+}
+
+// fail 将等待中的任务标记为失败
+func (s *dependencyScheduler) fail(taskID, reason string) {
+	s.mu.Lock()
+	entry, exists := s.pending[taskID]
+	if !exists || entry.instance.Status != "pending" {
+		s.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	instance := entry.instance
+	instance.Status = "failed"
+	instance.Error = reason
+	instance.EndTime = &now
+	instance.Duration = now.Sub(instance.StartTime)
+	instance.LastUpdate = now
+	snapshot := cloneSubagentInstance(instance)
+	s.mu.Unlock()
+
+	s.middleware.persistTask(snapshot)
+}
+
+func (s *dependencyScheduler) StartSubagent(ctx context.Context, config *builtin.SubagentConfig) (*builtin.SubagentInstance, error) {
+	return s.inner.StartSubagent(ctx, config)
+}
+
+// ResumeSubagent 带依赖的任务重新等待依赖，其余任务由底层管理器恢复
+func (s *dependencyScheduler) ResumeSubagent(taskID string) (*builtin.SubagentInstance, error) {
+	instance, err := s.GetSubagent(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Config == nil || instance.Config.Metadata[subagentDependsOnKey] == "" {
+		return s.inner.ResumeSubagent(taskID)
+	}
+	if instance.Status == "pending" || instance.Status == "running" {
+		return nil, fmt.Errorf("subagent cannot be resumed, current status: %s", instance.Status)
+	}
+
+	config := *instance.Config
+	config.ID = ""
+	config.ParentContext = maps.Clone(config.ParentContext)
+	delete(config.ParentContext, DependencyOutputsKey)
+	return s.schedule(&config, parseDependsOn(config.Metadata[subagentDependsOnKey]))
+}
+
+func (s *dependencyScheduler) GetSubagent(taskID string) (*builtin.SubagentInstance, error) {
+	s.mu.RLock()
+	entry, exists := s.pending[taskID]
+	var instance *builtin.SubagentInstance
+	if exists {
+		instance = cloneSubagentInstance(entry.instance)
+		if instance.Status == "pending" {
+			instance.Duration = time.Since(instance.StartTime)
+		}
+	}
+	s.mu.RUnlock()
+
+	if exists {
+		return instance, nil
+	}
+	return s.inner.GetSubagent(taskID)
+}
+
+func (s *dependencyScheduler) StopSubagent(taskID string) error {
+	s.mu.Lock()
+	entry, exists := s.pending[taskID]
+	if !exists {
+		s.mu.Unlock()
+		return s.inner.StopSubagent(taskID)
+	}
+	if entry.instance.Status != "pending" {
+		s.mu.Unlock()
+		return fmt.Errorf("subagent is not running, current status: %s", entry.instance.Status)
+	}
+
+	entry.cancel()
+	now := time.Now()
+	instance := entry.instance
+	instance.Status = "stopped"
+	instance.Error = "subagent stopped by request"
+	instance.EndTime = &now
+	instance.Duration = now.Sub(instance.StartTime)
+	instance.LastUpdate = now
+	snapshot := cloneSubagentInstance(instance)
+	s.mu.Unlock()
+
+	s.middleware.persistTask(snapshot)
+	return nil
+}
+
+func (s *dependencyScheduler) ListSubagents() ([]*builtin.SubagentInstance, error) {
+	list, err := s.inner.ListSubagents()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, entry := range s.pending {
+		// 刚交给底层管理器的任务已在列表中
+		if slices.ContainsFunc(list, func(instance *builtin.SubagentInstance) bool { return instance.ID == id }) {
+			continue
+		}
+		list = append(list, cloneSubagentInstance(entry.instance))
+	}
+	return list, nil
+}
+
+func (s *dependencyScheduler) GetSubagentOutput(taskID string) (string, error) {
+	instance, err := s.GetSubagent(taskID)
+	if err != nil {
+		return "", err
+	}
+	return instance.Output, nil
+}
+
+func (s *dependencyScheduler) CleanupSubagent(taskID string) error {
+	s.mu.Lock()
+	entry, exists := s.pending[taskID]
+	if exists {
+		entry.cancel()
+		delete(s.pending, taskID)
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return s.inner.CleanupSubagent(taskID)
+	}
+	s.middleware.forgetTask(taskID)
+	return nil
+}
+
+// parseDependsOn 解析逗号分隔的依赖任务 ID
+func parseDependsOn(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
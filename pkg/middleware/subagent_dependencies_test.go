@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools/builtin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDependencyTestMiddleware(t *testing.T, release <-chan struct{}) *SubAgentMiddleware {
+	t.Helper()
+	mw, err := NewSubAgentMiddleware(&SubAgentMiddlewareConfig{
+		Specs: []SubAgentSpec{{Name: "research"}, {Name: "write"}, {Name: "broken"}},
+		Factory: func(ctx context.Context, spec SubAgentSpec) (SubAgent, error) {
+			return NewSimpleSubAgent(spec.Name, "", func(ctx context.Context, description string, parentContext map[string]any) (string, error) {
+				switch spec.Name {
+				case "research":
+					select {
+					case <-release:
+					case <-ctx.Done():
+						return "", ctx.Err()
+					}
+					return "facts about " + description, nil
+				case "broken":
+					return "", errors.New("research failed")
+				}
+				return fmt.Sprintf("article using %v", parentContext[DependencyOutputsKey]), nil
+			}), nil
+		},
+		EnableAsync: true,
+	})
+	require.NoError(t, err)
+	return mw
+}
+
+func runTask(t *testing.T, mw *SubAgentMiddleware, input map[string]any) map[string]any {
+	t.Helper()
+	result, err := getTool[*TaskTool](t, mw, "task").Execute(context.Background(), input, nil)
+	require.NoError(t, err)
+	return result.(map[string]any)
+}
+
+func TestSubAgentMiddleware_DependsOn(t *testing.T) {
+	release := make(chan struct{})
+	mw := newDependencyTestMiddleware(t, release)
+
+	research := runTask(t, mw, map[string]any{"description": "go", "subagent_type": "research", "async": true})
+	researchID := research["task_id"].(string)
+
+	// depends_on 隐含异步执行
+	write := runTask(t, mw, map[string]any{"description": "blog", "subagent_type": "write", "depends_on": []any{researchID}})
+	require.True(t, write["ok"].(bool), "schedule failed: %v", write["error"])
+	assert.Equal(t, "pending", write["status"])
+	writeID := write["task_id"].(string)
+
+	instance, err := mw.manager.GetSubagent(writeID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", instance.Status)
+
+	list, err := mw.manager.ListSubagents()
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	close(release)
+	status := waitForStatus(t, mw, writeID)
+	assert.Equal(t, "completed", status["status"])
+	assert.Equal(t, fmt.Sprintf("article using map[%s:facts about go]", researchID), status["output"])
+}
+
+func TestSubAgentMiddleware_DependsOnFailure(t *testing.T) {
+	release := make(chan struct{})
+	mw := newDependencyTestMiddleware(t, release)
+
+	broken := runTask(t, mw, map[string]any{"description": "go", "subagent_type": "broken", "async": true})
+	write := runTask(t, mw, map[string]any{"description": "blog", "subagent_type": "write", "depends_on": []any{broken["task_id"]}})
+	require.True(t, write["ok"].(bool))
+
+	status := waitForStatus(t, mw, write["task_id"].(string))
+	assert.Equal(t, "failed", status["status"])
+	assert.Contains(t, status["error"], "did not complete, status: failed")
+
+	// 等待中的任务可以被停止，依赖完成后也不会再启动
+	research := runTask(t, mw, map[string]any{"description": "go", "subagent_type": "research", "async": true})
+	pending := runTask(t, mw, map[string]any{"description": "blog", "subagent_type": "write", "depends_on": []any{research["task_id"]}})
+	pendingID := pending["task_id"].(string)
+	require.NoError(t, mw.manager.StopSubagent(pendingID))
+	close(release)
+
+	waitForStatus(t, mw, research["task_id"].(string))
+	time.Sleep(3 * dependencyPollInterval)
+	instance, err := mw.manager.GetSubagent(pendingID)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", instance.Status)
+}
+
+func TestSubAgentMiddleware_DependsOnValidation(t *testing.T) {
+	mw := newDependencyTestMiddleware(t, nil)
+
+	result := runTask(t, mw, map[string]any{"description": "blog", "subagent_type": "write", "depends_on": []any{"missing"}})
+	assert.False(t, result["ok"].(bool))
+	assert.Contains(t, result["error"], "dependency not found: missing")
+
+	_, err := getTool[*TaskTool](t, mw, "task").Execute(context.Background(), map[string]any{
+		"description": "blog", "subagent_type": "write", "depends_on": "missing",
+	}, nil)
+	require.Error(t, err)
+
+	// 自依赖
+	_, err = mw.scheduler.schedule(&builtin.SubagentConfig{ID: "a", Type: "write"}, []string{"a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected: a -> a")
+
+	// b 依赖 a，而 a 已声明依赖 b
+	mw.scheduler.pending["a"] = &scheduledSubagent{
+		instance: &builtin.SubagentInstance{
+			ID:     "a",
+			Status: "pending",
+			Config: &builtin.SubagentConfig{ID: "a", Metadata: map[string]string{subagentDependsOnKey: "b"}},
+		},
+		cancel: func() {},
+	}
+	_, err = mw.scheduler.schedule(&builtin.SubagentConfig{ID: "b", Type: "write"}, []string{"a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected: b -> a -> b")
+
+	// 未启用异步时不支持 depends_on
+	syncMW, err := NewSubAgentMiddleware(&SubAgentMiddlewareConfig{
+		Specs: []SubAgentSpec{{Name: "write"}},
+		Factory: func(ctx context.Context, spec SubAgentSpec) (SubAgent, error) {
+			return NewSimpleSubAgent(spec.Name, "", nil), nil
+		},
+	})
+	require.NoError(t, err)
+	_, err = getTool[*TaskTool](t, syncMW, "task").Execute(context.Background(), map[string]any{
+		"description": "blog", "subagent_type": "write", "depends_on": []any{"a"},
+	}, nil)
+	require.Error(t, err)
+}
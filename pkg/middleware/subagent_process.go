@@ -0,0 +1,679 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/tools/builtin"
+)
+
+// SubAgentProcessEnv 子代理进程标识，父进程启动子进程时设置为 "1"
+const SubAgentProcessEnv = "ASTER_SUBAGENT_PROCESS"
+
+// subAgentResultKind 标识 stdout 上的结果行，其余输出（如日志）会被忽略
+const subAgentResultKind = "aster_subagent_result"
+
+// subAgentProgressKind 标识 stdout 上的增量输出行
+const subAgentProgressKind = "aster_subagent_progress"
+
+// stderrTailSize 失败时附带的 stderr 尾部长度
+const stderrTailSize = 4096
+
+// maxResultLineSize 子进程 stdout 单行的最大长度，超出的行被丢弃
+const maxResultLineSize = 8 << 20
+
+// SubAgentProcessConfig 进程隔离模式配置
+type SubAgentProcessConfig struct {
+	// Command 子进程可执行文件，默认为当前可执行文件
+	Command string
+
+	// Args 子进程启动参数
+	Args []string
+
+	// Env 额外的环境变量
+	Env map[string]string
+
+	// WorkDir 子进程工作目录，默认继承父进程
+	WorkDir string
+
+	// Limits 子进程资源限制（内存、CPU 时间等），为空时不限制
+	Limits *sandbox.ResourceLimits
+
+	// StopTimeout 停止时等待子进程退出的时间，超时后强制结束（默认 5 秒）
+	StopTimeout time.Duration
+}
+
+// subAgentProcessRequest 父进程通过 stdin 发送的任务
+type subAgentProcessRequest struct {
+	TaskID        string         `json:"task_id"`
+	Type          string         `json:"subagent_type"`
+	Description   string         `json:"description"`
+	ParentContext map[string]any `json:"parent_context,omitempty"`
+}
+
+// subAgentProcessResult 子进程通过 stdout 返回的结果或增量输出
+type subAgentProcessResult struct {
+	Kind   string `json:"kind"`
+	TaskID string `json:"task_id"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// IsSubAgentProcess 当前进程是否为进程隔离模式启动的子代理进程
+func IsSubAgentProcess() bool {
+	return os.Getenv(SubAgentProcessEnv) == "1"
+}
+
+// RunSubAgentProcess 在子进程中执行父进程委派的任务
+// 从 stdin 读取一个任务，执行后将结果写入 stdout；stdin 被关闭时取消执行。
+// config 应与父进程使用相同的 Specs 和 Factory，通常在 main 函数开头调用：
+//
+//	if middleware.IsSubAgentProcess() {
+//	    if err := middleware.RunSubAgentProcess(ctx, config); err != nil {
+//	        os.Exit(1)
+//	    }
+//	    os.Exit(0)
+//	}
+func RunSubAgentProcess(ctx context.Context, config *SubAgentMiddlewareConfig) error {
+	return serveSubAgentProcess(ctx, config, os.Stdin, os.Stdout)
+}
+
+func serveSubAgentProcess(ctx context.Context, config *SubAgentMiddlewareConfig, r io.Reader, w io.Writer) error {
+	if config == nil || config.Factory == nil {
+		return errors.New("subagent factory is required")
+	}
+
+	reader := bufio.NewReader(r)
+	line, err := reader.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return fmt.Errorf("read subagent request: %w", err)
+	}
+	var req subAgentProcessRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("decode subagent request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 父进程关闭 stdin 表示停止
+	go func() {
+		_, _ = io.Copy(io.Discard, reader)
+		cancel()
+	}()
+
+	// 增量输出在最终结果之前写出
+	output, execErr := executeSubAgentRequest(ctx, config, &req, func(chunk string) {
+		_ = writeSubAgentProcessResult(w, &subAgentProcessResult{Kind: subAgentProgressKind, TaskID: req.TaskID, Output: chunk})
+	})
+	result := subAgentProcessResult{Kind: subAgentResultKind, TaskID: req.TaskID, Output: output}
+	if execErr != nil {
+		result.Error = execErr.Error()
+	}
+
+	if err := writeSubAgentProcessResult(w, &result); err != nil {
+		return err
+	}
+	return execErr
+}
+
+// writeSubAgentProcessResult 向 stdout 写入一行结果
+func writeSubAgentProcessResult(w io.Writer, result *subAgentProcessResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encode subagent result: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write subagent result: %w", err)
+	}
+	return nil
+}
+
+// executeSubAgentRequest 按名称创建子代理并执行任务
+func executeSubAgentRequest(ctx context.Context, config *SubAgentMiddlewareConfig, req *subAgentProcessRequest, onProgress func(chunk string)) (string, error) {
+	for _, spec := range resolveSubAgentSpecs(config) {
+		if spec.Name != req.Type {
+			continue
+		}
+		agent, err := config.Factory(ctx, spec)
+		if err != nil {
+			return "", fmt.Errorf("create subagent: %w", err)
+		}
+		defer func() { _ = agent.Close() }()
+		return executeSubAgent(ctx, agent, req.Description, req.ParentContext, onProgress)
+	}
+	return "", fmt.Errorf("subagent not found: %s", req.Type)
+}
+
+// subAgentProcess 运行中的子代理进程
+type subAgentProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *resultWriter
+	stderr *tailBuffer
+	done   chan struct{}
+}
+
+// processSubagentManager 以独立进程运行子代理的 builtin.SubagentManager 实现
+// 子进程崩溃或失控不会影响父进程，停止时先关闭 stdin 通知退出，超时后强制结束。
+type processSubagentManager struct {
+	middleware *SubAgentMiddleware
+	config     SubAgentProcessConfig
+
+	mu        sync.RWMutex
+	instances map[string]*builtin.SubagentInstance
+	procs     map[string]*subAgentProcess
+	// pending 正在启动、尚未注册的任务，值为注册前收到的增量输出
+	pending map[string]string
+}
+
+func newProcessSubagentManager(mw *SubAgentMiddleware, config *SubAgentProcessConfig) *processSubagentManager {
+	pm := &processSubagentManager{
+		middleware: mw,
+		instances:  make(map[string]*builtin.SubagentInstance),
+		procs:      make(map[string]*subAgentProcess),
+		pending:    make(map[string]string),
+	}
+	if config != nil {
+		pm.config = *config
+	}
+	if pm.config.StopTimeout <= 0 {
+		pm.config.StopTimeout = 5 * time.Second
+	}
+	return pm
+}
+
+func (pm *processSubagentManager) StartSubagent(ctx context.Context, config *builtin.SubagentConfig) (*builtin.SubagentInstance, error) {
+	if config == nil {
+		return nil, errors.New("subagent config cannot be nil")
+	}
+	if !pm.middleware.hasSubAgent(config.Type) {
+		return nil, fmt.Errorf("subagent not found: %s", config.Type)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	taskID := config.ID
+	if taskID == "" {
+		taskID = fmt.Sprintf("subagent_%d", time.Now().UnixNano())
+	}
+	configCopy := *config
+	configCopy.ID = taskID
+
+	// 启动进程和发送任务可能阻塞，只在锁内预留任务 ID
+	pm.mu.Lock()
+	_, pending := pm.pending[taskID]
+	if _, exists := pm.instances[taskID]; exists || pending {
+		pm.mu.Unlock()
+		return nil, fmt.Errorf("subagent already exists: %s", taskID)
+	}
+	pm.pending[taskID] = ""
+	pm.mu.Unlock()
+
+	proc, err := pm.spawn(&configCopy)
+
+	pm.mu.Lock()
+	partialOutput := pm.pending[taskID]
+	delete(pm.pending, taskID)
+	if err != nil {
+		pm.mu.Unlock()
+		return nil, err
+	}
+
+	now := time.Now()
+	instance := &builtin.SubagentInstance{
+		ID:            taskID,
+		Type:          configCopy.Type,
+		Status:        "running",
+		PID:           proc.cmd.Process.Pid,
+		Command:       proc.cmd.String(),
+		Config:        &configCopy,
+		StartTime:     now,
+		Metadata:      make(map[string]string),
+		PartialOutput: partialOutput,
+		LastUpdate:    now,
+	}
+	maps.Copy(instance.Metadata, configCopy.Metadata)
+	pm.instances[taskID] = instance
+	pm.procs[taskID] = proc
+	snapshot := cloneSubagentInstance(instance)
+	pm.mu.Unlock()
+
+	pm.middleware.persistTask(snapshot)
+	go pm.wait(taskID, proc)
+	go pm.watch(ctx, taskID, proc, configCopy.Timeout)
+
+	return cloneSubagentInstance(instance), nil
+}
+
+// spawn 启动子进程并发送任务
+func (pm *processSubagentManager) spawn(config *builtin.SubagentConfig) (*subAgentProcess, error) {
+	command := pm.config.Command
+	if command == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("resolve subagent executable: %w", err)
+		}
+		command = exe
+	}
+	name, args := pm.config.Limits.LimitCommand(command, pm.config.Args...)
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = pm.config.WorkDir
+	if config.WorkDir != "" {
+		cmd.Dir = config.WorkDir
+	}
+	cmd.Env = append(os.Environ(), SubAgentProcessEnv+"=1")
+	for k, v := range pm.config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	proc := &subAgentProcess{
+		cmd:    cmd,
+		stdout: &resultWriter{onProgress: func(chunk string) { pm.appendPartialOutput(config.ID, chunk) }},
+		stderr: &tailBuffer{max: stderrTailSize},
+		done:   make(chan struct{}),
+	}
+	cmd.Stdout = proc.stdout
+	cmd.Stderr = proc.stderr
+	// 进程退出后孙进程仍占用输出管道时，最多再等待 StopTimeout
+	cmd.WaitDelay = pm.config.StopTimeout
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create subagent stdin: %w", err)
+	}
+	proc.stdin = stdin
+
+	request, err := json.Marshal(subAgentProcessRequest{
+		TaskID:        config.ID,
+		Type:          config.Type,
+		Description:   config.Prompt,
+		ParentContext: config.ParentContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode subagent request: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start subagent process: %w", err)
+	}
+	if _, err := stdin.Write(append(request, '\n')); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("send subagent request: %w", err)
+	}
+	return proc, nil
+}
+
+// wait 等待子进程退出并记录结果
+func (pm *processSubagentManager) wait(taskID string, proc *subAgentProcess) {
+	defer close(proc.done)
+	waitErr := proc.cmd.Wait()
+
+	if snapshot := pm.finish(taskID, proc, waitErr); snapshot != nil {
+		pm.middleware.persistTask(snapshot)
+	}
+}
+
+// finish 记录子进程退出结果，返回需要持久化的实例快照
+func (pm *processSubagentManager) finish(taskID string, proc *subAgentProcess, waitErr error) *builtin.SubagentInstance {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	instance, exists := pm.instances[taskID]
+	if !exists {
+		return nil
+	}
+
+	now := time.Now()
+	instance.LastUpdate = now
+	instance.ExitCode = proc.cmd.ProcessState.ExitCode()
+	if instance.EndTime == nil {
+		instance.EndTime = &now
+		instance.Duration = now.Sub(instance.StartTime)
+	}
+
+	// 已被停止或超时，退出码等信息随快照一起持久化
+	if instance.Status != "running" {
+		return cloneSubagentInstance(instance)
+	}
+
+	result := proc.stdout.result
+	switch {
+	case result == nil:
+		instance.Status = "failed"
+		instance.Error = processExitError(waitErr, proc.stderr.String())
+		if proc.stdout.dropped > 0 {
+			instance.Error += fmt.Sprintf(" (discarded %d stdout lines over %d bytes)", proc.stdout.dropped, maxResultLineSize)
+		}
+	case result.Error != "":
+		instance.Status = "failed"
+		instance.Error = result.Error
+		instance.Output = result.Output
+	default:
+		instance.Status = "completed"
+		instance.Output = result.Output
+	}
+	return cloneSubagentInstance(instance)
+}
+
+// watch 在超时或上下文取消时结束子进程
+func (pm *processSubagentManager) watch(ctx context.Context, taskID string, proc *subAgentProcess, timeout time.Duration) {
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case <-proc.done:
+	case <-timeoutC:
+		_ = pm.terminate(taskID, "failed", "subagent timeout")
+	case <-ctx.Done():
+		_ = pm.terminate(taskID, "failed", "subagent cancelled: "+ctx.Err().Error())
+	}
+}
+
+// terminate 标记状态并结束子进程，返回时子进程已退出
+func (pm *processSubagentManager) terminate(taskID, status, reason string) error {
+	pm.mu.Lock()
+	instance, exists := pm.instances[taskID]
+	if !exists {
+		pm.mu.Unlock()
+		return fmt.Errorf("subagent not found: %s", taskID)
+	}
+	if instance.Status != "running" {
+		pm.mu.Unlock()
+		return fmt.Errorf("subagent is not running, current status: %s", instance.Status)
+	}
+
+	now := time.Now()
+	instance.Status = status
+	instance.Error = reason
+	instance.EndTime = &now
+	instance.Duration = now.Sub(instance.StartTime)
+	instance.LastUpdate = now
+	proc := pm.procs[taskID]
+	snapshot := cloneSubagentInstance(instance)
+	pm.mu.Unlock()
+
+	pm.middleware.persistTask(snapshot)
+
+	if proc == nil {
+		return nil
+	}
+
+	// 关闭 stdin 通知子进程取消，超时后强制结束
+	_ = proc.stdin.Close()
+	select {
+	case <-proc.done:
+	case <-time.After(pm.config.StopTimeout):
+		_ = proc.cmd.Process.Kill()
+		<-proc.done
+	}
+	return nil
+}
+
+func (pm *processSubagentManager) ResumeSubagent(taskID string) (*builtin.SubagentInstance, error) {
+	pm.mu.RLock()
+	instance, exists := pm.instances[taskID]
+	var config builtin.SubagentConfig
+	var status string
+	if exists {
+		config = *instance.Config
+		status = instance.Status
+	}
+	pm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("subagent not found: %s", taskID)
+	}
+	if status == "running" {
+		return nil, fmt.Errorf("subagent cannot be resumed, current status: %s", status)
+	}
+
+	config.ID = ""
+	return pm.StartSubagent(context.Background(), &config)
+}
+
+func (pm *processSubagentManager) GetSubagent(taskID string) (*builtin.SubagentInstance, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	instance, exists := pm.instances[taskID]
+	if !exists {
+		return nil, fmt.Errorf("subagent not found: %s", taskID)
+	}
+	return cloneSubagentInstance(instance), nil
+}
+
+func (pm *processSubagentManager) StopSubagent(taskID string) error {
+	return pm.terminate(taskID, "stopped", "subagent stopped by request")
+}
+
+func (pm *processSubagentManager) ListSubagents() ([]*builtin.SubagentInstance, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	list := make([]*builtin.SubagentInstance, 0, len(pm.instances))
+	for _, instance := range pm.instances {
+		list = append(list, cloneSubagentInstance(instance))
+	}
+	return list, nil
+}
+
+func (pm *processSubagentManager) GetSubagentOutput(taskID string) (string, error) {
+	instance, err := pm.GetSubagent(taskID)
+	if err != nil {
+		return "", err
+	}
+	return instance.Output, nil
+}
+
+func (pm *processSubagentManager) CleanupSubagent(taskID string) error {
+	_ = pm.terminate(taskID, "stopped", "subagent cleaned up")
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if _, exists := pm.instances[taskID]; !exists {
+		return fmt.Errorf("subagent not found: %s", taskID)
+	}
+	delete(pm.instances, taskID)
+	delete(pm.procs, taskID)
+	pm.middleware.forgetTask(taskID)
+	return nil
+}
+
+// run 在子进程中同步执行任务，结束后清理记录
+func (pm *processSubagentManager) run(ctx context.Context, config *builtin.SubagentConfig) (string, error) {
+	instance, err := pm.StartSubagent(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = pm.CleanupSubagent(instance.ID) }()
+
+	pm.mu.RLock()
+	proc := pm.procs[instance.ID]
+	pm.mu.RUnlock()
+	<-proc.done
+
+	final, err := pm.GetSubagent(instance.ID)
+	if err != nil {
+		return "", err
+	}
+	if final.Status != "completed" {
+		return final.Output, errors.New(final.Error)
+	}
+	return final.Output, nil
+}
+
+// appendPartialOutput 追加运行中子代理的增量输出
+func (pm *processSubagentManager) appendPartialOutput(taskID, chunk string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if instance, exists := pm.instances[taskID]; exists && instance.Status == "running" {
+		instance.PartialOutput += chunk
+		instance.LastUpdate = time.Now()
+		return
+	}
+	if partial, pending := pm.pending[taskID]; pending {
+		pm.pending[taskID] = partial + chunk
+	}
+}
+
+// restoreSubagents 加载持久化的任务，已存在的任务不会被覆盖
+func (pm *processSubagentManager) restoreSubagents(instances []*builtin.SubagentInstance) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, instance := range instances {
+		if _, exists := pm.instances[instance.ID]; !exists {
+			pm.instances[instance.ID] = instance
+		}
+	}
+}
+
+// stopAll 结束所有运行中的子进程
+func (pm *processSubagentManager) stopAll() {
+	pm.mu.RLock()
+	ids := make([]string, 0, len(pm.instances))
+	for id, instance := range pm.instances {
+		if instance.Status == "running" {
+			ids = append(ids, id)
+		}
+	}
+	pm.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = pm.terminate(id, "stopped", "parent agent stopped")
+		}()
+	}
+	wg.Wait()
+}
+
+// processExitError 描述未返回结果就退出的子进程
+func processExitError(waitErr error, stderr string) string {
+	msg := "subagent process exited without result"
+	if waitErr != nil {
+		msg += ": " + waitErr.Error()
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		msg += "\n" + stderr
+	}
+	return msg
+}
+
+// cloneSubagentInstance 返回实例快照，运行中的实例更新耗时
+func cloneSubagentInstance(instance *builtin.SubagentInstance) *builtin.SubagentInstance {
+	clone := *instance
+	clone.Metadata = maps.Clone(instance.Metadata)
+	if clone.Status == "running" {
+		clone.Duration = time.Since(clone.StartTime)
+		clone.LastUpdate = time.Now()
+	}
+	return &clone
+}
+
+// resultWriter 按行解析子进程 stdout，保留最后一个结果行，增量输出交给 onProgress
+// 超过 maxResultLineSize 的行不再缓冲，直到下一个换行符前的内容都被丢弃
+type resultWriter struct {
+	buf        []byte
+	discarding bool
+	dropped    int
+	result     *subAgentProcessResult
+	onProgress func(chunk string)
+}
+
+func (w *resultWriter) Write(p []byte) (int, error) {
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if !w.discarding && len(w.buf)+len(data) > maxResultLineSize {
+				w.buf = nil
+				w.discarding = true
+				w.dropped++
+			}
+			if !w.discarding {
+				w.buf = append(w.buf, data...)
+			}
+			break
+		}
+
+		line := data[:i]
+		data = data[i+1:]
+		if w.discarding {
+			w.discarding = false
+			continue
+		}
+		if len(w.buf)+len(line) > maxResultLineSize {
+			w.buf = nil
+			w.dropped++
+			continue
+		}
+		if len(w.buf) > 0 {
+			line = append(w.buf, line...)
+			w.buf = nil
+		}
+		w.handleLine(line)
+	}
+	return len(p), nil
+}
+
+// handleLine 解析单行输出，非结果行（如日志）被忽略
+func (w *resultWriter) handleLine(line []byte) {
+	var result subAgentProcessResult
+	if err := json.Unmarshal(line, &result); err != nil {
+		return
+	}
+	switch result.Kind {
+	case subAgentResultKind:
+		w.result = &result
+	case subAgentProgressKind:
+		if w.onProgress != nil {
+			w.onProgress(result.Output)
+		}
+	}
+}
+
+// tailBuffer 只保留最后 max 字节的输出
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
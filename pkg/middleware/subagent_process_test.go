@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// processTestConfig 父子进程共用的子代理配置
+func processTestConfig() *SubAgentMiddlewareConfig {
+	return &SubAgentMiddlewareConfig{
+		Specs: []SubAgentSpec{{Name: "echo"}, {Name: "crash"}, {Name: "runaway"}, {Name: "slow"}, {Name: "stream"}},
+		Factory: func(ctx context.Context, spec SubAgentSpec) (SubAgent, error) {
+			if spec.Name == "stream" {
+				return &streamingTestAgent{
+					SimpleSubAgent: NewSimpleSubAgent(spec.Name, "", nil),
+					chunks:         []string{"found 3 sources\n", "reading...\n"},
+				}, nil
+			}
+			return NewSimpleSubAgent(spec.Name, "", func(ctx context.Context, description string, parentContext map[string]any) (string, error) {
+				switch spec.Name {
+				case "crash":
+					fmt.Fprintln(os.Stderr, "boom: out of memory")
+					os.Exit(3)
+				case "runaway":
+					// 忽略取消信号
+					time.Sleep(time.Hour)
+				case "slow":
+					<-ctx.Done()
+					return "", ctx.Err()
+				}
+				return fmt.Sprintf("echo: %s (pid %d, parent %v)", description, os.Getpid(), parentContext["from"]), nil
+			}), nil
+		},
+	}
+}
+
+// TestSubAgentProcessHelper 作为子代理子进程运行，普通测试时跳过
+func TestSubAgentProcessHelper(t *testing.T) {
+	if !IsSubAgentProcess() {
+		t.Skip("helper process")
+	}
+	if err := RunSubAgentProcess(context.Background(), processTestConfig()); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func newProcessTestMiddleware(t *testing.T, limits *sandbox.ResourceLimits) *SubAgentMiddleware {
+	t.Helper()
+	config := processTestConfig()
+	config.EnableAsync = true
+	config.EnableProcessIsolation = true
+	config.Process = &SubAgentProcessConfig{
+		Command:     os.Args[0],
+		Args:        []string{"-test.run=^TestSubAgentProcessHelper$"},
+		Limits:      limits,
+		StopTimeout: 200 * time.Millisecond,
+	}
+	mw, err := NewSubAgentMiddleware(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mw.OnAgentStop(context.Background(), "test") })
+	return mw
+}
+
+// waitForStatus 轮询直到子代理离开 pending/running 状态
+func waitForStatus(t *testing.T, mw *SubAgentMiddleware, taskID string) map[string]any {
+	t.Helper()
+	query := getTool[*QuerySubagentTool](t, mw, "query_subagent")
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		result, err := query.Execute(context.Background(), map[string]any{"task_id": taskID}, nil)
+		require.NoError(t, err)
+		data := result.(map[string]any)
+		if data["status"] != "running" && data["status"] != "pending" {
+			return data
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("subagent %s did not finish", taskID)
+	return nil
+}
+
+func startAsyncTask(t *testing.T, mw *SubAgentMiddleware, subagentType string) string {
+	t.Helper()
+	result, err := getTool[*TaskTool](t, mw, "task").Execute(context.Background(), map[string]any{
+		"description":   "hello",
+		"subagent_type": subagentType,
+		"async":         true,
+		"context":       map[string]any{"from": "parent"},
+	}, nil)
+	require.NoError(t, err)
+	data := result.(map[string]any)
+	require.True(t, data["ok"].(bool), "start failed: %v", data["error"])
+	return data["task_id"].(string)
+}
+
+func TestSubAgentProcess_SyncAndAsync(t *testing.T) {
+	mw := newProcessTestMiddleware(t, &sandbox.ResourceLimits{MaxCPUTime: time.Minute, MaxOpenFiles: 256})
+	assert.ElementsMatch(t, []string{"echo", "crash", "runaway", "slow", "stream"}, mw.ListSubAgents())
+
+	// 同步执行同样在子进程中
+	result, err := getTool[*TaskTool](t, mw, "task").Execute(context.Background(), map[string]any{
+		"description":   "sync task",
+		"subagent_type": "echo",
+		"context":       map[string]any{"from": "parent"},
+	}, nil)
+	require.NoError(t, err)
+	data := result.(map[string]any)
+	require.True(t, data["ok"].(bool), "sync task failed: %v", data["error"])
+	output := data["result"].(string)
+	assert.Contains(t, output, "echo: sync task")
+	assert.Contains(t, output, "parent parent")
+	assert.NotContains(t, output, fmt.Sprintf("pid %d,", os.Getpid()))
+
+	taskID := startAsyncTask(t, mw, "echo")
+	status := waitForStatus(t, mw, taskID)
+	assert.Equal(t, "completed", status["status"])
+	assert.Contains(t, status["output"], "echo: hello")
+}
+
+func TestSubAgentProcess_CrashDoesNotAffectParent(t *testing.T) {
+	mw := newProcessTestMiddleware(t, nil)
+
+	taskID := startAsyncTask(t, mw, "crash")
+	status := waitForStatus(t, mw, taskID)
+	assert.Equal(t, "failed", status["status"])
+	assert.Contains(t, status["error"], "exit status 3")
+	assert.Contains(t, status["error"], "boom: out of memory")
+	assert.Equal(t, 3, status["exit_code"])
+
+	// 崩溃后仍可恢复（重新启动新进程）
+	resumed, err := getTool[*ResumeSubagentTool](t, mw, "resume_subagent").Execute(context.Background(), map[string]any{"task_id": taskID}, nil)
+	require.NoError(t, err)
+	assert.True(t, resumed.(map[string]any)["ok"].(bool))
+}
+
+func TestSubAgentProcess_StopKillsRunaway(t *testing.T) {
+	mw := newProcessTestMiddleware(t, nil)
+	stop := getTool[*StopSubagentTool](t, mw, "stop_subagent")
+
+	for _, subagentType := range []string{"slow", "runaway"} {
+		t.Run(subagentType, func(t *testing.T) {
+			taskID := startAsyncTask(t, mw, subagentType)
+			instance, err := mw.manager.GetSubagent(taskID)
+			require.NoError(t, err)
+			require.Positive(t, instance.PID)
+
+			start := time.Now()
+			result, err := stop.Execute(context.Background(), map[string]any{"task_id": taskID}, nil)
+			require.NoError(t, err)
+			assert.True(t, result.(map[string]any)["ok"].(bool))
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			instance, err = mw.manager.GetSubagent(taskID)
+			require.NoError(t, err)
+			assert.Equal(t, "stopped", instance.Status)
+
+			// 进程已退出
+			mw.process.mu.RLock()
+			proc := mw.process.procs[taskID]
+			mw.process.mu.RUnlock()
+			select {
+			case <-proc.done:
+			default:
+				t.Fatal("subagent process should have exited after stop")
+			}
+			assert.NotNil(t, proc.cmd.ProcessState)
+		})
+	}
+}
+
+func TestSubAgentProcess_PartialOutput(t *testing.T) {
+	mw := newProcessTestMiddleware(t, nil)
+	taskID := startAsyncTask(t, mw, "stream")
+
+	waitForPartialOutput(t, mw, taskID, "found 3 sources\nreading...\n")
+
+	_, err := getTool[*StopSubagentTool](t, mw, "stop_subagent").Execute(context.Background(), map[string]any{"task_id": taskID}, nil)
+	require.NoError(t, err)
+	status := waitForStatus(t, mw, taskID)
+	assert.Equal(t, "stopped", status["status"])
+	assert.NotContains(t, status, "partial_output")
+}
+
+func TestSubAgentProcess_Timeout(t *testing.T) {
+	mw := newProcessTestMiddleware(t, nil)
+	task := getTool[*TaskTool](t, mw, "task")
+
+	result, err := task.Execute(context.Background(), map[string]any{
+		"description":   "never finishes",
+		"subagent_type": "slow",
+		"async":         true,
+		"timeout":       1.0,
+	}, nil)
+	require.NoError(t, err)
+	taskID := result.(map[string]any)["task_id"].(string)
+
+	status := waitForStatus(t, mw, taskID)
+	assert.Equal(t, "failed", status["status"])
+	assert.Equal(t, "subagent timeout", status["error"])
+}
+
+func TestServeSubAgentProcess(t *testing.T) {
+	request, err := json.Marshal(subAgentProcessRequest{TaskID: "t1", Type: "echo", Description: "direct"})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = serveSubAgentProcess(context.Background(), processTestConfig(), bytes.NewReader(append(request, '\n')), &out)
+	require.NoError(t, err)
+
+	var result subAgentProcessResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, subAgentResultKind, result.Kind)
+	assert.Equal(t, "t1", result.TaskID)
+	assert.True(t, strings.HasPrefix(result.Output, "echo: direct"))
+
+	// 未知子代理返回错误结果
+	request, _ = json.Marshal(subAgentProcessRequest{TaskID: "t2", Type: "missing"})
+	out.Reset()
+	err = serveSubAgentProcess(context.Background(), processTestConfig(), bytes.NewReader(append(request, '\n')), &out)
+	require.Error(t, err)
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Contains(t, result.Error, "subagent not found")
+}
+
+func TestResultWriter_IgnoresLogLines(t *testing.T) {
+	w := &resultWriter{}
+	_, _ = w.Write([]byte(`{"level":"info","msg":"starting"}` + "\nplain log line\n" + `{"kind":"aster_subagent_result","output":"do`))
+	assert.Nil(t, w.result)
+	_, _ = w.Write([]byte("ne\"}\n"))
+	require.NotNil(t, w.result)
+	assert.Equal(t, "done", w.result.Output)
+}
+
+func TestResultWriter_DiscardsOversizedLines(t *testing.T) {
+	w := &resultWriter{}
+	chunk := bytes.Repeat([]byte("x"), maxResultLineSize/2)
+	for range 3 {
+		_, _ = w.Write(chunk)
+	}
+	assert.LessOrEqual(t, len(w.buf), maxResultLineSize)
+
+	_, _ = w.Write([]byte("tail\n" + `{"kind":"aster_subagent_result","output":"done"}` + "\n"))
+	assert.Equal(t, 1, w.dropped)
+	assert.Empty(t, w.buf)
+	require.NotNil(t, w.result)
+	assert.Equal(t, "done", w.result.Output)
+}
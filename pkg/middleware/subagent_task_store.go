@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/tools/builtin"
+)
+
+// subagentTasksCollection TaskStore 中保存子代理任务的集合
+const subagentTasksCollection = "subagent_tasks"
+
+// subagentInterruptedError 进程重启前仍在运行的任务的错误信息
+const subagentInterruptedError = "subagent interrupted by process restart"
+
+// subagentTaskRestorer 支持恢复持久化任务的管理器
+type subagentTaskRestorer interface {
+	restoreSubagents(instances []*builtin.SubagentInstance)
+}
+
+// persistTask 将任务状态写入 TaskStore，未配置时忽略
+func (m *SubAgentMiddleware) persistTask(instance *builtin.SubagentInstance) {
+	if m.taskStore == nil || instance == nil {
+		return
+	}
+
+	// 增量输出只在运行期间有意义，不做持久化
+	task := *instance
+	task.PartialOutput = ""
+	if err := m.taskStore.Set(context.Background(), subagentTasksCollection, task.ID, &task); err != nil {
+		saLog.Warn(context.Background(), "failed to persist subagent task", map[string]any{"task_id": task.ID, "error": err.Error()})
+	}
+}
+
+// forgetTask 从 TaskStore 中删除任务
+func (m *SubAgentMiddleware) forgetTask(taskID string) {
+	if m.taskStore == nil {
+		return
+	}
+
+	if err := m.taskStore.Delete(context.Background(), subagentTasksCollection, taskID); err != nil && !errors.Is(err, store.ErrNotFound) {
+		saLog.Warn(context.Background(), "failed to delete subagent task", map[string]any{"task_id": taskID, "error": err.Error()})
+	}
+}
+
+// restoreTasks 从 TaskStore 加载已知任务
+// 上次退出时仍在运行的任务无法继续，标记为 interrupted，之后可通过 resume_subagent 重新执行。
+func (m *SubAgentMiddleware) restoreTasks(ctx context.Context) error {
+	restorer, ok := m.scheduler.inner.(subagentTaskRestorer)
+	if !ok {
+		saLog.Warn(ctx, "subagent manager does not support task restore", nil)
+		return nil
+	}
+
+	records, err := m.taskStore.List(ctx, subagentTasksCollection)
+	if err != nil {
+		return err
+	}
+
+	instances := make([]*builtin.SubagentInstance, 0, len(records))
+	for _, record := range records {
+		var instance builtin.SubagentInstance
+		if err := store.DecodeValue(record, &instance); err != nil {
+			return err
+		}
+		if instance.ID == "" || instance.Config == nil {
+			continue
+		}
+
+		if instance.Status == "running" || instance.Status == "starting" || instance.Status == "pending" {
+			now := time.Now()
+			instance.Status = "interrupted"
+			instance.Error = subagentInterruptedError
+			instance.PID = 0
+			instance.EndTime = &now
+			instance.Duration = now.Sub(instance.StartTime)
+			instance.LastUpdate = now
+			m.persistTask(&instance)
+		}
+		instances = append(instances, &instance)
+	}
+
+	restorer.restoreSubagents(instances)
+	saLog.Info(ctx, "restored subagent tasks", map[string]any{"count": len(instances)})
+	return nil
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubAgentMiddleware_TaskStoreSurvivesRestart(t *testing.T) {
+	taskStore, err := store.NewJSONStore(t.TempDir())
+	require.NoError(t, err)
+
+	block := make(chan struct{})
+	defer close(block)
+	newMiddleware := func() *SubAgentMiddleware {
+		mw, err := NewSubAgentMiddleware(&SubAgentMiddlewareConfig{
+			Specs: []SubAgentSpec{{Name: "quick"}, {Name: "blocking"}},
+			Factory: func(ctx context.Context, spec SubAgentSpec) (SubAgent, error) {
+				return NewSimpleSubAgent(spec.Name, "", func(ctx context.Context, description string, parentContext map[string]any) (string, error) {
+					if spec.Name == "blocking" {
+						select {
+						case <-block:
+						case <-ctx.Done():
+							return "", ctx.Err()
+						}
+					}
+					return "done: " + description, nil
+				}), nil
+			},
+			EnableAsync: true,
+			TaskStore:   taskStore,
+		})
+		require.NoError(t, err)
+		return mw
+	}
+
+	// 第一次运行：一个任务完成，一个任务仍在运行时进程"退出"
+	first := newMiddleware()
+	taskTool := getTool[*TaskTool](t, first, "task")
+	start := func(subagentType string) string {
+		result, err := taskTool.Execute(context.Background(), map[string]any{
+			"description":   "report",
+			"subagent_type": subagentType,
+			"async":         true,
+		}, nil)
+		require.NoError(t, err)
+		return result.(map[string]any)["task_id"].(string)
+	}
+	quickID := start("quick")
+	blockingID := start("blocking")
+
+	require.Eventually(t, func() bool {
+		var task map[string]any
+		if err := taskStore.Get(context.Background(), subagentTasksCollection, quickID, &task); err != nil {
+			return false
+		}
+		return task["status"] == "completed"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// 重启后从 TaskStore 恢复
+	second := newMiddleware()
+	query := getTool[*QuerySubagentTool](t, second, "query_subagent")
+
+	result, err := query.Execute(context.Background(), map[string]any{"task_id": quickID}, nil)
+	require.NoError(t, err)
+	data := result.(map[string]any)
+	assert.True(t, data["ok"].(bool))
+	assert.Equal(t, "completed", data["status"])
+	assert.Equal(t, "done: report", data["output"])
+
+	result, err = query.Execute(context.Background(), map[string]any{"task_id": blockingID}, nil)
+	require.NoError(t, err)
+	data = result.(map[string]any)
+	assert.Equal(t, "interrupted", data["status"])
+	assert.Equal(t, subagentInterruptedError, data["error"])
+
+	list, err := getTool[*ListSubagentsTool](t, second, "list_subagents").Execute(context.Background(), map[string]any{}, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, list.(map[string]any)["count"])
+
+	// 中断的任务可以恢复执行
+	resumed, err := getTool[*ResumeSubagentTool](t, second, "resume_subagent").Execute(context.Background(), map[string]any{"task_id": blockingID}, nil)
+	require.NoError(t, err)
+	resumedData := resumed.(map[string]any)
+	require.True(t, resumedData["ok"].(bool), "resume failed: %v", resumedData["error"])
+	assert.NotEqual(t, blockingID, resumedData["new_task_id"])
+
+	// 清理后从 TaskStore 删除
+	require.NoError(t, second.manager.CleanupSubagent(quickID))
+	exists, err := taskStore.Exists(context.Background(), subagentTasksCollection, quickID)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/astercloud/aster/pkg/logging"
 	"github.com/astercloud/aster/pkg/types"
@@ -31,6 +34,19 @@ type SummarizationMiddleware struct {
 	compactionStrategy       *CompactionStrategy // 渐进式压缩策略
 	useMetadataVisibility    bool                // 使用元数据控制可见性
 	enableProgressiveCompact bool                // 启用渐进式压缩
+
+	mu            sync.RWMutex
+	latestSummary *ConversationSummary // 最近一次生成的摘要
+}
+
+// ConversationSummary 一次对话摘要的结果
+type ConversationSummary struct {
+	Text          string    // 发送给模型的完整摘要（不含前缀）
+	ReplacedFrom  int       // 被替换消息在原消息列表中的起始索引
+	ReplacedTo    int       // 被替换消息的结束索引（不含）
+	ReplacedCount int       // 被替换的消息数
+	ToolCalls     int       // 摘要中保留的工具调用数
+	CreatedAt     time.Time // 生成时间
 }
 
 // TokenCounterFunc 自定义 token 计数函数类型
@@ -170,15 +186,25 @@ func (m *SummarizationMiddleware) WrapModelCall(ctx context.Context, req *ModelR
 		}
 	}
 
-	// 分离 system messages 和其他消息
+	// 分离 system messages 和其他消息，并记录常规消息在原列表中的索引
 	var systemMessages []types.Message
 	var regularMessages []types.Message
+	var regularIndexes []int
 
-	for _, msg := range messages {
-		if msg.Role == types.MessageRoleSystem {
+	// 之前生成的摘要合并进新摘要，避免多次摘要后堆积
+	var previousSummaries []string
+
+	for i, msg := range messages {
+		if text, ok := m.summaryText(msg); ok {
+			previousSummaries = append(previousSummaries, text)
+		} else if isSummaryMessage(msg) {
+			// 摘要后的确认消息随摘要一起替换
+			continue
+		} else if msg.Role == types.MessageRoleSystem {
 			systemMessages = append(systemMessages, msg)
 		} else {
 			regularMessages = append(regularMessages, msg)
+			regularIndexes = append(regularIndexes, i)
 		}
 	}
 
@@ -189,7 +215,12 @@ func (m *SummarizationMiddleware) WrapModelCall(ctx context.Context, req *ModelR
 	}
 
 	// 计算要总结的消息
-	numToSummarize := len(regularMessages) - m.messagesToKeep
+	// 保留的消息不能以孤立的工具结果开头，否则模型无法将其与工具调用配对
+	numToSummarize := summaryBoundary(regularMessages, len(regularMessages)-m.messagesToKeep)
+	if numToSummarize == 0 {
+		sumLog.Debug(ctx, "no safe summary boundary, skipping", nil)
+		return handler(ctx, req)
+	}
 	messagesToSummarize := regularMessages[:numToSummarize]
 	messagesToKeep := regularMessages[numToSummarize:]
 
@@ -202,21 +233,20 @@ func (m *SummarizationMiddleware) WrapModelCall(ctx context.Context, req *ModelR
 		return handler(ctx, req) // 失败时保留原始消息
 	}
 
-	sumLog.Info(ctx, "summary generated", map[string]any{"chars": len(summary)})
+	summary, toolCalls := appendToolCallContext(summary, messagesToSummarize)
+	sumLog.Info(ctx, "summary generated", map[string]any{"chars": len(summary), "tool_calls": toolCalls})
+	fullSummary := strings.Join(append(previousSummaries, summary), "\n\n")
 
 	// 构建新的消息列表: system messages + 总结消息 + 保留的最近消息
-	newMessages := make([]types.Message, 0, len(systemMessages)+1+len(messagesToKeep))
+	summaryMessages := m.summaryMessages(fullSummary, messagesToKeep[0])
+	newMessages := make([]types.Message, 0, len(systemMessages)+len(summaryMessages)+len(messagesToKeep))
 	newMessages = append(newMessages, systemMessages...)
-	newMessages = append(newMessages, types.Message{
-		Role: types.MessageRoleSystem,
-		ContentBlocks: []types.ContentBlock{
-			&types.TextBlock{
-				Text: fmt.Sprintf("%s\n\n%s", m.summaryPrefix, summary),
-			},
-		},
-	})
+	newMessages = append(newMessages, summaryMessages...)
 	newMessages = append(newMessages, messagesToKeep...)
 
+	// 通知调用方同步压缩持久化历史
+	req.CompactHistory(messagesToKeep[0].ID, summaryMessages)
+
 	// 计算压缩后的 token 数和压缩比
 	newTokens := m.tokenCounter(newMessages)
 	tokensSaved := totalTokens - newTokens
@@ -247,9 +277,146 @@ func (m *SummarizationMiddleware) WrapModelCall(ctx context.Context, req *ModelR
 		SummaryPreview:   truncateString(summary, 150),
 	})
 
+	latest := m.setLatestSummary(&ConversationSummary{
+		Text:          summary,
+		ReplacedFrom:  regularIndexes[0],
+		ReplacedTo:    regularIndexes[numToSummarize-1] + 1,
+		ReplacedCount: numToSummarize,
+		ToolCalls:     toolCalls,
+	})
+	req.EmitEvent(&types.ProgressConversationSummarizedEvent{
+		Summary:       latest.Text,
+		ReplacedFrom:  latest.ReplacedFrom,
+		ReplacedTo:    latest.ReplacedTo,
+		ReplacedCount: latest.ReplacedCount,
+		ToolCalls:     latest.ToolCalls,
+		Notice:        "Earlier conversation summarized",
+	})
+
 	return handler(ctx, req)
 }
 
+// summaryAcknowledgement 摘要之后的 assistant 确认消息
+const summaryAcknowledgement = "Understood. I'll continue the conversation based on this summary."
+
+// summaryMessages 构造替换历史的摘要消息
+// 摘要放在 user 消息中（部分 Provider 会丢弃对话中的 system 消息）；
+// 保留的消息不以 assistant 开头时追加一条确认消息，保持 user/assistant 交替
+func (m *SummarizationMiddleware) summaryMessages(summary string, next types.Message) []types.Message {
+	messages := []types.Message{{
+		Role: types.MessageRoleUser,
+		ContentBlocks: []types.ContentBlock{
+			&types.TextBlock{Text: fmt.Sprintf("%s\n\n%s", m.summaryPrefix, summary)},
+		},
+		Metadata: types.NewMessageMetadata().AgentOnly().WithSource("summary"),
+	}}
+	if next.Role != types.MessageRoleAssistant {
+		messages = append(messages, types.Message{
+			Role:          types.MessageRoleAssistant,
+			ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: summaryAcknowledgement}},
+			Metadata:      types.NewMessageMetadata().AgentOnly().WithSource("summary"),
+		})
+	}
+	return messages
+}
+
+// isSummaryMessage 判断消息是否由摘要生成
+func isSummaryMessage(msg types.Message) bool {
+	return msg.Metadata != nil && msg.Metadata.Source == "summary"
+}
+
+// summaryText 判断消息是否为本中间件生成的摘要，是则返回去掉前缀的摘要内容
+// 兼容早期以 system 消息保存的摘要
+func (m *SummarizationMiddleware) summaryText(msg types.Message) (string, bool) {
+	isSummary := msg.Role == types.MessageRoleSystem || (msg.Role == types.MessageRoleUser && isSummaryMessage(msg))
+	if !isSummary || len(msg.ContentBlocks) != 1 {
+		return "", false
+	}
+	block, ok := msg.ContentBlocks[0].(*types.TextBlock)
+	if !ok || !strings.HasPrefix(block.Text, m.summaryPrefix+"\n\n") {
+		return "", false
+	}
+	return strings.TrimPrefix(block.Text, m.summaryPrefix+"\n\n"), true
+}
+
+// summaryBoundary 调整摘要边界，使保留部分不以工具结果开头
+// 边界向前移动，让工具调用与其结果一同保留，返回 0 表示没有安全的边界
+func summaryBoundary(messages []types.Message, n int) int {
+	for n > 0 && n < len(messages) && isToolResultMessage(messages[n]) {
+		n--
+	}
+	return n
+}
+
+// isToolResultMessage 判断消息是否携带工具结果
+func isToolResultMessage(msg types.Message) bool {
+	if msg.Role == types.MessageRoleTool {
+		return true
+	}
+	for _, block := range msg.ContentBlocks {
+		if _, ok := block.(*types.ToolResultBlock); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// appendToolCallContext 在摘要后追加被摘要消息中的工具调用记录
+// 保证模型在历史被替换后仍知道调用过哪些工具及其结果状态，返回追加后的摘要和工具调用数
+func appendToolCallContext(summary string, messages []types.Message) (string, int) {
+	results := make(map[string]*types.ToolResultBlock)
+	var calls []*types.ToolUseBlock
+	for _, msg := range messages {
+		for _, block := range msg.ContentBlocks {
+			switch b := block.(type) {
+			case *types.ToolUseBlock:
+				calls = append(calls, b)
+			case *types.ToolResultBlock:
+				results[b.ToolUseID] = b
+			}
+		}
+	}
+	if len(calls) == 0 {
+		return summary, 0
+	}
+
+	var sb strings.Builder
+	sb.WriteString(summary)
+	sb.WriteString("\n\n### Tool calls in summarized history\n")
+	for _, call := range calls {
+		status := "no result"
+		if r, ok := results[call.ID]; ok {
+			status = "ok: " + truncateString(r.Content, 80)
+			if r.IsError {
+				status = "error: " + truncateString(r.Content, 80)
+			}
+		}
+		input, _ := json.Marshal(call.Input)
+		fmt.Fprintf(&sb, "- %s (%s) input=%s -> %s\n", call.Name, call.ID, truncateString(string(input), 120), status)
+	}
+	return strings.TrimRight(sb.String(), "\n"), len(calls)
+}
+
+// setLatestSummary 记录最近一次摘要并返回其副本
+func (m *SummarizationMiddleware) setLatestSummary(summary *ConversationSummary) ConversationSummary {
+	summary.CreatedAt = time.Now()
+	m.mu.Lock()
+	m.latestSummary = summary
+	m.mu.Unlock()
+	return *summary
+}
+
+// LatestSummary 返回最近一次生成的对话摘要，尚未摘要时返回 nil
+func (m *SummarizationMiddleware) LatestSummary() *ConversationSummary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.latestSummary == nil {
+		return nil
+	}
+	summary := *m.latestSummary
+	return &summary
+}
+
 // defaultSummarizer 默认的总结生成器
 func defaultSummarizer(ctx context.Context, messages []types.Message) (string, error) {
 	var summary strings.Builder
@@ -841,8 +1008,9 @@ func (m *SummarizationMiddleware) summarizeWithMetadata(
 		numToKeep = len(messages)
 	}
 
-	toKeep := messages[len(messages)-numToKeep:]
-	toSummarize := messages[:len(messages)-numToKeep]
+	boundary := summaryBoundary(messages, len(messages)-numToKeep)
+	toKeep := messages[boundary:]
+	toSummarize := messages[:boundary]
 
 	if len(toSummarize) == 0 {
 		return messages, nil
@@ -853,6 +1021,13 @@ func (m *SummarizationMiddleware) summarizeWithMetadata(
 	if err != nil {
 		return nil, err
 	}
+	summary, toolCalls := appendToolCallContext(summary, toSummarize)
+	m.setLatestSummary(&ConversationSummary{
+		Text:          summary,
+		ReplacedTo:    boundary,
+		ReplacedCount: boundary,
+		ToolCalls:     toolCalls,
+	})
 
 	// 创建摘要消息
 	summaryMsg := types.Message{
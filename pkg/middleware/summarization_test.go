@@ -162,7 +162,152 @@ func TestSummarizationMiddleware_TriggerSummarization(t *testing.T) {
 	}
 }
 
+// TestSummarizationMiddleware_ConversationSummarizedEvent 测试长历史摘要后发出带摘要和替换范围的事件
+func TestSummarizationMiddleware_ConversationSummarizedEvent(t *testing.T) {
+	middleware, err := NewSummarizationMiddleware(&SummarizationMiddlewareConfig{
+		Summarizer:             mockSummarizer("User asked to inspect config files", false),
+		MaxTokensBeforeSummary: 10,
+		MessagesToKeep:         3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	text := func(role types.Role, s string) types.Message {
+		return types.Message{Role: role, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: s}}}
+	}
+	toolUse := func(id string) types.Message {
+		return types.Message{Role: types.MessageRoleAssistant, ContentBlocks: []types.ContentBlock{
+			&types.ToolUseBlock{ID: id, Name: "Read", Input: map[string]any{"path": "config.yaml"}},
+		}}
+	}
+	toolResult := func(id, content string) types.Message {
+		return types.Message{Role: types.MessageRoleUser, ContentBlocks: []types.ContentBlock{
+			&types.ToolResultBlock{ToolUseID: id, Content: content},
+		}}
+	}
+
+	// 保留 3 条时原边界落在 toolu_2 的结果上，需要前移使 toolu_2 的调用与结果一同保留
+	req := &ModelRequest{
+		Messages: []types.Message{
+			text(types.MessageRoleSystem, "You are a helpful assistant"),
+			text(types.MessageRoleUser, "Inspect the config"),
+			toolUse("toolu_1"),
+			toolResult("toolu_1", "port: 8080"),
+			text(types.MessageRoleAssistant, "Port is 8080"),
+			text(types.MessageRoleUser, "Check again"),
+			toolUse("toolu_2"),
+			toolResult("toolu_2", "port: 9090"),
+			text(types.MessageRoleAssistant, "Port changed to 9090"),
+			text(types.MessageRoleUser, "Thanks"),
+		},
+		Metadata: map[string]any{},
+	}
+	var events []*types.ProgressConversationSummarizedEvent
+	req.Metadata[MetadataKeyEventEmitter] = EventEmitterFunc(func(event types.EventType) {
+		if e, ok := event.(*types.ProgressConversationSummarizedEvent); ok {
+			events = append(events, e)
+		}
+	})
+
+	handler := func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		return &ModelResponse{}, nil
+	}
+	if _, err := middleware.WrapModelCall(context.Background(), req, handler); err != nil {
+		t.Fatalf("WrapModelCall failed: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 conversation summarized event, got %d", len(events))
+	}
+	e := events[0]
+	if e.ReplacedFrom != 1 || e.ReplacedTo != 6 || e.ReplacedCount != 5 {
+		t.Errorf("Unexpected replaced range [%d,%d) count %d", e.ReplacedFrom, e.ReplacedTo, e.ReplacedCount)
+	}
+	if !strings.Contains(e.Summary, "User asked to inspect config files") {
+		t.Errorf("Event should carry the generated summary, got %q", e.Summary)
+	}
+	if e.ToolCalls != 1 || !strings.Contains(e.Summary, "toolu_1") || !strings.Contains(e.Summary, "port: 8080") {
+		t.Errorf("Summary should preserve tool call context, got %q", e.Summary)
+	}
+	if e.Notice == "" {
+		t.Error("Event should carry a user-facing notice")
+	}
+
+	latest := middleware.LatestSummary()
+	if latest == nil || latest.Text != e.Summary || latest.ReplacedTo != e.ReplacedTo {
+		t.Errorf("LatestSummary should match the emitted event, got %+v", latest)
+	}
+
+	// system + 摘要 + 保留的 4 条，且保留部分不以工具结果开头
+	if len(req.Messages) != 6 {
+		t.Fatalf("Expected 6 messages after summarization, got %d", len(req.Messages))
+	}
+	if isToolResultMessage(req.Messages[2]) {
+		t.Error("Kept messages should not start with an orphan tool result")
+	}
+}
+
 // TestSummarizationMiddleware_PreserveRecentMessages 测试保留最近的消息
+func TestSummarizationMiddleware_CompactHistory(t *testing.T) {
+	middleware, err := NewSummarizationMiddleware(&SummarizationMiddlewareConfig{
+		Summarizer:             mockSummarizer("Second summary", false),
+		MaxTokensBeforeSummary: 10,
+		MessagesToKeep:         1,
+		SummaryPrefix:          "## Summary",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	text := func(id string, role types.Role, s string) types.Message {
+		return types.Message{ID: id, Role: role, ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: s}}}
+	}
+	previous := middleware.summaryMessages("First summary", types.Message{Role: types.MessageRoleUser})
+	req := &ModelRequest{
+		Messages: append(previous,
+			text("msg-1", types.MessageRoleUser, "Old message"),
+			text("msg-2", types.MessageRoleAssistant, "Old response"),
+			text("msg-3", types.MessageRoleUser, "Recent message"),
+		),
+		Metadata: map[string]any{},
+	}
+	var keepFromID string
+	var summary []types.Message
+	req.Metadata[MetadataKeyHistoryCompactor] = HistoryCompactorFunc(func(id string, msgs []types.Message) {
+		keepFromID, summary = id, msgs
+	})
+
+	handler := func(ctx context.Context, req *ModelRequest) (*ModelResponse, error) {
+		return &ModelResponse{}, nil
+	}
+	if _, err := middleware.WrapModelCall(context.Background(), req, handler); err != nil {
+		t.Fatalf("WrapModelCall failed: %v", err)
+	}
+
+	if keepFromID != "msg-3" {
+		t.Fatalf("Expected compaction to keep from msg-3, got %q", keepFromID)
+	}
+	// 摘要以 user/assistant 消息对保存，Provider 不会像 system 消息那样丢弃
+	if len(summary) != 2 || summary[0].Role != types.MessageRoleUser || summary[1].Role != types.MessageRoleAssistant {
+		t.Fatalf("Expected a user/assistant summary pair, got %+v", summary)
+	}
+	// 之前的摘要合并进新摘要，请求中只有一组摘要消息
+	content := summary[0].GetContent()
+	if !strings.Contains(content, "First summary") || !strings.Contains(content, "Second summary") {
+		t.Errorf("Summary should merge previous summaries, got %q", content)
+	}
+	if len(req.Messages) != 3 || req.Messages[0].GetContent() != content || req.Messages[2].ID != "msg-3" {
+		t.Errorf("Request should contain merged summary and kept message, got %d messages", len(req.Messages))
+	}
+
+	// 保留的消息以 assistant 开头时不追加确认消息
+	pair := middleware.summaryMessages("s", types.Message{Role: types.MessageRoleAssistant})
+	if len(pair) != 1 {
+		t.Errorf("Expected only the summary message before an assistant message, got %d", len(pair))
+	}
+}
+
 func TestSummarizationMiddleware_PreserveRecentMessages(t *testing.T) {
 	ctx := context.Background()
 
@@ -539,17 +684,19 @@ func TestSummarizationMiddleware_SystemMessagePreservation(t *testing.T) {
 		t.Fatalf("WrapModelCall failed: %v", err)
 	}
 
-	// 验证 system messages 都被保留
-	systemCount := 0
+	// 验证 system messages 都被保留，总结以 user 消息加入
+	systemCount, summaryCount := 0, 0
 	for _, msg := range req.Messages {
 		if msg.Role == types.MessageRoleSystem {
 			systemCount++
 		}
+		if msg.Role == types.MessageRoleUser && isSummaryMessage(msg) {
+			summaryCount++
+		}
 	}
 
-	// 应该有 2 个原始 system + 1 个总结 system = 3 个
-	if systemCount < 3 {
-		t.Errorf("Expected at least 3 system messages (2 original + 1 summary), got %d", systemCount)
+	if systemCount != 2 || summaryCount != 1 {
+		t.Errorf("Expected 2 original system messages and 1 summary, got %d and %d", systemCount, summaryCount)
 	}
 }
 
@@ -103,7 +103,7 @@ func (ap *AnthropicProvider) Complete(ctx context.Context, messages []types.Mess
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		anthropicLog.Error(ctx, "API error response", map[string]any{"status": resp.StatusCode, "body": string(body)})
-		return nil, fmt.Errorf("anthropic api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("anthropic", resp, body)
 	}
 
 	// 解析完整响应
@@ -123,10 +123,7 @@ func (ap *AnthropicProvider) Complete(ctx context.Context, messages []types.Mess
 	// 解析Token使用情况
 	var usage *TokenUsage
 	if usageData, ok := apiResp["usage"].(map[string]any); ok {
-		usage = &TokenUsage{
-			InputTokens:  int64(usageData["input_tokens"].(float64)),
-			OutputTokens: int64(usageData["output_tokens"].(float64)),
-		}
+		usage = parseAnthropicUsage(usageData)
 	}
 
 	return &CompleteResponse{
@@ -181,7 +178,7 @@ func (ap *AnthropicProvider) Stream(ctx context.Context, messages []types.Messag
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
-		return nil, fmt.Errorf("anthropic api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("anthropic", resp, body)
 	}
 
 	// 创建流式响应channel
@@ -214,6 +211,11 @@ func (ap *AnthropicProvider) buildRequest(messages []types.Message, opts *Stream
 			req["temperature"] = opts.Temperature
 		}
 
+		if len(opts.StopSequences) > 0 {
+			req["stop_sequences"] = opts.StopSequences
+		}
+		logUnsupportedOptions(anthropicLog, "anthropic", ap.Capabilities(), opts)
+
 		// 当有工具时，确保 max_tokens 足够大
 		if len(opts.Tools) > 0 && opts.MaxTokens == 0 {
 			req["max_tokens"] = 4096
@@ -222,12 +224,7 @@ func (ap *AnthropicProvider) buildRequest(messages []types.Message, opts *Stream
 		if opts.System != "" {
 			// 使用数组格式的 system，兼容更多代理服务
 			// Anthropic API 支持字符串和数组两种格式，数组格式兼容性更好
-			req["system"] = []map[string]any{
-				{
-					"type": "text",
-					"text": opts.System,
-				},
-			}
+			req["system"] = []map[string]any{ap.systemBlock(opts.System, opts.CacheSystem)}
 			// 记录系统提示词长度和关键内容（用于调试）
 			if len(opts.System) > 500 {
 				anthropicLog.Debug(ctx, "system prompt", map[string]any{"length": len(opts.System), "preview": opts.System[:200]})
@@ -250,17 +247,16 @@ func (ap *AnthropicProvider) buildRequest(messages []types.Message, opts *Stream
 			}
 		} else if ap.systemPrompt != "" {
 			// 如果 opts 没有 system，使用存储的 systemPrompt（也使用数组格式）
-			req["system"] = []map[string]any{
-				{
-					"type": "text",
-					"text": ap.systemPrompt,
-				},
-			}
+			req["system"] = []map[string]any{ap.systemBlock(ap.systemPrompt, opts.CacheSystem)}
 		}
 
 		if len(opts.Tools) > 0 {
 			// 转换工具格式为 Anthropic API 格式
 			tools := make([]map[string]any, 0, len(opts.Tools))
+			breakpoints := 0
+			if opts.CacheSystem {
+				breakpoints++
+			}
 			for _, tool := range opts.Tools {
 				toolMap := map[string]any{
 					"name":         tool.Name,
@@ -287,6 +283,14 @@ func (ap *AnthropicProvider) buildRequest(messages []types.Message, opts *Stream
 				if len(tool.AllowedCallers) > 0 {
 					toolMap["allowed_callers"] = tool.AllowedCallers
 				}
+				if tool.Cacheable {
+					if breakpoints < anthropicMaxCacheBreakpoints {
+						toolMap["cache_control"] = ephemeralCacheControl()
+						breakpoints++
+					} else {
+						anthropicLog.Warn(ctx, "too many cache breakpoints, ignoring", map[string]any{"tool": tool.Name, "max": anthropicMaxCacheBreakpoints})
+					}
+				}
 				tools = append(tools, toolMap)
 			}
 			req["tools"] = tools
@@ -379,6 +383,22 @@ func (ap *AnthropicProvider) convertMessages(messages []types.Message) []map[str
 						"content":     b.Content,
 						"is_error":    b.IsError,
 					})
+				case *types.ImageContent:
+					image := map[string]any{"type": "image"}
+					switch b.Type {
+					case "base64":
+						image["source"] = map[string]any{
+							"type":       "base64",
+							"media_type": b.MimeType,
+							"data":       b.Source,
+						}
+					case "url":
+						image["source"] = map[string]any{
+							"type": "url",
+							"url":  b.Source,
+						}
+					}
+					blocks = append(blocks, image)
 				}
 			}
 			content = blocks
@@ -406,6 +426,7 @@ func (ap *AnthropicProvider) processStream(body io.ReadCloser, chunkCh chan<- St
 	defer close(chunkCh)
 	defer func() { _ = body.Close() }()
 
+	var startUsage *TokenUsage
 	scanner := bufio.NewScanner(body)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -430,6 +451,12 @@ func (ap *AnthropicProvider) processStream(body io.ReadCloser, chunkCh chan<- St
 
 		chunk := ap.parseStreamEvent(event)
 		if chunk != nil {
+			switch chunk.Type {
+			case "message_start":
+				startUsage = chunk.Usage
+			case "message_delta":
+				mergeStartUsage(chunk.Usage, startUsage)
+			}
 			chunkCh <- *chunk
 		}
 	}
@@ -485,9 +512,14 @@ func (ap *AnthropicProvider) parseStreamEvent(event map[string]any) *StreamChunk
 			chunk.Delta = delta
 		}
 		if usage, ok := event["usage"].(map[string]any); ok {
-			chunk.Usage = &TokenUsage{
-				InputTokens:  int64(usage["input_tokens"].(float64)),
-				OutputTokens: int64(usage["output_tokens"].(float64)),
+			chunk.Usage = parseAnthropicUsage(usage)
+		}
+
+	case "message_start":
+		// 输入和缓存 token 在 message_start 中返回
+		if message, ok := event["message"].(map[string]any); ok {
+			if usage, ok := message["usage"].(map[string]any); ok {
+				chunk.Usage = parseAnthropicUsage(usage)
 			}
 		}
 	}
@@ -495,6 +527,59 @@ func (ap *AnthropicProvider) parseStreamEvent(event map[string]any) *StreamChunk
 	return chunk
 }
 
+// anthropicMaxCacheBreakpoints 单次请求最多的 cache_control 断点数
+const anthropicMaxCacheBreakpoints = 4
+
+// ephemeralCacheControl Prompt Caching 断点
+func ephemeralCacheControl() map[string]any {
+	return map[string]any{"type": "ephemeral"}
+}
+
+// systemBlock 构建 system 文本块，cache 为 true 时设置缓存断点
+func (ap *AnthropicProvider) systemBlock(text string, cache bool) map[string]any {
+	block := map[string]any{
+		"type": "text",
+		"text": text,
+	}
+	if cache {
+		block["cache_control"] = ephemeralCacheControl()
+	}
+	return block
+}
+
+// parseAnthropicUsage 解析 usage，缺失的字段按 0 处理
+func parseAnthropicUsage(usage map[string]any) *TokenUsage {
+	count := func(key string) int64 {
+		v, _ := usage[key].(float64)
+		return int64(v)
+	}
+	result := &TokenUsage{
+		InputTokens:         count("input_tokens"),
+		OutputTokens:        count("output_tokens"),
+		CacheCreationTokens: count("cache_creation_input_tokens"),
+		CacheReadTokens:     count("cache_read_input_tokens"),
+	}
+	result.CachedTokens = result.CacheReadTokens
+	return result
+}
+
+// mergeStartUsage 用 message_start 的统计补全 message_delta 中缺失的输入和缓存 token
+func mergeStartUsage(usage, start *TokenUsage) {
+	if usage == nil || start == nil {
+		return
+	}
+	if usage.InputTokens == 0 {
+		usage.InputTokens = start.InputTokens
+	}
+	if usage.CacheCreationTokens == 0 {
+		usage.CacheCreationTokens = start.CacheCreationTokens
+	}
+	if usage.CacheReadTokens == 0 {
+		usage.CacheReadTokens = start.CacheReadTokens
+		usage.CachedTokens = start.CachedTokens
+	}
+}
+
 // Config 返回配置
 func (ap *AnthropicProvider) Config() *types.ModelConfig {
 	return ap.config
@@ -503,13 +588,16 @@ func (ap *AnthropicProvider) Config() *types.ModelConfig {
 // Capabilities 返回模型能力
 func (ap *AnthropicProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       false, // 根据模型决定
-		MaxTokens:           200000,
-		MaxToolsPerCall:     0, // 无限制
-		ToolCallingFormat:   "anthropic",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true, // Claude 3 及以后的模型均支持图片输入
+		SupportStopSequences: true,
+		SupportPromptCache:   true,
+		MaxTokens:            200000,
+		MaxToolsPerCall:      0, // 无限制
+		ToolCallingFormat:    "anthropic",
+		CacheMinTokens:       1024,
 	}
 }
 
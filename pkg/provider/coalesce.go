@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultCoalesceBytes 合并文本增量的默认字节阈值
+const DefaultCoalesceBytes = 512
+
+// CoalesceStream 按时间窗口或字节阈值合并连续的文本增量，减少下游事件数量
+// opts.CoalesceWindow <= 0 时原样返回输入流。
+// 遇到非文本块（工具调用、块结束、done 等）、流结束或上下文取消时立即刷新，
+// 合并后的文本与原始增量拼接结果完全一致。
+func CoalesceStream(ctx context.Context, in <-chan StreamChunk, opts *StreamOptions) <-chan StreamChunk {
+	if opts == nil || opts.CoalesceWindow <= 0 {
+		return in
+	}
+	maxBytes := opts.CoalesceBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultCoalesceBytes
+	}
+
+	out := make(chan StreamChunk, cap(in))
+	go func() {
+		defer close(out)
+
+		var pending *StreamChunk
+		var text strings.Builder
+		var timer *time.Timer
+		var timeout <-chan time.Time
+
+		flush := func() {
+			if pending == nil {
+				return
+			}
+			out <- withTextDelta(*pending, text.String())
+			pending = nil
+			text.Reset()
+			if timer != nil {
+				timer.Stop()
+			}
+			timeout = nil
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				// 取消后立即刷新，剩余块原样透传，保证上游不被阻塞
+				flush()
+				for chunk := range in {
+					out <- chunk
+				}
+				return
+
+			case <-timeout:
+				flush()
+
+			case chunk, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+
+				delta, mergeable := textDelta(chunk)
+				if !mergeable {
+					flush()
+					out <- chunk
+					continue
+				}
+				if pending != nil && (pending.Type != chunk.Type || pending.Index != chunk.Index) {
+					flush()
+				}
+				if pending == nil {
+					c := chunk
+					pending = &c
+					if timer == nil {
+						timer = time.NewTimer(opts.CoalesceWindow)
+					} else {
+						timer.Reset(opts.CoalesceWindow)
+					}
+					timeout = timer.C
+				}
+				text.WriteString(delta)
+				if text.Len() >= maxBytes {
+					flush()
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// textDelta 提取可合并的文本增量
+// 支持 OpenAI 兼容格式（text）和 Anthropic 格式（content_block_delta/text_delta）
+func textDelta(chunk StreamChunk) (string, bool) {
+	switch chunk.Type {
+	case string(ChunkTypeText):
+		if chunk.TextDelta != "" {
+			return chunk.TextDelta, true
+		}
+		s, ok := chunk.Delta.(string)
+		return s, ok
+	case string(ChunkTypeContentBlockDelta):
+		delta, ok := chunk.Delta.(map[string]any)
+		if !ok || delta["type"] != "text_delta" {
+			return "", false
+		}
+		s, ok := delta["text"].(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// withTextDelta 用合并后的文本替换块中的增量
+func withTextDelta(chunk StreamChunk, text string) StreamChunk {
+	if chunk.Type == string(ChunkTypeText) {
+		chunk.TextDelta = text
+		chunk.Delta = text
+		return chunk
+	}
+	chunk.Delta = map[string]any{"type": "text_delta", "text": text}
+	return chunk
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collectChunks(ch <-chan StreamChunk) []StreamChunk {
+	var chunks []StreamChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func TestCoalesceStream_MergesTextDeltas(t *testing.T) {
+	in := make(chan StreamChunk, 128)
+	var want strings.Builder
+	for i := range 100 {
+		c := string(rune('a' + i%26))
+		want.WriteString(c)
+		in <- StreamChunk{Type: string(ChunkTypeText), TextDelta: c, Delta: c}
+	}
+	in <- StreamChunk{Type: string(ChunkTypeDone), FinishReason: "stop"}
+	close(in)
+
+	chunks := collectChunks(CoalesceStream(context.Background(), in, &StreamOptions{CoalesceWindow: time.Second, CoalesceBytes: 40}))
+
+	var got strings.Builder
+	for _, chunk := range chunks[:len(chunks)-1] {
+		got.WriteString(chunk.TextDelta)
+	}
+	if got.String() != want.String() {
+		t.Errorf("coalesced text differs:\n got %q\nwant %q", got.String(), want.String())
+	}
+	// 100 字节按 40 字节阈值切分为 3 块，再加 done
+	if len(chunks) != 4 || chunks[3].Type != string(ChunkTypeDone) {
+		t.Errorf("expected 3 text chunks and done, got %d chunks", len(chunks))
+	}
+}
+
+func TestCoalesceStream_FlushesAtToolBoundary(t *testing.T) {
+	in := make(chan StreamChunk, 8)
+	textDeltaChunk := func(s string) StreamChunk {
+		return StreamChunk{Type: "content_block_delta", Delta: map[string]any{"type": "text_delta", "text": s}}
+	}
+	in <- textDeltaChunk("Let me ")
+	in <- textDeltaChunk("check.")
+	in <- StreamChunk{Type: "content_block_stop"}
+	in <- StreamChunk{Type: "content_block_start", Index: 1, Delta: map[string]any{"type": "tool_use", "name": "Read"}}
+	close(in)
+
+	chunks := collectChunks(CoalesceStream(context.Background(), in, &StreamOptions{CoalesceWindow: time.Second}))
+	if len(chunks) != 3 {
+		t.Fatalf("expected merged text followed by 2 boundary chunks, got %d", len(chunks))
+	}
+	if text := chunks[0].Delta.(map[string]any)["text"]; text != "Let me check." {
+		t.Errorf("unexpected merged text %q", text)
+	}
+	if chunks[1].Type != "content_block_stop" || chunks[2].Type != "content_block_start" {
+		t.Errorf("boundary chunks out of order: %+v", chunks[1:])
+	}
+}
+
+func TestCoalesceStream_FlushesAfterWindow(t *testing.T) {
+	in := make(chan StreamChunk)
+	out := CoalesceStream(context.Background(), in, &StreamOptions{CoalesceWindow: 10 * time.Millisecond})
+	defer close(in)
+
+	in <- StreamChunk{Type: string(ChunkTypeText), TextDelta: "hi"}
+	select {
+	case chunk := <-out:
+		if chunk.TextDelta != "hi" {
+			t.Errorf("unexpected chunk %+v", chunk)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending text should flush after the coalesce window")
+	}
+}
+
+func TestCoalesceStream_FlushesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan StreamChunk)
+	out := CoalesceStream(ctx, in, &StreamOptions{CoalesceWindow: time.Hour})
+
+	in <- StreamChunk{Type: string(ChunkTypeText), TextDelta: "partial"}
+	cancel()
+	select {
+	case chunk := <-out:
+		if chunk.TextDelta != "partial" {
+			t.Errorf("unexpected chunk %+v", chunk)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending text should flush on cancellation")
+	}
+	close(in)
+	if _, ok := <-out; ok {
+		t.Error("output should close after input closes")
+	}
+}
+
+func TestCoalesceStream_Disabled(t *testing.T) {
+	in := make(chan StreamChunk)
+	if out := CoalesceStream(context.Background(), in, &StreamOptions{}); out != (<-chan StreamChunk)(in) {
+		t.Error("stream should pass through unchanged without a coalesce window")
+	}
+}
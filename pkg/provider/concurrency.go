@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+var limiterLog = logging.ForComponent("ProviderLimiter")
+
+// ErrQueueTimeout 排队等待并发槽位超时
+var ErrQueueTimeout = errors.New("provider concurrency queue wait timed out")
+
+// ConcurrencyLimiterConfig 并发限制配置
+type ConcurrencyLimiterConfig struct {
+	MaxConcurrent int           // 同时进行的最大请求数（必需）
+	MaxWait       time.Duration // 排队的最长等待时间（默认 60s）
+	MaxRetries    int           // 收到 429 后的重试次数（默认 2，负数表示不重试）
+	RetryBackoff  time.Duration // 429 重试的基础退避时间，按指数增长（默认 1s）
+}
+
+// LimiterStats 并发限制器指标
+type LimiterStats struct {
+	MaxConcurrent int           `json:"max_concurrent"`
+	InFlight      int           `json:"in_flight"`   // 正在进行的请求数
+	QueueDepth    int           `json:"queue_depth"` // 正在排队的请求数
+	Acquired      int64         `json:"acquired"`    // 获得槽位的总次数
+	Queued        int64         `json:"queued"`      // 需要排队的总次数
+	TimedOut      int64         `json:"timed_out"`   // 排队超时次数
+	Retried       int64         `json:"retried"`     // 429 重试次数
+	TotalWait     time.Duration `json:"total_wait"`  // 累计排队时间
+	MaxWait       time.Duration `json:"max_wait"`    // 单次最长排队时间
+}
+
+// AvgWait 排队请求的平均等待时间
+func (s LimiterStats) AvgWait() time.Duration {
+	if s.Queued == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Queued)
+}
+
+// ConcurrencyLimiter Provider 级并发限制器
+// 超出上限的请求在有界时间内排队等待，平滑突发流量，避免触发账号级并发限制。
+type ConcurrencyLimiter struct {
+	config ConcurrencyLimiterConfig
+	sem    chan struct{}
+
+	mu    sync.Mutex
+	stats LimiterStats
+}
+
+// NewConcurrencyLimiter 创建并发限制器
+func NewConcurrencyLimiter(config ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 1
+	}
+	if config.MaxWait <= 0 {
+		config.MaxWait = 60 * time.Second
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 2
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+
+	return &ConcurrencyLimiter{
+		config: config,
+		sem:    make(chan struct{}, config.MaxConcurrent),
+		stats:  LimiterStats{MaxConcurrent: config.MaxConcurrent},
+	}
+}
+
+// Acquire 获取并发槽位，返回的 release 必须调用且只能调用一次
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case l.sem <- struct{}{}:
+		l.mu.Lock()
+		l.stats.Acquired++
+		l.stats.InFlight++
+		l.mu.Unlock()
+		return l.releaseFunc(), nil
+	default:
+	}
+
+	// 槽位已满，进入排队
+	start := time.Now()
+	l.mu.Lock()
+	l.stats.Queued++
+	l.stats.QueueDepth++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.config.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case l.sem <- struct{}{}:
+		l.recordWait(time.Since(start), nil)
+		return l.releaseFunc(), nil
+	case <-timer.C:
+		l.recordWait(time.Since(start), ErrQueueTimeout)
+		limiterLog.Warn(ctx, "queue wait timed out", map[string]any{"max_concurrent": l.config.MaxConcurrent, "max_wait": l.config.MaxWait.String()})
+		return nil, fmt.Errorf("%w after %s", ErrQueueTimeout, l.config.MaxWait)
+	case <-ctx.Done():
+		l.recordWait(time.Since(start), ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+// Stats 返回当前指标快照
+func (l *ConcurrencyLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// recordWait 记录排队结束
+func (l *ConcurrencyLimiter) recordWait(wait time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.QueueDepth--
+	l.stats.TotalWait += wait
+	if wait > l.stats.MaxWait {
+		l.stats.MaxWait = wait
+	}
+	switch {
+	case err == nil:
+		l.stats.Acquired++
+		l.stats.InFlight++
+	case errors.Is(err, ErrQueueTimeout):
+		l.stats.TimedOut++
+	}
+}
+
+// releaseFunc 创建只生效一次的释放函数
+func (l *ConcurrencyLimiter) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.stats.InFlight--
+			l.mu.Unlock()
+			<-l.sem
+		})
+	}
+}
+
+// backoff 第 attempt 次 429 重试前等待，等待期间不占用槽位
+func (l *ConcurrencyLimiter) backoff(ctx context.Context, attempt int) error {
+	l.mu.Lock()
+	l.stats.Retried++
+	l.mu.Unlock()
+
+	delay := l.config.RetryBackoff << attempt
+	limiterLog.Debug(ctx, "rate limited, backing off", map[string]any{"attempt": attempt + 1, "delay": delay.String()})
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shouldRetry 是否对错误进行 429 退避重试
+func (l *ConcurrencyLimiter) shouldRetry(err error, attempt int) bool {
+	return err != nil && attempt < l.config.MaxRetries && StatusCodeOf(err) == http.StatusTooManyRequests
+}
+
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = make(map[string]*ConcurrencyLimiter)
+)
+
+// SharedConcurrencyLimiter 返回按 Provider + API Key 共享的并发限制器
+// 同一账号下的多个 Agent 共用一个限制器，首次创建时的配置生效。
+func SharedConcurrencyLimiter(config *types.ModelConfig, limiterConfig ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	sum := sha256.Sum256([]byte(config.APIKey))
+	key := config.Provider + "|" + config.BaseURL + "|" + hex.EncodeToString(sum[:8])
+
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	if limiter, ok := sharedLimiters[key]; ok {
+		return limiter
+	}
+	limiter := NewConcurrencyLimiter(limiterConfig)
+	sharedLimiters[key] = limiter
+	return limiter
+}
+
+// LimitedProvider 带并发限制的 Provider 包装器
+// 流式请求在流结束或调用方取消前一直占用槽位；收到 429 时释放槽位并退避后重新排队。
+// 被包装 Provider 的其他可选接口通过 Unwrap 获取，见 As。
+type LimitedProvider struct {
+	Provider
+	limiter *ConcurrencyLimiter
+	noRetry bool
+}
+
+// NewLimitedProvider 使用并发限制器包装 Provider
+func NewLimitedProvider(p Provider, limiter *ConcurrencyLimiter) *LimitedProvider {
+	return &LimitedProvider{Provider: p, limiter: limiter}
+}
+
+// WithoutRetry 关闭 429 退避重试，由外层（如 RetryProvider）负责重试
+func (p *LimitedProvider) WithoutRetry() *LimitedProvider {
+	p.noRetry = true
+	return p
+}
+
+// Limiter 返回并发限制器
+func (p *LimitedProvider) Limiter() *ConcurrencyLimiter {
+	return p.limiter
+}
+
+// Unwrap 返回被包装的 Provider
+func (p *LimitedProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Stream 排队获取槽位后发起流式请求
+func (p *LimitedProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	for attempt := 0; ; attempt++ {
+		release, err := p.limiter.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		chunks, err := p.Provider.Stream(ctx, messages, opts)
+		if err != nil {
+			release()
+			if !p.noRetry && p.limiter.shouldRetry(err, attempt) {
+				if berr := p.limiter.backoff(ctx, attempt); berr != nil {
+					return nil, berr
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		out := make(chan StreamChunk)
+		go func() {
+			defer close(out)
+			for chunk := range chunks {
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					// 调用方已放弃，立即释放槽位，上游流在后台排空
+					release()
+					go drainStream(chunks)
+					return
+				}
+			}
+			release()
+		}()
+		return out, nil
+	}
+}
+
+// drainStream 消费剩余的块直到上游关闭
+func drainStream(chunks <-chan StreamChunk) {
+	for range chunks {
+	}
+}
+
+// Complete 排队获取槽位后发起非流式请求
+func (p *LimitedProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	for attempt := 0; ; attempt++ {
+		release, err := p.limiter.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := p.Provider.Complete(ctx, messages, opts)
+		release()
+		if !p.noRetry && p.limiter.shouldRetry(err, attempt) {
+			if berr := p.limiter.backoff(ctx, attempt); berr != nil {
+				return nil, berr
+			}
+			continue
+		}
+		return resp, err
+	}
+}
+
+// Ping 透传健康检查，不占用并发槽位
+func (p *LimitedProvider) Ping(ctx context.Context) error {
+	return Ping(ctx, p.Provider)
+}
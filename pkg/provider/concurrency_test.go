@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// slowProvider 记录最大并发数的测试 Provider
+type slowProvider struct {
+	delay       time.Duration
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	calls       atomic.Int32
+	rateLimited atomic.Int32 // 前 N 次调用返回 429
+}
+
+func (p *slowProvider) enter() {
+	n := p.inFlight.Add(1)
+	for {
+		old := p.maxInFlight.Load()
+		if n <= old || p.maxInFlight.CompareAndSwap(old, n) {
+			break
+		}
+	}
+}
+
+func (p *slowProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	p.calls.Add(1)
+	p.enter()
+	ch := make(chan StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		defer p.inFlight.Add(-1)
+		time.Sleep(p.delay)
+		ch <- StreamChunk{Type: "text", TextDelta: "ok"}
+	}()
+	return ch, nil
+}
+
+func (p *slowProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	p.calls.Add(1)
+	if p.rateLimited.Add(-1) >= 0 {
+		return nil, errors.New("mock API error: 429 - rate limited")
+	}
+	p.enter()
+	defer p.inFlight.Add(-1)
+	time.Sleep(p.delay)
+	return &CompleteResponse{Message: types.Message{Role: types.RoleAssistant, Content: "ok"}}, nil
+}
+
+func (p *slowProvider) Config() *types.ModelConfig          { return &types.ModelConfig{Provider: "mock"} }
+func (p *slowProvider) Capabilities() ProviderCapabilities  { return ProviderCapabilities{} }
+func (p *slowProvider) SetSystemPrompt(prompt string) error { return nil }
+func (p *slowProvider) GetSystemPrompt() string             { return "" }
+func (p *slowProvider) Close() error                        { return nil }
+
+func TestLimitedProvider_BoundsConcurrency(t *testing.T) {
+	mock := &slowProvider{delay: 20 * time.Millisecond}
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 2, MaxWait: 5 * time.Second})
+	p := NewLimitedProvider(mock, limiter)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				_, err := p.Complete(context.Background(), nil, nil)
+				errs <- err
+				return
+			}
+			chunks, err := p.Stream(context.Background(), nil, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for range chunks {
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	if got := mock.maxInFlight.Load(); got > 2 {
+		t.Errorf("max in flight = %d, want <= 2", got)
+	}
+	if got := mock.calls.Load(); got != 10 {
+		t.Errorf("calls = %d, want 10", got)
+	}
+
+	stats := limiter.Stats()
+	if stats.InFlight != 0 || stats.QueueDepth != 0 {
+		t.Errorf("limiter not drained: %+v", stats)
+	}
+	if stats.Acquired != 10 {
+		t.Errorf("acquired = %d, want 10", stats.Acquired)
+	}
+	if stats.Queued == 0 || stats.TotalWait <= 0 || stats.AvgWait() <= 0 {
+		t.Errorf("expected queued requests with wait time, got %+v", stats)
+	}
+}
+
+func TestLimitedProvider_QueueTimeout(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, MaxWait: 10 * time.Millisecond})
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer release()
+
+	p := NewLimitedProvider(&slowProvider{}, limiter)
+	if _, err := p.Complete(context.Background(), nil, nil); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected ErrQueueTimeout, got %v", err)
+	}
+	if stats := limiter.Stats(); stats.TimedOut != 1 {
+		t.Errorf("timed out = %d, want 1", stats.TimedOut)
+	}
+}
+
+func TestLimitedProvider_RetriesAfterRateLimit(t *testing.T) {
+	mock := &slowProvider{}
+	mock.rateLimited.Store(2)
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, RetryBackoff: time.Millisecond})
+	p := NewLimitedProvider(mock, limiter)
+
+	if _, err := p.Complete(context.Background(), nil, nil); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if got := mock.calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+	if stats := limiter.Stats(); stats.Retried != 2 || stats.InFlight != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSharedConcurrencyLimiter_PerKey(t *testing.T) {
+	a := SharedConcurrencyLimiter(&types.ModelConfig{Provider: "limiter-test", APIKey: "key-a"}, ConcurrencyLimiterConfig{MaxConcurrent: 2})
+	b := SharedConcurrencyLimiter(&types.ModelConfig{Provider: "limiter-test", APIKey: "key-a"}, ConcurrencyLimiterConfig{MaxConcurrent: 5})
+	c := SharedConcurrencyLimiter(&types.ModelConfig{Provider: "limiter-test", APIKey: "key-b"}, ConcurrencyLimiterConfig{MaxConcurrent: 2})
+
+	if a != b {
+		t.Error("same provider and key should share a limiter")
+	}
+	if a == c {
+		t.Error("different keys should not share a limiter")
+	}
+}
+
+// endlessProvider 持续发送块直到 stop 关闭的测试 Provider
+type endlessProvider struct {
+	slowProvider
+	stop chan struct{}
+}
+
+func (p *endlessProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- StreamChunk{Type: "text", TextDelta: "."}:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func TestLimitedProvider_StreamCancelReleasesSlot(t *testing.T) {
+	upstream := &endlessProvider{stop: make(chan struct{})}
+	defer close(upstream.stop)
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, MaxWait: time.Second})
+	p := NewLimitedProvider(upstream, limiter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := p.Stream(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	<-chunks
+	cancel()
+
+	// 上游仍在发送，槽位应已释放给下一个请求
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("slot should be released after cancel, got %v", err)
+	}
+	release()
+	for range chunks {
+	}
+}
+
+func TestAs(t *testing.T) {
+	mock := &slowProvider{}
+	limited := NewLimitedProvider(mock, NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1}))
+	p := NewRetryProvider(limited, types.RetryConfig{MaxRetries: 1})
+
+	if got, ok := As[*LimitedProvider](p); !ok || got != limited {
+		t.Errorf("expected to find the limited provider, got %v %v", got, ok)
+	}
+	if got, ok := As[*slowProvider](p); !ok || got != mock {
+		t.Errorf("expected to find the innermost provider, got %v %v", got, ok)
+	}
+	if _, ok := As[*FailoverProvider](p); ok {
+		t.Error("expected no failover provider in the chain")
+	}
+}
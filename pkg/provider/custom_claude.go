@@ -93,7 +93,7 @@ func (cp *CustomClaudeProvider) Complete(ctx context.Context, messages []types.M
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		customClaudeLog.Error(ctx, "API error response", map[string]any{"status": resp.StatusCode, "body": string(body)})
-		return nil, fmt.Errorf("api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("custom_claude", resp, body)
 	}
 
 	var apiResp map[string]any
@@ -167,7 +167,7 @@ func (cp *CustomClaudeProvider) Stream(ctx context.Context, messages []types.Mes
 				"tail": string(jsonData[max(0, len(jsonData)-5000):]),
 			})
 		}
-		return nil, fmt.Errorf("api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("custom_claude", resp, body)
 	}
 
 	chunkCh := make(chan StreamChunk, 10)
@@ -212,6 +212,11 @@ func (cp *CustomClaudeProvider) buildRequest(messages []types.Message, opts *Str
 			req["temperature"] = opts.Temperature
 		}
 
+		if len(opts.StopSequences) > 0 {
+			req["stop_sequences"] = opts.StopSequences
+		}
+		logUnsupportedOptions(customClaudeLog, "custom_claude", cp.Capabilities(), opts)
+
 		if opts.System != "" {
 			req["system"] = opts.System
 		} else if cp.systemPrompt != "" {
@@ -644,13 +649,14 @@ func (cp *CustomClaudeProvider) Config() *types.ModelConfig {
 // Capabilities 返回模型能力
 func (cp *CustomClaudeProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true,
-		MaxTokens:           200000,
-		MaxToolsPerCall:     0,
-		ToolCallingFormat:   "anthropic",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true,
+		SupportStopSequences: true,
+		MaxTokens:            200000,
+		MaxToolsPerCall:      0,
+		ToolCallingFormat:    "anthropic",
 	}
 }
 
@@ -103,7 +103,7 @@ func (dp *DeepseekProvider) Complete(ctx context.Context, messages []types.Messa
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		deepseekLog.Error(ctx, "API error response", map[string]any{"body": string(body)})
-		return nil, fmt.Errorf("deepseek api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("deepseek", resp, body)
 	}
 
 	deepseekLog.Debug(ctx, "parsing API response", nil)
@@ -189,7 +189,7 @@ func (dp *DeepseekProvider) Stream(ctx context.Context, messages []types.Message
 		body, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
 		deepseekLog.Error(ctx, "API error response", map[string]any{"body": string(body)})
-		return nil, fmt.Errorf("deepseek api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("deepseek", resp, body)
 	}
 
 	deepseekLog.Debug(ctx, "API request successful", map[string]any{"status": resp.StatusCode})
@@ -246,6 +246,11 @@ func (dp *DeepseekProvider) buildRequest(messages []types.Message, opts *StreamO
 			req["temperature"] = opts.Temperature
 		}
 
+		if len(opts.StopSequences) > 0 {
+			req["stop"] = opts.StopSequences
+		}
+		logUnsupportedOptions(deepseekLog, "deepseek", dp.Capabilities(), opts)
+
 		if len(opts.Tools) > 0 {
 			// Deepseek API 使用 tools 字段，格式与 OpenAI 完全兼容
 			tools := make([]map[string]any, 0, len(opts.Tools))
@@ -568,13 +573,14 @@ func (dp *DeepseekProvider) Config() *types.ModelConfig {
 // Capabilities 返回模型能力
 func (dp *DeepseekProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       false,
-		MaxTokens:           8192,
-		MaxToolsPerCall:     0,
-		ToolCallingFormat:   "openai", // Deepseek 使用 OpenAI 兼容格式
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        false,
+		SupportStopSequences: true,
+		MaxTokens:            8192,
+		MaxToolsPerCall:      0,
+		ToolCallingFormat:    "openai", // Deepseek 使用 OpenAI 兼容格式
 	}
 }
 
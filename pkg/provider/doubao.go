@@ -78,17 +78,18 @@ func NewDoubaoProviderSimple(config *types.ModelConfig) (Provider, error) {
 // Capabilities 返回 Doubao 的能力
 func (p *DoubaoProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true, // 部分模型支持
-		SupportAudio:        false,
-		SupportReasoning:    false,
-		SupportPromptCache:  false,
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           32768, // 取决于具体模型
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true, // 部分模型支持
+		SupportAudio:         false,
+		SupportReasoning:     false,
+		SupportPromptCache:   false,
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            32768, // 取决于具体模型
+		ToolCallingFormat:    "openai",
 	}
 }
 
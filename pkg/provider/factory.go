@@ -24,7 +24,53 @@ func NewMultiProviderFactory() *MultiProviderFactory {
 }
 
 // Create 根据配置创建相应的提供商
+// 配置了 MaxConcurrent 时，使用按 Provider/Key 共享的并发限制器包装；
+// 配置了 Retry 时在最外层重试，退避期间不占用并发槽位，限制器不再单独重试 429
 func (f *MultiProviderFactory) Create(config *types.ModelConfig) (Provider, error) {
+	p, err := f.createWithFailover(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxConcurrent > 0 {
+		limiter := SharedConcurrencyLimiter(config, ConcurrencyLimiterConfig{MaxConcurrent: config.MaxConcurrent})
+		limited := NewLimitedProvider(p, limiter)
+		if config.Retry != nil {
+			limited.WithoutRetry()
+		}
+		p = limited
+	}
+	if config.Retry != nil && config.Retry.MaxRetries > 0 {
+		p = NewRetryProvider(p, *config.Retry)
+	}
+	return p, nil
+}
+
+// createWithFailover 配置了多个 Base URL 时创建带端点故障转移的 Provider
+func (f *MultiProviderFactory) createWithFailover(config *types.ModelConfig) (Provider, error) {
+	urls := endpointURLs(config)
+	switch len(urls) {
+	case 0:
+		return f.create(config)
+	case 1:
+		single := *config
+		single.BaseURL = urls[0]
+		single.BaseURLs = nil
+		return f.create(&single)
+	}
+	return NewFailoverProvider(config, urls, config.EndpointCooldown, f.create)
+}
+
+// create 创建提供商，配置了 RequestTimeout 时为每个端点的请求设置超时
+func (f *MultiProviderFactory) create(config *types.ModelConfig) (Provider, error) {
+	p, err := f.createProvider(config)
+	if err != nil || config.RequestTimeout <= 0 {
+		return p, err
+	}
+	return NewTimeoutProvider(p, config.RequestTimeout), nil
+}
+
+// createProvider 根据 Provider 类型创建提供商
+func (f *MultiProviderFactory) createProvider(config *types.ModelConfig) (Provider, error) {
 	providerType := config.Provider
 	if providerType == "" {
 		// 默认使用 anthropic
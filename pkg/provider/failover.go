@@ -0,0 +1,302 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+var failoverLog = logging.ForComponent("ProviderFailover")
+
+// DefaultEndpointCooldown 端点失败后的默认冷却时间
+const DefaultEndpointCooldown = 30 * time.Second
+
+// EndpointStatus 端点健康状态
+type EndpointStatus struct {
+	BaseURL       string    `json:"base_url"`
+	Healthy       bool      `json:"healthy"` // 不在冷却期内
+	CooldownUntil time.Time `json:"cooldown_until,omitzero"`
+	Served        int64     `json:"served"`   // 成功处理的请求数
+	Failures      int64     `json:"failures"` // 失败次数
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// failoverEndpoint 单个端点及其状态
+type failoverEndpoint struct {
+	baseURL  string
+	provider Provider
+
+	cooldownUntil time.Time
+	served        int64
+	failures      int64
+	lastError     string
+}
+
+// FailoverProvider 同一模型多个 Base URL 之间的端点故障转移
+// 按配置顺序优先使用健康端点；端点返回连接错误、5xx、408 或 429 时进入冷却期并切换到下一个端点，
+// 冷却期结束后重新参与路由。所有端点都在冷却期时，按冷却结束时间依次尝试。
+// 与模型降级（ModelFallback）不同，故障转移不会更换模型。
+type FailoverProvider struct {
+	config    *types.ModelConfig
+	cooldown  time.Duration
+	endpoints []*failoverEndpoint
+
+	mu sync.Mutex
+}
+
+// NewFailoverProvider 为每个 Base URL 创建一个 Provider 并组合为故障转移 Provider
+// create 使用替换了 BaseURL 的配置副本创建单个端点的 Provider；cooldown <= 0 时使用默认值。
+func NewFailoverProvider(
+	config *types.ModelConfig,
+	baseURLs []string,
+	cooldown time.Duration,
+	create func(*types.ModelConfig) (Provider, error),
+) (*FailoverProvider, error) {
+	if len(baseURLs) == 0 {
+		return nil, errors.New("failover provider requires at least one base url")
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultEndpointCooldown
+	}
+
+	p := &FailoverProvider{config: config, cooldown: cooldown}
+	for _, baseURL := range baseURLs {
+		endpointConfig := *config
+		endpointConfig.BaseURL = baseURL
+		endpointConfig.BaseURLs = nil
+
+		inner, err := create(&endpointConfig)
+		if err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("create endpoint %s: %w", baseURL, err)
+		}
+		p.endpoints = append(p.endpoints, &failoverEndpoint{baseURL: baseURL, provider: inner})
+	}
+
+	failoverLog.Info(context.Background(), "failover provider created", map[string]any{
+		"provider":  config.Provider,
+		"model":     config.Model,
+		"endpoints": baseURLs,
+		"cooldown":  cooldown.String(),
+	})
+	return p, nil
+}
+
+// Stream 依次尝试端点发起流式请求，第一个块中记录实际处理请求的端点
+// 只在建立流之前进行故障转移，流开始后的错误由调用方处理。
+func (p *FailoverProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	var lastErr error
+	for _, ep := range p.candidates() {
+		chunks, err := ep.provider.Stream(ctx, messages, opts)
+		if err == nil {
+			p.markServed(ctx, ep)
+			return withEndpoint(ctx, chunks, ep.baseURL), nil
+		}
+		if !shouldFailover(ctx, err) {
+			return nil, err
+		}
+		p.markFailed(ctx, ep, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all %d endpoints failed: %w", len(p.endpoints), lastErr)
+}
+
+// Complete 依次尝试端点发起非流式请求，响应中记录实际处理请求的端点
+func (p *FailoverProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	var lastErr error
+	for _, ep := range p.candidates() {
+		resp, err := ep.provider.Complete(ctx, messages, opts)
+		if err == nil {
+			p.markServed(ctx, ep)
+			resp.Endpoint = ep.baseURL
+			return resp, nil
+		}
+		if !shouldFailover(ctx, err) {
+			return nil, err
+		}
+		p.markFailed(ctx, ep, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all %d endpoints failed: %w", len(p.endpoints), lastErr)
+}
+
+// Ping 依次检查端点，任一端点可用即返回成功
+func (p *FailoverProvider) Ping(ctx context.Context) error {
+	var lastErr error
+	for _, ep := range p.candidates() {
+		err := Ping(ctx, ep.provider)
+		if err == nil {
+			return nil
+		}
+		if !shouldFailover(ctx, err) {
+			return err
+		}
+		p.markFailed(ctx, ep, err)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// Endpoints 返回各端点的健康状态快照
+func (p *FailoverProvider) Endpoints() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		status := EndpointStatus{
+			BaseURL:   ep.baseURL,
+			Healthy:   !now.Before(ep.cooldownUntil),
+			Served:    ep.served,
+			Failures:  ep.failures,
+			LastError: ep.lastError,
+		}
+		if !status.Healthy {
+			status.CooldownUntil = ep.cooldownUntil
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Config 返回原始配置
+func (p *FailoverProvider) Config() *types.ModelConfig {
+	return p.config
+}
+
+// Capabilities 返回首个端点的模型能力（各端点为同一模型）
+func (p *FailoverProvider) Capabilities() ProviderCapabilities {
+	return p.endpoints[0].provider.Capabilities()
+}
+
+// SetSystemPrompt 为所有端点设置系统提示词
+func (p *FailoverProvider) SetSystemPrompt(prompt string) error {
+	for _, ep := range p.endpoints {
+		if err := ep.provider.SetSystemPrompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSystemPrompt 获取系统提示词
+func (p *FailoverProvider) GetSystemPrompt() string {
+	return p.endpoints[0].provider.GetSystemPrompt()
+}
+
+// Close 关闭所有端点
+func (p *FailoverProvider) Close() error {
+	var errs []error
+	for _, ep := range p.endpoints {
+		if err := ep.provider.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// candidates 返回本次请求的端点尝试顺序：健康端点按配置顺序在前，冷却中的端点按冷却结束时间在后
+func (p *FailoverProvider) candidates() []*failoverEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []*failoverEndpoint
+	for _, ep := range p.endpoints {
+		if now.Before(ep.cooldownUntil) {
+			cooling = append(cooling, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+	sort.SliceStable(cooling, func(i, j int) bool {
+		return cooling[i].cooldownUntil.Before(cooling[j].cooldownUntil)
+	})
+	return append(healthy, cooling...)
+}
+
+// markServed 记录端点成功处理请求，并结束其冷却期
+func (p *FailoverProvider) markServed(ctx context.Context, ep *failoverEndpoint) {
+	p.mu.Lock()
+	ep.served++
+	ep.cooldownUntil = time.Time{}
+	p.mu.Unlock()
+
+	failoverLog.Debug(ctx, "request served", map[string]any{"model": p.config.Model, "endpoint": ep.baseURL})
+}
+
+// withEndpoint 在流的第一个块中填写处理请求的端点
+func withEndpoint(ctx context.Context, chunks <-chan StreamChunk, endpoint string) <-chan StreamChunk {
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		first := true
+		for chunk := range chunks {
+			if first {
+				chunk.Endpoint = endpoint
+				first = false
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go drainStream(chunks)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// markFailed 记录端点失败并进入冷却期
+func (p *FailoverProvider) markFailed(ctx context.Context, ep *failoverEndpoint, err error) {
+	p.mu.Lock()
+	ep.failures++
+	ep.lastError = err.Error()
+	ep.cooldownUntil = time.Now().Add(p.cooldown)
+	p.mu.Unlock()
+
+	failoverLog.Warn(ctx, "endpoint failed, cooling down", map[string]any{
+		"model":    p.config.Model,
+		"endpoint": ep.baseURL,
+		"cooldown": p.cooldown.String(),
+		"error":    err.Error(),
+	})
+}
+
+// shouldFailover 是否切换到下一个端点
+// 连接错误和服务端错误可通过切换端点恢复；其他 4xx 是请求本身的问题，切换端点无意义。
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	code := StatusCodeOf(err)
+	return code == 0 || code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// endpointURLs 返回配置的去重端点列表，未配置 BaseURLs 时返回 nil
+func endpointURLs(config *types.ModelConfig) []string {
+	if len(config.BaseURLs) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, u := range append([]string{config.BaseURL}, config.BaseURLs...) {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// endpointProvider 按 BaseURL 区分的测试端点
+type endpointProvider struct {
+	config *types.ModelConfig
+	down   *atomic.Bool
+	calls  atomic.Int32
+}
+
+func (p *endpointProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	p.calls.Add(1)
+	if p.down.Load() {
+		return nil, errors.New("mock API error: 503 - unavailable")
+	}
+	ch := make(chan StreamChunk, 1)
+	ch <- StreamChunk{Type: "text", TextDelta: p.config.BaseURL}
+	close(ch)
+	return ch, nil
+}
+
+func (p *endpointProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	p.calls.Add(1)
+	if p.down.Load() {
+		return nil, errors.New("mock API error: 503 - unavailable")
+	}
+	return &CompleteResponse{Message: types.Message{Role: types.RoleAssistant, Content: "ok"}}, nil
+}
+
+func (p *endpointProvider) Config() *types.ModelConfig          { return p.config }
+func (p *endpointProvider) Capabilities() ProviderCapabilities  { return ProviderCapabilities{} }
+func (p *endpointProvider) SetSystemPrompt(prompt string) error { return nil }
+func (p *endpointProvider) GetSystemPrompt() string             { return "" }
+func (p *endpointProvider) Close() error                        { return nil }
+
+func TestFailoverProvider_ShiftsTrafficToHealthyEndpoint(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	endpoints := map[string]*endpointProvider{}
+	create := func(cfg *types.ModelConfig) (Provider, error) {
+		ep := &endpointProvider{config: cfg, down: &atomic.Bool{}}
+		if cfg.BaseURL == "https://a.example.com" {
+			ep.down = &primaryDown
+		}
+		endpoints[cfg.BaseURL] = ep
+		return ep, nil
+	}
+
+	p, err := NewFailoverProvider(&types.ModelConfig{Provider: "custom", Model: "m"},
+		[]string{"https://a.example.com", "https://b.example.com"}, 50*time.Millisecond, create)
+	if err != nil {
+		t.Fatalf("NewFailoverProvider: %v", err)
+	}
+	primary, secondary := endpoints["https://a.example.com"], endpoints["https://b.example.com"]
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	resp, err := p.Complete(context.Background(), msgs, nil)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Endpoint != "https://b.example.com" {
+		t.Errorf("request should be served by the second endpoint, got %q", resp.Endpoint)
+	}
+
+	// 冷却期内跳过失败的端点
+	chunks, err := p.Stream(context.Background(), msgs, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	for chunk := range chunks {
+		if chunk.TextDelta != "https://b.example.com" || chunk.Endpoint != "https://b.example.com" {
+			t.Errorf("stream should be served by the second endpoint, got %q (endpoint %q)", chunk.TextDelta, chunk.Endpoint)
+		}
+	}
+	if primary.calls.Load() != 1 || secondary.calls.Load() != 2 {
+		t.Errorf("primary should be skipped while cooling down, calls primary=%d secondary=%d",
+			primary.calls.Load(), secondary.calls.Load())
+	}
+	status := p.Endpoints()
+	if status[0].Healthy || status[0].Failures != 1 || !status[1].Healthy || status[1].Served != 2 {
+		t.Errorf("unexpected endpoint status %+v", status)
+	}
+
+	// 冷却结束后恢复的端点重新优先使用
+	primaryDown.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	resp, err = p.Complete(context.Background(), msgs, nil)
+	if err != nil {
+		t.Fatalf("Complete after cooldown: %v", err)
+	}
+	if resp.Endpoint != "https://a.example.com" {
+		t.Errorf("recovered primary should serve again, got %q", resp.Endpoint)
+	}
+}
+
+func TestFailoverProvider_NoFailoverOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	create := func(cfg *types.ModelConfig) (Provider, error) {
+		return &slowProvider{}, nil
+	}
+	p, err := NewFailoverProvider(&types.ModelConfig{Model: "m"}, []string{"a", "b"}, 0, create)
+	if err != nil {
+		t.Fatalf("NewFailoverProvider: %v", err)
+	}
+	p.endpoints[0].provider = &errorProvider{err: errors.New("mock API error: 400 - bad request"), calls: &calls}
+	p.endpoints[1].provider = &errorProvider{err: errors.New("unexpected"), calls: &calls}
+
+	_, err = p.Complete(context.Background(), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "400") || calls.Load() != 1 {
+		t.Errorf("client errors should not fail over, err=%v calls=%d", err, calls.Load())
+	}
+}
+
+func TestEndpointURLs(t *testing.T) {
+	got := endpointURLs(&types.ModelConfig{BaseURL: "a", BaseURLs: []string{"b", "a", " ", "c"}})
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("unexpected endpoints %v", got)
+	}
+	if endpointURLs(&types.ModelConfig{BaseURL: "a"}) != nil {
+		t.Error("single base url should not enable failover")
+	}
+}
+
+// errorProvider 始终返回指定错误的测试 Provider
+type errorProvider struct {
+	slowProvider
+	err   error
+	calls *atomic.Int32
+}
+
+func (p *errorProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	p.calls.Add(1)
+	return nil, p.err
+}
@@ -9,16 +9,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/astercloud/aster/pkg/logging"
 	"github.com/astercloud/aster/pkg/types"
 	"github.com/astercloud/aster/pkg/util"
 )
 
+var geminiLog = logging.ForComponent("GeminiProvider")
+
 const (
 	// GeminiAPIBaseURL Gemini API 基础 URL
 	GeminiAPIBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+	// GeminiDefaultModel 默认模型
+	GeminiDefaultModel = "gemini-2.0-flash"
 )
 
 // GeminiProvider Google Gemini 提供商
@@ -44,6 +51,9 @@ type GeminiPart struct {
 	// 内联数据（图片、音频等）
 	InlineData *GeminiBlob `json:"inlineData,omitempty"`
 
+	// 文件引用（图片 URL 等）
+	FileData *GeminiFileData `json:"fileData,omitempty"`
+
 	// 函数调用
 	FunctionCall *GeminiFunctionCall `json:"functionCall,omitempty"`
 
@@ -57,6 +67,12 @@ type GeminiBlob struct {
 	Data     string `json:"data"` // base64 编码
 }
 
+// GeminiFileData 通过 URI 引用的文件
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
 // GeminiFunctionCall 函数调用
 type GeminiFunctionCall struct {
 	Name string         `json:"name"`
@@ -82,6 +98,9 @@ type GeminiFunctionDeclaration struct {
 }
 
 // NewGeminiProvider 创建 Gemini 提供商
+//
+// BaseURL 可指向 Vertex AI 兼容端点（如 https://{region}-aiplatform.googleapis.com/v1/projects/{project}/locations/{region}/publishers/google），
+// 此时 APIKey 作为 OAuth access token 以 Bearer 方式发送。
 func NewGeminiProvider(config *types.ModelConfig) (Provider, error) {
 	if config.APIKey == "" {
 		return nil, errors.New("gemini: API key is required")
@@ -89,11 +108,11 @@ func NewGeminiProvider(config *types.ModelConfig) (Provider, error) {
 
 	// 设置默认模型
 	if config.Model == "" {
-		config.Model = "gemini-2.0-flash-exp" // 最新的 Gemini 2.0 Flash
+		config.Model = GeminiDefaultModel
 	}
 
 	// 使用配置中的 BaseURL，或使用默认值
-	baseURL := config.BaseURL
+	baseURL := strings.TrimRight(config.BaseURL, "/")
 	if baseURL == "" {
 		baseURL = GeminiAPIBaseURL
 	}
@@ -115,8 +134,7 @@ func (p *GeminiProvider) Stream(
 	requestBody := p.buildRequest(messages, opts, true)
 
 	// 发送 HTTP 请求
-	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s",
-		p.baseURL, p.config.Model, p.config.APIKey)
+	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", p.baseURL, p.config.Model)
 
 	// 使用确定性序列化以优化 KV-Cache 命中率
 	bodyBytes, err := util.MarshalDeterministic(requestBody)
@@ -130,6 +148,7 @@ func (p *GeminiProvider) Stream(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	p.setAuthHeader(req)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -140,7 +159,7 @@ func (p *GeminiProvider) Stream(
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
-		return nil, fmt.Errorf("gemini API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("gemini", resp, body)
 	}
 
 	// 创建流式响应 channel
@@ -162,8 +181,7 @@ func (p *GeminiProvider) Complete(
 	requestBody := p.buildRequest(messages, opts, false)
 
 	// 发送 HTTP 请求
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s",
-		p.baseURL, p.config.Model, p.config.APIKey)
+	url := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, p.config.Model)
 
 	// 使用确定性序列化以优化 KV-Cache 命中率
 	bodyBytes, err := util.MarshalDeterministic(requestBody)
@@ -177,6 +195,7 @@ func (p *GeminiProvider) Complete(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	p.setAuthHeader(req)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -187,7 +206,7 @@ func (p *GeminiProvider) Complete(
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("gemini API error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("gemini", resp, body)
 	}
 
 	// 解析响应
@@ -245,6 +264,10 @@ func (p *GeminiProvider) buildRequest(
 		if opts.Temperature > 0 {
 			generationConfig["temperature"] = opts.Temperature
 		}
+		if len(opts.StopSequences) > 0 {
+			generationConfig["stopSequences"] = opts.StopSequences
+		}
+		logUnsupportedOptions(geminiLog, "gemini", p.Capabilities(), opts)
 	}
 	if len(generationConfig) > 0 {
 		requestBody["generationConfig"] = generationConfig
@@ -253,6 +276,9 @@ func (p *GeminiProvider) buildRequest(
 	// 添加工具
 	if opts != nil && len(opts.Tools) > 0 {
 		requestBody["tools"] = []GeminiTool{p.convertTools(opts.Tools)}
+		if opts.ToolChoice != nil {
+			requestBody["toolConfig"] = geminiToolConfig(opts.ToolChoice)
+		}
 	}
 
 	return requestBody
@@ -262,6 +288,16 @@ func (p *GeminiProvider) buildRequest(
 func (p *GeminiProvider) convertMessages(messages []types.Message) []GeminiContent {
 	result := make([]GeminiContent, 0, len(messages))
 
+	// 工具结果需要使用函数名，按工具调用 ID 查找
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		for _, block := range msg.ContentBlocks {
+			if tu, ok := block.(*types.ToolUseBlock); ok {
+				toolNames[tu.ID] = tu.Name
+			}
+		}
+	}
+
 	for _, msg := range messages {
 		// 跳过 system 消息（在 systemInstruction 中处理）
 		if msg.Role == types.RoleSystem {
@@ -300,10 +336,11 @@ func (p *GeminiProvider) convertMessages(messages []types.Message) []GeminiConte
 							},
 						})
 					case "url":
-						// Gemini 不直接支持 URL，需要先下载并转换为 base64
-						// 这里简化处理，实际应用中需要下载图片
 						content.Parts = append(content.Parts, GeminiPart{
-							Text: fmt.Sprintf("[图片: %s]", b.Source),
+							FileData: &GeminiFileData{
+								MimeType: b.MimeType,
+								FileURI:  b.Source,
+							},
 						})
 					}
 
@@ -332,21 +369,31 @@ func (p *GeminiProvider) convertMessages(messages []types.Message) []GeminiConte
 
 				case *types.ToolUseBlock:
 					// 工具调用
+					args := b.Input
+					if args == nil {
+						args = map[string]any{}
+					}
 					content.Parts = append(content.Parts, GeminiPart{
 						FunctionCall: &GeminiFunctionCall{
 							Name: b.Name,
-							Args: b.Input,
+							Args: args,
 						},
 					})
 
 				case *types.ToolResultBlock:
 					// 工具结果
+					name, ok := toolNames[b.ToolUseID]
+					if !ok {
+						name = b.ToolUseID // 找不到对应调用时退回 ID
+					}
+					response := map[string]any{"content": b.Content}
+					if b.IsError {
+						response["error"] = true
+					}
 					content.Parts = append(content.Parts, GeminiPart{
 						FunctionResponse: &GeminiFunctionResponse{
-							Name: b.ToolUseID, // 使用 ID 作为名称
-							Response: map[string]any{
-								"content": b.Content,
-							},
+							Name:     name,
+							Response: response,
 						},
 					})
 				}
@@ -372,7 +419,7 @@ func (p *GeminiProvider) convertTools(tools []ToolSchema) GeminiTool {
 		declarations = append(declarations, GeminiFunctionDeclaration{
 			Name:        tool.Name,
 			Description: tool.Description,
-			Parameters:  tool.InputSchema,
+			Parameters:  geminiSchema(tool.InputSchema),
 			// TODO: Gemini API 暂不支持 input_examples，待官方支持后启用
 			// 参考: https://ai.google.dev/api/caching
 			// 实现参考: pkg/provider/anthropic.go buildRequest() 中的 InputExamples 处理
@@ -391,6 +438,7 @@ func (p *GeminiProvider) parseSSEStream(body io.ReadCloser, chunks chan<- Stream
 
 	scanner := bufio.NewScanner(body)
 	scanner.Split(bufio.ScanLines)
+	state := &geminiStreamState{}
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -410,7 +458,7 @@ func (p *GeminiProvider) parseSSEStream(body io.ReadCloser, chunks chan<- Stream
 		}
 
 		// 解析 chunk 并转换为 StreamChunk
-		streamChunks := p.parseStreamChunk(chunk)
+		streamChunks := p.parseStreamChunk(chunk, state)
 		for _, sc := range streamChunks {
 			chunks <- sc
 		}
@@ -427,8 +475,24 @@ func (p *GeminiProvider) parseSSEStream(body io.ReadCloser, chunks chan<- Stream
 	}
 }
 
+// geminiStreamState 单次流式响应的解析状态
+type geminiStreamState struct {
+	textSeen  bool
+	toolCalls int
+}
+
+// nextToolCallIndex 分配工具调用的内容块索引：已有文本输出时从 1 开始，避免与文本块冲突
+func (s *geminiStreamState) nextToolCallIndex() int {
+	index := s.toolCalls
+	if s.textSeen {
+		index++
+	}
+	s.toolCalls++
+	return index
+}
+
 // parseStreamChunk 解析单个流式 chunk
-func (p *GeminiProvider) parseStreamChunk(chunk map[string]any) []StreamChunk {
+func (p *GeminiProvider) parseStreamChunk(chunk map[string]any, state *geminiStreamState) []StreamChunk {
 	result := make([]StreamChunk, 0)
 
 	// 获取 candidates
@@ -460,10 +524,16 @@ func (p *GeminiProvider) parseStreamChunk(chunk map[string]any) []StreamChunk {
 
 	// 解析每个 part
 	for _, partData := range parts {
-		part := partData.(map[string]any)
+		part, ok := partData.(map[string]any)
+		if !ok {
+			continue
+		}
 
 		// 文本内容
 		if text, ok := part["text"].(string); ok && text != "" {
+			if state.toolCalls == 0 {
+				state.textSeen = true
+			}
 			result = append(result, StreamChunk{
 				Type:      string(ChunkTypeText),
 				TextDelta: text,
@@ -471,18 +541,18 @@ func (p *GeminiProvider) parseStreamChunk(chunk map[string]any) []StreamChunk {
 			})
 		}
 
-		// 函数调用
-		if functionCall, ok := part["functionCall"].(map[string]any); ok {
-			name := functionCall["name"].(string)
-			args := functionCall["args"].(map[string]any)
-
-			// 序列化参数
+		// 函数调用（Gemini 每次返回完整的调用，不分片）
+		if name, args, ok := geminiFunctionCall(part); ok {
 			argsJSON, _ := json.Marshal(args)
+			index := state.nextToolCallIndex()
 
 			result = append(result, StreamChunk{
-				Type: string(ChunkTypeToolCall),
+				Type:  string(ChunkTypeToolCall),
+				Index: index,
 				ToolCall: &ToolCallDelta{
-					Index:          0,
+					Index:          index,
+					ID:             fmt.Sprintf("%s_%d", name, index),
+					Type:           "function",
 					Name:           name,
 					ArgumentsDelta: string(argsJSON),
 				},
@@ -499,6 +569,14 @@ func (p *GeminiProvider) parseStreamChunk(chunk map[string]any) []StreamChunk {
 		})
 	}
 
+	// 最后一个 chunk 通常同时包含内容和 finishReason
+	if finishReason, ok := candidate["finishReason"].(string); ok && finishReason != "" {
+		result = append(result, StreamChunk{
+			Type:         string(ChunkTypeDone),
+			FinishReason: strings.ToLower(finishReason),
+		})
+	}
+
 	return result
 }
 
@@ -529,7 +607,10 @@ func (p *GeminiProvider) parseCompleteResponse(apiResp map[string]any) (types.Me
 	textParts := make([]string, 0)
 
 	for _, partData := range parts {
-		part := partData.(map[string]any)
+		part, ok := partData.(map[string]any)
+		if !ok {
+			continue
+		}
 
 		// 文本内容
 		if text, ok := part["text"].(string); ok {
@@ -537,12 +618,9 @@ func (p *GeminiProvider) parseCompleteResponse(apiResp map[string]any) (types.Me
 		}
 
 		// 函数调用
-		if functionCall, ok := part["functionCall"].(map[string]any); ok {
-			name := functionCall["name"].(string)
-			args := functionCall["args"].(map[string]any)
-
+		if name, args, ok := geminiFunctionCall(part); ok {
 			blocks = append(blocks, &types.ToolUseBlock{
-				ID:    name, // Gemini 不返回 ID，使用名称
+				ID:    fmt.Sprintf("%s_%d", name, len(blocks)), // Gemini 不返回 ID，按名称和序号生成
 				Name:  name,
 				Input: args,
 			})
@@ -589,14 +667,93 @@ func (p *GeminiProvider) parseUsageFromMap(usageData map[string]any) *TokenUsage
 		usage.TotalTokens = int64(totalTokens)
 	}
 
+	if thoughtsTokens, ok := usageData["thoughtsTokenCount"].(float64); ok {
+		usage.ReasoningTokens = int64(thoughtsTokens)
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens + usage.ReasoningTokens
+	}
+
 	// Gemini 支持 Context Caching
 	if cachedTokens, ok := usageData["cachedContentTokenCount"].(float64); ok {
 		usage.CachedTokens = int64(cachedTokens)
+		usage.CacheReadTokens = int64(cachedTokens)
 	}
 
 	return usage
 }
 
+// setAuthHeader 设置鉴权头：Vertex AI 端点使用 Bearer token，其余使用 API Key
+func (p *GeminiProvider) setAuthHeader(req *http.Request) {
+	if strings.Contains(p.baseURL, "aiplatform.googleapis.com") {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+		return
+	}
+	req.Header.Set("X-Goog-Api-Key", p.config.APIKey)
+}
+
+// geminiFunctionCall 解析 functionCall part，无参数的调用返回空 map
+func geminiFunctionCall(part map[string]any) (string, map[string]any, bool) {
+	functionCall, ok := part["functionCall"].(map[string]any)
+	if !ok {
+		return "", nil, false
+	}
+	name, _ := functionCall["name"].(string)
+	args, _ := functionCall["args"].(map[string]any)
+	if args == nil {
+		args = map[string]any{}
+	}
+	return name, args, name != ""
+}
+
+// geminiToolConfig 转换工具选择策略
+func geminiToolConfig(choice *ToolChoiceOption) map[string]any {
+	config := map[string]any{"mode": "AUTO"}
+	switch choice.Type {
+	case "any":
+		config["mode"] = "ANY"
+	case "tool":
+		config["mode"] = "ANY"
+		config["allowedFunctionNames"] = []string{choice.Name}
+	case "none":
+		config["mode"] = "NONE"
+	}
+	return map[string]any{"functionCallingConfig": config}
+}
+
+// geminiUnsupportedSchemaKeys Gemini 函数声明不接受的 JSON Schema 字段
+var geminiUnsupportedSchemaKeys = []string{"$schema", "additionalProperties", "$id", "$defs", "definitions"}
+
+// geminiSchema 复制 JSON Schema 并递归去除 Gemini 不支持的字段
+func geminiSchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	result := make(map[string]any, len(schema))
+	for key, value := range schema {
+		if slices.Contains(geminiUnsupportedSchemaKeys, key) {
+			continue
+		}
+		result[key] = geminiSchemaValue(value)
+	}
+	return result
+}
+
+func geminiSchemaValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return geminiSchema(v)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = geminiSchemaValue(item)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
 // Config 返回配置
 func (p *GeminiProvider) Config() *types.ModelConfig {
 	return p.config
@@ -605,19 +762,20 @@ func (p *GeminiProvider) Config() *types.ModelConfig {
 // Capabilities 返回能力
 func (p *GeminiProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true,
-		SupportAudio:        true,
-		SupportVideo:        true, // Gemini 独特支持视频
-		SupportReasoning:    false,
-		SupportPromptCache:  true, // Context Caching
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           1048576, // Gemini 2.0 支持 1M tokens
-		ToolCallingFormat:   "gemini",
-		CacheMinTokens:      32768, // 32K 最小缓存
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true,
+		SupportAudio:         true,
+		SupportVideo:         true, // Gemini 独特支持视频
+		SupportReasoning:     false,
+		SupportPromptCache:   true, // Context Caching
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            1048576, // Gemini 2.0 支持 1M tokens
+		ToolCallingFormat:    "gemini",
+		CacheMinTokens:       32768, // 32K 最小缓存
 	}
 }
 
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func newTestGeminiProvider(t *testing.T, baseURL string) *GeminiProvider {
+	t.Helper()
+	p, err := NewGeminiProvider(&types.ModelConfig{Provider: "gemini", APIKey: "test-key", BaseURL: baseURL})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	return p.(*GeminiProvider)
+}
+
+func TestGeminiProvider_DefaultModel(t *testing.T) {
+	p := newTestGeminiProvider(t, "")
+	if p.Config().Model != GeminiDefaultModel {
+		t.Errorf("expected default model %s, got %s", GeminiDefaultModel, p.Config().Model)
+	}
+	if p.baseURL != GeminiAPIBaseURL {
+		t.Errorf("expected default base URL, got %s", p.baseURL)
+	}
+}
+
+func TestGeminiProvider_Complete(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("X-Goog-Api-Key")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = fmt.Fprint(w, `{
+			"candidates": [{"content": {"role": "model", "parts": [
+				{"text": "checking"},
+				{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}},
+				{"functionCall": {"name": "get_time"}}
+			]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "thoughtsTokenCount": 3, "cachedContentTokenCount": 4}
+		}`)
+	}))
+	defer server.Close()
+
+	p := newTestGeminiProvider(t, server.URL+"/")
+	resp, err := p.Complete(context.Background(), []types.Message{{Role: types.RoleUser, Content: "weather?"}}, &StreamOptions{
+		Tools: []ToolSchema{{Name: "get_weather", InputSchema: map[string]any{
+			"$schema":              "http://json-schema.org/draft-07/schema#",
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           map[string]any{"city": map[string]any{"type": "string", "additionalProperties": false}},
+		}}},
+		ToolChoice: &ToolChoiceOption{Type: "tool", Name: "get_weather"},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if gotPath != "/models/"+GeminiDefaultModel+":generateContent" {
+		t.Errorf("unexpected path %s", gotPath)
+	}
+	if gotKey != "test-key" {
+		t.Errorf("expected API key header, got %q", gotKey)
+	}
+
+	params := gotBody["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)[0].(map[string]any)["parameters"].(map[string]any)
+	if _, ok := params["$schema"]; ok {
+		t.Error("$schema should be stripped from function parameters")
+	}
+	if _, ok := params["properties"].(map[string]any)["city"].(map[string]any)["additionalProperties"]; ok {
+		t.Error("nested additionalProperties should be stripped")
+	}
+	config := gotBody["toolConfig"].(map[string]any)["functionCallingConfig"].(map[string]any)
+	if config["mode"] != "ANY" || config["allowedFunctionNames"].([]any)[0] != "get_weather" {
+		t.Errorf("unexpected toolConfig %v", config)
+	}
+
+	blocks := resp.Message.ContentBlocks
+	if len(blocks) != 3 {
+		t.Fatalf("expected text and two tool calls, got %d blocks", len(blocks))
+	}
+	weather := blocks[1].(*types.ToolUseBlock)
+	if weather.Name != "get_weather" || weather.Input["city"] != "Paris" {
+		t.Errorf("unexpected tool call %+v", weather)
+	}
+	noArgs := blocks[2].(*types.ToolUseBlock)
+	if noArgs.Input == nil || noArgs.ID == weather.ID {
+		t.Errorf("tool call without args should have empty input and a distinct ID, got %+v", noArgs)
+	}
+
+	if resp.Usage.InputTokens != 10 || resp.Usage.ReasoningTokens != 3 || resp.Usage.CacheReadTokens != 4 || resp.Usage.TotalTokens != 18 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestGeminiProvider_StreamFunctionCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("alt") != "sse" || r.URL.Query().Has("key") {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		_, _ = fmt.Fprint(w, `data: {"candidates": [{"content": {"parts": [{"text": "Let me check."}]}}]}
+
+data: {"candidates": [{"content": {"parts": [{"functionCall": {"name": "read", "args": {"path": "a.go"}}}, {"functionCall": {"name": "read", "args": {"path": "b.go"}}}]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 7, "candidatesTokenCount": 2, "totalTokenCount": 9}}
+
+`)
+	}))
+	defer server.Close()
+
+	p := newTestGeminiProvider(t, server.URL)
+	chunks, err := p.Stream(context.Background(), []types.Message{{Role: types.RoleUser, Content: "read"}}, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var calls []*ToolCallDelta
+	var indexes []int
+	var finish string
+	for chunk := range chunks {
+		switch chunk.Type {
+		case string(ChunkTypeToolCall):
+			calls = append(calls, chunk.ToolCall)
+			indexes = append(indexes, chunk.Index)
+		case string(ChunkTypeDone):
+			finish = chunk.FinishReason
+		}
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(calls))
+	}
+	if calls[0].ID == calls[1].ID {
+		t.Errorf("tool calls should have distinct IDs, got %s", calls[0].ID)
+	}
+	if indexes[0] != 1 || indexes[1] != 2 || calls[0].Index != 1 {
+		t.Errorf("tool call indexes should follow the text block, got %v", indexes)
+	}
+	if calls[1].ArgumentsDelta != `{"path":"b.go"}` {
+		t.Errorf("unexpected arguments %s", calls[1].ArgumentsDelta)
+	}
+	if finish != "stop" {
+		t.Errorf("expected done chunk with finish reason, got %q", finish)
+	}
+}
+
+func TestGeminiProvider_VertexAuth(t *testing.T) {
+	p := newTestGeminiProvider(t, "https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google")
+
+	req, _ := http.NewRequest(http.MethodPost, p.baseURL, nil)
+	p.setAuthHeader(req)
+	if req.Header.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("expected bearer token for Vertex endpoint, got %q", req.Header.Get("Authorization"))
+	}
+	if req.Header.Get("X-Goog-Api-Key") != "" {
+		t.Error("Vertex endpoint should not send the API key header")
+	}
+}
+
+func TestGeminiProvider_FunctionResponseName(t *testing.T) {
+	p := newTestGeminiProvider(t, "")
+
+	contents := p.convertMessages([]types.Message{
+		{Role: types.RoleAssistant, ContentBlocks: []types.ContentBlock{
+			&types.ToolUseBlock{ID: "call_1", Name: "get_weather", Input: map[string]any{"city": "Paris"}},
+		}},
+		{Role: types.RoleUser, ContentBlocks: []types.ContentBlock{
+			&types.ToolResultBlock{ToolUseID: "call_1", Content: "sunny"},
+		}},
+	})
+
+	response := contents[len(contents)-1].Parts[0].FunctionResponse
+	if response == nil || response.Name != "get_weather" {
+		t.Fatalf("function response should use the function name, got %+v", response)
+	}
+}
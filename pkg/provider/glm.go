@@ -90,7 +90,7 @@ func (gp *GLMProvider) Complete(ctx context.Context, messages []types.Message, o
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		glmLog.Error(ctx, "API error response", map[string]any{"body": string(body)})
-		return nil, fmt.Errorf("glm api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("glm", resp, body)
 	}
 
 	// 解析完整响应
@@ -170,7 +170,7 @@ func (gp *GLMProvider) Stream(ctx context.Context, messages []types.Message, opt
 		body, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
 		glmLog.Error(ctx, "API error response", map[string]any{"body": string(body)})
-		return nil, fmt.Errorf("glm api error: %d - %s", resp.StatusCode, string(body))
+		return nil, newStatusError("glm", resp, body)
 	}
 
 	glmLog.Debug(ctx, "API request successful", map[string]any{"status": resp.StatusCode})
@@ -204,6 +204,11 @@ func (gp *GLMProvider) buildRequest(messages []types.Message, opts *StreamOption
 			req["temperature"] = opts.Temperature
 		}
 
+		if len(opts.StopSequences) > 0 {
+			req["stop"] = opts.StopSequences
+		}
+		logUnsupportedOptions(glmLog, "glm", gp.Capabilities(), opts)
+
 		if opts.System != "" {
 			// GLM API 使用 system 字段
 			req["system"] = opts.System
@@ -513,13 +518,14 @@ func (gp *GLMProvider) Config() *types.ModelConfig {
 // Capabilities 返回模型能力
 func (gp *GLMProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       false,
-		MaxTokens:           8192,
-		MaxToolsPerCall:     0,
-		ToolCallingFormat:   "openai", // GLM 使用 OpenAI 兼容格式
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        false,
+		SupportStopSequences: true,
+		MaxTokens:            8192,
+		MaxToolsPerCall:      0,
+		ToolCallingFormat:    "openai", // GLM 使用 OpenAI 兼容格式
 	}
 }
 
@@ -46,17 +46,18 @@ func NewGroqProvider(config *types.ModelConfig) (Provider, error) {
 // Capabilities 返回 Groq 的能力
 func (p *GroqProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       false,
-		SupportAudio:        false,
-		SupportReasoning:    false,
-		SupportPromptCache:  false,
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           32768, // Groq 支持 32K context
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        false,
+		SupportAudio:         false,
+		SupportReasoning:     false,
+		SupportPromptCache:   false,
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            32768, // Groq 支持 32K context
+		ToolCallingFormat:    "openai",
 	}
 }
 
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// defaultProbeTimeout 健康探测默认超时
+const defaultProbeTimeout = 10 * time.Second
+
+// Pinger 可选接口：支持轻量健康检查的 Provider
+// 实现应尽量使用不消耗 token 的请求（如模型列表），未实现时回退为一次极小的补全请求。
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// StatusError Provider 请求返回的非 2xx HTTP 状态错误
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+
+	// RetryAfter 响应 Retry-After 头指定的等待时间，未指定时为 0
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error: %d - %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// ModelHealth 模型健康状态
+type ModelHealth struct {
+	Provider   string        `json:"provider"`
+	Model      string        `json:"model"`
+	Reachable  bool          `json:"reachable"`  // 服务是否可达
+	AuthValid  bool          `json:"auth_valid"` // API Key 是否有效
+	StatusCode int           `json:"status_code,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+	CheckedAt  time.Time     `json:"checked_at"`
+}
+
+// Healthy 是否可以正常路由请求
+func (h *ModelHealth) Healthy() bool {
+	return h != nil && h.Reachable && h.AuthValid && h.Error == ""
+}
+
+// Ping 检查 Provider 是否可用
+// 优先使用 Pinger 接口，否则发送一次 max_tokens=1 的补全请求
+func Ping(ctx context.Context, p Provider) error {
+	if pinger, ok := p.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	_, err := p.Complete(ctx, []types.Message{{Role: types.RoleUser, Content: "ping"}}, &StreamOptions{MaxTokens: 1})
+	return err
+}
+
+// ProbeModel 使用默认工厂探测模型的可达性、鉴权有效性和延迟
+// 仅在无法创建 Provider（配置错误）时返回 error，探测失败记录在 ModelHealth 中
+func ProbeModel(cfg *types.ModelConfig) (*ModelHealth, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultProbeTimeout)
+	defer cancel()
+	return ProbeModelWithFactory(ctx, NewMultiProviderFactory(), cfg)
+}
+
+// ProbeModelWithFactory 使用指定工厂探测模型
+func ProbeModelWithFactory(ctx context.Context, factory Factory, cfg *types.ModelConfig) (*ModelHealth, error) {
+	if cfg == nil {
+		return nil, errors.New("model config is required")
+	}
+
+	health := &ModelHealth{
+		Provider:  cfg.Provider,
+		Model:     cfg.Model,
+		CheckedAt: time.Now(),
+	}
+
+	p, err := factory.Create(cfg)
+	if err != nil {
+		health.Error = err.Error()
+		return health, fmt.Errorf("create provider: %w", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	start := time.Now()
+	err = Ping(ctx, p)
+	health.Latency = time.Since(start)
+
+	if err == nil {
+		health.Reachable = true
+		health.AuthValid = true
+		return health, nil
+	}
+
+	health.Error = err.Error()
+	health.StatusCode = StatusCodeOf(err)
+	switch {
+	case health.StatusCode == http.StatusUnauthorized || health.StatusCode == http.StatusForbidden:
+		health.Reachable = true
+	case health.StatusCode > 0:
+		// 服务可达且鉴权通过，但请求失败（如模型不存在、限流）
+		health.Reachable = true
+		health.AuthValid = true
+	}
+
+	return health, nil
+}
+
+// statusCodePattern 匹配各 Provider 错误信息中的 HTTP 状态码（"... error: 401 - ..."）
+var statusCodePattern = regexp.MustCompile(`(?i)error: (\d{3}) -`)
+
+// StatusCodeOf 从 Provider 错误中提取 HTTP 状态码，无法识别时返回 0
+func StatusCodeOf(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	if m := statusCodePattern.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return code
+	}
+	return 0
+}
+
+// pingModels 请求模型列表接口检查可用性
+func pingModels(ctx context.Context, client *http.Client, providerName, url string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Provider: providerName, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// Ping 通过模型列表接口检查可用性
+func (p *OpenAICompatibleProvider) Ping(ctx context.Context) error {
+	headers := make(map[string]string, len(p.options.CustomHeaders)+1)
+	if p.config.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.config.APIKey
+	}
+	maps.Copy(headers, p.options.CustomHeaders)
+	return pingModels(ctx, p.httpClient, p.providerName, p.baseURL+"/models", headers)
+}
+
+// Ping 通过模型列表接口检查可用性
+func (ap *AnthropicProvider) Ping(ctx context.Context) error {
+	return pingModels(ctx, ap.client, "anthropic", ap.baseURL+"/v1/models", map[string]string{
+		"X-Api-Key":         ap.config.APIKey,
+		"Anthropic-Version": ap.version,
+	})
+}
+
+var (
+	_ Pinger = (*OpenAICompatibleProvider)(nil)
+	_ Pinger = (*AnthropicProvider)(nil)
+)
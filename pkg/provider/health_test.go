@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func newModelsServer(t *testing.T, validKey string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+validKey {
+			http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProbeModel(t *testing.T) {
+	server := newModelsServer(t, "good-key")
+
+	health, err := ProbeModel(&types.ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: "good-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("ProbeModel failed: %v", err)
+	}
+	if !health.Healthy() {
+		t.Errorf("expected healthy model, got %+v", health)
+	}
+	if health.Latency <= 0 {
+		t.Error("expected measured latency")
+	}
+
+	health, err = ProbeModel(&types.ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: "bad-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("ProbeModel failed: %v", err)
+	}
+	if health.Healthy() || !health.Reachable || health.AuthValid {
+		t.Errorf("expected reachable with invalid auth, got %+v", health)
+	}
+	if health.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", health.StatusCode)
+	}
+
+	// 无法创建 Provider 时返回错误
+	if _, err := ProbeModel(&types.ModelConfig{Provider: "unknown"}); err == nil {
+		t.Error("expected error for unsupported provider")
+	}
+}
+
+func TestStatusCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&StatusError{Provider: "x", StatusCode: 403}, 403},
+		{errors.New("anthropic api error: 401 - unauthorized"), 401},
+		{errors.New("dial tcp: connection refused"), 0},
+	}
+	for _, tt := range tests {
+		if got := StatusCodeOf(tt.err); got != tt.want {
+			t.Errorf("StatusCodeOf(%q) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPing_FallbackToComplete(t *testing.T) {
+	p, err := NewDeepseekProvider(&types.ModelConfig{Provider: "deepseek", Model: "deepseek-chat", APIKey: "key", BaseURL: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	if _, ok := Provider(p).(Pinger); ok {
+		t.Skip("provider implements Pinger")
+	}
+	if err := Ping(context.Background(), p); err == nil {
+		t.Error("expected ping to fail for unreachable provider")
+	}
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"github.com/astercloud/aster/pkg/types"
 )
@@ -53,6 +54,9 @@ type StreamChunk struct {
 
 	// FinishReason 完成原因（新增）
 	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Endpoint 实际处理请求的 Base URL（配置了多个 BaseURLs 时在第一个块中填写）
+	Endpoint string `json:"endpoint,omitempty"`
 }
 
 // ToolCallDelta 工具调用增量
@@ -154,8 +158,35 @@ type StreamOptions struct {
 	// Thinking Extended Thinking 配置（Claude 专属）
 	// 启用后模型会在响应前进行深度思考，思考过程会通过流式事件返回
 	Thinking *ThinkingConfig `json:"thinking,omitempty"`
+
+	// StopSequences 停止序列，模型输出遇到任一序列时停止生成（输出不包含该序列）
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// LogitBias token 偏置，key 为 token ID，value 范围 -100 ~ 100（OpenAI 支持）
+	LogitBias map[string]float64 `json:"logit_bias,omitempty"`
+
+	// ReasoningEffort 推理强度（OpenAI 推理模型支持），不支持的模型会忽略
+	ReasoningEffort ReasoningEffort `json:"reasoning_effort,omitempty"`
+
+	// CoalesceWindow 文本增量合并窗口，大于 0 时由 CoalesceStream 合并高频的单 token 增量
+	CoalesceWindow time.Duration `json:"coalesce_window,omitempty"`
+
+	// CoalesceBytes 合并缓冲达到该字节数时立即发送（默认 DefaultCoalesceBytes）
+	CoalesceBytes int `json:"coalesce_bytes,omitempty"`
+
+	// CacheSystem 将 System 标记为 Prompt Caching 断点（Anthropic cache_control），不支持的 Provider 忽略
+	CacheSystem bool `json:"cache_system,omitempty"`
 }
 
+// ReasoningEffort 推理强度
+type ReasoningEffort string
+
+const (
+	ReasoningEffortLow    ReasoningEffort = "low"
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	ReasoningEffortHigh   ReasoningEffort = "high"
+)
+
 // ToolChoiceOption 工具选择选项
 type ToolChoiceOption struct {
 	// Type 选择类型: "auto", "any", "tool"
@@ -172,6 +203,9 @@ type ToolChoiceOption struct {
 type CompleteResponse struct {
 	Message types.Message
 	Usage   *TokenUsage
+
+	// Endpoint 实际处理请求的 Base URL（配置了多个 BaseURLs 时填写）
+	Endpoint string
 }
 
 // ToolExample 工具使用示例（与 tools.ToolExample 保持一致）
@@ -195,6 +229,10 @@ type ToolSchema struct {
 	// 可选值: ["direct"], ["code_execution_20250825"], 或两者组合
 	// 默认: nil 或 ["direct"] - 仅 LLM 直接调用
 	AllowedCallers []string `json:"allowed_callers,omitempty"`
+
+	// Cacheable 在此工具处设置 Prompt Caching 断点（Anthropic cache_control），
+	// 缓存覆盖该工具及之前的所有工具定义；不支持的 Provider 忽略
+	Cacheable bool `json:"cacheable,omitempty"`
 }
 
 // ProviderCapabilities 模型能力（扩展版本）
@@ -215,6 +253,9 @@ type ProviderCapabilities struct {
 	SupportJSONMode         bool // 是否支持 JSON 模式
 	SupportFunctionCall     bool // 是否支持 Function Calling
 	SupportStructuredOutput bool // 是否支持结构化输出（JSON Schema）
+	SupportStopSequences    bool // 是否支持停止序列
+	SupportLogitBias        bool // 是否支持 logit bias
+	SupportReasoningEffort  bool // 是否支持推理强度（reasoning effort）
 
 	// 限制
 	MaxTokens       int // 最大 token 数
@@ -255,6 +296,23 @@ type Provider interface {
 	Close() error
 }
 
+// As 沿 Unwrap 链查找实现了 T 的 Provider
+// 用于获取被 LimitedProvider、RetryProvider 等包装器隐藏的可选接口，如 Pinger、*FailoverProvider。
+func As[T any](p Provider) (T, bool) {
+	for p != nil {
+		if target, ok := p.(T); ok {
+			return target, true
+		}
+		unwrapper, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			break
+		}
+		p = unwrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 // Factory 模型提供商工厂
 type Factory interface {
 	Create(config *types.ModelConfig) (Provider, error)
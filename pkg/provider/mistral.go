@@ -46,17 +46,18 @@ func NewMistralProvider(config *types.ModelConfig) (Provider, error) {
 // Capabilities 返回 Mistral 的能力
 func (p *MistralProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true, // Pixtral 模型
-		SupportAudio:        false,
-		SupportReasoning:    true, // 原生推理支持
-		SupportPromptCache:  false,
-		SupportJSONMode:     true, // response_format: json
-		SupportFunctionCall: true,
-		MaxTokens:           128000, // 128K context
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true, // Pixtral 模型
+		SupportAudio:         false,
+		SupportReasoning:     true, // 原生推理支持
+		SupportPromptCache:   false,
+		SupportJSONMode:      true, // response_format: json
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            128000, // 128K context
+		ToolCallingFormat:    "openai",
 	}
 }
 
@@ -59,17 +59,18 @@ func (p *MoonshotProvider) Capabilities() ProviderCapabilities {
 	supportsReasoning := isThinkingModel(model)
 
 	caps := ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       false,
-		SupportAudio:        false,
-		SupportReasoning:    supportsReasoning, // K2 模型支持 reasoning
-		SupportPromptCache:  false,
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           128000, // 默认 128K
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        false,
+		SupportAudio:         false,
+		SupportReasoning:     supportsReasoning, // K2 模型支持 reasoning
+		SupportPromptCache:   false,
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            128000, // 默认 128K
+		ToolCallingFormat:    "openai",
 	}
 
 	// 根据模型调整 MaxTokens
@@ -52,17 +52,18 @@ func NewOllamaProvider(config *types.ModelConfig) (Provider, error) {
 // Capabilities 返回 Ollama 的能力
 func (p *OllamaProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true, // 部分模型支持
-		SupportAudio:        false,
-		SupportReasoning:    false,
-		SupportPromptCache:  false,
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           128000, // 取决于具体模型
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true, // 部分模型支持
+		SupportAudio:         false,
+		SupportReasoning:     false,
+		SupportPromptCache:   false,
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		MaxTokens:            128000, // 取决于具体模型
+		ToolCallingFormat:    "openai",
 	}
 }
 
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"slices"
+	"strings"
 
 	"github.com/astercloud/aster/pkg/types"
 )
@@ -38,6 +39,10 @@ func NewOpenAIProviderWithBaseURL(config *types.ModelConfig) (Provider, error) {
 		SupportPromptCache: true,     // 支持 Prompt Caching
 		SupportVision:      true,     // 支持图片输入
 		SupportAudio:       true,     // 支持音频输入
+		SupportLogitBias:   true,     // 支持 logit_bias
+
+		SupportReasoningEffort: true, // o 系列与 gpt-5 支持 reasoning_effort
+		ResponsesAPIModels:     openAIResponsesOnlyModels,
 	}
 
 	// 创建 OpenAI 兼容 Provider
@@ -68,6 +73,9 @@ func (p *OpenAIProvider) Capabilities() ProviderCapabilities {
 		SupportPromptCache:      true,
 		SupportJSONMode:         true,
 		SupportFunctionCall:     true,
+		SupportStopSequences:    true,
+		SupportLogitBias:        true,
+		SupportReasoningEffort:  supportsReasoningEffort(p.Config().Model),
 		MaxTokens:               128000,
 		ToolCallingFormat:       "openai",
 		ReasoningTokensIncluded: true,
@@ -92,6 +100,32 @@ func isReasoningModel(model string) bool {
 	return slices.Contains(reasoningModels, model)
 }
 
+// openAIResponsesOnlyModels 只能通过 Responses API 调用的 OpenAI 模型前缀
+var openAIResponsesOnlyModels = []string{
+	"o1-pro",
+	"o3-pro",
+	"o3-deep-research",
+	"o4-mini-deep-research",
+	"gpt-5-pro",
+	"codex-mini",
+	"computer-use-preview",
+}
+
+// supportsReasoningEffort 检查模型是否支持 reasoning_effort 参数
+// o1/o3/o4 系列与 gpt-5 系列支持；早期 o1-mini/o1-preview 与 gpt-5 chat 版本不支持
+func supportsReasoningEffort(model string) bool {
+	model = strings.ToLower(model)
+	if strings.HasPrefix(model, "o1-mini") || strings.HasPrefix(model, "o1-preview") || strings.Contains(model, "-chat") {
+		return false
+	}
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // OpenAIFactory OpenAI 工厂
 type OpenAIFactory struct{}
 
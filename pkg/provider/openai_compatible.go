@@ -53,6 +53,15 @@ type OpenAICompatibleOptions struct {
 	SupportVision bool
 	SupportAudio  bool
 
+	// 是否支持 logit_bias 参数（并非所有兼容服务都支持）
+	SupportLogitBias bool
+
+	// 是否支持 reasoning_effort 参数（仅对 supportsReasoningEffort 判定的推理模型生效）
+	SupportReasoningEffort bool
+
+	// ResponsesAPIModels 只能通过 Responses API 调用的模型前缀，其余模型使用 chat completions
+	ResponsesAPIModels []string
+
 	// 超时配置
 	Timeout time.Duration
 
@@ -115,6 +124,13 @@ func NewOpenAICompatibleProvider(
 		}
 	}
 
+	// 配置了 ModelConfig.Retry 时由外层 RetryProvider 负责重试
+	if config.Retry != nil && options.MaxRetries > 0 {
+		noRetry := *options
+		noRetry.MaxRetries = 0
+		options = &noRetry
+	}
+
 	// 验证 API Key
 	if options.RequireAPIKey && config.APIKey == "" {
 		return nil, fmt.Errorf("%s: API key is required", providerName)
@@ -130,30 +146,35 @@ func NewOpenAICompatibleProvider(
 		config.Model = options.DefaultModel
 	}
 
+	capabilities := buildCapabilities(options)
+	capabilities.SupportReasoningEffort = options.SupportReasoningEffort && supportsReasoningEffort(config.Model)
+
 	return &OpenAICompatibleProvider{
 		config:       config,
 		baseURL:      baseURL,
 		providerName: providerName,
 		httpClient:   httpClient,
 		options:      *options,
-		capabilities: buildCapabilities(options),
+		capabilities: capabilities,
 	}, nil
 }
 
 // buildCapabilities 构建能力定义
 func buildCapabilities(options *OpenAICompatibleOptions) ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       options.SupportVision,
-		SupportAudio:        options.SupportAudio,
-		SupportReasoning:    options.SupportReasoning,
-		SupportPromptCache:  options.SupportPromptCache,
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           128000, // 默认值，可被具体 Provider 覆盖
-		ToolCallingFormat:   "openai",
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        options.SupportVision,
+		SupportAudio:         options.SupportAudio,
+		SupportReasoning:     options.SupportReasoning,
+		SupportPromptCache:   options.SupportPromptCache,
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		SupportLogitBias:     options.SupportLogitBias,
+		MaxTokens:            128000, // 默认值，可被具体 Provider 覆盖
+		ToolCallingFormat:    "openai",
 	}
 }
 
@@ -163,6 +184,10 @@ func (p *OpenAICompatibleProvider) Stream(
 	messages []types.Message,
 	opts *StreamOptions,
 ) (<-chan StreamChunk, error) {
+	if p.usesResponsesAPI() {
+		return p.streamResponses(ctx, messages, opts)
+	}
+
 	// 构建请求体
 	requestBody := p.buildRequest(messages, opts, true)
 
@@ -206,14 +231,18 @@ func (p *OpenAICompatibleProvider) Stream(
 			}
 		}
 
-		return nil, fmt.Errorf("%s API error: %d - %s", p.providerName, resp.StatusCode, string(body))
+		return nil, newStatusError(p.providerName, resp, body)
 	}
 
 	// 创建流式响应 channel
 	chunks := make(chan StreamChunk, 10)
 
 	// 在 goroutine 中解析 SSE 流
-	go p.parseSSEStream(resp.Body, chunks)
+	var stops []string
+	if opts != nil {
+		stops = opts.StopSequences
+	}
+	go p.parseSSEStream(resp.Body, chunks, stops)
 
 	return chunks, nil
 }
@@ -224,6 +253,10 @@ func (p *OpenAICompatibleProvider) Complete(
 	messages []types.Message,
 	opts *StreamOptions,
 ) (*CompleteResponse, error) {
+	if p.usesResponsesAPI() {
+		return p.completeResponses(ctx, messages, opts)
+	}
+
 	// 构建请求体
 	requestBody := p.buildRequest(messages, opts, false)
 
@@ -243,7 +276,7 @@ func (p *OpenAICompatibleProvider) Complete(
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%s API error: %d - %s", p.providerName, resp.StatusCode, string(body))
+		return nil, newStatusError(p.providerName, resp, body)
 	}
 
 	// 解析响应
@@ -300,13 +333,32 @@ func (p *OpenAICompatibleProvider) buildRequest(
 
 	// 添加可选参数
 	if opts != nil {
+		// 支持推理强度的模型使用 max_completion_tokens，且不支持 temperature
+		reasoning := p.capabilities.SupportReasoningEffort
 		if opts.MaxTokens > 0 {
-			requestBody["max_tokens"] = opts.MaxTokens
+			if reasoning {
+				requestBody["max_completion_tokens"] = opts.MaxTokens
+			} else {
+				requestBody["max_tokens"] = opts.MaxTokens
+			}
 		}
 		// 推理模型不支持 temperature
-		if opts.Temperature > 0 && !p.isReasoningModel(p.config.Model) {
+		if opts.Temperature > 0 && !reasoning && !p.isReasoningModel(p.config.Model) {
 			requestBody["temperature"] = opts.Temperature
 		}
+		if opts.ReasoningEffort != "" && reasoning {
+			requestBody["reasoning_effort"] = string(opts.ReasoningEffort)
+		}
+		if len(opts.StopSequences) > 0 {
+			requestBody["stop"] = opts.StopSequences
+		}
+		if len(opts.LogitBias) > 0 && p.capabilities.SupportLogitBias {
+			requestBody["logit_bias"] = opts.LogitBias
+		}
+		if rf := p.buildResponseFormat(opts.ResponseFormat); rf != nil {
+			requestBody["response_format"] = rf
+		}
+		logUnsupportedOptions(openaiLog, p.providerName, p.capabilities, opts)
 		// 添加工具
 		if len(opts.Tools) > 0 {
 			convertedTools := p.convertTools(opts.Tools)
@@ -314,6 +366,12 @@ func (p *OpenAICompatibleProvider) buildRequest(
 			// 设置 tool_choice 为 auto，明确启用工具调用
 			// 参考: https://openrouter.ai/docs/parameters
 			requestBody["tool_choice"] = "auto"
+			if opts.ToolChoice != nil && opts.ToolChoice.Type == "tool" && opts.ToolChoice.Name != "" {
+				requestBody["tool_choice"] = map[string]any{
+					"type":     "function",
+					"function": map[string]any{"name": opts.ToolChoice.Name},
+				}
+			}
 			// 添加调试日志，输出工具名称
 			toolNames := make([]string, len(opts.Tools))
 			for i, t := range opts.Tools {
@@ -326,6 +384,32 @@ func (p *OpenAICompatibleProvider) buildRequest(
 	return requestBody
 }
 
+// buildResponseFormat 转换响应格式为 OpenAI response_format
+func (p *OpenAICompatibleProvider) buildResponseFormat(rf *ResponseFormat) map[string]any {
+	if rf == nil || rf.Type == ResponseFormatText {
+		return nil
+	}
+
+	switch {
+	case rf.Type == ResponseFormatJSONSchema && p.capabilities.SupportStructuredOutput:
+		name := rf.Name
+		if name == "" {
+			name = "response"
+		}
+		return map[string]any{
+			"type": string(ResponseFormatJSONSchema),
+			"json_schema": map[string]any{
+				"name":   name,
+				"schema": rf.Schema,
+				"strict": rf.Strict,
+			},
+		}
+	case p.capabilities.SupportJSONMode || p.capabilities.SupportStructuredOutput:
+		return map[string]any{"type": string(ResponseFormatJSON)}
+	}
+	return nil
+}
+
 // convertMessages 转换消息格式为 OpenAI 格式
 func (p *OpenAICompatibleProvider) convertMessages(messages []types.Message) []map[string]any {
 	result := make([]map[string]any, 0, len(messages))
@@ -468,6 +552,9 @@ func (p *OpenAICompatibleProvider) convertContentBlocks(blocks []types.ContentBl
 				imageBlock["image_url"] = map[string]any{
 					"url": dataURL,
 				}
+				if b.Detail != "" {
+					imageBlock["image_url"].(map[string]any)["detail"] = b.Detail
+				}
 			}
 			content = append(content, imageBlock)
 
@@ -506,15 +593,20 @@ func (p *OpenAICompatibleProvider) convertTools(tools []ToolSchema) []map[string
 	return result
 }
 
-// createHTTPRequest 创建 HTTP 请求
+// createHTTPRequest 创建 chat completions HTTP 请求
 func (p *OpenAICompatibleProvider) createHTTPRequest(ctx context.Context, requestBody map[string]any) (*http.Request, error) {
+	return p.createHTTPRequestTo(ctx, "/chat/completions", requestBody)
+}
+
+// createHTTPRequestTo 创建指定路径的 HTTP 请求
+func (p *OpenAICompatibleProvider) createHTTPRequestTo(ctx context.Context, path string, requestBody map[string]any) (*http.Request, error) {
 	// 使用确定性序列化以优化 KV-Cache 命中率
 	bodyBytes, err := util.MarshalDeterministic(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := p.baseURL + "/chat/completions"
+	url := p.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return nil, err
@@ -548,9 +640,15 @@ func (p *OpenAICompatibleProvider) doRequestWithRetry(req *http.Request) (*http.
 
 		resp, err := p.httpClient.Do(req)
 		if err != nil {
+			// 请求已取消或超时，不再重试
+			if req.Context().Err() != nil {
+				return nil, err
+			}
 			lastErr = err
 			if attempt < p.options.MaxRetries {
-				time.Sleep(p.options.RetryDelay * time.Duration(attempt+1))
+				if err := p.retryWait(req.Context(), attempt); err != nil {
+					return nil, err
+				}
 				// 恢复 Body
 				if bodyBytes != nil {
 					req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -570,7 +668,9 @@ func (p *OpenAICompatibleProvider) doRequestWithRetry(req *http.Request) (*http.
 
 		if shouldRetry && attempt < p.options.MaxRetries {
 			_ = resp.Body.Close()
-			time.Sleep(p.options.RetryDelay * time.Duration(attempt+1))
+			if err := p.retryWait(req.Context(), attempt); err != nil {
+				return nil, err
+			}
 			// 恢复 Body
 			if bodyBytes != nil {
 				req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -584,11 +684,35 @@ func (p *OpenAICompatibleProvider) doRequestWithRetry(req *http.Request) (*http.
 	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
+// retryWait 第 attempt 次重试前等待，ctx 取消时立即返回
+func (p *OpenAICompatibleProvider) retryWait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.options.RetryDelay * time.Duration(attempt+1))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // parseSSEStream 解析 SSE 流
-func (p *OpenAICompatibleProvider) parseSSEStream(body io.ReadCloser, chunks chan<- StreamChunk) {
+// 指定 stops 时在客户端再做一次停止序列检测，保证输出在序列处截断，
+// 即使上游服务忽略了 stop 参数也不会输出停止序列之后的内容
+func (p *OpenAICompatibleProvider) parseSSEStream(body io.ReadCloser, chunks chan<- StreamChunk, stops []string) {
 	defer func() { _ = body.Close() }()
 	defer close(chunks)
 
+	matcher := newStopSequenceMatcher(stops)
+	flush := func() {
+		if matcher == nil {
+			return
+		}
+		if rest := matcher.Flush(); rest != "" {
+			chunks <- StreamChunk{Type: string(ChunkTypeText), TextDelta: rest, Delta: rest}
+		}
+	}
+
 	scanner := bufio.NewScanner(body)
 	scanner.Split(bufio.ScanLines)
 
@@ -614,6 +738,7 @@ func (p *OpenAICompatibleProvider) parseSSEStream(body io.ReadCloser, chunks cha
 		// 结束标记
 		if data == "[DONE]" {
 			openaiLog.Debug(context.Background(), "received [DONE] marker", map[string]any{"provider": p.providerName})
+			flush()
 			chunks <- StreamChunk{
 				Type:         string(ChunkTypeDone),
 				FinishReason: "stop",
@@ -635,10 +760,34 @@ func (p *OpenAICompatibleProvider) parseSSEStream(body io.ReadCloser, chunks cha
 			if sc.TextDelta != "" {
 				openaiLog.Debug(context.Background(), "text delta", map[string]any{"provider": p.providerName, "text": sc.TextDelta})
 			}
+
+			if matcher != nil {
+				if sc.Type == string(ChunkTypeDone) {
+					flush()
+				}
+				if sc.Type == string(ChunkTypeText) {
+					text, stopped := matcher.Push(sc.TextDelta)
+					if text != "" {
+						chunks <- StreamChunk{Type: string(ChunkTypeText), TextDelta: text, Delta: text}
+					}
+					if stopped {
+						openaiLog.Debug(context.Background(), "stop sequence reached", map[string]any{"provider": p.providerName})
+						chunks <- StreamChunk{
+							Type:         string(ChunkTypeDone),
+							FinishReason: "stop",
+						}
+						return
+					}
+					continue
+				}
+			}
+
 			chunks <- sc
 		}
 	}
 
+	flush()
+
 	if err := scanner.Err(); err != nil {
 		chunks <- StreamChunk{
 			Type: string(ChunkTypeError),
@@ -700,9 +849,16 @@ func (p *OpenAICompatibleProvider) parseStreamChunk(chunk map[string]any) []Stre
 
 	// 解析工具调用
 	if toolCalls, ok := delta["tool_calls"].([]any); ok {
-		for _, tc := range toolCalls {
-			toolCall := tc.(map[string]any)
-			index := int(toolCall["index"].(float64))
+		for i, tc := range toolCalls {
+			toolCall, ok := tc.(map[string]any)
+			if !ok {
+				continue
+			}
+			// 部分上游不返回 index，按数组位置处理
+			index := i
+			if v, ok := toolCall["index"].(float64); ok {
+				index = int(v)
+			}
 
 			tcDelta := &ToolCallDelta{
 				Index: index,
@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// OpenAI Responses API 支持
+// 部分模型（如 o1-pro、gpt-5-pro）只能通过 /responses 调用，
+// 请求与响应结构与 chat completions 不同，这里做双向转换。
+
+// usesResponsesAPI 检查当前模型是否需要使用 Responses API
+func (p *OpenAICompatibleProvider) usesResponsesAPI() bool {
+	model := strings.ToLower(p.config.Model)
+	for _, prefix := range p.options.ResponsesAPIModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// completeResponses 通过 Responses API 完成对话
+func (p *OpenAICompatibleProvider) completeResponses(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	req, err := p.createHTTPRequestTo(ctx, "/responses", p.buildResponsesRequest(messages, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.doRequestWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(p.providerName, resp, body)
+	}
+
+	var apiResp map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	message, err := p.parseResponsesOutput(apiResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompleteResponse{
+		Message: message,
+		Usage:   p.parseResponsesUsage(apiResp),
+	}, nil
+}
+
+// streamResponses Responses API 模型的流式接口
+// 这些模型响应较慢且以完整结果为主，这里请求完整响应后按流式块输出
+func (p *OpenAICompatibleProvider) streamResponses(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	result, err := p.completeResponses(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk, len(result.Message.ContentBlocks)+2)
+	toolIndex := 0
+	for _, block := range result.Message.ContentBlocks {
+		switch b := block.(type) {
+		case *types.TextBlock:
+			chunks <- StreamChunk{Type: string(ChunkTypeText), TextDelta: b.Text, Delta: b.Text}
+		case *types.ToolUseBlock:
+			args, _ := json.Marshal(b.Input)
+			chunks <- StreamChunk{
+				Type: string(ChunkTypeToolCall),
+				ToolCall: &ToolCallDelta{
+					Index:          toolIndex,
+					ID:             b.ID,
+					Type:           "function",
+					Name:           b.Name,
+					ArgumentsDelta: string(args),
+				},
+			}
+			toolIndex++
+		}
+	}
+	if result.Usage != nil {
+		chunks <- StreamChunk{Type: string(ChunkTypeUsage), Usage: result.Usage}
+	}
+	chunks <- StreamChunk{Type: string(ChunkTypeDone), FinishReason: "stop"}
+	close(chunks)
+
+	return chunks, nil
+}
+
+// buildResponsesRequest 构建 Responses API 请求体
+func (p *OpenAICompatibleProvider) buildResponsesRequest(messages []types.Message, opts *StreamOptions) map[string]any {
+	requestBody := map[string]any{
+		"model": p.config.Model,
+		"input": p.convertResponsesInput(messages),
+	}
+
+	system := p.systemPrompt
+	if opts != nil && opts.System != "" {
+		system = opts.System
+	}
+	if system != "" {
+		requestBody["instructions"] = system
+	}
+
+	if opts == nil {
+		return requestBody
+	}
+
+	if opts.MaxTokens > 0 {
+		requestBody["max_output_tokens"] = opts.MaxTokens
+	}
+	if opts.ReasoningEffort != "" && p.capabilities.SupportReasoningEffort {
+		requestBody["reasoning"] = map[string]any{"effort": string(opts.ReasoningEffort)}
+	}
+	if rf := p.buildResponseFormat(opts.ResponseFormat); rf != nil {
+		// Responses API 的 json_schema 参数平铺在 format 中
+		format := map[string]any{"type": rf["type"]}
+		if schema, ok := rf["json_schema"].(map[string]any); ok {
+			maps.Copy(format, schema)
+		}
+		requestBody["text"] = map[string]any{"format": format}
+	}
+	logUnsupportedOptions(openaiLog, p.providerName, p.capabilities, opts)
+
+	if len(opts.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(opts.Tools))
+		for _, tool := range opts.Tools {
+			tools = append(tools, map[string]any{
+				"type":        "function",
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  tool.InputSchema,
+			})
+		}
+		requestBody["tools"] = tools
+		requestBody["tool_choice"] = "auto"
+		if opts.ToolChoice != nil && opts.ToolChoice.Type == "tool" && opts.ToolChoice.Name != "" {
+			requestBody["tool_choice"] = map[string]any{"type": "function", "name": opts.ToolChoice.Name}
+		}
+	}
+
+	return requestBody
+}
+
+// convertResponsesInput 转换消息为 Responses API 的 input 列表
+// 工具调用与工具结果是独立的 input 项，不属于任何消息
+func (p *OpenAICompatibleProvider) convertResponsesInput(messages []types.Message) []map[string]any {
+	input := make([]map[string]any, 0, len(messages))
+
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			continue
+		}
+
+		textType := "input_text"
+		if msg.Role == types.RoleAssistant {
+			textType = "output_text"
+		}
+
+		var content []map[string]any
+		for _, block := range msg.ContentBlocks {
+			switch b := block.(type) {
+			case *types.TextBlock:
+				content = append(content, map[string]any{"type": textType, "text": b.Text})
+			case *types.ImageContent:
+				url := b.Source
+				if b.Type == "base64" {
+					url = fmt.Sprintf("data:%s;base64,%s", b.MimeType, b.Source)
+				}
+				image := map[string]any{"type": "input_image", "image_url": url}
+				if b.Detail != "" {
+					image["detail"] = b.Detail
+				}
+				content = append(content, image)
+			case *types.ToolUseBlock:
+				args, _ := json.Marshal(b.Input)
+				input = append(input, map[string]any{
+					"type":      "function_call",
+					"call_id":   b.ID,
+					"name":      b.Name,
+					"arguments": string(args),
+				})
+			case *types.ToolResultBlock:
+				input = append(input, map[string]any{
+					"type":    "function_call_output",
+					"call_id": b.ToolUseID,
+					"output":  b.Content,
+				})
+			}
+		}
+
+		switch {
+		case len(content) > 0:
+			input = append(input, map[string]any{"role": string(msg.Role), "content": content})
+		case len(msg.ContentBlocks) == 0 && msg.Content != "":
+			input = append(input, map[string]any{"role": string(msg.Role), "content": msg.Content})
+		}
+	}
+
+	return input
+}
+
+// parseResponsesOutput 解析 Responses API 的 output 列表
+func (p *OpenAICompatibleProvider) parseResponsesOutput(apiResp map[string]any) (types.Message, error) {
+	if errObj, ok := apiResp["error"].(map[string]any); ok {
+		msg, _ := errObj["message"].(string)
+		return types.Message{}, fmt.Errorf("%s API error: %s", p.providerName, msg)
+	}
+
+	output, ok := apiResp["output"].([]any)
+	if !ok {
+		return types.Message{}, errors.New("no output in response")
+	}
+
+	result := types.Message{Role: types.RoleAssistant}
+	var text strings.Builder
+	var blocks []types.ContentBlock
+
+	for _, raw := range output {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch item["type"] {
+		case "message":
+			parts, _ := item["content"].([]any)
+			for _, rawPart := range parts {
+				part, _ := rawPart.(map[string]any)
+				if part["type"] != "output_text" {
+					continue
+				}
+				if t, _ := part["text"].(string); t != "" {
+					text.WriteString(t)
+					blocks = append(blocks, &types.TextBlock{Text: t})
+				}
+			}
+		case "function_call":
+			var args map[string]any
+			if argsStr, ok := item["arguments"].(string); ok {
+				_ = json.Unmarshal([]byte(argsStr), &args)
+			}
+			id, _ := item["call_id"].(string)
+			name, _ := item["name"].(string)
+			blocks = append(blocks, &types.ToolUseBlock{ID: id, Name: name, Input: args})
+		}
+	}
+
+	result.Content = text.String()
+	if len(blocks) > 0 {
+		result.ContentBlocks = blocks
+	}
+	return result, nil
+}
+
+// parseResponsesUsage 解析 Responses API 的 usage
+func (p *OpenAICompatibleProvider) parseResponsesUsage(apiResp map[string]any) *TokenUsage {
+	usageData, ok := apiResp["usage"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	usage := &TokenUsage{Provider: p.providerName, Model: p.config.Model}
+	if v, ok := usageData["input_tokens"].(float64); ok {
+		usage.InputTokens = int64(v)
+	}
+	if v, ok := usageData["output_tokens"].(float64); ok {
+		usage.OutputTokens = int64(v)
+	}
+	if v, ok := usageData["total_tokens"].(float64); ok {
+		usage.TotalTokens = int64(v)
+	}
+	if details, ok := usageData["output_tokens_details"].(map[string]any); ok {
+		if v, ok := details["reasoning_tokens"].(float64); ok {
+			usage.ReasoningTokens = int64(v)
+		}
+	}
+	if details, ok := usageData["input_tokens_details"].(map[string]any); ok {
+		if v, ok := details["cached_tokens"].(float64); ok {
+			usage.CachedTokens = int64(v)
+		}
+	}
+	return usage
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// recordingServer 记录请求路径和请求体，并返回固定响应
+func recordingServer(t *testing.T, response map[string]any) (*httptest.Server, *string, *map[string]any) {
+	t.Helper()
+
+	var path string
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &path, &received
+}
+
+var chatCompletionResponse = map[string]any{
+	"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "done"}}},
+}
+
+func TestOpenAIProvider_ReasoningEffort(t *testing.T) {
+	tests := []struct {
+		model      string
+		wantEffort bool
+	}{
+		{"o3-mini", true},
+		{"gpt-5", true},
+		{"gpt-4o", false},
+		{"o1-mini", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			server, path, received := recordingServer(t, chatCompletionResponse)
+			p, err := NewOpenAIProvider(&types.ModelConfig{Provider: "openai", Model: tt.model, APIKey: "test-key", BaseURL: server.URL})
+			if err != nil {
+				t.Fatalf("create provider: %v", err)
+			}
+			if p.Capabilities().SupportReasoningEffort != tt.wantEffort {
+				t.Errorf("expected SupportReasoningEffort=%v", tt.wantEffort)
+			}
+
+			_, err = p.Complete(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, &StreamOptions{
+				MaxTokens:       512,
+				Temperature:     0.5,
+				ReasoningEffort: ReasoningEffortHigh,
+			})
+			if err != nil {
+				t.Fatalf("complete: %v", err)
+			}
+
+			if *path != "/chat/completions" {
+				t.Errorf("expected chat completions endpoint, got %s", *path)
+			}
+			effort, sent := (*received)["reasoning_effort"]
+			if sent != tt.wantEffort || (sent && effort != "high") {
+				t.Errorf("unexpected reasoning_effort in request: %v", *received)
+			}
+			if tt.wantEffort {
+				if (*received)["max_completion_tokens"] != float64(512) || (*received)["temperature"] != nil {
+					t.Errorf("reasoning model should use max_completion_tokens without temperature: %v", *received)
+				}
+			} else if (*received)["max_tokens"] != float64(512) {
+				t.Errorf("expected max_tokens for non-reasoning model: %v", *received)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_ResponsesAPI(t *testing.T) {
+	server, path, received := recordingServer(t, map[string]any{
+		"output": []any{
+			map[string]any{"type": "reasoning", "summary": []any{}},
+			map[string]any{"type": "message", "content": []any{
+				map[string]any{"type": "output_text", "text": "Let me check."},
+			}},
+			map[string]any{"type": "function_call", "call_id": "call_1", "name": "Read", "arguments": `{"path":"a.go"}`},
+		},
+		"usage": map[string]any{
+			"input_tokens": 10, "output_tokens": 20, "total_tokens": 30,
+			"output_tokens_details": map[string]any{"reasoning_tokens": 15},
+		},
+	})
+	p, err := NewOpenAIProvider(&types.ModelConfig{Provider: "openai", Model: "o3-pro", APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+
+	messages := []types.Message{
+		{Role: types.RoleUser, Content: "read a.go"},
+		{Role: types.RoleAssistant, ContentBlocks: []types.ContentBlock{&types.ToolUseBlock{ID: "call_0", Name: "Glob", Input: map[string]any{"pattern": "*.go"}}}},
+		{Role: types.RoleUser, ContentBlocks: []types.ContentBlock{&types.ToolResultBlock{ToolUseID: "call_0", Content: "a.go"}}},
+	}
+	resp, err := p.Complete(context.Background(), messages, &StreamOptions{
+		System:          "be brief",
+		MaxTokens:       256,
+		ReasoningEffort: ReasoningEffortLow,
+		Tools:           []ToolSchema{{Name: "Read", Description: "read file", InputSchema: map[string]any{"type": "object"}}},
+	})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if *path != "/responses" {
+		t.Fatalf("expected responses endpoint, got %s", *path)
+	}
+	if reasoning, _ := (*received)["reasoning"].(map[string]any); reasoning["effort"] != "low" {
+		t.Errorf("expected reasoning.effort in request, got %v", (*received)["reasoning"])
+	}
+	if (*received)["instructions"] != "be brief" || (*received)["max_output_tokens"] != float64(256) {
+		t.Errorf("unexpected request: %v", *received)
+	}
+	input := (*received)["input"].([]any)
+	if len(input) != 3 || input[1].(map[string]any)["type"] != "function_call" || input[2].(map[string]any)["type"] != "function_call_output" {
+		t.Errorf("unexpected input items: %v", input)
+	}
+
+	if resp.Message.Content != "Let me check." || len(resp.Message.ContentBlocks) != 2 {
+		t.Fatalf("unexpected message: %+v", resp.Message)
+	}
+	tool, ok := resp.Message.ContentBlocks[1].(*types.ToolUseBlock)
+	if !ok || tool.ID != "call_1" || tool.Input["path"] != "a.go" {
+		t.Errorf("unexpected tool call: %+v", resp.Message.ContentBlocks[1])
+	}
+	if resp.Usage == nil || resp.Usage.ReasoningTokens != 15 || resp.Usage.TotalTokens != 30 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"context"
+
 	"github.com/astercloud/aster/pkg/types"
 )
 
@@ -54,6 +56,7 @@ func NewOpenRouterProvider(config *types.ModelConfig, orConfig *OpenRouterConfig
 		SupportPromptCache: true,            // 支持 Prompt Caching
 		SupportVision:      true,            // 支持多模态
 		SupportAudio:       true,
+		SupportLogitBias:   true, // 透传给上游模型
 		CustomHeaders:      make(map[string]string),
 	}
 
@@ -82,6 +85,16 @@ func NewOpenRouterProvider(config *types.ModelConfig, orConfig *OpenRouterConfig
 	}, nil
 }
 
+// Stream 流式对话，分片的工具调用参数合并完整后再发送
+// OpenRouter 转发的上游模型可能交错发送多个工具调用的参数片段
+func (p *OpenRouterProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	chunks, err := p.OpenAICompatibleProvider.Stream(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	return assembleToolCalls(ctx, chunks), nil
+}
+
 // NewOpenRouterProviderSimple 创建 OpenRouter 提供商（简化版）
 func NewOpenRouterProviderSimple(config *types.ModelConfig) (Provider, error) {
 	return NewOpenRouterProvider(config, nil)
@@ -91,18 +104,20 @@ func NewOpenRouterProviderSimple(config *types.ModelConfig) (Provider, error) {
 // 注意：OpenRouter 聚合多个 provider，能力取决于所选模型
 func (p *OpenRouterProvider) Capabilities() ProviderCapabilities {
 	return ProviderCapabilities{
-		SupportToolCalling:  true,
-		SupportSystemPrompt: true,
-		SupportStreaming:    true,
-		SupportVision:       true, // 取决于所选模型
-		SupportAudio:        true, // 取决于所选模型
-		SupportReasoning:    true, // 支持 o1/o3 等推理模型
-		SupportPromptCache:  true, // 支持 Prompt Caching
-		SupportJSONMode:     true,
-		SupportFunctionCall: true,
-		MaxTokens:           200000, // 取决于所选模型，最高可达 200K
-		ToolCallingFormat:   "openai",
-		CacheMinTokens:      1024,
+		SupportToolCalling:   true,
+		SupportSystemPrompt:  true,
+		SupportStreaming:     true,
+		SupportVision:        true, // 取决于所选模型
+		SupportAudio:         true, // 取决于所选模型
+		SupportReasoning:     true, // 支持 o1/o3 等推理模型
+		SupportPromptCache:   true, // 支持 Prompt Caching
+		SupportJSONMode:      true,
+		SupportFunctionCall:  true,
+		SupportStopSequences: true,
+		SupportLogitBias:     true,
+		MaxTokens:            200000, // 取决于所选模型，最高可达 200K
+		ToolCallingFormat:    "openai",
+		CacheMinTokens:       1024,
 	}
 }
 
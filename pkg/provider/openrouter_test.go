@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestOpenRouterProvider_StreamAssemblesToolCalls(t *testing.T) {
+	recorded, err := os.ReadFile("testdata/openrouter_tool_calls.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(recorded)
+	}))
+	defer server.Close()
+
+	p, err := NewOpenRouterProviderSimple(&types.ModelConfig{Provider: "openrouter", Model: "openai/gpt-4o", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	p.(*OpenRouterProvider).baseURL = server.URL
+
+	chunks, err := p.Stream(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "weather?"}}, &StreamOptions{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var calls []*ToolCallDelta
+	var kinds []string
+	for chunk := range chunks {
+		kinds = append(kinds, chunk.Type)
+		if chunk.Type == string(ChunkTypeToolCall) {
+			calls = append(calls, chunk.ToolCall)
+		}
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected one chunk per tool call, got %d (%v)", len(calls), kinds)
+	}
+	want := []struct {
+		id, name string
+		index    int
+		args     map[string]any
+	}{
+		{"call_weather", "get_weather", 1, map[string]any{"city": "Paris"}},
+		{"call_time", "get_time", 2, map[string]any{"tz": "UTC"}},
+	}
+	for i, w := range want {
+		call := calls[i]
+		var args map[string]any
+		if err := json.Unmarshal([]byte(call.ArgumentsDelta), &args); err != nil {
+			t.Fatalf("tool call %d arguments not complete JSON: %q", i, call.ArgumentsDelta)
+		}
+		if call.ID != w.id || call.Name != w.name || call.Index != w.index || !reflect.DeepEqual(args, w.args) {
+			t.Errorf("tool call %d: got %+v args=%v", i, call, args)
+		}
+	}
+	if last := kinds[len(kinds)-1]; last != string(ChunkTypeDone) {
+		t.Errorf("tool calls should be emitted before the done chunk, last chunk %s", last)
+	}
+}
+
+func TestAssembleToolCalls_FlushesOnClose(t *testing.T) {
+	in := make(chan StreamChunk, 3)
+	in <- StreamChunk{Type: string(ChunkTypeToolCall), ToolCall: &ToolCallDelta{Index: 0, Name: "ping"}}
+	close(in)
+
+	var got []StreamChunk
+	for chunk := range assembleToolCalls(context.Background(), in) {
+		got = append(got, chunk)
+	}
+	if len(got) != 1 || got[0].ToolCall.ArgumentsDelta != "{}" || got[0].ToolCall.ID == "" || got[0].Index != 0 {
+		t.Errorf("unexpected chunks: %+v", got)
+	}
+}
+
+func TestAssembleToolCalls_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan StreamChunk)
+	out := assembleToolCalls(ctx, in)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		defer close(in)
+		for range 20 {
+			in <- StreamChunk{Type: string(ChunkTypeText), TextDelta: "x"}
+		}
+	}()
+	for len(out) < cap(out) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	// 调用方不再读取时，取消后上游仍能发送完毕而不阻塞
+	select {
+	case <-sent:
+	case <-time.After(2 * time.Second):
+		t.Fatal("assembler blocked upstream after cancellation")
+	}
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func newTestAnthropicProvider(t *testing.T, baseURL string) *AnthropicProvider {
+	t.Helper()
+	ap, err := NewAnthropicProvider(&types.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5", APIKey: "test-key", BaseURL: baseURL})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	return ap
+}
+
+func TestAnthropicProvider_CacheControl(t *testing.T) {
+	ap := newTestAnthropicProvider(t, "")
+
+	req := ap.buildRequest(nil, &StreamOptions{
+		System:      "long system prompt",
+		CacheSystem: true,
+		Tools: []ToolSchema{
+			{Name: "read", InputSchema: map[string]any{"type": "object"}},
+			{Name: "write", InputSchema: map[string]any{"type": "object"}, Cacheable: true},
+		},
+	})
+
+	system := req["system"].([]map[string]any)[0]
+	if cc, ok := system["cache_control"].(map[string]any); !ok || cc["type"] != "ephemeral" {
+		t.Errorf("expected ephemeral cache_control on system block, got %v", system)
+	}
+	tools := req["tools"].([]map[string]any)
+	if _, ok := tools[0]["cache_control"]; ok {
+		t.Error("tools not marked cacheable should not carry cache_control")
+	}
+	if cc, ok := tools[1]["cache_control"].(map[string]any); !ok || cc["type"] != "ephemeral" {
+		t.Errorf("expected cache_control on cacheable tool, got %v", tools[1])
+	}
+
+	plain := ap.buildRequest(nil, &StreamOptions{System: "short"})
+	if _, ok := plain["system"].([]map[string]any)[0]["cache_control"]; ok {
+		t.Error("system block should not be cached unless requested")
+	}
+}
+
+func TestAnthropicProvider_CacheBreakpointLimit(t *testing.T) {
+	ap := newTestAnthropicProvider(t, "")
+
+	tools := make([]ToolSchema, 5)
+	for i := range tools {
+		tools[i] = ToolSchema{Name: fmt.Sprintf("tool%d", i), Cacheable: true}
+	}
+	req := ap.buildRequest(nil, &StreamOptions{System: "s", CacheSystem: true, Tools: tools})
+
+	marked := 0
+	for _, tool := range req["tools"].([]map[string]any) {
+		if _, ok := tool["cache_control"]; ok {
+			marked++
+		}
+	}
+	if marked != anthropicMaxCacheBreakpoints-1 {
+		t.Errorf("expected %d cached tools alongside the system breakpoint, got %d", anthropicMaxCacheBreakpoints-1, marked)
+	}
+}
+
+func TestAnthropicProvider_CacheUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":false`) {
+			_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":2000,"cache_read_input_tokens":0}}`)
+			return
+		}
+		_, _ = io.WriteString(w, strings.Join([]string{
+			`data: {"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1,"cache_creation_input_tokens":0,"cache_read_input_tokens":2000}}}`,
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`,
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`,
+			"",
+		}, "\n"))
+	}))
+	defer server.Close()
+	ap := newTestAnthropicProvider(t, server.URL)
+
+	resp, err := ap.Complete(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "hi"}}, &StreamOptions{CacheSystem: true, System: "s"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Usage.CacheCreationTokens != 2000 || resp.Usage.CacheReadTokens != 0 || resp.Usage.InputTokens != 10 {
+		t.Errorf("unexpected complete usage: %+v", resp.Usage)
+	}
+
+	chunks, err := ap.Stream(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "hi"}}, &StreamOptions{})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var final *TokenUsage
+	for chunk := range chunks {
+		if chunk.Type == "message_delta" {
+			final = chunk.Usage
+		}
+	}
+	if final == nil || final.OutputTokens != 7 || final.InputTokens != 12 || final.CacheReadTokens != 2000 || final.CachedTokens != 2000 {
+		t.Errorf("message_delta usage should include input and cache tokens from message_start, got %+v", final)
+	}
+}
+
+func TestUnsupportedOptions_CacheSystem(t *testing.T) {
+	opts := &StreamOptions{CacheSystem: true}
+	if got := unsupportedOptions(ProviderCapabilities{}, opts); len(got) != 1 || got[0] != "cache_system" {
+		t.Errorf("expected cache_system reported as unsupported, got %v", got)
+	}
+	if got := unsupportedOptions(ProviderCapabilities{SupportPromptCache: true}, opts); len(got) != 0 {
+		t.Errorf("expected no unsupported options, got %v", got)
+	}
+}
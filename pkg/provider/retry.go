@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/logging"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+var retryLog = logging.ForComponent("ProviderRetry")
+
+// RetryProvider 对 HTTP 429 和 5xx 自动重试的 Provider 包装器
+// 响应带 Retry-After 时按其等待，否则按指数退避；其他错误（包括其余 4xx）立即返回。
+// 流式请求只在建立连接时重试，开始输出后的错误不再重试。
+type RetryProvider struct {
+	Provider
+	config types.RetryConfig
+}
+
+// NewRetryProvider 使用重试配置包装 Provider
+func NewRetryProvider(p Provider, config types.RetryConfig) *RetryProvider {
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 60 * time.Second
+	}
+	return &RetryProvider{Provider: p, config: config}
+}
+
+// Unwrap 返回被包装的 Provider
+func (p *RetryProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Stream 发起流式请求，建立连接失败且可重试时退避后重试
+func (p *RetryProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	var chunks <-chan StreamChunk
+	err := p.do(ctx, func() error {
+		var err error
+		chunks, err = p.Provider.Stream(ctx, messages, opts)
+		return err
+	})
+	return chunks, err
+}
+
+// Complete 发起非流式请求，可重试的错误退避后重试
+func (p *RetryProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	var resp *CompleteResponse
+	err := p.do(ctx, func() error {
+		var err error
+		resp, err = p.Provider.Complete(ctx, messages, opts)
+		return err
+	})
+	return resp, err
+}
+
+// Ping 透传健康检查，不重试
+func (p *RetryProvider) Ping(ctx context.Context) error {
+	return Ping(ctx, p.Provider)
+}
+
+// do 执行请求，可重试的错误在重试次数内退避后重新执行
+func (p *RetryProvider) do(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= p.config.MaxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		delay := p.backoff(err, attempt)
+		retryLog.Debug(ctx, "retrying provider request", map[string]any{
+			"attempt": attempt + 1,
+			"status":  StatusCodeOf(err),
+			"delay":   delay.String(),
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff 第 attempt 次重试前的等待时间，优先使用 Retry-After，不超过 MaxBackoff
+func (p *RetryProvider) backoff(err error, attempt int) time.Duration {
+	delay := p.config.BaseBackoff << attempt
+	if retryAfter := RetryAfterOf(err); retryAfter > 0 {
+		delay = retryAfter
+	}
+	if delay <= 0 || delay > p.config.MaxBackoff {
+		delay = p.config.MaxBackoff
+	}
+	return delay
+}
+
+// IsRetryable 错误是否可以重试（HTTP 429 或 5xx）
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := StatusCodeOf(err)
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// RetryAfterOf 返回错误中 Retry-After 指定的等待时间，未指定时返回 0
+func RetryAfterOf(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// newStatusError 根据非 2xx 响应创建状态错误，body 为已读取的响应体
+func newStatusError(providerName string, resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		Provider:   providerName,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），无效时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// newStatusServer 依次返回 statuses 中的状态码，用完后返回成功响应
+func newStatusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(statuses[n-1])
+			_, _ = io.WriteString(w, `{"error":"transient"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newRetryTestProvider(t *testing.T, baseURL string, maxRetries int) Provider {
+	t.Helper()
+	p, err := NewMultiProviderFactory().Create(&types.ModelConfig{
+		Provider: "anthropic",
+		Model:    "claude-sonnet-4-5",
+		APIKey:   "test-key",
+		BaseURL:  baseURL,
+		Retry:    &types.RetryConfig{MaxRetries: maxRetries, BaseBackoff: time.Millisecond, MaxBackoff: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	return p
+}
+
+func TestRetryProvider_RetriesTransientErrors(t *testing.T) {
+	server, calls := newStatusServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	p := newRetryTestProvider(t, server.URL, 3)
+
+	resp, err := p.Complete(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Message.GetContent() != "ok" {
+		t.Errorf("unexpected response %+v", resp.Message)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryProvider_NonRetryable(t *testing.T) {
+	server, calls := newStatusServer(t, http.StatusBadRequest)
+	p := newRetryTestProvider(t, server.URL, 3)
+
+	_, err := p.Complete(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "hi"}}, nil)
+	if StatusCodeOf(err) != http.StatusBadRequest {
+		t.Fatalf("expected 400 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("4xx errors should not be retried, got %d attempts", calls.Load())
+	}
+}
+
+func TestRetryProvider_Exhausted(t *testing.T) {
+	server, calls := newStatusServer(t, 500, 502, 503, 504)
+	p := newRetryTestProvider(t, server.URL, 2)
+
+	_, err := p.Stream(context.Background(), []types.Message{{Role: types.MessageRoleUser, Content: "hi"}}, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 {
+		t.Fatalf("expected last status error, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d", calls.Load())
+	}
+}
+
+func TestRetryProvider_Backoff(t *testing.T) {
+	p := NewRetryProvider(nil, types.RetryConfig{MaxRetries: 3, BaseBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second})
+
+	transient := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	if got := p.backoff(transient, 2); got != 400*time.Millisecond {
+		t.Errorf("expected exponential backoff 400ms, got %s", got)
+	}
+	if got := p.backoff(&StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}, 0); got != 3*time.Second {
+		t.Errorf("Retry-After should take precedence, got %s", got)
+	}
+	if got := p.backoff(&StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}, 0); got != 5*time.Second {
+		t.Errorf("delay should be capped by MaxBackoff, got %s", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-1", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFactory_RetryDisablesLimiterRetry(t *testing.T) {
+	p, err := NewMultiProviderFactory().Create(&types.ModelConfig{
+		Provider:      "anthropic",
+		Model:         "claude-sonnet-4-5",
+		APIKey:        "retry-limiter-key",
+		MaxConcurrent: 2,
+		Retry:         &types.RetryConfig{MaxRetries: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, ok := p.(*RetryProvider)
+	if !ok {
+		t.Fatalf("expected RetryProvider, got %T", p)
+	}
+	limited, ok := retry.Unwrap().(*LimitedProvider)
+	if !ok || !limited.noRetry {
+		t.Errorf("limiter inside RetryProvider should not retry 429 itself, got %T", retry.Unwrap())
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/logging"
+)
+
+// stopSequenceMatcher 流式输出的停止序列检测
+// 停止序列可能跨越多个 delta，因此会保留可能构成序列前缀的尾部文本，
+// 直到确认不匹配后再输出；命中后截断序列及其之后的内容。
+type stopSequenceMatcher struct {
+	stops   []string
+	maxLen  int
+	pending string
+	stopped bool
+}
+
+// newStopSequenceMatcher 创建停止序列检测器，无有效序列时返回 nil
+func newStopSequenceMatcher(stops []string) *stopSequenceMatcher {
+	m := &stopSequenceMatcher{}
+	for _, s := range stops {
+		if s == "" {
+			continue
+		}
+		m.stops = append(m.stops, s)
+		if len(s) > m.maxLen {
+			m.maxLen = len(s)
+		}
+	}
+	if len(m.stops) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Push 追加文本增量，返回可以安全输出的文本以及是否命中停止序列
+func (m *stopSequenceMatcher) Push(delta string) (string, bool) {
+	if m.stopped {
+		return "", true
+	}
+
+	m.pending += delta
+
+	// 查找最早出现的停止序列
+	cut := -1
+	for _, s := range m.stops {
+		if idx := strings.Index(m.pending, s); idx >= 0 && (cut < 0 || idx < cut) {
+			cut = idx
+		}
+	}
+	if cut >= 0 {
+		out := m.pending[:cut]
+		m.pending = ""
+		m.stopped = true
+		return out, true
+	}
+
+	// 保留可能是停止序列前缀的尾部
+	keep := 0
+	for _, s := range m.stops {
+		for n := min(len(s)-1, len(m.pending)); n > keep; n-- {
+			if strings.HasSuffix(m.pending, s[:n]) {
+				keep = n
+				break
+			}
+		}
+	}
+
+	out := m.pending[:len(m.pending)-keep]
+	m.pending = m.pending[len(m.pending)-keep:]
+	return out, false
+}
+
+// Flush 流结束时输出剩余的缓冲文本
+func (m *stopSequenceMatcher) Flush() string {
+	out := m.pending
+	m.pending = ""
+	return out
+}
+
+// unsupportedOptions 返回 opts 中 Provider 不支持的选项名称
+func unsupportedOptions(caps ProviderCapabilities, opts *StreamOptions) []string {
+	if opts == nil {
+		return nil
+	}
+
+	var unsupported []string
+	if len(opts.StopSequences) > 0 && !caps.SupportStopSequences {
+		unsupported = append(unsupported, "stop_sequences")
+	}
+	if len(opts.LogitBias) > 0 && !caps.SupportLogitBias {
+		unsupported = append(unsupported, "logit_bias")
+	}
+	if opts.ReasoningEffort != "" && !caps.SupportReasoningEffort {
+		unsupported = append(unsupported, "reasoning_effort")
+	}
+	if opts.ResponseFormat != nil && opts.ResponseFormat.Type != ResponseFormatText &&
+		!caps.SupportJSONMode && !caps.SupportStructuredOutput {
+		unsupported = append(unsupported, "response_format")
+	}
+	if opts.CacheSystem && !caps.SupportPromptCache {
+		unsupported = append(unsupported, "cache_system")
+	}
+	return unsupported
+}
+
+// logUnsupportedOptions 记录被忽略的不支持选项
+func logUnsupportedOptions(log *logging.ComponentLogger, providerName string, caps ProviderCapabilities, opts *StreamOptions) {
+	if ignored := unsupportedOptions(caps, opts); len(ignored) > 0 {
+		log.Info(context.Background(), "ignoring unsupported stream options", map[string]any{
+			"provider": providerName,
+			"options":  ignored,
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestStopSequenceMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		stops   []string
+		deltas  []string
+		want    string
+		stopped bool
+	}{
+		{"no match", []string{"END"}, []string{"hello ", "world"}, "hello world", false},
+		{"single delta", []string{"END"}, []string{"hello END world"}, "hello ", true},
+		{"split across deltas", []string{"###"}, []string{"answer #", "#", "# trailing"}, "answer ", true},
+		{"partial prefix released", []string{"STOP"}, []string{"ST", "ART"}, "START", false},
+		{"earliest of many", []string{"b", "a"}, []string{"xxab"}, "xx", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newStopSequenceMatcher(tt.stops)
+			var out strings.Builder
+			stopped := false
+			for _, d := range tt.deltas {
+				text, s := m.Push(d)
+				out.WriteString(text)
+				if s {
+					stopped = true
+					break
+				}
+			}
+			if !stopped {
+				out.WriteString(m.Flush())
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, out.String())
+			}
+			if stopped != tt.stopped {
+				t.Errorf("expected stopped=%v, got %v", tt.stopped, stopped)
+			}
+		})
+	}
+
+	if newStopSequenceMatcher([]string{""}) != nil {
+		t.Error("expected nil matcher for empty stop sequences")
+	}
+}
+
+func TestOpenAIProvider_StopSequencesAndLogitBias(t *testing.T) {
+	var received map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		// 模拟上游未处理 stop 参数，继续输出停止序列之后的内容
+		for _, delta := range []string{"Result: 42", "\n#", "## ignored", " more text"} {
+			chunk, _ := json.Marshal(map[string]any{
+				"choices": []any{map[string]any{"delta": map[string]any{"content": delta}}},
+			})
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p, err := NewOpenAIProvider(&types.ModelConfig{
+		Provider: "openai",
+		Model:    "gpt-4o",
+		APIKey:   "test-key",
+		BaseURL:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create OpenAI provider: %v", err)
+	}
+
+	caps := p.Capabilities()
+	if !caps.SupportStopSequences || !caps.SupportLogitBias {
+		t.Error("OpenAI should report stop sequence and logit bias support")
+	}
+
+	chunks, err := p.Stream(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, &StreamOptions{
+		StopSequences: []string{"###"},
+		LogitBias:     map[string]float64{"50256": -100},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	var text strings.Builder
+	var finish string
+	for chunk := range chunks {
+		switch chunk.Type {
+		case string(ChunkTypeText):
+			text.WriteString(chunk.TextDelta)
+		case string(ChunkTypeDone):
+			finish = chunk.FinishReason
+		}
+	}
+
+	if text.String() != "Result: 42\n" {
+		t.Errorf("expected output trimmed at stop sequence, got %q", text.String())
+	}
+	if finish != "stop" {
+		t.Errorf("expected finish reason 'stop', got %q", finish)
+	}
+
+	stop, ok := received["stop"].([]any)
+	if !ok || len(stop) != 1 || stop[0] != "###" {
+		t.Errorf("expected stop sequences in request, got %v", received["stop"])
+	}
+	bias, ok := received["logit_bias"].(map[string]any)
+	if !ok || bias["50256"] != float64(-100) {
+		t.Errorf("expected logit_bias in request, got %v", received["logit_bias"])
+	}
+}
+
+func TestProviders_UnsupportedLogitBiasIgnored(t *testing.T) {
+	opts := &StreamOptions{
+		StopSequences: []string{"\n\nHuman:"},
+		LogitBias:     map[string]float64{"1": 5},
+	}
+
+	ap, err := NewAnthropicProvider(&types.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Failed to create Anthropic provider: %v", err)
+	}
+	req := ap.buildRequest(nil, opts)
+	if stops, ok := req["stop_sequences"].([]string); !ok || len(stops) != 1 {
+		t.Errorf("expected stop_sequences in Anthropic request, got %v", req["stop_sequences"])
+	}
+	if _, exists := req["logit_bias"]; exists {
+		t.Error("Anthropic request should not contain logit_bias")
+	}
+	if got := unsupportedOptions(ap.Capabilities(), opts); len(got) != 1 || got[0] != "logit_bias" {
+		t.Errorf("expected logit_bias reported as unsupported, got %v", got)
+	}
+
+	gp, err := NewGroqProvider(&types.ModelConfig{Provider: "groq", Model: "llama-3.3-70b-versatile", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Failed to create Groq provider: %v", err)
+	}
+	body := gp.(*GroqProvider).buildRequest(nil, opts, true)
+	if _, exists := body["logit_bias"]; exists {
+		t.Error("Groq request should not contain logit_bias")
+	}
+	if _, exists := body["stop"]; !exists {
+		t.Error("Groq request should contain stop sequences")
+	}
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// ErrRequestTimeout 请求超过 ModelConfig.RequestTimeout，同时匹配 context.DeadlineExceeded
+var ErrRequestTimeout = errors.New("provider request timeout")
+
+// StreamErrorCodeTimeout 流式请求超时时错误块的错误码
+const StreamErrorCodeTimeout = "request_timeout"
+
+// TimeoutProvider 为每次请求设置超时的 Provider 包装器
+// 超时覆盖整个请求：Complete 直到返回，Stream 直到流结束。
+// 流式请求超时后发送一个 StreamErrorCodeTimeout 错误块并关闭通道，上游流在后台排空。
+type TimeoutProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+// NewTimeoutProvider 使用请求超时包装 Provider
+func NewTimeoutProvider(p Provider, timeout time.Duration) *TimeoutProvider {
+	return &TimeoutProvider{Provider: p, timeout: timeout}
+}
+
+// Unwrap 返回被包装的 Provider
+func (p *TimeoutProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Stream 发起带超时的流式请求
+func (p *TimeoutProvider) Stream(ctx context.Context, messages []types.Message, opts *StreamOptions) (<-chan StreamChunk, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	chunks, err := p.Provider.Stream(reqCtx, messages, opts)
+	if err != nil {
+		err = p.timeoutError(ctx, reqCtx, err)
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					return
+				}
+				select {
+				case out <- chunk:
+					continue
+				case <-reqCtx.Done():
+				}
+			case <-reqCtx.Done():
+			}
+
+			// 已取消：排空上游，超时（而非调用方取消）时通知调用方
+			go func() {
+				for range chunks {
+				}
+			}()
+			if ctx.Err() == nil {
+				select {
+				case out <- StreamChunk{
+					Type:  string(ChunkTypeError),
+					Error: &StreamError{Code: StreamErrorCodeTimeout, Message: fmt.Sprintf("stream exceeded %s", p.timeout)},
+				}:
+				case <-ctx.Done():
+				}
+			}
+			return
+		}
+	}()
+	return out, nil
+}
+
+// Complete 发起带超时的非流式请求
+func (p *TimeoutProvider) Complete(ctx context.Context, messages []types.Message, opts *StreamOptions) (*CompleteResponse, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	resp, err := p.Provider.Complete(reqCtx, messages, opts)
+	if err != nil {
+		return nil, p.timeoutError(ctx, reqCtx, err)
+	}
+	return resp, nil
+}
+
+// Ping 透传健康检查，使用健康检查自身的超时
+func (p *TimeoutProvider) Ping(ctx context.Context) error {
+	return Ping(ctx, p.Provider)
+}
+
+// timeoutError 请求超时（而非调用方取消）时返回 ErrRequestTimeout，其余错误原样返回
+func (p *TimeoutProvider) timeoutError(ctx, reqCtx context.Context, err error) error {
+	if ctx.Err() != nil || !errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w after %s: %w", ErrRequestTimeout, p.timeout, context.DeadlineExceeded)
+}
+
+// TimeoutErrorOf 返回超时错误块对应的错误，其他块返回 nil
+func TimeoutErrorOf(chunk StreamChunk) error {
+	if chunk.Type != string(ChunkTypeError) || chunk.Error == nil || chunk.Error.Code != StreamErrorCodeTimeout {
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %w", ErrRequestTimeout, chunk.Error.Message, context.DeadlineExceeded)
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// newSlowServer 先发送一个文本增量，然后挂起直到客户端断开；released 在处理函数返回时关闭
+func newSlowServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(released)
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		data, _ := io.ReadAll(r.Body) // 读完请求体后服务端才能感知连接断开
+		_ = json.Unmarshal(data, &body)
+		if !body.Stream {
+			// 非流式请求：直接挂起
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server, released
+}
+
+func newTimeoutTestProvider(t *testing.T, baseURL string, timeout time.Duration) Provider {
+	t.Helper()
+	p, err := NewMultiProviderFactory().Create(&types.ModelConfig{
+		Provider:       "openai",
+		Model:          "gpt-4o",
+		APIKey:         "test-key",
+		BaseURL:        baseURL,
+		RequestTimeout: timeout,
+	})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	if _, ok := p.(*TimeoutProvider); !ok {
+		t.Fatalf("expected TimeoutProvider, got %T", p)
+	}
+	return p
+}
+
+// waitReleased 等待服务端感知到连接取消
+func waitReleased(t *testing.T, released <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-released:
+	case <-time.After(2 * time.Second):
+		t.Fatal("HTTP request was not canceled")
+	}
+}
+
+func TestTimeoutProvider_Complete(t *testing.T) {
+	server, released := newSlowServer(t)
+	p := newTimeoutTestProvider(t, server.URL, 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := p.Complete(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, nil)
+	if !errors.Is(err, ErrRequestTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected request timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Complete should return promptly, took %s", elapsed)
+	}
+	waitReleased(t, released)
+}
+
+func TestTimeoutProvider_Stream(t *testing.T) {
+	server, released := newSlowServer(t)
+	p := newTimeoutTestProvider(t, server.URL, 100*time.Millisecond)
+
+	chunks, err := p.Stream(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var text string
+	var timeoutErr error
+	deadline := time.After(2 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				done = true
+				break
+			}
+			text += chunk.TextDelta
+			if err := TimeoutErrorOf(chunk); err != nil {
+				timeoutErr = err
+			}
+		case <-deadline:
+			t.Fatal("stream was not closed after timeout")
+		}
+	}
+
+	if text != "partial" {
+		t.Errorf("chunks before the timeout should be delivered, got %q", text)
+	}
+	if !errors.Is(timeoutErr, ErrRequestTimeout) || !errors.Is(timeoutErr, context.DeadlineExceeded) {
+		t.Errorf("expected timeout error chunk, got %v", timeoutErr)
+	}
+	waitReleased(t, released)
+}
+
+func TestTimeoutProvider_StreamCallerCancel(t *testing.T) {
+	server, released := newSlowServer(t)
+	p := newTimeoutTestProvider(t, server.URL, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := p.Stream(ctx, []types.Message{{Role: types.RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	<-chunks // 首个文本增量
+	cancel()
+
+	select {
+	case chunk, ok := <-chunks:
+		if ok {
+			t.Errorf("no chunk expected after caller cancellation, got %+v", chunk)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not closed after cancellation")
+	}
+	waitReleased(t, released)
+}
+
+func TestFactory_NoRequestTimeout(t *testing.T) {
+	p, err := NewMultiProviderFactory().Create(&types.ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: "test-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*TimeoutProvider); ok {
+		t.Error("provider should not be wrapped without RequestTimeout")
+	}
+}
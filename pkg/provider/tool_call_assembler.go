@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// assembleToolCalls 将流中按 index 分片的工具调用增量合并为完整的工具调用
+//
+// 多个工具调用可以交错到达，参数在流结束（done 块或通道关闭）时才算完整，
+// 此时按 index 顺序为每个工具调用发送一个 tool_call 块（ArgumentsDelta 为完整参数 JSON）。
+// 发送的 Index 为内容块索引：已有文本输出时从 1 开始，避免与文本块冲突。
+// ctx 取消后停止发送并在后台排空 in，调用方停止读取时不会阻塞。
+func assembleToolCalls(ctx context.Context, in <-chan StreamChunk) <-chan StreamChunk {
+	out := make(chan StreamChunk, 10)
+
+	go func() {
+		defer close(out)
+
+		send := func(chunk StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				go func() {
+					for range in {
+					}
+				}()
+				return false
+			}
+		}
+
+		calls := make(map[int]*ToolCallDelta)
+		args := make(map[int]*strings.Builder)
+		hasText := false
+
+		flush := func() bool {
+			offset := 0
+			if hasText {
+				offset = 1
+			}
+			for _, index := range slices.Sorted(maps.Keys(calls)) {
+				call := calls[index]
+				call.ArgumentsDelta = args[index].String()
+				if strings.TrimSpace(call.ArgumentsDelta) == "" {
+					call.ArgumentsDelta = "{}"
+				}
+				if call.ID == "" {
+					call.ID = fmt.Sprintf("call_%d", index)
+				}
+				if call.Type == "" {
+					call.Type = "function"
+				}
+				call.Index = index + offset
+				if !send(StreamChunk{Type: string(ChunkTypeToolCall), Index: call.Index, ToolCall: call}) {
+					return false
+				}
+			}
+			clear(calls)
+			clear(args)
+			return true
+		}
+
+		for chunk := range in {
+			switch chunk.Type {
+			case string(ChunkTypeToolCall):
+				if delta := chunk.ToolCall; delta != nil {
+					call, ok := calls[delta.Index]
+					if !ok {
+						call = &ToolCallDelta{}
+						calls[delta.Index] = call
+						args[delta.Index] = &strings.Builder{}
+					}
+					if call.ID == "" {
+						call.ID = delta.ID
+					}
+					if call.Type == "" {
+						call.Type = delta.Type
+					}
+					if call.Name == "" {
+						call.Name = delta.Name
+					}
+					args[delta.Index].WriteString(delta.ArgumentsDelta)
+				}
+				continue
+			case string(ChunkTypeText):
+				hasText = true
+			case string(ChunkTypeDone):
+				if !flush() {
+					return
+				}
+			}
+			if !send(chunk) {
+				return
+			}
+		}
+		flush()
+	}()
+
+	return out
+}
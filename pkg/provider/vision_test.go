@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// imageMessages 一条包含文本、base64 图片和 URL 图片的用户消息
+func imageMessages() []types.Message {
+	return []types.Message{{
+		Role: types.MessageRoleUser,
+		ContentBlocks: []types.ContentBlock{
+			&types.TextBlock{Text: "what is in these images?"},
+			types.NewImageFromBytes([]byte("\x89PNG\r\n\x1a\nfake"), ""),
+			types.NewImageFromURL("https://example.com/diagram.jpg", "image/jpeg"),
+		},
+	}}
+}
+
+func TestAnthropicProvider_EncodesImages(t *testing.T) {
+	ap, err := NewAnthropicProvider(&types.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	if !ap.Capabilities().SupportVision {
+		t.Error("Anthropic should report vision support")
+	}
+
+	content := ap.convertMessages(imageMessages())[0]["content"].([]any)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(content))
+	}
+
+	b64 := content[1].(map[string]any)
+	source := b64["source"].(map[string]any)
+	if b64["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/png" || source["data"] == "" {
+		t.Errorf("unexpected base64 image block: %v", b64)
+	}
+
+	url := content[2].(map[string]any)["source"].(map[string]any)
+	if url["type"] != "url" || url["url"] != "https://example.com/diagram.jpg" {
+		t.Errorf("unexpected url image block: %v", url)
+	}
+}
+
+func TestOpenAICompatibleProvider_EncodesImages(t *testing.T) {
+	p, err := NewOpenAICompatibleProvider(&types.ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: "test-key"}, OpenAIAPIBaseURL, "OpenAI", nil)
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+
+	content := p.convertMessages(imageMessages())[0]["content"].([]map[string]any)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(content))
+	}
+
+	dataURL := content[1]["image_url"].(map[string]any)["url"].(string)
+	if content[1]["type"] != "image_url" || dataURL[:22] != "data:image/png;base64," {
+		t.Errorf("unexpected base64 image part: %v", content[1])
+	}
+	if content[2]["image_url"].(map[string]any)["url"] != "https://example.com/diagram.jpg" {
+		t.Errorf("unexpected url image part: %v", content[2])
+	}
+}
+
+func TestGeminiProvider_EncodesImages(t *testing.T) {
+	p, err := NewGeminiProvider(&types.ModelConfig{Provider: "gemini", Model: "gemini-2.0-flash", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	gp := p.(*GeminiProvider)
+	if !gp.Capabilities().SupportVision {
+		t.Error("Gemini should report vision support")
+	}
+
+	parts := gp.convertMessages(imageMessages())[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	if parts[1].InlineData == nil || parts[1].InlineData.MimeType != "image/png" || parts[1].InlineData.Data == "" {
+		t.Errorf("unexpected inline image part: %+v", parts[1])
+	}
+	if parts[2].FileData == nil || parts[2].FileData.FileURI != "https://example.com/diagram.jpg" || parts[2].FileData.MimeType != "image/jpeg" {
+		t.Errorf("unexpected file image part: %+v", parts[2])
+	}
+}
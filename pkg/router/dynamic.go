@@ -0,0 +1,212 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// DynamicRouterConfig 动态路由器配置
+type DynamicRouterConfig struct {
+	// Alpha 指数移动平均的平滑系数 (0, 1]，越大越偏向最近的结果，默认 0.3
+	Alpha float64 `json:"alpha,omitempty"`
+	// ErrorThreshold 错误率达到该值时熔断模型，默认 0.5
+	ErrorThreshold float64 `json:"error_threshold,omitempty"`
+	// MinSamples 熔断前至少需要的样本数，默认 5
+	MinSamples int `json:"min_samples,omitempty"`
+	// Cooldown 熔断持续时间，到期后放行请求试探恢复，默认 30s
+	Cooldown time.Duration `json:"cooldown,omitempty"`
+}
+
+// ModelStats 模型的运行统计快照
+type ModelStats struct {
+	Latency   time.Duration `json:"latency"`    // 成功请求延迟的移动平均
+	ErrorRate float64       `json:"error_rate"` // 错误率的移动平均
+	Samples   int           `json:"samples"`
+	OpenUntil time.Time     `json:"open_until,omitzero"` // 熔断截止时间，零值表示未熔断
+}
+
+// modelStats 单个模型的内部统计
+type modelStats struct {
+	ModelStats
+	latencySamples int
+	halfOpen       bool
+}
+
+// DynamicRouter 根据实际调用结果路由的路由器。
+// 按 StaticRouter 的匹配规则得到候选模型，跳过被熔断的模型；
+// PriorityLatency 时按观测到的延迟从低到高选择，尚无延迟数据的模型排在后面。
+// 调用方通过 RecordResult 反馈每次请求的延迟和错误。
+type DynamicRouter struct {
+	static *StaticRouter
+	config DynamicRouterConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]*modelStats
+}
+
+// NewDynamicRouter 创建动态路由器
+func NewDynamicRouter(static *StaticRouter, config DynamicRouterConfig) *DynamicRouter {
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = 0.3
+	}
+	if config.ErrorThreshold <= 0 {
+		config.ErrorThreshold = 0.5
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+
+	return &DynamicRouter{
+		static: static,
+		config: config,
+		now:    time.Now,
+		stats:  make(map[string]*modelStats),
+	}
+}
+
+// SelectModel 选择未熔断的候选模型
+func (r *DynamicRouter) SelectModel(ctx context.Context, intent *RouteIntent) (*types.ModelConfig, error) {
+	candidates := r.static.candidates(intent)
+	if len(candidates) == 0 {
+		return r.static.SelectModel(ctx, intent)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	type candidate struct {
+		model   *types.ModelConfig
+		latency time.Duration
+	}
+	var available []candidate
+	for _, cfg := range candidates {
+		stats := r.stats[modelKey(cfg)]
+		if stats == nil {
+			available = append(available, candidate{model: cfg})
+			continue
+		}
+		if now.Before(stats.OpenUntil) {
+			continue
+		}
+		var latency time.Duration
+		if stats.latencySamples > 0 {
+			latency = stats.Latency
+		}
+		available = append(available, candidate{model: cfg, latency: latency})
+	}
+
+	if len(available) == 0 {
+		return nil, fmt.Errorf("all %d candidate models are circuit-broken", len(candidates))
+	}
+
+	if intent != nil && intent.Priority == PriorityLatency {
+		slices.SortStableFunc(available, func(a, b candidate) int {
+			// 无延迟数据的模型排在已知模型之后
+			if (a.latency == 0) != (b.latency == 0) {
+				if a.latency == 0 {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Compare(a.latency, b.latency)
+		})
+	}
+
+	return available[0].model, nil
+}
+
+// RecordResult 反馈一次请求的结果。
+// 成功请求计入延迟；调用方取消的请求不计入统计。
+func (r *DynamicRouter) RecordResult(model *types.ModelConfig, latency time.Duration, err error) {
+	if model == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := modelKey(model)
+	stats := r.stats[key]
+	if stats == nil {
+		stats = &modelStats{}
+		r.stats[key] = stats
+	}
+
+	now := r.now()
+	if !stats.OpenUntil.IsZero() && !now.Before(stats.OpenUntil) {
+		// 熔断到期，本次请求为试探
+		stats.halfOpen = true
+		stats.OpenUntil = time.Time{}
+	}
+
+	alpha := r.config.Alpha
+	stats.Samples++
+	if err != nil {
+		stats.ErrorRate = ema(stats.ErrorRate, 1, alpha, stats.Samples)
+	} else {
+		stats.ErrorRate = ema(stats.ErrorRate, 0, alpha, stats.Samples)
+		if latency > 0 {
+			stats.latencySamples++
+			stats.Latency = time.Duration(ema(float64(stats.Latency), float64(latency), alpha, stats.latencySamples))
+		}
+	}
+
+	if stats.halfOpen {
+		stats.halfOpen = false
+		if err != nil {
+			stats.OpenUntil = now.Add(r.config.Cooldown)
+		} else {
+			stats.ErrorRate = 0
+		}
+		return
+	}
+
+	if stats.Samples >= r.config.MinSamples && stats.ErrorRate >= r.config.ErrorThreshold {
+		stats.OpenUntil = now.Add(r.config.Cooldown)
+	}
+}
+
+// Stats 返回模型的统计快照
+func (r *DynamicRouter) Stats(model *types.ModelConfig) (ModelStats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[modelKey(model)]
+	if !ok {
+		return ModelStats{}, false
+	}
+	return stats.ModelStats, true
+}
+
+// Reset 清除所有统计和熔断状态
+func (r *DynamicRouter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats = make(map[string]*modelStats)
+}
+
+// ema 计算指数移动平均，首个样本直接作为初始值
+func ema(current, sample, alpha float64, samples int) float64 {
+	if samples <= 1 {
+		return sample
+	}
+	return alpha*sample + (1-alpha)*current
+}
+
+// modelKey 模型统计的索引键
+func modelKey(cfg *types.ModelConfig) string {
+	return cfg.Provider + "/" + cfg.Model + "@" + cfg.BaseURL
+}
+
+var _ Router = (*DynamicRouter)(nil)
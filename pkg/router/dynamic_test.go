@@ -0,0 +1,134 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// newTestDynamicRouter 创建使用可控时钟的动态路由器
+func newTestDynamicRouter(static *StaticRouter, config DynamicRouterConfig) (*DynamicRouter, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewDynamicRouter(static, config)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestDynamicRouter_PicksFastestModel(t *testing.T) {
+	slow := &types.ModelConfig{Provider: "openai", Model: "slow"}
+	fast := &types.ModelConfig{Provider: "openai", Model: "fast"}
+	unknown := &types.ModelConfig{Provider: "openai", Model: "unknown"}
+	static := NewStaticRouter(nil, []StaticRouteEntry{
+		{Task: "chat", Priority: PriorityLatency, Model: unknown},
+		{Task: "chat", Priority: PriorityLatency, Model: slow},
+		{Task: "chat", Priority: PriorityLatency, Model: fast},
+	})
+	r, _ := newTestDynamicRouter(static, DynamicRouterConfig{})
+
+	r.RecordResult(slow, 800*time.Millisecond, nil)
+	r.RecordResult(fast, 200*time.Millisecond, nil)
+
+	intent := &RouteIntent{Task: "chat", Priority: PriorityLatency}
+	selected, err := r.SelectModel(context.Background(), intent)
+	if err != nil {
+		t.Fatalf("SelectModel failed: %v", err)
+	}
+	if selected != fast {
+		t.Errorf("expected fastest model, got %s", selected.Model)
+	}
+
+	// 延迟变化后按移动平均重新排序
+	for range 10 {
+		r.RecordResult(fast, 2*time.Second, nil)
+	}
+	if selected, _ = r.SelectModel(context.Background(), intent); selected != slow {
+		t.Errorf("expected slow model to become fastest, got %s", selected.Model)
+	}
+
+	// 无延迟数据的模型排在已知模型之后，但仍可作为候选
+	r.RecordResult(slow, 0, errors.New("boom"))
+	r.RecordResult(fast, 0, errors.New("boom"))
+	if selected, _ = r.SelectModel(context.Background(), intent); selected == unknown {
+		t.Error("models without latency data should rank after measured ones")
+	}
+}
+
+func TestDynamicRouter_EMA(t *testing.T) {
+	m := &types.ModelConfig{Provider: "p", Model: "m"}
+	r, _ := newTestDynamicRouter(NewStaticRouter(m, nil), DynamicRouterConfig{Alpha: 0.5})
+
+	r.RecordResult(m, 100*time.Millisecond, nil)
+	r.RecordResult(m, 300*time.Millisecond, nil)
+	r.RecordResult(m, 0, errors.New("boom"))
+
+	stats, ok := r.Stats(m)
+	if !ok {
+		t.Fatal("expected stats to be recorded")
+	}
+	if stats.Latency != 200*time.Millisecond {
+		t.Errorf("expected latency EMA 200ms, got %s", stats.Latency)
+	}
+	if stats.ErrorRate != 0.5 || stats.Samples != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// 调用方取消的请求不计入
+	r.RecordResult(m, time.Second, context.Canceled)
+	if stats, _ = r.Stats(m); stats.Samples != 3 {
+		t.Errorf("canceled requests should be ignored, got %d samples", stats.Samples)
+	}
+}
+
+func TestDynamicRouter_CircuitBreaker(t *testing.T) {
+	flaky := &types.ModelConfig{Provider: "openai", Model: "flaky"}
+	stable := &types.ModelConfig{Provider: "openai", Model: "stable"}
+	static := NewStaticRouter(stable, []StaticRouteEntry{{Task: "chat", Model: flaky}})
+	r, now := newTestDynamicRouter(static, DynamicRouterConfig{MinSamples: 3, ErrorThreshold: 0.5, Cooldown: time.Minute})
+	intent := &RouteIntent{Task: "chat"}
+
+	for range 3 {
+		r.RecordResult(flaky, 0, errors.New("503"))
+	}
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != stable {
+		t.Fatalf("flaky model should be circuit-broken, got %s", selected.Model)
+	}
+
+	// 熔断到期后放行试探，试探失败重新熔断
+	*now = now.Add(time.Minute)
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != flaky {
+		t.Fatalf("expected half-open model to be selectable, got %s", selected.Model)
+	}
+	r.RecordResult(flaky, 0, errors.New("503"))
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != stable {
+		t.Fatalf("failed probe should reopen the circuit, got %s", selected.Model)
+	}
+
+	// 试探成功后恢复
+	*now = now.Add(time.Minute)
+	r.RecordResult(flaky, 100*time.Millisecond, nil)
+	stats, _ := r.Stats(flaky)
+	if !stats.OpenUntil.IsZero() || stats.ErrorRate != 0 {
+		t.Errorf("successful probe should close the circuit, got %+v", stats)
+	}
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != flaky {
+		t.Errorf("expected recovered model, got %s", selected.Model)
+	}
+}
+
+func TestDynamicRouter_AllCircuitsOpen(t *testing.T) {
+	m := &types.ModelConfig{Provider: "p", Model: "m"}
+	r, _ := newTestDynamicRouter(NewStaticRouter(m, nil), DynamicRouterConfig{MinSamples: 1})
+
+	r.RecordResult(m, 0, errors.New("down"))
+	if _, err := r.SelectModel(context.Background(), &RouteIntent{}); err == nil {
+		t.Error("expected error when every candidate is circuit-broken")
+	}
+
+	r.Reset()
+	if selected, err := r.SelectModel(context.Background(), &RouteIntent{}); err != nil || selected != m {
+		t.Errorf("expected model after reset, got %v, %v", selected, err)
+	}
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// defaultHealthTTL 健康检查结果默认缓存时长
+const defaultHealthTTL = time.Minute
+
+// HealthProbe 模型健康探测函数
+type HealthProbe func(ctx context.Context, cfg *types.ModelConfig) (*provider.ModelHealth, error)
+
+// HealthAwareRouter 感知模型健康状态的路由器。
+// 按 StaticRouter 的匹配顺序依次尝试候选模型，跳过不可达或鉴权失败的模型；
+// 探测结果会缓存 TTL 时长，避免每次路由都发起请求。
+type HealthAwareRouter struct {
+	static *StaticRouter
+	probe  HealthProbe
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	health map[string]*provider.ModelHealth
+}
+
+// NewHealthAwareRouter 创建健康感知路由器，probe 为 nil 时使用 provider.ProbeModelWithFactory
+func NewHealthAwareRouter(static *StaticRouter, probe HealthProbe) *HealthAwareRouter {
+	if probe == nil {
+		factory := provider.NewMultiProviderFactory()
+		probe = func(ctx context.Context, cfg *types.ModelConfig) (*provider.ModelHealth, error) {
+			return provider.ProbeModelWithFactory(ctx, factory, cfg)
+		}
+	}
+
+	return &HealthAwareRouter{
+		static: static,
+		probe:  probe,
+		ttl:    defaultHealthTTL,
+		health: make(map[string]*provider.ModelHealth),
+	}
+}
+
+// WithTTL 设置健康检查结果的缓存时长
+func (r *HealthAwareRouter) WithTTL(ttl time.Duration) *HealthAwareRouter {
+	r.ttl = ttl
+	return r
+}
+
+// SelectModel 选择第一个健康的候选模型
+func (r *HealthAwareRouter) SelectModel(ctx context.Context, intent *RouteIntent) (*types.ModelConfig, error) {
+	candidates := r.static.candidates(intent)
+	if len(candidates) == 0 {
+		return r.static.SelectModel(ctx, intent)
+	}
+
+	var unhealthy []string
+	for _, cfg := range candidates {
+		health := r.Health(ctx, cfg)
+		if health.Healthy() {
+			return cfg, nil
+		}
+		unhealthy = append(unhealthy, fmt.Sprintf("%s/%s (%s)", cfg.Provider, cfg.Model, health.Error))
+	}
+
+	return nil, fmt.Errorf("no healthy model available: %s", strings.Join(unhealthy, "; "))
+}
+
+// Health 返回模型的健康状态（优先使用缓存）
+func (r *HealthAwareRouter) Health(ctx context.Context, cfg *types.ModelConfig) *provider.ModelHealth {
+	key := modelKey(cfg)
+
+	r.mu.Lock()
+	cached, ok := r.health[key]
+	r.mu.Unlock()
+	if ok && time.Since(cached.CheckedAt) < r.ttl {
+		return cached
+	}
+
+	health, err := r.probe(ctx, cfg)
+	if health == nil {
+		health = &provider.ModelHealth{Provider: cfg.Provider, Model: cfg.Model, CheckedAt: time.Now()}
+		if err != nil {
+			health.Error = err.Error()
+		}
+	}
+
+	r.mu.Lock()
+	r.health[key] = health
+	r.mu.Unlock()
+
+	return health
+}
+
+// Invalidate 清除所有缓存的健康状态
+func (r *HealthAwareRouter) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.health = make(map[string]*provider.ModelHealth)
+}
+
+var _ Router = (*HealthAwareRouter)(nil)
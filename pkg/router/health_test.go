@@ -0,0 +1,65 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestHealthAwareRouter_SkipsUnhealthyModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	primary := &types.ModelConfig{Provider: "openai", Model: "primary", APIKey: "bad-key", BaseURL: server.URL}
+	fallback := &types.ModelConfig{Provider: "openai", Model: "fallback", APIKey: "good-key", BaseURL: server.URL}
+
+	static := NewStaticRouter(fallback, []StaticRouteEntry{{Task: "chat", Model: primary}})
+	r := NewHealthAwareRouter(static, nil)
+
+	selected, err := r.SelectModel(context.Background(), &RouteIntent{Task: "chat"})
+	if err != nil {
+		t.Fatalf("SelectModel failed: %v", err)
+	}
+	if selected != fallback {
+		t.Errorf("expected fallback model, got %s", selected.Model)
+	}
+
+	health := r.Health(context.Background(), primary)
+	if health.Healthy() || health.AuthValid {
+		t.Errorf("expected primary to be reported with invalid auth, got %+v", health)
+	}
+}
+
+func TestHealthAwareRouter_CachesProbeResults(t *testing.T) {
+	calls := 0
+	probe := func(_ context.Context, cfg *types.ModelConfig) (*provider.ModelHealth, error) {
+		calls++
+		if cfg.Model == "down" {
+			return nil, errors.New("unreachable")
+		}
+		return &provider.ModelHealth{Model: cfg.Model, Reachable: true, AuthValid: true}, nil
+	}
+
+	down := &types.ModelConfig{Provider: "mock", Model: "down"}
+	r := NewHealthAwareRouter(NewStaticRouter(down, nil), probe)
+
+	for range 3 {
+		if _, err := r.SelectModel(context.Background(), &RouteIntent{Task: "chat"}); err == nil {
+			t.Fatal("expected error when no healthy model is available")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected probe result to be cached, got %d calls", calls)
+	}
+}
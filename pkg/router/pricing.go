@@ -0,0 +1,120 @@
+package router
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// defaultRankTokens 按成本排序时未指定预估用量的默认 Token 数
+const defaultRankTokens = 1000
+
+// ModelPrice 模型单价（美元 / 1K tokens）
+type ModelPrice struct {
+	InputPer1K  float64 `json:"input_per_1k"`
+	OutputPer1K float64 `json:"output_per_1k"`
+}
+
+// defaultPrices 内置参考价格，键为 "provider/model"
+var defaultPrices = map[string]ModelPrice{
+	"anthropic/claude-opus-4-1":   {InputPer1K: 0.015, OutputPer1K: 0.075},
+	"anthropic/claude-sonnet-4-5": {InputPer1K: 0.003, OutputPer1K: 0.015},
+	"anthropic/claude-haiku-4-5":  {InputPer1K: 0.001, OutputPer1K: 0.005},
+	"openai/gpt-4o":               {InputPer1K: 0.0025, OutputPer1K: 0.01},
+	"openai/gpt-4o-mini":          {InputPer1K: 0.00015, OutputPer1K: 0.0006},
+	"deepseek/deepseek-chat":      {InputPer1K: 0.00014, OutputPer1K: 0.00028},
+	"gemini/gemini-2.0-flash":     {InputPer1K: 0.0001, OutputPer1K: 0.0004},
+}
+
+// PricingTable 按 provider/model 索引的模型价格表，并发安全。
+// provider 为空的条目对任意 Provider 的同名模型生效，优先级低于精确匹配。
+type PricingTable struct {
+	mu     sync.RWMutex
+	prices map[string]ModelPrice
+}
+
+// NewPricingTable 创建价格表，prices 的键为 "provider/model" 或 "/model"
+func NewPricingTable(prices map[string]ModelPrice) *PricingTable {
+	return &PricingTable{prices: maps.Clone(prices)}
+}
+
+// DefaultPricingTable 返回内置参考价格的副本，可通过 Set 覆盖
+func DefaultPricingTable() *PricingTable {
+	return NewPricingTable(defaultPrices)
+}
+
+// Set 设置（覆盖）模型价格，provider 为空时对任意 Provider 生效
+func (t *PricingTable) Set(provider, model string, price ModelPrice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.prices == nil {
+		t.prices = make(map[string]ModelPrice)
+	}
+	t.prices[provider+"/"+model] = price
+}
+
+// Remove 删除模型价格
+func (t *PricingTable) Remove(provider, model string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.prices, provider+"/"+model)
+}
+
+// Price 查询模型价格，先精确匹配 provider/model，再匹配不限 Provider 的条目
+func (t *PricingTable) Price(provider, model string) (ModelPrice, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if price, ok := t.prices[provider+"/"+model]; ok {
+		return price, true
+	}
+	price, ok := t.prices["/"+model]
+	return price, ok
+}
+
+// EstimateCost 估算一次调用的成本（美元），价格未知时返回 false
+func (t *PricingTable) EstimateCost(cfg *types.ModelConfig, inputTokens, outputTokens int64) (float64, bool) {
+	if cfg == nil {
+		return 0, false
+	}
+	price, ok := t.Price(cfg.Provider, cfg.Model)
+	if !ok {
+		return 0, false
+	}
+	return float64(inputTokens)/1000*price.InputPer1K + float64(outputTokens)/1000*price.OutputPer1K, true
+}
+
+// rankByCost 按预估成本从低到高稳定排序，价格未知的模型保持原顺序排在最后
+func (t *PricingTable) rankByCost(models []*types.ModelConfig, inputTokens, outputTokens int64) []*types.ModelConfig {
+	if inputTokens <= 0 && outputTokens <= 0 {
+		inputTokens, outputTokens = defaultRankTokens, defaultRankTokens
+	}
+
+	type ranked struct {
+		model *types.ModelConfig
+		cost  float64
+		known bool
+	}
+	items := make([]ranked, len(models))
+	for i, model := range models {
+		cost, known := t.EstimateCost(model, inputTokens, outputTokens)
+		items[i] = ranked{model: model, cost: cost, known: known}
+	}
+	slices.SortStableFunc(items, func(a, b ranked) int {
+		if a.known != b.known {
+			if a.known {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.cost, b.cost)
+	})
+
+	result := make([]*types.ModelConfig, len(items))
+	for i, item := range items {
+		result[i] = item.model
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestPricingTable_EstimateCost(t *testing.T) {
+	table := NewPricingTable(map[string]ModelPrice{
+		"openai/gpt-4o": {InputPer1K: 0.0025, OutputPer1K: 0.01},
+		"/shared-model": {InputPer1K: 0.001, OutputPer1K: 0.002},
+	})
+
+	cost, ok := table.EstimateCost(&types.ModelConfig{Provider: "openai", Model: "gpt-4o"}, 2000, 500)
+	if !ok || math.Abs(cost-0.01) > 1e-9 {
+		t.Errorf("expected cost 0.01, got %v (known=%v)", cost, ok)
+	}
+
+	// provider 为空的条目对任意 Provider 生效
+	cost, ok = table.EstimateCost(&types.ModelConfig{Provider: "openrouter", Model: "shared-model"}, 1000, 1000)
+	if !ok || math.Abs(cost-0.003) > 1e-9 {
+		t.Errorf("expected wildcard price 0.003, got %v (known=%v)", cost, ok)
+	}
+
+	if _, ok := table.EstimateCost(&types.ModelConfig{Provider: "openai", Model: "unknown"}, 1000, 1000); ok {
+		t.Error("unknown model should not have a price")
+	}
+	if _, ok := table.EstimateCost(nil, 1000, 1000); ok {
+		t.Error("nil config should not have a price")
+	}
+}
+
+func TestPricingTable_SetOverridesWildcard(t *testing.T) {
+	table := NewPricingTable(map[string]ModelPrice{"/m": {InputPer1K: 1, OutputPer1K: 1}})
+	table.Set("p", "m", ModelPrice{InputPer1K: 0.5, OutputPer1K: 0.5})
+
+	if price, _ := table.Price("p", "m"); price.InputPer1K != 0.5 {
+		t.Errorf("exact entry should take precedence, got %+v", price)
+	}
+	if price, _ := table.Price("other", "m"); price.InputPer1K != 1 {
+		t.Errorf("other providers should use wildcard entry, got %+v", price)
+	}
+
+	table.Remove("p", "m")
+	if price, _ := table.Price("p", "m"); price.InputPer1K != 1 {
+		t.Errorf("removed entry should fall back to wildcard, got %+v", price)
+	}
+}
+
+func TestStaticRouter_PriorityCostPicksCheapest(t *testing.T) {
+	expensive := &types.ModelConfig{Provider: "anthropic", Model: "claude-opus-4-1"}
+	cheap := &types.ModelConfig{Provider: "openai", Model: "gpt-4o-mini"}
+	unpriced := &types.ModelConfig{Provider: "local", Model: "llama"}
+	fallback := &types.ModelConfig{Provider: "deepseek", Model: "deepseek-chat"}
+
+	r := NewStaticRouter(fallback, []StaticRouteEntry{
+		{Task: "chat", Priority: PriorityCost, Model: unpriced},
+		{Task: "chat", Priority: PriorityCost, Model: expensive},
+		{Task: "chat", Model: cheap},
+	})
+
+	got := r.candidates(&RouteIntent{Task: "chat", Priority: PriorityCost})
+	want := []*types.ModelConfig{cheap, expensive, unpriced, fallback}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d candidates, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate %d: expected %s, got %s", i, want[i].Model, got[i].Model)
+		}
+	}
+
+	// 覆盖价格后排序随之变化
+	r.Pricing().Set("local", "llama", ModelPrice{})
+	selected, err := r.SelectModel(context.Background(), &RouteIntent{Task: "chat", Priority: PriorityCost})
+	if err != nil {
+		t.Fatalf("SelectModel failed: %v", err)
+	}
+	if selected != unpriced {
+		t.Errorf("expected free local model after override, got %s", selected.Model)
+	}
+}
+
+func TestStaticRouter_PriorityCostUsesExpectedTokens(t *testing.T) {
+	// 输入便宜输出贵 vs 输入贵输出便宜
+	inputHeavy := &types.ModelConfig{Provider: "p", Model: "cheap-input"}
+	outputHeavy := &types.ModelConfig{Provider: "p", Model: "cheap-output"}
+	r := NewStaticRouter(nil, []StaticRouteEntry{
+		{Task: "summarize", Priority: PriorityCost, Model: outputHeavy},
+		{Task: "summarize", Priority: PriorityCost, Model: inputHeavy},
+	}).WithPricing(NewPricingTable(map[string]ModelPrice{
+		"p/cheap-input":  {InputPer1K: 0.001, OutputPer1K: 0.01},
+		"p/cheap-output": {InputPer1K: 0.01, OutputPer1K: 0.001},
+	}))
+
+	intent := &RouteIntent{Task: "summarize", Priority: PriorityCost, ExpectedInputTokens: 50000, ExpectedOutputTokens: 500}
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != inputHeavy {
+		t.Errorf("expected input-cheap model for long prompts, got %s", selected.Model)
+	}
+
+	intent = &RouteIntent{Task: "summarize", Priority: PriorityCost, ExpectedInputTokens: 500, ExpectedOutputTokens: 50000}
+	if selected, _ := r.SelectModel(context.Background(), intent); selected != outputHeavy {
+		t.Errorf("expected output-cheap model for long outputs, got %s", selected.Model)
+	}
+}
+
+func TestStaticRouter_OtherPrioritiesKeepStaticOrder(t *testing.T) {
+	expensive := &types.ModelConfig{Provider: "anthropic", Model: "claude-opus-4-1"}
+	cheap := &types.ModelConfig{Provider: "openai", Model: "gpt-4o-mini"}
+	r := NewStaticRouter(nil, []StaticRouteEntry{
+		{Task: "code", Priority: PriorityQuality, Model: expensive},
+		{Task: "code", Priority: PriorityQuality, Model: cheap},
+	})
+
+	selected, err := r.SelectModel(context.Background(), &RouteIntent{Task: "code", Priority: PriorityQuality})
+	if err != nil {
+		t.Fatalf("SelectModel failed: %v", err)
+	}
+	if selected != expensive {
+		t.Errorf("non-cost priorities should keep static order, got %s", selected.Model)
+	}
+}
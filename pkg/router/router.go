@@ -30,6 +30,10 @@ type RouteIntent struct {
 	Priority Priority `json:"priority,omitempty"`
 	// TemplateID 可选，对应当前 Agent 使用的模板 ID。
 	TemplateID string `json:"template_id,omitempty"`
+	// ExpectedInputTokens / ExpectedOutputTokens 可选，预估的输入/输出 Token 数，
+	// 用于 PriorityCost 下按成本排序；均未指定时按各 1K 估算。
+	ExpectedInputTokens  int64 `json:"expected_input_tokens,omitempty"`
+	ExpectedOutputTokens int64 `json:"expected_output_tokens,omitempty"`
 	// Metadata 预留扩展字段，比如调用方、业务场景等。
 	Metadata map[string]any `json:"metadata,omitempty"`
 }
@@ -53,6 +57,7 @@ type StaticRouteEntry struct {
 type StaticRouter struct {
 	defaultModel *types.ModelConfig
 	routes       []StaticRouteEntry
+	pricing      *PricingTable
 }
 
 // NewStaticRouter 创建一个静态路由器。
@@ -61,46 +66,70 @@ func NewStaticRouter(defaultModel *types.ModelConfig, routes []StaticRouteEntry)
 	return &StaticRouter{
 		defaultModel: defaultModel,
 		routes:       routes,
+		pricing:      DefaultPricingTable(),
 	}
 }
 
+// WithPricing 设置 PriorityCost 排序使用的价格表，传 nil 时退回静态顺序
+func (r *StaticRouter) WithPricing(pricing *PricingTable) *StaticRouter {
+	r.pricing = pricing
+	return r
+}
+
+// Pricing 返回当前价格表，可直接 Set 覆盖价格
+func (r *StaticRouter) Pricing() *PricingTable {
+	return r.pricing
+}
+
 // SelectModel 根据 RouteIntent 选择模型。
 // 匹配规则：
 //  1. 先找 Task + Priority 都匹配的条目。
 //  2. 如果找不到，再找 Task 匹配但 Priority 为空的条目。
 //  3. 否则返回 defaultModel（如果存在）。
+//
+// Priority 为 PriorityCost 时，1、2 中匹配的模型按价格表预估成本从低到高选择。
 func (r *StaticRouter) SelectModel(_ context.Context, intent *RouteIntent) (*types.ModelConfig, error) {
+	if candidates := r.candidates(intent); len(candidates) > 0 {
+		return candidates[0], nil
+	}
+
 	if intent == nil {
-		if r.defaultModel != nil {
-			return r.defaultModel, nil
-		}
 		return nil, errors.New("route intent is nil and no default model configured")
 	}
+	return nil, fmt.Errorf("no route matched for task=%q priority=%q and no default model configured", intent.Task, intent.Priority)
+}
 
-	// 1. Task + Priority 精确匹配
-	for _, entry := range r.routes {
-		if entry.Model == nil {
-			continue
-		}
-		if entry.Task == intent.Task && entry.Priority == intent.Priority {
-			return entry.Model, nil
+// candidates 按 SelectModel 的匹配规则返回所有候选模型（按优先顺序）
+func (r *StaticRouter) candidates(intent *RouteIntent) []*types.ModelConfig {
+	var result []*types.ModelConfig
+
+	if intent != nil {
+		// 1. Task + Priority 精确匹配
+		for _, entry := range r.routes {
+			if entry.Model != nil && entry.Task == intent.Task && entry.Priority == intent.Priority {
+				result = append(result, entry.Model)
+			}
 		}
-	}
 
-	// 2. 只根据 Task 匹配（Priority 为空）
-	for _, entry := range r.routes {
-		if entry.Model == nil {
-			continue
+		// 2. 只根据 Task 匹配（Priority 为空）
+		if intent.Priority != "" {
+			for _, entry := range r.routes {
+				if entry.Model != nil && entry.Task == intent.Task && entry.Priority == "" {
+					result = append(result, entry.Model)
+				}
+			}
 		}
-		if entry.Task == intent.Task && entry.Priority == "" {
-			return entry.Model, nil
+
+		// PriorityCost 按预估成本排序，兜底模型仍排在最后
+		if intent.Priority == PriorityCost && r.pricing != nil && len(result) > 1 {
+			result = r.pricing.rankByCost(result, intent.ExpectedInputTokens, intent.ExpectedOutputTokens)
 		}
 	}
 
 	// 3. 兜底
 	if r.defaultModel != nil {
-		return r.defaultModel, nil
+		result = append(result, r.defaultModel)
 	}
 
-	return nil, fmt.Errorf("no route matched for task=%q priority=%q and no default model configured", intent.Task, intent.Priority)
+	return result
 }
@@ -47,74 +47,42 @@ func NewMCPClient(config *MCPClientConfig) *MCPClient {
 
 // CallTool 调用 MCP 工具
 func (mc *MCPClient) CallTool(ctx context.Context, toolName string, params map[string]any) (json.RawMessage, error) {
-	// 构建 MCP 请求
-	request := &MCPRequest{
-		JSONRPC: "2.0",
-		Method:  "tools/call",
-		ID:      time.Now().UnixNano(),
-		Params: MCPCallParams{
-			Name:      toolName,
-			Arguments: params,
-		},
-	}
-
-	reqBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	// 创建 HTTP 请求
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.endpoint, bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-Access-Key-Id", mc.accessKeyID)
-	httpReq.Header.Set("X-Access-Key-Secret", mc.accessKeySecret)
-	if mc.securityToken != "" {
-		httpReq.Header.Set("X-Security-Token", mc.securityToken)
-	}
-
-	// 发送请求
-	resp, err := mc.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
+	return mc.Request(ctx, "tools/call", MCPCallParams{
+		Name:      toolName,
+		Arguments: params,
+	})
+}
 
-	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
+// ListTools 列出可用工具
+func (mc *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
+	result, err := mc.Request(ctx, "tools/list", nil)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, err
 	}
 
-	// 检查 HTTP 状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http error: %d - %s", resp.StatusCode, string(respBody))
+	var listResult struct {
+		Tools []MCPTool `json:"tools"`
 	}
-
-	// 解析 MCP 响应
-	var mcpResp MCPResponse
-	if err := json.Unmarshal(respBody, &mcpResp); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
-	// 检查 MCP 错误
-	if mcpResp.Error != nil {
-		return nil, fmt.Errorf("mcp error: %s (code: %d)", mcpResp.Error.Message, mcpResp.Error.Code)
-	}
-
-	return mcpResp.Result, nil
+	return listResult.Tools, nil
 }
 
-// ListTools 列出可用工具
-func (mc *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
-	request := &MCPRequest{
+// Request 发送任意 JSON-RPC 请求并返回 result
+// 服务端返回的错误以 *MCPError 包装，可通过 errors.As 获取错误码。
+func (mc *MCPClient) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	request := struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		ID      int64  `json:"id"`
+		Params  any    `json:"params,omitempty"`
+	}{
 		JSONRPC: "2.0",
-		Method:  "tools/list",
+		Method:  method,
 		ID:      time.Now().UnixNano(),
+		Params:  params,
 	}
 
 	reqBody, err := json.Marshal(request)
@@ -122,11 +90,13 @@ func (mc *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
+	// 创建 HTTP 请求
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.endpoint, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
+	// 设置请求头
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("X-Access-Key-Id", mc.accessKeyID)
 	httpReq.Header.Set("X-Access-Key-Secret", mc.accessKeySecret)
@@ -134,39 +104,36 @@ func (mc *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
 		httpReq.Header.Set("X-Security-Token", mc.securityToken)
 	}
 
+	// 发送请求
 	resp, err := mc.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	// 读取响应
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read response: %w", err)
 	}
 
+	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("http error: %d - %s", resp.StatusCode, string(respBody))
 	}
 
-	var mcpResp struct {
-		JSONRPC string `json:"jsonrpc"`
-		ID      int64  `json:"id"`
-		Result  struct {
-			Tools []MCPTool `json:"tools"`
-		} `json:"result"`
-		Error *MCPError `json:"error,omitempty"`
-	}
-
+	// 解析 MCP 响应
+	var mcpResp MCPResponse
 	if err := json.Unmarshal(respBody, &mcpResp); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
+	// 检查 MCP 错误
 	if mcpResp.Error != nil {
-		return nil, fmt.Errorf("mcp error: %s", mcpResp.Error.Message)
+		return nil, fmt.Errorf("mcp error: %w", mcpResp.Error)
 	}
 
-	return mcpResp.Result.Tools, nil
+	return mcpResp.Result, nil
 }
 
 // MCPRequest MCP 请求
@@ -198,9 +165,71 @@ type MCPError struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+// JSON-RPC 标准错误码
+const (
+	MCPErrorMethodNotFound = -32601
+	MCPErrorInvalidParams  = -32602
+)
+
+func (e *MCPError) Error() string {
+	return fmt.Sprintf("%s (code: %d)", e.Message, e.Code)
+}
+
 // MCPTool MCP 工具定义
 type MCPTool struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	InputSchema map[string]any `json:"inputSchema"`
 }
+
+// MCPResource MCP 资源定义
+type MCPResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPResourceContent MCP 资源内容，Text 和 Blob（base64）二选一
+type MCPResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// MCPPrompt MCP 提示词模板定义
+type MCPPrompt struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument 提示词模板参数
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPPromptMessage 渲染后的提示词消息
+type MCPPromptMessage struct {
+	Role    string           `json:"role"` // "user" 或 "assistant"
+	Content MCPPromptContent `json:"content"`
+}
+
+// MCPPromptContent 提示词消息内容
+// Type 为 "text" 时使用 Text，为 "resource" 时使用 Resource，图片等其他类型保留原始字段
+type MCPPromptContent struct {
+	Type     string              `json:"type"`
+	Text     string              `json:"text,omitempty"`
+	Data     string              `json:"data,omitempty"`
+	MimeType string              `json:"mimeType,omitempty"`
+	Resource *MCPResourceContent `json:"resource,omitempty"`
+}
+
+// MCPGetPromptResult prompts/get 结果
+type MCPGetPromptResult struct {
+	Description string             `json:"description,omitempty"`
+	Messages    []MCPPromptMessage `json:"messages"`
+}
@@ -36,9 +36,12 @@ func (f *Factory) Create(config *types.SandboxConfig) (Sandbox, error) {
 			AllowPaths:      config.AllowPaths,
 			WatchFiles:      config.WatchFiles,
 			Settings:        config.Settings,
+			EnvAllowlist:    config.Env,
+			Secrets:         config.Secrets,
 		})
 
 	case types.SandboxKindDocker:
+		// Docker 沙箱实现时需同样遵循 Env 白名单与 Secrets 注入策略
 		return nil, errors.New("docker sandbox not implemented yet")
 
 	case types.SandboxKindK8s:
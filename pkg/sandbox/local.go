@@ -197,6 +197,10 @@ type LocalSandbox struct {
 	blockedCommands map[string]bool
 	commandStats    map[string]*CommandStats
 	statsMu         sync.RWMutex
+
+	// 环境变量与密钥
+	envAllowlist []string
+	secrets      []secret
 }
 
 // AuditEntry 审计日志条目
@@ -264,6 +268,11 @@ type LocalSandboxConfig struct {
 	ResourceLimits  *ResourceLimits
 	BlockedCommands []string
 	MaxAuditEntries int
+
+	// EnvAllowlist 透传的主机环境变量名（nil 时使用 DefaultEnvAllowlist，严格模式下不透传）
+	EnvAllowlist []string
+	// Secrets 按工具注入的密钥
+	Secrets []types.SandboxSecret
 }
 
 // NewLocalSandbox 创建本地沙箱
@@ -332,6 +341,8 @@ func NewLocalSandbox(config *LocalSandboxConfig) (*LocalSandbox, error) {
 		resourceLimits:  resourceLimits,
 		blockedCommands: blockedCommands,
 		commandStats:    make(map[string]*CommandStats),
+		envAllowlist:    config.EnvAllowlist,
+		secrets:         resolveSecrets(config.Secrets),
 	}
 
 	// 应用 Claude Agent SDK 风格的安全配置
@@ -382,6 +393,7 @@ func (ls *LocalSandbox) Exec(ctx context.Context, cmd string, opts *ExecOptions)
 		if err != nil {
 			return nil, err
 		}
+		result = ls.maskResult(result)
 		ls.recordAudit(cmd, opts, result, startTime, false, "excluded_command")
 		return result, nil
 	}
@@ -431,7 +443,7 @@ func (ls *LocalSandbox) Exec(ctx context.Context, cmd string, opts *ExecOptions)
 	}
 
 	// 6. 执行命令（带资源限制）
-	result := ls.execWithLimits(ctx, cmd, opts)
+	result := ls.maskResult(ls.execWithLimits(ctx, cmd, opts))
 
 	// 7. 记录审计日志
 	ls.recordAudit(cmd, opts, result, startTime, false, "")
@@ -486,7 +498,7 @@ func (ls *LocalSandbox) execWithLimits(ctx context.Context, cmd string, opts *Ex
 	command.Dir = workDir
 
 	// 设置安全环境变量
-	env := ls.buildSecureEnv(opts)
+	env := ls.buildSecureEnv(ctx, opts)
 	command.Env = env
 
 	// 执行并捕获输出
@@ -554,8 +566,44 @@ func (ls *LocalSandbox) buildSecureCommand(cmd string) string {
 	return cmd
 }
 
+// LimitCommand 返回在资源限制下启动 name 的命令和参数
+// Unix 上通过 shell 设置 ulimit 后 exec 替换为目标进程（PID 不变），
+// 适用于需要以独立进程运行的场景（如进程隔离的子代理）。
+// 限制为空或在 Windows 上时原样返回。
+func (l *ResourceLimits) LimitCommand(name string, args ...string) (string, []string) {
+	if l == nil || runtime.GOOS == "windows" {
+		return name, args
+	}
+
+	var limits []string
+	if l.MaxMemoryMB > 0 {
+		// 限制虚拟内存 (KB)
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", l.MaxMemoryMB*1024))
+	}
+	if l.MaxCPUTime > 0 {
+		// 限制 CPU 时间 (秒)，不足 1 秒按 1 秒计
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", max(int64(l.MaxCPUTime/time.Second), 1)))
+	}
+	if l.MaxFileSizeMB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -f %d", l.MaxFileSizeMB*1024))
+	}
+	if l.MaxProcesses > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -u %d", l.MaxProcesses))
+	}
+	if l.MaxOpenFiles > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -n %d", l.MaxOpenFiles))
+	}
+	if len(limits) == 0 {
+		return name, args
+	}
+
+	script := strings.Join(limits, " && ") + ` && exec "$0" "$@"`
+	return getShell(), append([]string{"-c", script, name}, args...)
+}
+
 // buildSecureEnv 构建安全环境变量
-func (ls *LocalSandbox) buildSecureEnv(opts *ExecOptions) []string {
+// 主机环境变量只透传白名单中的变量，密钥按当前工具注入
+func (ls *LocalSandbox) buildSecureEnv(ctx context.Context, opts *ExecOptions) []string {
 	// 构建 PATH：包含常用路径，支持 macOS (Intel/Apple Silicon) 和 Linux
 	// 优先级：用户本地 > Homebrew > 系统路径
 	pathDirs := []string{
@@ -581,15 +629,11 @@ func (ls *LocalSandbox) buildSecureEnv(opts *ExecOptions) []string {
 	// 添加工作目录
 	env = append(env, "PWD="+ls.workDir)
 
-	// 在严格模式下，不继承主机环境变量
-	if ls.securityLevel < SecurityLevelStrict {
-		// 继承部分安全的环境变量
-		safeEnvVars := []string{"TERM", "SHELL", "USER", "LOGNAME", "GOPATH", "GOROOT", "NODE_PATH"}
-		for _, key := range safeEnvVars {
-			if val := os.Getenv(key); val != "" {
-				env = append(env, key+"="+val)
-			}
-		}
+	// 透传白名单中的主机环境变量；严格模式下未显式配置白名单时不继承
+	if ls.envAllowlist != nil {
+		env = append(env, hostEnv(ls.envAllowlist)...)
+	} else if ls.securityLevel < SecurityLevelStrict {
+		env = append(env, hostEnv(DefaultEnvAllowlist)...)
 	}
 
 	// 添加用户指定的环境变量
@@ -602,6 +646,9 @@ func (ls *LocalSandbox) buildSecureEnv(opts *ExecOptions) []string {
 		}
 	}
 
+	// 注入密钥（最后追加，覆盖同名变量）
+	env = append(env, ls.secretEnv(ctx)...)
+
 	return env
 }
 
@@ -793,7 +840,7 @@ func (ls *LocalSandbox) recordAudit(cmd string, opts *ExecOptions, result *ExecR
 
 	entry := AuditEntry{
 		Timestamp:   startTime,
-		Command:     truncate(cmd, 500),
+		Command:     truncate(ls.MaskSecrets(cmd), 500),
 		WorkDir:     ls.workDir,
 		Duration:    time.Since(startTime),
 		Blocked:     blocked,
@@ -820,12 +867,12 @@ func (ls *LocalSandbox) recordAudit(cmd string, opts *ExecOptions, result *ExecR
 	// 记录到结构化日志
 	if blocked {
 		sandboxLogger.Warn(context.Background(), "Command blocked", map[string]any{
-			"command": truncate(cmd, 100),
+			"command": truncate(ls.MaskSecrets(cmd), 100),
 			"reason":  blockReason,
 		})
 	} else {
 		sandboxLogger.Debug(context.Background(), "Command executed", map[string]any{
-			"command":  truncate(cmd, 100),
+			"command":  truncate(ls.MaskSecrets(cmd), 100),
 			"exitCode": entry.ExitCode,
 			"duration": entry.Duration,
 		})
@@ -1090,13 +1137,8 @@ func (ls *LocalSandbox) execDirect(ctx context.Context, cmd string, opts *ExecOp
 	}
 	command.Dir = workDir
 
-	if opts != nil && len(opts.Env) > 0 {
-		env := os.Environ()
-		for k, v := range opts.Env {
-			env = append(env, fmt.Sprintf("%s=%s", k, v))
-		}
-		command.Env = env
-	}
+	// 排除命令同样只使用白名单环境变量
+	command.Env = ls.buildSecureEnv(ctx, opts)
 
 	output, err := command.CombinedOutput()
 	if err != nil {
@@ -3,7 +3,10 @@ package sandbox
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -625,3 +628,24 @@ func TestLocalSandbox_SetSecurityLevel(t *testing.T) {
 		t.Error("expected SecurityLevelStrict after setting")
 	}
 }
+
+func TestResourceLimits_LimitCommand(t *testing.T) {
+	var nilLimits *ResourceLimits
+	if name, args := nilLimits.LimitCommand("echo", "hi"); name != "echo" || len(args) != 1 {
+		t.Errorf("nil limits should return the command unchanged, got %s %v", name, args)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("ulimit is not available on windows")
+	}
+
+	limits := &ResourceLimits{MaxCPUTime: 1500 * time.Millisecond, MaxOpenFiles: 64}
+	name, args := limits.LimitCommand("sh", "-c", `echo "$(ulimit -t) $(ulimit -n) $1"`, "sh", "arg with space")
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		t.Fatalf("limited command failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "1 64 arg with space" {
+		t.Errorf("expected limits and arguments to be applied, got %q", got)
+	}
+}
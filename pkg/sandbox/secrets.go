@@ -0,0 +1,138 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// SecretMask 替换输出中密钥值的掩码
+const SecretMask = "******"
+
+// DefaultEnvAllowlist 未配置 Env 时透传的主机环境变量（均为非敏感变量）
+var DefaultEnvAllowlist = []string{"TERM", "SHELL", "USER", "LOGNAME", "GOPATH", "GOROOT", "NODE_PATH"}
+
+// SecretMasker 能对文本中的密钥值做掩码的沙箱
+// 工具输出、事件和追踪等沙箱之外的输出路径通过它隐藏密钥。
+type SecretMasker interface {
+	MaskSecrets(s string) string
+}
+
+// CommandEnvProvider 能提供命令执行环境变量的沙箱
+// 不经过 Exec 启动的进程（如后台任务）使用它获得与 Exec 相同的白名单环境变量和密钥。
+type CommandEnvProvider interface {
+	CommandEnv(ctx context.Context, opts *ExecOptions) []string
+}
+
+type toolNameKey struct{}
+
+// WithToolName 在 ctx 中记录当前执行的工具名，沙箱据此决定注入哪些密钥
+func WithToolName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, toolNameKey{}, name)
+}
+
+// ToolNameFromContext 获取 ctx 中的工具名
+func ToolNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey{}).(string)
+	return name
+}
+
+// secret 已解析的密钥
+type secret struct {
+	name  string
+	value string
+	tools []string
+}
+
+// appliesTo 密钥是否可注入到指定工具
+func (s secret) appliesTo(tool string) bool {
+	return len(s.tools) == 0 || slices.Contains(s.tools, tool)
+}
+
+// resolveSecrets 解析密钥配置，跳过值为空的密钥
+func resolveSecrets(configs []types.SandboxSecret) []secret {
+	secrets := make([]secret, 0, len(configs))
+	for _, cfg := range configs {
+		value := cfg.Value
+		if value == "" && cfg.FromEnv != "" {
+			value = os.Getenv(cfg.FromEnv)
+		}
+		if cfg.Name == "" || value == "" {
+			continue
+		}
+		secrets = append(secrets, secret{name: cfg.Name, value: value, tools: cfg.Tools})
+	}
+	return secrets
+}
+
+// hostEnv 返回允许透传的主机环境变量
+func hostEnv(allowlist []string) []string {
+	env := make([]string, 0, len(allowlist))
+	for _, key := range allowlist {
+		if val, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+val)
+		}
+	}
+	return env
+}
+
+// secretEnv 返回当前工具可用的密钥环境变量
+func (ls *LocalSandbox) secretEnv(ctx context.Context) []string {
+	tool := ToolNameFromContext(ctx)
+	env := make([]string, 0, len(ls.secrets))
+	for _, s := range ls.secrets {
+		if s.appliesTo(tool) {
+			env = append(env, s.name+"="+s.value)
+		}
+	}
+	return env
+}
+
+// MaskSecrets 将文本中出现的所有密钥值替换为掩码
+func (ls *LocalSandbox) MaskSecrets(s string) string {
+	for _, secret := range ls.secrets {
+		s = strings.ReplaceAll(s, secret.value, SecretMask)
+	}
+	return s
+}
+
+// CommandEnv 返回与 Exec 相同的命令环境变量
+func (ls *LocalSandbox) CommandEnv(ctx context.Context, opts *ExecOptions) []string {
+	return ls.buildSecureEnv(ctx, opts)
+}
+
+// MaskValue 返回 v 的副本，其中所有字符串中的密钥值都被掩码
+// 支持嵌套的 map[string]any 和 []any，其他类型原样返回。
+func MaskValue(masker SecretMasker, v any) any {
+	switch val := v.(type) {
+	case string:
+		return masker.MaskSecrets(val)
+	case map[string]any:
+		masked := make(map[string]any, len(val))
+		for k, item := range val {
+			masked[k] = MaskValue(masker, item)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(val))
+		for i, item := range val {
+			masked[i] = MaskValue(masker, item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// maskResult 掩码命令输出中的密钥
+func (ls *LocalSandbox) maskResult(result *ExecResult) *ExecResult {
+	if result == nil || len(ls.secrets) == 0 {
+		return result
+	}
+	result.Stdout = ls.MaskSecrets(result.Stdout)
+	result.Stderr = ls.MaskSecrets(result.Stderr)
+	return result
+}
@@ -0,0 +1,102 @@
+package sandbox
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func newEnvTestSandbox(t *testing.T, config *LocalSandboxConfig) *LocalSandbox {
+	t.Helper()
+	config.WorkDir = t.TempDir()
+	sb, err := NewLocalSandbox(config)
+	if err != nil {
+		t.Fatalf("failed to create sandbox: %v", err)
+	}
+	t.Cleanup(func() { _ = sb.Dispose() })
+	return sb
+}
+
+func TestLocalSandbox_EnvAllowlist(t *testing.T) {
+	t.Setenv("ASTER_TEST_HOST_TOKEN", "host-token")
+	t.Setenv("ASTER_TEST_ALLOWED", "visible")
+
+	ctx := context.Background()
+	cmd := `echo "token=[$ASTER_TEST_HOST_TOKEN] allowed=[$ASTER_TEST_ALLOWED]"`
+
+	// 默认不继承非白名单的主机环境变量
+	sb := newEnvTestSandbox(t, &LocalSandboxConfig{})
+	result, err := sb.Exec(ctx, cmd, nil)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "token=[] allowed=[]") {
+		t.Errorf("host env should not leak by default, got %q", result.Stdout)
+	}
+
+	// 显式白名单只透传指定变量
+	sb = newEnvTestSandbox(t, &LocalSandboxConfig{EnvAllowlist: []string{"ASTER_TEST_ALLOWED"}})
+	result, err = sb.Exec(ctx, cmd, nil)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "token=[] allowed=[visible]") {
+		t.Errorf("expected only allowlisted var, got %q", result.Stdout)
+	}
+
+	// 排除命令同样使用干净的环境
+	sb = newEnvTestSandbox(t, &LocalSandboxConfig{Settings: &types.SandboxSettings{ExcludedCommands: []string{"echo"}}})
+	result, err = sb.Exec(ctx, cmd, nil)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "token=[]") {
+		t.Errorf("excluded command should not inherit host env, got %q", result.Stdout)
+	}
+}
+
+func TestLocalSandbox_SecretsScopedAndMasked(t *testing.T) {
+	t.Setenv("ASTER_TEST_DEPLOY_KEY", "deploy-key-value")
+
+	sb := newEnvTestSandbox(t, &LocalSandboxConfig{
+		Secrets: []types.SandboxSecret{
+			{Name: "API_TOKEN", Value: "s3cr3t-token", Tools: []string{"Bash"}},
+			{Name: "DEPLOY_KEY", FromEnv: "ASTER_TEST_DEPLOY_KEY"},
+		},
+	})
+
+	bashCtx := WithToolName(context.Background(), "Bash")
+	result, err := sb.Exec(bashCtx, `[ "$API_TOKEN" = "s3cr3t-token" ] && echo present; echo "token=$API_TOKEN key=$DEPLOY_KEY"`, nil)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "present") {
+		t.Errorf("secret should be injected for Bash, got %q", result.Stdout)
+	}
+	if strings.Contains(result.Stdout, "s3cr3t-token") || strings.Contains(result.Stdout, "deploy-key-value") {
+		t.Errorf("secret values must be masked in output, got %q", result.Stdout)
+	}
+	if !strings.Contains(result.Stdout, "token="+SecretMask+" key="+SecretMask) {
+		t.Errorf("expected masked secrets, got %q", result.Stdout)
+	}
+
+	// 未授权的工具拿不到受限密钥
+	grepCtx := WithToolName(context.Background(), "Grep")
+	result, err = sb.Exec(grepCtx, `echo "token=[$API_TOKEN]"`, nil)
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "token=[]") {
+		t.Errorf("secret should not be injected for Grep, got %q", result.Stdout)
+	}
+
+	// 审计日志中不出现密钥
+	_, _ = sb.Exec(bashCtx, "echo s3cr3t-token", nil)
+	for _, entry := range sb.GetAuditLog() {
+		if strings.Contains(entry.Command, "s3cr3t-token") {
+			t.Errorf("secret must not appear in audit log: %q", entry.Command)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package netguard 出站 HTTP 请求的 SSRF 防护
+// 校验域名黑白名单，并在建立连接时校验实际解析到的 IP，防止 DNS 重绑定绕过。
+// 内置工具（HTTPRequest、WebFetch）和 A2A 推送通知共用。
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked 目标地址被 SSRF 防护拦截
+var ErrBlocked = errors.New("blocked by SSRF protection")
+
+// blockedNetworks 默认禁止访问的网段（私有、回环、链路本地、云元数据等）
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16", // 链路本地，包含 169.254.169.254 元数据服务
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// blockedHostnames 默认禁止访问的云元数据主机名
+var blockedHostnames = map[string]bool{
+	"metadata":                 true,
+	"metadata.google.internal": true,
+}
+
+// domainPolicy 一组域名黑白名单
+type domainPolicy struct {
+	allowed []string
+	denied  []string
+}
+
+// check 黑名单优先，白名单为空表示不限制
+func (p domainPolicy) check(host string) error {
+	if matchDomain(host, p.denied) {
+		return fmt.Errorf("%w: domain %s is denied", ErrBlocked, host)
+	}
+	if len(p.allowed) > 0 && !matchDomain(host, p.allowed) {
+		return fmt.Errorf("%w: domain %s is not in the allowlist", ErrBlocked, host)
+	}
+	return nil
+}
+
+// Config SSRF 防护配置
+type Config struct {
+	// AllowedDomains 允许访问的域名（含子域名），为空表示不限制
+	AllowedDomains []string
+
+	// DeniedDomains 禁止访问的域名（含子域名），优先于 AllowedDomains
+	DeniedDomains []string
+
+	// AllowedNetworks 显式放行的 IP 或 CIDR，不受私有地址拦截
+	AllowedNetworks []string
+}
+
+// Guard SSRF 防护
+// 默认禁止访问私有、回环、链路本地和云元数据地址。
+type Guard struct {
+	policies        []domainPolicy // 须全部通过
+	allowedNetworks []*net.IPNet
+}
+
+// New 创建 SSRF 防护，config 为 nil 时使用默认规则
+func New(config *Config) (*Guard, error) {
+	g := &Guard{}
+	if config == nil {
+		return g, nil
+	}
+	g = g.WithDomains(config.AllowedDomains, config.DeniedDomains)
+	for _, entry := range config.AllowedNetworks {
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed_networks entry %q: %w", entry, err)
+		}
+		g.allowedNetworks = append(g.allowedNetworks, network)
+	}
+	return g, nil
+}
+
+// WithDomains 返回追加了一组域名黑白名单的副本，两组白名单同时生效
+func (g *Guard) WithDomains(allowed, denied []string) *Guard {
+	policy := domainPolicy{allowed: normalizeDomains(allowed), denied: normalizeDomains(denied)}
+	if len(policy.allowed) == 0 && len(policy.denied) == 0 {
+		return g
+	}
+	return &Guard{
+		policies:        append(slices.Clip(g.policies), policy),
+		allowedNetworks: g.allowedNetworks,
+	}
+}
+
+// CheckURL 校验域名黑白名单和字面量 IP
+func (g *Guard) CheckURL(u *url.URL) error {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if blockedHostnames[host] {
+		return fmt.Errorf("%w: host %s is a metadata endpoint", ErrBlocked, host)
+	}
+	for _, policy := range g.policies {
+		if err := policy.check(host); err != nil {
+			return err
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return g.CheckIP(ip)
+	}
+	return nil
+}
+
+// CheckIP 拦截私有、回环、链路本地和元数据地址，AllowedNetworks 中的地址除外
+func (g *Guard) CheckIP(ip net.IP) error {
+	if ip == nil {
+		return fmt.Errorf("%w: invalid address", ErrBlocked)
+	}
+	for _, network := range g.allowedNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: address %s is not allowed", ErrBlocked, ip)
+		}
+	}
+	return nil
+}
+
+// Transport 创建连接前校验目标 IP 的 Transport
+func (g *Guard) Transport(responseHeaderTimeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// 连接前校验实际 IP，覆盖 DNS 解析和重定向后的所有目标
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return g.CheckIP(net.ParseIP(host))
+		},
+	}
+	return &http.Transport{
+		Proxy:                 nil, // 不使用环境代理，避免绕过 IP 校验
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+}
+
+// Client 创建限制重定向次数并校验重定向目标的 HTTP 客户端
+func (g *Guard) Client(transport http.RoundTripper, maxRedirects int) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return g.CheckURL(req.URL)
+		},
+	}
+}
+
+// matchDomain 判断主机是否为列表中的域名或其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains 统一域名格式
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		if d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// parseNetwork 解析 IP 或 CIDR
+func parseNetwork(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.New("not an IP address or CIDR")
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	return network, err
+}
+
+// mustParseCIDRs 解析内置网段列表
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,285 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// AgentInfo.Metadata 中缓存标题使用的键
+const (
+	MetadataTitle            = "title"
+	MetadataTitleTags        = "title_tags"
+	MetadataTitleTurns       = "title_turns"
+	MetadataTitleFingerprint = "title_fingerprint"
+)
+
+const (
+	maxTitleRunes = 60
+	maxTitleTags  = 5
+)
+
+// ErrEmptyConversation 会话中没有可用于生成标题的内容
+var ErrEmptyConversation = errors.New("no conversation content to generate title")
+
+// GenerateTitle 根据会话内容生成简短标题和主题标签
+// 会话过短（只有一条消息）或未提供 Provider 时，使用第一条用户消息作为标题。
+func GenerateTitle(ctx context.Context, messages []types.Message, p provider.Provider) (string, []string, error) {
+	conversation := conversationTexts(messages)
+	if len(conversation) == 0 {
+		return "", nil, ErrEmptyConversation
+	}
+
+	fallback := fallbackTitle(messages)
+	if p == nil || len(conversation) < 2 {
+		return fallback, nil, nil
+	}
+
+	response, err := p.Complete(ctx, []types.Message{
+		{
+			Role:          types.MessageRoleUser,
+			ContentBlocks: []types.ContentBlock{&types.TextBlock{Text: buildTitlePrompt(conversation)}},
+		},
+	}, &provider.StreamOptions{
+		System:      titleSystemPrompt,
+		Temperature: 0.2,
+		MaxTokens:   200,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("generate title: %w", err)
+	}
+
+	title, tags := parseTitleResponse(response.Message.GetContent())
+	if title == "" {
+		title = fallback
+	}
+	return title, tags, nil
+}
+
+// TitleConfig 标题生成配置
+type TitleConfig struct {
+	Provider    provider.Provider
+	EveryNTurns int // 每隔多少轮用户输入重新生成标题
+	MaxMessages int // 生成标题时使用的最近消息数
+}
+
+// TitleGenerator 自动生成并更新会话标题
+// 标题缓存在 AgentInfo.Metadata 中，只有对话内容发生变化时才重新生成。
+type TitleGenerator struct {
+	provider    provider.Provider
+	everyN      int
+	maxMessages int
+}
+
+// NewTitleGenerator 创建标题生成器
+func NewTitleGenerator(config TitleConfig) *TitleGenerator {
+	if config.EveryNTurns <= 0 {
+		config.EveryNTurns = 3
+	}
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = 20
+	}
+	return &TitleGenerator{
+		provider:    config.Provider,
+		everyN:      config.EveryNTurns,
+		maxMessages: config.MaxMessages,
+	}
+}
+
+// Update 按需生成标题并写入 info.Metadata，返回是否发生更新
+func (g *TitleGenerator) Update(ctx context.Context, info *types.AgentInfo, messages []types.Message) (bool, error) {
+	turns := countUserTurns(messages)
+	if turns == 0 {
+		return false, nil
+	}
+
+	title, _ := info.Metadata[MetadataTitle].(string)
+	if title != "" && turns-metadataInt(info.Metadata[MetadataTitleTurns]) < g.everyN {
+		return false, nil
+	}
+
+	if len(messages) > g.maxMessages {
+		messages = messages[len(messages)-g.maxMessages:]
+	}
+	fingerprint := conversationFingerprint(messages)
+	if title != "" && info.Metadata[MetadataTitleFingerprint] == fingerprint {
+		return false, nil
+	}
+
+	newTitle, tags, err := GenerateTitle(ctx, messages, g.provider)
+	if err != nil {
+		return false, err
+	}
+
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]any)
+	}
+	info.Metadata[MetadataTitle] = newTitle
+	info.Metadata[MetadataTitleTags] = tags
+	info.Metadata[MetadataTitleTurns] = turns
+	info.Metadata[MetadataTitleFingerprint] = fingerprint
+	return true, nil
+}
+
+// TitleFromInfo 读取 AgentInfo 中缓存的标题和标签
+func TitleFromInfo(info *types.AgentInfo) (string, []string) {
+	if info == nil || info.Metadata == nil {
+		return "", nil
+	}
+	title, _ := info.Metadata[MetadataTitle].(string)
+
+	var tags []string
+	switch v := info.Metadata[MetadataTitleTags].(type) {
+	case []string:
+		tags = v
+	case []any:
+		// 从存储反序列化后为 []any
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+	return title, tags
+}
+
+// conversationTexts 提取用户与助手消息的文本，忽略工具调用和结果
+func conversationTexts(messages []types.Message) []string {
+	var texts []string
+	for i := range messages {
+		msg := &messages[i]
+		if msg.Role != types.MessageRoleUser && msg.Role != types.MessageRoleAssistant {
+			continue
+		}
+		text := strings.TrimSpace(msg.GetContent())
+		if text == "" {
+			continue
+		}
+		texts = append(texts, fmt.Sprintf("%s: %s", msg.Role, text))
+	}
+	return texts
+}
+
+// countUserTurns 统计用户输入轮数（不含工具结果消息）
+func countUserTurns(messages []types.Message) int {
+	turns := 0
+	for i := range messages {
+		if messages[i].Role == types.MessageRoleUser && strings.TrimSpace(messages[i].GetContent()) != "" {
+			turns++
+		}
+	}
+	return turns
+}
+
+// conversationFingerprint 计算规范化后的对话指纹，忽略大小写和空白差异
+func conversationFingerprint(messages []types.Message) string {
+	h := sha256.New()
+	for _, text := range conversationTexts(messages) {
+		h.Write([]byte(strings.ToLower(strings.Join(strings.Fields(text), " "))))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fallbackTitle 使用第一条用户消息作为标题
+func fallbackTitle(messages []types.Message) string {
+	for i := range messages {
+		if messages[i].Role != types.MessageRoleUser {
+			continue
+		}
+		if text := strings.TrimSpace(messages[i].GetContent()); text != "" {
+			return truncateTitle(strings.Join(strings.Fields(text), " "))
+		}
+	}
+	return "New conversation"
+}
+
+// truncateTitle 截断标题到最大长度
+func truncateTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= maxTitleRunes {
+		return title
+	}
+	return strings.TrimSpace(string(runes[:maxTitleRunes])) + "…"
+}
+
+// buildTitlePrompt 构建标题生成提示词
+func buildTitlePrompt(conversation []string) string {
+	var prompt strings.Builder
+	prompt.WriteString("Generate a short title and topic tags for the following conversation.\n\n")
+	prompt.WriteString("## Conversation\n\n")
+	for _, line := range conversation {
+		prompt.WriteString(line)
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("\nRespond with JSON only: {\"title\": \"...\", \"tags\": [\"...\"]}\n")
+	return prompt.String()
+}
+
+// parseTitleResponse 解析模型返回的标题 JSON
+// 无法解析时使用第一行非空文本作为标题。
+func parseTitleResponse(text string) (string, []string) {
+	text = strings.TrimSpace(text)
+
+	var result struct {
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start >= 0 && end > start && json.Unmarshal([]byte(text[start:end+1]), &result) == nil {
+		return truncateTitle(strings.TrimSpace(result.Title)), normalizeTags(result.Tags)
+	}
+
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "#*\"' ")
+		if line != "" {
+			return truncateTitle(line), nil
+		}
+	}
+	return "", nil
+}
+
+// normalizeTags 规范化标签：小写、去重、限制数量
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+		if len(result) == maxTitleTags {
+			break
+		}
+	}
+	return result
+}
+
+// metadataInt 读取元数据中的整数（兼容 JSON 反序列化后的 float64）
+func metadataInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// titleSystemPrompt 标题生成系统提示词
+const titleSystemPrompt = `You name conversations for a conversation list.
+
+Guidelines:
+- The title is at most 8 words and describes the main task or topic
+- Do not wrap the title in quotes or add trailing punctuation
+- Provide 1-5 short lowercase topic tags
+- Reply with JSON only`
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// titleProvider 返回固定标题响应的测试 Provider
+type titleProvider struct {
+	provider.Provider
+	reply string
+	calls int
+}
+
+func (p *titleProvider) Complete(ctx context.Context, messages []types.Message, opts *provider.StreamOptions) (*provider.CompleteResponse, error) {
+	p.calls++
+	return &provider.CompleteResponse{
+		Message: types.Message{Role: types.MessageRoleAssistant, Content: p.reply},
+	}, nil
+}
+
+func titleConversation(texts ...string) []types.Message {
+	messages := make([]types.Message, 0, len(texts))
+	for i, text := range texts {
+		role := types.MessageRoleUser
+		if i%2 == 1 {
+			role = types.MessageRoleAssistant
+		}
+		messages = append(messages, types.Message{Role: role, Content: text})
+	}
+	return messages
+}
+
+func TestGenerateTitle(t *testing.T) {
+	p := &titleProvider{reply: "```json\n{\"title\": \"Set up CI for Go service\", \"tags\": [\"CI\", \"go\", \"ci\"]}\n```"}
+	messages := titleConversation("How do I add GitHub Actions to my Go service?", "Create .github/workflows/ci.yml ...")
+
+	title, tags, err := GenerateTitle(context.Background(), messages, p)
+	if err != nil {
+		t.Fatalf("GenerateTitle: %v", err)
+	}
+	if title != "Set up CI for Go service" {
+		t.Errorf("unexpected title %q", title)
+	}
+	if len(tags) != 2 || tags[0] != "ci" || tags[1] != "go" {
+		t.Errorf("expected normalized tags [ci go], got %v", tags)
+	}
+}
+
+func TestGenerateTitle_ShortConversationFallback(t *testing.T) {
+	p := &titleProvider{reply: `{"title": "unused"}`}
+
+	title, tags, err := GenerateTitle(context.Background(), titleConversation("  Fix the   flaky login test  "), p)
+	if err != nil {
+		t.Fatalf("GenerateTitle: %v", err)
+	}
+	if title != "Fix the flaky login test" || tags != nil || p.calls != 0 {
+		t.Errorf("expected first-message fallback without model call, got %q %v (calls=%d)", title, tags, p.calls)
+	}
+
+	if _, _, err := GenerateTitle(context.Background(), nil, p); err != ErrEmptyConversation {
+		t.Errorf("expected ErrEmptyConversation, got %v", err)
+	}
+}
+
+func TestTitleGenerator_Update(t *testing.T) {
+	p := &titleProvider{reply: `{"title": "Database migration", "tags": ["postgres"]}`}
+	g := NewTitleGenerator(TitleConfig{Provider: p, EveryNTurns: 2})
+	info := &types.AgentInfo{}
+
+	messages := titleConversation("Migrate users table", "Sure, here is the SQL")
+	updated, err := g.Update(context.Background(), info, messages)
+	if err != nil || !updated {
+		t.Fatalf("expected initial title generation, updated=%v err=%v", updated, err)
+	}
+	title, tags := TitleFromInfo(info)
+	if title != "Database migration" || len(tags) != 1 || tags[0] != "postgres" {
+		t.Errorf("unexpected cached title %q %v", title, tags)
+	}
+
+	// 未达到 N 轮，不重新生成
+	messages = append(messages, titleConversation("Also add an index", "Done")...)
+	if updated, _ := g.Update(context.Background(), info, messages); updated {
+		t.Error("title should not regenerate before N turns")
+	}
+
+	// 达到 N 轮且内容变化，重新生成
+	messages = append(messages, titleConversation("Now write a rollback", "Here it is")...)
+	if updated, _ := g.Update(context.Background(), info, messages); !updated || p.calls != 2 {
+		t.Errorf("expected regeneration after N turns, updated=%v calls=%d", updated, p.calls)
+	}
+}
@@ -10,17 +10,22 @@ import (
 type StoreType string
 
 const (
-	StoreTypeJSON  StoreType = "json"
-	StoreTypeRedis StoreType = "redis"
-	StoreTypeMySQL StoreType = "mysql"
+	StoreTypeJSON   StoreType = "json"
+	StoreTypeRedis  StoreType = "redis"
+	StoreTypeMySQL  StoreType = "mysql"
+	StoreTypeSQLite StoreType = "sqlite"
 )
 
 // Config Store 配置
 type Config struct {
-	Type StoreType `json:"type" yaml:"type"` // Store 类型: json, redis, mysql
+	Type StoreType `json:"type" yaml:"type"` // Store 类型: json, redis, mysql, sqlite
 
 	// JSON Store 配置
 	DataDir string `json:"data_dir,omitempty" yaml:"data_dir,omitempty"` // 数据目录
+	// EncryptionKey base64 编码的 AES 密钥，为空时读取 ASTER_STORE_ENCRYPTION_KEY，均未设置则不加密
+	EncryptionKey string `json:"encryption_key,omitempty" yaml:"encryption_key,omitempty"`
+	// PreviousEncryptionKeys 轮换前的旧密钥（base64），仅用于解密
+	PreviousEncryptionKeys []string `json:"previous_encryption_keys,omitempty" yaml:"previous_encryption_keys,omitempty"`
 
 	// Redis Store 配置
 	RedisAddr     string        `json:"redis_addr,omitempty" yaml:"redis_addr,omitempty"`         // Redis 地址
@@ -34,6 +39,9 @@ type Config struct {
 	MySQLMaxOpenConns int           `json:"mysql_max_open_conns,omitempty" yaml:"mysql_max_open_conns,omitempty"` // 最大打开连接数
 	MySQLMaxIdleConns int           `json:"mysql_max_idle_conns,omitempty" yaml:"mysql_max_idle_conns,omitempty"` // 最大空闲连接数
 	MySQLMaxLifetime  time.Duration `json:"mysql_max_lifetime,omitempty" yaml:"mysql_max_lifetime,omitempty"`     // 连接最大生命周期
+
+	// SQLite Store 配置
+	SQLitePath string `json:"sqlite_path,omitempty" yaml:"sqlite_path,omitempty"` // 数据库文件路径
 }
 
 // NewStore 创建 Store（工厂方法）
@@ -45,7 +53,11 @@ func NewStore(config Config) (Store, error) {
 		if dataDir == "" {
 			dataDir = ".aster"
 		}
-		return NewJSONStore(dataDir)
+		opts, err := config.jsonStoreOptions()
+		if err != nil {
+			return nil, err
+		}
+		return NewJSONStore(dataDir, opts...)
 
 	case StoreTypeRedis:
 		if config.RedisAddr == "" {
@@ -76,6 +88,13 @@ func NewStore(config Config) (Store, error) {
 
 		return NewMySQLStore(mysqlConfig)
 
+	case StoreTypeSQLite:
+		if config.SQLitePath == "" {
+			return nil, errors.New("sqlite_path is required for sqlite store")
+		}
+
+		return NewSQLiteStore(config.SQLitePath)
+
 	default:
 		return nil, fmt.Errorf("unknown store type: %s", config.Type)
 	}
@@ -89,3 +108,30 @@ func MustNewStore(config Config) Store {
 	}
 	return s
 }
+
+// jsonStoreOptions 根据配置和环境变量生成 JSONStore 加密选项
+func (c Config) jsonStoreOptions() ([]JSONStoreOption, error) {
+	var key []byte
+	var err error
+	if c.EncryptionKey != "" {
+		key, err = ParseEncryptionKey(c.EncryptionKey)
+	} else {
+		key, err = EncryptionKeyFromEnv()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	opts := []JSONStoreOption{WithEncryptionKey(key)}
+	for _, encoded := range c.PreviousEncryptionKeys {
+		previous, err := ParseEncryptionKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPreviousEncryptionKeys(previous))
+	}
+	return opts, nil
+}
@@ -2,15 +2,19 @@ package store
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/astercloud/aster/pkg/types"
 )
 
 // Store 持久化存储接口
 type Store interface {
-	// SaveMessages 保存消息列表
+	// SaveMessages 保存消息列表（整体重写，如压缩历史后）
 	SaveMessages(ctx context.Context, agentID string, messages []types.Message) error
 
+	// AppendMessages 在已保存的消息列表末尾追加消息
+	AppendMessages(ctx context.Context, agentID string, messages []types.Message) error
+
 	// LoadMessages 加载消息列表
 	LoadMessages(ctx context.Context, agentID string) ([]types.Message, error)
 
@@ -18,6 +22,10 @@ type Store interface {
 	// 如果 maxMessages <= 0，则不修剪
 	TrimMessages(ctx context.Context, agentID string, maxMessages int) error
 
+	// CompactMessages 将消息 [0:keepFromIndex) 原子地替换为摘要消息
+	// 用于对话摘要后使持久化历史与发送给模型的历史一致
+	CompactMessages(ctx context.Context, agentID string, keepFromIndex int, summary []types.Message) error
+
 	// SaveToolCallRecords 保存工具调用记录
 	SaveToolCallRecords(ctx context.Context, agentID string, records []types.ToolCallRecord) error
 
@@ -69,6 +77,17 @@ type Store interface {
 	Exists(ctx context.Context, collection, key string) (bool, error)
 }
 
+// compactMessageList 返回用 summary 替换 [0:keepFromIndex) 后的消息列表
+func compactMessageList(messages []types.Message, keepFromIndex int, summary []types.Message) ([]types.Message, error) {
+	if keepFromIndex < 0 || keepFromIndex > len(messages) {
+		return nil, fmt.Errorf("compact index %d out of range [0, %d]", keepFromIndex, len(messages))
+	}
+
+	compacted := make([]types.Message, 0, len(messages)-keepFromIndex+len(summary))
+	compacted = append(compacted, summary...)
+	return append(compacted, messages[keepFromIndex:]...), nil
+}
+
 var (
 	// ErrNotFound 资源未找到错误
 	ErrNotFound = &StoreError{Code: "not_found", Message: "resource not found"}
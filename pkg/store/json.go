@@ -1,11 +1,15 @@
 package store
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -15,6 +19,7 @@ import (
 // JSONStore JSON文件存储实现
 type JSONStore struct {
 	baseDir string
+	cipher  *fileCipher // 为 nil 时不加密
 	mu      sync.RWMutex
 }
 
@@ -37,7 +42,17 @@ func sanitizeAgentIDForPath(agentID string) string {
 }
 
 // NewJSONStore 创建JSON存储
-func NewJSONStore(baseDir string) (*JSONStore, error) {
+func NewJSONStore(baseDir string, opts ...JSONStoreOption) (*JSONStore, error) {
+	options := &jsonStoreOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	fc, err := newFileCipher(options.encryptionKey, options.previousKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	// 确保目录存在
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("create base directory: %w", err)
@@ -45,9 +60,33 @@ func NewJSONStore(baseDir string) (*JSONStore, error) {
 
 	return &JSONStore{
 		baseDir: baseDir,
+		cipher:  fc,
 	}, nil
 }
 
+// readFile 读取文件并按需解密
+func (js *JSONStore) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return js.cipher.open(data, js.fileAAD(path))
+}
+
+// fileAAD 加密文件绑定的附加数据：相对 baseDir 的路径，防止密文被复制到其他文件
+func (js *JSONStore) fileAAD(path string) []byte {
+	rel, err := filepath.Rel(js.baseDir, path)
+	if err != nil {
+		rel = path
+	}
+	return []byte(filepath.ToSlash(rel))
+}
+
+// lineAAD 追加日志行绑定的附加数据：日志路径、Agent ID 和行号，防止行被重排或移到其他日志
+func (js *JSONStore) lineAAD(path, agentID string, index int) []byte {
+	return fmt.Appendf(js.fileAAD(path), "\x00%s\x00%d", agentID, index)
+}
+
 // agentDir 获取Agent的存储目录
 func (js *JSONStore) agentDir(agentID string) string {
 	// 优先使用原始 AgentID 目录（兼容旧数据，主要用于已有的 *nix 环境）
@@ -80,18 +119,26 @@ func (js *JSONStore) saveJSON(path string, data any) error {
 	if err != nil {
 		return fmt.Errorf("marshal json: %w", err)
 	}
+	if jsonData, err = js.cipher.seal(jsonData, js.fileAAD(path)); err != nil {
+		return fmt.Errorf("encrypt file: %w", err)
+	}
 
-	// 写入文件
-	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+	// 先写临时文件再重命名，避免写入中断时损坏原文件
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, jsonData, 0644); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename file: %w", err)
+	}
 
 	return nil
 }
 
 // loadJSON 加载JSON文件
 func (js *JSONStore) loadJSON(path string, dest any) error {
-	data, err := os.ReadFile(path)
+	data, err := js.readFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // 文件不存在返回nil
@@ -106,7 +153,53 @@ func (js *JSONStore) loadJSON(path string, dest any) error {
 	return nil
 }
 
+// messageSnapshot messages.json 的内容
+// Generation 为快照的代数：只有代数不小于它的追加日志才在快照之后写入，
+// 旧格式（纯消息数组）视为第 0 代
+type messageSnapshot struct {
+	Generation int             `json:"generation"`
+	Messages   []types.Message `json:"messages"`
+}
+
+// messageLogName 返回第 generation 代追加日志的文件名（第 0 代沿用 messages.log）
+func messageLogName(generation int) string {
+	if generation == 0 {
+		return "messages.log"
+	}
+	return fmt.Sprintf("messages.%d.log", generation)
+}
+
+// messageLogGenerations 返回目录中已有追加日志的代数（升序）
+func messageLogGenerations(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read agent dir: %w", err)
+	}
+
+	var generations []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == "messages.log" {
+			generations = append(generations, 0)
+			continue
+		}
+		gen, ok := strings.CutPrefix(name, "messages.")
+		if gen, ok = strings.CutSuffix(gen, ".log"); !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(gen); err == nil && n > 0 {
+			generations = append(generations, n)
+		}
+	}
+	slices.Sort(generations)
+	return generations, nil
+}
+
 // SaveMessages 保存消息列表
+// 整体重写 messages.json 并清空追加日志
 func (js *JSONStore) SaveMessages(ctx context.Context, agentID string, messages []types.Message) error {
 	js.mu.Lock()
 	defer js.mu.Unlock()
@@ -115,8 +208,66 @@ func (js *JSONStore) SaveMessages(ctx context.Context, agentID string, messages
 		return err
 	}
 
-	path := filepath.Join(js.agentDir(agentID), "messages.json")
-	return js.saveJSON(path, messages)
+	return js.rewriteMessages(agentID, messages)
+}
+
+// AppendMessages 追加消息
+// 新消息以 JSON Lines 写入最新一代的追加日志，不重写已有历史；下次 SaveMessages 或 TrimMessages 时合并
+func (js *JSONStore) AppendMessages(ctx context.Context, agentID string, messages []types.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	if err := js.ensureAgentDir(agentID); err != nil {
+		return err
+	}
+
+	dir := js.agentDir(agentID)
+	generations, err := messageLogGenerations(dir)
+	if err != nil {
+		return err
+	}
+	generation := 0
+	if len(generations) > 0 {
+		generation = generations[len(generations)-1]
+	}
+	path := filepath.Join(dir, messageLogName(generation))
+
+	// 加密时每行绑定行号，需要先统计已有行数
+	index := 0
+	if js.cipher != nil {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read message log: %w", err)
+		}
+		index = bytes.Count(data, []byte("\n"))
+	}
+
+	var buf bytes.Buffer
+	for i, msg := range messages {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("marshal message: %w", err)
+		}
+		if line, err = js.cipher.sealLine(line, js.lineAAD(path, agentID, index+i)); err != nil {
+			return fmt.Errorf("encrypt message: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open message log: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("append message log: %w", err)
+	}
+	return f.Close()
 }
 
 // LoadMessages 加载消息列表
@@ -124,11 +275,53 @@ func (js *JSONStore) LoadMessages(ctx context.Context, agentID string) ([]types.
 	js.mu.RLock()
 	defer js.mu.RUnlock()
 
-	var messages []types.Message
-	path := filepath.Join(js.agentDir(agentID), "messages.json")
-	if err := js.loadJSON(path, &messages); err != nil {
+	return js.loadMessages(agentID)
+}
+
+// loadSnapshot 加载 messages.json，兼容旧的纯数组格式
+func (js *JSONStore) loadSnapshot(agentID string) (*messageSnapshot, error) {
+	snapshot := &messageSnapshot{}
+	data, err := js.readFile(filepath.Join(js.agentDir(agentID), "messages.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshot, nil
+		}
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &snapshot.Messages)
+	} else {
+		err = json.Unmarshal(data, snapshot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal json: %w", err)
+	}
+	return snapshot, nil
+}
+
+// loadMessages 加载 messages.json 及快照之后的追加日志中的消息
+// 早于快照代数的日志是重写中断后的残留（内容已包含在快照中），跳过
+func (js *JSONStore) loadMessages(agentID string) ([]types.Message, error) {
+	snapshot, err := js.loadSnapshot(agentID)
+	if err != nil {
 		return nil, err
 	}
+	messages := snapshot.Messages
+
+	dir := js.agentDir(agentID)
+	generations, err := messageLogGenerations(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, generation := range generations {
+		if generation < snapshot.Generation {
+			continue
+		}
+		if messages, err = js.replayMessageLog(filepath.Join(dir, messageLogName(generation)), agentID, messages); err != nil {
+			return nil, err
+		}
+	}
 
 	if messages == nil {
 		messages = []types.Message{}
@@ -137,6 +330,93 @@ func (js *JSONStore) LoadMessages(ctx context.Context, agentID string) ([]types.
 	return messages, nil
 }
 
+// replayMessageLog 将追加日志中的消息追加到 messages
+func (js *JSONStore) replayMessageLog(path, agentID string, messages []types.Message) ([]types.Message, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read message log: %w", err)
+	}
+	index := -1
+	for raw := range bytes.Lines(data) {
+		index++
+		line := bytes.TrimSpace(raw)
+		if len(line) == 0 {
+			continue
+		}
+		line, err := js.cipher.openLine(line, js.lineAAD(path, agentID, index))
+		// 密钥错误时报错；没有换行符的最后一行是写入中断的残留，忽略
+		if errors.Is(err, ErrDecryptionFailed) && bytes.HasSuffix(raw, []byte("\n")) {
+			return nil, fmt.Errorf("read message log: %w", err)
+		}
+		var msg types.Message
+		if err != nil || json.Unmarshal(line, &msg) != nil {
+			// 写入中断导致的不完整行忽略
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// CompactMessages 用摘要消息替换 [0:keepFromIndex) 并整体重写
+func (js *JSONStore) CompactMessages(ctx context.Context, agentID string, keepFromIndex int, summary []types.Message) error {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	messages, err := js.loadMessages(agentID)
+	if err != nil {
+		return err
+	}
+
+	compacted, err := compactMessageList(messages, keepFromIndex, summary)
+	if err != nil {
+		return err
+	}
+
+	if err := js.ensureAgentDir(agentID); err != nil {
+		return err
+	}
+	return js.rewriteMessages(agentID, compacted)
+}
+
+// rewriteMessages 以新的代数重写 messages.json 并删除旧的追加日志
+// 先创建新一代的空日志再原子替换快照，任一步骤中断时加载结果都不会重复或丢失消息：
+// 替换快照前新追加的消息写入新日志且仍会被回放，替换后旧日志因代数较小被跳过
+func (js *JSONStore) rewriteMessages(agentID string, messages []types.Message) error {
+	dir := js.agentDir(agentID)
+	generations, err := messageLogGenerations(dir)
+	if err != nil {
+		return err
+	}
+	snapshot, err := js.loadSnapshot(agentID)
+	if err != nil {
+		return err
+	}
+	generation := snapshot.Generation + 1
+	if len(generations) > 0 {
+		generation = max(generation, generations[len(generations)-1]+1)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, messageLogName(generation)), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("create message log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("create message log: %w", err)
+	}
+
+	if err := js.saveJSON(filepath.Join(dir, "messages.json"), messageSnapshot{Generation: generation, Messages: messages}); err != nil {
+		return err
+	}
+
+	for _, old := range generations {
+		if err := os.Remove(filepath.Join(dir, messageLogName(old))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove message log: %w", err)
+		}
+	}
+	return nil
+}
+
 // TrimMessages 修剪消息列表，保留最近的 N 条消息
 // 如果 maxMessages <= 0，则不修剪
 func (js *JSONStore) TrimMessages(ctx context.Context, agentID string, maxMessages int) error {
@@ -147,10 +427,9 @@ func (js *JSONStore) TrimMessages(ctx context.Context, agentID string, maxMessag
 	js.mu.Lock()
 	defer js.mu.Unlock()
 
-	// 加载现有消息
-	var messages []types.Message
-	path := filepath.Join(js.agentDir(agentID), "messages.json")
-	if err := js.loadJSON(path, &messages); err != nil {
+	// 加载现有消息（包括追加日志）
+	messages, err := js.loadMessages(agentID)
+	if err != nil {
 		return err
 	}
 
@@ -163,7 +442,7 @@ func (js *JSONStore) TrimMessages(ctx context.Context, agentID string, maxMessag
 	trimmedMessages := messages[len(messages)-maxMessages:]
 
 	// 保存修剪后的消息
-	return js.saveJSON(path, trimmedMessages)
+	return js.rewriteMessages(agentID, trimmedMessages)
 }
 
 // SaveToolCallRecords 保存工具调用记录
@@ -374,7 +653,7 @@ func (js *JSONStore) Get(ctx context.Context, collection, key string, dest any)
 	defer js.mu.RUnlock()
 
 	path := filepath.Join(js.collectionDir(collection), key+".json")
-	data, err := os.ReadFile(path)
+	data, err := js.readFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return ErrNotFound
@@ -440,7 +719,7 @@ func (js *JSONStore) List(ctx context.Context, collection string) ([]any, error)
 
 		var item any
 		path := filepath.Join(dir, entry.Name())
-		data, err := os.ReadFile(path)
+		data, err := js.readFile(path)
 		if err != nil {
 			continue // 忽略读取失败的文件
 		}
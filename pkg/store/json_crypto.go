@@ -0,0 +1,176 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptionKeyEnv 未在配置中指定密钥时读取的环境变量，值为 base64 编码的 AES 密钥
+const EncryptionKeyEnv = "ASTER_STORE_ENCRYPTION_KEY"
+
+// encryptedFileMagic 加密文件头，后跟 1 字节版本号
+const encryptedFileMagic = "ASTERENC"
+
+// encryptedFileVersion 当前加密格式版本：AES-GCM，头部之后为 nonce + 密文，
+// 密文绑定文件相对路径等附加数据（AAD），不能在文件或日志行之间互换
+const encryptedFileVersion byte = 2
+
+// legacyEncryptedFileVersion 不带附加数据的旧格式，仍可读取，下次保存时升级
+const legacyEncryptedFileVersion byte = 1
+
+// ErrDecryptionFailed 密钥错误或文件被篡改导致解密失败
+var ErrDecryptionFailed = &StoreError{Code: "decryption_failed", Message: "decrypt file failed"}
+
+// JSONStoreOption JSONStore 配置选项
+type JSONStoreOption func(*jsonStoreOptions)
+
+type jsonStoreOptions struct {
+	encryptionKey []byte
+	previousKeys  [][]byte
+}
+
+// WithEncryptionKey 使用 AES-GCM 加密写入的文件，key 长度需为 16、24 或 32 字节
+// 未加密的旧文件仍可读取，下次保存时加密
+func WithEncryptionKey(key []byte) JSONStoreOption {
+	return func(o *jsonStoreOptions) {
+		o.encryptionKey = key
+	}
+}
+
+// WithPreviousEncryptionKeys 轮换密钥时仍用于解密的旧密钥
+// 用旧密钥加密的文件在下次保存时以新密钥重新加密
+func WithPreviousEncryptionKeys(keys ...[]byte) JSONStoreOption {
+	return func(o *jsonStoreOptions) {
+		o.previousKeys = append(o.previousKeys, keys...)
+	}
+}
+
+// ParseEncryptionKey 解析 base64 编码的密钥
+func ParseEncryptionKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+	return key, nil
+}
+
+// EncryptionKeyFromEnv 从 ASTER_STORE_ENCRYPTION_KEY 读取密钥，未设置时返回 nil
+func EncryptionKeyFromEnv() ([]byte, error) {
+	encoded := os.Getenv(EncryptionKeyEnv)
+	if encoded == "" {
+		return nil, nil
+	}
+	return ParseEncryptionKey(encoded)
+}
+
+// fileCipher 文件加解密，nil 表示不加密
+type fileCipher struct {
+	current cipher.AEAD
+	all     []cipher.AEAD // 当前密钥在前，其后为旧密钥
+}
+
+func newFileCipher(key []byte, previous [][]byte) (*fileCipher, error) {
+	if len(key) == 0 {
+		if len(previous) > 0 {
+			return nil, fmt.Errorf("previous encryption keys require a current key")
+		}
+		return nil, nil
+	}
+
+	c := &fileCipher{}
+	for i, k := range append([][]byte{key}, previous...) {
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %d: %w", i, err)
+		}
+		c.all = append(c.all, aead)
+	}
+	c.current = c.all[0]
+	return c, nil
+}
+
+// seal 加密数据并加上文件头，aad 为绑定的附加数据；未配置密钥时原样返回
+func (c *fileCipher) seal(plaintext, aad []byte) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+
+	header := len(encryptedFileMagic) + 1
+	nonceSize := c.current.NonceSize()
+	out := make([]byte, header+nonceSize, header+nonceSize+len(plaintext)+c.current.Overhead())
+	copy(out, encryptedFileMagic)
+	out[len(encryptedFileMagic)] = encryptedFileVersion
+	nonce := out[header:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return c.current.Seal(out, nonce, plaintext, aad), nil
+}
+
+// open 解密带文件头的数据，aad 需与加密时一致；没有文件头的旧文件原样返回
+func (c *fileCipher) open(data, aad []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedFileMagic)) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: file is encrypted but no key is configured", ErrDecryptionFailed)
+	}
+
+	data = data[len(encryptedFileMagic):]
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: unsupported encryption version", ErrDecryptionFailed)
+	}
+	switch data[0] {
+	case encryptedFileVersion:
+	case legacyEncryptedFileVersion:
+		aad = nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported encryption version", ErrDecryptionFailed)
+	}
+	data = data[1:]
+
+	for _, aead := range c.all {
+		if len(data) < aead.NonceSize() {
+			break
+		}
+		plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, ErrDecryptionFailed
+}
+
+// sealLine 加密追加日志中的一行，加密后以 base64 编码保证不含换行
+func (c *fileCipher) sealLine(line, aad []byte) ([]byte, error) {
+	if c == nil {
+		return line, nil
+	}
+	sealed, err := c.seal(line, aad)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// openLine 解密追加日志中的一行，未加密的 JSON 行原样返回
+func (c *fileCipher) openLine(line, aad []byte) ([]byte, error) {
+	if len(line) > 0 && line[0] == '{' {
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return nil, fmt.Errorf("decode message log line: %w", err)
+	}
+	return c.open(sealed, aad)
+}
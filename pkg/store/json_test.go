@@ -0,0 +1,240 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONStore_AppendMessages(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJSONStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 0"}}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "msg 1"}}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 2"}}))
+
+	// 追加只写日志段，不重写 messages.json
+	base, err := s.loadSnapshot("agent-1")
+	require.NoError(t, err)
+	assert.Len(t, base.Messages, 1)
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "msg 1", messages[1].Content)
+	assert.Equal(t, "msg 2", messages[2].Content)
+
+	// 修剪时合并日志段
+	require.NoError(t, s.TrimMessages(ctx, "agent-1", 2))
+	_, err = os.Stat(filepath.Join(dir, "agent-1", "messages.log"))
+	assert.True(t, os.IsNotExist(err))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "msg 1", messages[0].Content)
+
+	// 整体重写会丢弃日志段
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "msg 3"}}))
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "summary"}}))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "summary", messages[0].Content)
+}
+
+func TestJSONStore_RewriteInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJSONStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+	agentDir := filepath.Join(dir, "agent-1")
+
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 0"}}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "msg 1"}}))
+
+	// 新一代日志已创建、快照未替换时中断：之后追加的消息写入新日志，旧日志仍被回放
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, messageLogName(2)), nil, 0644))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 2"}}))
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "msg 2", messages[2].Content)
+
+	// 快照已替换、旧日志未删除时中断：旧日志不再回放，消息不重复
+	require.NoError(t, s.saveJSON(filepath.Join(agentDir, "messages.json"), messageSnapshot{Generation: 2, Messages: messages[:2]}))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "msg 1", messages[1].Content)
+	assert.Equal(t, "msg 2", messages[2].Content)
+
+	// 下次重写清理残留日志
+	require.NoError(t, s.TrimMessages(ctx, "agent-1", 2))
+	_, err = os.Stat(filepath.Join(agentDir, messageLogName(1)))
+	assert.True(t, os.IsNotExist(err))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "msg 1", messages[0].Content)
+}
+
+func TestJSONStore_LegacyMessageFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJSONStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 旧格式：messages.json 为纯数组，追加日志为 messages.log
+	agentDir := filepath.Join(dir, "agent-1")
+	require.NoError(t, os.MkdirAll(agentDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "messages.json"), []byte(`[{"role":"user","content":"msg 0"}]`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentDir, "messages.log"), []byte(`{"role":"assistant","content":"msg 1"}`+"\n"), 0644))
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "msg 1", messages[1].Content)
+}
+
+func TestJSONStore_CompactMessages(t *testing.T) {
+	s, err := NewJSONStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", []types.Message{
+		{Role: types.RoleUser, Content: "msg 0"},
+		{Role: types.RoleAssistant, Content: "msg 1"},
+	}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 2"}}))
+
+	summary := []types.Message{
+		{Role: types.RoleUser, Content: "summary"},
+		{Role: types.RoleAssistant, Content: "ack"},
+	}
+	require.Error(t, s.CompactMessages(ctx, "agent-1", 4, summary))
+	require.NoError(t, s.CompactMessages(ctx, "agent-1", 2, summary))
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "summary", messages[0].Content)
+	assert.Equal(t, "ack", messages[1].Content)
+	assert.Equal(t, "msg 2", messages[2].Content)
+}
+
+func TestJSONStore_Encryption(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{1}, 32)
+
+	// 加密前写入的旧文件
+	legacy, err := NewJSONStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, legacy.SaveInfo(ctx, "agent-1", types.AgentInfo{ID: "agent-1", Model: "legacy"}))
+
+	s, err := NewJSONStore(dir, WithEncryptionKey(key))
+	require.NoError(t, err)
+	info, err := s.LoadInfo(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", info.Model)
+
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "secret 0"}}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "secret 1"}}))
+	require.NoError(t, s.Set(ctx, "tasks", "a", map[string]any{"note": "secret 2"}))
+
+	// 磁盘上不出现明文
+	for _, name := range []string{"agent-1/messages.json", "agent-1/" + messageLogName(1), "_collections/tasks/a.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "secret", name)
+	}
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "secret 1", messages[1].Content)
+	var task map[string]any
+	require.NoError(t, s.Get(ctx, "tasks", "a", &task))
+	assert.Equal(t, "secret 2", task["note"])
+
+	// 密文绑定文件路径和行号，复制到其他文件或调换日志行后无法解密
+	data, err := os.ReadFile(filepath.Join(dir, "_collections/tasks/a.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "_collections/tasks/b.json"), data, 0644))
+	require.ErrorIs(t, s.Get(ctx, "tasks", "b", &task), ErrDecryptionFailed)
+	require.NoError(t, s.Delete(ctx, "tasks", "b"))
+
+	logPath := filepath.Join(dir, "agent-1", messageLogName(1))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "secret 3"}}))
+	logData, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(logData, []byte("\n"))
+	require.NoError(t, os.WriteFile(logPath, slices.Concat(lines[1], lines[0]), 0644))
+	_, err = s.LoadMessages(ctx, "agent-1")
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+	require.NoError(t, os.WriteFile(logPath, lines[0], 0644))
+
+	// 不带附加数据的旧格式仍可读取
+	nonce := bytes.Repeat([]byte{9}, s.cipher.current.NonceSize())
+	legacyTask := append([]byte(encryptedFileMagic), legacyEncryptedFileVersion)
+	legacyTask = append(legacyTask, nonce...)
+	legacyTask = s.cipher.current.Seal(legacyTask, nonce, []byte(`{"note":"legacy"}`), nil)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "_collections/tasks/c.json"), legacyTask, 0644))
+	require.NoError(t, s.Get(ctx, "tasks", "c", &task))
+	assert.Equal(t, "legacy", task["note"])
+	require.NoError(t, s.Delete(ctx, "tasks", "c"))
+
+	// 错误的密钥或未配置密钥时读取失败
+	wrong, err := NewJSONStore(dir, WithEncryptionKey(bytes.Repeat([]byte{2}, 32)))
+	require.NoError(t, err)
+	_, err = wrong.LoadMessages(ctx, "agent-1")
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = legacy.LoadMessages(ctx, "agent-1")
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+
+	// 密钥轮换：旧密钥仍可解密，保存后以新密钥重新加密
+	newKey := bytes.Repeat([]byte{3}, 32)
+	rotated, err := NewJSONStore(dir, WithEncryptionKey(newKey), WithPreviousEncryptionKeys(key))
+	require.NoError(t, err)
+	messages, err = rotated.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.NoError(t, rotated.SaveMessages(ctx, "agent-1", messages))
+
+	onlyNew, err := NewJSONStore(dir, WithEncryptionKey(newKey))
+	require.NoError(t, err)
+	messages, err = onlyNew.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	_, err = NewJSONStore(dir, WithEncryptionKey([]byte("short")))
+	require.Error(t, err)
+}
+
+func TestNewStore_JSONEncryptionKeyFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	t.Setenv(EncryptionKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+
+	s, err := NewStore(Config{Type: StoreTypeJSON, DataDir: dir})
+	require.NoError(t, err)
+	require.NoError(t, s.SaveTodos(ctx, "agent-1", []string{"secret"}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "agent-1", "todos.json"))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte(encryptedFileMagic)))
+
+	t.Setenv(EncryptionKeyEnv, "not base64!")
+	_, err = NewStore(Config{Type: StoreTypeJSON, DataDir: dir})
+	require.Error(t, err)
+}
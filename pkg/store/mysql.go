@@ -138,6 +138,38 @@ func (s *MySQLStore) SaveMessages(ctx context.Context, agentID string, messages
 	return result.Error
 }
 
+// AppendMessages 追加消息
+func (s *MySQLStore) AppendMessages(ctx context.Context, agentID string, messages []types.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txStore := &MySQLStore{db: tx}
+		existing, err := txStore.LoadMessages(ctx, agentID)
+		if err != nil {
+			return err
+		}
+		return txStore.SaveMessages(ctx, agentID, append(existing, messages...))
+	})
+}
+
+// CompactMessages 在事务中用摘要消息替换 [0:keepFromIndex)
+func (s *MySQLStore) CompactMessages(ctx context.Context, agentID string, keepFromIndex int, summary []types.Message) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txStore := &MySQLStore{db: tx}
+		messages, err := txStore.LoadMessages(ctx, agentID)
+		if err != nil {
+			return err
+		}
+		compacted, err := compactMessageList(messages, keepFromIndex, summary)
+		if err != nil {
+			return err
+		}
+		return txStore.SaveMessages(ctx, agentID, compacted)
+	})
+}
+
 // LoadMessages 加载消息列表
 func (s *MySQLStore) LoadMessages(ctx context.Context, agentID string) ([]types.Message, error) {
 	var record AgentMessage
@@ -87,6 +87,72 @@ func (rs *RedisStore) SaveMessages(ctx context.Context, agentID string, messages
 	return nil
 }
 
+// AppendMessages 追加消息（原子操作）
+func (rs *RedisStore) AppendMessages(ctx context.Context, agentID string, messages []types.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	key := rs.prefix + "messages:" + agentID
+
+	return rs.client.Watch(ctx, func(tx *redis.Tx) error {
+		var existing []types.Message
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &existing); err != nil {
+				return err
+			}
+		}
+
+		newData, err := json.Marshal(append(existing, messages...))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newData, rs.ttl)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// CompactMessages 用摘要消息替换 [0:keepFromIndex)（原子操作）
+func (rs *RedisStore) CompactMessages(ctx context.Context, agentID string, keepFromIndex int, summary []types.Message) error {
+	key := rs.prefix + "messages:" + agentID
+
+	return rs.client.Watch(ctx, func(tx *redis.Tx) error {
+		var messages []types.Message
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &messages); err != nil {
+				return err
+			}
+		}
+
+		compacted, err := compactMessageList(messages, keepFromIndex, summary)
+		if err != nil {
+			return err
+		}
+		newData, err := json.Marshal(compacted)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newData, rs.ttl)
+			return nil
+		})
+		return err
+	}, key)
+}
+
 // LoadMessages 加载消息列表
 func (rs *RedisStore) LoadMessages(ctx context.Context, agentID string) ([]types.Message, error) {
 	key := rs.prefix + "messages:" + agentID
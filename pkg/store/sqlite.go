@@ -0,0 +1,551 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore SQLite 存储实现
+// 消息和工具调用记录按行存储并以 agent_id 建立索引，写入使用事务；
+// 数据库以 WAL 模式打开，多个 Agent 并发读写时由 SQLite 自身加锁，不使用全局文件锁。
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// sqliteMigrations 按版本顺序执行的 schema 迁移，只能追加
+var sqliteMigrations = []string{
+	// v1: 初始 schema
+	`
+	CREATE TABLE IF NOT EXISTS messages (
+		agent_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (agent_id, seq)
+	);
+
+	CREATE TABLE IF NOT EXISTS tool_call_records (
+		agent_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (agent_id, seq)
+	);
+
+	CREATE TABLE IF NOT EXISTS snapshots (
+		agent_id TEXT NOT NULL,
+		snapshot_id TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (agent_id, snapshot_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_info (
+		agent_id TEXT PRIMARY KEY,
+		data TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_todos (
+		agent_id TEXT PRIMARY KEY,
+		data TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS collections (
+		collection TEXT NOT NULL,
+		key TEXT NOT NULL,
+		data TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (collection, key)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_snapshots_agent_created ON snapshots(agent_id, created_at);
+	`,
+}
+
+// NewSQLiteStore 创建 SQLite 存储
+// path 为数据库文件路径，文件不存在时自动创建并初始化 schema。
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	// _txlock=immediate: 写事务开始时即获取写锁，避免并发事务升级锁时死锁
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+
+	// 内存数据库每个连接相互独立，只能使用单连接
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+	db.SetConnMaxLifetime(time.Hour)
+
+	s := &SQLiteStore{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate sqlite database: %w", err)
+	}
+
+	return s, nil
+}
+
+// migrate 执行尚未应用的 schema 迁移
+func (s *SQLiteStore) migrate(ctx context.Context) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+			return err
+		}
+
+		var current int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+			return err
+		}
+
+		for i := current; i < len(sqliteMigrations); i++ {
+			if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+				return fmt.Errorf("apply migration %d: %w", i+1, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withTx 在事务中执行 fn，出错时回滚
+func (s *SQLiteStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceRows 在事务中替换 Agent 在 table 中的全部行
+func replaceRows[T any](ctx context.Context, s *SQLiteStore, table, agentID string, items []T) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE agent_id = ?`, agentID); err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO `+table+` (agent_id, seq, data) VALUES (?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for i, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return fmt.Errorf("marshal %s: %w", table, err)
+			}
+			if _, err := stmt.ExecContext(ctx, agentID, i, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// loadRows 按顺序加载 Agent 在 table 中的全部行
+func loadRows[T any](ctx context.Context, s *SQLiteStore, table, agentID string) ([]T, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM `+table+` WHERE agent_id = ? ORDER BY seq`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", table, err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// upsertAgentValue 写入按 agent_id 唯一的数据
+func (s *SQLiteStore) upsertAgentValue(ctx context.Context, table, agentID string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", table, err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO `+table+` (agent_id, data, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(agent_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		agentID, string(data),
+	)
+	return err
+}
+
+// loadAgentValue 读取按 agent_id 唯一的数据，不存在时返回 ErrNotFound
+func (s *SQLiteStore) loadAgentValue(ctx context.Context, table, agentID string, dest any) error {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM `+table+` WHERE agent_id = ?`, agentID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", table, err)
+	}
+	return nil
+}
+
+// SaveMessages 保存消息列表
+func (s *SQLiteStore) SaveMessages(ctx context.Context, agentID string, messages []types.Message) error {
+	return replaceRows(ctx, s, "messages", agentID, messages)
+}
+
+// AppendMessages 追加消息，新行的 seq 接在已有消息之后
+func (s *SQLiteStore) AppendMessages(ctx context.Context, agentID string, messages []types.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var next int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), -1) + 1 FROM messages WHERE agent_id = ?`, agentID).Scan(&next); err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO messages (agent_id, seq, data) VALUES (?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for i, msg := range messages {
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return fmt.Errorf("marshal messages: %w", err)
+			}
+			if _, err := stmt.ExecContext(ctx, agentID, next+i, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadMessages 加载消息列表
+func (s *SQLiteStore) LoadMessages(ctx context.Context, agentID string) ([]types.Message, error) {
+	return loadRows[types.Message](ctx, s, "messages", agentID)
+}
+
+// TrimMessages 修剪消息列表，保留最近的 N 条消息
+// 如果 maxMessages <= 0，则不修剪
+func (s *SQLiteStore) TrimMessages(ctx context.Context, agentID string, maxMessages int) error {
+	if maxMessages <= 0 {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM messages WHERE agent_id = ? AND seq NOT IN (
+			SELECT seq FROM messages WHERE agent_id = ? ORDER BY seq DESC LIMIT ?
+		)`,
+		agentID, agentID, maxMessages,
+	)
+	return err
+}
+
+// CompactMessages 在事务中删除 [0:keepFromIndex) 的行并插入摘要消息
+func (s *SQLiteStore) CompactMessages(ctx context.Context, agentID string, keepFromIndex int, summary []types.Message) error {
+	rows := make([]string, len(summary))
+	for i, msg := range summary {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("marshal messages: %w", err)
+		}
+		rows[i] = string(data)
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE agent_id = ?`, agentID).Scan(&count); err != nil {
+			return err
+		}
+		if keepFromIndex < 0 || keepFromIndex > count {
+			return fmt.Errorf("compact index %d out of range [0, %d]", keepFromIndex, count)
+		}
+
+		// 摘要占用第一条保留消息之前的序号，前面的行都会被删除，序号可以为负
+		var keepSeq int
+		err := tx.QueryRowContext(ctx,
+			`SELECT seq FROM messages WHERE agent_id = ? ORDER BY seq LIMIT 1 OFFSET ?`,
+			agentID, keepFromIndex,
+		).Scan(&keepSeq)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), -1) + 1 FROM messages WHERE agent_id = ?`, agentID).Scan(&keepSeq)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE agent_id = ? AND seq < ?`, agentID, keepSeq); err != nil {
+			return err
+		}
+		for i, data := range rows {
+			seq := keepSeq - len(rows) + i
+			if _, err := tx.ExecContext(ctx, `INSERT INTO messages (agent_id, seq, data) VALUES (?, ?, ?)`, agentID, seq, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveToolCallRecords 保存工具调用记录
+func (s *SQLiteStore) SaveToolCallRecords(ctx context.Context, agentID string, records []types.ToolCallRecord) error {
+	return replaceRows(ctx, s, "tool_call_records", agentID, records)
+}
+
+// LoadToolCallRecords 加载工具调用记录
+func (s *SQLiteStore) LoadToolCallRecords(ctx context.Context, agentID string) ([]types.ToolCallRecord, error) {
+	return loadRows[types.ToolCallRecord](ctx, s, "tool_call_records", agentID)
+}
+
+// SaveSnapshot 保存快照
+func (s *SQLiteStore) SaveSnapshot(ctx context.Context, agentID string, snapshot types.Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO snapshots (agent_id, snapshot_id, data) VALUES (?, ?, ?)
+		 ON CONFLICT(agent_id, snapshot_id) DO UPDATE SET data = excluded.data`,
+		agentID, snapshot.ID, string(data),
+	)
+	return err
+}
+
+// LoadSnapshot 加载快照
+func (s *SQLiteStore) LoadSnapshot(ctx context.Context, agentID string, snapshotID string) (*types.Snapshot, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data FROM snapshots WHERE agent_id = ? AND snapshot_id = ?`, agentID, snapshotID,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot types.Snapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshal snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots 列出快照
+func (s *SQLiteStore) ListSnapshots(ctx context.Context, agentID string) ([]types.Snapshot, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT data FROM snapshots WHERE agent_id = ? ORDER BY created_at, snapshot_id`, agentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	snapshots := []types.Snapshot{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var snapshot types.Snapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			continue // 忽略损坏的记录
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// SaveInfo 保存Agent元信息
+func (s *SQLiteStore) SaveInfo(ctx context.Context, agentID string, info types.AgentInfo) error {
+	return s.upsertAgentValue(ctx, "agent_info", agentID, info)
+}
+
+// LoadInfo 加载Agent元信息
+func (s *SQLiteStore) LoadInfo(ctx context.Context, agentID string) (*types.AgentInfo, error) {
+	var info types.AgentInfo
+	if err := s.loadAgentValue(ctx, "agent_info", agentID, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SaveTodos 保存Todo列表
+func (s *SQLiteStore) SaveTodos(ctx context.Context, agentID string, todos any) error {
+	return s.upsertAgentValue(ctx, "agent_todos", agentID, todos)
+}
+
+// LoadTodos 加载Todo列表
+func (s *SQLiteStore) LoadTodos(ctx context.Context, agentID string) (any, error) {
+	var todos any
+	if err := s.loadAgentValue(ctx, "agent_todos", agentID, &todos); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return todos, nil
+}
+
+// sqliteAgentTables 保存 Agent 数据的表
+var sqliteAgentTables = []string{"messages", "tool_call_records", "snapshots", "agent_info", "agent_todos"}
+
+// DeleteAgent 删除Agent所有数据
+func (s *SQLiteStore) DeleteAgent(ctx context.Context, agentID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range sqliteAgentTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE agent_id = ?`, agentID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListAgents 列出所有Agent
+func (s *SQLiteStore) ListAgents(ctx context.Context) ([]string, error) {
+	selects := make([]string, len(sqliteAgentTables))
+	for i, table := range sqliteAgentTables {
+		selects[i] = `SELECT agent_id FROM ` + table
+	}
+
+	rows, err := s.db.QueryContext(ctx, strings.Join(selects, " UNION ")+` ORDER BY agent_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	agents := []string{}
+	for rows.Next() {
+		var agentID string
+		if err := rows.Scan(&agentID); err != nil {
+			return nil, err
+		}
+		agents = append(agents, agentID)
+	}
+	return agents, rows.Err()
+}
+
+// --- 通用 CRUD 方法 ---
+
+// Get 获取单个资源
+func (s *SQLiteStore) Get(ctx context.Context, collection, key string, dest any) error {
+	var data string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data FROM collections WHERE collection = ? AND key = ?`, collection, key,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	return nil
+}
+
+// Set 设置资源
+func (s *SQLiteStore) Set(ctx context.Context, collection, key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO collections (collection, key, data, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(collection, key) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		collection, key, string(data),
+	)
+	return err
+}
+
+// Delete 删除资源
+func (s *SQLiteStore) Delete(ctx context.Context, collection, key string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM collections WHERE collection = ? AND key = ?`, collection, key)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List 列出资源
+func (s *SQLiteStore) List(ctx context.Context, collection string) ([]any, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM collections WHERE collection = ? ORDER BY key`, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []any{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var item any
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			continue // 忽略损坏的记录
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Exists 检查资源是否存在
+func (s *SQLiteStore) Exists(ctx context.Context, collection, key string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM collections WHERE collection = ? AND key = ?)`, collection, key,
+	).Scan(&exists)
+	return exists, err
+}
+
+// Close 关闭数据库连接
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteStore(t *testing.T) (*SQLiteStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "aster.db")
+	s, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s, path
+}
+
+func TestSQLiteStore_Messages(t *testing.T) {
+	s, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	var saved []types.Message
+	for i := range 5 {
+		saved = append(saved, types.Message{Role: types.RoleUser, Content: fmt.Sprintf("msg %d", i)})
+	}
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", saved))
+	require.NoError(t, s.SaveMessages(ctx, "agent-2", saved[:1]))
+
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 5)
+	assert.Equal(t, "msg 0", messages[0].Content)
+
+	// 再次保存会整体替换
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", saved[:3]))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Len(t, messages, 3)
+
+	require.NoError(t, s.TrimMessages(ctx, "agent-1", 2))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "msg 1", messages[0].Content)
+	assert.Equal(t, "msg 2", messages[1].Content)
+
+	messages, err = s.LoadMessages(ctx, "agent-2")
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestSQLiteStore_AppendMessages(t *testing.T) {
+	s, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleUser, Content: "msg 0"}}))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", nil))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{
+		{Role: types.RoleAssistant, Content: "msg 1"},
+		{Role: types.RoleUser, Content: "msg 2"},
+	}))
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "msg 2", messages[2].Content)
+
+	// 修剪后继续追加，顺序保持不变
+	require.NoError(t, s.TrimMessages(ctx, "agent-1", 1))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "msg 3"}}))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "msg 2", messages[0].Content)
+	assert.Equal(t, "msg 3", messages[1].Content)
+}
+
+func TestSQLiteStore_CompactMessages(t *testing.T) {
+	s, path := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	var saved []types.Message
+	for i := range 5 {
+		saved = append(saved, types.Message{Role: types.RoleUser, Content: fmt.Sprintf("msg %d", i)})
+	}
+	require.NoError(t, s.SaveMessages(ctx, "agent-1", saved))
+	require.NoError(t, s.TrimMessages(ctx, "agent-1", 4))
+
+	summary := []types.Message{
+		{Role: types.RoleUser, Content: "summary"},
+		{Role: types.RoleAssistant, Content: "ack"},
+	}
+	require.NoError(t, s.CompactMessages(ctx, "agent-1", 2, summary))
+	require.Error(t, s.CompactMessages(ctx, "agent-1", 9, summary))
+	require.NoError(t, s.AppendMessages(ctx, "agent-1", []types.Message{{Role: types.RoleAssistant, Content: "msg 5"}}))
+
+	// 重新打开后仍是压缩后的历史
+	require.NoError(t, s.Close())
+	s, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	messages, err := s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 5)
+	assert.Equal(t, "summary", messages[0].Content)
+	assert.Equal(t, "ack", messages[1].Content)
+	assert.Equal(t, "msg 3", messages[2].Content)
+	assert.Equal(t, "msg 5", messages[4].Content)
+
+	// 再次压缩，摘要的序号早于第一条保留消息
+	require.NoError(t, s.CompactMessages(ctx, "agent-1", 3, summary))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "summary", messages[0].Content)
+	assert.Equal(t, "msg 4", messages[2].Content)
+
+	// 压缩全部消息
+	require.NoError(t, s.CompactMessages(ctx, "agent-1", 4, summary))
+	messages, err = s.LoadMessages(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "summary", messages[0].Content)
+	assert.Equal(t, "ack", messages[1].Content)
+}
+
+func TestSQLiteStore_AgentData(t *testing.T) {
+	s, path := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	_, err := s.LoadInfo(ctx, "agent-1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.SaveInfo(ctx, "agent-1", types.AgentInfo{ID: "agent-1", Model: "m1"}))
+	require.NoError(t, s.SaveInfo(ctx, "agent-1", types.AgentInfo{ID: "agent-1", Model: "m2"}))
+	require.NoError(t, s.SaveToolCallRecords(ctx, "agent-1", []types.ToolCallRecord{{ID: "tc-1", Name: "Read"}}))
+	require.NoError(t, s.SaveSnapshot(ctx, "agent-1", types.Snapshot{ID: "snap-1", AgentID: "agent-1"}))
+	require.NoError(t, s.SaveTodos(ctx, "agent-2", []string{"write tests"}))
+
+	// 重新打开后数据仍在，迁移不会重复执行
+	require.NoError(t, s.Close())
+	s, err = NewSQLiteStore(path)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	info, err := s.LoadInfo(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "m2", info.Model)
+
+	records, err := s.LoadToolCallRecords(ctx, "agent-1")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Read", records[0].Name)
+
+	snapshot, err := s.LoadSnapshot(ctx, "agent-1", "snap-1")
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", snapshot.AgentID)
+	snapshots, err := s.ListSnapshots(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+
+	todos, err := s.LoadTodos(ctx, "agent-2")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"write tests"}, todos)
+
+	agents, err := s.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent-1", "agent-2"}, agents)
+
+	require.NoError(t, s.DeleteAgent(ctx, "agent-1"))
+	agents, err = s.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent-2"}, agents)
+	_, err = s.LoadSnapshot(ctx, "agent-1", "snap-1")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLiteStore_Collections(t *testing.T) {
+	s, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	var dest map[string]any
+	require.ErrorIs(t, s.Get(ctx, "tasks", "a", &dest), ErrNotFound)
+
+	require.NoError(t, s.Set(ctx, "tasks", "a", map[string]any{"status": "running"}))
+	require.NoError(t, s.Set(ctx, "tasks", "a", map[string]any{"status": "completed"}))
+	require.NoError(t, s.Set(ctx, "tasks", "b", map[string]any{"status": "failed"}))
+
+	require.NoError(t, s.Get(ctx, "tasks", "a", &dest))
+	assert.Equal(t, "completed", dest["status"])
+
+	items, err := s.List(ctx, "tasks")
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	exists, err := s.Exists(ctx, "tasks", "b")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, s.Delete(ctx, "tasks", "b"))
+	require.ErrorIs(t, s.Delete(ctx, "tasks", "b"), ErrNotFound)
+	exists, err = s.Exists(ctx, "tasks", "b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSQLiteStore_ConcurrentAgents(t *testing.T) {
+	s, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agentID := fmt.Sprintf("agent-%02d", i)
+			var messages []types.Message
+			for j := range 10 {
+				messages = append(messages, types.Message{Role: types.RoleUser, Content: fmt.Sprintf("%s-%d", agentID, j)})
+				if err := s.SaveMessages(ctx, agentID, messages); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	agents, err := s.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Len(t, agents, 20)
+	messages, err := s.LoadMessages(ctx, "agent-07")
+	require.NoError(t, err)
+	assert.Len(t, messages, 10)
+}
+
+func TestNewStore_SQLite(t *testing.T) {
+	_, err := NewStore(Config{Type: StoreTypeSQLite})
+	require.Error(t, err)
+
+	s, err := NewStore(Config{Type: StoreTypeSQLite, SQLitePath: filepath.Join(t.TempDir(), "aster.db")})
+	require.NoError(t, err)
+	_ = s.(*SQLiteStore).Close()
+}
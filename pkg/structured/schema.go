@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -291,6 +292,32 @@ func (s *JSONSchema) ToMap() (map[string]any, error) {
 	return result, nil
 }
 
+// Clone 深拷贝 Schema
+func (s *JSONSchema) Clone() *JSONSchema {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	if s.Properties != nil {
+		clone.Properties = make(map[string]*JSONSchema, len(s.Properties))
+		for name, prop := range s.Properties {
+			clone.Properties[name] = prop.Clone()
+		}
+	}
+	clone.Items = s.Items.Clone()
+	clone.Required = slices.Clone(s.Required)
+	clone.Enum = slices.Clone(s.Enum)
+	if s.Minimum != nil {
+		minimum := *s.Minimum
+		clone.Minimum = &minimum
+	}
+	if s.Maximum != nil {
+		maximum := *s.Maximum
+		clone.Maximum = &maximum
+	}
+	return &clone
+}
+
 // SchemaValidator Schema 验证器
 type SchemaValidator struct {
 	schema *JSONSchema
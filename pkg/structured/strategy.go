@@ -0,0 +1,161 @@
+package structured
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// Strategy 结构化输出实现机制
+type Strategy string
+
+const (
+	StrategyNone     Strategy = ""          // 未配置（仅解析文本）
+	StrategyJSONMode Strategy = "json_mode" // 原生 JSON 模式（response_format）
+	StrategyToolMode Strategy = "tool_mode" // 强制调用输出工具，工具参数即结构化结果
+	StrategyPrompt   Strategy = "prompt"    // 仅通过提示词约束输出格式
+)
+
+// OutputToolName 工具模式下强制调用的输出工具名
+const OutputToolName = "structured_output"
+
+// StrategySelector 根据 Provider 能力选择结构化输出机制。
+// 默认优先级: JSON 模式 > 工具强制 > 提示词。
+type StrategySelector struct {
+	// Preferred 指定优先使用的机制，Provider 不支持时回退到默认优先级
+	Preferred Strategy
+}
+
+// NewStrategySelector 创建默认的策略选择器
+func NewStrategySelector() *StrategySelector {
+	return &StrategySelector{}
+}
+
+// WithPreferred 设置优先使用的机制
+func (s *StrategySelector) WithPreferred(strategy Strategy) *StrategySelector {
+	s.Preferred = strategy
+	return s
+}
+
+// Select 选择 Provider 能支持的最佳机制
+func (s *StrategySelector) Select(caps provider.ProviderCapabilities) Strategy {
+	if s.Preferred != StrategyNone && supports(caps, s.Preferred) {
+		return s.Preferred
+	}
+	for _, strategy := range []Strategy{StrategyJSONMode, StrategyToolMode} {
+		if supports(caps, strategy) {
+			return strategy
+		}
+	}
+	return StrategyPrompt
+}
+
+// Configure 选择机制并据此修改请求选项，返回选中的机制
+func (s *StrategySelector) Configure(caps provider.ProviderCapabilities, spec OutputSpec, opts *provider.StreamOptions) Strategy {
+	strategy := s.Select(caps)
+	Apply(strategy, caps, spec, opts)
+	return strategy
+}
+
+// Apply 按机制修改请求选项:
+//   - json_mode: 设置 ResponseFormat（有 Schema 且支持时使用 json_schema）
+//   - tool_mode: 追加输出工具并强制调用
+//   - prompt: 在 System 中追加 JSON 输出说明
+func Apply(strategy Strategy, caps provider.ProviderCapabilities, spec OutputSpec, opts *provider.StreamOptions) {
+	if opts == nil {
+		return
+	}
+
+	switch strategy {
+	case StrategyJSONMode:
+		if len(spec.Schema) > 0 && caps.SupportStructuredOutput {
+			opts.ResponseFormat = &provider.ResponseFormat{
+				Type:   provider.ResponseFormatJSONSchema,
+				Name:   OutputToolName,
+				Schema: spec.Schema,
+			}
+		} else {
+			opts.ResponseFormat = &provider.ResponseFormat{Type: provider.ResponseFormatJSON}
+		}
+
+	case StrategyToolMode:
+		opts.Tools = append(opts.Tools, provider.ToolSchema{
+			Name:        OutputToolName,
+			Description: "Return the final answer as structured data. The tool input is the result.",
+			InputSchema: outputToolSchema(spec),
+		})
+		opts.ToolChoice = &provider.ToolChoiceOption{Type: "tool", Name: OutputToolName}
+
+	case StrategyPrompt:
+		instruction := promptInstruction(spec)
+		if opts.System == "" {
+			opts.System = instruction
+		} else {
+			opts.System += "\n\n" + instruction
+		}
+	}
+}
+
+// ExtractToolOutput 从工具模式的响应中提取输出工具参数（JSON 文本）
+// 找到时返回 JSON 文本并从消息中移除该工具调用，避免被当作真实工具执行。
+func ExtractToolOutput(msg *types.Message) (string, bool) {
+	for i, block := range msg.ContentBlocks {
+		toolUse, ok := block.(*types.ToolUseBlock)
+		if !ok || toolUse.Name != OutputToolName {
+			continue
+		}
+		data, err := json.Marshal(toolUse.Input)
+		if err != nil {
+			return "", false
+		}
+		msg.ContentBlocks = append(msg.ContentBlocks[:i:i], msg.ContentBlocks[i+1:]...)
+		msg.ContentBlocks = append(msg.ContentBlocks, &types.TextBlock{Text: string(data)})
+		return string(data), true
+	}
+	return "", false
+}
+
+// supports 判断 Provider 是否支持指定机制
+func supports(caps provider.ProviderCapabilities, strategy Strategy) bool {
+	switch strategy {
+	case StrategyJSONMode:
+		return caps.SupportJSONMode || caps.SupportStructuredOutput
+	case StrategyToolMode:
+		return caps.SupportToolCalling
+	case StrategyPrompt:
+		return true
+	}
+	return false
+}
+
+// outputToolSchema 构建输出工具的参数 Schema
+func outputToolSchema(spec OutputSpec) map[string]any {
+	if len(spec.Schema) > 0 {
+		return spec.Schema
+	}
+	schema := map[string]any{"type": "object"}
+	if len(spec.RequiredFields) > 0 {
+		schema["required"] = spec.RequiredFields
+	}
+	return schema
+}
+
+// promptInstruction 构建提示词模式的输出说明
+func promptInstruction(spec OutputSpec) string {
+	var sb strings.Builder
+	sb.WriteString("Respond with a single valid JSON object only, without any surrounding text or code fences.")
+	if len(spec.RequiredFields) > 0 {
+		sb.WriteString(" Required top-level fields: ")
+		sb.WriteString(strings.Join(spec.RequiredFields, ", "))
+		sb.WriteString(".")
+	}
+	if len(spec.Schema) > 0 {
+		if data, err := json.Marshal(spec.Schema); err == nil {
+			sb.WriteString(" The JSON must conform to this schema: ")
+			sb.Write(data)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/types"
+)
+
+func TestStrategySelector_Select(t *testing.T) {
+	openai := provider.ProviderCapabilities{SupportToolCalling: true, SupportJSONMode: true, SupportStructuredOutput: true}
+	anthropic := provider.ProviderCapabilities{SupportToolCalling: true}
+	bare := provider.ProviderCapabilities{}
+
+	selector := NewStrategySelector()
+	tests := []struct {
+		name string
+		caps provider.ProviderCapabilities
+		want Strategy
+	}{
+		{"openai", openai, StrategyJSONMode},
+		{"anthropic", anthropic, StrategyToolMode},
+		{"bare", bare, StrategyPrompt},
+	}
+	for _, tt := range tests {
+		if got := selector.Select(tt.caps); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	// 偏好不被支持时回退到默认优先级
+	preferred := NewStrategySelector().WithPreferred(StrategyToolMode)
+	if got := preferred.Select(openai); got != StrategyToolMode {
+		t.Errorf("expected preferred tool_mode, got %s", got)
+	}
+	if got := preferred.Select(bare); got != StrategyPrompt {
+		t.Errorf("expected fallback to prompt, got %s", got)
+	}
+}
+
+func TestStrategySelector_Configure(t *testing.T) {
+	spec := OutputSpec{
+		Enabled:        true,
+		RequiredFields: []string{"answer"},
+		Schema:         map[string]any{"type": "object", "required": []string{"answer"}},
+	}
+	selector := NewStrategySelector()
+
+	opts := &provider.StreamOptions{}
+	strategy := selector.Configure(provider.ProviderCapabilities{SupportJSONMode: true, SupportStructuredOutput: true}, spec, opts)
+	if strategy != StrategyJSONMode || opts.ResponseFormat == nil || opts.ResponseFormat.Type != provider.ResponseFormatJSONSchema {
+		t.Errorf("expected json_schema response format, got %s %+v", strategy, opts.ResponseFormat)
+	}
+
+	opts = &provider.StreamOptions{}
+	selector.Configure(provider.ProviderCapabilities{SupportJSONMode: true}, spec, opts)
+	if opts.ResponseFormat == nil || opts.ResponseFormat.Type != provider.ResponseFormatJSON {
+		t.Errorf("expected json_object response format, got %+v", opts.ResponseFormat)
+	}
+
+	opts = &provider.StreamOptions{Tools: []provider.ToolSchema{{Name: "search"}}}
+	selector.Configure(provider.ProviderCapabilities{SupportToolCalling: true}, spec, opts)
+	if len(opts.Tools) != 2 || opts.Tools[1].Name != OutputToolName {
+		t.Errorf("expected output tool appended, got %+v", opts.Tools)
+	}
+	if opts.ToolChoice == nil || opts.ToolChoice.Type != "tool" || opts.ToolChoice.Name != OutputToolName {
+		t.Errorf("expected forced output tool, got %+v", opts.ToolChoice)
+	}
+
+	opts = &provider.StreamOptions{System: "You are helpful."}
+	selector.Configure(provider.ProviderCapabilities{}, spec, opts)
+	if !strings.HasPrefix(opts.System, "You are helpful.") || !strings.Contains(opts.System, "answer") {
+		t.Errorf("expected prompt instruction appended, got %q", opts.System)
+	}
+	if opts.ResponseFormat != nil || opts.ToolChoice != nil {
+		t.Error("prompt mode should not set response format or tool choice")
+	}
+}
+
+func TestExtractToolOutput(t *testing.T) {
+	msg := types.Message{
+		ContentBlocks: []types.ContentBlock{
+			&types.ToolUseBlock{ID: "call_1", Name: OutputToolName, Input: map[string]any{"answer": 42}},
+		},
+	}
+
+	raw, ok := ExtractToolOutput(&msg)
+	if !ok || raw != `{"answer":42}` {
+		t.Fatalf("unexpected tool output: %q %v", raw, ok)
+	}
+	if len(msg.ContentBlocks) != 1 {
+		t.Fatalf("expected tool call replaced by text, got %d blocks", len(msg.ContentBlocks))
+	}
+	if _, isTool := msg.ContentBlocks[0].(*types.ToolUseBlock); isTool {
+		t.Error("output tool call should be removed from message")
+	}
+}
@@ -23,6 +23,7 @@ type ParseResult struct {
 	RawJSON       string   // 提取出的 JSON 文本
 	Data          any      // JSON 解析结果
 	MissingFields []string // 缺失的必填字段
+	Strategy      Strategy // 生成该输出所用的机制（便于调试）
 }
 
 // Parser 结构化输出解析器接口。
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 )
 
 // TypedParser 类型化解析器，支持直接绑定到 Go struct
@@ -170,13 +171,39 @@ func ParseTyped(ctx context.Context, text string, spec TypedOutputSpec) (*TypedP
 	return result, nil
 }
 
+// schemaCache 按类型缓存生成的 Schema（reflect.Type -> *JSONSchema）
+var schemaCache sync.Map
+
 // GenerateSchema 从 Go struct 生成 JSON Schema
+// 反射结果按类型缓存，每次返回缓存的独立副本，调用方可以直接修改。
 func GenerateSchema(structType any) (*JSONSchema, error) {
 	t := reflect.TypeOf(structType)
+	if t == nil {
+		return nil, errors.New("struct type is required")
+	}
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*JSONSchema).Clone(), nil
+	}
+
+	schema, err := generateSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := schemaCache.LoadOrStore(t, schema.Clone())
+	return actual.(*JSONSchema).Clone(), nil
+}
+
+// ClearSchemaCache 清空 Schema 缓存
+func ClearSchemaCache() {
+	schemaCache.Clear()
+}
+
+// generateSchema 通过反射生成 Schema（不使用缓存）
+func generateSchema(t reflect.Type) (*JSONSchema, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("expected struct type, got %s", t.Kind())
 	}
@@ -2,6 +2,8 @@ package structured
 
 import (
 	"context"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -261,3 +263,86 @@ type ValidationError struct {
 func (e *ValidationError) Error() string {
 	return e.Field + ": " + e.Message
 }
+
+func TestGenerateSchema_CachedMatchesUncached(t *testing.T) {
+	ClearSchemaCache()
+
+	uncached, err := generateSchema(reflect.TypeOf(TestTaskList{}))
+	if err != nil {
+		t.Fatalf("generateSchema failed: %v", err)
+	}
+
+	first, err := GenerateSchema(TestTaskList{})
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+	second, err := GenerateSchema(&TestTaskList{})
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("expected value and pointer types to share the cached schema")
+	}
+	if !reflect.DeepEqual(uncached, first) {
+		t.Errorf("cached schema differs from uncached:\n%+v\n%+v", uncached, first)
+	}
+
+	// 每次返回独立副本，修改不影响缓存和其他调用方
+	if first == second || first.Properties["total"] == second.Properties["total"] {
+		t.Error("expected each call to return an independent copy")
+	}
+	first.Properties["total"].Type = "string"
+	first.Required = append(first.Required, "extra")
+	if again := MustGenerateSchema(TestTaskList{}); !reflect.DeepEqual(again, uncached) {
+		t.Error("modifying a returned schema must not change the cache")
+	}
+	if second.Properties["total"].Type != "integer" {
+		t.Error("modifying a returned schema must not change other copies")
+	}
+
+	ClearSchemaCache()
+	third := MustGenerateSchema(TestTaskList{})
+	if !reflect.DeepEqual(third, uncached) {
+		t.Error("regenerated schema should equal the previous one")
+	}
+}
+
+func TestGenerateSchema_Concurrent(t *testing.T) {
+	ClearSchemaCache()
+
+	var wg sync.WaitGroup
+	results := make([]*JSONSchema, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = MustGenerateSchema(TestTask{})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, schema := range results[1:] {
+		if !reflect.DeepEqual(schema, results[0]) || schema == results[0] {
+			t.Fatal("concurrent calls should return equal, independent schemas")
+		}
+	}
+}
+
+func BenchmarkGenerateSchema_Uncached(b *testing.B) {
+	typ := reflect.TypeOf(TestTask{})
+	for b.Loop() {
+		if _, err := generateSchema(typ); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateSchema_Cached(b *testing.B) {
+	ClearSchemaCache()
+	for b.Loop() {
+		if _, err := GenerateSchema(TestTask{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,17 +2,30 @@ package bridge
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/astercloud/aster/pkg/tools"
+	"github.com/google/uuid"
 )
 
+// ExecutionIDHeader PTC 执行 ID 请求头，服务端据此查找该次执行允许调用的工具
+const ExecutionIDHeader = "X-Aster-Execution-ID"
+
+// unixAddrPrefix 监听地址以此开头时使用 unix socket，如 "unix:/tmp/aster-bridge.sock"
+const unixAddrPrefix = "unix:"
+
 // 性能优化: Schema 缓存
 type schemaCache struct {
 	schemas map[string]any
@@ -64,18 +77,30 @@ func (c *schemaCache) set(key string, value any) {
 // HTTPBridgeServer HTTP 桥接服务器
 // 提供 HTTP API 供 Python/Node.js 代码调用 Go 侧的工具
 type HTTPBridgeServer struct {
-	bridge *ToolBridge
-	server *http.Server
-	mu     sync.RWMutex
+	bridge   *ToolBridge
+	server   *http.Server
+	listener net.Listener
+	mu       sync.RWMutex
+
+	// unix socket 文件路径，Shutdown 时删除
+	socketPath string
+
+	// 共享密钥，非空时除 /health 外的请求都需要携带 "Authorization: Bearer <token>"
+	authToken string
 
 	// 工具上下文工厂
 	contextFactory func() *tools.ToolContext
 
 	// 性能优化: Schema 缓存
 	schemaCache *schemaCache
+
+	// 工具授权：未携带执行 ID 的请求使用 allowedTools，否则使用对应执行登记的工具
+	allowedTools map[string]bool
+	executions   map[string]map[string]bool
 }
 
 // NewHTTPBridgeServer 创建 HTTP 桥接服务器
+// addr 为 TCP 地址（如 "localhost:8080"）或 "unix:" 开头的 unix socket 路径。
 func NewHTTPBridgeServer(bridge *ToolBridge, addr string) *HTTPBridgeServer {
 	s := &HTTPBridgeServer{
 		bridge: bridge,
@@ -91,6 +116,7 @@ func NewHTTPBridgeServer(bridge *ToolBridge, addr string) *HTTPBridgeServer {
 		},
 		// 初始化 Schema 缓存(5分钟TTL)
 		schemaCache: newSchemaCache(5 * time.Minute),
+		executions:  make(map[string]map[string]bool),
 	}
 
 	// 设置路由
@@ -100,10 +126,46 @@ func NewHTTPBridgeServer(bridge *ToolBridge, addr string) *HTTPBridgeServer {
 	mux.HandleFunc("/tools/schema", s.handleToolSchema)
 	mux.HandleFunc("/health", s.handleHealth)
 
-	s.server.Handler = mux
+	s.server.Handler = s.authenticate(mux)
 	return s
 }
 
+// NewAuthToken 生成随机的桥接服务器访问令牌
+func NewAuthToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate auth token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SetAuthToken 设置访问令牌，为空时不校验
+// 运行时通过 PythonRuntime.SetAuthToken 携带相同的令牌。
+func (s *HTTPBridgeServer) SetAuthToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authToken = token
+}
+
+// authenticate 校验 Bearer 令牌，失败返回 401
+func (s *HTTPBridgeServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		token := s.authToken
+		s.mu.RUnlock()
+
+		if token != "" && r.URL.Path != "/health" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				s.sendError(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // SetContextFactory 设置工具上下文工厂
 func (s *HTTPBridgeServer) SetContextFactory(factory func() *tools.ToolContext) {
 	s.mu.Lock()
@@ -111,6 +173,69 @@ func (s *HTTPBridgeServer) SetContextFactory(factory func() *tools.ToolContext)
 	s.contextFactory = factory
 }
 
+// SetAllowedTools 设置未携带执行 ID 的请求允许调用的工具
+// 默认不允许调用任何工具，只有显式授权给代码执行的工具才能从 Python 中调用。
+func (s *HTTPBridgeServer) SetAllowedTools(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowedTools = toolSet(names)
+}
+
+// BeginExecution 为一次代码执行登记允许调用的工具
+// 返回执行 ID（通过 WithExecution 传给 PythonRuntime）和结束登记的函数，
+// 结束后携带该 ID 的请求会被拒绝。
+func (s *HTTPBridgeServer) BeginExecution(names []string) (string, func()) {
+	id := uuid.NewString()
+
+	s.mu.Lock()
+	s.executions[id] = toolSet(names)
+	s.mu.Unlock()
+
+	return id, func() {
+		s.mu.Lock()
+		delete(s.executions, id)
+		s.mu.Unlock()
+	}
+}
+
+// allowedToolsFor 返回请求可以调用的工具集合
+func (s *HTTPBridgeServer) allowedToolsFor(r *http.Request) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id := r.Header.Get(ExecutionIDHeader)
+	if id == "" {
+		return s.allowedTools, nil
+	}
+	allowed, ok := s.executions[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown or finished execution %q", id)
+	}
+	return allowed, nil
+}
+
+// authorizeTool 检查请求是否允许调用工具，不允许时返回 403
+func (s *HTTPBridgeServer) authorizeTool(w http.ResponseWriter, r *http.Request, name string) bool {
+	allowed, err := s.allowedToolsFor(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusForbidden)
+		return false
+	}
+	if !allowed[name] {
+		s.sendError(w, fmt.Sprintf("tool %s is not allowed for code execution", name), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func toolSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
 // ToolCallRequest 工具调用请求
 type ToolCallRequest struct {
 	Tool  string         `json:"tool"`
@@ -145,6 +270,11 @@ func (s *HTTPBridgeServer) handleToolCall(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// 服务端校验工具授权，不依赖客户端注入的工具列表
+	if !s.authorizeTool(w, r, req.Tool) {
+		return
+	}
+
 	// 获取工具上下文
 	tc := s.getToolContext()
 
@@ -167,7 +297,19 @@ func (s *HTTPBridgeServer) handleToolList(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	tools := s.bridge.ListAvailableTools()
+	allowed, err := s.allowedToolsFor(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// 只列出允许调用的工具
+	tools := make([]string, 0, len(allowed))
+	for _, name := range s.bridge.ListAvailableTools() {
+		if allowed[name] {
+			tools = append(tools, name)
+		}
+	}
 	s.sendJSON(w, map[string]any{
 		"tools": tools,
 	})
@@ -186,6 +328,10 @@ func (s *HTTPBridgeServer) handleToolSchema(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !s.authorizeTool(w, r, toolName) {
+		return
+	}
+
 	// 尝试从缓存获取
 	if cachedSchema, ok := s.schemaCache.get(toolName); ok {
 		s.sendJSON(w, cachedSchema)
@@ -252,24 +398,106 @@ func (s *HTTPBridgeServer) sendError(w http.ResponseWriter, message string, stat
 
 // Start 启动服务器
 func (s *HTTPBridgeServer) Start() error {
-	fmt.Printf("HTTP Bridge Server listening on %s\n", s.server.Addr)
-	return s.server.ListenAndServe()
+	if err := s.listen(); err != nil {
+		return err
+	}
+	return s.serve()
 }
 
-// StartAsync 异步启动服务器
+// StartAsync 异步启动服务器，监听失败时直接返回错误
 func (s *HTTPBridgeServer) StartAsync() error {
+	if err := s.listen(); err != nil {
+		return err
+	}
+
 	go func() {
-		if err := s.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			fmt.Printf("HTTP Bridge Server error: %v\n", err)
 		}
 	}()
+	return nil
+}
+
+// URL 返回运行时访问服务器使用的地址，unix socket 时为 "unix:<path>"
+func (s *HTTPBridgeServer) URL() string {
+	if strings.HasPrefix(s.server.Addr, unixAddrPrefix) {
+		return s.server.Addr
+	}
+	return "http://" + s.server.Addr
+}
+
+// listen 按地址类型监听 TCP 端口或 unix socket
+func (s *HTTPBridgeServer) listen() error {
+	var (
+		listener net.Listener
+		err      error
+	)
+	if path, ok := strings.CutPrefix(s.server.Addr, unixAddrPrefix); ok {
+		// 清理上次异常退出遗留的 socket 文件
+		_ = os.Remove(path)
+		if listener, err = listenUnix(path); err != nil {
+			return fmt.Errorf("listen on unix socket %s: %w", path, err)
+		}
+		s.mu.Lock()
+		s.socketPath = path
+		s.mu.Unlock()
+	} else if listener, err = net.Listen("tcp", s.server.Addr); err != nil {
+		return fmt.Errorf("listen on %s: %w", s.server.Addr, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
 
-	// 等待服务器启动
-	time.Sleep(100 * time.Millisecond)
+	fmt.Printf("HTTP Bridge Server listening on %s\n", s.server.Addr)
 	return nil
 }
 
-// Shutdown 关闭服务器
+// listenUnix 在私有临时目录（0700）中创建 socket，设置 0600 权限后再移动到 path，
+// 保证 socket 在限制权限之前不会被其他用户连接
+func listenUnix(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".aster-bridge-")
+	if err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tmpPath := filepath.Join(dir, "bridge.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// socket 文件移动后关闭监听不会删除它，由 Shutdown 负责
+	listener.SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("chmod unix socket: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("move unix socket: %w", err)
+	}
+	return listener, nil
+}
+
+func (s *HTTPBridgeServer) serve() error {
+	s.mu.RLock()
+	listener := s.listener
+	s.mu.RUnlock()
+	return s.server.Serve(listener)
+}
+
+// Shutdown 关闭服务器，使用 unix socket 时删除 socket 文件
 func (s *HTTPBridgeServer) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+
+	s.mu.Lock()
+	socketPath := s.socketPath
+	s.socketPath = ""
+	s.mu.Unlock()
+	if socketPath != "" {
+		_ = os.Remove(socketPath)
+	}
+	return err
 }
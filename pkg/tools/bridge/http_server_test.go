@@ -0,0 +1,246 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+func newAuthorizationTestServer(t *testing.T) (*HTTPBridgeServer, *int) {
+	t.Helper()
+
+	calls := 0
+	registry := tools.NewRegistry()
+	for _, name := range []string{"Read", "Bash"} {
+		registry.Register(name, func(config map[string]any) (tools.Tool, error) {
+			return &mockTool{
+				name: name,
+				executeFunc: func(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+					calls++
+					return "ok", nil
+				},
+			}, nil
+		})
+	}
+	return NewHTTPBridgeServer(NewToolBridge(registry), "localhost:0"), &calls
+}
+
+func callBridgeTool(s *HTTPBridgeServer, tool, executionID string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(ToolCallRequest{Tool: tool, Input: map[string]any{}})
+	req := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewReader(body))
+	if executionID != "" {
+		req.Header.Set(ExecutionIDHeader, executionID)
+	}
+	rec := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPBridgeServer_DeniesToolsByDefault(t *testing.T) {
+	server, calls := newAuthorizationTestServer(t)
+
+	rec := callBridgeTool(server, "Read", "")
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if *calls != 0 {
+		t.Error("disallowed tool must not be executed")
+	}
+
+	server.SetAllowedTools([]string{"Read"})
+	if rec := callBridgeTool(server, "Read", ""); rec.Code != http.StatusOK {
+		t.Errorf("allowed tool should succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := callBridgeTool(server, "Bash", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("tool outside allowlist should be rejected, got %d", rec.Code)
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 execution, got %d", *calls)
+	}
+}
+
+func TestHTTPBridgeServer_ExecutionAllowlist(t *testing.T) {
+	server, calls := newAuthorizationTestServer(t)
+	server.SetAllowedTools([]string{"Read", "Bash"})
+
+	id, end := server.BeginExecution([]string{"Read"})
+
+	if rec := callBridgeTool(server, "Read", id); rec.Code != http.StatusOK {
+		t.Errorf("tool allowed for execution should succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 执行级授权优先于服务器默认授权
+	rec := callBridgeTool(server, "Bash", id)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "not allowed") {
+		t.Errorf("tool outside execution allowlist should be rejected, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 列表和 Schema 同样受授权限制
+	req := httptest.NewRequest(http.MethodGet, "/tools/list", nil)
+	req.Header.Set(ExecutionIDHeader, id)
+	listRec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(listRec, req)
+	var list struct {
+		Tools []string `json:"tools"`
+	}
+	_ = json.Unmarshal(listRec.Body.Bytes(), &list)
+	if len(list.Tools) != 1 || list.Tools[0] != "Read" {
+		t.Errorf("expected only Read to be listed, got %v", list.Tools)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tools/schema?name=Bash", nil)
+	req.Header.Set(ExecutionIDHeader, id)
+	schemaRec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(schemaRec, req)
+	if schemaRec.Code != http.StatusForbidden {
+		t.Errorf("schema of disallowed tool should be rejected, got %d", schemaRec.Code)
+	}
+
+	// 执行结束或伪造的执行 ID 被拒绝
+	end()
+	if rec := callBridgeTool(server, "Read", id); rec.Code != http.StatusForbidden {
+		t.Errorf("finished execution should be rejected, got %d", rec.Code)
+	}
+	if rec := callBridgeTool(server, "Read", "forged"); rec.Code != http.StatusForbidden {
+		t.Errorf("unknown execution should be rejected, got %d", rec.Code)
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 execution, got %d", *calls)
+	}
+}
+
+func TestPythonRuntime_WithExecution(t *testing.T) {
+	runtime := NewPythonRuntime(nil)
+	runtime.SetTools([]string{"Read", "Bash"})
+
+	ctx := WithExecution(context.Background(), "exec-1", []string{"Read"})
+	wrapped := runtime.wrapCodeFor(ctx, "print('test')", map[string]any{})
+
+	if !strings.Contains(wrapped, `{"X-Aster-Execution-ID":"exec-1"}`) {
+		t.Error("expected execution ID header in wrapped code")
+	}
+	if !strings.Contains(wrapped, `_available_tools = ["Read"]`) {
+		t.Error("expected only execution tools to be injected")
+	}
+}
+
+func TestHTTPBridgeServer_RequiresAuthToken(t *testing.T) {
+	server, calls := newAuthorizationTestServer(t)
+	server.SetAllowedTools([]string{"Read"})
+	server.SetAuthToken("secret")
+
+	send := func(path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"tool":"Read","input":{}}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", "secret", "Basic c2VjcmV0"} {
+		rec := send("/tools/call", auth)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("auth %q: expected 401, got %d", auth, rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("auth %q: expected WWW-Authenticate header", auth)
+		}
+	}
+	if *calls != 0 {
+		t.Error("unauthenticated request must not execute tools")
+	}
+
+	if rec := send("/tools/call", "Bearer secret"); rec.Code != http.StatusOK {
+		t.Errorf("authenticated request should succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 健康检查不需要令牌
+	rec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("health check should not require auth, got %d", rec.Code)
+	}
+}
+
+func TestHTTPBridgeServer_UnixSocket(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("Read", func(config map[string]any) (tools.Tool, error) {
+		return &mockTool{name: "Read"}, nil
+	})
+
+	socketPath := filepath.Join(t.TempDir(), "bridge.sock")
+	server := NewHTTPBridgeServer(NewToolBridge(registry), "unix:"+socketPath)
+	server.SetAllowedTools([]string{"Read"})
+	server.SetAuthToken("secret")
+	if err := server.StartAsync(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	if server.URL() != "unix:"+socketPath {
+		t.Errorf("unexpected URL: %s", server.URL())
+	}
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected socket permission 0600, got %o", perm)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/tools/call", strings.NewReader(`{"tool":"Read","input":{}}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result ToolCallResponse
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK || !result.Success {
+		t.Errorf("expected successful call, got %d %+v", resp.StatusCode, result)
+	}
+
+	// 临时目录已清理，关闭后删除 socket 文件
+	if entries, _ := os.ReadDir(filepath.Dir(socketPath)); len(entries) != 1 {
+		t.Errorf("expected only the socket in its directory, got %d entries", len(entries))
+	}
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("expected socket to be removed on shutdown, got %v", err)
+	}
+}
+
+func TestPythonRuntime_InjectsAuthToken(t *testing.T) {
+	runtime := NewPythonRuntime(nil)
+	runtime.SetTools([]string{"Read"})
+	runtime.SetBridgeURL("unix:/tmp/bridge.sock")
+	runtime.SetAuthToken("secret")
+
+	wrapped := runtime.wrapCode("print('test')", map[string]any{})
+	if !strings.Contains(wrapped, `"Authorization":"Bearer secret"`) {
+		t.Error("expected Authorization header in wrapped code")
+	}
+	if !strings.Contains(wrapped, `_AsterBridge("unix:/tmp/bridge.sock"`) {
+		t.Error("expected unix socket bridge URL in wrapped code")
+	}
+}
@@ -65,6 +65,7 @@ func TestPTCIntegration(t *testing.T) {
 
 	// 3. 创建 HTTP 桥接服务器
 	server := NewHTTPBridgeServer(toolBridge, "localhost:18080")
+	server.SetAllowedTools([]string{"MockTool"})
 
 	// 4. 启动服务器
 	if err := server.StartAsync(); err != nil {
@@ -67,6 +67,7 @@ type PythonRuntime struct {
 	pythonPath     string
 	availableTools []string // PTC: 可用工具列表
 	bridgeURL      string   // PTC: HTTP 桥接服务器地址
+	authToken      string   // PTC: HTTP 桥接服务器访问令牌
 }
 
 // NewPythonRuntime 创建 Python 运行时
@@ -104,10 +105,39 @@ func (r *PythonRuntime) SetTools(tools []string) {
 }
 
 // SetBridgeURL 设置 HTTP 桥接服务器地址 (PTC 支持)
+// 支持 "http://host:port" 和 "unix:<path>" 两种形式。
 func (r *PythonRuntime) SetBridgeURL(url string) {
 	r.bridgeURL = url
 }
 
+// SetAuthToken 设置 HTTP 桥接服务器访问令牌 (PTC 支持)
+func (r *PythonRuntime) SetAuthToken(token string) {
+	r.authToken = token
+}
+
+type executionKey struct{}
+
+// execution 单次代码执行的工具授权
+type execution struct {
+	id    string
+	tools []string
+}
+
+// WithExecution 为单次执行指定执行 ID 和允许调用的工具 (PTC 支持)
+// 执行 ID 由 HTTPBridgeServer.BeginExecution 返回，PythonRuntime 只注入这些工具，
+// 并在调用桥接服务器时携带执行 ID，由服务端校验授权。
+func WithExecution(ctx context.Context, id string, tools []string) context.Context {
+	return context.WithValue(ctx, executionKey{}, &execution{id: id, tools: tools})
+}
+
+// wrapCodeFor 按 ctx 中的执行授权包装代码，未指定时使用 SetTools 设置的工具
+func (r *PythonRuntime) wrapCodeFor(ctx context.Context, code string, input map[string]any) string {
+	if scope, ok := ctx.Value(executionKey{}).(*execution); ok {
+		return r.wrapCodeWith(code, input, scope.tools, scope.id)
+	}
+	return r.wrapCode(code, input)
+}
+
 func (r *PythonRuntime) Execute(ctx context.Context, code string, input map[string]any) (*ExecutionResult, error) {
 	start := time.Now()
 
@@ -119,7 +149,7 @@ func (r *PythonRuntime) Execute(ctx context.Context, code string, input map[stri
 	defer func() { _ = os.Remove(tmpFile.Name()) }()
 
 	// 包装代码以处理输入和输出
-	wrappedCode := r.wrapCode(code, input)
+	wrappedCode := r.wrapCodeFor(ctx, code, input)
 	if _, err := tmpFile.WriteString(wrappedCode); err != nil {
 		return nil, fmt.Errorf("write code: %w", err)
 	}
@@ -144,52 +174,21 @@ func (r *PythonRuntime) Execute(ctx context.Context, code string, input map[stri
 	cmd.Stderr = &stderr
 
 	err = cmd.Run()
-	duration := time.Since(start).Milliseconds()
-
-	result := &ExecutionResult{
-		Stdout:   truncateOutput(stdout.String(), r.config.MaxOutput),
-		Stderr:   truncateOutput(stderr.String(), r.config.MaxOutput),
-		Duration: duration,
-	}
-
-	if err != nil {
-		if execCtx.Err() == context.DeadlineExceeded {
-			result.Error = "execution timeout"
-			result.ExitCode = -1
-		} else if exitErr := (&exec.ExitError{}); errors.As(err, &exitErr) {
-			result.ExitCode = exitErr.ExitCode()
-			result.Error = stderr.String()
-		} else {
-			result.Error = err.Error()
-			result.ExitCode = -1
-		}
-		return result, nil
-	}
-
-	result.Success = true
-	result.ExitCode = 0
-
-	// 尝试解析 JSON 输出
-	output := strings.TrimSpace(stdout.String())
-	if strings.HasPrefix(output, "{") || strings.HasPrefix(output, "[") {
-		var jsonOutput any
-		if err := json.Unmarshal([]byte(output), &jsonOutput); err == nil {
-			result.Output = jsonOutput
-		} else {
-			result.Output = output
-		}
-	} else {
-		result.Output = output
-	}
 
+	result := &ExecutionResult{Duration: time.Since(start).Milliseconds()}
+	r.finishResult(result, err, execCtx, stdout.String(), stderr.String())
 	return result, nil
 }
 
 func (r *PythonRuntime) wrapCode(code string, input map[string]any) string {
+	return r.wrapCodeWith(code, input, r.availableTools, "")
+}
+
+func (r *PythonRuntime) wrapCodeWith(code string, input map[string]any, availableTools []string, executionID string) string {
 	inputJSON, _ := json.Marshal(input)
 
 	// 如果没有配置工具,使用简单包装
-	if len(r.availableTools) == 0 {
+	if len(availableTools) == 0 {
 		return fmt.Sprintf(`import json
 import sys
 
@@ -208,7 +207,17 @@ _input = json.loads('%s')
 	}
 
 	// 生成工具列表 JSON
-	toolsJSON, _ := json.Marshal(r.availableTools)
+	toolsJSON, _ := json.Marshal(availableTools)
+
+	// 访问令牌和执行 ID 请求头，由服务端校验身份和工具授权
+	headers := map[string]string{}
+	if r.authToken != "" {
+		headers["Authorization"] = "Bearer " + r.authToken
+	}
+	if executionID != "" {
+		headers[ExecutionIDHeader] = executionID
+	}
+	headersJSON, _ := json.Marshal(headers)
 
 	return fmt.Sprintf(`import json
 import asyncio
@@ -231,15 +240,21 @@ class _NetworkError(Exception):
     pass
 
 class _AsterBridge:
-    def __init__(self, base_url, max_retries=3, retry_delay=0.5):
+    def __init__(self, base_url, headers=None, max_retries=3, retry_delay=0.5):
+        self.socket_path = None
+        if base_url.startswith("unix:"):
+            self.socket_path = base_url[len("unix:"):]
+            base_url = "http://localhost"
         self.base_url = base_url
+        self.headers = headers or {}
         self.max_retries = max_retries
         self.retry_delay = retry_delay
         self._session = None
 
     async def _get_session(self):
         if self._session is None or self._session.closed:
-            self._session = aiohttp.ClientSession()
+            connector = aiohttp.UnixConnector(path=self.socket_path) if self.socket_path else None
+            self._session = aiohttp.ClientSession(headers=self.headers, connector=connector)
         return self._session
 
     async def call_tool(self, name, **kwargs):
@@ -259,6 +274,9 @@ class _AsterBridge:
                             await asyncio.sleep(self.retry_delay * (2 ** attempt))
                             continue
                         raise last_error
+                    if resp.status == 403:
+                        error_text = await resp.text()
+                        raise _ToolExecutionError(f"Tool {name} is not allowed: {error_text}")
                     if resp.status >= 400:
                         error_text = await resp.text()
                         raise _NetworkError(f"Client error (HTTP {resp.status}): {error_text}")
@@ -296,7 +314,7 @@ class _AsterBridge:
             await self._session.close()
 
 # 初始化桥接
-_bridge = _AsterBridge("%s")
+_bridge = _AsterBridge("%s", json.loads('%s'))
 
 # 动态生成工具函数
 def _create_tool_function(bridge, tool_name):
@@ -326,7 +344,7 @@ if __name__ == "__main__":
     finally:
         # 确保关闭会话
         asyncio.run(_bridge.close())
-`, bridgeURL, string(toolsJSON), string(inputJSON), indentCode(code, "    "))
+`, bridgeURL, string(headersJSON), string(toolsJSON), string(inputJSON), indentCode(code, "    "))
 }
 
 // indentCode 缩进代码
@@ -435,19 +453,7 @@ func (r *NodeJSRuntime) Execute(ctx context.Context, code string, input map[stri
 
 	result.Success = true
 	result.ExitCode = 0
-
-	// 尝试解析 JSON 输出
-	output := strings.TrimSpace(stdout.String())
-	if strings.HasPrefix(output, "{") || strings.HasPrefix(output, "[") {
-		var jsonOutput any
-		if err := json.Unmarshal([]byte(output), &jsonOutput); err == nil {
-			result.Output = jsonOutput
-		} else {
-			result.Output = output
-		}
-	} else {
-		result.Output = output
-	}
+	result.Output = parseOutput(stdout.String())
 
 	return result, nil
 }
@@ -648,6 +654,13 @@ func (m *RuntimeManager) SetPythonBridgeURL(url string) {
 	}
 }
 
+// SetPythonAuthToken 设置 Python 运行时的 HTTP 桥接服务器访问令牌 (PTC 支持)
+func (m *RuntimeManager) SetPythonAuthToken(token string) {
+	if runtime, ok := m.runtimes[LangPython].(*PythonRuntime); ok {
+		runtime.SetAuthToken(token)
+	}
+}
+
 // DetectLanguage 根据文件扩展名检测语言
 func DetectLanguage(filename string) Language {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -663,6 +676,18 @@ func DetectLanguage(filename string) Language {
 	}
 }
 
+// parseOutput 解析标准输出，JSON 对象或数组解析为结构化数据，否则返回去除首尾空白的文本
+func parseOutput(stdout string) any {
+	output := strings.TrimSpace(stdout)
+	if strings.HasPrefix(output, "{") || strings.HasPrefix(output, "[") {
+		var jsonOutput any
+		if err := json.Unmarshal([]byte(output), &jsonOutput); err == nil {
+			return jsonOutput
+		}
+	}
+	return output
+}
+
 // truncateOutput 截断输出
 func truncateOutput(s string, maxLen int) string {
 	if len(s) <= maxLen {
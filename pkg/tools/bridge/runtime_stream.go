@@ -0,0 +1,193 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// streamWaitDelay 进程被终止后等待输出管道关闭的最长时间
+const streamWaitDelay = time.Second
+
+// OutputChunk 流式执行的一段输出
+type OutputChunk struct {
+	Stream string `json:"stream"` // "stdout" 或 "stderr"
+	Data   string `json:"data"`   // 一行输出（含换行符）；进程结束时未换行的剩余部分原样输出
+}
+
+// ExecutionStream 流式执行句柄
+// 调用方从 Output 逐行读取输出，结束后通过 Wait 获取最终结果。
+type ExecutionStream struct {
+	chunks chan OutputChunk
+	done   chan struct{}
+	result *ExecutionResult
+}
+
+// Output 返回输出通道，进程结束且剩余输出发送完毕后关闭
+func (s *ExecutionStream) Output() <-chan OutputChunk {
+	return s.chunks
+}
+
+// Wait 等待执行结束并返回最终结果，未读取的输出会被丢弃
+// 结果中的 Stdout/Stderr 包含完整输出（按 MaxOutput 截断）。
+func (s *ExecutionStream) Wait() *ExecutionResult {
+	for range s.chunks {
+	}
+	<-s.done
+	return s.result
+}
+
+// ExecuteStream 流式执行 Python 代码，stdout/stderr 按行实时输出
+// ctx 取消或超时时终止进程，已产生的输出仍会发送并计入结果。
+func (r *PythonRuntime) ExecuteStream(ctx context.Context, code string, input map[string]any) (*ExecutionStream, error) {
+	start := time.Now()
+
+	// 创建临时文件
+	tmpFile, err := os.CreateTemp(r.config.WorkDir, "aster_*.py")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	removeTmp := func() { _ = os.Remove(tmpFile.Name()) }
+
+	wrappedCode := r.wrapCodeFor(ctx, code, input)
+	if _, err := tmpFile.WriteString(wrappedCode); err != nil {
+		_ = tmpFile.Close()
+		removeTmp()
+		return nil, fmt.Errorf("write code: %w", err)
+	}
+	_ = tmpFile.Close()
+
+	execCtx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+
+	// -u 关闭 Python 的输出缓冲，确保逐行输出
+	cmd := exec.CommandContext(execCtx, r.pythonPath, "-u", tmpFile.Name())
+	cmd.Dir = r.config.WorkDir
+	cmd.WaitDelay = streamWaitDelay
+
+	cmd.Env = os.Environ()
+	for k, v := range r.config.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	stream := &ExecutionStream{
+		chunks: make(chan OutputChunk, 64),
+		done:   make(chan struct{}),
+	}
+	emit := func(chunk OutputChunk) {
+		select {
+		case stream.chunks <- chunk:
+		case <-execCtx.Done():
+			// 进程已终止，调用方仍在读取时尽量送达剩余输出
+			select {
+			case stream.chunks <- chunk:
+			default:
+			}
+		}
+	}
+	stdout := &lineWriter{stream: "stdout", emit: emit}
+	stderr := &lineWriter{stream: "stderr", emit: emit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		removeTmp()
+		return nil, fmt.Errorf("start python: %w", err)
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer removeTmp()
+		defer cancel()
+
+		err := cmd.Wait()
+
+		// 输出未换行的剩余部分
+		stdout.flush()
+		stderr.flush()
+		close(stream.chunks)
+
+		result := &ExecutionResult{Duration: time.Since(start).Milliseconds()}
+		r.finishResult(result, err, execCtx, stdout.String(), stderr.String())
+		stream.result = result
+	}()
+
+	return stream, nil
+}
+
+// finishResult 根据进程退出状态填充执行结果
+func (r *PythonRuntime) finishResult(result *ExecutionResult, err error, execCtx context.Context, stdout, stderr string) {
+	result.Stdout = truncateOutput(stdout, r.config.MaxOutput)
+	result.Stderr = truncateOutput(stderr, r.config.MaxOutput)
+
+	if err != nil {
+		switch exitErr := (&exec.ExitError{}); {
+		case errors.Is(execCtx.Err(), context.DeadlineExceeded):
+			result.Error = "execution timeout"
+			result.ExitCode = -1
+		case errors.Is(execCtx.Err(), context.Canceled):
+			result.Error = "execution canceled"
+			result.ExitCode = -1
+		case errors.As(err, &exitErr):
+			result.ExitCode = exitErr.ExitCode()
+			result.Error = stderr
+		default:
+			result.Error = err.Error()
+			result.ExitCode = -1
+		}
+		return
+	}
+
+	result.Success = true
+	result.ExitCode = 0
+	result.Output = parseOutput(stdout)
+}
+
+// lineWriter 将写入的数据按行切分并逐行回调，同时保留完整输出
+type lineWriter struct {
+	stream string
+	emit   func(OutputChunk)
+
+	mu      sync.Mutex
+	all     bytes.Buffer
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.all.Write(p)
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(OutputChunk{Stream: w.stream, Data: string(w.pending[:i+1])})
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush 输出最后一段未换行的内容
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		w.emit(OutputChunk{Stream: w.stream, Data: string(w.pending)})
+		w.pending = nil
+	}
+}
+
+func (w *lineWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.all.String()
+}
@@ -250,3 +250,113 @@ func TestDetectLanguage(t *testing.T) {
 		}
 	}
 }
+
+func TestPythonRuntime_ExecuteStream(t *testing.T) {
+	runtime := NewPythonRuntime(nil)
+
+	if !runtime.IsAvailable() {
+		t.Skip("Python not available")
+	}
+
+	code := `
+import sys, time
+print("step", _input['n'])
+print("warn", file=sys.stderr)
+time.sleep(0.2)
+print("step 2")
+sys.stdout.write("done")
+`
+	stream, err := runtime.ExecuteStream(context.Background(), code, map[string]any{"n": 1})
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+
+	var stdout []string
+	var stderr []string
+	for chunk := range stream.Output() {
+		switch chunk.Stream {
+		case "stdout":
+			stdout = append(stdout, chunk.Data)
+		case "stderr":
+			stderr = append(stderr, chunk.Data)
+		}
+	}
+
+	want := []string{"step 1\n", "step 2\n", "done"}
+	if len(stdout) != len(want) {
+		t.Fatalf("expected stdout chunks %q, got %q", want, stdout)
+	}
+	for i := range want {
+		if stdout[i] != want[i] {
+			t.Errorf("chunk %d: expected %q, got %q", i, want[i], stdout[i])
+		}
+	}
+	if len(stderr) != 1 || stderr[0] != "warn\n" {
+		t.Errorf("expected stderr chunk 'warn', got %q", stderr)
+	}
+
+	result := stream.Wait()
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if result.Stdout != "step 1\nstep 2\ndone" {
+		t.Errorf("unexpected stdout: %q", result.Stdout)
+	}
+	if result.Output != "step 1\nstep 2\ndone" {
+		t.Errorf("unexpected output: %v", result.Output)
+	}
+}
+
+func TestPythonRuntime_ExecuteStreamCancel(t *testing.T) {
+	runtime := NewPythonRuntime(nil)
+
+	if !runtime.IsAvailable() {
+		t.Skip("Python not available")
+	}
+
+	code := `
+import sys, time
+print("ready")
+sys.stdout.write("partial")
+sys.stdout.flush()
+print("waiting", file=sys.stderr)
+time.sleep(30)
+print("never")
+`
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := runtime.ExecuteStream(ctx, code, nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+
+	// stderr 输出 waiting 时 partial 已写入管道，此时取消，进程应被终止
+	var stdout []string
+	for chunk := range stream.Output() {
+		if chunk.Stream == "stderr" {
+			break
+		}
+		stdout = append(stdout, chunk.Data)
+	}
+	start := time.Now()
+	cancel()
+
+	for chunk := range stream.Output() {
+		stdout = append(stdout, chunk.Data)
+	}
+	result := stream.Wait()
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("process was not killed promptly, took %v", elapsed)
+	}
+	if len(stdout) != 2 || stdout[0] != "ready\n" || stdout[1] != "partial" {
+		t.Errorf("expected partial output to be flushed, got %q", stdout)
+	}
+	if result.Success || result.Error != "execution canceled" {
+		t.Errorf("expected canceled result, got %+v", result)
+	}
+	if result.Stdout != "ready\npartial" {
+		t.Errorf("unexpected stdout: %q", result.Stdout)
+	}
+}
@@ -205,6 +205,13 @@ func (t *BashTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 			Shell:         shellType,
 			CaptureOutput: captureOutput,
 		}
+		// 后台进程不经过 Exec，由沙箱提供相同的环境变量白名单、密钥注入和输出掩码
+		if envProvider, ok := tc.Sandbox.(sandbox.CommandEnvProvider); ok {
+			taskOpts.ExecEnv = envProvider.CommandEnv(ctx, &sandbox.ExecOptions{WorkDir: workingDir, Env: environment})
+		}
+		if masker, ok := tc.Sandbox.(sandbox.SecretMasker); ok {
+			taskOpts.Mask = masker.MaskSecrets
+		}
 
 		taskInfo, taskErr := taskManager.StartTask(ctx, command, taskOpts)
 		if taskErr != nil {
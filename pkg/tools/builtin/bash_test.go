@@ -1,10 +1,16 @@
 package builtin
 
 import (
+	"context"
 	"errors"
 	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/types"
 )
 
 func TestNewBashTool(t *testing.T) {
@@ -425,3 +431,55 @@ func BenchmarkBashTool_ComplexCommand(b *testing.B) {
 
 	BenchmarkTool(b, tool, input)
 }
+
+func TestBashTool_BackgroundUsesSandboxEnv(t *testing.T) {
+	t.Setenv("ASTER_TEST_HOST_TOKEN", "host-token")
+	sb, err := sandbox.NewLocalSandbox(&sandbox.LocalSandboxConfig{
+		WorkDir: t.TempDir(),
+		Secrets: []types.SandboxSecret{{Name: "ASTER_TEST_API_KEY", Value: "sk-background-secret", Tools: []string{"Bash"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer func() { _ = sb.Dispose() }()
+
+	tool, err := NewBashTool(nil)
+	if err != nil {
+		t.Fatalf("Failed to create Bash tool: %v", err)
+	}
+
+	ctx := sandbox.WithToolName(context.Background(), "Bash")
+	output, err := tool.Execute(ctx, map[string]any{
+		"command":    `echo "host=[$ASTER_TEST_HOST_TOKEN] key=[$ASTER_TEST_API_KEY]"; sleep 2`,
+		"background": true,
+	}, &tools.ToolContext{Signal: ctx, Sandbox: sb})
+	if err != nil {
+		t.Fatalf("Tool execution failed: %v", err)
+	}
+	result := AssertToolSuccess(t, output.(map[string]any))
+	taskID, _ := result["task_id"].(string)
+	if taskID == "" {
+		t.Fatalf("expected task_id, got %+v", result)
+	}
+
+	// 任务结束后会被清理，在运行期间轮询输出
+	var stdout string
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(stdout, "key=") {
+		if time.Now().After(deadline) {
+			t.Fatalf("background task produced no output, got %q", stdout)
+		}
+		time.Sleep(20 * time.Millisecond)
+		stdout, _, err = GetGlobalTaskManager().GetTaskOutput(taskID, "", 0)
+		if err != nil {
+			t.Fatalf("GetTaskOutput failed: %v", err)
+		}
+	}
+
+	if !strings.Contains(stdout, "host=[]") {
+		t.Errorf("background task should not inherit host env, got %q", stdout)
+	}
+	if strings.Contains(stdout, "sk-background-secret") || !strings.Contains(stdout, "key=["+sandbox.SecretMask+"]") {
+		t.Errorf("secret should be injected and masked in background output, got %q", stdout)
+	}
+}
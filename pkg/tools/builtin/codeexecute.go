@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/astercloud/aster/pkg/provider"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/tools/bridge"
 )
@@ -20,6 +23,7 @@ type CodeExecuteTool struct {
 	// PTC 支持
 	httpServer    *bridge.HTTPBridgeServer
 	bridgeURL     string
+	allowedTools  []string // 允许代码调用的工具，为空时不允许调用任何工具
 	serverStarted bool
 	mu            sync.Mutex
 }
@@ -51,12 +55,36 @@ func NewCodeExecuteToolWithBridge(toolBridge *bridge.ToolBridge) *CodeExecuteToo
 }
 
 // SetBridgeURL 设置 HTTP 桥接服务器地址
+// 支持 "http://host:port" 和 "unix:<path>"，后者使用 unix socket 代替 TCP 端口。
 func (t *CodeExecuteTool) SetBridgeURL(url string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.bridgeURL = url
 }
 
+// SetAllowedTools 设置允许代码通过桥接服务器调用的工具
+// 每次执行都会在桥接服务器登记这些工具，未登记的工具调用返回 403。
+// 默认不允许调用任何工具。
+func (t *CodeExecuteTool) SetAllowedTools(names []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowedTools = names
+}
+
+// SetAllowedToolsFromSchemas 按 ToolSchema.AllowedCallers 设置允许代码调用的工具
+// AllowedCallers 包含 code_execution 调用方（如 "code_execution_20250825"）的工具会被允许。
+func (t *CodeExecuteTool) SetAllowedToolsFromSchemas(schemas []provider.ToolSchema) {
+	names := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		if slices.ContainsFunc(schema.AllowedCallers, func(caller string) bool {
+			return strings.HasPrefix(caller, "code_execution")
+		}) {
+			names = append(names, schema.Name)
+		}
+	}
+	t.SetAllowedTools(names)
+}
+
 func (t *CodeExecuteTool) Name() string {
 	return "CodeExecute"
 }
@@ -102,8 +130,13 @@ func (t *CodeExecuteTool) ensureBridgeServer(tc *tools.ToolContext) error {
 		return nil
 	}
 
-	// 创建并启动 HTTP 桥接服务器
-	t.httpServer = bridge.NewHTTPBridgeServer(t.toolBridge, t.bridgeURL)
+	// 创建并启动 HTTP 桥接服务器，使用随机令牌拒绝其他调用方
+	token, err := bridge.NewAuthToken()
+	if err != nil {
+		return err
+	}
+	t.httpServer = bridge.NewHTTPBridgeServer(t.toolBridge, strings.TrimPrefix(t.bridgeURL, "http://"))
+	t.httpServer.SetAuthToken(token)
 
 	// 设置工具上下文工厂
 	t.httpServer.SetContextFactory(func() *tools.ToolContext {
@@ -115,12 +148,9 @@ func (t *CodeExecuteTool) ensureBridgeServer(tc *tools.ToolContext) error {
 		return fmt.Errorf("failed to start HTTP bridge server: %w", err)
 	}
 
-	// 获取可用工具列表
-	availableTools := t.toolBridge.ListAvailableTools()
-
-	// 设置 RuntimeManager 的工具列表和桥接 URL
-	t.runtimeManager.SetPythonTools(availableTools)
-	t.runtimeManager.SetPythonBridgeURL(t.bridgeURL)
+	// 设置 RuntimeManager 的桥接 URL 和令牌，可调用的工具在每次执行时登记
+	t.runtimeManager.SetPythonBridgeURL(t.httpServer.URL())
+	t.runtimeManager.SetPythonAuthToken(token)
 
 	t.serverStarted = true
 	return nil
@@ -168,6 +198,13 @@ func (t *CodeExecuteTool) Execute(ctx context.Context, input map[string]any, tc
 		codeInput = inputData
 	}
 
+	// PTC: 在桥接服务器登记本次执行允许调用的工具
+	if lang == bridge.LangPython {
+		var end func()
+		ctx, end = t.beginExecution(ctx)
+		defer end()
+	}
+
 	// 执行代码
 	result, err := t.runtimeManager.Execute(ctx, lang, code, codeInput)
 	if err != nil {
@@ -188,6 +225,21 @@ func (t *CodeExecuteTool) Execute(ctx context.Context, input map[string]any, tc
 	}, nil
 }
 
+// beginExecution 登记本次执行的工具授权，返回携带执行 ID 的 ctx 和结束登记的函数
+func (t *CodeExecuteTool) beginExecution(ctx context.Context) (context.Context, func()) {
+	t.mu.Lock()
+	server := t.httpServer
+	allowed := t.allowedTools
+	t.mu.Unlock()
+
+	// 非 PTC 模式
+	if server == nil {
+		return ctx, func() {}
+	}
+	id, end := server.BeginExecution(allowed)
+	return bridge.WithExecution(ctx, id, allowed), end
+}
+
 func (t *CodeExecuteTool) Prompt() string {
 	return `Execute code in Python, Node.js, or Bash.
 
@@ -0,0 +1,31 @@
+package builtin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/provider"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/tools/bridge"
+)
+
+func TestCodeExecuteTool_AllowedTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	RegisterAll(registry)
+	tool := NewCodeExecuteToolWithBridge(bridge.NewToolBridge(registry))
+
+	// 默认不授权任何工具，即使桥接器中注册了全部内置工具
+	if len(tool.allowedTools) != 0 {
+		t.Fatalf("expected empty allowlist by default, got %v", tool.allowedTools)
+	}
+
+	tool.SetAllowedToolsFromSchemas([]provider.ToolSchema{
+		{Name: "CodeExecute", AllowedCallers: []string{"direct"}},
+		{Name: "Read", AllowedCallers: []string{"direct", "code_execution_20250825"}},
+		{Name: "Glob", AllowedCallers: []string{"code_execution_20250825"}},
+		{Name: "Bash"},
+	})
+	if !slices.Equal(tool.allowedTools, []string{"Read", "Glob"}) {
+		t.Errorf("expected [Read Glob], got %v", tool.allowedTools)
+	}
+}
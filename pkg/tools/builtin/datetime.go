@@ -0,0 +1,80 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+// DateTimeTool 当前时间工具
+// 时间来自 ToolContext 中的 Clock，测试时可注入固定时钟
+type DateTimeTool struct{}
+
+// NewDateTimeTool 创建DateTime工具
+func NewDateTimeTool(config map[string]any) (tools.Tool, error) {
+	return &DateTimeTool{}, nil
+}
+
+func (t *DateTimeTool) Name() string {
+	return "DateTime"
+}
+
+func (t *DateTimeTool) Description() string {
+	return "获取当前日期和时间，可指定时区"
+}
+
+func (t *DateTimeTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"timezone": map[string]any{
+				"type":        "string",
+				"description": "IANA 时区名称，例如 Asia/Shanghai、America/New_York，默认为 UTC",
+			},
+		},
+	}
+}
+
+func (t *DateTimeTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	timezone := GetStringParam(input, "timezone", "UTC")
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return NewClaudeErrorResponse(
+			fmt.Errorf("unknown timezone: %s", timezone),
+			"Use an IANA timezone name such as UTC, Asia/Shanghai or America/New_York",
+		), nil
+	}
+
+	now := tools.ClockFrom(tc).Now().In(loc)
+	return map[string]any{
+		"ok":       true,
+		"datetime": now.Format(time.RFC3339),
+		"date":     now.Format("2006-01-02"),
+		"time":     now.Format("15:04:05"),
+		"weekday":  now.Weekday().String(),
+		"timezone": timezone,
+		"unix":     now.Unix(),
+	}, nil
+}
+
+func (t *DateTimeTool) Prompt() string {
+	return `获取当前日期和时间。
+
+需要知道当前时间（例如计算截止日期、判断信息是否过期）时使用，不要根据训练数据推测当前时间。
+
+## 参数说明
+
+- timezone: （可选）IANA 时区名称，默认为 UTC`
+}
+
+// Annotations 返回工具安全注解
+func (t *DateTimeTool) Annotations() *tools.ToolAnnotations {
+	return &tools.ToolAnnotations{
+		ReadOnly:   true,
+		Idempotent: false, // 结果随时间变化
+		RiskLevel:  tools.RiskLevelSafe,
+		Category:   tools.CategorySystem,
+	}
+}
@@ -0,0 +1,44 @@
+package builtin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+func TestDateTimeTool_UsesInjectedClock(t *testing.T) {
+	tool, err := NewDateTimeTool(nil)
+	if err != nil {
+		t.Fatalf("Failed to create DateTime tool: %v", err)
+	}
+	tc := &tools.ToolContext{Clock: tools.FixedClock{Time: time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)}}
+
+	result, err := tool.Execute(context.Background(), map[string]any{}, tc)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	out := result.(map[string]any)
+	if out["datetime"] != "2024-03-15T23:30:00Z" || out["weekday"] != "Friday" || out["unix"] != int64(1710545400) {
+		t.Errorf("unexpected UTC result: %v", out)
+	}
+
+	result, _ = tool.Execute(context.Background(), map[string]any{"timezone": "Asia/Shanghai"}, tc)
+	out = result.(map[string]any)
+	if out["date"] != "2024-03-16" || out["time"] != "07:30:00" || out["timezone"] != "Asia/Shanghai" {
+		t.Errorf("unexpected Asia/Shanghai result: %v", out)
+	}
+}
+
+func TestDateTimeTool_InvalidTimezone(t *testing.T) {
+	tool, _ := NewDateTimeTool(nil)
+
+	result, err := tool.Execute(context.Background(), map[string]any{"timezone": "Mars/Olympus"}, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if out := result.(map[string]any); out["ok"] != false {
+		t.Errorf("expected error response for unknown timezone, got %v", out)
+	}
+}
@@ -16,6 +16,12 @@ type PlanModeManagerInterface interface {
 	IsActive() bool
 }
 
+// PlanProposer 支持计划审批的 Plan 模式管理器
+// 实现该接口时 ExitPlanMode 提交计划后保持 Plan 模式，直到用户批准
+type PlanProposer interface {
+	ProposePlan(planID, planFilePath, content string, steps []string, rationale string)
+}
+
 // EnterPlanModeTool 进入规划模式工具
 // 用于复杂任务的规划阶段，在此模式下只允许只读操作和计划文件写入
 type EnterPlanModeTool struct {
@@ -67,6 +67,15 @@ func (t *ExitPlanModeTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "计划文件的路径（可选，已弃用）。仅在未提供 plan 参数时使用。",
 			},
+			"steps": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "计划步骤（可选）。未提供时从计划内容的列表项中提取。",
+			},
+			"rationale": map[string]any{
+				"type":        "string",
+				"description": "计划的理由（可选）",
+			},
 		},
 		"required": []string{},
 	}
@@ -182,8 +191,13 @@ func (t *ExitPlanModeTool) Execute(ctx context.Context, input map[string]any, tc
 		fmt.Printf("[ExitPlanMode] Warning: failed to store plan record: %v\n", err)
 	}
 
+	// 支持审批的管理器保持 Plan 模式直到用户批准，否则直接退出
+	awaitingApproval := false
 	if tc != nil && tc.Services != nil {
-		if pmm, ok := tc.Services["plan_mode_manager"].(PlanModeManagerInterface); ok {
+		if proposer, ok := tc.Services["plan_mode_manager"].(PlanProposer); ok {
+			proposer.ProposePlan(planID, planFilePath, planContent, GetStringSliceParam(input, "steps"), GetStringParam(input, "rationale", ""))
+			awaitingApproval = true
+		} else if pmm, ok := tc.Services["plan_mode_manager"].(PlanModeManagerInterface); ok {
 			pmm.ExitPlanMode()
 		}
 	}
@@ -205,7 +219,7 @@ func (t *ExitPlanModeTool) Execute(ctx context.Context, input map[string]any, tc
 		"status":                "pending_approval",
 		"confirmation_required": true,
 		"duration_ms":           duration.Milliseconds(),
-		"plan_mode_exited":      true,
+		"plan_mode_exited":      !awaitingApproval,
 		"message":               "计划已准备就绪，等待用户审批。",
 		"next_steps": []string{
 			"用户审核计划内容",
@@ -5,13 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/astercloud/aster/pkg/sandbox"
 	"github.com/astercloud/aster/pkg/tools"
+	"github.com/bmatcuk/doublestar/v4"
 )
 
+// globIgnoreFiles 搜索根目录下会被读取的忽略规则文件
+var globIgnoreFiles = []string{".gitignore", ".asterignore"}
+
+// globDefaultIgnores 启用忽略规则时始终排除的目录
+var globDefaultIgnores = []string{".git/**", "**/.git/**"}
+
 // GlobTool 增强的文件搜索工具
 // 支持模式匹配文件搜索功能
 type GlobTool struct{}
@@ -37,6 +45,13 @@ func (t *GlobTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "要搜索的文件模式，支持通配符如 *.go, **/*.js",
 			},
+			"patterns": map[string]any{
+				"type":        "array",
+				"description": "额外的搜索模式列表，结果取并集",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
 			"path": map[string]any{
 				"type":        "string",
 				"description": "搜索的起始目录，默认为当前目录",
@@ -64,6 +79,10 @@ func (t *GlobTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "结果排序方式：name, size, modified_time, 默认为name",
 			},
+			"respect_gitignore": map[string]any{
+				"type":        "boolean",
+				"description": "是否遵循搜索目录下的 .gitignore/.asterignore 规则，默认为true",
+			},
 			"recursive": map[string]any{
 				"type":        "boolean",
 				"description": "是否递归搜索子目录，默认为true",
@@ -80,6 +99,7 @@ func (t *GlobTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 	}
 
 	pattern := t.getStringParam(input, "pattern", "")
+	extraPatterns := t.getStringSlice(input, "patterns")
 	path := t.getStringParam(input, "path", ".")
 	excludePatterns := t.getStringSlice(input, "exclude_patterns")
 	includeHidden := t.getBoolParam(input, "include_hidden", false)
@@ -87,6 +107,7 @@ func (t *GlobTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 	maxResults := t.getIntParam(input, "max_results", 100)
 	sortBy := t.getStringParam(input, "sort_by", "name")
 	recursive := t.getBoolParam(input, "recursive", true)
+	respectGitignore := t.getBoolParam(input, "respect_gitignore", true)
 
 	if pattern == "" {
 		return NewClaudeErrorResponse(errors.New("pattern cannot be empty")), nil
@@ -103,8 +124,21 @@ func (t *GlobTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 
 	start := time.Now()
 
+	patterns := []string{pattern}
+	for _, p := range extraPatterns {
+		if p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	// 加载忽略规则
+	var ignoreRules *globIgnoreRules
+	if respectGitignore {
+		ignoreRules = t.loadIgnoreRules(ctx, path, tc)
+	}
+
 	// 执行文件搜索
-	matches, err := t.searchFiles(ctx, path, pattern, excludePatterns, includeHidden, caseSensitive, recursive, tc)
+	matches, err := t.searchFiles(ctx, path, patterns, excludePatterns, includeHidden, caseSensitive, recursive, ignoreRules, tc)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -123,23 +157,26 @@ func (t *GlobTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 		}, nil
 	}
 
+	// 获取文件信息并排序（先排序再截断，保证截断后的结果有意义）
+	stats := t.statMatches(ctx, matches, tc)
+	t.sortMatches(matches, stats, sortBy)
+
 	// 限制结果数量
+	totalFound := len(matches)
+	truncated := false
 	if maxResults > 0 && len(matches) > maxResults {
 		matches = matches[:maxResults]
+		truncated = true
 	}
 
-	// 排序结果
-	t.sortMatches(matches, sortBy)
-
-	// 获取文件信息
 	fileInfos := make([]map[string]any, len(matches))
 	for i, match := range matches {
-		info, err := tc.Sandbox.FS().Stat(ctx, match)
+		info, ok := stats[match]
 		fileType := "unknown"
 		var size int64 = 0
 		var modifiedTime time.Time
 
-		if err == nil {
+		if ok {
 			size = info.Size
 			modifiedTime = info.ModTime
 
@@ -202,19 +239,22 @@ func (t *GlobTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 	}
 
 	return map[string]any{
-		"ok":               true,
-		"pattern":          pattern,
-		"path":             path,
-		"matches":          fileInfos,
-		"total_matches":    len(fileInfos),
-		"truncated":        maxResults > 0 && len(matches) >= maxResults,
-		"exclude_patterns": excludePatterns,
-		"include_hidden":   includeHidden,
-		"case_sensitive":   caseSensitive,
-		"sort_by":          sortBy,
-		"recursive":        recursive,
-		"max_results":      maxResults,
-		"duration_ms":      duration.Milliseconds(),
+		"ok":                true,
+		"pattern":           pattern,
+		"patterns":          patterns,
+		"path":              path,
+		"matches":           fileInfos,
+		"total_matches":     len(fileInfos),
+		"total_found":       totalFound,
+		"truncated":         truncated,
+		"respect_gitignore": respectGitignore,
+		"exclude_patterns":  excludePatterns,
+		"include_hidden":    includeHidden,
+		"case_sensitive":    caseSensitive,
+		"sort_by":           sortBy,
+		"recursive":         recursive,
+		"max_results":       maxResults,
+		"duration_ms":       duration.Milliseconds(),
 	}, nil
 }
 
@@ -287,8 +327,8 @@ func (t *GlobTool) validatePath(path string) error {
 	return nil
 }
 
-// searchFiles 搜索匹配的文件
-func (t *GlobTool) searchFiles(ctx context.Context, rootPath, pattern string, excludePatterns []string, includeHidden, caseSensitive, recursive bool, tc *tools.ToolContext) ([]string, error) {
+// searchFiles 搜索匹配的文件，多个模式的结果取并集并去重
+func (t *GlobTool) searchFiles(ctx context.Context, rootPath string, patterns, excludePatterns []string, includeHidden, caseSensitive, recursive bool, ignoreRules *globIgnoreRules, tc *tools.ToolContext) ([]string, error) {
 	// 使用沙箱的Glob功能
 	opts := &sandbox.GlobOptions{
 		CWD:      rootPath,
@@ -300,12 +340,69 @@ func (t *GlobTool) searchFiles(ctx context.Context, rootPath, pattern string, ex
 		opts.Ignore = excludePatterns
 	}
 
-	matches, err := tc.Sandbox.FS().Glob(ctx, pattern, opts)
-	if err != nil {
-		return nil, err
+	fs := tc.Sandbox.FS()
+	root := fs.Resolve(rootPath)
+	seen := make(map[string]bool)
+	results := make([]string, 0)
+
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(ctx, pattern, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			// 沙箱边界检查
+			if !fs.IsInside(fs.Resolve(match)) {
+				continue
+			}
+
+			if ignoreRules != nil {
+				rel := t.getRelativePath(fs.Resolve(match), root)
+				if ignoreRules.Match(filepath.ToSlash(rel)) {
+					continue
+				}
+			}
+
+			results = append(results, match)
+		}
 	}
 
-	return matches, nil
+	return results, nil
+}
+
+// loadIgnoreRules 从搜索根目录读取 .gitignore/.asterignore 规则
+func (t *GlobTool) loadIgnoreRules(ctx context.Context, rootPath string, tc *tools.ToolContext) *globIgnoreRules {
+	rules := &globIgnoreRules{}
+	for _, pattern := range globDefaultIgnores {
+		rules.patterns = append(rules.patterns, globIgnorePattern{pattern: pattern})
+	}
+
+	for _, name := range globIgnoreFiles {
+		content, err := tc.Sandbox.FS().Read(ctx, filepath.Join(rootPath, name))
+		if err != nil {
+			continue
+		}
+		rules.parse(content)
+	}
+
+	return rules
+}
+
+// statMatches 获取匹配文件的状态信息
+func (t *GlobTool) statMatches(ctx context.Context, matches []string, tc *tools.ToolContext) map[string]sandbox.FileInfo {
+	stats := make(map[string]sandbox.FileInfo, len(matches))
+	for _, match := range matches {
+		if info, err := tc.Sandbox.FS().Stat(ctx, match); err == nil {
+			stats[match] = info
+		}
+	}
+	return stats
 }
 
 // getRelativePath 获取相对路径
@@ -317,18 +414,92 @@ func (t *GlobTool) getRelativePath(filePath, basePath string) string {
 }
 
 // sortMatches 排序匹配结果
-func (t *GlobTool) sortMatches(matches []string, sortBy string) {
-	// 这里实现不同的排序逻辑
-	// 为了简化，只实现按名称排序
+// name 按路径升序；size 按大小降序；modified_time 按修改时间降序（最新的在前）
+func (t *GlobTool) sortMatches(matches []string, stats map[string]sandbox.FileInfo, sortBy string) {
 	switch sortBy {
-	case "name":
-		// 默认已经是按名称排序（使用filepath.Glob的结果）
-	case "size", "modified_time":
-		// 需要获取文件信息进行排序
-		// 这里简化处理，保持原顺序
+	case "size":
+		sort.SliceStable(matches, func(i, j int) bool {
+			si, sj := stats[matches[i]].Size, stats[matches[j]].Size
+			if si != sj {
+				return si > sj
+			}
+			return matches[i] < matches[j]
+		})
+	case "modified_time":
+		sort.SliceStable(matches, func(i, j int) bool {
+			ti, tj := stats[matches[i]].ModTime, stats[matches[j]].ModTime
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			return matches[i] < matches[j]
+		})
 	default:
-		// 默认按名称排序
+		sort.Strings(matches)
+	}
+}
+
+// globIgnorePattern 单条忽略规则
+type globIgnorePattern struct {
+	pattern string
+	negate  bool
+}
+
+// globIgnoreRules gitignore 风格的忽略规则集合
+// 支持注释、取反（!）、目录规则（末尾 /）以及锚定规则（包含 /）
+type globIgnoreRules struct {
+	patterns []globIgnorePattern
+}
+
+// parse 解析 gitignore 文件内容
+func (r *globIgnoreRules) parse(content string) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		negate := false
+		if strings.HasPrefix(line, "!") {
+			negate = true
+			line = line[1:]
+		}
+
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		// 包含 / 的规则相对根目录锚定，否则在任意层级匹配
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+
+		r.patterns = append(r.patterns, globIgnorePattern{pattern: line, negate: negate})
+	}
+}
+
+// Match 判断相对路径是否被忽略，后出现的规则优先
+func (r *globIgnoreRules) Match(relPath string) bool {
+	ignored := false
+	for _, p := range r.patterns {
+		if r.matchPattern(p.pattern, relPath) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matchPattern 匹配文件自身或其任一父目录
+func (r *globIgnoreRules) matchPattern(pattern, relPath string) bool {
+	if ok, _ := doublestar.Match(pattern, relPath); ok {
+		return true
 	}
+	if ok, _ := doublestar.Match(pattern+"/**", relPath); ok {
+		return true
+	}
+	return false
 }
 
 func (t *GlobTool) Prompt() string {
@@ -347,7 +518,11 @@ func (t *GlobTool) Prompt() string {
 - exclude_patterns: 可选参数，排除模式列表
 - include_hidden: 可选参数，是否包含隐藏文件
 - max_results: 可选参数，最大结果数量
-- sort_by: 可选参数，排序方式
+- sort_by: 可选参数，排序方式（name, size, modified_time）
+- patterns: 可选参数，额外的搜索模式，结果合并去重
+- respect_gitignore: 可选参数，默认遵循 .gitignore/.asterignore 并排除 .git 目录
+
+结果超过 max_results 时会被截断，并返回 truncated=true 和 total_found。
 
 模式示例：
 - *.go - 匹配所有Go文件
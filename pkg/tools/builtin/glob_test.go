@@ -1,9 +1,16 @@
 package builtin
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/tools"
 )
 
 func TestNewGlobTool(t *testing.T) {
@@ -451,6 +458,123 @@ func TestGlobTool_ConcurrentOperations(t *testing.T) {
 		result.SuccessCount, result.ErrorCount, result.Duration)
 }
 
+// executeGlobInLocalSandbox 在以 dir 为工作目录的本地沙箱中执行 Glob
+func executeGlobInLocalSandbox(t *testing.T, dir string, input map[string]any) map[string]any {
+	t.Helper()
+
+	sb, err := sandbox.NewLocalSandbox(&sandbox.LocalSandboxConfig{WorkDir: dir, EnforceBoundary: true})
+	if err != nil {
+		t.Fatalf("Failed to create local sandbox: %v", err)
+	}
+	defer func() { _ = sb.Dispose() }()
+
+	tool, err := NewGlobTool(nil)
+	if err != nil {
+		t.Fatalf("Failed to create Glob tool: %v", err)
+	}
+
+	ctx := context.Background()
+	result, err := tool.Execute(ctx, input, &tools.ToolContext{Signal: ctx, Sandbox: sb})
+	if err != nil {
+		t.Fatalf("Tool execution failed: %v", err)
+	}
+	return result.(map[string]any)
+}
+
+func globMatchPaths(result map[string]any) []string {
+	matches := result["matches"].([]map[string]any)
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = filepath.ToSlash(m["path"].(string))
+	}
+	return paths
+}
+
+func TestGlobTool_RespectsIgnoreFiles(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile(".gitignore", "# deps\nnode_modules/\n*.log\n!keep.log\n/build\n")
+	th.CreateTempFile(".asterignore", "secret/\n")
+	th.CreateTempFile("main.go", "package main")
+	th.CreateTempFile("src/app.go", "package src")
+	th.CreateTempFile("node_modules/pkg/index.go", "package pkg")
+	th.CreateTempFile("src/node_modules/dep.go", "package dep")
+	th.CreateTempFile("build/out.go", "package out")
+	th.CreateTempFile("src/build/keep.go", "package build")
+	th.CreateTempFile("secret/key.go", "package secret")
+	th.CreateTempFile(".git/hooks/hook.go", "package hooks")
+	th.CreateTempFile("debug.log", "log")
+	th.CreateTempFile("keep.log", "log")
+
+	result := executeGlobInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":        "**/*.go",
+		"patterns":       []any{"**/*.log"},
+		"include_hidden": true,
+	})
+	result = AssertToolSuccess(t, result)
+
+	got := globMatchPaths(result)
+	want := []string{"keep.log", "main.go", "src/app.go", "src/build/keep.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected matches %v, got %v", want, got)
+	}
+
+	// 关闭忽略规则后应能看到被忽略的文件
+	result = executeGlobInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":           "**/*.go",
+		"respect_gitignore": false,
+	})
+	result = AssertToolSuccess(t, result)
+	if n := len(globMatchPaths(result)); n != 8 {
+		t.Errorf("Expected 8 matches without ignore rules, got %d: %v", n, globMatchPaths(result))
+	}
+}
+
+func TestGlobTool_SortAndTruncate(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	names := []string{"c.txt", "a.txt", "d.txt", "b.txt"}
+	base := time.Now().Add(-time.Hour)
+	for i, name := range names {
+		path := th.CreateTempFile(name, strings.Repeat("x", i+1))
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+
+	result := executeGlobInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "*.txt",
+		"max_results": float64(3),
+	})
+	result = AssertToolSuccess(t, result)
+
+	if got := strings.Join(globMatchPaths(result), ","); got != "a.txt,b.txt,c.txt" {
+		t.Errorf("Expected name-sorted, capped matches, got %s", got)
+	}
+	if truncated, _ := result["truncated"].(bool); !truncated {
+		t.Error("Expected truncated=true when results exceed max_results")
+	}
+	if total, _ := result["total_found"].(int); total != 4 {
+		t.Errorf("Expected total_found=4, got %v", result["total_found"])
+	}
+
+	result = executeGlobInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern": "*.txt",
+		"sort_by": "modified_time",
+	})
+	result = AssertToolSuccess(t, result)
+
+	if got := strings.Join(globMatchPaths(result), ","); got != "b.txt,d.txt,a.txt,c.txt" {
+		t.Errorf("Expected newest-first order, got %s", got)
+	}
+	if truncated, _ := result["truncated"].(bool); truncated {
+		t.Error("Expected truncated=false when results fit within max_results")
+	}
+}
+
 func BenchmarkGlobTool_SimplePattern(b *testing.B) {
 	tool, err := NewGlobTool(nil)
 	if err != nil {
@@ -1,11 +1,17 @@
 package builtin
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,13 +19,34 @@ import (
 	"github.com/astercloud/aster/pkg/tools"
 )
 
+// defaultGrepMaxFileSize 默认的单文件大小上限
+const defaultGrepMaxFileSize int64 = 10 << 20
+
 // GrepTool 增强的内容搜索工具
-// 支持正则表达式内容搜索功能
-type GrepTool struct{}
+// 本地沙箱中安装了 rg 时由 rg 搜索，否则通过沙箱文件系统逐个文件搜索，只访问沙箱允许的路径
+type GrepTool struct {
+	maxFileSize int64 // 超过该大小（字节）的文件不搜索
+}
 
 // NewGrepTool 创建Grep工具
+// 支持的配置: max_file_size 单文件大小上限（字节），默认 10MB
 func NewGrepTool(config map[string]any) (tools.Tool, error) {
-	return &GrepTool{}, nil
+	tool := &GrepTool{maxFileSize: defaultGrepMaxFileSize}
+	switch size := config["max_file_size"].(type) {
+	case float64:
+		if size > 0 {
+			tool.maxFileSize = int64(size)
+		}
+	case int:
+		if size > 0 {
+			tool.maxFileSize = int64(size)
+		}
+	case int64:
+		if size > 0 {
+			tool.maxFileSize = size
+		}
+	}
+	return tool, nil
 }
 
 func (t *GrepTool) Name() string {
@@ -27,7 +54,7 @@ func (t *GrepTool) Name() string {
 }
 
 func (t *GrepTool) Description() string {
-	return "在文件内容中搜索正则表达式模式"
+	return "在文件内容中搜索正则表达式模式，返回带上下文的结构化匹配结果"
 }
 
 func (t *GrepTool) InputSchema() map[string]any {
@@ -36,7 +63,7 @@ func (t *GrepTool) InputSchema() map[string]any {
 		"properties": map[string]any{
 			"pattern": map[string]any{
 				"type":        "string",
-				"description": "要搜索的正则表达式模式",
+				"description": "要搜索的正则表达式模式（RE2 语法）",
 			},
 			"path": map[string]any{
 				"type":        "string",
@@ -44,11 +71,11 @@ func (t *GrepTool) InputSchema() map[string]any {
 			},
 			"glob": map[string]any{
 				"type":        "string",
-				"description": "文件模式过滤器，如 *.go, **/*.js",
+				"description": "文件模式过滤器，如 *.go, **/*.js；不含 / 时匹配任意子目录",
 			},
 			"file_type": map[string]any{
 				"type":        "string",
-				"description": "文件类型过滤器，如 go, js, python",
+				"description": "文件类型过滤器（扩展名），如 go, js, py",
 			},
 			"output_mode": map[string]any{
 				"type":        "string",
@@ -58,37 +85,45 @@ func (t *GrepTool) InputSchema() map[string]any {
 				"type":        "integer",
 				"description": "返回的最大结果数量，默认为50",
 			},
+			"-A": map[string]any{
+				"type":        "integer",
+				"description": "显示匹配行之后的行数",
+			},
+			"-B": map[string]any{
+				"type":        "integer",
+				"description": "显示匹配行之前的行数",
+			},
+			"-C": map[string]any{
+				"type":        "integer",
+				"description": "显示匹配行前后的行数，-A/-B 未指定时生效",
+			},
 			"context_lines": map[string]any{
 				"type":        "integer",
-				"description": "显示匹配行前后的上下文行数，默认为0",
+				"description": "同 -C，默认为0",
 			},
 			"case_insensitive": map[string]any{
 				"type":        "boolean",
 				"description": "是否忽略大小写，默认为false",
 			},
+			"-i": map[string]any{
+				"type":        "boolean",
+				"description": "同 case_insensitive",
+			},
 			"whole_word": map[string]any{
 				"type":        "boolean",
 				"description": "是否匹配完整单词，默认为false",
 			},
 			"line_numbers": map[string]any{
 				"type":        "boolean",
-				"description": "是否显示行号，默认为true",
-			},
-			"no_heading": map[string]any{
-				"type":        "boolean",
-				"description": "是否禁用文件标题，默认为false",
+				"description": "是否返回行号，默认为true",
 			},
 			"hidden": map[string]any{
 				"type":        "boolean",
 				"description": "是否搜索隐藏文件，默认为false",
 			},
-			"follow": map[string]any{
-				"type":        "boolean",
-				"description": "是否跟随符号链接，默认为false",
-			},
 			"multiline": map[string]any{
 				"type":        "boolean",
-				"description": "是否允许跨行匹配，默认为false",
+				"description": "是否允许跨行匹配（. 匹配换行），默认为false",
 			},
 		},
 		"required": []string{"pattern"},
@@ -107,13 +142,13 @@ func (t *GrepTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 	fileType := t.getStringParam(input, "file_type", "")
 	outputMode := t.getStringParam(input, "output_mode", "content")
 	maxResults := t.getIntParam(input, "max_results", 50)
-	contextLines := t.getIntParam(input, "context_lines", 0)
-	caseInsensitive := t.getBoolParam(input, "case_insensitive", false)
+	contextLines := t.getIntParam(input, "-C", t.getIntParam(input, "context_lines", 0))
+	afterLines := t.getIntParam(input, "-A", contextLines)
+	beforeLines := t.getIntParam(input, "-B", contextLines)
+	caseInsensitive := t.getBoolParam(input, "-i", t.getBoolParam(input, "case_insensitive", false))
 	wholeWord := t.getBoolParam(input, "whole_word", false)
 	lineNumbers := t.getBoolParam(input, "line_numbers", true)
-	noHeading := t.getBoolParam(input, "no_heading", false)
 	hidden := t.getBoolParam(input, "hidden", false)
-	follow := t.getBoolParam(input, "follow", false)
 	multiline := t.getBoolParam(input, "multiline", false)
 
 	if pattern == "" {
@@ -138,47 +173,111 @@ func (t *GrepTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 			"确保路径不包含 '..' 避免路径遍历攻击",
 		), nil
 	}
+	if tc == nil || tc.Sandbox == nil {
+		return NewClaudeErrorResponse(errors.New("sandbox not available")), nil
+	}
+	fs := tc.Sandbox.FS()
+	if !fs.IsInside(path) {
+		return NewClaudeErrorResponse(
+			fmt.Errorf("path outside sandbox: %s", path),
+			"只能搜索沙箱工作目录或允许的路径",
+		), nil
+	}
 
-	start := time.Now()
+	re, err := t.compilePattern(pattern, caseInsensitive, wholeWord, multiline)
+	if err != nil {
+		return NewClaudeErrorResponse(
+			fmt.Errorf("invalid pattern: %w", err),
+			"检查正则表达式语法是否正确（RE2 语法，不支持反向引用和环视）",
+		), nil
+	}
 
-	// 构建搜索命令（简化实现，使用grep或find命令）
-	command := t.buildGrepCommand(pattern, path, glob, fileType, outputMode, maxResults, contextLines, caseInsensitive, wholeWord, lineNumbers, noHeading, hidden, follow, multiline)
+	start := time.Now()
 
-	// 执行搜索
-	result, err := tc.Sandbox.Exec(ctx, command, &sandbox.ExecOptions{
-		Timeout: time.Duration(30) * time.Second,
-		WorkDir: path,
-	})
+	searcher := &grepSearcher{
+		re:          re,
+		multiline:   multiline,
+		beforeLines: max(beforeLines, 0),
+		afterLines:  max(afterLines, 0),
+		lineNumbers: lineNumbers,
+	}
+	result := newGrepResult()
 
-	duration := time.Since(start)
+	// 每个文件的结果通过 Reporter 实时推送，已达到上限时停止搜索
+	report := func(file string, matches []GrepMatch) bool {
+		if !fs.IsInside(file) {
+			return true
+		}
+		if result.limitReached(outputMode, maxResults) {
+			result.truncated = true
+			return false
+		}
+		if added := result.add(file, matches, outputMode, maxResults); added > 0 && tc.Reporter != nil {
+			tc.Reporter.Intermediate("grep_matches", map[string]any{
+				"file":    file,
+				"matches": result.matches[len(result.matches)-added:],
+			})
+		}
+		return true
+	}
+
+	searched := false
+	if rg := t.ripgrepPath(tc.Sandbox, glob, fileType); rg != "" {
+		args := t.ripgrepArgs(re, path, glob, fileType, outputMode, searcher, hidden, multiline)
+		searched = runRipgrep(ctx, rg, tc.Sandbox.WorkDir(), args, searcher, result, report)
+	}
+	if ctx.Err() != nil {
+		return NewClaudeErrorResponse(fmt.Errorf("search cancelled: %w", ctx.Err())), nil
+	}
+
+	if !searched {
+		// rg 中途失败时丢弃已有结果，重新逐文件搜索
+		*result = *newGrepResult()
+		files, err := t.listFiles(ctx, fs, path, glob, fileType, hidden)
+		if err != nil {
+			return map[string]any{
+				"ok":    false,
+				"error": fmt.Sprintf("search failed: %v", err),
+				"recommendations": []string{
+					"确认搜索路径是否存在",
+					"检查 glob 模式是否正确",
+					"验证是否有读取权限",
+				},
+				"pattern":     pattern,
+				"path":        path,
+				"duration_ms": time.Since(start).Milliseconds(),
+			}, nil
+		}
 
-	if err != nil {
-		return map[string]any{
-			"ok":    false,
-			"error": fmt.Sprintf("search failed: %v", err),
-			"recommendations": []string{
-				"检查正则表达式语法是否正确",
-				"确认搜索路径是否存在",
-				"验证是否有读取权限",
-				"检查模式是否包含特殊字符需要转义",
-				"确认系统是否安装了grep工具",
-			},
-			"pattern":     pattern,
-			"path":        path,
-			"duration_ms": duration.Milliseconds(),
-		}, nil
+		// 逐个文件搜索
+		for i, file := range files {
+			if ctx.Err() != nil {
+				return NewClaudeErrorResponse(fmt.Errorf("search cancelled: %w", ctx.Err())), nil
+			}
+			// 遵守沙箱允许的路径
+			if !fs.IsInside(file) {
+				continue
+			}
+			matches, err := t.searchFile(ctx, tc.Sandbox, searcher, file, result)
+			if err != nil {
+				continue
+			}
+			if tc.Reporter != nil {
+				tc.Reporter.Progress(float64(i+1)/float64(len(files)), file, i+1, len(files), nil, 0)
+			}
+			if len(matches) > 0 && !report(file, matches) {
+				break
+			}
+		}
 	}
 
-	// 解析结果
-	parsedResult := t.parseGrepOutput(result.Stdout, outputMode, lineNumbers, !noHeading)
-
 	// 添加元数据
 	response := map[string]any{
 		"ok":          true,
 		"pattern":     pattern,
 		"path":        path,
 		"output_mode": outputMode,
-		"duration_ms": duration.Milliseconds(),
+		"duration_ms": time.Since(start).Milliseconds(),
 	}
 
 	// 添加搜索参数
@@ -187,36 +286,27 @@ func (t *GrepTool) Execute(ctx context.Context, input map[string]any, tc *tools.
 	response["case_insensitive"] = caseInsensitive
 	response["whole_word"] = wholeWord
 	response["line_numbers"] = lineNumbers
-	response["context_lines"] = contextLines
+	response["before_context"] = searcher.beforeLines
+	response["after_context"] = searcher.afterLines
 	response["hidden"] = hidden
-	response["follow"] = follow
 	response["multiline"] = multiline
+	response["files_scanned"] = result.filesScanned
+	response["files_skipped"] = result.filesSkipped
+	response["truncated"] = result.truncated
 
 	// 添加结果
 	switch outputMode {
 	case "content":
-		response["matches"] = parsedResult.matches
-		response["total_matches"] = len(parsedResult.matches)
-		response["total_files"] = parsedResult.totalFiles
+		response["matches"] = result.matches
+		response["total_matches"] = len(result.matches)
+		response["total_files"] = len(result.files)
 	case "files_with_matches":
-		response["files"] = parsedResult.files
-		response["total_files"] = len(parsedResult.files)
+		response["files"] = result.files
+		response["total_files"] = len(result.files)
 	case "count":
-		response["file_counts"] = parsedResult.fileCounts
-		response["total_matches"] = parsedResult.totalMatches
-		response["total_files"] = len(parsedResult.fileCounts)
-	}
-
-	// 检查结果限制
-	if maxResults > 0 {
-		switch outputMode {
-		case "content":
-			response["truncated"] = len(parsedResult.matches) >= maxResults
-		case "files_with_matches":
-			response["truncated"] = len(parsedResult.files) >= maxResults
-		case "count":
-			response["truncated"] = len(parsedResult.fileCounts) >= maxResults
-		}
+		response["file_counts"] = result.fileCounts
+		response["total_matches"] = result.totalMatches
+		response["total_files"] = len(result.fileCounts)
 	}
 
 	return response, nil
@@ -243,8 +333,11 @@ func (t *GrepTool) getStringParam(input map[string]any, key string, defaultValue
 
 func (t *GrepTool) getIntParam(input map[string]any, key string, defaultValue int) int {
 	if value, exists := input[key]; exists {
-		if num, ok := value.(float64); ok {
+		switch num := value.(type) {
+		case float64:
 			return int(num)
+		case int:
+			return num
 		}
 	}
 	return defaultValue
@@ -266,191 +359,303 @@ func (t *GrepTool) validatePath(path string) error {
 	return nil
 }
 
-func (t *GrepTool) buildGrepCommand(pattern, path, glob, fileType, outputMode string, maxResults, contextLines int, caseInsensitive, wholeWord, lineNumbers, noHeading, hidden, follow, multiline bool) string {
-	var parts []string
-
-	parts = append(parts, "grep")
-
-	// 添加选项
-	if caseInsensitive {
-		parts = append(parts, "-i")
-	}
+// compilePattern 按选项编译正则表达式
+func (t *GrepTool) compilePattern(pattern string, caseInsensitive, wholeWord, multiline bool) (*regexp.Regexp, error) {
 	if wholeWord {
-		parts = append(parts, "-w")
+		pattern = `\b(?:` + pattern + `)\b`
 	}
-	if lineNumbers {
-		parts = append(parts, "-n")
-	}
-	if noHeading {
-		parts = append(parts, "--no-heading")
-	}
-	if hidden {
-		parts = append(parts, "--hidden")
-	}
-	if follow {
-		parts = append(parts, "--follow")
+	flags := ""
+	if caseInsensitive {
+		flags += "i"
 	}
 	if multiline {
-		parts = append(parts, "--multiline")
+		flags += "s"
 	}
-
-	// 上下文行数
-	if contextLines > 0 {
-		parts = append(parts, "-C", strconv.Itoa(contextLines))
-	}
-
-	// 输出模式
-	switch outputMode {
-	case "files_with_matches":
-		parts = append(parts, "-l")
-	case "count":
-		parts = append(parts, "-c")
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
 	}
+	return regexp.Compile(pattern)
+}
 
-	// 文件类型过滤
-	if fileType != "" {
-		parts = append(parts, "--include=*."+fileType)
+// listFiles 列出待搜索的文件，path 为文件时只搜索该文件
+func (t *GrepTool) listFiles(ctx context.Context, fs sandbox.SandboxFS, path, glob, fileType string, hidden bool) ([]string, error) {
+	if info, err := fs.Stat(ctx, path); err == nil && !info.IsDir {
+		return []string{path}, nil
 	}
 
-	// Glob模式
+	pattern := "**/*"
 	if glob != "" {
-		parts = append(parts, "--include="+glob)
+		pattern = glob
+		if !strings.Contains(glob, "/") {
+			pattern = "**/" + glob
+		}
 	}
 
-	// 结果限制
-	if maxResults > 0 && outputMode == "content" {
-		parts = append(parts, "-m", strconv.Itoa(maxResults))
+	files, err := fs.Glob(ctx, pattern, &sandbox.GlobOptions{
+		CWD:    path,
+		Ignore: grepIgnorePatterns,
+		Dot:    hidden,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 搜索模式
-	parts = append(parts, fmt.Sprintf("'%s'", pattern))
+	filtered := files[:0]
+	for _, file := range files {
+		if fileType != "" && !strings.EqualFold(strings.TrimPrefix(filepath.Ext(file), "."), fileType) {
+			continue
+		}
+		if !hidden && isHiddenPath(file) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	sort.Strings(filtered)
+	return filtered, nil
+}
 
-	// 搜索路径
-	parts = append(parts, path)
+// grepIgnorePatterns 默认跳过的目录
+var grepIgnorePatterns = []string{".git/**", "**/.git/**", "node_modules/**", "**/node_modules/**"}
 
-	return strings.Join(parts, " ")
+// isHiddenPath 路径中任一段以 . 开头视为隐藏文件
+func isHiddenPath(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if len(part) > 1 && strings.HasPrefix(part, ".") && part != ".." {
+			return true
+		}
+	}
+	return false
 }
 
-func (t *GrepTool) parseGrepOutput(output, outputMode string, lineNumbers, withHeading bool) *GrepResult {
-	result := &GrepResult{
-		matches:    []GrepMatch{},
-		files:      []string{},
-		fileCounts: []FileCount{},
-	}
+// grepBinaryProbeSize 检测二进制文件时读取的字节数
+const grepBinaryProbeSize = 8192
 
-	if output == "" {
-		return result
+// searchFile 搜索单个文件，跳过超过大小上限的文件和二进制文件
+// 本地沙箱直接流式读取文件，读取量不超过大小上限；其他沙箱通过 FS 读取
+func (t *GrepTool) searchFile(ctx context.Context, sb sandbox.Sandbox, searcher *grepSearcher, file string, result *grepResult) ([]GrepMatch, error) {
+	fs := sb.FS()
+	info, err := fs.Stat(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size > t.maxFileSize {
+		result.filesSkipped++
+		return nil, nil
 	}
 
-	lines := strings.Split(output, "\n")
-
-	switch outputMode {
-	case "content":
-		t.parseContentOutput(lines, result, lineNumbers)
-	case "files_with_matches":
-		t.parseFilesOutput(lines, result)
-	case "count":
-		t.parseCountOutput(lines, result)
+	var r io.Reader
+	if sb.Kind() == "local" {
+		f, err := os.Open(fs.Resolve(file))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = io.LimitReader(f, t.maxFileSize)
+	} else {
+		content, err := fs.Read(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		r = strings.NewReader(content)
 	}
 
-	return result
+	br := bufio.NewReader(r)
+	// 前 8KB 含 NUL 字节视为二进制文件
+	if head, _ := br.Peek(grepBinaryProbeSize); bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+	result.filesScanned++
+	return searcher.search(file, br)
 }
 
-func (t *GrepTool) parseContentOutput(lines []string, result *GrepResult, lineNumbers bool) {
-	currentFile := ""
+// grepSearcher 在单个文件内搜索
+type grepSearcher struct {
+	re          *regexp.Regexp
+	multiline   bool
+	beforeLines int
+	afterLines  int
+	lineNumbers bool
+}
 
-	for _, line := range lines {
-		if line == "" {
-			continue
+// search 逐行读取并匹配，只保留上下文所需的行；跨行匹配时需要读取整个文件
+func (s *grepSearcher) search(file string, br *bufio.Reader) ([]GrepMatch, error) {
+	if s.multiline {
+		content, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return s.searchContent(file, string(content)), nil
+	}
+
+	var (
+		matches []GrepMatch
+		before  []GrepContextLine // 最近的 beforeLines 行
+		pending []int             // 仍在收集之后上下文的匹配
+	)
+	for lineNumber := 1; ; lineNumber++ {
+		text, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
 		}
+		if text == "" && err == io.EOF {
+			break
+		}
+		text = strings.TrimSuffix(text, "\n")
+		line := s.contextLine(lineNumber, text)
+
+		remaining := pending[:0]
+		for _, idx := range pending {
+			matches[idx].After = append(matches[idx].After, line)
+			if len(matches[idx].After) < s.afterLines {
+				remaining = append(remaining, idx)
+			}
+		}
+		pending = remaining
 
-		// 简化的解析逻辑
-		if strings.HasPrefix(line, "./") {
-			// 文件路径行
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) >= 2 {
-				currentFile = parts[0]
-				remaining := strings.Join(parts[1:], ":")
-
-				// 提取行号和内容
-				if lineNumbers {
-					contentParts := strings.SplitN(remaining, ":", 2)
-					if len(contentParts) >= 2 {
-						lineNumStr := strings.TrimSpace(contentParts[0])
-						var lineNum int
-						_, _ = fmt.Sscanf(lineNumStr, "%d", &lineNum)
-						content := contentParts[1]
-
-						result.matches = append(result.matches, GrepMatch{
-							File:    currentFile,
-							Line:    lineNum,
-							Content: strings.TrimSpace(content),
-						})
-					}
-				}
+		if s.re.MatchString(text) {
+			match := GrepMatch{File: file, Line: text, Before: slices.Clone(before)}
+			if s.lineNumbers {
+				match.LineNumber = lineNumber
+			}
+			matches = append(matches, match)
+			if s.afterLines > 0 {
+				pending = append(pending, len(matches)-1)
 			}
-		} else if currentFile != "" {
-			// 内容行（可能是上下文）
-			result.matches = append(result.matches, GrepMatch{
-				File:    currentFile,
-				Line:    0,
-				Content: strings.TrimSpace(line),
-			})
 		}
-	}
-}
 
-func (t *GrepTool) parseFilesOutput(lines []string, result *GrepResult) {
-	for _, line := range lines {
-		if line != "" {
-			if !t.containsString(result.files, line) {
-				result.files = append(result.files, strings.TrimSpace(line))
+		if s.beforeLines > 0 {
+			before = append(before, line)
+			if len(before) > s.beforeLines {
+				before = before[1:]
 			}
 		}
+		if err == io.EOF {
+			break
+		}
 	}
+	return matches, nil
 }
 
-func (t *GrepTool) parseCountOutput(lines []string, result *GrepResult) {
-	for _, line := range lines {
-		if line == "" {
-			continue
+// searchContent 在整个文件内容中跨行匹配，报告匹配起始行
+func (s *grepSearcher) searchContent(file, content string) []GrepMatch {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	lineStarts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		lineStarts[i] = offset
+		offset += len(line) + 1
+	}
+	var matchLines []int
+	for _, loc := range s.re.FindAllStringIndex(content, -1) {
+		idx := sort.SearchInts(lineStarts, loc[0]+1) - 1
+		if len(matchLines) == 0 || matchLines[len(matchLines)-1] != idx {
+			matchLines = append(matchLines, idx)
 		}
+	}
 
-		if strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				file := strings.TrimSpace(parts[0])
-				countStr := strings.TrimSpace(parts[1])
-				var count int
-				_, _ = fmt.Sscanf(countStr, "%d", &count)
-
-				result.fileCounts = append(result.fileCounts, FileCount{
-					File:  file,
-					Count: count,
-				})
-				result.totalMatches += count
-			}
+	matches := make([]GrepMatch, 0, len(matchLines))
+	for _, idx := range matchLines {
+		match := GrepMatch{
+			File:   file,
+			Line:   lines[idx],
+			Before: s.contextLines(lines, idx-s.beforeLines, idx),
+			After:  s.contextLines(lines, idx+1, idx+1+s.afterLines),
+		}
+		if s.lineNumbers {
+			match.LineNumber = idx + 1
 		}
+		matches = append(matches, match)
 	}
+	return matches
 }
 
-func (t *GrepTool) containsString(slice []string, item string) bool {
-	return slices.Contains(slice, item)
+// contextLine 构造一行上下文，未启用行号时不填行号
+func (s *grepSearcher) contextLine(lineNumber int, text string) GrepContextLine {
+	line := GrepContextLine{Line: text}
+	if s.lineNumbers {
+		line.LineNumber = lineNumber
+	}
+	return line
+}
+
+// contextLines 返回 [from, to) 范围内的上下文行
+func (s *grepSearcher) contextLines(lines []string, from, to int) []GrepContextLine {
+	from, to = max(from, 0), min(to, len(lines))
+	if from >= to {
+		return nil
+	}
+	result := make([]GrepContextLine, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, s.contextLine(i+1, lines[i]))
+	}
+	return result
 }
 
-// 数据结构
-type GrepResult struct {
+// grepResult 搜索结果汇总
+type grepResult struct {
 	matches      []GrepMatch
 	files        []string
 	fileCounts   []FileCount
-	totalFiles   int
 	totalMatches int
+	filesScanned int
+	filesSkipped int // 超过大小上限而跳过的文件
+	truncated    bool
+}
+
+func newGrepResult() *grepResult {
+	return &grepResult{
+		matches:    []GrepMatch{},
+		files:      []string{},
+		fileCounts: []FileCount{},
+	}
 }
 
+// limitReached 检查是否已达到结果上限
+func (r *grepResult) limitReached(outputMode string, maxResults int) bool {
+	if maxResults <= 0 {
+		return false
+	}
+	switch outputMode {
+	case "content":
+		return len(r.matches) >= maxResults
+	case "files_with_matches":
+		return len(r.files) >= maxResults
+	default:
+		return len(r.fileCounts) >= maxResults
+	}
+}
+
+// add 记录一个文件的匹配，返回新增到 matches 的数量
+func (r *grepResult) add(file string, matches []GrepMatch, outputMode string, maxResults int) int {
+	r.files = append(r.files, file)
+	r.fileCounts = append(r.fileCounts, FileCount{File: file, Count: len(matches)})
+	r.totalMatches += len(matches)
+
+	if outputMode != "content" {
+		return 0
+	}
+	if maxResults > 0 && len(r.matches)+len(matches) > maxResults {
+		matches = matches[:maxResults-len(r.matches)]
+		r.truncated = true
+	}
+	r.matches = append(r.matches, matches...)
+	return len(matches)
+}
+
+// GrepMatch 一条匹配结果
 type GrepMatch struct {
-	File    string `json:"file"`
-	Line    int    `json:"line"`
-	Content string `json:"content"`
+	File       string            `json:"file"`
+	LineNumber int               `json:"line_number,omitempty"`
+	Line       string            `json:"line"`
+	Before     []GrepContextLine `json:"before,omitempty"`
+	After      []GrepContextLine `json:"after,omitempty"`
+}
+
+// GrepContextLine 匹配行前后的上下文行
+type GrepContextLine struct {
+	LineNumber int    `json:"line_number,omitempty"`
+	Line       string `json:"line"`
 }
 
 type FileCount struct {
@@ -459,22 +664,28 @@ type FileCount struct {
 }
 
 func (t *GrepTool) Prompt() string {
-	return `在文件内容中搜索正则表达式模式。
+	return `在文件内容中搜索正则表达式模式，返回结构化的匹配结果。
 
 功能特性：
-- 正则表达式模式搜索
+- 正则表达式模式搜索（RE2 语法）
 - 支持多种输出模式
-- 上下文行显示
-- 文件类型过滤
+- 上下文行显示（-A/-B/-C）
+- glob 与文件类型过滤
 - 大小写敏感选项
 
 使用指南：
 - pattern: 必需参数，搜索的正则表达式
-- path: 可选参数，搜索路径
+- path: 可选参数，搜索的文件或目录
+- glob: 可选参数，文件模式过滤，如 *.go
 - output_mode: 可选参数，输出模式（content/files_with_matches/count）
-- max_results: 可选参数，最大结果数
-- case_insensitive: 可选参数，忽略大小写
-- line_numbers: 可选参数，显示行号
+- max_results: 可选参数，最大结果数，达到上限时 truncated 为 true
+- -A/-B/-C: 可选参数，匹配行之后/之前/前后的上下文行数
+- -i 或 case_insensitive: 可选参数，忽略大小写
+- line_numbers: 可选参数，返回行号
+
+返回结果：
+- content 模式下 matches 为匹配列表，每项包含 file、line_number、line 以及 before/after 上下文
+- 每个文件的匹配会在搜索过程中实时推送
 
 正则表达式示例：
 - "function\s+\w+" - 匹配函数定义
@@ -483,9 +694,8 @@ func (t *GrepTool) Prompt() string {
 
 安全性：
 - 路径遍历攻击防护
-- 命令注入防护
-- 沙箱环境隔离
-- 超时控制`
+- 只搜索沙箱允许的路径
+- 跳过二进制文件、超过大小上限的文件、.git 和 node_modules`
 }
 
 // Examples 返回 Grep 工具的使用示例
@@ -502,19 +712,18 @@ func (t *GrepTool) Examples() []tools.ToolExample {
 		{
 			Description: "搜索函数定义并显示上下文",
 			Input: map[string]any{
-				"pattern":          "func\\s+\\w+\\(",
-				"path":             "/app/src",
-				"output_mode":      "content",
-				"context_lines":    3,
-				"case_insensitive": false,
+				"pattern":     "func\\s+\\w+\\(",
+				"path":        "/app/src",
+				"output_mode": "content",
+				"-C":          3,
 			},
 		},
 		{
 			Description: "统计每个文件中 error 出现的次数",
 			Input: map[string]any{
-				"pattern":          "error",
-				"output_mode":      "count",
-				"case_insensitive": true,
+				"pattern":     "error",
+				"output_mode": "count",
+				"-i":          true,
 			},
 		},
 		{
@@ -522,7 +731,7 @@ func (t *GrepTool) Examples() []tools.ToolExample {
 			Input: map[string]any{
 				"pattern":     "import\\s+\"",
 				"path":        "/app",
-				"file_types":  []string{"go"},
+				"glob":        "*.go",
 				"output_mode": "content",
 				"max_results": 50,
 			},
@@ -0,0 +1,204 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+)
+
+// lookupRipgrep 查找 rg 可执行文件，未安装时返回空字符串
+var lookupRipgrep = func() string {
+	path, err := exec.LookPath("rg")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// ripgrepPath 返回可用于本次搜索的 rg 路径
+// 只在本地沙箱中使用；glob 含 / 或同时指定 glob 和 file_type 时 rg 的语义不同，回退到逐文件搜索
+func (t *GrepTool) ripgrepPath(sb sandbox.Sandbox, glob, fileType string) string {
+	if sb.Kind() != "local" || strings.Contains(glob, "/") || (glob != "" && fileType != "") {
+		return ""
+	}
+	return lookupRipgrep()
+}
+
+// ripgrepArgs 构造 rg 参数
+// 直接使用已编译的正则，大小写、整词和跨行选项已包含在表达式中，与逐文件搜索的结果一致
+func (t *GrepTool) ripgrepArgs(re *regexp.Regexp, path, glob, fileType, outputMode string, searcher *grepSearcher, hidden, multiline bool) []string {
+	args := []string{
+		"--json", "--no-config", "--no-ignore", "--sort", "path",
+		"--max-filesize", strconv.FormatInt(t.maxFileSize, 10),
+		"--glob", "!.git", "--glob", "!node_modules",
+	}
+	if hidden {
+		args = append(args, "--hidden")
+	}
+	if multiline {
+		args = append(args, "--multiline")
+	}
+	if outputMode == "content" {
+		args = append(args,
+			"--after-context", strconv.Itoa(searcher.afterLines),
+			"--before-context", strconv.Itoa(searcher.beforeLines))
+	}
+	if glob != "" {
+		args = append(args, "--glob", glob)
+	}
+	if fileType != "" {
+		args = append(args, "--iglob", "*."+fileType)
+	}
+	return append(args, "--regexp", re.String(), "--", path)
+}
+
+// rgMessage rg --json 输出的一行
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgData `json:"path"`
+		Lines      rgData `json:"lines"`
+		LineNumber int    `json:"line_number"`
+		Stats      struct {
+			Searches int `json:"searches"`
+		} `json:"stats"`
+	} `json:"data"`
+}
+
+// rgData rg 输出的文本，非 UTF-8 内容以 base64 放在 bytes 中
+type rgData struct {
+	Text  string `json:"text"`
+	Bytes string `json:"bytes"`
+}
+
+func (d rgData) String() string {
+	if d.Bytes != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(d.Bytes); err == nil {
+			return string(decoded)
+		}
+	}
+	return d.Text
+}
+
+// runRipgrep 在 workDir 中运行 rg 并流式解析结果，每个文件结束时交给 report，report 返回 false 时停止 rg
+// 返回 false 表示 rg 未能完成搜索，调用方应回退到逐文件搜索
+func runRipgrep(ctx context.Context, rg, workDir string, args []string, searcher *grepSearcher, result *grepResult, report func(file string, matches []GrepMatch) bool) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, rg, args...)
+	cmd.Dir = workDir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false
+	}
+	if err := cmd.Start(); err != nil {
+		return false
+	}
+
+	var (
+		file         string
+		lines        map[int]string
+		matchNumbers []int
+		stopped      bool
+		finished     bool
+	)
+	br := bufio.NewReader(stdout)
+	for !stopped {
+		raw, err := br.ReadBytes('\n')
+		if len(raw) > 0 {
+			var msg rgMessage
+			if json.Unmarshal(raw, &msg) == nil {
+				switch msg.Type {
+				case "begin":
+					file = ripgrepRelPath(workDir, msg.Data.Path.String())
+					lines = make(map[int]string)
+					matchNumbers = matchNumbers[:0]
+				case "match", "context":
+					// 跨行匹配的 lines 包含多行，每行都可能作为其他匹配的上下文
+					text := strings.TrimSuffix(msg.Data.Lines.String(), "\n")
+					for i, line := range strings.Split(text, "\n") {
+						lines[msg.Data.LineNumber+i] = line
+					}
+					if msg.Type == "match" && !slices.Contains(matchNumbers, msg.Data.LineNumber) {
+						matchNumbers = append(matchNumbers, msg.Data.LineNumber)
+					}
+				case "end":
+					if len(matchNumbers) > 0 {
+						stopped = !report(file, searcher.ripgrepMatches(file, lines, matchNumbers))
+					}
+				case "summary":
+					result.filesScanned = msg.Data.Stats.Searches
+					finished = true
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if stopped {
+		cancel()
+	}
+	waitErr := cmd.Wait()
+	if stopped {
+		return true
+	}
+	// 退出码 1 表示没有匹配，2 表示部分文件出错但已完成搜索
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		return false
+	}
+	return finished
+}
+
+// ripgrepMatches 根据一个文件的匹配行和上下文行构造匹配结果
+func (s *grepSearcher) ripgrepMatches(file string, lines map[int]string, matchNumbers []int) []GrepMatch {
+	matches := make([]GrepMatch, 0, len(matchNumbers))
+	for _, n := range matchNumbers {
+		match := GrepMatch{
+			File:   file,
+			Line:   lines[n],
+			Before: s.ripgrepContext(lines, n-s.beforeLines, n),
+			After:  s.ripgrepContext(lines, n+1, n+1+s.afterLines),
+		}
+		if s.lineNumbers {
+			match.LineNumber = n
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// ripgrepContext 返回 [from, to) 范围内 rg 输出过的行
+func (s *grepSearcher) ripgrepContext(lines map[int]string, from, to int) []GrepContextLine {
+	var result []GrepContextLine
+	for n := max(from, 1); n < to; n++ {
+		if line, ok := lines[n]; ok {
+			result = append(result, s.contextLine(n, line))
+		}
+	}
+	return result
+}
+
+// ripgrepRelPath 将 rg 输出的路径转换为相对工作目录的路径，与沙箱 Glob 的结果一致
+func ripgrepRelPath(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+		return path
+	}
+	return filepath.Clean(path)
+}
@@ -1,10 +1,18 @@
 package builtin
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/astercloud/aster/pkg/sandbox"
+	"github.com/astercloud/aster/pkg/tools"
 )
 
 func TestNewGrepTool(t *testing.T) {
@@ -464,3 +472,321 @@ func BenchmarkGrepTool_ComplexPattern(b *testing.B) {
 
 	BenchmarkTool(b, tool, input)
 }
+
+// grepTestReporter 记录 Grep 推送的中间结果
+type grepTestReporter struct {
+	mu    sync.Mutex
+	files []string
+}
+
+func (r *grepTestReporter) Progress(float64, string, int, int, map[string]any, int64) {}
+
+func (r *grepTestReporter) Intermediate(label string, data any) {
+	if label != "grep_matches" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, data.(map[string]any)["file"].(string))
+}
+
+// executeGrepInLocalSandbox 在以 dir 为工作目录的本地沙箱中执行 Grep
+func executeGrepInLocalSandbox(t *testing.T, dir string, input map[string]any, reporter tools.Reporter) map[string]any {
+	t.Helper()
+
+	tool, err := NewGrepTool(nil)
+	if err != nil {
+		t.Fatalf("Failed to create Grep tool: %v", err)
+	}
+	return executeToolInLocalSandbox(t, dir, tool, input, reporter)
+}
+
+// executeToolInLocalSandbox 在以 dir 为工作目录的本地沙箱中执行工具
+func executeToolInLocalSandbox(t *testing.T, dir string, tool tools.Tool, input map[string]any, reporter tools.Reporter) map[string]any {
+	t.Helper()
+
+	sb, err := sandbox.NewLocalSandbox(&sandbox.LocalSandboxConfig{WorkDir: dir, EnforceBoundary: true})
+	if err != nil {
+		t.Fatalf("Failed to create local sandbox: %v", err)
+	}
+	defer func() { _ = sb.Dispose() }()
+
+	ctx := context.Background()
+	result, err := tool.Execute(ctx, input, &tools.ToolContext{Signal: ctx, Sandbox: sb, Reporter: reporter})
+	if err != nil {
+		t.Fatalf("Tool execution failed: %v", err)
+	}
+	return result.(map[string]any)
+}
+
+func TestGrepTool_StructuredMatchesWithContext(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile("main.go", "package main\n\nfunc main() {\n\t// TODO: run\n}\n")
+	th.CreateTempFile("src/util.go", "package src\n// todo: cleanup\nfunc util() {}\n")
+	th.CreateTempFile("README.md", "TODO: docs\n")
+	th.CreateTempFile("node_modules/dep/index.go", "// TODO: vendored\n")
+	th.CreateTempFile(".hidden/secret.go", "// TODO: hidden\n")
+
+	reporter := &grepTestReporter{}
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern": "todo",
+		"glob":    "*.go",
+		"-i":      true,
+		"-B":      float64(1),
+		"-A":      float64(1),
+	}, reporter)
+	result = AssertToolSuccess(t, result)
+
+	matches := result["matches"].([]GrepMatch)
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %+v", matches)
+	}
+
+	first := matches[0]
+	if first.File != "main.go" || first.LineNumber != 4 || first.Line != "\t// TODO: run" {
+		t.Errorf("Unexpected first match: %+v", first)
+	}
+	if len(first.Before) != 1 || first.Before[0].LineNumber != 3 || first.Before[0].Line != "func main() {" {
+		t.Errorf("Unexpected before context: %+v", first.Before)
+	}
+	if len(first.After) != 1 || first.After[0].LineNumber != 5 || first.After[0].Line != "}" {
+		t.Errorf("Unexpected after context: %+v", first.After)
+	}
+
+	second := matches[1]
+	if filepath.ToSlash(second.File) != "src/util.go" || second.LineNumber != 2 {
+		t.Errorf("Unexpected second match: %+v", second)
+	}
+	if len(second.Before) != 1 || len(second.After) != 1 {
+		t.Errorf("Expected one context line on each side, got %+v", second)
+	}
+
+	if len(reporter.files) != 2 {
+		t.Errorf("Expected matches to be streamed per file, got %v", reporter.files)
+	}
+	if result["truncated"] != false {
+		t.Errorf("Expected truncated false, got %v", result["truncated"])
+	}
+}
+
+func TestGrepTool_CaseSensitiveAndContextDefault(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile("a.txt", "one\nError here\nerror there\nfour\n")
+
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern": "error",
+		"-C":      float64(2),
+	}, nil)
+	result = AssertToolSuccess(t, result)
+
+	matches := result["matches"].([]GrepMatch)
+	if len(matches) != 1 || matches[0].LineNumber != 3 {
+		t.Fatalf("Expected only the lowercase match, got %+v", matches)
+	}
+	if len(matches[0].Before) != 2 || len(matches[0].After) != 1 {
+		t.Errorf("Expected context clipped at file end, got %+v", matches[0])
+	}
+}
+
+func TestGrepTool_MaxResultsTruncates(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile("a.txt", "x\nx\nx\n")
+	th.CreateTempFile("b.txt", "x\n")
+
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "x",
+		"max_results": float64(2),
+	}, nil)
+	result = AssertToolSuccess(t, result)
+
+	if matches := result["matches"].([]GrepMatch); len(matches) != 2 {
+		t.Errorf("Expected 2 matches, got %d", len(matches))
+	}
+	if result["truncated"] != true {
+		t.Errorf("Expected truncated true, got %v", result["truncated"])
+	}
+
+	result = executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "x",
+		"output_mode": "count",
+	}, nil)
+	result = AssertToolSuccess(t, result)
+	if result["total_matches"] != 4 {
+		t.Errorf("Expected 4 total matches, got %v", result["total_matches"])
+	}
+}
+
+func TestGrepTool_RespectsSandboxBoundary(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("password\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	th.CreateTempFile("a.txt", "password\n")
+
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern": "password",
+		"path":    outside,
+	}, nil)
+	if msg := AssertToolError(t, result); !strings.Contains(msg, "outside sandbox") {
+		t.Errorf("Expected outside sandbox error, got %q", msg)
+	}
+
+	result = executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "password",
+		"output_mode": "files_with_matches",
+	}, nil)
+	result = AssertToolSuccess(t, result)
+	if files := result["files"].([]string); len(files) != 1 || files[0] != "a.txt" {
+		t.Errorf("Expected only a.txt, got %v", files)
+	}
+}
+
+func TestGrepTool_InvalidRegex(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{"pattern": "("}, nil)
+	if msg := AssertToolError(t, result); !strings.Contains(msg, "invalid pattern") {
+		t.Errorf("Expected invalid pattern error, got %q", msg)
+	}
+}
+
+func TestGrepTool_SkipsLargeFiles(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile("small.txt", "needle\n")
+	th.CreateTempFile("large.txt", "needle\n"+strings.Repeat("x", 64)+"\n")
+
+	tool, err := NewGrepTool(map[string]any{"max_file_size": float64(32)})
+	if err != nil {
+		t.Fatalf("Failed to create Grep tool: %v", err)
+	}
+	result := executeToolInLocalSandbox(t, th.TmpDir, tool, map[string]any{
+		"pattern":     "needle",
+		"output_mode": "files_with_matches",
+	}, nil)
+	result = AssertToolSuccess(t, result)
+
+	if files := result["files"].([]string); len(files) != 1 || files[0] != "small.txt" {
+		t.Errorf("Expected only small.txt, got %v", files)
+	}
+	if result["files_skipped"] != 1 {
+		t.Errorf("Expected 1 skipped file, got %v", result["files_skipped"])
+	}
+}
+
+// fakeRipgrep 用输出固定 JSON 的脚本代替 rg，返回记录参数的文件路径
+func fakeRipgrep(t *testing.T, output string, exitCode int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	outputFile := filepath.Join(dir, "output")
+	if err := os.WriteFile(outputFile, []byte(output), 0o644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %q\ncat %q\nexit %d\n", argsFile, outputFile, exitCode)
+	rg := filepath.Join(dir, "rg")
+	if err := os.WriteFile(rg, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	orig := lookupRipgrep
+	lookupRipgrep = func() string { return rg }
+	t.Cleanup(func() { lookupRipgrep = orig })
+	return argsFile
+}
+
+func TestGrepTool_Ripgrep(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	argsFile := fakeRipgrep(t, `{"type":"begin","data":{"path":{"text":"./src/a.go"}}}
+{"type":"context","data":{"path":{"text":"./src/a.go"},"lines":{"text":"package src\n"},"line_number":1}}
+{"type":"match","data":{"path":{"text":"./src/a.go"},"lines":{"text":"// TODO: one\n"},"line_number":2}}
+{"type":"match","data":{"path":{"text":"./src/a.go"},"lines":{"text":"// todo: two\n"},"line_number":3}}
+{"type":"context","data":{"path":{"text":"./src/a.go"},"lines":{"text":"func a() {}\n"},"line_number":4}}
+{"type":"end","data":{"path":{"text":"./src/a.go"}}}
+{"type":"begin","data":{"path":{"text":"./src/b.go"}}}
+{"type":"match","data":{"path":{"text":"./src/b.go"},"lines":{"text":"// TODO: three\n"},"line_number":1}}
+{"type":"end","data":{"path":{"text":"./src/b.go"}}}
+{"type":"summary","data":{"stats":{"searches":5}}}
+`, 0)
+
+	reporter := &grepTestReporter{}
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern": "todo",
+		"glob":    "*.go",
+		"-i":      true,
+		"-C":      float64(1),
+	}, reporter)
+	result = AssertToolSuccess(t, result)
+
+	want := []GrepMatch{
+		{
+			File: filepath.Join("src", "a.go"), LineNumber: 2, Line: "// TODO: one",
+			Before: []GrepContextLine{{LineNumber: 1, Line: "package src"}},
+			After:  []GrepContextLine{{LineNumber: 3, Line: "// todo: two"}},
+		},
+		{
+			File: filepath.Join("src", "a.go"), LineNumber: 3, Line: "// todo: two",
+			Before: []GrepContextLine{{LineNumber: 2, Line: "// TODO: one"}},
+			After:  []GrepContextLine{{LineNumber: 4, Line: "func a() {}"}},
+		},
+		{File: filepath.Join("src", "b.go"), LineNumber: 1, Line: "// TODO: three"},
+	}
+	if matches := result["matches"].([]GrepMatch); !reflect.DeepEqual(matches, want) {
+		t.Errorf("Unexpected matches:\n got %+v\nwant %+v", matches, want)
+	}
+	if result["files_scanned"] != 5 || len(reporter.files) != 2 {
+		t.Errorf("Expected 5 files scanned and 2 streamed, got %v %v", result["files_scanned"], reporter.files)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read args: %v", err)
+	}
+	for _, arg := range []string{"--json", "--max-filesize\n10485760", "--glob\n*.go", "--regexp\n(?i)todo\n--\n."} {
+		if !strings.Contains(string(args), arg) {
+			t.Errorf("Expected rg args to contain %q, got:\n%s", arg, args)
+		}
+	}
+
+	// 达到上限后停止读取
+	result = executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "todo",
+		"output_mode": "files_with_matches",
+		"max_results": float64(1),
+	}, nil)
+	result = AssertToolSuccess(t, result)
+	if files := result["files"].([]string); len(files) != 1 || result["truncated"] != true {
+		t.Errorf("Expected 1 file and truncated, got %v %v", files, result["truncated"])
+	}
+}
+
+func TestGrepTool_RipgrepFallback(t *testing.T) {
+	th := NewTestHelper(t)
+	defer th.CleanupAll()
+
+	th.CreateTempFile("a.txt", "needle\n")
+	fakeRipgrep(t, "", 2)
+
+	result := executeGrepInLocalSandbox(t, th.TmpDir, map[string]any{
+		"pattern":     "needle",
+		"output_mode": "files_with_matches",
+	}, nil)
+	result = AssertToolSuccess(t, result)
+	if files := result["files"].([]string); len(files) != 1 || files[0] != "a.txt" {
+		t.Errorf("Expected fallback to find a.txt, got %v", files)
+	}
+}
@@ -0,0 +1,438 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedHTMLElements 转换时整体丢弃的元素（脚本、样式及不可见内容）
+var skippedHTMLElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// blockHTMLElements 按段落分隔的块级元素
+var blockHTMLElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Header:     true,
+	atom.Footer:     true,
+	atom.Nav:        true,
+	atom.Aside:      true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Form:       true,
+	atom.Fieldset:   true,
+	atom.Address:    true,
+	atom.Details:    true,
+	atom.Summary:    true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlToMarkdown 将 HTML 转换为 Markdown，丢弃脚本和样式，相对链接按 base 解析为绝对地址
+// 返回页面标题和正文
+func htmlToMarkdown(r io.Reader, base *url.URL) (title, markdown string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", fmt.Errorf("parse html: %w", err)
+	}
+
+	c := &markdownConverter{base: base}
+	if n := findHTMLElement(doc, atom.Title); n != nil {
+		title = strings.Join(strings.Fields(htmlTextContent(n)), " ")
+	}
+	return title, c.render(doc), nil
+}
+
+// markdownConverter HTML 到 Markdown 的转换器
+type markdownConverter struct {
+	base *url.URL
+}
+
+// render 渲染节点的子节点并整理空白
+func (c *markdownConverter) render(n *html.Node) string {
+	w := &markdownBuffer{}
+	c.children(n, w)
+	return normalizeMarkdown(string(w.buf))
+}
+
+// inline 将节点内容渲染为单行文本
+func (c *markdownConverter) inline(n *html.Node) string {
+	w := &markdownBuffer{}
+	c.children(n, w)
+	return strings.Join(strings.Fields(string(w.buf)), " ")
+}
+
+func (c *markdownConverter) children(n *html.Node, w *markdownBuffer) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child, w)
+	}
+}
+
+func (c *markdownConverter) node(n *html.Node, w *markdownBuffer) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(collapseWhitespace(n.Data))
+	case html.DocumentNode:
+		c.children(n, w)
+	case html.ElementNode:
+		c.element(n, w)
+	}
+}
+
+func (c *markdownConverter) element(n *html.Node, w *markdownBuffer) {
+	if skippedHTMLElements[n.DataAtom] {
+		return
+	}
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		if text := c.inline(n); text != "" {
+			w.block()
+			w.raw(strings.Repeat("#", level) + " " + text)
+			w.block()
+		}
+		return
+	}
+	if blockHTMLElements[n.DataAtom] {
+		w.block()
+		c.children(n, w)
+		w.block()
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.newline()
+	case atom.Hr:
+		w.block()
+		w.raw("---")
+		w.block()
+	case atom.Strong, atom.B:
+		c.wrap(n, w, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, w, "*")
+	case atom.Del, atom.S:
+		c.wrap(n, w, "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		if text := strings.TrimSpace(htmlTextContent(n)); text != "" {
+			w.inline("`" + text + "`")
+		}
+	case atom.Pre:
+		w.block()
+		w.raw("```\n" + strings.Trim(htmlTextContent(n), "\n") + "\n```")
+		w.block()
+	case atom.A:
+		c.link(n, w)
+	case atom.Img:
+		if src := c.resolve(htmlAttr(n, "src")); src != "" {
+			w.inline(fmt.Sprintf("![%s](%s)", strings.TrimSpace(htmlAttr(n, "alt")), src))
+		}
+	case atom.Ul, atom.Ol:
+		w.block()
+		c.list(n, w)
+		w.block()
+	case atom.Blockquote:
+		content := c.render(n)
+		if content == "" {
+			return
+		}
+		w.block()
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		w.raw(strings.Join(lines, "\n"))
+		w.block()
+	case atom.Table:
+		w.block()
+		c.table(n, w)
+		w.block()
+	case atom.Li, atom.Tr:
+		w.newline()
+		c.children(n, w)
+		w.newline()
+	case atom.Td, atom.Th:
+		w.text(" ")
+		c.children(n, w)
+		w.text(" ")
+	default:
+		c.children(n, w)
+	}
+}
+
+// wrap 用标记包裹行内内容，如 **粗体**
+func (c *markdownConverter) wrap(n *html.Node, w *markdownBuffer, marker string) {
+	if text := c.inline(n); text != "" {
+		w.inline(marker + text + marker)
+	}
+}
+
+func (c *markdownConverter) link(n *html.Node, w *markdownBuffer) {
+	text := c.inline(n)
+	href := htmlAttr(n, "href")
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "javascript:") {
+		w.text(text)
+		return
+	}
+	href = c.resolve(href)
+	if text == "" {
+		text = href
+	}
+	w.inline("[" + text + "](" + href + ")")
+}
+
+func (c *markdownConverter) list(n *html.Node, w *markdownBuffer) {
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		content := c.render(li)
+		if content == "" {
+			continue
+		}
+		prefix := "- "
+		if ordered {
+			prefix = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		indent := strings.Repeat(" ", len(prefix))
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			switch {
+			case i == 0:
+				lines[i] = prefix + line
+			case line != "":
+				lines[i] = indent + line
+			}
+		}
+		w.raw(strings.Join(lines, "\n"))
+		w.newline()
+	}
+}
+
+func (c *markdownConverter) table(n *html.Node, w *markdownBuffer) {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						cells = append(cells, strings.ReplaceAll(c.inline(cell), "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(child)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	writeRow := func(cells []string) {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		w.raw("| " + strings.Join(cells, " | ") + " |")
+		w.newline()
+	}
+	writeRow(rows[0])
+	separator := make([]string, columns)
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeRow(separator)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+}
+
+// resolve 将相对地址解析为绝对地址
+func (c *markdownConverter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || c.base == nil {
+		return ref
+	}
+	u, err := c.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// markdownBuffer 处理行首空白和段落分隔的输出缓冲
+type markdownBuffer struct {
+	buf []byte
+}
+
+func (w *markdownBuffer) atLineStart() bool {
+	return len(w.buf) == 0 || w.buf[len(w.buf)-1] == '\n'
+}
+
+func (w *markdownBuffer) trimTrailingSpaces() {
+	for len(w.buf) > 0 && w.buf[len(w.buf)-1] == ' ' {
+		w.buf = w.buf[:len(w.buf)-1]
+	}
+}
+
+func (w *markdownBuffer) raw(s string) {
+	w.buf = append(w.buf, s...)
+}
+
+// text 写入已折叠空白的文本，去掉行首和重复的空格
+func (w *markdownBuffer) text(s string) {
+	if w.atLineStart() || w.buf[len(w.buf)-1] == ' ' {
+		s = strings.TrimLeft(s, " ")
+	}
+	w.raw(s)
+}
+
+// inline 写入行内 Markdown 片段
+func (w *markdownBuffer) inline(s string) {
+	w.raw(s)
+}
+
+func (w *markdownBuffer) newline() {
+	w.trimTrailingSpaces()
+	w.raw("\n")
+}
+
+// block 结束当前段落
+func (w *markdownBuffer) block() {
+	w.trimTrailingSpaces()
+	if len(w.buf) == 0 {
+		return
+	}
+	for !strings.HasSuffix(string(w.buf), "\n\n") {
+		w.raw("\n")
+	}
+}
+
+// normalizeMarkdown 去掉行尾空白并合并连续空行，代码块内容保持不变
+func normalizeMarkdown(s string) string {
+	var out []string
+	inFence := false
+	blank := false
+	for line := range strings.SplitSeq(s, "\n") {
+		fence := strings.HasPrefix(line, "```")
+		if inFence && !fence {
+			out = append(out, line)
+			continue
+		}
+		if fence {
+			inFence = !inFence
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// collapseWhitespace 将连续空白折叠为单个空格
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// htmlTextContent 返回节点内的全部文本
+func htmlTextContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		if node.Type == html.ElementNode && node.DataAtom == atom.Br {
+			b.WriteByte('\n')
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func findHTMLElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findHTMLElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
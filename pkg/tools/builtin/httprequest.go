@@ -0,0 +1,250 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/security/netguard"
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+const (
+	defaultHTTPRequestTimeout = 30 * time.Second
+	defaultMaxResponseBytes   = 1 << 20 // 1MB
+	defaultMaxRedirects       = 5
+	httpRequestCacheTTL       = 5 * time.Minute
+)
+
+// sensitiveResponseHeaders 不返回给模型的响应头
+var sensitiveResponseHeaders = map[string]bool{
+	"Set-Cookie":          true,
+	"Set-Cookie2":         true,
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Www-Authenticate":    true,
+	"Proxy-Authenticate":  true,
+}
+
+// HTTPRequestTool 带 SSRF 防护的通用 HTTP 请求工具
+// 默认禁止访问私有、回环、链路本地和云元数据地址（可通过 allowed_networks 显式放行），
+// 在建立连接时校验实际解析到的 IP，防止 DNS 重绑定绕过；响应体大小和重定向次数均有上限。
+type HTTPRequestTool struct {
+	timeout          time.Duration
+	maxResponseBytes int64
+	maxRedirects     int
+	cache            *tools.ToolCache
+	transport        *http.Transport
+	guard            *netguard.Guard
+}
+
+// NewHTTPRequestTool 创建 HTTPRequest 工具
+// 支持的配置项：
+//   - timeout: 默认超时（秒），默认 30
+//   - max_response_bytes: 响应体上限（字节），默认 1MB
+//   - max_redirects: 最大重定向次数，默认 5
+//   - allowed_domains: 允许访问的域名（含子域名），为空表示不限制
+//   - denied_domains: 禁止访问的域名（含子域名），优先于 allowed_domains
+//   - allowed_networks: 显式放行的 IP 或 CIDR（如内网网关），不受私有地址拦截
+//   - cache: *tools.ToolCache，缓存无请求体的 GET 成功响应
+func NewHTTPRequestTool(config map[string]any) (tools.Tool, error) {
+	if config == nil {
+		config = map[string]any{}
+	}
+
+	guard, err := newNetGuard(config)
+	if err != nil {
+		return nil, err
+	}
+	t := &HTTPRequestTool{
+		timeout:          defaultHTTPRequestTimeout,
+		maxResponseBytes: defaultMaxResponseBytes,
+		maxRedirects:     defaultMaxRedirects,
+		guard:            guard,
+	}
+	if v := GetIntParam(config, "timeout", 0); v > 0 {
+		t.timeout = time.Duration(v) * time.Second
+	}
+	if v := GetIntParam(config, "max_response_bytes", 0); v > 0 {
+		t.maxResponseBytes = int64(v)
+	}
+	if v := GetIntParam(config, "max_redirects", -1); v >= 0 {
+		t.maxRedirects = v
+	}
+	if cache, ok := config["cache"].(*tools.ToolCache); ok {
+		t.cache = cache
+	}
+
+	t.transport = guard.Transport(t.timeout)
+	return t, nil
+}
+
+func (t *HTTPRequestTool) Name() string {
+	return "HTTPRequest"
+}
+
+func (t *HTTPRequestTool) Description() string {
+	return "Send an HTTP request to an API endpoint with SSRF protection"
+}
+
+func (t *HTTPRequestTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "Target URL (http:// or https://)",
+			},
+			"method": map[string]any{
+				"type":        "string",
+				"enum":        []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD"},
+				"description": "HTTP method (default: GET)",
+			},
+			"headers": map[string]any{
+				"type":        "object",
+				"description": "Request headers",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Request body (for POST/PUT/PATCH)",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Timeout in seconds, cannot exceed the configured default",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *HTTPRequestTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	rawURL := GetStringParam(input, "url", "")
+	if rawURL == "" {
+		return nil, errors.New("url must be a non-empty string")
+	}
+	method := strings.ToUpper(GetStringParam(input, "method", http.MethodGet))
+	body := GetStringParam(input, "body", "")
+
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return httpRequestError(rawURL, "url must be an absolute http:// or https:// URL"), nil
+	}
+	if err := t.guard.CheckURL(target); err != nil {
+		return httpRequestError(rawURL, err.Error()), nil
+	}
+
+	// 仅缓存无请求体的 GET（幂等请求）
+	var cacheKey string
+	if t.cache != nil && method == http.MethodGet && body == "" {
+		cacheKey = t.cache.GenerateKey(t.Name(), map[string]any{"url": rawURL, "headers": input["headers"]})
+		if cached, ok := t.cache.Get(ctx, cacheKey); ok {
+			if result, ok := cached.(map[string]any); ok {
+				hit := maps.Clone(result)
+				hit["cached"] = true
+				return hit, nil
+			}
+		}
+	}
+
+	timeout := t.timeout
+	if v := GetIntParam(input, "timeout", 0); v > 0 && time.Duration(v)*time.Second < timeout {
+		timeout = time.Duration(v) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reqBody)
+	if err != nil {
+		return httpRequestError(rawURL, fmt.Sprintf("failed to create request: %v", err)), nil
+	}
+	if headers, ok := input["headers"].(map[string]any); ok {
+		for key, value := range headers {
+			if s, ok := value.(string); ok {
+				req.Header.Set(key, s)
+			}
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "Aster-Agent/1.0")
+	}
+
+	resp, err := t.guard.Client(t.transport, t.maxRedirects).Do(req)
+	if err != nil {
+		if errors.Is(err, netguard.ErrBlocked) {
+			return httpRequestError(rawURL, err.Error()), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return httpRequestError(rawURL, fmt.Sprintf("request timeout after %v", timeout)), nil
+		}
+		return httpRequestError(rawURL, fmt.Sprintf("request failed: %v", err)), nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
+	if err != nil {
+		return httpRequestError(rawURL, fmt.Sprintf("failed to read response body: %v", err)), nil
+	}
+	truncated := int64(len(data)) > t.maxResponseBytes
+	if truncated {
+		data = data[:t.maxResponseBytes]
+	}
+
+	headers := make(map[string]string)
+	for key, values := range resp.Header {
+		if len(values) > 0 && !sensitiveResponseHeaders[http.CanonicalHeaderKey(key)] {
+			headers[key] = values[0]
+		}
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	result := map[string]any{
+		"success":      success,
+		"status_code":  resp.StatusCode,
+		"headers":      headers,
+		"body":         string(data),
+		"truncated":    truncated,
+		"content_type": resp.Header.Get("Content-Type"),
+		"url":          resp.Request.URL.String(),
+	}
+
+	if cacheKey != "" && success && !truncated {
+		_ = t.cache.Set(ctx, cacheKey, result, httpRequestCacheTTL)
+	}
+	return result, nil
+}
+
+func (t *HTTPRequestTool) Prompt() string {
+	return `Send an HTTP request to an internal or external API.
+
+Usage:
+- Supports GET, POST, PUT, DELETE, PATCH and HEAD with custom headers and body
+- Private, loopback, link-local and cloud metadata addresses are blocked unless allowlisted
+- Response bodies larger than the configured limit are truncated ("truncated": true)
+- Sensitive response headers such as Set-Cookie are removed
+
+Response fields: success, status_code, headers, body, truncated, content_type, url (final URL after redirects)`
+}
+
+// Annotations 返回工具安全注解
+func (t *HTTPRequestTool) Annotations() *tools.ToolAnnotations {
+	return tools.AnnotationsNetworkWrite
+}
+
+// httpRequestError 构造失败结果
+func httpRequestError(rawURL, msg string) map[string]any {
+	return map[string]any{
+		"success": false,
+		"error":   msg,
+		"url":     rawURL,
+	}
+}
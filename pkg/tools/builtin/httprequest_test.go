@@ -0,0 +1,137 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+func TestHTTPRequestTool_BlocksMetadataIP(t *testing.T) {
+	tool, err := NewHTTPRequestTool(nil)
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	for _, target := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://metadata.google.internal/computeMetadata/v1/",
+		"http://[::ffff:169.254.169.254]/",
+	} {
+		result, err := tool.Execute(context.Background(), map[string]any{"url": target}, &tools.ToolContext{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		res := result.(map[string]any)
+		if res["success"] != false || !strings.Contains(res["error"].(string), "SSRF") {
+			t.Errorf("%s should be blocked, got %+v", target, res)
+		}
+	}
+}
+
+func TestHTTPRequestTool_BlocksLoopbackWithoutAllowlist(t *testing.T) {
+	var hits atomic.Int32
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	tool, _ := NewHTTPRequestTool(nil)
+	result, _ := tool.Execute(context.Background(), map[string]any{"url": server.URL}, &tools.ToolContext{})
+	if res := result.(map[string]any); res["success"] != false || hits.Load() != 0 {
+		t.Errorf("loopback request should be blocked before reaching the server, got %+v", res)
+	}
+}
+
+func TestHTTPRequestTool_CapsResponseBody(t *testing.T) {
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 10*1024)))
+	}))
+	defer server.Close()
+
+	tool, _ := NewHTTPRequestTool(map[string]any{
+		"allowed_networks":   []string{"127.0.0.1"},
+		"max_response_bytes": float64(1024),
+	})
+	result, err := tool.Execute(context.Background(), map[string]any{"url": server.URL}, &tools.ToolContext{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	res := result.(map[string]any)
+	if res["truncated"] != true || len(res["body"].(string)) != 1024 {
+		t.Errorf("body should be capped at 1024 bytes, got truncated=%v len=%d", res["truncated"], len(res["body"].(string)))
+	}
+}
+
+func TestHTTPRequestTool_AllowlistedRequest(t *testing.T) {
+	var hits atomic.Int32
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	cache := tools.NewToolCache(&tools.CacheConfig{Enabled: true, Strategy: tools.CacheStrategyMemory})
+	tool, _ := NewHTTPRequestTool(map[string]any{
+		"allowed_networks": []any{"127.0.0.0/8"},
+		"cache":            cache,
+	})
+	input := map[string]any{
+		"url":     server.URL + "/status",
+		"headers": map[string]any{"X-Api-Key": "secret"},
+	}
+
+	result, err := tool.Execute(context.Background(), input, &tools.ToolContext{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	res := result.(map[string]any)
+	if res["success"] != true || res["status_code"] != http.StatusOK || res["body"] != `{"ok":true}` {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if _, ok := res["headers"].(map[string]string)["Set-Cookie"]; ok {
+		t.Error("Set-Cookie should be filtered from response headers")
+	}
+
+	// 幂等 GET 第二次命中缓存
+	result, _ = tool.Execute(context.Background(), input, &tools.ToolContext{})
+	if result.(map[string]any)["cached"] != true || hits.Load() != 1 {
+		t.Errorf("repeated GET should be served from cache, hits=%d", hits.Load())
+	}
+
+	// POST 不使用缓存
+	input["method"] = "POST"
+	input["body"] = `{}`
+	_, _ = tool.Execute(context.Background(), input, &tools.ToolContext{})
+	if hits.Load() != 2 {
+		t.Errorf("POST should bypass the cache, hits=%d", hits.Load())
+	}
+}
+
+func TestHTTPRequestTool_DomainLists(t *testing.T) {
+	tool, _ := NewHTTPRequestTool(map[string]any{
+		"allowed_domains": []any{"example.com"},
+		"denied_domains":  []any{"internal.example.com"},
+	})
+	for target, allowed := range map[string]bool{
+		"https://api.example.com/v1":      true,
+		"https://internal.example.com/v1": false,
+		"https://evil.com/":               false,
+	} {
+		u, _ := url.Parse(target)
+		err := tool.(*HTTPRequestTool).guard.CheckURL(u)
+		if (err == nil) != allowed {
+			t.Errorf("%s: allowed=%v, err=%v", target, allowed, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
 	"github.com/astercloud/aster/pkg/tools"
 )
 
@@ -26,6 +27,12 @@ type MCPResourceContent struct {
 	Blob     string `json:"blob,omitempty"`
 }
 
+// mcpResourceServer 支持资源的 MCP Server（如 *mcp.MCPServer）
+type mcpResourceServer interface {
+	ListResources(ctx context.Context) ([]cloud.MCPResource, error)
+	ReadResource(ctx context.Context, uri string) ([]cloud.MCPResourceContent, error)
+}
+
 // ListMcpResourcesTool 列出 MCP 资源工具
 type ListMcpResourcesTool struct{}
 
@@ -118,10 +125,27 @@ func (t *ListMcpResourcesTool) listResources(ctx context.Context, serverFilter s
 }
 
 func (t *ListMcpResourcesTool) getServerResources(ctx context.Context, server any, serverID string) ([]MCPResource, error) {
-	// 尝试调用服务器的 ListResources 方法
-	// 这里需要根据实际的 MCP 服务器接口来实现
-	// 当前返回空列表，等待 MCP 服务器支持资源列表功能
-	return []MCPResource{}, nil
+	resourceServer, ok := server.(mcpResourceServer)
+	if !ok {
+		return []MCPResource{}, nil
+	}
+
+	serverResources, err := resourceServer.ListResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]MCPResource, 0, len(serverResources))
+	for _, r := range serverResources {
+		resources = append(resources, MCPResource{
+			URI:         r.URI,
+			Name:        r.Name,
+			Description: r.Description,
+			MimeType:    r.MimeType,
+			Server:      serverID,
+		})
+	}
+	return resources, nil
 }
 
 func (t *ListMcpResourcesTool) Prompt() string {
@@ -242,11 +266,21 @@ func (t *ReadMcpResourceTool) readResource(ctx context.Context, serverName, uri
 		return nil, fmt.Errorf("server not found: %s", serverName)
 	}
 
-	// 尝试调用服务器的 ReadResource 方法
-	// 这里需要根据实际的 MCP 服务器接口来实现
-	_ = server // 使用 server 变量
+	resourceServer, ok := server.(mcpResourceServer)
+	if !ok {
+		return nil, fmt.Errorf("server does not support resources: %s", serverName)
+	}
 
-	return nil, errors.New("resource reading not yet implemented for this server")
+	serverContents, err := resourceServer.ReadResource(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]MCPResourceContent, 0, len(serverContents))
+	for _, c := range serverContents {
+		contents = append(contents, MCPResourceContent(c))
+	}
+	return contents, nil
 }
 
 func (t *ReadMcpResourceTool) Prompt() string {
@@ -0,0 +1,13 @@
+package builtin
+
+import "github.com/astercloud/aster/pkg/security/netguard"
+
+// newNetGuard 从工具配置创建 SSRF 防护
+// 支持的配置项：allowed_domains、denied_domains、allowed_networks
+func newNetGuard(config map[string]any) (*netguard.Guard, error) {
+	return netguard.New(&netguard.Config{
+		AllowedDomains:  GetStringSliceParam(config, "allowed_domains"),
+		DeniedDomains:   GetStringSliceParam(config, "denied_domains"),
+		AllowedNetworks: GetStringSliceParam(config, "allowed_networks"),
+	})
+}
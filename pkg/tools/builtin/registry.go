@@ -4,40 +4,45 @@ import "github.com/astercloud/aster/pkg/tools"
 
 // RegisterAll 注册所有内置工具 （重要：克制，未经严格的讨论禁止再增加）
 // 保持精简（约18个工具）
+// 每个工具按分类打上标签，可通过 registry.FindByTag 查找
 func RegisterAll(registry *tools.Registry) {
 	// 文件操作工具 (5)
-	registry.Register("Read", NewReadTool)
-	registry.Register("Write", NewWriteTool)
-	registry.Register("Edit", NewEditTool)
-	registry.Register("Glob", NewGlobTool)
-	registry.Register("Grep", NewGrepTool)
+	registry.RegisterWithTags("Read", NewReadTool, tools.CategoryFilesystem)
+	registry.RegisterWithTags("Write", NewWriteTool, tools.CategoryFilesystem)
+	registry.RegisterWithTags("Edit", NewEditTool, tools.CategoryFilesystem)
+	registry.RegisterWithTags("Glob", NewGlobTool, tools.CategoryFilesystem)
+	registry.RegisterWithTags("Grep", NewGrepTool, tools.CategoryFilesystem)
 
 	// 命令行执行工具 (3)
-	registry.Register("Bash", NewBashTool)
-	registry.Register("BashOutput", NewBashOutputTool)
-	registry.Register("KillShell", NewKillShellTool)
+	registry.RegisterWithTags("Bash", NewBashTool, tools.CategoryExecution)
+	registry.RegisterWithTags("BashOutput", NewBashOutputTool, tools.CategoryExecution)
+	registry.RegisterWithTags("KillShell", NewKillShellTool, tools.CategoryExecution)
 
 	// 智能代理工具 (1)
-	registry.Register("Task", NewTaskTool)
+	registry.RegisterWithTags("Task", NewTaskTool, "agent")
 
 	// 规划管理工具 (3)
-	registry.Register("TodoWrite", NewTodoWriteTool)
-	registry.Register("EnterPlanMode", NewEnterPlanModeTool)
-	registry.Register("ExitPlanMode", NewExitPlanModeTool)
+	registry.RegisterWithTags("TodoWrite", NewTodoWriteTool, "planning")
+	registry.RegisterWithTags("EnterPlanMode", NewEnterPlanModeTool, "planning")
+	registry.RegisterWithTags("ExitPlanMode", NewExitPlanModeTool, "planning")
 
 	// 用户交互工具 (1)
-	registry.Register("AskUserQuestion", NewAskUserQuestionTool)
+	registry.RegisterWithTags("AskUserQuestion", NewAskUserQuestionTool, "interaction")
 
-	// 网络工具 (2)
-	registry.Register("WebFetch", NewWebFetchTool)
-	registry.Register("WebSearch", NewWebSearchTool)
+	// 网络工具 (3)
+	registry.RegisterWithTags("WebFetch", NewWebFetchTool, tools.CategoryNetwork)
+	registry.RegisterWithTags("WebSearch", NewWebSearchTool, tools.CategoryNetwork)
+	registry.RegisterWithTags("HTTPRequest", NewHTTPRequestTool, tools.CategoryNetwork)
 
 	// MCP 资源工具 (2)
-	registry.Register("ListMcpResources", NewListMcpResourcesTool)
-	registry.Register("ReadMcpResource", NewReadMcpResourceTool)
+	registry.RegisterWithTags("ListMcpResources", NewListMcpResourcesTool, tools.CategoryMCP)
+	registry.RegisterWithTags("ReadMcpResource", NewReadMcpResourceTool, tools.CategoryMCP)
 
 	// 技能工具 (1)
-	registry.Register("Skill", NewSkillTool)
+	registry.RegisterWithTags("Skill", NewSkillTool, "skill")
+
+	// 系统工具 (1)
+	registry.RegisterWithTags("DateTime", NewDateTimeTool, tools.CategorySystem)
 }
 
 // FileSystemTools 返回文件系统工具列表
@@ -67,7 +72,7 @@ func InteractionTools() []string {
 
 // NetworkTools 返回网络工具列表
 func NetworkTools() []string {
-	return []string{"WebFetch", "WebSearch"}
+	return []string{"WebFetch", "WebSearch", "HTTPRequest"}
 }
 
 // McpTools 返回 MCP 资源工具列表
@@ -80,7 +85,12 @@ func SkillTools() []string {
 	return []string{"Skill"}
 }
 
-// AllTools 返回所有内置工具列表（共18个）
+// SystemTools 返回系统工具列表
+func SystemTools() []string {
+	return []string{"DateTime"}
+}
+
+// AllTools 返回所有内置工具列表（共20个）
 func AllTools() []string {
 	tools := FileSystemTools()
 	tools = append(tools, ExecutionTools()...)
@@ -90,5 +100,6 @@ func AllTools() []string {
 	tools = append(tools, NetworkTools()...)
 	tools = append(tools, McpTools()...)
 	tools = append(tools, SkillTools()...)
+	tools = append(tools, SystemTools()...)
 	return tools
 }
@@ -66,6 +66,7 @@ type SubagentInstance struct {
 	EndTime       *time.Time             `json:"end_time,omitempty"`
 	Duration      time.Duration          `json:"duration"`
 	Output        string                 `json:"output"`
+	PartialOutput string                 `json:"partial_output,omitempty"` // 运行中已产生的增量输出
 	Error         string                 `json:"error,omitempty"`
 	ExitCode      int                    `json:"exit_code,omitempty"`
 	LastUpdate    time.Time              `json:"last_update"`
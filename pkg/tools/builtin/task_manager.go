@@ -49,6 +49,11 @@ type TaskOptions struct {
 	Shell         string            `json:"shell"`
 	CaptureOutput bool              `json:"capture_output"`
 	OutputDir     string            `json:"output_dir"`
+
+	// ExecEnv 进程的完整环境变量（由沙箱提供，已包含 Env），设置后不再继承主机环境变量
+	ExecEnv []string `json:"-"`
+	// Mask 读取任务输出时对密钥做掩码
+	Mask func(string) string `json:"-"`
 }
 
 // TaskInfo 任务信息
@@ -146,8 +151,10 @@ func (tm *FileTaskManager) StartTask(ctx context.Context, cmd string, opts *Task
 	cmdObj := exec.CommandContext(ctx, "bash", "-c", fullCmd)
 	cmdObj.Dir = opts.WorkDir
 
-	// 设置环境变量
-	if len(opts.Env) > 0 {
+	// 设置环境变量：沙箱提供的环境变量优先，与前台命令使用同样的白名单和密钥
+	if opts.ExecEnv != nil {
+		cmdObj.Env = opts.ExecEnv
+	} else if len(opts.Env) > 0 {
 		env := os.Environ()
 		for k, v := range opts.Env {
 			env = append(env, fmt.Sprintf("%s=%s", k, v))
@@ -159,6 +166,7 @@ func (tm *FileTaskManager) StartTask(ctx context.Context, cmd string, opts *Task
 	outputFile := filepath.Join(opts.OutputDir, taskID+".stdout")
 	errorFile := filepath.Join(opts.OutputDir, taskID+".stderr")
 
+	var outputs []*os.File
 	if opts.CaptureOutput {
 		outFile, err := os.Create(outputFile)
 		if err != nil {
@@ -173,16 +181,14 @@ func (tm *FileTaskManager) StartTask(ctx context.Context, cmd string, opts *Task
 
 		cmdObj.Stdout = outFile
 		cmdObj.Stderr = errFile
-
-		// 延迟关闭文件
-		go func() {
-			defer func() { _ = outFile.Close() }()
-			defer func() { _ = errFile.Close() }()
-		}()
+		outputs = append(outputs, outFile, errFile)
 	}
 
-	// 启动进程
+	// 启动进程，子进程持有输出文件描述符的副本，启动后即可关闭本进程的文件
 	err := cmdObj.Start()
+	for _, f := range outputs {
+		_ = f.Close()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
@@ -244,6 +250,12 @@ func (tm *FileTaskManager) GetTaskOutput(taskID string, filter string, lines int
 	stdoutStr := string(stdout)
 	stderrStr := string(stderr)
 
+	// 掩码输出中的密钥
+	if task.Options.Mask != nil {
+		stdoutStr = task.Options.Mask(stdoutStr)
+		stderrStr = task.Options.Mask(stderrStr)
+	}
+
 	// 应用过滤器
 	if filter != "" {
 		stdoutStr = tm.filterOutput(stdoutStr, filter)
@@ -56,6 +56,9 @@ func GetBoolParam(input map[string]any, key string, defaultValue bool) bool {
 // GetStringSliceParam 获取字符串数组参数的通用函数
 func GetStringSliceParam(input map[string]any, key string) []string {
 	if value, exists := input[key]; exists {
+		if slice, ok := value.([]string); ok {
+			return slice
+		}
 		if slice, ok := value.([]any); ok {
 			result := make([]string, len(slice))
 			for i, item := range slice {
@@ -3,36 +3,69 @@ package builtin
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/astercloud/aster/pkg/security/netguard"
 	"github.com/astercloud/aster/pkg/tools"
 )
 
+const (
+	defaultWebFetchTimeout          = 30 * time.Second
+	defaultWebFetchMaxResponseBytes = 5 << 20 // 5MB
+)
+
 // WebFetchTool 网页获取工具
+// 获取 URL 内容并将 HTML 转换为 Markdown，与 HTTPRequest 共用 SSRF 防护，
+// 重定向次数、响应大小和超时均有上限。
 type WebFetchTool struct {
-	defaultTimeout time.Duration
-	client         *http.Client
+	timeout          time.Duration
+	maxResponseBytes int64
+	maxRedirects     int
+	transport        *http.Transport
+	guard            *netguard.Guard
 }
 
 // NewWebFetchTool 创建 WebFetch 工具
+// 支持的配置项：
+//   - timeout: 默认超时（秒），默认 30
+//   - max_response_bytes: 响应体上限（字节），默认 5MB
+//   - max_redirects: 最大重定向次数，默认 5
+//   - allowed_domains / denied_domains / allowed_networks: 同 HTTPRequest
+//
+// ToolContext.Services 中的 "allowed_domains" / "denied_domains"（[]string）会与配置同时生效。
 func NewWebFetchTool(config map[string]any) (tools.Tool, error) {
-	timeout := 30 * time.Second
-	if t, ok := config["timeout"].(float64); ok {
-		timeout = time.Duration(t) * time.Second
+	if config == nil {
+		config = map[string]any{}
 	}
 
-	return &WebFetchTool{
-		defaultTimeout: timeout,
-		client: &http.Client{
-			Timeout: timeout,
-		},
-	}, nil
+	guard, err := newNetGuard(config)
+	if err != nil {
+		return nil, err
+	}
+	t := &WebFetchTool{
+		timeout:          defaultWebFetchTimeout,
+		maxResponseBytes: defaultWebFetchMaxResponseBytes,
+		maxRedirects:     defaultMaxRedirects,
+		guard:            guard,
+	}
+	if v := GetIntParam(config, "timeout", 0); v > 0 {
+		t.timeout = time.Duration(v) * time.Second
+	}
+	if v := GetIntParam(config, "max_response_bytes", 0); v > 0 {
+		t.maxResponseBytes = int64(v)
+	}
+	if v := GetIntParam(config, "max_redirects", -1); v >= 0 {
+		t.maxRedirects = v
+	}
+	t.transport = guard.Transport(t.timeout)
+	return t, nil
 }
 
 func (t *WebFetchTool) Name() string {
@@ -40,7 +73,7 @@ func (t *WebFetchTool) Name() string {
 }
 
 func (t *WebFetchTool) Description() string {
-	return "获取和分析网页内容"
+	return "获取网页内容，HTML 自动转换为 Markdown"
 }
 
 func (t *WebFetchTool) InputSchema() map[string]any {
@@ -51,22 +84,9 @@ func (t *WebFetchTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "目标 URL（必须以 http:// 或 https:// 开头）",
 			},
-			"method": map[string]any{
-				"type":        "string",
-				"enum":        []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD"},
-				"description": "HTTP 方法（默认: GET）",
-			},
-			"headers": map[string]any{
-				"type":        "object",
-				"description": "HTTP 请求头（键值对）",
-			},
-			"body": map[string]any{
-				"type":        "string",
-				"description": "请求体（用于 POST/PUT/PATCH）",
-			},
 			"timeout": map[string]any{
 				"type":        "number",
-				"description": "请求超时时间（秒），默认 30",
+				"description": "请求超时时间（秒），不能超过配置的默认值",
 			},
 		},
 		"required": []string{"url"},
@@ -74,148 +94,133 @@ func (t *WebFetchTool) InputSchema() map[string]any {
 }
 
 func (t *WebFetchTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
-	url, ok := input["url"].(string)
-	if !ok || url == "" {
+	rawURL := GetStringParam(input, "url", "")
+	if rawURL == "" {
 		return nil, errors.New("url must be a non-empty string")
 	}
 
-	method := "GET"
-	if m, ok := input["method"].(string); ok {
-		method = m
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return webFetchError(rawURL, "url must be an absolute http:// or https:// URL"), nil
 	}
 
-	var reqBody io.Reader
-	if bodyStr, ok := input["body"].(string); ok && bodyStr != "" {
-		reqBody = bytes.NewBufferString(bodyStr)
+	guard := t.guard
+	if tc != nil {
+		guard = guard.WithDomains(GetStringSliceParam(tc.Services, "allowed_domains"), GetStringSliceParam(tc.Services, "denied_domains"))
 	}
-
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return map[string]any{
-			"success": false,
-			"error":   fmt.Sprintf("failed to create request: %v", err),
-		}, nil
+	if err := guard.CheckURL(target); err != nil {
+		return webFetchError(rawURL, err.Error()), nil
 	}
 
-	if headers, ok := input["headers"].(map[string]any); ok {
-		for key, value := range headers {
-			if valueStr, ok := value.(string); ok {
-				req.Header.Set(key, valueStr)
-			}
-		}
+	timeout := t.timeout
+	if v := GetIntParam(input, "timeout", 0); v > 0 && time.Duration(v)*time.Second < timeout {
+		timeout = time.Duration(v) * time.Second
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	if req.Header.Get("User-Agent") == "" {
-		req.Header.Set("User-Agent", "Aster-Agent/1.0")
-	}
-
-	client := t.client
-	if timeoutSec, ok := input["timeout"].(float64); ok && timeoutSec > 0 {
-		client = &http.Client{
-			Timeout: time.Duration(timeoutSec) * time.Second,
-		}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return webFetchError(rawURL, fmt.Sprintf("failed to create request: %v", err)), nil
 	}
+	req.Header.Set("User-Agent", "Aster-Agent/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
 
-	resp, err := client.Do(req)
+	resp, err := guard.Client(t.transport, t.maxRedirects).Do(req)
 	if err != nil {
-		var netErr net.Error
-		if ctx.Err() == context.DeadlineExceeded || (errors.As(err, &netErr) && netErr.Timeout()) {
-			return map[string]any{
-				"success": false,
-				"error":   fmt.Sprintf("request timeout after %v", client.Timeout),
-				"url":     url,
-			}, nil
+		if errors.Is(err, netguard.ErrBlocked) {
+			return webFetchError(rawURL, err.Error()), nil
 		}
-
-		return map[string]any{
-			"success": false,
-			"error":   fmt.Sprintf("request failed: %v", err),
-			"url":     url,
-		}, nil
+		if ctx.Err() == context.DeadlineExceeded {
+			return webFetchError(rawURL, fmt.Sprintf("request timeout after %v", timeout)), nil
+		}
+		return webFetchError(rawURL, fmt.Sprintf("request failed: %v", err)), nil
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
 	if err != nil {
-		return map[string]any{
-			"success":     false,
-			"error":       fmt.Sprintf("failed to read response body: %v", err),
-			"status_code": resp.StatusCode,
-			"url":         url,
-		}, nil
-	}
-
-	var content any
-	contentType := resp.Header.Get("Content-Type")
-
-	if len(bodyBytes) > 0 {
-		var jsonData any
-		if err := json.Unmarshal(bodyBytes, &jsonData); err == nil {
-			content = jsonData
-		} else {
-			content = string(bodyBytes)
+		if ctx.Err() == context.DeadlineExceeded {
+			return webFetchError(rawURL, fmt.Sprintf("request timeout after %v", timeout)), nil
 		}
-	} else {
-		content = ""
+		return webFetchError(rawURL, fmt.Sprintf("failed to read response body: %v", err)), nil
+	}
+	truncated := int64(len(data)) > t.maxResponseBytes
+	if truncated {
+		data = data[:t.maxResponseBytes]
 	}
 
-	headers := make(map[string]string)
-	for key, values := range resp.Header {
-		if len(values) > 0 {
-			headers[key] = values[0]
-		}
+	finalURL := resp.Request.URL
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"success":      resp.StatusCode >= 200 && resp.StatusCode < 300,
 		"status_code":  resp.StatusCode,
-		"headers":      headers,
-		"content":      content,
+		"url":          rawURL,
+		"final_url":    finalURL.String(),
 		"content_type": contentType,
-		"url":          url,
-	}, nil
+		"truncated":    truncated,
+	}
+
+	switch {
+	case isHTMLMediaType(mediaType):
+		title, markdown, err := htmlToMarkdown(bytes.NewReader(data), finalURL)
+		if err != nil {
+			return webFetchError(rawURL, err.Error()), nil
+		}
+		result["format"] = "markdown"
+		result["title"] = title
+		result["content"] = markdown
+	case isTextMediaType(mediaType):
+		result["format"] = "text"
+		result["content"] = strings.ToValidUTF8(string(data), "�")
+	default:
+		// 二进制内容不返回给模型
+		result["success"] = false
+		result["error"] = fmt.Sprintf("unsupported content type %q: only HTML and text content can be fetched", mediaType)
+	}
+	return result, nil
 }
 
 func (t *WebFetchTool) Prompt() string {
-	return `获取和分析网页内容。
-
-支持的 HTTP 方法: GET, POST, PUT, DELETE, PATCH, HEAD
+	return `获取网页内容，用于阅读文档、文章等页面。
 
 使用指南:
-- 验证 URL 后再发送请求
-- 根据操作类型选择合适的 HTTP 方法
-- 设置适当的请求头（Content-Type, Authorization 等）
-- 自动处理 JSON 和纯文本响应
-- 默认超时 30 秒（可通过 timeout 参数配置）
+- 只支持 GET 请求；调用 API 或发送请求体请使用 HTTPRequest
+- HTML 页面会去除脚本和样式并转换为 Markdown，相对链接会转换为绝对地址
+- 纯文本、JSON、XML 等文本内容原样返回；图片、PDF 等二进制内容不返回
+- 自动跟随有限次数的重定向，响应超过大小上限时截断（truncated 为 true）
+- 私有网络地址和不在允许列表中的域名会被拒绝
 
 响应格式:
-- success: 请求是否成功（2xx 状态码）
+- success: 请求是否成功（2xx 状态码且内容可读取）
 - status_code: HTTP 状态码
-- headers: 响应头（键值对）
-- content: 解析后的 JSON 对象或纯文本
+- url / final_url: 请求的 URL 和重定向后的最终 URL
 - content_type: Content-Type 头值
-- url: 最终 URL（可能因重定向而不同）`
+- format: markdown 或 text
+- title: 页面标题（仅 HTML）
+- content: 转换后的内容
+- truncated: 响应是否被截断`
 }
 
 // Examples 返回 WebFetch 工具的使用示例
 func (t *WebFetchTool) Examples() []tools.ToolExample {
 	return []tools.ToolExample{
 		{
-			Description: "获取网页内容",
+			Description: "阅读文档页面",
 			Input: map[string]any{
-				"url":    "https://api.example.com/data",
-				"method": "GET",
+				"url": "https://go.dev/doc/effective_go",
 			},
 		},
 		{
-			Description: "发送 POST 请求",
+			Description: "获取原始文本文件并缩短超时",
 			Input: map[string]any{
-				"url":    "https://api.example.com/users",
-				"method": "POST",
-				"headers": map[string]string{
-					"Content-Type": "application/json",
-				},
-				"body": `{"name": "John"}`,
+				"url":     "https://raw.githubusercontent.com/golang/go/master/README.md",
+				"timeout": 10,
 			},
 		},
 	}
@@ -225,3 +230,30 @@ func (t *WebFetchTool) Examples() []tools.ToolExample {
 func (t *WebFetchTool) Annotations() *tools.ToolAnnotations {
 	return tools.AnnotationsNetworkRead
 }
+
+// isHTMLMediaType 判断是否为需要转换的 HTML 内容
+func isHTMLMediaType(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// isTextMediaType 判断是否为可直接返回的文本内容
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-javascript",
+		"application/yaml", "application/x-yaml", "application/toml", "application/x-ndjson":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// webFetchError 构造失败结果
+func webFetchError(rawURL, msg string) map[string]any {
+	return map[string]any{
+		"success": false,
+		"error":   msg,
+		"url":     rawURL,
+	}
+}
@@ -0,0 +1,177 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	page := `<html><head><title> Example  Page </title><style>body{}</style></head>
+<body>
+<script>alert("x")</script>
+<nav><a href="/">Home</a></nav>
+<h1>Hello <em>World</em></h1>
+<p>Some   <strong>bold</strong> text with a <a href="docs/intro">relative link</a>.</p>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<pre><code>func main() {
+    fmt.Println("hi")
+}</code></pre>
+<blockquote><p>quoted</p></blockquote>
+<table><tr><th>Name</th><th>Value</th></tr><tr><td>a</td><td>1</td></tr></table>
+<noscript>enable js</noscript>
+</body></html>`
+
+	base, _ := url.Parse("https://example.com/guide/")
+	title, markdown, err := htmlToMarkdown(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatalf("htmlToMarkdown failed: %v", err)
+	}
+	if title != "Example Page" {
+		t.Errorf("Expected title 'Example Page', got %q", title)
+	}
+
+	want := "[Home](https://example.com/)\n\n" +
+		"# Hello *World*\n\n" +
+		"Some **bold** text with a [relative link](https://example.com/guide/docs/intro).\n\n" +
+		"- one\n" +
+		"- two\n\n" +
+		"  1. nested\n\n" +
+		"```\nfunc main() {\n    fmt.Println(\"hi\")\n}\n```\n\n" +
+		"> quoted\n\n" +
+		"| Name | Value |\n" +
+		"| --- | --- |\n" +
+		"| a | 1 |"
+	if markdown != want {
+		t.Errorf("Unexpected markdown:\n%s\n--- want ---\n%s", markdown, want)
+	}
+	for _, s := range []string{"alert", "body{}", "enable js"} {
+		if strings.Contains(markdown, s) {
+			t.Errorf("Markdown should not contain %q", s)
+		}
+	}
+}
+
+func newTestWebFetchTool(t *testing.T, config map[string]any) tools.Tool {
+	t.Helper()
+	if config == nil {
+		config = map[string]any{}
+	}
+	config["allowed_networks"] = []string{"127.0.0.1"}
+	tool, err := NewWebFetchTool(config)
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	return tool
+}
+
+func TestWebFetchTool_FetchesHTMLAsMarkdown(t *testing.T) {
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<title>Doc</title><script>var x</script><h2>Intro</h2><p><a href="next">Next</a></p>`))
+		}
+	}))
+	defer server.Close()
+
+	tool := newTestWebFetchTool(t, nil)
+	result, err := tool.Execute(context.Background(), map[string]any{"url": server.URL + "/old"}, &tools.ToolContext{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	res := result.(map[string]any)
+	if res["success"] != true || res["status_code"] != http.StatusOK {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res["final_url"] != server.URL+"/page" || res["url"] != server.URL+"/old" {
+		t.Errorf("unexpected urls: url=%v final_url=%v", res["url"], res["final_url"])
+	}
+	if res["title"] != "Doc" || res["format"] != "markdown" {
+		t.Errorf("unexpected title/format: %+v", res)
+	}
+	if want := "## Intro\n\n[Next](" + server.URL + "/next)"; res["content"] != want {
+		t.Errorf("content = %q, want %q", res["content"], want)
+	}
+}
+
+func TestWebFetchTool_LimitsRedirects(t *testing.T) {
+	var hits atomic.Int32
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer server.Close()
+
+	tool := newTestWebFetchTool(t, map[string]any{"max_redirects": float64(2)})
+	result, _ := tool.Execute(context.Background(), map[string]any{"url": server.URL}, &tools.ToolContext{})
+	res := result.(map[string]any)
+	if res["success"] != false || !strings.Contains(res["error"].(string), "stopped after 2 redirects") {
+		t.Errorf("expected redirect limit error, got %+v", res)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", hits.Load())
+	}
+}
+
+func TestWebFetchTool_ContentTypes(t *testing.T) {
+	server := newLocalHTTPServerWS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G', 0})
+		case "/big.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(strings.Repeat("x", 4096)))
+		}
+	}))
+	defer server.Close()
+
+	tool := newTestWebFetchTool(t, map[string]any{"max_response_bytes": float64(1024)})
+	fetch := func(path string) map[string]any {
+		result, err := tool.Execute(context.Background(), map[string]any{"url": server.URL + path}, &tools.ToolContext{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result.(map[string]any)
+	}
+
+	if res := fetch("/data.json"); res["success"] != true || res["format"] != "text" || res["content"] != `{"ok":true}` {
+		t.Errorf("json should be returned as text, got %+v", res)
+	}
+	if res := fetch("/image.png"); res["success"] != false || res["content"] != nil || !strings.Contains(res["error"].(string), "image/png") {
+		t.Errorf("binary content should be rejected, got %+v", res)
+	}
+	if res := fetch("/big.txt"); res["truncated"] != true || len(res["content"].(string)) != 1024 {
+		t.Errorf("body should be capped at 1024 bytes, got truncated=%v", res["truncated"])
+	}
+}
+
+func TestWebFetchTool_DomainListsFromToolContext(t *testing.T) {
+	tool, _ := NewWebFetchTool(map[string]any{"denied_domains": []any{"evil.com"}})
+
+	for target, tc := range map[string]*tools.ToolContext{
+		"https://evil.com/":        {},
+		"https://docs.example.com": {Services: map[string]any{"denied_domains": []string{"example.com"}}},
+		"https://other.org/":       {Services: map[string]any{"allowed_domains": []string{"example.com"}}},
+		"http://127.0.0.1/":        {},
+	} {
+		result, err := tool.Execute(context.Background(), map[string]any{"url": target}, tc)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if res := result.(map[string]any); res["success"] != false || !strings.Contains(res["error"].(string), "SSRF") {
+			t.Errorf("%s should be blocked, got %+v", target, res)
+		}
+	}
+}
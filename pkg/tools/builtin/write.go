@@ -7,13 +7,18 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/astercloud/aster/pkg/logging"
 	"github.com/astercloud/aster/pkg/tools"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 var writeLog = logging.ForComponent("WriteTool")
 
+// maxDiffBytes 响应中 diff 的最大长度，超出部分截断
+const maxDiffBytes = 64 * 1024
+
 // WriteTool 增强的文件写入工具
 // 兼容标准Write工具功能
 type WriteTool struct{}
@@ -55,6 +60,10 @@ func (t *WriteTool) InputSchema() map[string]any {
 				"type":        "boolean",
 				"description": "是否以追加模式写入，默认为false（覆盖模式）",
 			},
+			"preview_only": map[string]any{
+				"type":        "boolean",
+				"description": "仅返回将要产生的 diff 而不写入文件，默认为false",
+			},
 		},
 		"required": []string{"file_path", "content"},
 	}
@@ -71,6 +80,7 @@ func (t *WriteTool) Execute(ctx context.Context, input map[string]any, tc *tools
 	createDirs := GetBoolParam(input, "create_dirs", true)
 	backup := GetBoolParam(input, "backup", false)
 	append := GetBoolParam(input, "append", false)
+	previewOnly := GetBoolParam(input, "preview_only", false)
 
 	if filePath == "" {
 		return NewClaudeErrorResponse(errors.New("file_path cannot be empty")), nil
@@ -105,6 +115,33 @@ func (t *WriteTool) Execute(ctx context.Context, input map[string]any, tc *tools
 		writeContent = content
 	}
 
+	// 计算与现有内容的差异
+	diff, linesAdded, linesRemoved := writeDiff(filePath, existingContent, writeContent, fileExists)
+	operation := "created"
+	if fileExists {
+		operation = "overwritten"
+		if append {
+			operation = "appended"
+		}
+	}
+
+	// 仅预览：返回 diff，不写入
+	if previewOnly {
+		response := map[string]any{
+			"ok":            true,
+			"preview_only":  true,
+			"file_path":     filePath,
+			"file_existed":  fileExists,
+			"operation":     operation,
+			"changed":       !fileExists || existingContent != writeContent,
+			"lines_added":   linesAdded,
+			"lines_removed": linesRemoved,
+			"duration_ms":   time.Since(start).Milliseconds(),
+		}
+		setDiff(response, diff)
+		return response, nil
+	}
+
 	// 如果需要备份，创建备份文件
 	var backupPath string
 	if backup && fileExists {
@@ -195,18 +232,73 @@ func (t *WriteTool) Execute(ctx context.Context, input map[string]any, tc *tools
 	}
 
 	// 添加文件状态信息
-	if fileExists {
-		response["file_existed"] = true
-		response["operation"] = "overwritten"
-		if append {
-			response["operation"] = "appended"
+	response["file_existed"] = fileExists
+	response["operation"] = operation
+	response["changed"] = !fileExists || existingContent != writeContent
+	response["lines_added"] = linesAdded
+	response["lines_removed"] = linesRemoved
+	setDiff(response, diff)
+
+	return response, nil
+}
+
+// writeDiff 生成写入前后的 unified diff 及增删行数
+// 新文件以 /dev/null 作为原始版本
+func writeDiff(filePath, oldContent, newContent string, exists bool) (string, int, int) {
+	if exists && oldContent == newContent {
+		return "", 0, 0
+	}
+
+	fromFile := "a" + filePath
+	if !exists {
+		fromFile = "/dev/null"
+	}
+	oldLines := difflib.SplitLines(oldContent)
+	newLines := difflib.SplitLines(newContent)
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        oldLines,
+		B:        newLines,
+		FromFile: fromFile,
+		ToFile:   "b" + filePath,
+		Context:  3,
+	})
+	if err != nil {
+		return "", 0, 0
+	}
+
+	// 按 diff 操作计数，内容本身以 "+"/"-" 开头的行不会被误判为文件头
+	added, removed := 0, 0
+	for _, op := range difflib.NewMatcher(oldLines, newLines).GetOpCodes() {
+		switch op.Tag {
+		case 'r':
+			removed += op.I2 - op.I1
+			added += op.J2 - op.J1
+		case 'd':
+			removed += op.I2 - op.I1
+		case 'i':
+			added += op.J2 - op.J1
 		}
-	} else {
-		response["file_existed"] = false
-		response["operation"] = "created"
 	}
+	return diff, added, removed
+}
 
-	return response, nil
+// setDiff 将 diff 写入响应，过长时截断
+// 在 maxDiffBytes 之前的最后一个换行处截断，单行超长时退回到 UTF-8 字符边界
+func setDiff(response map[string]any, diff string) {
+	if len(diff) > maxDiffBytes {
+		cut := maxDiffBytes
+		if i := strings.LastIndexByte(diff[:maxDiffBytes], '\n'); i >= 0 {
+			cut = i + 1
+		} else {
+			for cut > 0 && !utf8.RuneStart(diff[cut]) {
+				cut--
+			}
+		}
+		response["diff"] = diff[:cut]
+		response["diff_truncated"] = true
+		return
+	}
+	response["diff"] = diff
 }
 
 // validatePath 验证文件路径安全性
@@ -253,6 +345,9 @@ func (t *WriteTool) Prompt() string {
 - create_dirs: 可选参数，是否创建父目录
 - backup: 可选参数，是否创建备份
 - append: 可选参数，是否追加模式
+- preview_only: 可选参数，只返回 diff 而不写入（便于审批前预览）
+
+覆盖已存在的文件时，返回结果中的 diff 字段为修改前后的 unified diff。
 
 安全性：
 - 路径遍历攻击防护
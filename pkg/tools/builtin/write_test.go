@@ -2,7 +2,9 @@ package builtin
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestNewWriteTool(t *testing.T) {
@@ -163,3 +165,121 @@ func BenchmarkWriteTool_SmallFile(b *testing.B) {
 
 	BenchmarkTool(b, tool, input)
 }
+
+func TestWriteTool_OverwriteReturnsDiff(t *testing.T) {
+	tool, _ := NewWriteTool(nil)
+	helper := NewTestHelper(t)
+	defer helper.CleanupAll()
+
+	filePath := helper.CreateTempFile("diff.txt", "line1\nline2\nline3\n")
+
+	result := ExecuteToolWithRealFS(t, tool, map[string]any{
+		"file_path": filePath,
+		"content":   "line1\nchanged\nline3\n",
+	})
+	result = AssertToolSuccess(t, result)
+
+	AssertFileContent(t, filePath, "line1\nchanged\nline3\n")
+	if result["operation"] != "overwritten" {
+		t.Errorf("operation = %v, want overwritten", result["operation"])
+	}
+	diff, _ := result["diff"].(string)
+	for _, want := range []string{"--- a" + filePath, "+++ b" + filePath, "-line2", "+changed", " line1"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	if result["lines_added"] != 1 || result["lines_removed"] != 1 {
+		t.Errorf("lines_added/lines_removed = %v/%v, want 1/1", result["lines_added"], result["lines_removed"])
+	}
+}
+
+func TestWriteDiff_CountsLinesStartingWithMarkers(t *testing.T) {
+	// 内容行以 "++"/"--" 开头时渲染为 "+++"/"---"，计数不能依赖渲染前缀
+	oldContent := "keep\n-- old comment\n"
+	newContent := "keep\n++ counter\n--- divider\n"
+	diff, added, removed := writeDiff("/x.txt", oldContent, newContent, true)
+	if !strings.Contains(diff, "+++ counter") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if added != 2 || removed != 1 {
+		t.Errorf("added/removed = %d/%d, want 2/1", added, removed)
+	}
+}
+
+func TestSetDiff_TruncatesAtLineOrRuneBoundary(t *testing.T) {
+	// 多行时在最后一个完整行处截断
+	line := "+" + strings.Repeat("中", 100) + "\n"
+	response := map[string]any{}
+	setDiff(response, strings.Repeat(line, maxDiffBytes/len(line)+1))
+	diff := response["diff"].(string)
+	if response["diff_truncated"] != true || len(diff) > maxDiffBytes || !strings.HasSuffix(diff, "\n") {
+		t.Fatalf("expected truncation at a line boundary, got %d bytes", len(diff))
+	}
+
+	// 单行超长时不拆分多字节字符
+	response = map[string]any{}
+	setDiff(response, "+a"+strings.Repeat("中", maxDiffBytes))
+	diff = response["diff"].(string)
+	if len(diff) > maxDiffBytes || !utf8.ValidString(diff) {
+		t.Errorf("expected valid UTF-8 within limit, got %d bytes valid=%v", len(diff), utf8.ValidString(diff))
+	}
+}
+
+func TestWriteTool_PreviewOnlyDoesNotWrite(t *testing.T) {
+	tool, _ := NewWriteTool(nil)
+	helper := NewTestHelper(t)
+	defer helper.CleanupAll()
+
+	filePath := helper.CreateTempFile("preview.txt", "old\n")
+
+	result := ExecuteToolWithRealFS(t, tool, map[string]any{
+		"file_path":    filePath,
+		"content":      "new\n",
+		"preview_only": true,
+	})
+	result = AssertToolSuccess(t, result)
+
+	AssertFileContent(t, filePath, "old\n")
+	if result["preview_only"] != true || result["changed"] != true {
+		t.Errorf("unexpected preview result: %v", result)
+	}
+	diff, _ := result["diff"].(string)
+	if !strings.Contains(diff, "-old") || !strings.Contains(diff, "+new") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestWriteTool_NewFileReportsCreation(t *testing.T) {
+	tool, _ := NewWriteTool(nil)
+	helper := NewTestHelper(t)
+	defer helper.CleanupAll()
+
+	filePath := helper.TmpDir + "/created.txt"
+
+	preview := ExecuteToolWithRealFS(t, tool, map[string]any{
+		"file_path":    filePath,
+		"content":      "hello\n",
+		"preview_only": true,
+	})
+	preview = AssertToolSuccess(t, preview)
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatal("preview must not create the file")
+	}
+	if preview["operation"] != "created" || preview["file_existed"] != false {
+		t.Errorf("preview should report creation: %v", preview)
+	}
+
+	result := ExecuteToolWithRealFS(t, tool, map[string]any{
+		"file_path": filePath,
+		"content":   "hello\n",
+	})
+	result = AssertToolSuccess(t, result)
+	if result["operation"] != "created" {
+		t.Errorf("operation = %v, want created", result["operation"])
+	}
+	diff, _ := result["diff"].(string)
+	if !strings.Contains(diff, "--- /dev/null") || !strings.Contains(diff, "+hello") {
+		t.Errorf("new file diff should be against /dev/null:\n%s", diff)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 // CacheStrategy 缓存策略
@@ -18,6 +21,9 @@ const (
 	CacheStrategyMemory CacheStrategy = "memory" // 内存缓存
 	CacheStrategyFile   CacheStrategy = "file"   // 文件缓存
 	CacheStrategyBoth   CacheStrategy = "both"   // 内存+文件双层缓存
+
+	CacheStrategyRedis       CacheStrategy = "redis"        // Redis 共享缓存
+	CacheStrategyMemoryRedis CacheStrategy = "memory_redis" // 内存+Redis 双层缓存
 )
 
 // CacheConfig 缓存配置
@@ -37,8 +43,40 @@ type CacheConfig struct {
 	// MaxMemoryItems 内存缓存最大条目数（0 表示无限制）
 	MaxMemoryItems int
 
-	// MaxFileSize 单个缓存文件最大大小（字节，0 表示无限制）
+	// MaxFileSize 单个缓存文件或 Redis 条目最大大小（字节，0 表示无限制）
 	MaxFileSize int64
+
+	// Redis Redis 连接配置（仅用于 redis 和 memory_redis 策略）
+	Redis *RedisCacheConfig
+
+	// Semantic 语义缓存配置（可选，为 nil 时仅使用精确键匹配）
+	Semantic *SemanticCacheConfig
+
+	// KeyFunc 自定义缓存键（可选），返回用于计算键的规范化字符串
+	// 用于忽略时间戳、请求 ID 等易变字段；返回空字符串时使用完整输入
+	KeyFunc KeyFunc
+}
+
+// KeyFunc 根据工具名和输入计算缓存键的规范化内容
+type KeyFunc func(toolName string, input map[string]any) string
+
+// KeyFromFields 返回只使用指定输入字段计算缓存键的 KeyFunc
+// 未出现在 fields 中的字段（如时间戳、请求 ID）不影响缓存命中
+func KeyFromFields(fields ...string) KeyFunc {
+	return func(toolName string, input map[string]any) string {
+		selected := make(map[string]any, len(fields))
+		for _, field := range fields {
+			if value, ok := input[field]; ok {
+				selected[field] = value
+			}
+		}
+		// map 序列化时键有序，结果与字段顺序无关
+		data, err := json.Marshal(selected)
+		if err != nil {
+			return fmt.Sprintf("%v", selected)
+		}
+		return string(data)
+	}
 }
 
 // DefaultCacheConfig 默认缓存配置
@@ -75,13 +113,22 @@ type ToolCache struct {
 	memoryCache map[string]*CacheEntry
 	memoryMu    sync.RWMutex
 
-	// 统计信息
-	stats *CacheStats
+	// Redis 缓存
+	redis redis.UniversalClient
+
+	// 语义索引
+	semantic *semanticIndex
+
+	// 统计信息（由 statsMu 保护，可在持有 memoryMu 时获取）
+	stats   *CacheStats
+	statsMu sync.Mutex
 }
 
 // CacheStats 缓存统计
 type CacheStats struct {
-	Hits          int64
+	Hits          int64 // 总命中数（ExactHits + SemanticHits）
+	ExactHits     int64
+	SemanticHits  int64
 	Misses        int64
 	Sets          int64
 	Evictions     int64
@@ -100,10 +147,14 @@ func NewToolCache(config *CacheConfig) *ToolCache {
 	cache := &ToolCache{
 		config:      config,
 		memoryCache: make(map[string]*CacheEntry),
+		semantic:    &semanticIndex{entries: make(map[string][]*semanticEntry)},
 		stats: &CacheStats{
 			LastCleanupAt: time.Now(),
 		},
 	}
+	if cache.usesRedis() {
+		cache.redis = newRedisCacheClient(config.Redis)
+	}
 
 	// 启动后台清理任务
 	if config.Enabled {
@@ -114,12 +165,20 @@ func NewToolCache(config *CacheConfig) *ToolCache {
 }
 
 // GenerateKey 生成缓存键
+// 配置了 KeyFunc 时使用其返回的规范化内容，否则使用完整输入
 func (c *ToolCache) GenerateKey(toolName string, input map[string]any) string {
-	// 序列化输入参数
-	data, err := json.Marshal(input)
-	if err != nil {
-		// 如果序列化失败，使用简单的字符串拼接
-		return fmt.Sprintf("%s_%v", toolName, input)
+	var data []byte
+	if c.config.KeyFunc != nil {
+		data = []byte(c.config.KeyFunc(toolName, input))
+	}
+	if len(data) == 0 {
+		// 序列化输入参数
+		var err error
+		data, err = json.Marshal(input)
+		if err != nil {
+			// 如果序列化失败，使用简单的字符串拼接
+			return fmt.Sprintf("%s_%v", toolName, input)
+		}
 	}
 
 	// 计算 SHA256 哈希
@@ -133,30 +192,47 @@ func (c *ToolCache) Get(ctx context.Context, key string) (any, bool) {
 		return nil, false
 	}
 
+	if value, ok := c.get(key); ok {
+		c.statsMu.Lock()
+		c.stats.Hits++
+		c.stats.ExactHits++
+		c.statsMu.Unlock()
+		return value, true
+	}
+
+	c.statsMu.Lock()
+	c.stats.Misses++
+	c.statsMu.Unlock()
+	return nil, false
+}
+
+// get 按精确键查找缓存（不更新统计）
+func (c *ToolCache) get(key string) (any, bool) {
 	// 先尝试内存缓存
-	if c.config.Strategy == CacheStrategyMemory || c.config.Strategy == CacheStrategyBoth {
+	if c.usesMemory() {
 		if value, ok := c.getFromMemory(key); ok {
-			c.stats.Hits++
 			return value, true
 		}
 	}
 
-	// 再尝试文件缓存
-	if c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth {
-		if value, ok := c.getFromFile(key); ok {
-			c.stats.Hits++
-
-			// 如果是双层缓存，将文件缓存加载到内存
-			if c.config.Strategy == CacheStrategyBoth {
-				c.setToMemory(key, value, c.config.TTL)
-			}
-
-			return value, true
-		}
+	// 再尝试文件缓存或 Redis 缓存
+	var entry *CacheEntry
+	var ok bool
+	switch {
+	case c.usesFile():
+		entry, ok = c.getFromFile(key)
+	case c.usesRedis():
+		entry, ok = c.getFromRedis(key)
+	}
+	if !ok {
+		return nil, false
 	}
 
-	c.stats.Misses++
-	return nil, false
+	// 如果是双层缓存，将二级缓存加载到内存（沿用其过期时间）
+	if c.usesMemory() {
+		c.setToMemory(key, entry.Value, time.Until(entry.ExpiresAt))
+	}
+	return entry.Value, true
 }
 
 // Set 设置缓存
@@ -165,21 +241,35 @@ func (c *ToolCache) Set(ctx context.Context, key string, value any, ttl time.Dur
 		return nil
 	}
 
+	c.statsMu.Lock()
 	c.stats.Sets++
+	c.statsMu.Unlock()
 
 	// 设置到内存缓存
-	if c.config.Strategy == CacheStrategyMemory || c.config.Strategy == CacheStrategyBoth {
+	if c.usesMemory() {
 		c.setToMemory(key, value, ttl)
 	}
 
 	// 设置到文件缓存
-	if c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth {
+	if c.usesFile() {
 		if err := c.setToFile(key, value, ttl); err != nil {
+			c.statsMu.Lock()
 			c.stats.Errors++
+			c.statsMu.Unlock()
 			return fmt.Errorf("failed to set file cache: %w", err)
 		}
 	}
 
+	// 设置到 Redis 缓存
+	if c.usesRedis() {
+		if err := c.setToRedis(key, value, ttl); err != nil {
+			c.statsMu.Lock()
+			c.stats.Errors++
+			c.statsMu.Unlock()
+			return fmt.Errorf("failed to set redis cache: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -190,21 +280,44 @@ func (c *ToolCache) Delete(key string) error {
 	}
 
 	// 从内存删除
-	if c.config.Strategy == CacheStrategyMemory || c.config.Strategy == CacheStrategyBoth {
+	if c.usesMemory() {
 		c.deleteFromMemory(key)
 	}
 
 	// 从文件删除
-	if c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth {
+	if c.usesFile() {
 		if err := c.deleteFromFile(key); err != nil {
+			c.statsMu.Lock()
 			c.stats.Errors++
+			c.statsMu.Unlock()
 			return fmt.Errorf("failed to delete file cache: %w", err)
 		}
 	}
 
+	// 从 Redis 删除
+	if c.usesRedis() {
+		if err := c.deleteFromRedis(key); err != nil {
+			c.statsMu.Lock()
+			c.stats.Errors++
+			c.statsMu.Unlock()
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Invalidate 删除工具在该输入下的缓存结果（按 GenerateKey 计算的键），同时移除语义索引
+func (c *ToolCache) Invalidate(toolName string, input map[string]any) error {
+	if !c.config.Enabled {
+		return nil
+	}
+
+	key := c.GenerateKey(toolName, input)
+	c.semantic.remove(toolName, key)
+	return c.Delete(key)
+}
+
 // Clear 清空所有缓存
 func (c *ToolCache) Clear() error {
 	if !c.config.Enabled {
@@ -212,43 +325,94 @@ func (c *ToolCache) Clear() error {
 	}
 
 	// 清空内存缓存
-	if c.config.Strategy == CacheStrategyMemory || c.config.Strategy == CacheStrategyBoth {
+	if c.usesMemory() {
 		c.memoryMu.Lock()
 		c.memoryCache = make(map[string]*CacheEntry)
+		c.statsMu.Lock()
 		c.stats.ItemCount = 0
 		c.stats.TotalSize = 0
+		c.statsMu.Unlock()
 		c.memoryMu.Unlock()
 	}
 
+	// 清空语义索引
+	c.semantic.mu.Lock()
+	c.semantic.entries = make(map[string][]*semanticEntry)
+	c.semantic.mu.Unlock()
+
 	// 清空文件缓存
-	if c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth {
+	if c.usesFile() {
 		if err := os.RemoveAll(c.config.CacheDir); err != nil {
+			c.statsMu.Lock()
 			c.stats.Errors++
+			c.statsMu.Unlock()
 			return fmt.Errorf("failed to clear file cache: %w", err)
 		}
 	}
 
+	// 清空 Redis 缓存（仅当前前缀）
+	if c.usesRedis() {
+		if err := c.clearRedis(); err != nil {
+			c.statsMu.Lock()
+			c.stats.Errors++
+			c.statsMu.Unlock()
+			return err
+		}
+	}
+
 	return nil
 }
 
-// GetStats 获取统计信息
+// GetStats 获取统计信息快照
+// ItemCount 和 TotalSize 只统计本地内存缓存
 func (c *ToolCache) GetStats() *CacheStats {
-	return c.stats
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	stats := *c.stats
+	return &stats
+}
+
+// usesMemory 策略是否包含内存缓存
+func (c *ToolCache) usesMemory() bool {
+	switch c.config.Strategy {
+	case CacheStrategyMemory, CacheStrategyBoth, CacheStrategyMemoryRedis:
+		return true
+	}
+	return false
+}
+
+// usesFile 策略是否包含文件缓存
+func (c *ToolCache) usesFile() bool {
+	return c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth
+}
+
+// usesRedis 策略是否包含 Redis 缓存
+func (c *ToolCache) usesRedis() bool {
+	return c.config.Strategy == CacheStrategyRedis || c.config.Strategy == CacheStrategyMemoryRedis
 }
 
 // 内存缓存操作
 
 func (c *ToolCache) getFromMemory(key string) (any, bool) {
 	c.memoryMu.RLock()
-	defer c.memoryMu.RUnlock()
-
 	entry, ok := c.memoryCache[key]
+	c.memoryMu.RUnlock()
 	if !ok {
 		return nil, false
 	}
 
-	// 检查是否过期
+	// 过期条目在读取时删除，不等待后台清理
 	if entry.IsExpired() {
+		c.memoryMu.Lock()
+		if c.memoryCache[key] == entry {
+			delete(c.memoryCache, key)
+			c.statsMu.Lock()
+			c.stats.ItemCount--
+			c.stats.TotalSize -= entry.Size
+			c.stats.Evictions++
+			c.statsMu.Unlock()
+		}
+		c.memoryMu.Unlock()
 		return nil, false
 	}
 
@@ -275,9 +439,17 @@ func (c *ToolCache) setToMemory(key string, value any, ttl time.Duration) {
 		Size:      size,
 	}
 
+	if old, ok := c.memoryCache[key]; ok {
+		c.statsMu.Lock()
+		c.stats.ItemCount--
+		c.stats.TotalSize -= old.Size
+		c.statsMu.Unlock()
+	}
 	c.memoryCache[key] = entry
+	c.statsMu.Lock()
 	c.stats.ItemCount++
 	c.stats.TotalSize += size
+	c.statsMu.Unlock()
 }
 
 func (c *ToolCache) deleteFromMemory(key string) {
@@ -286,8 +458,10 @@ func (c *ToolCache) deleteFromMemory(key string) {
 
 	if entry, ok := c.memoryCache[key]; ok {
 		delete(c.memoryCache, key)
+		c.statsMu.Lock()
 		c.stats.ItemCount--
 		c.stats.TotalSize -= entry.Size
+		c.statsMu.Unlock()
 	}
 }
 
@@ -306,16 +480,18 @@ func (c *ToolCache) evictOldest() {
 	if oldestKey != "" {
 		if entry, ok := c.memoryCache[oldestKey]; ok {
 			delete(c.memoryCache, oldestKey)
+			c.statsMu.Lock()
 			c.stats.ItemCount--
 			c.stats.TotalSize -= entry.Size
 			c.stats.Evictions++
+			c.statsMu.Unlock()
 		}
 	}
 }
 
 // 文件缓存操作
 
-func (c *ToolCache) getFromFile(key string) (any, bool) {
+func (c *ToolCache) getFromFile(key string) (*CacheEntry, bool) {
 	filePath := c.getCacheFilePath(key)
 
 	// 读取文件
@@ -327,18 +503,23 @@ func (c *ToolCache) getFromFile(key string) (any, bool) {
 	// 反序列化
 	var entry CacheEntry
 	if err := json.Unmarshal(data, &entry); err != nil {
+		c.statsMu.Lock()
 		c.stats.Errors++
+		c.statsMu.Unlock()
 		return nil, false
 	}
 
-	// 检查是否过期
+	// 过期条目在读取时删除，不等待后台清理
 	if entry.IsExpired() {
-		// 删除过期文件
-		_ = os.Remove(filePath)
+		if err := os.Remove(filePath); err == nil {
+			c.statsMu.Lock()
+			c.stats.Evictions++
+			c.statsMu.Unlock()
+		}
 		return nil, false
 	}
 
-	return entry.Value, true
+	return &entry, true
 }
 
 func (c *ToolCache) setToFile(key string, value any, ttl time.Duration) error {
@@ -398,15 +579,17 @@ func (c *ToolCache) cleanupLoop() {
 }
 
 func (c *ToolCache) cleanup() {
+	c.statsMu.Lock()
 	c.stats.LastCleanupAt = time.Now()
+	c.statsMu.Unlock()
 
 	// 清理内存缓存
-	if c.config.Strategy == CacheStrategyMemory || c.config.Strategy == CacheStrategyBoth {
+	if c.usesMemory() {
 		c.cleanupMemory()
 	}
 
-	// 清理文件缓存
-	if c.config.Strategy == CacheStrategyFile || c.config.Strategy == CacheStrategyBoth {
+	// 清理文件缓存；Redis 条目由 EXPIRE 自动过期
+	if c.usesFile() {
 		c.cleanupFiles()
 	}
 }
@@ -418,9 +601,11 @@ func (c *ToolCache) cleanupMemory() {
 	for key, entry := range c.memoryCache {
 		if entry.IsExpired() {
 			delete(c.memoryCache, key)
+			c.statsMu.Lock()
 			c.stats.ItemCount--
 			c.stats.TotalSize -= entry.Size
 			c.stats.Evictions++
+			c.statsMu.Unlock()
 		}
 	}
 }
@@ -432,7 +617,9 @@ func (c *ToolCache) cleanupFiles() {
 
 	entries, err := os.ReadDir(c.config.CacheDir)
 	if err != nil {
+		c.statsMu.Lock()
 		c.stats.Errors++
+		c.statsMu.Unlock()
 		return
 	}
 
@@ -456,15 +643,19 @@ func (c *ToolCache) cleanupFiles() {
 
 		if cacheEntry.IsExpired() {
 			_ = os.Remove(filePath)
+			c.statsMu.Lock()
 			c.stats.Evictions++
+			c.statsMu.Unlock()
 		}
 	}
 }
 
 // CachedTool 带缓存的工具包装器
+// 缓存未命中时，相同输入的并发调用只执行一次，其余调用等待共享结果
 type CachedTool struct {
-	tool  Tool
-	cache *ToolCache
+	tool   Tool
+	cache  *ToolCache
+	flight singleflight.Group
 }
 
 // NewCachedTool 创建带缓存的工具
@@ -496,26 +687,63 @@ func (ct *CachedTool) Prompt() string {
 }
 
 // Execute 实现 Tool 接口（带缓存）
+// 语义缓存仅对只读工具生效，避免对有副作用的工具返回近似输入的结果
 func (ct *CachedTool) Execute(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
-	// 生成缓存键
-	key := ct.cache.GenerateKey(ct.tool.Name(), input)
+	if !ct.cache.config.Enabled {
+		return ct.tool.Execute(ctx, input, tc)
+	}
+
+	readOnly := ct.readOnly()
 
 	// 尝试从缓存获取
-	if cached, ok := ct.cache.Get(ctx, key); ok {
+	if cached, hit := ct.cache.Lookup(ctx, ct.tool.Name(), input, readOnly); hit != CacheHitNone {
 		return cached, nil
 	}
 
-	// 执行工具
-	result, err := ct.tool.Execute(ctx, input, tc)
-	if err != nil {
-		return nil, err
-	}
+	// 相同键的并发调用共享一次执行；共享执行不随单个调用方取消，但保留其超时
+	key := ct.cache.GenerateKey(ct.tool.Name(), input)
+	ch := ct.flight.DoChan(key, func() (any, error) {
+		// 等待期间可能已有其他调用写入缓存
+		if cached, ok := ct.cache.get(key); ok {
+			return cached, nil
+		}
+
+		execCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			execCtx, cancel = context.WithDeadline(execCtx, deadline)
+			defer cancel()
+		}
+
+		// 执行工具，错误不缓存
+		result, err := ct.tool.Execute(execCtx, input, tc)
+		if err != nil {
+			return nil, err
+		}
 
-	// 缓存结果
-	if err := ct.cache.Set(ctx, key, result, ct.cache.config.TTL); err != nil {
-		// 缓存失败不影响结果返回，只记录错误
-		_ = err // 忽略缓存错误
+		// 缓存结果
+		if err := ct.cache.Store(execCtx, ct.tool.Name(), input, result, readOnly); err != nil {
+			// 缓存失败不影响结果返回，只记录错误
+			_ = err // 忽略缓存错误
+		}
+		return result, nil
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+}
+
+// Annotations 透传被包装工具的安全注解
+func (ct *CachedTool) Annotations() *ToolAnnotations {
+	return GetAnnotations(ct.tool)
+}
 
-	return result, nil
+// readOnly 被包装的工具是否声明为只读
+func (ct *CachedTool) readOnly() bool {
+	annotations := GetAnnotations(ct.tool)
+	return annotations != nil && annotations.ReadOnly
 }
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisCachePrefix  = "aster:toolcache:"
+	defaultRedisCacheTimeout = 500 * time.Millisecond
+)
+
+// RedisCacheConfig Redis 缓存配置（用于 redis 和 memory_redis 策略）
+type RedisCacheConfig struct {
+	Addr     string        // Redis 地址，格式: "host:port"
+	Password string        // 密码
+	DB       int           // 数据库编号
+	Prefix   string        // Key 前缀，默认 "aster:toolcache:"
+	Timeout  time.Duration // 单次操作超时，默认 500ms；超时视为未命中
+
+	// Client 复用已有的 Redis 客户端（可选），设置后忽略 Addr/Password/DB
+	Client redis.UniversalClient
+}
+
+// newRedisCacheClient 创建 Redis 客户端，不在创建时连接
+// Redis 不可用时读取视为未命中，写入返回错误，不影响工具执行
+func newRedisCacheClient(config *RedisCacheConfig) redis.UniversalClient {
+	if config == nil {
+		return nil
+	}
+	if config.Client != nil {
+		return config.Client
+	}
+	timeout := config.redisTimeout()
+	return redis.NewClient(&redis.Options{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		MaxRetries:   -1, // 缓存读写失败直接降级，不重试
+	})
+}
+
+func (c *RedisCacheConfig) redisPrefix() string {
+	if c == nil || c.Prefix == "" {
+		return defaultRedisCachePrefix
+	}
+	return c.Prefix
+}
+
+func (c *RedisCacheConfig) redisTimeout() time.Duration {
+	if c == nil || c.Timeout <= 0 {
+		return defaultRedisCacheTimeout
+	}
+	return c.Timeout
+}
+
+// Redis 缓存操作
+
+func (c *ToolCache) redisKey(key string) string {
+	return c.config.Redis.redisPrefix() + key
+}
+
+func (c *ToolCache) redisContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.config.Redis.redisTimeout())
+}
+
+func (c *ToolCache) getFromRedis(key string) (*CacheEntry, bool) {
+	if c.redis == nil {
+		return nil, false
+	}
+
+	ctx, cancel := c.redisContext()
+	defer cancel()
+
+	data, err := c.redis.Get(ctx, c.redisKey(key)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.statsMu.Lock()
+			c.stats.Errors++
+			c.statsMu.Unlock()
+		}
+		return nil, false
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		c.statsMu.Lock()
+		c.stats.Errors++
+		c.statsMu.Unlock()
+		return nil, false
+	}
+
+	// Redis 按 EXPIRE 删除过期键，这里只防御时钟偏差
+	if entry.IsExpired() {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (c *ToolCache) setToRedis(key string, value any, ttl time.Duration) error {
+	if c.redis == nil {
+		return errors.New("redis cache is not configured")
+	}
+
+	entry := &CacheEntry{
+		Key:       key,
+		Value:     value,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if c.config.MaxFileSize > 0 && int64(len(data)) > c.config.MaxFileSize {
+		return fmt.Errorf("cache entry too large: %d bytes (max: %d)", len(data), c.config.MaxFileSize)
+	}
+
+	ctx, cancel := c.redisContext()
+	defer cancel()
+
+	if err := c.redis.Set(ctx, c.redisKey(key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write redis cache: %w", err)
+	}
+	return nil
+}
+
+func (c *ToolCache) deleteFromRedis(key string) error {
+	if c.redis == nil {
+		return nil
+	}
+
+	ctx, cancel := c.redisContext()
+	defer cancel()
+
+	if err := c.redis.Del(ctx, c.redisKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to delete redis cache: %w", err)
+	}
+	return nil
+}
+
+// clearRedis 删除当前前缀下的所有缓存键
+func (c *ToolCache) clearRedis() error {
+	if c.redis == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*c.config.Redis.redisTimeout())
+	defer cancel()
+
+	iter := c.redis.Scan(ctx, 0, c.config.Redis.redisPrefix()+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to clear redis cache: %w", err)
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan redis cache: %w", err)
+	}
+	if len(keys) > 0 {
+		if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to clear redis cache: %w", err)
+		}
+	}
+	return nil
+}
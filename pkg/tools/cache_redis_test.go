@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// setupRedisCacheClient 启动 Redis 容器并返回客户端，Docker 不可用时跳过
+func setupRedisCacheClient(t *testing.T) redis.UniversalClient {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("Skipping Redis integration test in short mode")
+	}
+	if os.Getenv("SKIP_INTEGRATION_TESTS") != "" {
+		t.Skip("Skipping Redis integration test (SKIP_INTEGRATION_TESTS is set)")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker not available, skipping Redis integration test: %v", r)
+		}
+	}()
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Skipf("Failed to start Redis container (Docker may not be available): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Skipf("Failed to get container host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		t.Skipf("Failed to get container port: %v", err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, port.Port())})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestToolCache_RedisSharedAcrossInstances(t *testing.T) {
+	client := setupRedisCacheClient(t)
+	ctx := context.Background()
+
+	newCache := func(strategy CacheStrategy) *ToolCache {
+		return NewToolCache(&CacheConfig{
+			Enabled:  true,
+			Strategy: strategy,
+			TTL:      time.Hour,
+			Redis:    &RedisCacheConfig{Client: client, Prefix: "test:toolcache:"},
+		})
+	}
+	writer := newCache(CacheStrategyRedis)
+	reader := newCache(CacheStrategyMemoryRedis)
+
+	input := map[string]any{"url": "https://example.com"}
+	if err := writer.Store(ctx, "fetch", input, map[string]any{"status": float64(200)}, false); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	// TTL 通过 Redis 过期时间生效
+	ttl := client.TTL(ctx, "test:toolcache:"+writer.GenerateKey("fetch", input)).Val()
+	if ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Expected redis TTL within 1h, got: %v", ttl)
+	}
+
+	// 另一个实例从 Redis 读取，并加载到本地内存
+	value, hit := reader.Lookup(ctx, "fetch", input, false)
+	if hit != CacheHitExact {
+		t.Fatal("Expected hit from shared redis cache")
+	}
+	if value.(map[string]any)["status"] != float64(200) {
+		t.Errorf("Unexpected value: %v", value)
+	}
+	if stats := reader.GetStats(); stats.Hits != 1 || stats.ItemCount != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// 失效后两个实例都不再命中 Redis
+	if err := writer.Invalidate("fetch", input); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if _, hit := writer.Lookup(ctx, "fetch", input, false); hit != CacheHitNone {
+		t.Error("Invalidated entry should miss")
+	}
+
+	// Clear 只删除当前前缀下的键
+	_ = writer.Store(ctx, "fetch", input, "again", false)
+	client.Set(ctx, "other:key", "keep", 0)
+	if err := writer.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if n := client.Exists(ctx, "other:key").Val(); n != 1 {
+		t.Error("Clear should not delete keys outside the prefix")
+	}
+	if _, hit := writer.Lookup(ctx, "fetch", input, false); hit != CacheHitNone {
+		t.Error("Cleared entry should miss")
+	}
+}
+
+func TestToolCache_RedisUnreachableDegradesToMiss(t *testing.T) {
+	cache := NewToolCache(&CacheConfig{
+		Enabled:  true,
+		Strategy: CacheStrategyMemoryRedis,
+		TTL:      time.Hour,
+		Redis:    &RedisCacheConfig{Addr: "127.0.0.1:1", Timeout: 100 * time.Millisecond},
+	})
+	ctx := context.Background()
+	input := map[string]any{"url": "https://example.com"}
+
+	if _, hit := cache.Lookup(ctx, "fetch", input, false); hit != CacheHitNone {
+		t.Fatal("Unreachable redis should be treated as a miss")
+	}
+	if err := cache.Store(ctx, "fetch", input, "page", false); err == nil {
+		t.Error("Store should report the redis error")
+	}
+
+	// 内存层仍然可用
+	if _, hit := cache.Lookup(ctx, "fetch", input, false); hit != CacheHitExact {
+		t.Error("Memory layer should still serve the value")
+	}
+	stats := cache.GetStats()
+	if stats.Errors != 2 || stats.Misses != 1 || stats.Hits != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// 包装的工具照常执行
+	calls := 0
+	tool := &MockTool{
+		name: "search",
+		executeFunc: func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			calls++
+			return "result", nil
+		},
+	}
+	redisOnly := NewToolCache(&CacheConfig{
+		Enabled:  true,
+		Strategy: CacheStrategyRedis,
+		TTL:      time.Hour,
+		Redis:    &RedisCacheConfig{Addr: "127.0.0.1:1", Timeout: 100 * time.Millisecond},
+	})
+	cached := NewCachedTool(tool, redisOnly)
+	for range 2 {
+		result, err := cached.Execute(ctx, map[string]any{"q": "go"}, nil)
+		if err != nil || result != "result" {
+			t.Fatalf("Execute should succeed without redis, got %v, %v", result, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected tool to execute on every call without redis, got: %d", calls)
+	}
+}
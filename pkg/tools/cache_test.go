@@ -2,7 +2,10 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -546,3 +549,252 @@ func TestToolCache_Cleanup(t *testing.T) {
 		t.Errorf("Expected 5 evictions, got: %d", stats.Evictions)
 	}
 }
+
+func TestToolCache_KeyFunc(t *testing.T) {
+	cache := NewToolCache(&CacheConfig{
+		Enabled:  true,
+		Strategy: CacheStrategyMemory,
+		TTL:      time.Hour,
+		KeyFunc:  KeyFromFields("query", "limit"),
+	})
+
+	key1 := cache.GenerateKey("search", map[string]any{"query": "go", "limit": 10, "request_id": "a", "timestamp": 1})
+	key2 := cache.GenerateKey("search", map[string]any{"timestamp": 2, "limit": 10, "query": "go", "request_id": "b"})
+	if key1 != key2 {
+		t.Errorf("Volatile fields should not affect the key: %s != %s", key1, key2)
+	}
+	if key3 := cache.GenerateKey("search", map[string]any{"query": "rust", "limit": 10}); key3 == key1 {
+		t.Error("Selected fields should affect the key")
+	}
+	if key4 := cache.GenerateKey("fetch", map[string]any{"query": "go", "limit": 10}); key4 == key1 {
+		t.Error("Different tools should have different keys")
+	}
+
+	// 通过 CachedTool 调用时易变字段不影响命中
+	tool := &MockTool{name: "search"}
+	cached := NewCachedTool(tool, cache)
+	ctx := context.Background()
+	for i := range 3 {
+		if _, err := cached.Execute(ctx, map[string]any{"query": "go", "request_id": i}, nil); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+	}
+	if tool.callCount != 1 {
+		t.Errorf("Expected 1 execution, got: %d", tool.callCount)
+	}
+
+	// 返回空字符串时使用完整输入
+	fallback := NewToolCache(&CacheConfig{
+		Enabled: true,
+		KeyFunc: func(string, map[string]any) string { return "" },
+	})
+	if fallback.GenerateKey("t", map[string]any{"a": 1}) == fallback.GenerateKey("t", map[string]any{"a": 2}) {
+		t.Error("Empty KeyFunc result should fall back to the full input")
+	}
+}
+
+func TestToolCache_Invalidate(t *testing.T) {
+	for _, strategy := range []CacheStrategy{CacheStrategyMemory, CacheStrategyFile, CacheStrategyBoth} {
+		t.Run(string(strategy), func(t *testing.T) {
+			cache := NewToolCache(&CacheConfig{
+				Enabled:  true,
+				Strategy: strategy,
+				TTL:      time.Hour,
+				CacheDir: t.TempDir(),
+			})
+			tool := &MockTool{name: "fetch"}
+			cached := NewCachedTool(tool, cache)
+			ctx := context.Background()
+			input := map[string]any{"url": "https://example.com"}
+			other := map[string]any{"url": "https://example.org"}
+
+			for _, in := range []map[string]any{input, input, other} {
+				if _, err := cached.Execute(ctx, in, nil); err != nil {
+					t.Fatalf("Execute failed: %v", err)
+				}
+			}
+			if tool.callCount != 2 {
+				t.Fatalf("Expected 2 executions, got: %d", tool.callCount)
+			}
+
+			if err := cache.Invalidate("fetch", input); err != nil {
+				t.Fatalf("Invalidate failed: %v", err)
+			}
+			if _, hit := cache.Lookup(ctx, "fetch", input, false); hit != CacheHitNone {
+				t.Error("Invalidated entry should miss")
+			}
+			if _, hit := cache.Lookup(ctx, "fetch", other, false); hit != CacheHitExact {
+				t.Error("Other entries should be kept")
+			}
+
+			if _, err := cached.Execute(ctx, input, nil); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if tool.callCount != 3 {
+				t.Errorf("Invalidated input should execute again, got %d executions", tool.callCount)
+			}
+
+			if err := cache.Clear(); err != nil {
+				t.Fatalf("Clear failed: %v", err)
+			}
+			if _, hit := cache.Lookup(ctx, "fetch", other, false); hit != CacheHitNone {
+				t.Error("Clear should remove all entries")
+			}
+		})
+	}
+}
+
+func TestToolCache_FileExpiresLazilyOnRead(t *testing.T) {
+	cache := NewToolCache(&CacheConfig{
+		Enabled:  true,
+		Strategy: CacheStrategyBoth,
+		TTL:      time.Hour,
+		CacheDir: t.TempDir(),
+	})
+	ctx := context.Background()
+
+	if err := cache.setToFile("short", "value", 50*time.Millisecond); err != nil {
+		t.Fatalf("setToFile failed: %v", err)
+	}
+
+	// 从文件加载到内存时沿用原过期时间，而不是重新计算 TTL
+	if _, ok := cache.Get(ctx, "short"); !ok {
+		t.Fatal("Expected cache hit before expiration")
+	}
+	time.Sleep(80 * time.Millisecond)
+
+	if _, ok := cache.Get(ctx, "short"); ok {
+		t.Fatal("Expected cache miss after expiration")
+	}
+	if _, err := os.Stat(cache.getCacheFilePath("short")); !os.IsNotExist(err) {
+		t.Error("Expired cache file should be removed on read")
+	}
+	if stats := cache.GetStats(); stats.ItemCount != 0 || stats.Evictions != 2 {
+		t.Errorf("Expected expired entries evicted from both layers, got items=%d evictions=%d", stats.ItemCount, stats.Evictions)
+	}
+}
+
+func TestCachedTool_DeduplicatesConcurrentMisses(t *testing.T) {
+	var executions atomic.Int32
+	release := make(chan struct{})
+	tool := &MockTool{
+		name: "fetch",
+		executeFunc: func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			executions.Add(1)
+			<-release
+			return "page", nil
+		},
+	}
+	cache := NewToolCache(&CacheConfig{Enabled: true, Strategy: CacheStrategyMemory, TTL: time.Hour})
+	cached := NewCachedTool(tool, cache)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan any, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := cached.Execute(context.Background(), map[string]any{"url": "https://example.com"}, nil)
+			if err != nil {
+				t.Errorf("Execute failed: %v", err)
+			}
+			results <- result
+		}()
+	}
+
+	// 等待第一次执行开始后再放行，确保其余调用在等待共享结果
+	for executions.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if n := executions.Load(); n != 1 {
+		t.Errorf("Expected 1 execution, got: %d", n)
+	}
+	for result := range results {
+		if result != "page" {
+			t.Errorf("Expected shared result, got: %v", result)
+		}
+	}
+	if _, hit := cache.Lookup(context.Background(), "fetch", map[string]any{"url": "https://example.com"}, false); hit != CacheHitExact {
+		t.Error("Shared result should be cached")
+	}
+}
+
+func TestCachedTool_WaiterCancellationDoesNotCancelSharedWork(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var sharedErr error
+	tool := &MockTool{
+		name: "fetch",
+		executeFunc: func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			close(started)
+			<-release
+			sharedErr = ctx.Err()
+			return "page", nil
+		},
+	}
+	cached := NewCachedTool(tool, NewToolCache(&CacheConfig{Enabled: true, Strategy: CacheStrategyMemory, TTL: time.Hour}))
+	input := map[string]any{"url": "https://example.com"}
+
+	// 第一个调用方发起执行后取消
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := cached.Execute(ctx, input, nil)
+		firstDone <- err
+	}()
+	<-started
+
+	secondDone := make(chan any, 1)
+	go func() {
+		result, err := cached.Execute(context.Background(), input, nil)
+		if err != nil {
+			t.Errorf("Second caller failed: %v", err)
+		}
+		secondDone <- result
+	}()
+
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("Canceled caller should return context.Canceled, got: %v", err)
+	}
+
+	close(release)
+	if result := <-secondDone; result != "page" {
+		t.Errorf("Expected shared result, got: %v", result)
+	}
+	if sharedErr != nil {
+		t.Errorf("Shared execution should not be canceled, got: %v", sharedErr)
+	}
+}
+
+func TestCachedTool_ErrorsAreNotCached(t *testing.T) {
+	var executions atomic.Int32
+	tool := &MockTool{
+		name: "fetch",
+		executeFunc: func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			if executions.Add(1) == 1 {
+				return nil, errors.New("temporary failure")
+			}
+			return "page", nil
+		},
+	}
+	cached := NewCachedTool(tool, NewToolCache(&CacheConfig{Enabled: true, Strategy: CacheStrategyMemory, TTL: time.Hour}))
+	input := map[string]any{"url": "https://example.com"}
+
+	if _, err := cached.Execute(context.Background(), input, nil); err == nil {
+		t.Fatal("Expected first call to fail")
+	}
+	result, err := cached.Execute(context.Background(), input, nil)
+	if err != nil || result != "page" {
+		t.Fatalf("Expected retry to execute again, got %v, %v", result, err)
+	}
+	if n := executions.Load(); n != 2 {
+		t.Errorf("Expected 2 executions, got: %d", n)
+	}
+}
@@ -0,0 +1,32 @@
+package tools
+
+import "time"
+
+// Clock 时钟接口
+// 工具和 Agent 通过 Clock 获取当前时间，测试中可注入固定时钟保证结果确定。
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FixedClock 始终返回固定时间的时钟（用于测试）
+type FixedClock struct {
+	Time time.Time
+}
+
+// Now 返回固定时间
+func (c FixedClock) Now() time.Time { return c.Time }
+
+// ClockFrom 返回工具上下文中的时钟，未设置时返回系统时钟
+func ClockFrom(tc *ToolContext) Clock {
+	if tc == nil || tc.Clock == nil {
+		return SystemClock
+	}
+	return tc.Clock
+}
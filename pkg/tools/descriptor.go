@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"slices"
+	"sort"
+)
+
+// ToolDescriptor 工具描述信息
+// 用于动态工具选择器、文档生成等需要内省工具的场景
+type ToolDescriptor struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	InputSchema map[string]any   `json:"input_schema"`
+	Tags        []string         `json:"tags,omitempty"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+
+	// SideEffecting 是否有副作用（未声明只读的工具均视为有副作用）
+	SideEffecting bool `json:"side_effecting"`
+}
+
+// RegisterWithTags 注册工具并附加能力标签（如 "filesystem"、"network"）
+func (r *Registry) RegisterWithTags(name string, factory ToolFactory, tags ...string) {
+	r.Register(name, factory)
+	r.AddTags(name, tags...)
+}
+
+// AddTags 为已注册的工具追加标签，重复标签会被忽略
+func (r *Registry) AddTags(name string, tags ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range tags {
+		if tag != "" && !slices.Contains(r.tags[name], tag) {
+			r.tags[name] = append(r.tags[name], tag)
+		}
+	}
+}
+
+// Tags 返回工具的标签
+func (r *Registry) Tags(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.tags[name])
+}
+
+// FindByTag 返回带有指定标签的工具名（按名称排序）
+func (r *Registry) FindByTag(tag string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0)
+	for name, tags := range r.tags {
+		if _, ok := r.factories[name]; ok && slices.Contains(tags, tag) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Describe 返回工具的描述信息
+// 会使用空配置创建一次工具实例以读取描述和 Schema
+func (r *Registry) Describe(name string) (*ToolDescriptor, error) {
+	tool, err := r.Create(name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := GetAnnotations(tool)
+	return &ToolDescriptor{
+		Name:          name,
+		Description:   tool.Description(),
+		InputSchema:   tool.InputSchema(),
+		Tags:          r.Tags(name),
+		Annotations:   annotations,
+		SideEffecting: annotations == nil || !annotations.ReadOnly,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// readOnlyTestTool 只读测试工具
+type readOnlyTestTool struct {
+	MockTool
+}
+
+func (t *readOnlyTestTool) Annotations() *ToolAnnotations {
+	return AnnotationsSafeReadOnly
+}
+
+func TestRegistry_FindByTag(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterWithTags("Read", func(map[string]any) (Tool, error) {
+		return &readOnlyTestTool{MockTool{name: "Read", description: "read files"}}, nil
+	}, "filesystem", "read")
+	registry.RegisterWithTags("Write", func(map[string]any) (Tool, error) {
+		return &MockTool{name: "Write", description: "write files"}, nil
+	}, "filesystem")
+	registry.Register("Bash", func(map[string]any) (Tool, error) {
+		return &MockTool{name: "Bash"}, nil
+	})
+
+	fsTools := registry.FindByTag("filesystem")
+	if len(fsTools) != 2 || fsTools[0] != "Read" || fsTools[1] != "Write" {
+		t.Errorf("Expected [Read Write], got %v", fsTools)
+	}
+	if got := registry.FindByTag("network"); len(got) != 0 {
+		t.Errorf("Expected no tools for unknown tag, got %v", got)
+	}
+
+	// 未带标签注册的工具仍可正常使用
+	if _, err := registry.Create("Bash", nil); err != nil {
+		t.Errorf("Expected untagged tool to be created: %v", err)
+	}
+	if len(registry.Tags("Bash")) != 0 {
+		t.Errorf("Expected no tags for Bash, got %v", registry.Tags("Bash"))
+	}
+}
+
+func TestRegistry_Unregister(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterWithTags("Read", func(map[string]any) (Tool, error) {
+		return &MockTool{name: "Read"}, nil
+	}, "filesystem")
+
+	if !registry.Unregister("Read") {
+		t.Fatal("Expected Unregister to report an existing tool")
+	}
+	if registry.Has("Read") || len(registry.Tags("Read")) != 0 {
+		t.Error("Expected tool and tags to be removed")
+	}
+	if registry.Unregister("Read") {
+		t.Error("Expected Unregister to report a missing tool")
+	}
+}
+
+func TestRegistry_Describe(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterWithTags("Read", func(map[string]any) (Tool, error) {
+		return &readOnlyTestTool{MockTool{name: "Read", description: "read files"}}, nil
+	}, "filesystem")
+	registry.Register("Write", func(map[string]any) (Tool, error) {
+		return &MockTool{name: "Write", description: "write files"}, nil
+	})
+
+	desc, err := registry.Describe("Read")
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	if desc.Description != "read files" || desc.SideEffecting {
+		t.Errorf("Unexpected descriptor: %+v", desc)
+	}
+	props, ok := desc.InputSchema["properties"].(map[string]any)
+	if !ok || props["input"] == nil {
+		t.Errorf("Expected input schema properties, got %v", desc.InputSchema)
+	}
+	if len(desc.Tags) != 1 || desc.Tags[0] != "filesystem" {
+		t.Errorf("Expected filesystem tag, got %v", desc.Tags)
+	}
+
+	write, err := registry.Describe("Write")
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	if !write.SideEffecting {
+		t.Error("Expected tool without annotations to be side-effecting")
+	}
+
+	if _, err := registry.Describe("Missing"); err == nil {
+		t.Error("Expected error for unknown tool")
+	}
+
+}
+
+func TestRegistry_ConcurrentAccess(t *testing.T) {
+	registry := NewRegistry()
+	factory := func(map[string]any) (Tool, error) {
+		return &MockTool{name: "tool"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			registry.RegisterWithTags(fmt.Sprintf("tool-%d", i), factory, "network")
+		}()
+		go func() {
+			defer wg.Done()
+			registry.FindByTag("network")
+			registry.Tags("tool-0")
+			registry.List()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(registry.FindByTag("network")); got != 20 {
+		t.Errorf("Expected 20 tagged tools, got %d", got)
+	}
+}
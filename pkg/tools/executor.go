@@ -2,10 +2,11 @@ package tools
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/astercloud/aster/pkg/sandbox"
 	"github.com/astercloud/aster/pkg/types"
 )
 
@@ -13,6 +14,11 @@ import (
 type ExecutorConfig struct {
 	MaxConcurrency int           // 最大并发数
 	DefaultTimeout time.Duration // 默认超时时间
+	ValidateInputs bool          // 执行前按 InputSchema 校验所有工具的输入
+
+	RateLimit        types.RateLimit            // 每个工具的默认限流（令牌桶，按工具名分别计数）
+	ToolRateLimits   map[string]types.RateLimit // 按工具名覆盖默认限流，QPS <= 0 表示该工具不限流
+	RateLimitMaxWait time.Duration              // 超出限流时最多等待的时长，0 表示立即拒绝
 }
 
 // Executor 工具执行器
@@ -20,6 +26,7 @@ type Executor struct {
 	config    ExecutorConfig
 	semaphore chan struct{}
 	running   sync.WaitGroup
+	limiter   *rateLimiter
 }
 
 // NewExecutor 创建工具执行器
@@ -34,6 +41,7 @@ func NewExecutor(config ExecutorConfig) *Executor {
 	return &Executor{
 		config:    config,
 		semaphore: make(chan struct{}, config.MaxConcurrency),
+		limiter:   newRateLimiter(config.RateLimit, config.ToolRateLimits, config.RateLimitMaxWait),
 	}
 }
 
@@ -45,6 +53,10 @@ type ExecuteRequest struct {
 	Timeout time.Duration
 }
 
+// ErrFatal 工具遇到模型无法通过调整调用恢复的错误（如凭证失效、依赖服务不可用）
+// 工具返回包装了 ErrFatal 的错误时，Agent 保存工具结果后停止本次运行，而不是把错误交给模型重试。
+var ErrFatal = errors.New("fatal tool error")
+
 // ExecuteResult 执行结果
 type ExecuteResult struct {
 	Success    bool
@@ -59,6 +71,16 @@ type ExecuteResult struct {
 func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteResult {
 	startTime := time.Now()
 
+	// 限流：在获取信号量之前等待，避免排队的调用占用并发名额
+	if err := e.waitRateLimit(ctx, req.Tool.Name()); err != nil {
+		return &ExecuteResult{
+			Success:   false,
+			Error:     err,
+			StartedAt: startTime,
+			EndedAt:   time.Now(),
+		}
+	}
+
 	// 获取信号量
 	select {
 	case e.semaphore <- struct{}{}:
@@ -81,8 +103,18 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// 执行工具
-	output, err := req.Tool.Execute(execCtx, req.Input, req.Context)
+	// 记录工具名，沙箱据此注入该工具可用的密钥
+	execCtx = sandbox.WithToolName(execCtx, req.Tool.Name())
+
+	// 执行工具（ValidatedTool 自行校验，这里不重复）
+	var output any
+	var err error
+	if _, validated := req.Tool.(*ValidatedTool); e.config.ValidateInputs && !validated {
+		err = ValidateInput(req.Tool, req.Input)
+	}
+	if err == nil {
+		output, err = req.Tool.Execute(execCtx, req.Input, req.Context)
+	}
 	endTime := time.Now()
 
 	result := &ExecuteResult{
@@ -97,6 +129,24 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 	return result
 }
 
+// waitRateLimit 按工具限流等待，超出可等待时长时返回 *RateLimitedError
+func (e *Executor) waitRateLimit(ctx context.Context, toolName string) error {
+	wait, cancel, err := e.limiter.reserve(toolName)
+	if err != nil || wait <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
 // ExecuteBatch 批量执行工具
 func (e *Executor) ExecuteBatch(ctx context.Context, requests []*ExecuteRequest) []*ExecuteResult {
 	results := make([]*ExecuteResult, len(requests))
@@ -119,27 +169,6 @@ func (e *Executor) Wait() {
 	e.running.Wait()
 }
 
-// ValidateInput 验证工具输入
-func ValidateInput(tool Tool, input map[string]any) error {
-	schema := tool.InputSchema()
-	if schema == nil {
-		return nil // 没有schema,跳过验证
-	}
-
-	// TODO: 使用jsonschema库进行验证
-	// 这里先做简单的required字段检查
-	if required, ok := schema["required"].([]any); ok {
-		for _, field := range required {
-			fieldName := field.(string)
-			if _, exists := input[fieldName]; !exists {
-				return fmt.Errorf("missing required field: %s", fieldName)
-			}
-		}
-	}
-
-	return nil
-}
-
 // ToolCallRecordBuilder 工具调用记录构建器
 type ToolCallRecordBuilder struct {
 	record *types.ToolCallRecord
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"sync"
 
 	"github.com/astercloud/aster/pkg/sandbox"
 )
@@ -26,6 +27,8 @@ type ToolContext struct {
 	ThreadID   string              // Working Memory 会话 ID
 	ResourceID string              // Working Memory 资源 ID
 	MCPManager MCPManagerInterface // MCP 管理器，用于访问 MCP 资源
+	Clock      Clock               // 时钟（nil 使用系统时钟）
+	CallID     string              // 工具调用 ID（run-{runID}-tc-{n}），与工具事件和调用记录一致，可用于日志关联
 }
 
 // Reporter 工具执行实时反馈接口
@@ -150,25 +153,46 @@ type ToolConfig struct {
 type ToolFactory func(config map[string]any) (Tool, error)
 
 // Registry 工具注册表
+// 可并发使用
 type Registry struct {
+	mu        sync.RWMutex
 	factories map[string]ToolFactory
+	tags      map[string][]string
 }
 
 // NewRegistry 创建工具注册表
 func NewRegistry() *Registry {
 	return &Registry{
 		factories: make(map[string]ToolFactory),
+		tags:      make(map[string][]string),
 	}
 }
 
 // Register 注册工具
 func (r *Registry) Register(name string, factory ToolFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.factories[name] = factory
 }
 
+// Unregister 移除已注册的工具，返回工具是否存在
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.factories[name]; !ok {
+		return false
+	}
+	delete(r.factories, name)
+	delete(r.tags, name)
+	return true
+}
+
 // Create 创建工具实例
 func (r *Registry) Create(name string, config map[string]any) (Tool, error) {
+	r.mu.RLock()
 	factory, ok := r.factories[name]
+	r.mu.RUnlock()
 	if !ok {
 		return nil, &ToolNotFoundError{Name: name}
 	}
@@ -178,6 +202,9 @@ func (r *Registry) Create(name string, config map[string]any) (Tool, error) {
 
 // List 列出所有已注册的工具
 func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	names := make([]string, 0, len(r.factories))
 	for name := range r.factories {
 		names = append(names, name)
@@ -187,6 +214,8 @@ func (r *Registry) List() []string {
 
 // Has 检查工具是否已注册
 func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	_, ok := r.factories[name]
 	return ok
 }
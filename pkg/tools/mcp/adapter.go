@@ -11,7 +11,7 @@ import (
 
 // MCPToolAdapter 将 MCP 工具适配为 aster Tool 接口
 type MCPToolAdapter struct {
-	client      *cloud.MCPClient
+	client      ToolCaller
 	name        string
 	description string
 	inputSchema map[string]any
@@ -20,7 +20,7 @@ type MCPToolAdapter struct {
 
 // MCPToolAdapterConfig MCP 工具适配器配置
 type MCPToolAdapterConfig struct {
-	Client      *cloud.MCPClient
+	Client      ToolCaller
 	Name        string
 	Description string
 	InputSchema map[string]any
@@ -77,7 +77,7 @@ func (m *MCPToolAdapter) Execute(ctx context.Context, input map[string]any, tc *
 }
 
 // ToolFactory 创建 MCP 工具工厂函数
-func ToolFactory(mcpClient *cloud.MCPClient, mcpTool cloud.MCPTool) tools.ToolFactory {
+func ToolFactory(mcpClient ToolCaller, mcpTool cloud.MCPTool) tools.ToolFactory {
 	return func(config map[string]any) (tools.Tool, error) {
 		// 从配置中提取自定义 prompt (可选)
 		prompt := ""
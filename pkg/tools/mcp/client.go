@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
+)
+
+// ProtocolVersion 客户端在 initialize 握手中声明的 MCP 协议版本
+const ProtocolVersion = "2024-11-05"
+
+// 传输方式
+const (
+	TransportHTTP  = "http"  // JSON-RPC over HTTP POST
+	TransportStdio = "stdio" // 启动本地子进程，通过 stdin/stdout 按行传输 JSON-RPC
+)
+
+// ToolCaller 调用 MCP 工具的最小接口，MCPToolAdapter 只依赖该接口
+type ToolCaller interface {
+	CallTool(ctx context.Context, toolName string, params map[string]any) (json.RawMessage, error)
+}
+
+// Client MCP 客户端
+// 不同传输方式实现相同的协议操作，MCPServer 的握手和工具发现流程与传输无关。
+type Client interface {
+	ToolCaller
+
+	// Connect 建立连接并完成 initialize 握手
+	Connect(ctx context.Context) error
+	// ListTools 列出服务端提供的工具
+	ListTools(ctx context.Context) ([]cloud.MCPTool, error)
+	// Request 发送任意 JSON-RPC 请求并返回 result
+	Request(ctx context.Context, method string, params any) (json.RawMessage, error)
+	// Close 断开连接，stdio 传输会终止子进程
+	Close() error
+}
+
+// InitializeResult initialize 握手结果
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"serverInfo"`
+}
+
+// initializeParams initialize 请求参数
+func initializeParams() map[string]any {
+	return map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "aster",
+			"version": "1.0.0",
+		},
+	}
+}
+
+// httpClient HTTP 传输的 MCP 客户端
+type httpClient struct {
+	*cloud.MCPClient
+}
+
+// Connect 发送 initialize 请求
+// 兼容不实现 initialize 的简化 HTTP 服务端（如 pkg/mcpserver），返回 method not found 时视为成功。
+func (c *httpClient) Connect(ctx context.Context) error {
+	_, err := c.Request(ctx, "initialize", initializeParams())
+	if isMethodNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	return nil
+}
+
+// Close HTTP 传输无需释放连接
+func (c *httpClient) Close() error {
+	return nil
+}
+
+func isMethodNotFound(err error) bool {
+	var mcpErr *cloud.MCPError
+	return errors.As(err, &mcpErr) && mcpErr.Code == cloud.MCPErrorMethodNotFound
+}
@@ -2,29 +2,69 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/astercloud/aster/pkg/commands"
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
 	"github.com/astercloud/aster/pkg/tools"
 	"github.com/astercloud/aster/pkg/tools/search"
 )
 
 // MCPManager MCP Server 管理器
-// 管理多个 MCP Server 连接和工具注册
+// 管理多个 MCP Server 连接和工具注册。已连接的 Server 断开后按 ReconnectPolicy 自动重连，
+// 重连成功后重新同步工具。
 type MCPManager struct {
-	mu       sync.RWMutex
-	servers  map[string]*MCPServer
-	registry *tools.Registry
+	mu        sync.RWMutex
+	servers   map[string]*MCPServer
+	registry  *tools.Registry
+	policy    *ReconnectPolicy
+	listeners []StatusListener
+
+	// 生命周期，Close 时取消所有重连
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// NewMCPManager 创建 MCP Manager
+// NewMCPManager 创建 MCP Manager，默认使用 DefaultReconnectPolicy
 func NewMCPManager(registry *tools.Registry) *MCPManager {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &MCPManager{
 		servers:  make(map[string]*MCPServer),
 		registry: registry,
+		policy:   DefaultReconnectPolicy(),
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
+// SetReconnectPolicy 设置断线重连策略，nil 表示不自动重连
+// 只影响之后发生的断线
+func (m *MCPManager) SetReconnectPolicy(policy *ReconnectPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = policy
+}
+
+// OnStatusChange 添加连接状态变化监听器
+func (m *MCPManager) OnStatusChange(listener StatusListener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// GetServerStatus 获取指定 MCP Server 的连接状态
+func (m *MCPManager) GetServerStatus(serverID string) (ServerStatusInfo, bool) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return ServerStatusInfo{}, false
+	}
+	return server.Status(), true
+}
+
 // AddServer 添加 MCP Server
 func (m *MCPManager) AddServer(config *MCPServerConfig) (*MCPServer, error) {
 	m.mu.Lock()
@@ -40,6 +80,7 @@ func (m *MCPManager) AddServer(config *MCPServerConfig) (*MCPServer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create mcp server: %w", err)
 	}
+	server.onConnectionLost = m.handleConnectionLost
 
 	m.servers[config.ServerID] = server
 	return server, nil
@@ -55,16 +96,33 @@ func (m *MCPManager) ConnectServer(ctx context.Context, serverID string) error {
 		return fmt.Errorf("server not found: %s", serverID)
 	}
 
+	return m.connect(ctx, server, func() error {
+		// 注册工具到 Registry
+		if err := server.RegisterTools(); err != nil {
+			return fmt.Errorf("register tools: %w", err)
+		}
+		return nil
+	})
+}
+
+// connect 连接 Server 并执行 setup（注册或索引工具），成功后开始监听断线
+func (m *MCPManager) connect(ctx context.Context, server *MCPServer, setup func() error) error {
+	m.transition(server, StatusConnecting, nil, 0)
+
 	// 连接并发现工具
 	if err := server.Connect(ctx); err != nil {
-		return fmt.Errorf("connect to server: %w", err)
+		err = fmt.Errorf("connect to server: %w", err)
+		m.transition(server, StatusDisconnected, err, 0)
+		return err
 	}
 
-	// 注册工具到 Registry
-	if err := server.RegisterTools(); err != nil {
-		return fmt.Errorf("register tools: %w", err)
+	if err := setup(); err != nil {
+		m.transition(server, StatusDisconnected, err, 0)
+		return err
 	}
 
+	m.transition(server, StatusConnected, nil, 0)
+	server.watch()
 	return nil
 }
 
@@ -126,19 +184,141 @@ func (m *MCPManager) GetTotalToolCount() int {
 	return count
 }
 
-// RemoveServer 移除 MCP Server
+// RemoveServer 移除 MCP Server 并断开连接
 func (m *MCPManager) RemoveServer(serverID string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, exists := m.servers[serverID]; !exists {
+	server, exists := m.servers[serverID]
+	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("server not found: %s", serverID)
 	}
-
 	delete(m.servers, serverID)
+	m.mu.Unlock()
+
+	return m.closeServer(server)
+}
+
+// closeServer 关闭 Server 并发送 closed 事件
+func (m *MCPManager) closeServer(server *MCPServer) error {
+	prev := server.GetStatus()
+	err := server.Close()
+	if prev != StatusClosed {
+		m.emit(StatusEvent{ServerID: server.GetServerID(), Status: StatusClosed, Previous: prev, Time: time.Now()})
+	}
+	if err != nil {
+		return fmt.Errorf("close server %s: %w", server.GetServerID(), err)
+	}
 	return nil
 }
 
+// Close 断开所有 MCP Server，终止 stdio 子进程并停止重连
+func (m *MCPManager) Close() error {
+	m.cancel()
+
+	m.mu.Lock()
+	servers := m.servers
+	m.servers = make(map[string]*MCPServer)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, server := range servers {
+		if err := m.closeServer(server); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handleConnectionLost 连接断开时由 MCPServer 回调，Server 已处于 reconnecting 状态
+// 未设置重连策略时直接标记为 failed，返回是否会自动重连
+func (m *MCPManager) handleConnectionLost(server *MCPServer, err error) bool {
+	m.mu.RLock()
+	policy := m.policy
+	m.mu.RUnlock()
+
+	status := StatusReconnecting
+	if policy == nil {
+		status = StatusFailed
+		server.setStatus(status, err)
+	}
+	m.emit(StatusEvent{
+		ServerID: server.GetServerID(),
+		Status:   status,
+		Previous: StatusConnected,
+		Err:      err,
+		Time:     time.Now(),
+	})
+
+	if policy == nil {
+		return false
+	}
+	go m.reconnect(server, *policy)
+	return true
+}
+
+// reconnect 按退避策略重连，直到成功、次数用尽或 Server 被关闭
+func (m *MCPManager) reconnect(server *MCPServer, policy ReconnectPolicy) {
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-m.ctx.Done():
+			timer.Stop()
+			return
+		}
+		if server.GetStatus() != StatusReconnecting {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(m.ctx, policy.connectTimeout())
+		err := server.Connect(ctx)
+		cancel()
+		if err == nil {
+			err = server.resync()
+		}
+		if err == nil {
+			m.transition(server, StatusConnected, nil, attempt)
+			server.watch()
+			return
+		}
+		if errors.Is(err, ErrServerUnavailable) {
+			return
+		}
+		server.recordAttempt(attempt, err)
+	}
+
+	server.mu.RLock()
+	lastErr := server.lastErr
+	server.mu.RUnlock()
+	m.transition(server, StatusFailed, lastErr, policy.MaxAttempts)
+}
+
+// transition 切换 Server 状态，状态变化时通知监听器
+func (m *MCPManager) transition(server *MCPServer, status ServerStatus, err error, attempt int) {
+	prev := server.setStatus(status, err)
+	if prev == status || prev == StatusClosed {
+		return
+	}
+	m.emit(StatusEvent{
+		ServerID: server.GetServerID(),
+		Status:   status,
+		Previous: prev,
+		Attempt:  attempt,
+		Err:      err,
+		Time:     time.Now(),
+	})
+}
+
+func (m *MCPManager) emit(event StatusEvent) {
+	m.mu.RLock()
+	listeners := append([]StatusListener(nil), m.listeners...)
+	m.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
 // ConnectServerDeferred 连接 MCP Server 但使用延迟加载模式
 // 只发现工具并添加到索引，不立即注册到 Registry
 func (m *MCPManager) ConnectServerDeferred(ctx context.Context, serverID string, index *search.ToolIndex) error {
@@ -150,17 +330,13 @@ func (m *MCPManager) ConnectServerDeferred(ctx context.Context, serverID string,
 		return fmt.Errorf("server not found: %s", serverID)
 	}
 
-	// 连接并发现工具
-	if err := server.Connect(ctx); err != nil {
-		return fmt.Errorf("connect to server: %w", err)
-	}
-
-	// 将工具添加到索引（延迟加载模式）
-	if err := server.IndexToolsToIndex(index); err != nil {
-		return fmt.Errorf("index tools: %w", err)
-	}
-
-	return nil
+	return m.connect(ctx, server, func() error {
+		// 将工具添加到索引（延迟加载模式）
+		if err := server.IndexToolsToIndex(index); err != nil {
+			return fmt.Errorf("index tools: %w", err)
+		}
+		return nil
+	})
 }
 
 // ConnectAllDeferred 连接所有 MCP Server 使用延迟加载模式
@@ -208,3 +384,97 @@ func (m *MCPManager) GetAllToolIndexEntries() []search.ToolIndexEntry {
 	}
 	return entries
 }
+
+// ListResources 列出指定 MCP Server 的资源
+func (m *MCPManager) ListResources(ctx context.Context, serverID string) ([]cloud.MCPResource, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	return server.ListResources(ctx)
+}
+
+// ReadResource 读取指定 MCP Server 的资源
+func (m *MCPManager) ReadResource(ctx context.Context, serverID, uri string) ([]cloud.MCPResourceContent, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	return server.ReadResource(ctx, uri)
+}
+
+// ResourceContext 读取多个资源并格式化为上下文文本，可追加到系统提示词或用户消息
+func (m *MCPManager) ResourceContext(ctx context.Context, serverID string, uris ...string) (string, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return "", fmt.Errorf("server not found: %s", serverID)
+	}
+
+	var builder strings.Builder
+	for _, uri := range uris {
+		contents, err := server.ReadResource(ctx, uri)
+		if err != nil {
+			return "", fmt.Errorf("read resource %s: %w", uri, err)
+		}
+		builder.WriteString(FormatResourceContext(serverID, contents))
+	}
+	return builder.String(), nil
+}
+
+// ListPrompts 列出指定 MCP Server 的提示词模板
+func (m *MCPManager) ListPrompts(ctx context.Context, serverID string) ([]cloud.MCPPrompt, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	return server.ListPrompts(ctx)
+}
+
+// GetPrompt 渲染指定 MCP Server 的提示词模板
+func (m *MCPManager) GetPrompt(ctx context.Context, serverID, name string, args map[string]string) (*cloud.MCPGetPromptResult, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	return server.GetPrompt(ctx, name, args)
+}
+
+// RegisterPromptCommands 将指定 MCP Server 的提示词模板注册为 Slash Command
+// 命令名为 "{server_id}:{prompt}"，返回注册的命令名
+func (m *MCPManager) RegisterPromptCommands(ctx context.Context, serverID string, executor *commands.Executor) ([]string, error) {
+	server, exists := m.GetServer(serverID)
+	if !exists {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+
+	prompts, err := server.ListPrompts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list prompts: %w", err)
+	}
+
+	names := make([]string, 0, len(prompts))
+	for _, prompt := range prompts {
+		cmd := PromptCommand(server, prompt)
+		executor.Register(cmd)
+		names = append(names, cmd.Name)
+	}
+	return names, nil
+}
+
+// ToolContextManager 返回可设置到 tools.ToolContext.MCPManager 的适配器
+// 供 ListMcpResources、ReadMcpResource 等内置工具访问 MCP 资源
+func (m *MCPManager) ToolContextManager() tools.MCPManagerInterface {
+	return toolContextManager{m}
+}
+
+type toolContextManager struct {
+	manager *MCPManager
+}
+
+func (a toolContextManager) ListServers() []string {
+	return a.manager.ListServers()
+}
+
+func (a toolContextManager) GetServer(serverID string) (any, bool) {
+	return a.manager.GetServer(serverID)
+}
@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/mcpserver"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/tools/search"
+)
+
+// eventRecorder 记录状态事件，便于等待特定状态
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []StatusEvent
+	next   int // waitFor 从该位置开始查找
+	notify chan struct{}
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{notify: make(chan struct{}, 100)}
+}
+
+func (r *eventRecorder) listener(event StatusEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	r.notify <- struct{}{}
+}
+
+// waitFor 等待上次返回的事件之后出现指定状态的事件
+func (r *eventRecorder) waitFor(t *testing.T, status ServerStatus) StatusEvent {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		r.mu.Lock()
+		for i := r.next; i < len(r.events); i++ {
+			if r.events[i].Status == status {
+				r.next = i + 1
+				r.mu.Unlock()
+				return r.events[i]
+			}
+		}
+		r.mu.Unlock()
+
+		select {
+		case <-r.notify:
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %s event, got: %v", status, r.statuses())
+		}
+	}
+}
+
+func (r *eventRecorder) statuses() []ServerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]ServerStatus, 0, len(r.events))
+	for _, event := range r.events {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+func TestMCPManager_ReconnectStdioServer(t *testing.T) {
+	registry := tools.NewRegistry()
+	manager := NewMCPManager(registry)
+	defer func() { _ = manager.Close() }()
+	manager.SetReconnectPolicy(&ReconnectPolicy{InitialBackoff: 200 * time.Millisecond})
+
+	recorder := newEventRecorder()
+	manager.OnStatusChange(recorder.listener)
+
+	config := helperServerConfig(t, "local", "server")
+	config.Env["HELPER_STATE_FILE"] = filepath.Join(t.TempDir(), "launches")
+	if _, err := manager.AddServer(config); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := manager.ConnectServer(ctx, "local"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+	if status, _ := manager.GetServerStatus("local"); status.Status != StatusConnected {
+		t.Fatalf("Expected connected status, got %+v", status)
+	}
+
+	echo, err := registry.Create("local:echo", nil)
+	if err != nil {
+		t.Fatalf("Create tool failed: %v", err)
+	}
+
+	// 子进程退出，调用返回可重试错误
+	server, _ := manager.GetServer("local")
+	_, err = server.CallTool(ctx, "exit", nil)
+	if !IsRetryable(err) {
+		t.Fatalf("Expected retryable error when the server drops, got %v", err)
+	}
+	recorder.waitFor(t, StatusReconnecting)
+
+	// 重连期间调用快速失败且可重试
+	_, err = echo.Execute(ctx, map[string]any{"text": "hi"}, nil)
+	if !IsRetryable(err) {
+		t.Errorf("Expected retryable error during reconnect, got %v", err)
+	}
+	if status, _ := manager.GetServerStatus("local"); status.Status != StatusReconnecting || status.LastError == "" {
+		t.Errorf("Expected reconnecting status with last error, got %+v", status)
+	}
+
+	event := recorder.waitFor(t, StatusConnected)
+	if event.Previous != StatusReconnecting || event.Attempt != 1 {
+		t.Errorf("Unexpected reconnect event: %+v", event)
+	}
+
+	// 之前创建的工具实例继续可用
+	result, err := echo.Execute(ctx, map[string]any{"text": "hi"}, nil)
+	if err != nil || result.(map[string]any)["text"] != "hi" {
+		t.Fatalf("Expected echo to work after reconnect, got %v, %v", result, err)
+	}
+
+	// 工具重新同步：新工具注册，服务端不再提供的工具被移除
+	if !registry.Has("local:version") {
+		t.Errorf("Expected new tool to be registered after reconnect, got %v", registry.List())
+	}
+	if registry.Has("local:env") {
+		t.Error("Expected removed tool to be unregistered after reconnect")
+	}
+	version, _ := registry.Create("local:version", nil)
+	result, err = version.Execute(ctx, nil, nil)
+	if err != nil || result.(map[string]any)["generation"] != float64(2) {
+		t.Errorf("Expected call to reach the restarted server, got %v, %v", result, err)
+	}
+
+	// 移除后不再重连
+	if err := manager.RemoveServer("local"); err != nil {
+		t.Fatalf("RemoveServer failed: %v", err)
+	}
+	recorder.waitFor(t, StatusClosed)
+	_, err = echo.Execute(ctx, map[string]any{"text": "hi"}, nil)
+	if !errors.Is(err, ErrServerUnavailable) || IsRetryable(err) {
+		t.Errorf("Expected non-retryable unavailable error after removal, got %v", err)
+	}
+}
+
+func TestMCPManager_ReconnectGivesUp(t *testing.T) {
+	ts, addr := startHTTPMCPServer(t)
+
+	registry := tools.NewRegistry()
+	manager := NewMCPManager(registry)
+	defer func() { _ = manager.Close() }()
+	manager.SetReconnectPolicy(&ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, MaxAttempts: 2})
+
+	recorder := newEventRecorder()
+	manager.OnStatusChange(recorder.listener)
+
+	if _, err := manager.AddServer(&MCPServerConfig{ServerID: "remote", Endpoint: ts.URL}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := manager.ConnectServer(ctx, "remote"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+
+	// 服务端下线
+	ts.Close()
+	echo, _ := registry.Create("remote:echo", nil)
+	if _, err := echo.Execute(ctx, map[string]any{"text": "hi"}, nil); !IsRetryable(err) {
+		t.Fatalf("Expected retryable error after connection loss, got %v", err)
+	}
+
+	failed := recorder.waitFor(t, StatusFailed)
+	if failed.Attempt != 2 || failed.Err == nil {
+		t.Errorf("Unexpected failed event: %+v", failed)
+	}
+	status, _ := manager.GetServerStatus("remote")
+	if status.Status != StatusFailed || status.Attempts != 2 || status.LastError == "" {
+		t.Errorf("Unexpected status after giving up: %+v", status)
+	}
+	if _, err := echo.Execute(ctx, map[string]any{"text": "hi"}, nil); !errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("Expected unavailable error after giving up, got %v", err)
+	}
+
+	// 服务端恢复后可以手动重新连接
+	restartHTTPMCPServer(t, addr)
+	if err := manager.ConnectServer(ctx, "remote"); err != nil {
+		t.Fatalf("Manual reconnect failed: %v", err)
+	}
+	if _, err := echo.Execute(ctx, map[string]any{"text": "hi"}, nil); err != nil {
+		t.Errorf("Expected echo to work after manual reconnect, got %v", err)
+	}
+
+	want := []ServerStatus{StatusConnecting, StatusConnected, StatusReconnecting, StatusFailed, StatusConnecting, StatusConnected}
+	if got := recorder.statuses(); !slices.Equal(got, want) {
+		t.Errorf("Unexpected events: %v, want %v", got, want)
+	}
+}
+
+func TestMCPManager_ReconnectDisabled(t *testing.T) {
+	ts, _ := startHTTPMCPServer(t)
+
+	manager := NewMCPManager(tools.NewRegistry())
+	defer func() { _ = manager.Close() }()
+	manager.SetReconnectPolicy(nil)
+
+	if _, err := manager.AddServer(&MCPServerConfig{ServerID: "remote", Endpoint: ts.URL}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx := context.Background()
+	index := search.NewToolIndex()
+	if err := manager.ConnectServerDeferred(ctx, "remote", index); err != nil {
+		t.Fatalf("ConnectServerDeferred failed: %v", err)
+	}
+
+	ts.Close()
+	server, _ := manager.GetServer("remote")
+	_, err := server.CallTool(ctx, "echo", map[string]any{"text": "hi"})
+	if err == nil || IsRetryable(err) {
+		t.Errorf("Expected hard failure without reconnect policy, got %v", err)
+	}
+	if status, _ := manager.GetServerStatus("remote"); status.Status != StatusFailed {
+		t.Errorf("Expected failed status, got %+v", status)
+	}
+}
+
+func TestMCPManager_InitialConnectFailure(t *testing.T) {
+	manager := NewMCPManager(tools.NewRegistry())
+	defer func() { _ = manager.Close() }()
+
+	if _, err := manager.AddServer(&MCPServerConfig{ServerID: "down", Endpoint: "http://127.0.0.1:1/mcp"}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	if err := manager.ConnectServer(context.Background(), "down"); err == nil {
+		t.Fatal("Expected connect error")
+	}
+	status, ok := manager.GetServerStatus("down")
+	if !ok || status.Status != StatusDisconnected || status.LastError == "" {
+		t.Errorf("Expected disconnected status with error, got %+v", status)
+	}
+	if _, ok := manager.GetServerStatus("missing"); ok {
+		t.Error("Expected unknown server to report not found")
+	}
+}
+
+func TestReconnectPolicy_Backoff(t *testing.T) {
+	policy := &ReconnectPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+}
+
+// startHTTPMCPServer 启动提供 echo 工具的 HTTP MCP Server
+func startHTTPMCPServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	ts := httptest.NewServer(newEchoMCPHandler(t))
+	t.Cleanup(ts.Close)
+	return ts, ts.Listener.Addr().String()
+}
+
+// restartHTTPMCPServer 在原地址上重新启动 HTTP MCP Server
+func restartHTTPMCPServer(t *testing.T, addr string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Cannot rebind %s: %v", addr, err)
+	}
+	srv := &http.Server{Handler: newEchoMCPHandler(t)}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = srv.Close() })
+}
+
+func newEchoMCPHandler(t *testing.T) http.Handler {
+	t.Helper()
+	remote := tools.NewRegistry()
+	remote.Register("echo", func(config map[string]any) (tools.Tool, error) {
+		return &echoTool{}, nil
+	})
+	srv, err := mcpserver.New(&mcpserver.Config{Registry: remote})
+	if err != nil {
+		t.Fatalf("mcpserver.New failed: %v", err)
+	}
+	return srv.Handler()
+}
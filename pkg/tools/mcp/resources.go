@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/astercloud/aster/pkg/commands"
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
+)
+
+// ListResources 列出服务端提供的资源，自动处理分页
+func (s *MCPServer) ListResources(ctx context.Context) ([]cloud.MCPResource, error) {
+	return listPaged[cloud.MCPResource](ctx, s, "resources/list", "resources")
+}
+
+// ReadResource 读取指定 URI 的资源内容
+func (s *MCPServer) ReadResource(ctx context.Context, uri string) ([]cloud.MCPResourceContent, error) {
+	result, err := s.Request(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+
+	var read struct {
+		Contents []cloud.MCPResourceContent `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &read); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return read.Contents, nil
+}
+
+// ListPrompts 列出服务端提供的提示词模板，自动处理分页
+func (s *MCPServer) ListPrompts(ctx context.Context) ([]cloud.MCPPrompt, error) {
+	return listPaged[cloud.MCPPrompt](ctx, s, "prompts/list", "prompts")
+}
+
+// GetPrompt 使用参数渲染提示词模板
+func (s *MCPServer) GetPrompt(ctx context.Context, name string, args map[string]string) (*cloud.MCPGetPromptResult, error) {
+	params := map[string]any{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+
+	result, err := s.Request(ctx, "prompts/get", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompt cloud.MCPGetPromptResult
+	if err := json.Unmarshal(result, &prompt); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return &prompt, nil
+}
+
+// listPaged 按 nextCursor 分页获取列表结果
+func listPaged[T any](ctx context.Context, s *MCPServer, method, field string) ([]T, error) {
+	var (
+		all    []T
+		cursor string
+	)
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		result, err := s.Request(ctx, method, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		var items []T
+		if raw, ok := page[field]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("unmarshal %s: %w", field, err)
+			}
+		}
+		all = append(all, items...)
+
+		cursor = ""
+		if raw, ok := page["nextCursor"]; ok {
+			_ = json.Unmarshal(raw, &cursor)
+		}
+		if cursor == "" {
+			return all, nil
+		}
+	}
+}
+
+// FormatResourceContext 将资源内容格式化为可注入系统提示词或用户消息的上下文
+// 二进制内容（Blob）只保留占位说明，不写入上下文。
+func FormatResourceContext(serverID string, contents []cloud.MCPResourceContent) string {
+	var builder strings.Builder
+	for _, content := range contents {
+		fmt.Fprintf(&builder, "<mcp_resource server=%q uri=%q", serverID, content.URI)
+		if content.MimeType != "" {
+			fmt.Fprintf(&builder, " mime_type=%q", content.MimeType)
+		}
+		builder.WriteString(">\n")
+		if content.Text != "" {
+			builder.WriteString(content.Text)
+		} else if content.Blob != "" {
+			fmt.Fprintf(&builder, "[binary content, %d bytes base64]", len(content.Blob))
+		}
+		builder.WriteString("\n</mcp_resource>\n")
+	}
+	return builder.String()
+}
+
+// PromptCommand 将 MCP 提示词模板转换为 Slash Command
+// 命令名为 "{server_id}:{prompt}"，执行时调用 prompts/get 由服务端渲染。
+func PromptCommand(server *MCPServer, prompt cloud.MCPPrompt) *commands.CommandDefinition {
+	hints := make([]string, 0, len(prompt.Arguments))
+	for _, arg := range prompt.Arguments {
+		if arg.Required {
+			hints = append(hints, "<"+arg.Name+">")
+		} else {
+			hints = append(hints, "["+arg.Name+"]")
+		}
+	}
+
+	return &commands.CommandDefinition{
+		Name:         fmt.Sprintf("%s:%s", server.GetServerID(), prompt.Name),
+		Description:  prompt.Description,
+		ArgumentHint: strings.Join(hints, " "),
+		Render: func(ctx context.Context, args map[string]string) (string, error) {
+			for _, arg := range prompt.Arguments {
+				if arg.Required && args[arg.Name] == "" {
+					return "", fmt.Errorf("missing required argument: %s", arg.Name)
+				}
+			}
+			result, err := server.GetPrompt(ctx, prompt.Name, args)
+			if err != nil {
+				return "", err
+			}
+			return FormatPromptMessages(server.GetServerID(), result.Messages), nil
+		},
+	}
+}
+
+// FormatPromptMessages 将渲染后的提示词消息合并为一段文本
+// 只有一条用户消息时直接返回其内容，否则按角色分段。
+func FormatPromptMessages(serverID string, messages []cloud.MCPPromptMessage) string {
+	render := func(content cloud.MCPPromptContent) string {
+		switch {
+		case content.Type == "resource" && content.Resource != nil:
+			return FormatResourceContext(serverID, []cloud.MCPResourceContent{*content.Resource})
+		case content.Text != "":
+			return content.Text
+		default:
+			return fmt.Sprintf("[%s content omitted]", content.Type)
+		}
+	}
+
+	if len(messages) == 1 && messages[0].Role == "user" {
+		return render(messages[0].Content)
+	}
+
+	var builder strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			builder.WriteString("\n\n")
+		}
+		fmt.Fprintf(&builder, "**%s**:\n%s", msg.Role, render(msg.Content))
+	}
+	return builder.String()
+}
@@ -0,0 +1,181 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/commands"
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
+	"github.com/astercloud/aster/pkg/tools"
+	"github.com/astercloud/aster/pkg/tools/builtin"
+)
+
+// connectHelperManager 启动模拟 stdio MCP Server 并连接
+func connectHelperManager(t *testing.T) *MCPManager {
+	t.Helper()
+	manager := NewMCPManager(tools.NewRegistry())
+	t.Cleanup(func() { _ = manager.Close() })
+
+	if _, err := manager.AddServer(helperServerConfig(t, "local", "server")); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := manager.ConnectServer(ctx, "local"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+	return manager
+}
+
+func TestMCPManager_Resources(t *testing.T) {
+	manager := connectHelperManager(t)
+	ctx := context.Background()
+
+	resources, err := manager.ListResources(ctx, "local")
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	if len(resources) != 2 || resources[0].URI != "file:///README.md" || resources[1].URI != "db://schema" {
+		t.Fatalf("Expected resources across pages, got %+v", resources)
+	}
+
+	contents, err := manager.ReadResource(ctx, "local", "db://schema")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "content of db://schema" {
+		t.Errorf("Unexpected contents: %+v", contents)
+	}
+
+	// 资源作为上下文注入
+	text, err := manager.ResourceContext(ctx, "local", "file:///README.md", "db://schema")
+	if err != nil {
+		t.Fatalf("ResourceContext failed: %v", err)
+	}
+	for _, want := range []string{`<mcp_resource server="local" uri="file:///README.md"`, "content of db://schema"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected context to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	if _, err := manager.ListResources(ctx, "missing"); err == nil {
+		t.Error("Expected error for unknown server")
+	}
+}
+
+func TestMCPManager_PromptsAsCommands(t *testing.T) {
+	manager := connectHelperManager(t)
+	ctx := context.Background()
+
+	prompts, err := manager.ListPrompts(ctx, "local")
+	if err != nil {
+		t.Fatalf("ListPrompts failed: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "review" || len(prompts[0].Arguments) != 2 {
+		t.Fatalf("Unexpected prompts: %+v", prompts)
+	}
+
+	result, err := manager.GetPrompt(ctx, "local", "review", map[string]string{"path": "main.go", "focus": "errors"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Content.Text != "Review main.go focusing on errors" {
+		t.Errorf("Unexpected prompt result: %+v", result)
+	}
+
+	// 注册为 Slash Command
+	executor := commands.NewExecutor(&commands.ExecutorConfig{})
+	names, err := manager.RegisterPromptCommands(ctx, "local", executor)
+	if err != nil {
+		t.Fatalf("RegisterPromptCommands failed: %v", err)
+	}
+	if len(names) != 1 || names[0] != "local:review" {
+		t.Fatalf("Unexpected command names: %v", names)
+	}
+	if listed, _ := executor.List(ctx); len(listed) != 1 || listed[0] != "local:review" {
+		t.Errorf("Expected registered command to be listed, got %v", listed)
+	}
+
+	message, err := executor.Execute(ctx, "local:review", map[string]string{"path": "main.go", "focus": "tests"})
+	if err != nil {
+		t.Fatalf("Execute command failed: %v", err)
+	}
+	if !strings.Contains(message, "/local:review") || !strings.Contains(message, "Review main.go focusing on tests") {
+		t.Errorf("Unexpected command message:\n%s", message)
+	}
+
+	// 缺少必需参数时不请求服务端
+	if _, err := executor.Execute(ctx, "local:review", nil); err == nil || !strings.Contains(err.Error(), "path") {
+		t.Errorf("Expected missing argument error, got %v", err)
+	}
+}
+
+func TestMCPManager_BuiltinResourceTools(t *testing.T) {
+	manager := connectHelperManager(t)
+	ctx := context.Background()
+	tc := &tools.ToolContext{MCPManager: manager.ToolContextManager()}
+
+	listTool, _ := builtin.NewListMcpResourcesTool(nil)
+	out, err := listTool.Execute(ctx, map[string]any{"server": "local"}, tc)
+	if err != nil {
+		t.Fatalf("ListMcpResources failed: %v", err)
+	}
+	listed := out.(map[string]any)
+	if listed["total"] != 2 {
+		t.Fatalf("Expected 2 resources, got %v", listed)
+	}
+	if r := listed["resources"].([]builtin.MCPResource)[1]; r.Server != "local" || r.URI != "db://schema" {
+		t.Errorf("Unexpected resource: %+v", r)
+	}
+
+	readTool, _ := builtin.NewReadMcpResourceTool(nil)
+	out, err = readTool.Execute(ctx, map[string]any{"server": "local", "uri": "file:///README.md"}, tc)
+	if err != nil {
+		t.Fatalf("ReadMcpResource failed: %v", err)
+	}
+	read := out.(map[string]any)
+	contents, ok := read["contents"].([]builtin.MCPResourceContent)
+	if read["ok"] != true || !ok || contents[0].Text != "content of file:///README.md" {
+		t.Errorf("Unexpected read result: %v", read)
+	}
+}
+
+func TestMCPServer_ResourcesUnsupported(t *testing.T) {
+	// pkg/mcpserver 只提供工具，资源请求返回 method not found
+	ts, _ := startHTTPMCPServer(t)
+	manager := NewMCPManager(tools.NewRegistry())
+	defer func() { _ = manager.Close() }()
+
+	if _, err := manager.AddServer(&MCPServerConfig{ServerID: "remote", Endpoint: ts.URL}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := manager.ConnectServer(ctx, "remote"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+
+	_, err := manager.ListPrompts(ctx, "remote")
+	var mcpErr *cloud.MCPError
+	if !errors.As(err, &mcpErr) || mcpErr.Code != cloud.MCPErrorMethodNotFound {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+	if status, _ := manager.GetServerStatus("remote"); status.Status != StatusConnected {
+		t.Errorf("Protocol errors should not affect connection status, got %+v", status)
+	}
+}
+
+func TestFormatPromptMessages(t *testing.T) {
+	text := FormatPromptMessages("docs", []cloud.MCPPromptMessage{
+		{Role: "user", Content: cloud.MCPPromptContent{Type: "text", Text: "Summarize"}},
+		{Role: "user", Content: cloud.MCPPromptContent{Type: "resource", Resource: &cloud.MCPResourceContent{URI: "doc://a", Text: "body"}}},
+		{Role: "assistant", Content: cloud.MCPPromptContent{Type: "image", Data: "AAAA", MimeType: "image/png"}},
+	})
+	for _, want := range []string{"**user**:\nSummarize", `uri="doc://a"`, "body", "**assistant**:\n[image content omitted]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
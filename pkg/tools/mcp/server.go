@@ -2,9 +2,11 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/astercloud/aster/pkg/sandbox/cloud"
 	"github.com/astercloud/aster/pkg/tools"
@@ -12,21 +14,61 @@ import (
 )
 
 // MCPServer MCP Server 连接管理器
+// 注册到 Registry 的工具通过 MCPServer 调用，重连后替换底层客户端不影响已注册的工具。
 type MCPServer struct {
-	mu       sync.RWMutex
-	client   *cloud.MCPClient
-	serverID string
-	tools    []cloud.MCPTool
-	registry *tools.Registry
+	mu        sync.RWMutex
+	client    Client
+	newClient func() Client
+	used      bool // client 是否已连接过，再次连接时需要新建客户端
+	serverID  string
+	tools     []cloud.MCPTool
+	registry  *tools.Registry
+
+	// 已注册的工具名，重新同步时用于移除服务端不再提供的工具
+	registered map[string]bool
+	index      *search.ToolIndex // 延迟加载模式下的工具索引
+	indexed    map[string]bool
+
+	status      ServerStatus
+	attempts    int
+	lastErr     error
+	connectedAt time.Time
+
+	// onConnectionLost 连接断开时的回调，由 MCPManager 设置，返回是否会自动重连
+	onConnectionLost func(s *MCPServer, err error) bool
 }
 
 // MCPServerConfig MCP Server 配置
 type MCPServerConfig struct {
-	ServerID        string
+	ServerID string
+
+	// Transport 传输方式: "http" 或 "stdio"
+	// 为空时根据配置推断: 设置了 Command 且未设置 Endpoint 时使用 stdio，否则使用 http
+	Transport string
+
+	// HTTP 传输
 	Endpoint        string
 	AccessKeyID     string
 	AccessKeySecret string
 	SecurityToken   string
+
+	// stdio 传输
+	Command         string            // 启动 MCP Server 的命令
+	Args            []string          // 命令参数
+	Env             map[string]string // 额外环境变量
+	WorkDir         string            // 工作目录
+	ShutdownTimeout time.Duration     // 关闭时等待子进程退出的时间，默认 5s
+}
+
+// transport 返回实际使用的传输方式
+func (c *MCPServerConfig) transport() string {
+	if c.Transport != "" {
+		return c.Transport
+	}
+	if c.Command != "" && c.Endpoint == "" {
+		return TransportStdio
+	}
+	return TransportHTTP
 }
 
 // NewMCPServer 创建 MCP Server 连接
@@ -35,63 +77,253 @@ func NewMCPServer(config *MCPServerConfig, registry *tools.Registry) (*MCPServer
 		return nil, errors.New("server_id is required")
 	}
 
-	if config.Endpoint == "" {
-		return nil, errors.New("endpoint is required")
+	// 按传输方式创建 MCP 客户端
+	var newClient func() Client
+	switch config.transport() {
+	case TransportHTTP:
+		if config.Endpoint == "" {
+			return nil, errors.New("endpoint is required")
+		}
+		newClient = func() Client {
+			return &httpClient{cloud.NewMCPClient(&cloud.MCPClientConfig{
+				Endpoint:        config.Endpoint,
+				AccessKeyID:     config.AccessKeyID,
+				AccessKeySecret: config.AccessKeySecret,
+				SecurityToken:   config.SecurityToken,
+			})}
+		}
+	case TransportStdio:
+		if config.Command == "" {
+			return nil, errors.New("command is required for stdio transport")
+		}
+		newClient = func() Client {
+			return NewStdioClient(StdioClientConfig{
+				Command:         config.Command,
+				Args:            config.Args,
+				Env:             config.Env,
+				WorkDir:         config.WorkDir,
+				ShutdownTimeout: config.ShutdownTimeout,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transport: %s", config.Transport)
 	}
 
-	// 创建 MCP 客户端
-	client := cloud.NewMCPClient(&cloud.MCPClientConfig{
-		Endpoint:        config.Endpoint,
-		AccessKeyID:     config.AccessKeyID,
-		AccessKeySecret: config.AccessKeySecret,
-		SecurityToken:   config.SecurityToken,
-	})
-
 	return &MCPServer{
-		client:   client,
-		serverID: config.ServerID,
-		tools:    make([]cloud.MCPTool, 0),
-		registry: registry,
+		client:     newClient(),
+		newClient:  newClient,
+		serverID:   config.ServerID,
+		tools:      make([]cloud.MCPTool, 0),
+		registry:   registry,
+		registered: make(map[string]bool),
+		status:     StatusDisconnected,
 	}, nil
 }
 
 // Connect 连接到 MCP Server 并发现工具
+// 先完成 initialize 握手，再列出工具，流程与传输方式无关。
+// 再次调用时（如重连）会创建新的客户端，成功后替换并关闭旧客户端。
 func (s *MCPServer) Connect(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	if s.status == StatusClosed {
+		s.mu.Unlock()
+		return ErrServerUnavailable
+	}
+	client := s.client
+	if s.used {
+		client = s.newClient()
+	}
+	s.used = true
+	s.mu.Unlock()
+
+	if err := client.Connect(ctx); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("initialize mcp session: %w", err)
+	}
 
 	// 列出服务端提供的工具
-	mcpTools, err := s.client.ListTools(ctx)
+	mcpTools, err := client.ListTools(ctx)
 	if err != nil {
+		_ = client.Close()
 		return fmt.Errorf("list mcp tools: %w", err)
 	}
 
+	s.mu.Lock()
+	if s.status == StatusClosed {
+		s.mu.Unlock()
+		_ = client.Close()
+		return ErrServerUnavailable
+	}
+	old := s.client
+	s.client = client
 	s.tools = mcpTools
+	s.connectedAt = time.Now()
+	s.mu.Unlock()
+
+	if old != client {
+		_ = old.Close()
+	}
 	return nil
 }
 
 // RegisterTools 将 MCP 工具注册到 aster Registry
 func (s *MCPServer) RegisterTools() error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if len(s.tools) == 0 {
 		return errors.New("no tools available, call Connect() first")
 	}
 
+	s.syncRegistryLocked(true)
+	return nil
+}
+
+// syncRegistryLocked 注册当前工具，并移除服务端不再提供的工具，调用方需持有 s.mu
+// all 为 false 时只重新注册之前已注册过的工具（延迟加载模式）
+func (s *MCPServer) syncRegistryLocked(all bool) {
+	current := make(map[string]bool, len(s.tools))
+
 	// 为每个 MCP 工具创建工厂并注册
 	for _, mcpTool := range s.tools {
 		// 使用 server_id 作为前缀避免工具名冲突
 		toolName := fmt.Sprintf("%s:%s", s.serverID, mcpTool.Name)
+		if !all && !s.registered[toolName] {
+			continue
+		}
+		current[toolName] = true
 
-		// 创建工具工厂
-		factory := ToolFactory(s.client, mcpTool)
+		// 工具通过 MCPServer 调用，重连后无需重新创建
+		s.registry.Register(toolName, ToolFactory(s, mcpTool))
+	}
 
-		// 注册到 Registry
-		s.registry.Register(toolName, factory)
+	for name := range s.registered {
+		if !current[name] {
+			s.registry.Unregister(name)
+		}
 	}
+	s.registered = current
+}
 
-	return nil
+// CallTool 调用 MCP 工具
+// 重连期间返回可重试的 ErrServerReconnecting；检测到连接断开时通知 MCPManager 开始重连。
+func (s *MCPServer) CallTool(ctx context.Context, toolName string, params map[string]any) (json.RawMessage, error) {
+	return s.call(func(client Client) (json.RawMessage, error) {
+		return client.CallTool(ctx, toolName, params)
+	})
+}
+
+// Request 发送任意 JSON-RPC 请求，重连状态和断线检测与 CallTool 相同
+func (s *MCPServer) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return s.call(func(client Client) (json.RawMessage, error) {
+		return client.Request(ctx, method, params)
+	})
+}
+
+// call 使用当前客户端发送请求
+func (s *MCPServer) call(fn func(client Client) (json.RawMessage, error)) (json.RawMessage, error) {
+	s.mu.RLock()
+	client, status := s.client, s.status
+	s.mu.RUnlock()
+
+	switch status {
+	case StatusReconnecting:
+		return nil, fmt.Errorf("%w: %s", ErrServerReconnecting, s.serverID)
+	case StatusFailed, StatusClosed:
+		return nil, fmt.Errorf("%w: %s (%s)", ErrServerUnavailable, s.serverID, status)
+	}
+
+	result, err := fn(client)
+	if err != nil && isConnectionError(err) {
+		// 断线可能已被 stdio 子进程监听先发现
+		if s.connectionLost(client, err) || s.GetStatus() == StatusReconnecting {
+			return nil, fmt.Errorf("%w: %s: %v", ErrServerReconnecting, s.serverID, err)
+		}
+	}
+	return result, err
+}
+
+// connectionLost 标记连接断开并通知 MCPManager
+// 只有 client 仍是当前客户端且处于已连接状态时生效，返回是否会自动重连
+func (s *MCPServer) connectionLost(client Client, err error) bool {
+	s.mu.Lock()
+	if client != s.client || s.status != StatusConnected || s.onConnectionLost == nil {
+		s.mu.Unlock()
+		return false
+	}
+	s.status = StatusReconnecting
+	s.attempts = 0
+	s.lastErr = err
+	handler := s.onConnectionLost
+	s.mu.Unlock()
+
+	return handler(s, err)
+}
+
+// watch 监听 stdio 子进程退出，退出时视为连接断开
+func (s *MCPServer) watch() {
+	s.mu.RLock()
+	client := s.client
+	s.mu.RUnlock()
+
+	stdio, ok := client.(*StdioClient)
+	if !ok {
+		return
+	}
+	done := stdio.Done()
+	if done == nil {
+		return
+	}
+	go func() {
+		<-done
+		s.connectionLost(client, fmt.Errorf("%w: process exited", ErrClientClosed))
+	}()
+}
+
+// setStatus 更新连接状态，返回之前的状态；已关闭的 Server 保持 closed
+func (s *MCPServer) setStatus(status ServerStatus, err error) ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.status
+	if prev == StatusClosed {
+		return prev
+	}
+	s.status = status
+	if err != nil {
+		s.lastErr = err
+	}
+	if status == StatusConnected {
+		s.attempts = 0
+		s.lastErr = nil
+	}
+	return prev
+}
+
+// recordAttempt 记录一次失败的重连尝试
+func (s *MCPServer) recordAttempt(attempt int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = attempt
+	s.lastErr = err
+}
+
+// Status 返回连接状态信息
+func (s *MCPServer) Status() ServerStatusInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info := ServerStatusInfo{
+		ServerID:    s.serverID,
+		Status:      s.status,
+		Attempts:    s.attempts,
+		ConnectedAt: s.connectedAt,
+		ToolCount:   len(s.tools),
+	}
+	if s.lastErr != nil {
+		info.LastError = s.lastErr.Error()
+	}
+	return info
 }
 
 // ListTools 返回已发现的工具列表
@@ -118,10 +350,31 @@ func (s *MCPServer) GetServerID() string {
 }
 
 // GetClient 获取底层 MCP 客户端
-func (s *MCPServer) GetClient() *cloud.MCPClient {
+// 重连后会替换为新的客户端，长期持有时应通过 MCPServer.CallTool 调用工具
+func (s *MCPServer) GetClient() Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.client
 }
 
+// GetStatus 获取连接状态
+func (s *MCPServer) GetStatus() ServerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Close 断开与 MCP Server 的连接，stdio 传输会终止子进程
+// 关闭后不再自动重连，工具调用返回 ErrServerUnavailable
+func (s *MCPServer) Close() error {
+	s.mu.Lock()
+	s.status = StatusClosed
+	client := s.client
+	s.mu.Unlock()
+
+	return client.Close()
+}
+
 // GetToolIndexEntries 获取工具索引条目（用于延迟加载）
 // 返回工具的元数据，但不实际注册到 Registry
 func (s *MCPServer) GetToolIndexEntries() []search.ToolIndexEntry {
@@ -155,16 +408,17 @@ func (s *MCPServer) GetToolIndexEntries() []search.ToolIndexEntry {
 
 // RegisterToolDeferred 延迟注册单个工具（按需激活时调用）
 func (s *MCPServer) RegisterToolDeferred(toolName string) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	// 查找对应的 MCP 工具
 	for _, mcpTool := range s.tools {
 		fullName := fmt.Sprintf("%s:%s", s.serverID, mcpTool.Name)
 		if fullName == toolName {
 			// 创建工具工厂并注册
-			factory := ToolFactory(s.client, mcpTool)
+			factory := ToolFactory(s, mcpTool)
 			s.registry.Register(toolName, factory)
+			s.registered[toolName] = true
 			return nil
 		}
 	}
@@ -175,10 +429,42 @@ func (s *MCPServer) RegisterToolDeferred(toolName string) error {
 // IndexToolsToIndex 将工具添加到工具索引（延迟加载模式）
 func (s *MCPServer) IndexToolsToIndex(index *search.ToolIndex) error {
 	entries := s.GetToolIndexEntries()
+
+	s.mu.Lock()
+	s.index = index
+	indexed := s.indexed
+	s.indexed = make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		s.indexed[entry.Name] = true
+	}
+	s.mu.Unlock()
+
 	for _, entry := range entries {
 		if err := index.IndexToolEntry(entry); err != nil {
 			return fmt.Errorf("index tool %s: %w", entry.Name, err)
 		}
 	}
+
+	// 移除服务端不再提供的工具
+	for name := range indexed {
+		if !s.indexed[name] {
+			index.RemoveTool(name)
+		}
+	}
+	return nil
+}
+
+// resync 重连后同步工具
+// 立即注册模式下重新注册全部工具；延迟加载模式下更新索引，并只重新注册已激活的工具。
+// 服务端不再提供的工具会从 Registry 和索引中移除。
+func (s *MCPServer) resync() error {
+	s.mu.Lock()
+	index := s.index
+	s.syncRegistryLocked(index == nil)
+	s.mu.Unlock()
+
+	if index != nil {
+		return s.IndexToolsToIndex(index)
+	}
 	return nil
 }
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ServerStatus MCP Server 连接状态
+type ServerStatus string
+
+const (
+	StatusDisconnected ServerStatus = "disconnected" // 已添加，尚未连接或首次连接失败
+	StatusConnecting   ServerStatus = "connecting"   // 正在首次连接
+	StatusConnected    ServerStatus = "connected"    // 已连接，工具可用
+	StatusReconnecting ServerStatus = "reconnecting" // 连接断开，正在按退避策略重连
+	StatusFailed       ServerStatus = "failed"       // 重连次数用尽，不再自动重连
+	StatusClosed       ServerStatus = "closed"       // 已移除或关闭
+)
+
+var (
+	// ErrServerReconnecting MCP Server 正在重连，调用方可稍后重试
+	ErrServerReconnecting = errors.New("mcp server is reconnecting")
+	// ErrServerUnavailable MCP Server 已关闭或重连失败
+	ErrServerUnavailable = errors.New("mcp server is unavailable")
+)
+
+// IsRetryable 判断 MCP 工具调用错误是否可以稍后重试
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrServerReconnecting)
+}
+
+// ServerStatusInfo MCP Server 连接状态信息
+type ServerStatusInfo struct {
+	ServerID    string       `json:"server_id"`
+	Status      ServerStatus `json:"status"`
+	Attempts    int          `json:"attempts"`              // 当前这轮重连已失败的次数
+	LastError   string       `json:"last_error,omitempty"`  // 最近一次断开或连接失败的原因
+	ConnectedAt time.Time    `json:"connected_at,omitzero"` // 最近一次连接成功的时间
+	ToolCount   int          `json:"tool_count"`            // 已发现的工具数量
+}
+
+// StatusEvent 连接状态变化事件
+type StatusEvent struct {
+	ServerID string
+	Status   ServerStatus
+	Previous ServerStatus
+	Attempt  int   // 重连成功或失败时为已进行的尝试次数
+	Err      error // 断开或失败原因
+	Time     time.Time
+}
+
+// StatusListener 连接状态变化监听器
+// 在状态变化的 goroutine 中同步调用，不应长时间阻塞。
+type StatusListener func(event StatusEvent)
+
+// ReconnectPolicy 断线重连策略
+type ReconnectPolicy struct {
+	// InitialBackoff 首次重连前的等待时间，之后每次翻倍，默认 1s
+	InitialBackoff time.Duration
+
+	// MaxBackoff 单次等待时间上限，默认 30s
+	MaxBackoff time.Duration
+
+	// MaxAttempts 最大重连次数，0 表示不限制
+	MaxAttempts int
+
+	// ConnectTimeout 单次连接（握手和工具发现）超时，默认 30s
+	ConnectTimeout time.Duration
+}
+
+// DefaultReconnectPolicy 默认重连策略: 1s 起指数退避，最长 30s，不限次数
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		ConnectTimeout: 30 * time.Second,
+	}
+}
+
+// backoff 第 attempt 次重连前的等待时间
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	initial, maxBackoff := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	wait := initial
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func (p *ReconnectPolicy) connectTimeout() time.Duration {
+	if p.ConnectTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return p.ConnectTimeout
+}
+
+// isConnectionError 判断错误是否表示与 MCP Server 的连接已断开
+// MCP 协议错误和调用方取消、超时不视为断开
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrClientClosed) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
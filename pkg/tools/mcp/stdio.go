@@ -0,0 +1,407 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
+)
+
+const (
+	defaultShutdownTimeout = 5 * time.Second
+	stderrTailSize         = 4096
+	maxStdioMessageSize    = 16 * 1024 * 1024
+)
+
+// ErrClientClosed 客户端已关闭或子进程已退出
+var ErrClientClosed = errors.New("mcp client closed")
+
+// StdioClientConfig stdio 传输配置
+type StdioClientConfig struct {
+	Command string            // 可执行文件
+	Args    []string          // 命令参数
+	Env     map[string]string // 额外环境变量，与当前进程环境合并
+	WorkDir string            // 工作目录
+
+	// ShutdownTimeout 关闭 stdin 后等待子进程退出的时间，超时后依次发送 SIGTERM、SIGKILL，默认 5s
+	ShutdownTimeout time.Duration
+}
+
+// StdioClient 通过子进程 stdin/stdout 通信的 MCP 客户端
+// 按 MCP 规范，每条 JSON-RPC 消息占一行；子进程的 stderr 仅用于日志。
+type StdioClient struct {
+	config StdioClientConfig
+
+	mu      sync.Mutex
+	writeMu sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	pending map[int64]chan *rpcMessage
+	closed  bool
+	exited  chan struct{} // 子进程退出后关闭
+	waitErr error
+	readErr error // 读取 stdout 失败的原因（如消息超过 maxStdioMessageSize）
+
+	nextID atomic.Int64
+	stderr *tailBuffer
+}
+
+// rpcMessage JSON-RPC 消息（请求、响应或通知）
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  any              `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *cloud.MCPError  `json:"error,omitempty"`
+}
+
+// NewStdioClient 创建 stdio 客户端，子进程在 Connect 时启动
+func NewStdioClient(config StdioClientConfig) *StdioClient {
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+	return &StdioClient{
+		config: config,
+		stderr: &tailBuffer{max: stderrTailSize},
+	}
+}
+
+// Connect 启动子进程并完成 initialize 握手
+func (c *StdioClient) Connect(ctx context.Context) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	if _, err := c.Request(ctx, "initialize", initializeParams()); err != nil {
+		_ = c.Close()
+		return fmt.Errorf("initialize: %w", err)
+	}
+	if err := c.notify("notifications/initialized", nil); err != nil {
+		_ = c.Close()
+		return fmt.Errorf("initialized notification: %w", err)
+	}
+	return nil
+}
+
+func (c *StdioClient) start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd != nil {
+		return errors.New("mcp stdio client already started")
+	}
+
+	cmd := exec.Command(c.config.Command, c.config.Args...)
+	cmd.Dir = c.config.WorkDir
+	cmd.Env = os.Environ()
+	for k, v := range c.config.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+	cmd.Stderr = c.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", c.config.Command, err)
+	}
+
+	c.cmd = cmd
+	c.stdin = stdin
+	c.pending = make(map[int64]chan *rpcMessage)
+	c.exited = make(chan struct{})
+
+	go c.readLoop(stdout)
+	return nil
+}
+
+// readLoop 读取子进程输出，将响应分发给等待中的请求
+func (c *StdioClient) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdioMessageSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			// 服务端发起的请求
+			c.handleServerRequest(&msg)
+		case msg.Method != "":
+			// 服务端通知，当前忽略
+		case msg.ID != nil:
+			var id int64
+			if err := json.Unmarshal(*msg.ID, &id); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		}
+	}
+
+	// 读取出错后无法继续解析后续消息：先让等待中的请求失败，
+	// 再结束子进程，否则它可能阻塞在写 stdout 上，Wait 无法返回
+	if err := scanner.Err(); err != nil {
+		c.mu.Lock()
+		c.readErr = fmt.Errorf("read stdout: %w", err)
+		c.failPendingLocked()
+		c.mu.Unlock()
+		_ = c.cmd.Process.Kill()
+	}
+
+	// stdout 关闭意味着子进程已退出或即将退出
+	err := c.cmd.Wait()
+
+	c.mu.Lock()
+	c.waitErr = err
+	c.failPendingLocked()
+	c.mu.Unlock()
+	close(c.exited)
+}
+
+// failPendingLocked 让所有等待中的请求以 exitError 失败，之后的请求直接失败
+// 调用方需持有 c.mu
+func (c *StdioClient) failPendingLocked() {
+	for _, ch := range c.pending {
+		close(ch)
+	}
+	c.pending = nil
+}
+
+// handleServerRequest 响应服务端请求，仅支持 ping
+func (c *StdioClient) handleServerRequest(msg *rpcMessage) {
+	resp := &rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &cloud.MCPError{
+			Code:    cloud.MCPErrorMethodNotFound,
+			Message: "method not found: " + msg.Method,
+		}
+	}
+	_ = c.write(resp)
+}
+
+// Request 发送 JSON-RPC 请求并等待响应
+func (c *StdioClient) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := c.nextID.Add(1)
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	ch := make(chan *rpcMessage, 1)
+
+	c.mu.Lock()
+	if c.cmd == nil || c.closed {
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	}
+	if c.pending == nil {
+		c.mu.Unlock()
+		return nil, c.exitError()
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(&rpcMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: params}); err != nil {
+		c.removePending(id)
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return nil, c.exitError()
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("mcp error: %w", resp.Error)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		c.removePending(id)
+		return nil, ctx.Err()
+	}
+}
+
+// CallTool 调用 MCP 工具
+func (c *StdioClient) CallTool(ctx context.Context, toolName string, params map[string]any) (json.RawMessage, error) {
+	return c.Request(ctx, "tools/call", cloud.MCPCallParams{
+		Name:      toolName,
+		Arguments: params,
+	})
+}
+
+// ListTools 列出可用工具，自动处理分页
+func (c *StdioClient) ListTools(ctx context.Context) ([]cloud.MCPTool, error) {
+	var (
+		all    []cloud.MCPTool
+		cursor string
+	)
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		result, err := c.Request(ctx, "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tools      []cloud.MCPTool `json:"tools"`
+			NextCursor string          `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		all = append(all, page.Tools...)
+
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// Close 关闭 stdin 请求子进程退出，超时后发送 SIGTERM，仍未退出则强制结束
+func (c *StdioClient) Close() error {
+	c.mu.Lock()
+	if c.cmd == nil || c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	cmd, exited := c.cmd, c.exited
+	c.mu.Unlock()
+
+	_ = c.stdin.Close()
+
+	timeout := c.config.ShutdownTimeout
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("kill mcp server: %w", err)
+	}
+	<-exited
+	return nil
+}
+
+// Done 返回在子进程退出后关闭的通道，未启动时返回 nil
+func (c *StdioClient) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exited
+}
+
+// Stderr 返回子进程最近的 stderr 输出
+func (c *StdioClient) Stderr() string {
+	return c.stderr.String()
+}
+
+func (c *StdioClient) notify(method string, params any) error {
+	return c.write(&rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *StdioClient) write(msg *rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	data = append(data, '\n')
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.stdin.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *StdioClient) removePending(id int64) {
+	c.mu.Lock()
+	if c.pending != nil {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+// exitError 描述子进程退出原因，附带 stderr 尾部便于排查
+// 调用方需持有 c.mu
+func (c *StdioClient) exitError() error {
+	waitErr := c.waitErr
+	msg := "mcp server exited"
+	if c.readErr != nil {
+		msg = "mcp server output unreadable: " + c.readErr.Error()
+	} else if waitErr != nil {
+		msg += ": " + waitErr.Error()
+	}
+	if tail := strings.TrimSpace(c.stderr.String()); tail != "" {
+		msg += "\nstderr: " + tail
+	}
+	return fmt.Errorf("%w: %s", ErrClientClosed, msg)
+}
+
+// tailBuffer 只保留最后 max 字节的写入内容
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
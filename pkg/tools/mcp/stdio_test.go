@@ -0,0 +1,367 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/sandbox/cloud"
+	"github.com/astercloud/aster/pkg/tools"
+)
+
+// TestHelperProcess 作为子进程运行的模拟 stdio MCP Server
+// 仅在设置 ASTER_MCP_HELPER 时生效，取值决定行为:
+//   - "server": 正常处理 initialize、tools/list（分两页）和 tools/call（回显参数）
+//   - "stubborn": 同 server，但忽略 stdin 关闭和 SIGTERM，只能被强制结束
+//   - "crash": 收到 initialize 后写 stderr 并退出
+//   - "oversized": 收到 initialize 后输出超过 maxStdioMessageSize 的一行，之后不再退出
+//
+// 设置 HELPER_STATE_FILE 时记录启动次数，第二次及以后启动时第二页工具由 env 变为 version，
+// 用于验证重连后的工具同步；调用 exit 工具会使进程立即退出。
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("ASTER_MCP_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	generation := 1
+	if stateFile := os.Getenv("HELPER_STATE_FILE"); stateFile != "" {
+		data, _ := os.ReadFile(stateFile)
+		generation = len(data) + 1
+		_ = os.WriteFile(stateFile, append(data, 'x'), 0o600)
+	}
+
+	if mode == "stubborn" {
+		signal.Ignore(syscall.SIGTERM)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id json.RawMessage, result any) {
+		_ = out.Encode(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Cursor    string         `json:"cursor"`
+				Name      string         `json:"name"`
+				URI       string         `json:"uri"`
+				Arguments map[string]any `json:"arguments"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		switch req.Method {
+		case "initialize":
+			if mode == "crash" {
+				fmt.Fprintln(os.Stderr, "fatal: missing API key")
+				os.Exit(3)
+			}
+			if mode == "oversized" {
+				_, _ = os.Stdout.Write([]byte(strings.Repeat("x", maxStdioMessageSize+1) + "\n"))
+				select {}
+			}
+			// 握手期间先向客户端发送 ping，验证客户端会响应服务端请求
+			_ = out.Encode(map[string]any{"jsonrpc": "2.0", "id": "srv-1", "method": "ping"})
+			reply(req.ID, map[string]any{
+				"protocolVersion": ProtocolVersion,
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "helper", "version": "0.1"},
+			})
+		case "notifications/initialized":
+			fmt.Fprintln(os.Stderr, "initialized")
+		case "tools/list":
+			if req.Params.Cursor == "" {
+				reply(req.ID, map[string]any{
+					"tools":      []map[string]any{{"name": "echo", "description": "Echo arguments"}},
+					"nextCursor": "page-2",
+				})
+			} else {
+				second := map[string]any{"name": "env", "description": "Read env var"}
+				if generation > 1 {
+					second = map[string]any{"name": "version", "description": "Server generation"}
+				}
+				reply(req.ID, map[string]any{"tools": []map[string]any{second}})
+			}
+		case "resources/list":
+			if req.Params.Cursor == "" {
+				reply(req.ID, map[string]any{
+					"resources":  []map[string]any{{"uri": "file:///README.md", "name": "README", "mimeType": "text/markdown"}},
+					"nextCursor": "page-2",
+				})
+			} else {
+				reply(req.ID, map[string]any{
+					"resources": []map[string]any{{"uri": "db://schema", "name": "Schema"}},
+				})
+			}
+		case "resources/read":
+			reply(req.ID, map[string]any{
+				"contents": []map[string]any{{"uri": req.Params.URI, "mimeType": "text/plain", "text": "content of " + req.Params.URI}},
+			})
+		case "prompts/list":
+			reply(req.ID, map[string]any{
+				"prompts": []map[string]any{{
+					"name":        "review",
+					"description": "Review a file",
+					"arguments":   []map[string]any{{"name": "path", "required": true}, {"name": "focus"}},
+				}},
+			})
+		case "prompts/get":
+			reply(req.ID, map[string]any{
+				"messages": []map[string]any{{
+					"role":    "user",
+					"content": map[string]any{"type": "text", "text": fmt.Sprintf("Review %v focusing on %v", req.Params.Arguments["path"], req.Params.Arguments["focus"])},
+				}},
+			})
+		case "tools/call":
+			switch req.Params.Name {
+			case "echo":
+				reply(req.ID, req.Params.Arguments)
+			case "env":
+				reply(req.ID, map[string]any{"value": os.Getenv(req.Params.Arguments["key"].(string))})
+			case "version":
+				reply(req.ID, map[string]any{"generation": generation})
+			case "exit":
+				os.Exit(1)
+			default:
+				_ = out.Encode(map[string]any{
+					"jsonrpc": "2.0",
+					"id":      req.ID,
+					"error":   map[string]any{"code": -32602, "message": "unknown tool: " + req.Params.Name},
+				})
+			}
+		}
+	}
+
+	// stdin 关闭即退出；stubborn 模式一直阻塞直到被强制结束
+	if mode == "stubborn" {
+		select {}
+	}
+}
+
+// helperServerConfig 返回启动模拟 MCP Server 的 stdio 配置
+func helperServerConfig(t *testing.T, serverID, mode string) *MCPServerConfig {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to get test executable: %v", err)
+	}
+	return &MCPServerConfig{
+		ServerID: serverID,
+		Command:  exe,
+		Args:     []string{"-test.run=^TestHelperProcess$"},
+		Env: map[string]string{
+			"ASTER_MCP_HELPER": mode,
+			"HELPER_SECRET":    "s3cret",
+		},
+		ShutdownTimeout: 200 * time.Millisecond,
+	}
+}
+
+func TestMCPManager_StdioServer(t *testing.T) {
+	registry := tools.NewRegistry()
+	manager := NewMCPManager(registry)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server, err := manager.AddServer(helperServerConfig(t, "local", "server"))
+	if err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	if err := manager.ConnectServer(ctx, "local"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+
+	// 两页工具都被发现
+	if server.GetToolCount() != 2 {
+		t.Fatalf("Expected 2 tools across pages, got %d", server.GetToolCount())
+	}
+	if !registry.Has("local:echo") || !registry.Has("local:env") {
+		t.Fatalf("Expected tools to be registered, got %v", registry.List())
+	}
+
+	// 通过适配器调用
+	tool := NewMCPToolAdapter(&MCPToolAdapterConfig{Client: server.GetClient(), Name: "echo"})
+	result, err := tool.Execute(ctx, map[string]any{"text": "hello"}, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.(map[string]any)["text"] != "hello" {
+		t.Errorf("Unexpected echo result: %v", result)
+	}
+
+	// 配置的环境变量传给子进程
+	raw, err := server.GetClient().CallTool(ctx, "env", map[string]any{"key": "HELPER_SECRET"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !strings.Contains(string(raw), "s3cret") {
+		t.Errorf("Expected env var to reach the subprocess, got %s", raw)
+	}
+
+	// 服务端错误保留错误码
+	_, err = server.GetClient().CallTool(ctx, "missing", nil)
+	if !isInvalidParams(err) {
+		t.Errorf("Expected invalid params error, got %v", err)
+	}
+
+	stdio := server.GetClient().(*StdioClient)
+	done := stdio.Done()
+
+	// 移除时优雅关闭子进程
+	if err := manager.RemoveServer("local"); err != nil {
+		t.Fatalf("RemoveServer failed: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("Expected subprocess to exit after RemoveServer")
+	}
+	if !strings.Contains(stdio.Stderr(), "initialized") {
+		t.Errorf("Expected initialized notification to be sent, stderr: %q", stdio.Stderr())
+	}
+	if _, err := stdio.CallTool(ctx, "echo", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed after close, got %v", err)
+	}
+}
+
+func TestStdioClient_ForceKillOnShutdown(t *testing.T) {
+	config := helperServerConfig(t, "stubborn", "stubborn")
+	server, err := NewMCPServer(config, tools.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewMCPServer failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	start := time.Now()
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	// stdin 关闭和 SIGTERM 各等待一次 ShutdownTimeout，之后强制结束
+	if elapsed := time.Since(start); elapsed < 2*config.ShutdownTimeout {
+		t.Errorf("Expected close to wait for graceful shutdown, took %v", elapsed)
+	}
+	select {
+	case <-server.GetClient().(*StdioClient).Done():
+	default:
+		t.Fatal("Expected subprocess to be killed")
+	}
+}
+
+func TestStdioClient_ProcessExitReportsStderr(t *testing.T) {
+	server, err := NewMCPServer(helperServerConfig(t, "crash", "crash"), tools.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewMCPServer failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = server.Connect(ctx)
+	if err == nil {
+		t.Fatal("Expected connect to fail when the subprocess exits")
+	}
+	if !errors.Is(err, ErrClientClosed) || !strings.Contains(err.Error(), "missing API key") {
+		t.Errorf("Expected exit error with stderr tail, got: %v", err)
+	}
+}
+
+func TestStdioClient_OversizedMessageFailsPending(t *testing.T) {
+	server, err := NewMCPServer(helperServerConfig(t, "oversized", "oversized"), tools.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewMCPServer failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 等待中的 initialize 立即失败，子进程被结束而不是等到超时
+	err = server.Connect(ctx)
+	if !errors.Is(err, ErrClientClosed) || !strings.Contains(err.Error(), "token too long") {
+		t.Fatalf("Expected read error for oversized message, got: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Expected connect to fail before the context deadline")
+	}
+	select {
+	case <-server.GetClient().(*StdioClient).Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected subprocess to be killed after the read error")
+	}
+}
+
+func TestMCPServer_TransportSelection(t *testing.T) {
+	registry := tools.NewRegistry()
+
+	server, err := NewMCPServer(&MCPServerConfig{ServerID: "s", Command: "mcp-server"}, registry)
+	if err != nil {
+		t.Fatalf("NewMCPServer failed: %v", err)
+	}
+	if _, ok := server.GetClient().(*StdioClient); !ok {
+		t.Errorf("Expected stdio client when only command is set, got %T", server.GetClient())
+	}
+
+	if _, err := NewMCPServer(&MCPServerConfig{ServerID: "s", Transport: TransportStdio}, registry); err == nil {
+		t.Error("Expected error for stdio transport without command")
+	}
+	if _, err := NewMCPServer(&MCPServerConfig{ServerID: "s", Transport: "ws", Endpoint: "ws://x"}, registry); err == nil {
+		t.Error("Expected error for unsupported transport")
+	}
+
+	// 未连接时关闭是安全的
+	if err := server.Close(); err != nil {
+		t.Errorf("Close before connect failed: %v", err)
+	}
+}
+
+func TestMCPManager_HTTPServer(t *testing.T) {
+	// 使用 pkg/mcpserver 作为 HTTP MCP Server，它不实现 initialize
+	ts, _ := startHTTPMCPServer(t)
+
+	registry := tools.NewRegistry()
+	manager := NewMCPManager(registry)
+	defer func() { _ = manager.Close() }()
+
+	if _, err := manager.AddServer(&MCPServerConfig{ServerID: "remote", Endpoint: ts.URL}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := manager.ConnectServer(ctx, "remote"); err != nil {
+		t.Fatalf("ConnectServer failed: %v", err)
+	}
+	if !registry.Has("remote:echo") {
+		t.Fatalf("Expected remote:echo to be registered, got %v", registry.List())
+	}
+}
+
+// echoTool 回显输入的测试工具
+type echoTool struct{}
+
+func (e *echoTool) Name() string                { return "echo" }
+func (e *echoTool) Description() string         { return "Echo input" }
+func (e *echoTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (e *echoTool) Prompt() string              { return "" }
+func (e *echoTool) Execute(ctx context.Context, input map[string]any, tc *tools.ToolContext) (any, error) {
+	return input, nil
+}
+
+func isInvalidParams(err error) bool {
+	var mcpErr *cloud.MCPError
+	return errors.As(err, &mcpErr) && mcpErr.Code == cloud.MCPErrorInvalidParams
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// RateLimitedError 工具调用超出限流
+// 错误信息面向模型，以 "rate_limited" 开头并给出建议的重试间隔。
+type RateLimitedError struct {
+	Tool       string
+	Limit      types.RateLimit
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate_limited: tool %s exceeded its rate limit (%g calls/s, burst %d); retry after %s or use another approach",
+		e.Tool, e.Limit.QPS, e.Limit.Burst, e.RetryAfter.Round(time.Millisecond))
+}
+
+// rateLimiter 按工具名分别计数的令牌桶限流器
+type rateLimiter struct {
+	mu       sync.Mutex
+	defaults types.RateLimit
+	perTool  map[string]types.RateLimit
+	maxWait  time.Duration
+	buckets  map[string]*tokenBucket
+	now      func() time.Time
+}
+
+// newRateLimiter 创建限流器，未配置任何限流时返回 nil
+func newRateLimiter(defaults types.RateLimit, perTool map[string]types.RateLimit, maxWait time.Duration) *rateLimiter {
+	limited := defaults.QPS > 0
+	for _, limit := range perTool {
+		limited = limited || limit.QPS > 0
+	}
+	if !limited {
+		return nil
+	}
+	return &rateLimiter{
+		defaults: defaults,
+		perTool:  perTool,
+		maxWait:  maxWait,
+		buckets:  make(map[string]*tokenBucket),
+		now:      time.Now,
+	}
+}
+
+// reserve 为一次调用预留令牌，返回需要等待的时长和取消预留的函数
+// 需要等待的时间超过 maxWait 时不预留，返回 *RateLimitedError。
+func (l *rateLimiter) reserve(tool string) (time.Duration, func(), error) {
+	if l == nil {
+		return 0, func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[tool]
+	if !ok {
+		limit, ok := l.perTool[tool]
+		if !ok {
+			limit = l.defaults
+		}
+		if limit.QPS <= 0 {
+			return 0, func() {}, nil
+		}
+		if limit.Burst <= 0 {
+			limit.Burst = max(1, int(math.Ceil(limit.QPS)))
+		}
+		b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: l.now()}
+		l.buckets[tool] = b
+	}
+
+	b.refill(l.now())
+	wait := b.waitFor()
+	if wait > l.maxWait {
+		return 0, nil, &RateLimitedError{Tool: tool, Limit: b.limit, RetryAfter: wait}
+	}
+	b.tokens--
+	return wait, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		b.refill(l.now())
+		b.tokens = min(b.tokens+1, float64(b.limit.Burst))
+	}, nil
+}
+
+// tokenBucket 令牌桶，tokens 为负表示已有调用在排队等待
+type tokenBucket struct {
+	limit  types.RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.limit.QPS, float64(b.limit.Burst))
+		b.last = now
+	}
+}
+
+// waitFor 返回获得一个令牌需要等待的时长
+func (b *tokenBucket) waitFor() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.QPS * float64(time.Second))
+}
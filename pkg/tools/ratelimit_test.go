@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astercloud/aster/pkg/types"
+)
+
+// fakeRateClock 可手动推进的时钟
+type fakeRateClock struct {
+	now time.Time
+}
+
+func (c *fakeRateClock) Now() time.Time { return c.now }
+
+func newRateLimitedExecutor(config ExecutorConfig, clock *fakeRateClock) *Executor {
+	e := NewExecutor(config)
+	e.limiter.now = clock.Now
+	return e
+}
+
+func TestExecutor_RateLimitAllowsBurstThenRejects(t *testing.T) {
+	clock := &fakeRateClock{now: time.Unix(0, 0)}
+	bash := &MockTool{name: "Bash"}
+	read := &MockTool{name: "Read"}
+	e := newRateLimitedExecutor(ExecutorConfig{
+		RateLimit:      types.RateLimit{QPS: 1, Burst: 3},
+		ToolRateLimits: map[string]types.RateLimit{"Read": {}},
+	}, clock)
+
+	ctx := context.Background()
+	for i := range 3 {
+		if result := e.Execute(ctx, &ExecuteRequest{Tool: bash}); !result.Success {
+			t.Fatalf("call %d within burst failed: %v", i, result.Error)
+		}
+	}
+
+	result := e.Execute(ctx, &ExecuteRequest{Tool: bash})
+	var limited *RateLimitedError
+	if result.Success || !errors.As(result.Error, &limited) {
+		t.Fatalf("expected rate limited error, got %+v", result)
+	}
+	if limited.Tool != "Bash" || limited.RetryAfter != time.Second {
+		t.Errorf("unexpected error details: %+v", limited)
+	}
+	if !strings.HasPrefix(result.Error.Error(), "rate_limited:") {
+		t.Errorf("error should start with rate_limited, got %q", result.Error)
+	}
+	if bash.callCount != 3 {
+		t.Errorf("rejected call must not execute the tool, got %d calls", bash.callCount)
+	}
+
+	// 其他工具单独计数，QPS 为 0 的覆盖表示不限流
+	for range 10 {
+		if result := e.Execute(ctx, &ExecuteRequest{Tool: read}); !result.Success {
+			t.Fatalf("unlimited tool was throttled: %v", result.Error)
+		}
+	}
+
+	// 令牌按 QPS 补充
+	clock.now = clock.now.Add(time.Second)
+	if result := e.Execute(ctx, &ExecuteRequest{Tool: bash}); !result.Success {
+		t.Fatalf("call after refill failed: %v", result.Error)
+	}
+	if result := e.Execute(ctx, &ExecuteRequest{Tool: bash}); result.Success {
+		t.Error("only one token should have been refilled")
+	}
+}
+
+func TestExecutor_RateLimitDelaysSustainedLoad(t *testing.T) {
+	tool := &MockTool{name: "WebFetch"}
+	e := NewExecutor(ExecutorConfig{
+		ToolRateLimits:   map[string]types.RateLimit{"WebFetch": {QPS: 50, Burst: 2}},
+		RateLimitMaxWait: time.Second,
+	})
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := range 7 {
+		if result := e.Execute(ctx, &ExecuteRequest{Tool: tool}); !result.Success {
+			t.Fatalf("call %d should wait instead of failing: %v", i, result.Error)
+		}
+	}
+	// 2 次突发后其余 5 次按 50 QPS 间隔 20ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("sustained calls were not throttled, took %v", elapsed)
+	}
+
+	// 等待中的调用随 ctx 取消
+	e = NewExecutor(ExecutorConfig{RateLimit: types.RateLimit{QPS: 1}, RateLimitMaxWait: time.Minute})
+	_ = e.Execute(context.Background(), &ExecuteRequest{Tool: tool})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := e.Execute(ctx, &ExecuteRequest{Tool: tool}); result.Success || !errors.Is(result.Error, context.Canceled) {
+		t.Errorf("expected canceled error, got %+v", result)
+	}
+}
+
+func TestExecutor_NoRateLimitByDefault(t *testing.T) {
+	if e := NewExecutor(ExecutorConfig{}); e.limiter != nil {
+		t.Error("limiter should be disabled without configuration")
+	}
+}
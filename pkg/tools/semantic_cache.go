@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/vector"
+)
+
+// DefaultSemanticThreshold 语义缓存默认相似度阈值
+// 取值较高以保证只有近似同义的输入才会命中
+const DefaultSemanticThreshold = 0.95
+
+// SemanticCacheConfig 语义缓存配置
+// 精确键未命中时，将工具输入向量化，并在同一工具、未过期的缓存条目中查找相似输入。
+// 仅对只读工具生效（工具需实现 AnnotatedTool 且 ReadOnly 为 true）。
+type SemanticCacheConfig struct {
+	// Embedder 向量化器（必需）
+	Embedder vector.Embedder
+
+	// Threshold 余弦相似度阈值（默认 0.95）
+	Threshold float64
+
+	// MaxEntriesPerTool 每个工具保留的最大语义索引条目数（0 表示无限制）
+	MaxEntriesPerTool int
+}
+
+// CacheHitKind 缓存命中类型
+type CacheHitKind string
+
+const (
+	CacheHitNone     CacheHitKind = ""         // 未命中
+	CacheHitExact    CacheHitKind = "exact"    // 精确键命中
+	CacheHitSemantic CacheHitKind = "semantic" // 语义相似命中
+)
+
+// semanticEntry 语义索引条目，Key 指向精确缓存中的结果
+type semanticEntry struct {
+	key       string
+	embedding []float32
+	expiresAt time.Time
+}
+
+// semanticIndex 按工具名组织的语义索引
+type semanticIndex struct {
+	mu      sync.RWMutex
+	entries map[string][]*semanticEntry
+}
+
+// remove 移除指向 key 的语义索引条目
+func (idx *semanticIndex) remove(toolName, key string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entries := idx.entries[toolName]
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.key != key {
+			kept = append(kept, entry)
+		}
+	}
+	clear(entries[len(kept):])
+	idx.entries[toolName] = kept
+}
+
+// semanticEnabled 是否启用语义缓存
+func (c *ToolCache) semanticEnabled() bool {
+	return c.config.Semantic != nil && c.config.Semantic.Embedder != nil
+}
+
+// semanticThreshold 返回生效的相似度阈值
+func (c *ToolCache) semanticThreshold() float64 {
+	if c.config.Semantic.Threshold > 0 {
+		return c.config.Semantic.Threshold
+	}
+	return DefaultSemanticThreshold
+}
+
+// Lookup 查找工具结果缓存：先精确匹配，allowSemantic 为 true 时再尝试语义匹配
+func (c *ToolCache) Lookup(ctx context.Context, toolName string, input map[string]any, allowSemantic bool) (any, CacheHitKind) {
+	if !c.config.Enabled {
+		return nil, CacheHitNone
+	}
+
+	if value, ok := c.get(c.GenerateKey(toolName, input)); ok {
+		c.statsMu.Lock()
+		c.stats.Hits++
+		c.stats.ExactHits++
+		c.statsMu.Unlock()
+		return value, CacheHitExact
+	}
+
+	if allowSemantic && c.semanticEnabled() {
+		if value, ok := c.getSemantic(ctx, toolName, input); ok {
+			c.statsMu.Lock()
+			c.stats.Hits++
+			c.stats.SemanticHits++
+			c.statsMu.Unlock()
+			return value, CacheHitSemantic
+		}
+	}
+
+	c.statsMu.Lock()
+	c.stats.Misses++
+	c.statsMu.Unlock()
+	return nil, CacheHitNone
+}
+
+// Store 缓存工具结果，indexSemantic 为 true 时同时写入语义索引
+func (c *ToolCache) Store(ctx context.Context, toolName string, input map[string]any, value any, indexSemantic bool) error {
+	if !c.config.Enabled {
+		return nil
+	}
+
+	key := c.GenerateKey(toolName, input)
+	if err := c.Set(ctx, key, value, c.config.TTL); err != nil {
+		return err
+	}
+
+	if indexSemantic && c.semanticEnabled() {
+		c.indexSemantic(ctx, toolName, input, key)
+	}
+	return nil
+}
+
+// getSemantic 在语义索引中查找最相似的未过期条目
+func (c *ToolCache) getSemantic(ctx context.Context, toolName string, input map[string]any) (any, bool) {
+	embedding, err := c.embedInput(ctx, input)
+	if err != nil {
+		c.statsMu.Lock()
+		c.stats.Errors++
+		c.statsMu.Unlock()
+		return nil, false
+	}
+
+	c.semantic.mu.RLock()
+	var best *semanticEntry
+	bestScore := c.semanticThreshold()
+	now := time.Now()
+	for _, entry := range c.semantic.entries[toolName] {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		if score := vector.CosineSimilarity(embedding, entry.embedding); score >= bestScore {
+			best = entry
+			bestScore = score
+		}
+	}
+	c.semantic.mu.RUnlock()
+
+	if best == nil {
+		return nil, false
+	}
+	return c.get(best.key)
+}
+
+// indexSemantic 将工具输入加入语义索引
+func (c *ToolCache) indexSemantic(ctx context.Context, toolName string, input map[string]any, key string) {
+	embedding, err := c.embedInput(ctx, input)
+	if err != nil {
+		c.statsMu.Lock()
+		c.stats.Errors++
+		c.statsMu.Unlock()
+		return
+	}
+
+	c.semantic.mu.Lock()
+	defer c.semantic.mu.Unlock()
+
+	// 清理过期条目和同键旧条目
+	now := time.Now()
+	entries := c.semantic.entries[toolName][:0]
+	for _, entry := range c.semantic.entries[toolName] {
+		if entry.key != key && now.Before(entry.expiresAt) {
+			entries = append(entries, entry)
+		}
+	}
+
+	entries = append(entries, &semanticEntry{
+		key:       key,
+		embedding: embedding,
+		expiresAt: now.Add(c.config.TTL),
+	})
+
+	if limit := c.config.Semantic.MaxEntriesPerTool; limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	c.semantic.entries[toolName] = entries
+}
+
+// embedInput 将工具输入序列化后向量化
+func (c *ToolCache) embedInput(ctx context.Context, input map[string]any) ([]float32, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := c.config.Semantic.Embedder.EmbedText(ctx, []string{string(data)})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	return vectors[0], nil
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// keywordEmbedder 按关键词出现情况生成向量，用于模拟语义相近的输入
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e *keywordEmbedder) EmbedText(_ context.Context, texts []string) ([][]float32, error) {
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(e.keywords))
+		lower := strings.ToLower(text)
+		for j, kw := range e.keywords {
+			if strings.Contains(lower, kw) {
+				vec[j] = 1
+			}
+		}
+		result[i] = vec
+	}
+	return result, nil
+}
+
+// readOnlyMockTool 声明为只读的模拟工具
+type readOnlyMockTool struct {
+	MockTool
+}
+
+func (t *readOnlyMockTool) Annotations() *ToolAnnotations {
+	return AnnotationsNetworkRead
+}
+
+func newSemanticCache() *ToolCache {
+	return NewToolCache(&CacheConfig{
+		Enabled:  true,
+		Strategy: CacheStrategyMemory,
+		TTL:      time.Hour,
+		Semantic: &SemanticCacheConfig{
+			Embedder: &keywordEmbedder{keywords: []string{"weather", "paris", "tokyo"}},
+		},
+	})
+}
+
+func TestCachedTool_SemanticHit(t *testing.T) {
+	ctx := context.Background()
+	cache := newSemanticCache()
+	search := &readOnlyMockTool{MockTool{name: "WebSearch"}}
+	cached := NewCachedTool(search, cache)
+
+	if _, err := cached.Execute(ctx, map[string]any{"query": "weather in Paris"}, nil); err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+	if _, err := cached.Execute(ctx, map[string]any{"query": "what is the Paris weather like"}, nil); err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+	if search.callCount != 1 {
+		t.Errorf("Expected paraphrased query to hit semantic cache, got %d calls", search.callCount)
+	}
+
+	// 语义不同的查询不应命中
+	if _, err := cached.Execute(ctx, map[string]any{"query": "weather in Tokyo"}, nil); err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+	if search.callCount != 2 {
+		t.Errorf("Expected different query to miss cache, got %d calls", search.callCount)
+	}
+
+	// 精确命中
+	if _, err := cached.Execute(ctx, map[string]any{"query": "weather in Paris"}, nil); err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+
+	stats := cache.GetStats()
+	if stats.SemanticHits != 1 || stats.ExactHits != 1 || stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCachedTool_SemanticSkipsNonReadOnlyTools(t *testing.T) {
+	ctx := context.Background()
+	cache := newSemanticCache()
+	tool := &MockTool{name: "Write"}
+	cached := NewCachedTool(tool, cache)
+
+	_, _ = cached.Execute(ctx, map[string]any{"content": "weather in Paris"}, nil)
+	_, _ = cached.Execute(ctx, map[string]any{"content": "Paris weather"}, nil)
+
+	if tool.callCount != 2 {
+		t.Errorf("Expected non read-only tool to bypass semantic cache, got %d calls", tool.callCount)
+	}
+	if cache.GetStats().SemanticHits != 0 {
+		t.Error("Expected no semantic hits for non read-only tool")
+	}
+}
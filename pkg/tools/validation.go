@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// InvalidArgumentsError 工具参数不符合 InputSchema
+// 错误信息面向模型，列出所有问题以便模型修正后重新调用。
+type InvalidArgumentsError struct {
+	Tool       string
+	Violations []string
+}
+
+func (e *InvalidArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %s; fix the arguments and call the tool again",
+		e.Tool, strings.Join(e.Violations, "; "))
+}
+
+// ValidateInput 按工具的 InputSchema 校验输入
+// 支持 required、type、enum、嵌套 properties/items 和 additionalProperties: false；
+// 校验失败时返回 *InvalidArgumentsError。
+func ValidateInput(tool Tool, input map[string]any) error {
+	schema := tool.InputSchema()
+	if schema == nil {
+		return nil // 没有schema,跳过验证
+	}
+
+	violations := validateValue(schema, input, "")
+	if len(violations) == 0 {
+		return nil
+	}
+	return &InvalidArgumentsError{Tool: tool.Name(), Violations: violations}
+}
+
+// ValidatedTool 执行前校验输入的工具包装器
+// 未包装的工具也可以通过 ExecutorConfig.ValidateInputs 统一开启校验。
+type ValidatedTool struct {
+	Tool
+}
+
+// NewValidatedTool 包装工具，使其在 Execute 前按 InputSchema 校验输入
+func NewValidatedTool(tool Tool) *ValidatedTool {
+	if vt, ok := tool.(*ValidatedTool); ok {
+		return vt
+	}
+	return &ValidatedTool{Tool: tool}
+}
+
+// Unwrap 返回被包装的工具
+func (t *ValidatedTool) Unwrap() Tool {
+	return t.Tool
+}
+
+// Execute 校验输入后执行工具
+func (t *ValidatedTool) Execute(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+	if err := ValidateInput(t.Tool, input); err != nil {
+		return nil, err
+	}
+	return t.Tool.Execute(ctx, input, tc)
+}
+
+// validateValue 递归校验值，返回所有违规描述
+func validateValue(schema map[string]any, value any, path string) []string {
+	if value == nil && path == "" {
+		value = map[string]any{}
+	}
+
+	var violations []string
+
+	if typ, ok := schema["type"]; ok {
+		if !matchesType(typ, value) {
+			return []string{fmt.Sprintf("%s must be %s, got %s", fieldLabel(path), typeLabel(typ), jsonType(value))}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return equalJSON(e, value) }) {
+		violations = append(violations, fmt.Sprintf("%s must be one of %v", fieldLabel(path), enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range requiredFields(schema) {
+			if _, exists := v[name]; !exists {
+				violations = append(violations, "missing required field "+fieldLabel(joinPath(path, name)))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := properties[name].(map[string]any)
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					violations = append(violations, "unknown field "+fieldLabel(joinPath(path, name)))
+				}
+				continue
+			}
+			violations = append(violations, validateValue(prop, v[name], joinPath(path, name))...)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				violations = append(violations, validateValue(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// requiredFields 读取 required 列表，兼容 []any 和 []string
+func requiredFields(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		fields := make([]string, 0, len(required))
+		for _, field := range required {
+			if name, ok := field.(string); ok {
+				fields = append(fields, name)
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+// matchesType 判断值是否符合 type（支持字符串或字符串数组）
+func matchesType(typ any, value any) bool {
+	switch t := typ.(type) {
+	case string:
+		return matchesSingleType(t, value)
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		return slices.ContainsFunc(t, func(name string) bool { return matchesSingleType(name, value) })
+	}
+	return true
+}
+
+func matchesSingleType(typ string, value any) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	}
+	return true
+}
+
+// toFloat 将 JSON 数字（以及 Go 代码直接构造的整数）转换为 float64
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// jsonType 返回值对应的 JSON 类型名
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeLabel(typ any) string {
+	switch t := typ.(type) {
+	case []any:
+		names := make([]string, 0, len(t))
+		for _, item := range t {
+			names = append(names, fmt.Sprint(item))
+		}
+		return strings.Join(names, " or ")
+	case []string:
+		return strings.Join(t, " or ")
+	}
+	return fmt.Sprint(typ)
+}
+
+func equalJSON(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fieldLabel 生成错误信息中的字段名（避免双引号，结果会嵌入 JSON 字符串）
+func fieldLabel(path string) string {
+	if path == "" {
+		return "input"
+	}
+	return "'" + path + "'"
+}
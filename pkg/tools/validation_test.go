@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// schemaTool 带完整 InputSchema 的测试工具
+type schemaTool struct {
+	MockTool
+}
+
+func (t *schemaTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":   map[string]any{"type": "string"},
+			"limit":  map[string]any{"type": "integer"},
+			"mode":   map[string]any{"type": "string", "enum": []any{"read", "write"}},
+			"labels": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"required": []any{"path"},
+	}
+}
+
+func newSchemaTool() *schemaTool {
+	return &schemaTool{MockTool: MockTool{name: "read_file"}}
+}
+
+func TestValidateInput(t *testing.T) {
+	tool := newSchemaTool()
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  []string
+	}{
+		{"valid", map[string]any{"path": "/a", "limit": float64(10), "mode": "read"}, nil},
+		{"missing required", map[string]any{"limit": float64(10)}, []string{"missing required field 'path'"}},
+		{"wrong type", map[string]any{"path": "/a", "limit": "10"}, []string{"'limit' must be integer, got string"}},
+		{"fractional integer", map[string]any{"path": "/a", "limit": 1.5}, []string{"'limit' must be integer"}},
+		{"enum", map[string]any{"path": "/a", "mode": "delete"}, []string{"'mode' must be one of [read write]"}},
+		{"array items", map[string]any{"path": "/a", "labels": []any{"x", float64(1)}}, []string{"'labels[1]' must be string, got number"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInput(tool, tt.input)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected valid input, got %v", err)
+				}
+				return
+			}
+
+			var invalid *InvalidArgumentsError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected InvalidArgumentsError, got %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q should contain %q", err.Error(), want)
+				}
+			}
+			if strings.Contains(err.Error(), `"`) {
+				t.Errorf("error must not contain double quotes, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestValidatedTool_SkipsExecuteOnInvalidInput(t *testing.T) {
+	inner := newSchemaTool()
+	tool := NewValidatedTool(inner)
+
+	if NewValidatedTool(tool) != tool {
+		t.Error("wrapping twice should return the same wrapper")
+	}
+
+	_, err := tool.Execute(context.Background(), map[string]any{"limit": "ten"}, nil)
+	var invalid *InvalidArgumentsError
+	if !errors.As(err, &invalid) || len(invalid.Violations) != 2 {
+		t.Fatalf("expected both violations, got %v", err)
+	}
+	if inner.callCount != 0 {
+		t.Error("Execute must not run with invalid input")
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": "/a"}, nil); err != nil {
+		t.Fatalf("valid input failed: %v", err)
+	}
+	if inner.callCount != 1 {
+		t.Errorf("expected 1 call, got %d", inner.callCount)
+	}
+}
+
+func TestExecutor_ValidateInputs(t *testing.T) {
+	inner := newSchemaTool()
+	req := &ExecuteRequest{Tool: inner, Input: map[string]any{"path": 42}}
+
+	// 默认不校验
+	if result := NewExecutor(ExecutorConfig{}).Execute(context.Background(), req); !result.Success {
+		t.Fatalf("validation is opt-in, got %v", result.Error)
+	}
+
+	result := NewExecutor(ExecutorConfig{ValidateInputs: true}).Execute(context.Background(), req)
+	var invalid *InvalidArgumentsError
+	if result.Success || !errors.As(result.Error, &invalid) {
+		t.Fatalf("expected invalid arguments error, got %+v", result)
+	}
+	if inner.callCount != 1 {
+		t.Errorf("tool should not run when validation fails, got %d calls", inner.callCount)
+	}
+}
@@ -162,7 +162,7 @@ type StoreConfig struct {
 	MaxMessages int `json:"max_messages,omitempty"`
 
 	// AutoTrim 是否在每次保存消息后自动修剪
-	// true = 每次 SaveMessages 后自动调用 TrimMessages
+	// true = 每次保存（追加）消息后自动调用 TrimMessages
 	// false = 需要手动调用 TrimMessages
 	// 默认值: true
 	AutoTrim bool `json:"auto_trim,omitempty"`
@@ -278,6 +278,30 @@ type ModelConfig struct {
 	APIKey        string        `json:"api_key,omitempty" yaml:"api_key,omitempty"`
 	BaseURL       string        `json:"base_url,omitempty" yaml:"base_url,omitempty"`
 	ExecutionMode ExecutionMode `json:"execution_mode,omitempty" yaml:"execution_mode,omitempty"` // 执行模式：streaming/non-streaming/auto
+	MaxConcurrent int           `json:"max_concurrent,omitempty" yaml:"max_concurrent,omitempty"` // 同一 Provider/Key 的最大并发请求数，0 表示不限制
+
+	// RequestTimeout 单次模型请求（Complete 或整个 Stream）的超时时间，0 表示不限制
+	RequestTimeout time.Duration `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"`
+
+	// Retry Provider 内部对 HTTP 429 和 5xx 的重试，nil 表示不重试
+	// 与 ModelFallback 组合时先在同一模型内重试，重试耗尽后再切换备用模型
+	Retry *RetryConfig `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// BaseURLs 同一模型的多个冗余端点，按顺序优先使用，失败的端点在冷却期内被跳过
+	// 与 BaseURL 同时配置时 BaseURL 排在最前
+	BaseURLs []string `json:"base_urls,omitempty" yaml:"base_urls,omitempty"`
+	// EndpointCooldown 端点失败后的冷却时间，0 表示使用默认值（30s）
+	EndpointCooldown time.Duration `json:"endpoint_cooldown,omitempty" yaml:"endpoint_cooldown,omitempty"`
+
+	// StreamCoalesceWindow 流式文本增量合并窗口，0 表示逐个增量发送事件
+	StreamCoalesceWindow time.Duration `json:"stream_coalesce_window,omitempty" yaml:"stream_coalesce_window,omitempty"`
+}
+
+// RetryConfig Provider 请求重试配置
+type RetryConfig struct {
+	MaxRetries  int           `json:"max_retries" yaml:"max_retries"`                       // 最大重试次数
+	BaseBackoff time.Duration `json:"base_backoff,omitempty" yaml:"base_backoff,omitempty"` // 基础退避时间，按指数增长（默认 1s）
+	MaxBackoff  time.Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`   // 单次等待上限，同样限制 Retry-After（默认 60s）
 }
 
 // SandboxKind 沙箱类型
@@ -309,6 +333,13 @@ type SandboxConfig struct {
 
 	// PermissionMode 沙箱权限模式
 	PermissionMode SandboxPermissionMode `json:"permission_mode,omitempty"`
+
+	// Env 允许透传到命令执行环境的主机环境变量名
+	// 为空时仅透传 TERM、USER 等非敏感变量；其余主机环境变量一律不继承
+	Env []string `json:"env,omitempty"`
+
+	// Secrets 按工具注入的密钥
+	Secrets []SandboxSecret `json:"secrets,omitempty"`
 }
 
 // CloudCredentials 云平台凭证
@@ -371,6 +402,15 @@ type AgentConfig struct {
 	SkillsPackage  *SkillsPackageConfig   `json:"skills_package,omitempty" yaml:"skills_package,omitempty"` // Skills 包配置
 	Metadata       map[string]any         `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 
+	// RunLimits 运行限制配置（可选）
+	RunLimits *RunLimits `json:"run_limits,omitempty" yaml:"run_limits,omitempty"`
+
+	// ValidateToolInputs 执行前按 InputSchema 校验工具参数，不合法时把可修正的错误返回给模型
+	ValidateToolInputs bool `json:"validate_tool_inputs,omitempty" yaml:"validate_tool_inputs,omitempty"`
+
+	// ToolRateLimits 工具调用限流配置（可选），按工具名分别计数
+	ToolRateLimits *ToolRateLimits `json:"tool_rate_limits,omitempty" yaml:"tool_rate_limits,omitempty"`
+
 	// === 多租户支持 ===
 
 	// Multitenancy 多租户配置
@@ -392,6 +432,30 @@ type AgentConfig struct {
 	AllowDangerouslySkipPermissions bool `json:"allow_dangerously_skip_permissions,omitempty"`
 }
 
+// RunLimits 运行限制配置
+type RunLimits struct {
+	// MaxTurnRetries 模型返回空内容或无法解析的工具调用时，整轮重试的最大次数（0 表示不重试）
+	MaxTurnRetries int `json:"max_turn_retries,omitempty" yaml:"max_turn_retries,omitempty"`
+}
+
+// RateLimit 令牌桶限流参数
+type RateLimit struct {
+	// QPS 每秒补充的令牌数，<= 0 表示不限流
+	QPS float64 `json:"qps,omitempty" yaml:"qps,omitempty"`
+	// Burst 桶容量，即允许的突发调用数，<= 0 时取 QPS 向上取整（至少为 1）
+	Burst int `json:"burst,omitempty" yaml:"burst,omitempty"`
+}
+
+// ToolRateLimits 工具调用限流配置
+type ToolRateLimits struct {
+	// Default 每个工具的默认限流
+	Default RateLimit `json:"default,omitempty" yaml:"default,omitempty"`
+	// Tools 按工具名覆盖默认限流，QPS <= 0 表示该工具不限流
+	Tools map[string]RateLimit `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// MaxWaitMs 超出限流时最多等待的毫秒数，0 表示立即拒绝
+	MaxWaitMs int `json:"max_wait_ms,omitempty" yaml:"max_wait_ms,omitempty"`
+}
+
 // ResumeStrategy 恢复策略
 type ResumeStrategy string
 
@@ -439,6 +503,9 @@ type CompleteResult struct {
 	Text          string    `json:"text,omitempty"`
 	Last          *Bookmark `json:"last,omitempty"`
 	PermissionIDs []string  `json:"permission_ids,omitempty"`
+
+	// Usage 本次对话所有模型调用累计的 Token 使用量
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
 // ExecutionMode 执行模式
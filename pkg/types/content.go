@@ -1,6 +1,11 @@
 package types
 
-import "strings"
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // MultimodalContent 多模态内容接口
 // 扩展 ContentBlock 以支持图片、音频、视频等多模态输入
@@ -35,6 +40,55 @@ func (i *ImageContent) GetMediaType() string {
 	return "image"
 }
 
+// String 返回不含图片数据的描述，避免 base64 内容出现在日志中
+func (i *ImageContent) String() string {
+	if i == nil {
+		return "[image]"
+	}
+	switch {
+	case i.Type == "base64":
+		padding := len(i.Source) - len(strings.TrimRight(i.Source, "="))
+		return fmt.Sprintf("[image %s, %d bytes]", i.MimeType, base64.StdEncoding.DecodedLen(len(i.Source))-padding)
+	case strings.HasPrefix(i.Source, "data:"):
+		// data URL 内嵌图片数据，同样不输出
+		return fmt.Sprintf("[image %s, inline data]", i.MimeType)
+	}
+	return fmt.Sprintf("[image %s]", i.Source)
+}
+
+// LogValue 写入日志时的取值（见 logging.LogValuer），只输出图片描述
+func (i *ImageContent) LogValue() any {
+	return i.String()
+}
+
+// redacted 返回图片数据替换为描述的副本，用于日志与追踪
+func (i *ImageContent) redacted() *ImageContent {
+	cp := *i
+	cp.Source = i.String()
+	return &cp
+}
+
+// NewImageFromBytes 从图片字节创建 base64 图片内容，mimeType 为空时自动检测
+func NewImageFromBytes(data []byte, mimeType string) *ImageContent {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return &ImageContent{
+		Type:     "base64",
+		Source:   base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// NewImageFromURL 创建引用 URL 的图片内容
+func NewImageFromURL(url, mimeType string) *ImageContent {
+	return &ImageContent{
+		Type:     "url",
+		Source:   url,
+		MimeType: mimeType,
+	}
+}
+
 // AudioContent 音频内容
 type AudioContent struct {
 	// Type 音频来源类型: "url", "base64"
@@ -189,6 +189,20 @@ type ProgressSessionSummarizedEvent struct {
 func (e *ProgressSessionSummarizedEvent) Channel() AgentChannel { return ChannelProgress }
 func (e *ProgressSessionSummarizedEvent) EventType() string     { return "session_summarized" }
 
+// ProgressConversationSummarizedEvent 较早的对话已被摘要替换事件
+// 携带完整摘要和被替换的消息范围，UI 可据此展示"较早的对话已总结"并查看摘要内容
+type ProgressConversationSummarizedEvent struct {
+	Summary       string `json:"summary"`        // 发送给模型的完整摘要
+	ReplacedFrom  int    `json:"replaced_from"`  // 被替换消息在原消息列表中的起始索引
+	ReplacedTo    int    `json:"replaced_to"`    // 被替换消息的结束索引（不含）
+	ReplacedCount int    `json:"replaced_count"` // 被替换的消息数
+	ToolCalls     int    `json:"tool_calls"`     // 摘要中保留的工具调用数
+	Notice        string `json:"notice"`         // 面向用户的提示文案
+}
+
+func (e *ProgressConversationSummarizedEvent) Channel() AgentChannel { return ChannelProgress }
+func (e *ProgressConversationSummarizedEvent) EventType() string     { return "conversation_summarized" }
+
 // ===================
 // Control Channel Events
 // ===================
@@ -216,6 +230,27 @@ type ControlPermissionDecidedEvent struct {
 func (e *ControlPermissionDecidedEvent) Channel() AgentChannel { return ChannelControl }
 func (e *ControlPermissionDecidedEvent) EventType() string     { return "permission_decided" }
 
+// ControlPlanProposedEvent Plan 模式提交计划，等待用户审批
+type ControlPlanProposedEvent struct {
+	PlanID    string   `json:"plan_id"`
+	Content   string   `json:"content"`
+	Steps     []string `json:"steps,omitempty"`
+	Rationale string   `json:"rationale,omitempty"`
+}
+
+func (e *ControlPlanProposedEvent) Channel() AgentChannel { return ChannelControl }
+func (e *ControlPlanProposedEvent) EventType() string     { return "plan_proposed" }
+
+// ControlPlanDecidedEvent 计划审批结果事件
+type ControlPlanDecidedEvent struct {
+	PlanID   string `json:"plan_id"`
+	Decision string `json:"decision"` // "approved" or "rejected"
+	Feedback string `json:"feedback,omitempty"`
+}
+
+func (e *ControlPlanDecidedEvent) Channel() AgentChannel { return ChannelControl }
+func (e *ControlPlanDecidedEvent) EventType() string     { return "plan_decided" }
+
 // ControlIterationLimitEvent 迭代限制事件
 type ControlIterationLimitEvent struct {
 	CurrentIteration int    `json:"current_iteration"`
@@ -279,6 +314,16 @@ type MonitorErrorEvent struct {
 func (e *MonitorErrorEvent) Channel() AgentChannel { return ChannelMonitor }
 func (e *MonitorErrorEvent) EventType() string     { return "error" }
 
+// MonitorTurnRetryEvent 整轮重试事件（模型输出为空或工具调用无效）
+type MonitorTurnRetryEvent struct {
+	Attempt    int    `json:"attempt"`
+	MaxRetries int    `json:"max_retries"`
+	Reason     string `json:"reason"` // "empty_output" | "invalid_tool_call"
+}
+
+func (e *MonitorTurnRetryEvent) Channel() AgentChannel { return ChannelMonitor }
+func (e *MonitorTurnRetryEvent) EventType() string     { return "turn_retry" }
+
 // MonitorTokenUsageEvent Token使用统计事件
 type MonitorTokenUsageEvent struct {
 	InputTokens  int64 `json:"input_tokens"`
@@ -289,6 +334,25 @@ type MonitorTokenUsageEvent struct {
 func (e *MonitorTokenUsageEvent) Channel() AgentChannel { return ChannelMonitor }
 func (e *MonitorTokenUsageEvent) EventType() string     { return "token_usage" }
 
+// MonitorModelEndpointEvent 模型请求实际使用的端点（配置了多个 BaseURLs 时发出）
+type MonitorModelEndpointEvent struct {
+	Step     int    `json:"step"`
+	Endpoint string `json:"endpoint"`
+}
+
+func (e *MonitorModelEndpointEvent) Channel() AgentChannel { return ChannelMonitor }
+func (e *MonitorModelEndpointEvent) EventType() string     { return "model_endpoint" }
+
+// MonitorRunUsageEvent 一次运行（Chat/Send）结束时累计的 Token 使用量
+type MonitorRunUsageEvent struct {
+	RunID      string     `json:"run_id"`
+	ModelCalls int        `json:"model_calls"` // 上报了用量的模型调用次数
+	Usage      TokenUsage `json:"usage"`
+}
+
+func (e *MonitorRunUsageEvent) Channel() AgentChannel { return ChannelMonitor }
+func (e *MonitorRunUsageEvent) EventType() string     { return "run_usage" }
+
 // MonitorToolExecutedEvent 工具执行完成事件
 type MonitorToolExecutedEvent struct {
 	Call ToolCallSnapshot `json:"call"`
@@ -155,6 +155,9 @@ func (t *ToolResultBlock) IsContentBlock() {}
 
 // Message 表示一条消息
 type Message struct {
+	// ID 消息 ID，由 Agent 在消息加入历史时分配
+	ID string `json:"id,omitempty"`
+
 	// Role 消息角色
 	Role Role `json:"role"`
 
@@ -177,6 +180,10 @@ type Message struct {
 	// Metadata 消息元数据，用于可见性控制和标记
 	// 如果为 nil，默认双方可见
 	Metadata *MessageMetadata `json:"metadata,omitempty"`
+
+	// Annotations 消息注解（如用户评价、结果是否命中缓存），随消息持久化，用于事后分析
+	// 不会发送给模型
+	Annotations map[string]any `json:"annotations,omitempty"`
 }
 
 // IsVisibleForAgent 检查消息是否对 Agent/LLM 可见
@@ -389,10 +396,12 @@ type contentBlockJSON struct {
 	ToolUseID string         `json:"tool_use_id,omitempty"`
 	Content   string         `json:"content,omitempty"`
 	IsError   bool           `json:"is_error,omitempty"`
+	Image     *ImageContent  `json:"image,omitempty"`
 }
 
 // messageJSON 用于 JSON 序列化的消息结构
 type messageJSON struct {
+	ID            string             `json:"id,omitempty"`
 	Role          Role               `json:"role"`
 	Content       string             `json:"content,omitempty"`
 	ContentBlocks []contentBlockJSON `json:"content_blocks,omitempty"`
@@ -400,17 +409,35 @@ type messageJSON struct {
 	ToolCalls     []ToolCall         `json:"tool_calls,omitempty"`
 	ToolCallID    string             `json:"tool_call_id,omitempty"`
 	Metadata      *MessageMetadata   `json:"metadata,omitempty"`
+	Annotations   map[string]any     `json:"annotations,omitempty"`
 }
 
 // MarshalJSON 自定义 JSON 序列化
 func (m Message) MarshalJSON() ([]byte, error) {
+	return m.marshalJSON(false)
+}
+
+// LogValue 写入日志时的取值（见 logging.LogValuer）
+// 图片数据替换为描述，持久化仍使用 MarshalJSON 的完整形式
+func (m Message) LogValue() any {
+	data, err := m.marshalJSON(true)
+	if err != nil {
+		return m.Content
+	}
+	return json.RawMessage(data)
+}
+
+// marshalJSON 序列化消息，redact 为 true 时不输出图片数据
+func (m Message) marshalJSON(redact bool) ([]byte, error) {
 	msg := messageJSON{
-		Role:       m.Role,
-		Content:    m.Content,
-		Name:       m.Name,
-		ToolCalls:  m.ToolCalls,
-		ToolCallID: m.ToolCallID,
-		Metadata:   m.Metadata,
+		ID:          m.ID,
+		Role:        m.Role,
+		Content:     m.Content,
+		Name:        m.Name,
+		ToolCalls:   m.ToolCalls,
+		ToolCallID:  m.ToolCallID,
+		Metadata:    m.Metadata,
+		Annotations: m.Annotations,
 	}
 
 	// 序列化 ContentBlocks
@@ -437,6 +464,15 @@ func (m Message) MarshalJSON() ([]byte, error) {
 					Content:   b.Content,
 					IsError:   b.IsError,
 				})
+			case *ImageContent:
+				image := b
+				if redact && b != nil {
+					image = b.redacted()
+				}
+				msg.ContentBlocks = append(msg.ContentBlocks, contentBlockJSON{
+					Type:  "image",
+					Image: image,
+				})
 			}
 		}
 	}
@@ -451,12 +487,14 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	m.ID = msg.ID
 	m.Role = msg.Role
 	m.Content = msg.Content
 	m.Name = msg.Name
 	m.ToolCalls = msg.ToolCalls
 	m.ToolCallID = msg.ToolCallID
 	m.Metadata = msg.Metadata
+	m.Annotations = msg.Annotations
 
 	// 反序列化 ContentBlocks
 	if len(msg.ContentBlocks) > 0 {
@@ -477,6 +515,10 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 					Content:   b.Content,
 					IsError:   b.IsError,
 				})
+			case "image":
+				if b.Image != nil {
+					m.ContentBlocks = append(m.ContentBlocks, b.Image)
+				}
 			}
 		}
 	}
@@ -1,8 +1,14 @@
 package types
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/astercloud/aster/pkg/logging"
 )
 
 func TestMessageMetadata_Defaults(t *testing.T) {
@@ -270,3 +276,80 @@ func TestMessage_NoMetadata_DefaultVisible(t *testing.T) {
 		t.Error("Message without metadata should be visible to user")
 	}
 }
+
+func TestMessageJSON_ImageRoundTrip(t *testing.T) {
+	image := NewImageFromBytes([]byte("\x89PNG\r\n\x1a\nfake"), "")
+	msg := Message{
+		Role:          RoleUser,
+		ContentBlocks: []ContentBlock{&TextBlock{Text: "look"}, image},
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decoded.ContentBlocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(decoded.ContentBlocks))
+	}
+	got, ok := decoded.ContentBlocks[1].(*ImageContent)
+	if !ok || got.Source != image.Source || got.MimeType != "image/png" {
+		t.Errorf("image not preserved: %+v", decoded.ContentBlocks[1])
+	}
+}
+
+func TestImageContent_StringOmitsData(t *testing.T) {
+	image := NewImageFromBytes(make([]byte, 300), "image/png")
+	if s := fmt.Sprintf("%v", image); strings.Contains(s, image.Source) || !strings.Contains(s, "300 bytes") {
+		t.Errorf("image data should be redacted in logs, got %q", s)
+	}
+}
+
+// bufferTransport 将日志记录编码到内存，与 stdout/file transport 的 JSON 行一致
+type bufferTransport struct {
+	buf bytes.Buffer
+}
+
+func (t *bufferTransport) Name() string { return "buffer" }
+
+func (t *bufferTransport) Log(ctx context.Context, rec *logging.LogRecord) error {
+	return json.NewEncoder(&t.buf).Encode(rec)
+}
+
+func (t *bufferTransport) Flush(ctx context.Context) error { return nil }
+
+func TestMessage_LogOmitsImageData(t *testing.T) {
+	image := NewImageFromBytes(bytes.Repeat([]byte("pixel"), 100), "image/png")
+	msg := Message{
+		Role:          RoleUser,
+		ContentBlocks: []ContentBlock{&TextBlock{Text: "look"}, image},
+	}
+
+	transport := &bufferTransport{}
+	logger := logging.NewLogger(logging.LevelDebug, transport).ForComponent("test")
+	logger.Debug(context.Background(), "request", map[string]any{
+		"message":  msg,
+		"messages": []Message{msg},
+		"blocks":   msg.ContentBlocks,
+	})
+
+	out := transport.buf.String()
+	if strings.Contains(out, image.Source) {
+		t.Fatalf("log output contains image data: %s", out)
+	}
+	if !strings.Contains(out, "500 bytes") || !strings.Contains(out, "look") {
+		t.Errorf("log output should describe the message, got %s", out)
+	}
+
+	// 持久化形式保持完整
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), image.Source) {
+		t.Error("persisted message should keep image data")
+	}
+}
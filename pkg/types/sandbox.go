@@ -165,3 +165,19 @@ const (
 	// SandboxPermissionPlan 规划模式 - 不执行
 	SandboxPermissionPlan SandboxPermissionMode = "plan"
 )
+
+// SandboxSecret 沙箱密钥
+// 以环境变量形式注入到指定工具的命令执行中，值不会出现在命令输出、审计日志和事件中。
+type SandboxSecret struct {
+	// Name 注入的环境变量名
+	Name string `json:"name"`
+
+	// Value 密钥值（不参与序列化，避免随配置持久化）
+	Value string `json:"-"`
+
+	// FromEnv 从主机环境变量读取密钥值（Value 为空时使用）
+	FromEnv string `json:"from_env,omitempty"`
+
+	// Tools 允许注入该密钥的工具名，为空表示所有工具
+	Tools []string `json:"tools,omitempty"`
+}
@@ -6,7 +6,8 @@ import (
 
 // ToolCallRecord 工具调用记录
 type ToolCallRecord struct {
-	ID           string               `json:"id"`                     // 工具调用 ID
+	ID           string               `json:"id"`                     // 工具调用 ID（run-{runID}-tc-{n}）
+	ToolUseID    string               `json:"tool_use_id,omitempty"`  // 模型返回的原始 tool_use ID
 	Name         string               `json:"name"`                   // 工具名称（新字段）
 	ToolName     string               `json:"tool_name"`              // 工具名称（兼容）
 	Input        map[string]any       `json:"input"`                  // 输入参数
@@ -136,7 +136,7 @@ func (s *MemoryStore) Query(_ context.Context, q Query) ([]Hit, error) {
 			}
 		}
 
-		score := cosineSimilarity(q.Vector, doc.Embedding)
+		score := CosineSimilarity(q.Vector, doc.Embedding)
 		if math.IsNaN(score) {
 			continue
 		}
@@ -176,21 +176,3 @@ func (s *MemoryStore) Query(_ context.Context, q Query) ([]Hit, error) {
 func (s *MemoryStore) Close() error {
 	return nil
 }
-
-func cosineSimilarity(a, b []float32) float64 {
-	if len(a) == 0 || len(b) == 0 || len(a) != len(b) {
-		return 0
-	}
-	var dot, na, nb float64
-	for i := range a {
-		av := float64(a[i])
-		bv := float64(b[i])
-		dot += av * bv
-		na += av * av
-		nb += bv * bv
-	}
-	if na == 0 || nb == 0 {
-		return 0
-	}
-	return dot / (math.Sqrt(na) * math.Sqrt(nb))
-}
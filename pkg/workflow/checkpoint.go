@@ -0,0 +1,217 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/stream"
+)
+
+// ErrCheckpointNotFound 检查点不存在
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint Workflow 执行检查点，每个步骤成功后保存，用于崩溃后恢复
+//
+// 步骤输出内容需要能被 Checkpointer 序列化（JSON 检查点恢复后结构体会变为 map）；
+// 输出不可序列化的步骤应设置 StepConfig.NonResumable，该步骤之后不保存检查点，
+// 其输出也不会出现在 StepContents 中，恢复时从上一个检查点重新执行。
+type Checkpoint struct {
+	// ID 检查点标识，等于运行的 RunID
+	ID string `json:"id"`
+
+	// WorkflowName Workflow 名称，恢复时校验
+	WorkflowName string `json:"workflow_name"`
+
+	SessionID      string         `json:"session_id,omitempty"`
+	UserID         string         `json:"user_id,omitempty"`
+	Input          any            `json:"input,omitempty"`
+	AdditionalData map[string]any `json:"additional_data,omitempty"`
+
+	// NextStep 下一个要执行的步骤索引
+	NextStep int `json:"next_step"`
+
+	// LastStep 最后完成的步骤名称，恢复时校验步骤顺序未改变
+	LastStep string `json:"last_step,omitempty"`
+
+	PreviousStepContent any            `json:"previous_step_content,omitempty"`
+	StepContents        map[string]any `json:"step_contents,omitempty"`
+	SessionState        map[string]any `json:"session_state,omitempty"`
+	SharedContext       map[string]any `json:"shared_context,omitempty"`
+	Metrics             *RunMetrics    `json:"metrics,omitempty"`
+
+	// Completed 运行已完成，不能再恢复
+	Completed bool `json:"completed"`
+
+	StartTime time.Time `json:"start_time"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Checkpointer 检查点存储
+type Checkpointer interface {
+	// SaveState 保存（覆盖）检查点
+	SaveState(ctx context.Context, checkpoint *Checkpoint) error
+
+	// LoadState 加载检查点，不存在时返回 ErrCheckpointNotFound
+	LoadState(ctx context.Context, id string) (*Checkpoint, error)
+}
+
+// WithCheckpointer 启用检查点，每个步骤成功后保存执行状态
+func (w *Workflow) WithCheckpointer(c Checkpointer) *Workflow {
+	w.Checkpointer = c
+	return w
+}
+
+// Resume 从检查点恢复执行，RunID 保持不变
+func (w *Workflow) Resume(ctx context.Context, checkpointID string) *stream.Reader[*RunEvent] {
+	checkpoint, err := w.loadCheckpoint(ctx, checkpointID)
+	if err != nil {
+		reader, writer := stream.Pipe[*RunEvent](1)
+		writer.Send(nil, err)
+		writer.Close()
+		return reader
+	}
+
+	return w.execute(ctx, &WorkflowInput{
+		Input:          checkpoint.Input,
+		AdditionalData: checkpoint.AdditionalData,
+		SessionID:      checkpoint.SessionID,
+		UserID:         checkpoint.UserID,
+	}, checkpoint)
+}
+
+// loadCheckpoint 加载并校验检查点
+func (w *Workflow) loadCheckpoint(ctx context.Context, id string) (*Checkpoint, error) {
+	if w.Checkpointer == nil {
+		return nil, errors.New("no checkpointer configured")
+	}
+	checkpoint, err := w.Checkpointer.LoadState(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint %s: %w", id, err)
+	}
+
+	switch {
+	case checkpoint.Completed:
+		return nil, fmt.Errorf("checkpoint %s: run already completed", id)
+	case checkpoint.WorkflowName != w.Name:
+		return nil, fmt.Errorf("checkpoint %s belongs to workflow %s, not %s", id, checkpoint.WorkflowName, w.Name)
+	case checkpoint.NextStep < 0 || checkpoint.NextStep > len(w.Steps):
+		return nil, fmt.Errorf("checkpoint %s: step index %d out of range", id, checkpoint.NextStep)
+	case checkpoint.NextStep > 0 && w.Steps[checkpoint.NextStep-1].Name() != checkpoint.LastStep:
+		return nil, fmt.Errorf("checkpoint %s: step %d is %s, expected %s", id, checkpoint.NextStep-1, w.Steps[checkpoint.NextStep-1].Name(), checkpoint.LastStep)
+	}
+	return checkpoint, nil
+}
+
+// newCheckpoint 根据当前运行状态创建检查点
+func newCheckpoint(run *WorkflowRun, w *Workflow, input *WorkflowInput, userID string, sessionState map[string]any, shared *SharedContext) *Checkpoint {
+	contents := make(map[string]any, len(run.StepOutputs))
+	for _, step := range w.Steps {
+		if output, ok := run.StepOutputs[step.Name()]; ok && !step.Config().NonResumable {
+			contents[step.Name()] = contentOf(output)
+		}
+	}
+
+	metrics := *run.Metrics
+	metrics.StepMetrics = maps.Clone(run.Metrics.StepMetrics)
+	metrics.Steps = slices.Clone(run.Metrics.Steps)
+
+	return &Checkpoint{
+		ID:             run.RunID,
+		WorkflowName:   w.Name,
+		SessionID:      run.SessionID,
+		UserID:         userID,
+		Input:          input.Input,
+		AdditionalData: input.AdditionalData,
+		StepContents:   contents,
+		SessionState:   maps.Clone(sessionState),
+		SharedContext:  shared.Snapshot(),
+		Metrics:        &metrics,
+		StartTime:      run.StartTime,
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// ===== FileCheckpointer =====
+
+// FileCheckpointer 以 JSON 文件保存检查点，每个检查点一个文件
+type FileCheckpointer struct {
+	dir string
+}
+
+// NewFileCheckpointer 创建 JSON 文件检查点存储
+func NewFileCheckpointer(dir string) (*FileCheckpointer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	return &FileCheckpointer{dir: dir}, nil
+}
+
+// SaveState 写入临时文件后原子替换
+func (c *FileCheckpointer) SaveState(ctx context.Context, checkpoint *Checkpoint) error {
+	path, err := c.path(checkpoint.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadState 读取检查点文件
+func (c *FileCheckpointer) LoadState(ctx context.Context, id string) (*Checkpoint, error) {
+	path, err := c.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCheckpointNotFound
+		}
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// path 检查点文件路径，拒绝包含路径分隔符的 ID
+func (c *FileCheckpointer) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid checkpoint id: %q", id)
+	}
+	return filepath.Join(c.dir, id+".json"), nil
+}
+
+var _ Checkpointer = (*FileCheckpointer)(nil)
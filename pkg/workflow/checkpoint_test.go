@@ -0,0 +1,202 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// newCheckpointTestWorkflow 构造 a -> b -> c 三步 Workflow，counts 记录每个步骤的执行次数
+func newCheckpointTestWorkflow(cp Checkpointer, counts map[string]int, failC bool) *Workflow {
+	step := func(name string) *FunctionStep {
+		return NewFunctionStep(name, func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			counts[name]++
+			if name == "c" && failC {
+				return nil, errors.New("crash")
+			}
+			return &StepOutput{Content: fmt.Sprintf("%v>%s", input.PreviousStepContent, name)}, nil
+		})
+	}
+
+	wf := New("checkpoint-test").WithStream().WithCheckpointer(cp)
+	wf.AddStep(step("a"))
+	wf.AddStep(step("b"))
+	wf.AddStep(step("c"))
+	return wf
+}
+
+func TestCheckpoint_ResumeAfterFailure(t *testing.T) {
+	cp, err := NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+
+	events, _ := collectRunEvents(t, newCheckpointTestWorkflow(cp, counts, true).Execute(context.Background(), &WorkflowInput{Input: "in"}))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowFailed {
+		t.Fatalf("expected first run to fail, got %s", last.Type)
+	}
+	runID := last.RunID
+
+	saved, err := cp.LoadState(context.Background(), runID)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if saved.NextStep != 2 || saved.LastStep != "b" || saved.PreviousStepContent != "<nil>>a>b" {
+		t.Errorf("unexpected checkpoint: next=%d last=%s content=%v", saved.NextStep, saved.LastStep, saved.PreviousStepContent)
+	}
+
+	// 进程重启后重新构建 Workflow 并恢复
+	events, errs := collectRunEvents(t, newCheckpointTestWorkflow(cp, counts, false).Resume(context.Background(), runID))
+	last = events[len(events)-1]
+	if last.Type != EventWorkflowCompleted || errs[len(errs)-1] != nil {
+		t.Fatalf("expected resumed run to complete, got %s (%v)", last.Type, errs[len(errs)-1])
+	}
+	if last.RunID != runID {
+		t.Errorf("resumed run should keep RunID %s, got %s", runID, last.RunID)
+	}
+	if got := last.Data.(map[string]any)["output"]; got != "<nil>>a>b>c" {
+		t.Errorf("unexpected output: %v", got)
+	}
+	if counts["a"] != 1 || counts["b"] != 1 || counts["c"] != 2 {
+		t.Errorf("completed steps should not rerun, counts = %v", counts)
+	}
+	if started := events[0].Data.(map[string]any); started["resumed"] != true || started["resume_step"] != 2 {
+		t.Errorf("started event should describe the resume, got %+v", started)
+	}
+
+	// 已完成的运行不能再恢复
+	_, errs = collectRunEvents(t, newCheckpointTestWorkflow(cp, counts, false).Resume(context.Background(), runID))
+	if errs[0] == nil {
+		t.Error("expected error when resuming a completed run")
+	}
+}
+
+func TestCheckpoint_RestoresStepOutputs(t *testing.T) {
+	cp, err := NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wf := New("restore-test").WithCheckpointer(cp)
+	wf.AddStep(TransformFunction("first", func(input any) any { return "first-out" }))
+	wf.AddStep(NewFunctionStep("second", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		return nil, errors.New("crash")
+	}))
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	runID := events[len(events)-1].RunID
+
+	var seen any
+	resumed := New("restore-test").WithCheckpointer(cp)
+	resumed.AddStep(TransformFunction("first", func(input any) any { return "first-out" }))
+	resumed.AddStep(NewFunctionStep("second", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		seen = input.PreviousStepOutputs["first"].Content
+		return &StepOutput{Content: "done"}, nil
+	}))
+	events, _ = collectRunEvents(t, resumed.Resume(context.Background(), runID))
+	if last := events[len(events)-1]; last.Type != EventWorkflowCompleted {
+		t.Fatalf("expected completion, got %s", last.Type)
+	}
+	if seen != "first-out" {
+		t.Errorf("PreviousStepOutputs should be restored, got %v", seen)
+	}
+}
+
+func TestCheckpoint_NonResumableStep(t *testing.T) {
+	cp, err := NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	build := func(fail bool) *Workflow {
+		wf := New("non-resumable").WithCheckpointer(cp)
+		wf.AddStep(TransformFunction("a", func(input any) any { counts["a"]++; return "a" }))
+		wf.AddStep(TransformFunction("conn", func(input any) any { counts["conn"]++; return "conn" }).WithNonResumable())
+		wf.AddStep(NewFunctionStep("c", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			if fail {
+				return nil, errors.New("crash")
+			}
+			return &StepOutput{Content: input.PreviousStepContent}, nil
+		}))
+		return wf
+	}
+
+	events, _ := collectRunEvents(t, build(true).Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	runID := events[len(events)-1].RunID
+
+	saved, err := cp.LoadState(context.Background(), runID)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if saved.NextStep != 1 {
+		t.Errorf("no checkpoint should be saved after a non-resumable step, next=%d", saved.NextStep)
+	}
+
+	events, _ = collectRunEvents(t, build(false).Resume(context.Background(), runID))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted || last.Data.(map[string]any)["output"] != "conn" {
+		t.Fatalf("unexpected result %s %+v", last.Type, last.Data)
+	}
+	if counts["a"] != 1 || counts["conn"] != 2 {
+		t.Errorf("non-resumable step should rerun on resume, counts = %v", counts)
+	}
+}
+
+func TestResume_Errors(t *testing.T) {
+	cp, err := NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	_, errs := collectRunEvents(t, New("no-checkpointer").Resume(ctx, "run"))
+	if errs[0] == nil {
+		t.Error("expected error without checkpointer")
+	}
+
+	_, errs = collectRunEvents(t, New("missing").WithCheckpointer(cp).Resume(ctx, "run"))
+	if !errors.Is(errs[0], ErrCheckpointNotFound) {
+		t.Errorf("expected ErrCheckpointNotFound, got %v", errs[0])
+	}
+
+	if err := cp.SaveState(ctx, &Checkpoint{ID: "run", WorkflowName: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	_, errs = collectRunEvents(t, New("mine").WithCheckpointer(cp).Resume(ctx, "run"))
+	if errs[0] == nil {
+		t.Error("expected error for checkpoint of another workflow")
+	}
+}
+
+func TestFileCheckpointer_RoundTrip(t *testing.T) {
+	cp, err := NewFileCheckpointer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	in := &Checkpoint{
+		ID:           "run-1",
+		WorkflowName: "wf",
+		NextStep:     2,
+		LastStep:     "b",
+		StepContents: map[string]any{"a": "x", "b": map[string]any{"n": 1.0}},
+		Metrics:      &RunMetrics{TotalSteps: 3, SuccessfulSteps: 2},
+	}
+	if err := cp.SaveState(ctx, in); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+	out, err := cp.LoadState(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if out.NextStep != 2 || out.LastStep != "b" || out.StepContents["a"] != "x" || out.Metrics.SuccessfulSteps != 2 {
+		t.Errorf("unexpected checkpoint after round trip: %+v", out)
+	}
+
+	if err := cp.SaveState(ctx, &Checkpoint{ID: "../escape"}); err == nil {
+		t.Error("expected error for checkpoint id with path separator")
+	}
+}
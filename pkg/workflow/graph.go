@@ -0,0 +1,379 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/astercloud/aster/pkg/stream"
+	"github.com/google/uuid"
+)
+
+// ===== Graph =====
+
+// graphNode Graph 中的节点
+type graphNode struct {
+	step Step
+	deps []string
+}
+
+// Graph 按依赖关系调度的步骤图（DAG）
+//
+// 节点通过名称声明依赖，执行时按拓扑顺序运行，互不依赖的分支并发执行。
+// 每个节点的 PreviousStepOutputs 包含已完成节点的输出（以节点名为键）；
+// PreviousStepContent 在只有一个依赖时为该依赖的输出内容，多个依赖时为
+// map[节点名]内容，没有依赖时为 Graph 自身的 PreviousStepContent。
+// Graph 的输出为所有终点节点（没有被其他节点依赖）的内容，只有一个终点时直接返回其内容。
+type Graph struct {
+	id          string
+	name        string
+	description string
+	nodes       map[string]*graphNode
+	order       []string
+	duplicates  []string
+	config      *StepConfig
+}
+
+func NewGraph(name string) *Graph {
+	return &Graph{
+		id:    uuid.New().String(),
+		name:  name,
+		nodes: make(map[string]*graphNode),
+		config: &StepConfig{
+			Name:        name,
+			Type:        StepTypeGraph,
+			MaxRetries:  1,
+			SkipOnError: false,
+		},
+	}
+}
+
+func (g *Graph) ID() string          { return g.id }
+func (g *Graph) Name() string        { return g.name }
+func (g *Graph) Type() StepType      { return StepTypeGraph }
+func (g *Graph) Description() string { return g.description }
+func (g *Graph) Config() *StepConfig { return g.config }
+
+// AddNode 添加节点，dependsOn 为依赖的节点名称
+func (g *Graph) AddNode(step Step, dependsOn ...string) *Graph {
+	name := step.Name()
+	if _, exists := g.nodes[name]; exists {
+		g.duplicates = append(g.duplicates, name)
+		return g
+	}
+	g.nodes[name] = &graphNode{step: step, deps: dependsOn}
+	g.order = append(g.order, name)
+	return g
+}
+
+func (g *Graph) WithDescription(desc string) *Graph {
+	g.description = desc
+	return g
+}
+
+// Validate 检查重复节点、未知依赖和循环依赖
+func (g *Graph) Validate() error {
+	if len(g.nodes) == 0 {
+		return fmt.Errorf("graph %s has no nodes", g.name)
+	}
+	if len(g.duplicates) > 0 {
+		return fmt.Errorf("graph %s: duplicate node name: %s", g.name, g.duplicates[0])
+	}
+	for _, name := range g.order {
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("graph %s: node %s depends on unknown node %s", g.name, name, dep)
+			}
+		}
+	}
+	if cycle := g.findCycle(); cycle != nil {
+		return fmt.Errorf("graph %s: dependency cycle detected: %s", g.name, strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// TopologicalOrder 返回节点的拓扑顺序，同一层级内保持添加顺序
+func (g *Graph) TopologicalOrder() ([]string, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	remaining := g.pendingDeps()
+	var order, ready []string
+	for _, name := range g.order {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	dependents := g.dependents()
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, next := range dependents[name] {
+			remaining[next]--
+			if remaining[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	return order, nil
+}
+
+// findCycle 深度优先查找循环依赖，返回形如 a -> b -> a 的路径
+func (g *Graph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g.nodes))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			switch state[dep] {
+			case visiting:
+				start := slices.Index(path, dep)
+				return append(slices.Clone(path[start:]), dep)
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range g.order {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				// 按执行方向（依赖在前）输出
+				slices.Reverse(cycle)
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// pendingDeps 每个节点尚未完成的依赖数
+func (g *Graph) pendingDeps() map[string]int {
+	remaining := make(map[string]int, len(g.nodes))
+	for name, node := range g.nodes {
+		remaining[name] = len(node.deps)
+	}
+	return remaining
+}
+
+// dependents 每个节点的下游节点（按添加顺序）
+func (g *Graph) dependents() map[string][]string {
+	dependents := make(map[string][]string, len(g.nodes))
+	for _, name := range g.order {
+		for _, dep := range g.nodes[name].deps {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	return dependents
+}
+
+// graphResult 节点执行结果
+type graphResult struct {
+	name   string
+	output *StepOutput
+	err    error
+}
+
+func (g *Graph) Execute(ctx context.Context, input *StepInput) *stream.Reader[*StepOutput] {
+	reader, writer := stream.Pipe[*StepOutput](1)
+
+	go func() {
+		defer writer.Close()
+		startTime := time.Now()
+
+		errorOutput := func(err error, nested []*StepOutput) *StepOutput {
+			output := &StepOutput{
+				StepID:      g.id,
+				StepName:    g.name,
+				StepType:    StepTypeGraph,
+				Error:       err,
+				StartTime:   startTime,
+				EndTime:     time.Now(),
+				NestedSteps: nested,
+				Metadata:    map[string]any{"completed": len(nested), "total": len(g.nodes)},
+			}
+			output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
+			return output
+		}
+
+		if err := g.Validate(); err != nil {
+			writer.Send(errorOutput(err, nil), err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		outputs := make(map[string]*StepOutput, len(input.PreviousStepOutputs)+len(g.nodes))
+		maps.Copy(outputs, input.PreviousStepOutputs)
+
+		results := make(chan graphResult)
+		running := 0
+		launch := func(name string) {
+			node := g.nodes[name]
+			nodeInput := g.nodeInput(input, node, outputs)
+
+			running++
+			go func() {
+				output, err := drainStep(ctx, node.step, nodeInput)
+				results <- graphResult{name: name, output: output, err: err}
+			}()
+		}
+
+		remaining := g.pendingDeps()
+		dependents := g.dependents()
+		for _, name := range g.order {
+			if remaining[name] == 0 {
+				launch(name)
+			}
+		}
+
+		var completed []*StepOutput
+		var firstErr error
+		for running > 0 {
+			result := <-results
+			running--
+
+			if result.err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("graph node %s: %w", result.name, result.err)
+					cancel()
+				}
+				continue
+			}
+			if firstErr != nil {
+				continue
+			}
+
+			outputs[result.name] = result.output
+			if result.output != nil {
+				completed = append(completed, result.output)
+				// 节点输出作为进度转发
+				writer.Send(result.output, nil)
+			}
+
+			for _, next := range dependents[result.name] {
+				remaining[next]--
+				if remaining[next] == 0 {
+					launch(next)
+				}
+			}
+		}
+
+		if firstErr != nil {
+			writer.Send(errorOutput(firstErr, completed), firstErr)
+			return
+		}
+
+		output := &StepOutput{
+			StepID:      g.id,
+			StepName:    g.name,
+			StepType:    StepTypeGraph,
+			Content:     g.sinkContent(outputs, dependents),
+			StartTime:   startTime,
+			EndTime:     time.Now(),
+			NestedSteps: completed,
+			Metadata:    map[string]any{"completed": len(completed), "total": len(g.nodes)},
+			Metrics:     &StepMetrics{ExecutionTime: time.Since(startTime).Seconds()},
+		}
+		output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
+		writer.Send(output, nil)
+	}()
+
+	return reader
+}
+
+// nodeInput 构造节点输入，PreviousStepOutputs 为 outputs 的快照
+func (g *Graph) nodeInput(input *StepInput, node *graphNode, outputs map[string]*StepOutput) *StepInput {
+	nodeInput := &StepInput{
+		Input:               input.Input,
+		PreviousStepContent: input.PreviousStepContent,
+		PreviousStepOutputs: maps.Clone(outputs),
+		AdditionalData:      input.AdditionalData,
+		SessionState:        input.SessionState,
+		Images:              input.Images,
+		Videos:              input.Videos,
+		Audio:               input.Audio,
+		Files:               input.Files,
+		WorkflowSession:     input.WorkflowSession,
+		SharedContext:       input.SharedContext,
+	}
+
+	switch len(node.deps) {
+	case 0:
+	case 1:
+		nodeInput.PreviousStepContent = contentOf(outputs[node.deps[0]])
+	default:
+		contents := make(map[string]any, len(node.deps))
+		for _, dep := range node.deps {
+			contents[dep] = contentOf(outputs[dep])
+		}
+		nodeInput.PreviousStepContent = contents
+	}
+	return nodeInput
+}
+
+// sinkContent 汇总终点节点的输出内容
+func (g *Graph) sinkContent(outputs map[string]*StepOutput, dependents map[string][]string) any {
+	var sinks []string
+	for _, name := range g.order {
+		if len(dependents[name]) == 0 {
+			sinks = append(sinks, name)
+		}
+	}
+	if len(sinks) == 1 {
+		return contentOf(outputs[sinks[0]])
+	}
+	contents := make(map[string]any, len(sinks))
+	for _, name := range sinks {
+		contents[name] = contentOf(outputs[name])
+	}
+	return contents
+}
+
+// contentOf 返回输出内容，输出为空时返回 nil
+func contentOf(output *StepOutput) any {
+	if output == nil {
+		return nil
+	}
+	return output.Content
+}
+
+// drainStep 执行步骤并返回最后一个输出，出错时优先返回随错误发送的输出
+func drainStep(ctx context.Context, step Step, input *StepInput) (*StepOutput, error) {
+	var last *StepOutput
+	reader := step.Execute(ctx, input)
+	for {
+		output, err := reader.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return last, nil
+			}
+			if output != nil {
+				last = output
+			}
+			return last, err
+		}
+		last = output
+	}
+}
@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGraph_DiamondRunsBranchesConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	bothStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(bothStarted)
+	}()
+
+	branch := func(name string) Step {
+		return NewFunctionStep(name, func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			started.Done()
+			select {
+			case <-bothStarted:
+			case <-time.After(2 * time.Second):
+				return nil, errors.New("branches did not run concurrently")
+			}
+			return &StepOutput{Content: input.PreviousStepContent.(string) + "-" + name}, nil
+		})
+	}
+
+	g := NewGraph("diamond").
+		AddNode(TransformFunction("fetch", func(input any) any { return "data" })).
+		AddNode(branch("left"), "fetch").
+		AddNode(branch("right"), "fetch").
+		AddNode(NewFunctionStep("join", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			contents := input.PreviousStepContent.(map[string]any)
+			fetched := input.PreviousStepOutputs["fetch"].Content
+			return &StepOutput{Content: contents["left"].(string) + "+" + contents["right"].(string) + "@" + fetched.(string)}, nil
+		}), "left", "right")
+
+	wf := New("graph")
+	wf.AddStep(g)
+	if err := wf.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	events, errs := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted {
+		t.Fatalf("expected workflow_completed, got %s (%v)", last.Type, errs[len(errs)-1])
+	}
+	if output := last.Data.(map[string]any)["output"]; output != "data-left+data-right@data" {
+		t.Errorf("output = %v", output)
+	}
+}
+
+func TestGraph_ValidateDetectsCycles(t *testing.T) {
+	noop := func(name string) Step {
+		return TransformFunction(name, func(input any) any { return input })
+	}
+	g := NewGraph("cyclic").
+		AddNode(noop("a"), "c").
+		AddNode(noop("b"), "a").
+		AddNode(noop("c"), "b").
+		AddNode(noop("d"))
+
+	err := g.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cycle") || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("expected descriptive cycle error, got %v", err)
+	}
+
+	wf := New("cyclic-wf")
+	wf.AddStep(g)
+	if err := wf.Validate(); err == nil {
+		t.Error("workflow Validate should report the graph cycle")
+	}
+
+	unknown := NewGraph("unknown").AddNode(noop("a"), "missing")
+	if err := unknown.Validate(); err == nil || !strings.Contains(err.Error(), "unknown node missing") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+}
+
+func TestGraph_TopologicalOrder(t *testing.T) {
+	noop := func(name string) Step {
+		return TransformFunction(name, func(input any) any { return input })
+	}
+	g := NewGraph("order").
+		AddNode(noop("report"), "left", "right").
+		AddNode(noop("left"), "load").
+		AddNode(noop("right"), "load").
+		AddNode(noop("load"))
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("TopologicalOrder failed: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "load,left,right,report" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestGraph_NodeErrorFailsGraph(t *testing.T) {
+	boom := errors.New("boom")
+	var ranAfter bool
+	g := NewGraph("failing").
+		AddNode(NewFunctionStep("bad", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			return nil, boom
+		})).
+		AddNode(NewFunctionStep("after", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+			ranAfter = true
+			return &StepOutput{}, nil
+		}), "bad")
+
+	output, err := drainStep(context.Background(), g, &StepInput{Input: "x"})
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "graph node bad") {
+		t.Fatalf("expected wrapped node error, got %v", err)
+	}
+	if output == nil || output.StepType != StepTypeGraph {
+		t.Errorf("expected graph error output, got %+v", output)
+	}
+	if ranAfter {
+		t.Error("dependents of a failed node should not run")
+	}
+}
@@ -0,0 +1,222 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/stream"
+	"github.com/google/uuid"
+)
+
+// MapErrorPolicy MapStep 元素出错时的处理方式
+type MapErrorPolicy string
+
+const (
+	// MapFailFast 任一元素出错时取消其余元素，步骤失败（默认）
+	MapFailFast MapErrorPolicy = "fail_fast"
+	// MapCollectErrors 执行全部元素，失败元素的结果为 nil，错误记录在 Metadata["errors"]（索引 -> 错误信息）
+	MapCollectErrors MapErrorPolicy = "collect"
+)
+
+// ===== MapStep =====
+
+// MapStep 对集合中的每个元素并发执行同一个步骤
+//
+// PreviousStepContent 必须为切片，每个元素作为 body 的 PreviousStepContent，
+// AdditionalData["map_index"] 为元素索引。输出 Content 为按原顺序排列的 []any。
+type MapStep struct {
+	id          string
+	name        string
+	description string
+	body        Step
+	concurrency int
+	errorPolicy MapErrorPolicy
+	config      *StepConfig
+}
+
+// NewMapStep 创建 MapStep，concurrency <= 0 表示不限制并发数
+func NewMapStep(name string, body Step, concurrency int) *MapStep {
+	return &MapStep{
+		id:          uuid.New().String(),
+		name:        name,
+		body:        body,
+		concurrency: concurrency,
+		errorPolicy: MapFailFast,
+		config: &StepConfig{
+			Name:        name,
+			Type:        StepTypeMap,
+			MaxRetries:  1,
+			SkipOnError: false,
+		},
+	}
+}
+
+func (s *MapStep) ID() string          { return s.id }
+func (s *MapStep) Name() string        { return s.name }
+func (s *MapStep) Type() StepType      { return StepTypeMap }
+func (s *MapStep) Description() string { return s.description }
+func (s *MapStep) Config() *StepConfig { return s.config }
+
+func (s *MapStep) WithDescription(desc string) *MapStep {
+	s.description = desc
+	return s
+}
+
+// WithErrorPolicy 设置元素出错时的处理方式
+func (s *MapStep) WithErrorPolicy(policy MapErrorPolicy) *MapStep {
+	s.errorPolicy = policy
+	return s
+}
+
+func (s *MapStep) Execute(ctx context.Context, input *StepInput) *stream.Reader[*StepOutput] {
+	reader, writer := stream.Pipe[*StepOutput](1)
+
+	go func() {
+		defer writer.Close()
+		startTime := time.Now()
+
+		newOutput := func() *StepOutput {
+			return &StepOutput{
+				StepID:    s.id,
+				StepName:  s.name,
+				StepType:  StepTypeMap,
+				StartTime: startTime,
+			}
+		}
+
+		items, err := mapItems(input.PreviousStepContent)
+		if err != nil {
+			output := newOutput()
+			output.Error = fmt.Errorf("map step %s: %w", s.name, err)
+			output.EndTime = time.Now()
+			writer.Send(output, output.Error)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		concurrency := s.concurrency
+		if concurrency <= 0 || concurrency > len(items) {
+			concurrency = len(items)
+		}
+
+		results := make([]*StepOutput, len(items))
+		errs := make([]error, len(items))
+		var once sync.Once
+		var firstErr error
+
+		sem := make(chan struct{}, max(concurrency, 1))
+		var wg sync.WaitGroup
+	launch:
+		for i, item := range items {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break launch
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				output, err := drainStep(ctx, s.body, s.itemInput(input, i, item))
+				results[i] = output
+				if err != nil {
+					errs[i] = err
+					if s.errorPolicy != MapCollectErrors {
+						once.Do(func() {
+							firstErr = fmt.Errorf("map item %d: %w", i, err)
+							cancel()
+						})
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		var nested []*StepOutput
+		for _, result := range results {
+			if result != nil {
+				nested = append(nested, result)
+			}
+		}
+
+		output := newOutput()
+		output.NestedSteps = nested
+		output.Metadata = map[string]any{"items": len(items), "concurrency": concurrency}
+		output.EndTime = time.Now()
+		output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
+
+		if firstErr == nil && ctx.Err() != nil {
+			// 父 ctx 取消，部分元素可能未执行
+			firstErr = ctx.Err()
+		}
+		if firstErr != nil {
+			output.Error = firstErr
+			writer.Send(output, firstErr)
+			return
+		}
+
+		contents := make([]any, len(items))
+		failed := make(map[int]string)
+		for i, result := range results {
+			if errs[i] != nil {
+				failed[i] = errs[i].Error()
+				continue
+			}
+			contents[i] = contentOf(result)
+		}
+		if len(failed) > 0 {
+			output.Metadata["errors"] = failed
+		}
+		output.Metadata["failed"] = len(failed)
+		output.Content = contents
+		output.Metrics = &StepMetrics{ExecutionTime: output.Duration}
+		writer.Send(output, nil)
+	}()
+
+	return reader
+}
+
+// itemInput 构造单个元素的输入
+func (s *MapStep) itemInput(input *StepInput, index int, item any) *StepInput {
+	additional := make(map[string]any, len(input.AdditionalData)+1)
+	maps.Copy(additional, input.AdditionalData)
+	additional["map_index"] = index
+
+	return &StepInput{
+		Input:               input.Input,
+		PreviousStepContent: item,
+		PreviousStepOutputs: input.PreviousStepOutputs,
+		AdditionalData:      additional,
+		SessionState:        input.SessionState,
+		Images:              input.Images,
+		Videos:              input.Videos,
+		Audio:               input.Audio,
+		Files:               input.Files,
+		WorkflowSession:     input.WorkflowSession,
+		SharedContext:       input.SharedContext,
+	}
+}
+
+// mapItems 将内容转换为 []any，支持任意切片和数组类型
+func mapItems(content any) ([]any, error) {
+	if items, ok := content.([]any); ok {
+		return items, nil
+	}
+	v := reflect.ValueOf(content)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("previous step content must be a slice, got %T", content)
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
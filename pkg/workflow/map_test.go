@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func runMapStep(t *testing.T, step Step, content any) (*StepOutput, error) {
+	t.Helper()
+	return drainStep(context.Background(), step, &StepInput{PreviousStepContent: content})
+}
+
+func TestMapStep_PreservesOrder(t *testing.T) {
+	body := NewFunctionStep("double", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		n := input.PreviousStepContent.(int)
+		// 前面的元素更慢，验证结果仍按输入顺序排列
+		time.Sleep(time.Duration(5-n) * 5 * time.Millisecond)
+		return &StepOutput{Content: n * 2}, nil
+	})
+
+	output, err := runMapStep(t, NewMapStep("map", body, 0), []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []any{2, 4, 6, 8}; !reflect.DeepEqual(output.Content, want) {
+		t.Errorf("got %v, want %v", output.Content, want)
+	}
+	if len(output.NestedSteps) != 4 {
+		t.Errorf("expected 4 nested outputs, got %d", len(output.NestedSteps))
+	}
+}
+
+func TestMapStep_ConcurrencyLimit(t *testing.T) {
+	var running, peak int32
+	body := NewFunctionStep("work", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &StepOutput{Content: input.AdditionalData["map_index"]}, nil
+	})
+
+	output, err := runMapStep(t, NewMapStep("map", body, 2), make([]any, 8))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent items, got %d", peak)
+	}
+	if want := []any{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(output.Content, want) {
+		t.Errorf("got %v, want %v", output.Content, want)
+	}
+}
+
+func failOnOdd() *FunctionStep {
+	return NewFunctionStep("odd", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		n := input.PreviousStepContent.(int)
+		if n%2 == 1 {
+			return nil, fmt.Errorf("odd %d", n)
+		}
+		return &StepOutput{Content: n}, nil
+	})
+}
+
+func TestMapStep_FailFast(t *testing.T) {
+	_, err := runMapStep(t, NewMapStep("map", failOnOdd(), 1), []int{0, 1, 2, 3})
+	if err == nil || err.Error() != "map item 1: odd 1" {
+		t.Errorf("expected first item error, got %v", err)
+	}
+}
+
+func TestMapStep_CollectErrors(t *testing.T) {
+	output, err := runMapStep(t, NewMapStep("map", failOnOdd(), 2).WithErrorPolicy(MapCollectErrors), []int{0, 1, 2, 3})
+	if err != nil {
+		t.Fatalf("collect policy should not fail the step: %v", err)
+	}
+	if want := []any{0, nil, 2, nil}; !reflect.DeepEqual(output.Content, want) {
+		t.Errorf("got %v, want %v", output.Content, want)
+	}
+	want := map[int]string{1: "odd 1", 3: "odd 3"}
+	if !reflect.DeepEqual(output.Metadata["errors"], want) || output.Metadata["failed"] != 2 {
+		t.Errorf("unexpected metadata: %+v", output.Metadata)
+	}
+}
+
+func TestMapStep_RequiresSlice(t *testing.T) {
+	_, err := runMapStep(t, NewMapStep("map", failOnOdd(), 1), "not a slice")
+	if err == nil {
+		t.Error("expected error for non-slice content")
+	}
+}
+
+func TestMapStep_InWorkflow(t *testing.T) {
+	wf := New("map-workflow")
+	wf.AddStep(TransformFunction("split", func(input any) any { return []any{"a", "b"} }))
+	wf.AddStep(NewMapStep("upper", NewFunctionStep("up", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		return &StepOutput{Content: input.PreviousStepContent.(string) + "!"}, nil
+	}), 2))
+
+	events, errs := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted || errors.Join(errs...) != nil {
+		t.Fatalf("expected completion, got %s (%v)", last.Type, errs)
+	}
+	if got := last.Data.(map[string]any)["output"]; !reflect.DeepEqual(got, []any{"a!", "b!"}) {
+		t.Errorf("unexpected output: %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import "encoding/json"
+
+// StepStatus 步骤执行结果
+type StepStatus string
+
+const (
+	StepStatusCompleted StepStatus = "completed"
+	StepStatusFailed    StepStatus = "failed"
+	StepStatusSkipped   StepStatus = "skipped" // 出错后按 SkipOnError / OnTimeout 继续执行
+)
+
+// StepMetric 单个步骤的执行指标，用于导出遥测数据
+type StepMetric struct {
+	Name     string     `json:"name"`
+	Type     StepType   `json:"type"`
+	Parent   string     `json:"parent,omitempty"` // 嵌套步骤（并行、循环、路由等）的父步骤名称
+	Duration float64    `json:"duration"`         // 秒
+	Status   StepStatus `json:"status"`
+	Retries  int        `json:"retries"`
+	Cached   bool       `json:"cached,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// ToJSON 导出运行指标
+func (m *RunMetrics) ToJSON() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// recordStep 记录顶层步骤及其嵌套步骤的指标
+func (m *RunMetrics) recordStep(step Step, output *StepOutput, status StepStatus, duration float64, err error) {
+	metric := StepMetric{
+		Name:     step.Name(),
+		Type:     step.Type(),
+		Duration: duration,
+		Status:   status,
+	}
+	if err != nil {
+		metric.Error = err.Error()
+	}
+	if output != nil {
+		metric.Cached = output.FromCache
+		if output.Metrics != nil && !output.FromCache {
+			metric.Retries = output.Metrics.RetryCount
+		}
+	}
+	m.Steps = append(m.Steps, metric)
+
+	if output != nil && !output.FromCache {
+		m.recordNested(step.Name(), output.NestedSteps)
+	}
+}
+
+// recordNested 递归记录嵌套步骤的指标
+func (m *RunMetrics) recordNested(parent string, outputs []*StepOutput) {
+	for _, output := range outputs {
+		if output == nil {
+			continue
+		}
+		metric := StepMetric{
+			Name:     output.StepName,
+			Type:     output.StepType,
+			Parent:   parent,
+			Duration: output.Duration,
+			Status:   StepStatusCompleted,
+		}
+		if output.Error != nil {
+			metric.Status = StepStatusFailed
+			metric.Error = output.Error.Error()
+		}
+		if output.Metrics != nil {
+			metric.Retries = output.Metrics.RetryCount
+			if metric.Duration == 0 {
+				metric.Duration = output.Metrics.ExecutionTime
+			}
+		}
+		m.Steps = append(m.Steps, metric)
+		m.recordNested(output.StepName, output.NestedSteps)
+	}
+}
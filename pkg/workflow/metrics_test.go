@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestRunMetrics_Steps(t *testing.T) {
+	wf := New("metrics-test").WithStream()
+	wf.AddStep(TransformFunction("prepare", func(input any) any { return input }))
+	wf.AddStep(NewParallelStep("fanout",
+		TransformFunction("left", func(input any) any { return "l" }),
+		TransformFunction("right", func(input any) any { return "r" }),
+	))
+	flaky := NewFunctionStep("flaky", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		return nil, errors.New("boom")
+	})
+	flaky.Config().SkipOnError = true
+	wf.AddStep(flaky)
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted {
+		t.Fatalf("expected completion, got %s", last.Type)
+	}
+	metrics := last.Data.(map[string]any)["metrics"].(*RunMetrics)
+
+	want := []StepMetric{
+		{Name: "prepare", Type: StepTypeFunction, Status: StepStatusCompleted},
+		{Name: "fanout", Type: StepTypeParallel, Status: StepStatusCompleted},
+		{Name: "left", Type: StepTypeFunction, Parent: "fanout", Status: StepStatusCompleted},
+		{Name: "right", Type: StepTypeFunction, Parent: "fanout", Status: StepStatusCompleted},
+		{Name: "flaky", Type: StepTypeFunction, Status: StepStatusSkipped, Error: "boom"},
+	}
+	if len(metrics.Steps) != len(want) {
+		t.Fatalf("expected %d step metrics, got %+v", len(want), metrics.Steps)
+	}
+	for i, w := range want {
+		got := metrics.Steps[i]
+		got.Duration = 0
+		if got != w {
+			t.Errorf("step %d: got %+v, want %+v", i, got, w)
+		}
+	}
+	if metrics.Steps[1].Duration <= 0 {
+		t.Errorf("expected positive duration for parallel step, got %v", metrics.Steps[1].Duration)
+	}
+}
+
+func TestRunMetrics_ToJSON(t *testing.T) {
+	metrics := &RunMetrics{
+		TotalSteps:      2,
+		SuccessfulSteps: 1,
+		Steps: []StepMetric{
+			{Name: "a", Type: StepTypeFunction, Duration: 0.5, Status: StepStatusCompleted, Retries: 2},
+			{Name: "b", Type: StepTypeFunction, Parent: "a", Status: StepStatusFailed, Error: "boom"},
+		},
+	}
+
+	data, err := metrics.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	var decoded struct {
+		TotalSteps int `json:"total_steps"`
+		Steps      []struct {
+			Name     string  `json:"name"`
+			Parent   string  `json:"parent"`
+			Duration float64 `json:"duration"`
+			Status   string  `json:"status"`
+			Retries  int     `json:"retries"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.TotalSteps != 2 || len(decoded.Steps) != 2 {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+	if s := decoded.Steps[0]; s.Name != "a" || s.Duration != 0.5 || s.Retries != 2 || s.Status != "completed" {
+		t.Errorf("unexpected first step: %+v", s)
+	}
+	if s := decoded.Steps[1]; s.Parent != "a" || s.Status != "failed" {
+		t.Errorf("unexpected nested step: %+v", s)
+	}
+}
@@ -0,0 +1,437 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/stream"
+)
+
+// runEventsCollection 运行事件在 store 中的集合名前缀，每次运行一个集合，每个事件一条记录
+const runEventsCollection = "workflow_run_events"
+
+// runEventsKey 运行的事件集合名
+func runEventsKey(runID string) string {
+	return runEventsCollection + "/" + runID
+}
+
+// DefaultMaxRecordedRuns 内存中默认保留的运行数
+const DefaultMaxRecordedRuns = 100
+
+// RecordedEvent 已记录的运行事件
+// Error 保存事件发送时附带的错误（如 workflow_failed），用于回放时原样还原
+type RecordedEvent struct {
+	Seq   int       `json:"seq"` // 在本次运行中的序号，从 0 开始
+	Event *RunEvent `json:"event"`
+	Error string    `json:"error,omitempty"`
+}
+
+// RunRecorder 运行事件记录器
+// 记录一次 Workflow 运行中发出的全部事件，用于事后回放和生成运行报告。
+// 与检查点不同，它只关注可观测性，不参与恢复执行。
+// 内存中最多保留 MaxRuns 次运行，超出时移除最早结束的运行；仍在运行的不会被移除（此时允许超出上限），
+// 配置了 DB 时被移除的运行仍可从 DB 读取。
+type RunRecorder struct {
+	mu    sync.RWMutex
+	runs  map[string]*recordedRun
+	order []string
+
+	// DB 可选的持久化存储，为 nil 时仅保存在内存中
+	DB store.Store
+
+	// MaxRuns 内存中保留的最大运行数（<= 0 时使用 DefaultMaxRecordedRuns）
+	MaxRuns int
+}
+
+// recordedRun 内存中的单次运行
+// nextSeq 独立于 events 计数，保证事件序号（DB 中的记录 key）单调递增
+type recordedRun struct {
+	events   []*RecordedEvent
+	nextSeq  int
+	finished bool
+}
+
+// NewRunRecorder 创建运行事件记录器，db 可为 nil
+func NewRunRecorder(db store.Store) *RunRecorder {
+	return &RunRecorder{
+		runs:    make(map[string]*recordedRun),
+		order:   make([]string, 0),
+		DB:      db,
+		MaxRuns: DefaultMaxRecordedRuns,
+	}
+}
+
+// Record 记录单个事件
+func (r *RunRecorder) Record(ctx context.Context, event *RunEvent, eventErr error) error {
+	if event == nil || event.RunID == "" {
+		return nil
+	}
+
+	rec := &RecordedEvent{Event: event}
+	if eventErr != nil {
+		rec.Error = eventErr.Error()
+	}
+
+	r.mu.Lock()
+	run, exists := r.runs[event.RunID]
+	if !exists {
+		run = &recordedRun{}
+		r.runs[event.RunID] = run
+		r.order = append(r.order, event.RunID)
+	}
+	rec.Seq = run.nextSeq
+	run.nextSeq++
+	run.events = append(run.events, rec)
+	switch event.Type {
+	case EventWorkflowCompleted, EventWorkflowFailed, EventWorkflowCancelled:
+		run.finished = true
+		r.evictLocked()
+	}
+	r.mu.Unlock()
+
+	// 只追加当前事件，不重写整个运行
+	if r.DB != nil {
+		if err := r.DB.Set(ctx, runEventsKey(event.RunID), fmt.Sprintf("%08d", rec.Seq), rec); err != nil {
+			return fmt.Errorf("persist run event: %w", err)
+		}
+	}
+	return nil
+}
+
+// evictLocked 超出 MaxRuns 时按记录顺序移除已结束的运行，调用方需持有写锁
+// 仍在运行的不会被移除，它们结束时再次检查上限
+func (r *RunRecorder) evictLocked() {
+	maxRuns := r.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = DefaultMaxRecordedRuns
+	}
+
+	excess := len(r.order) - maxRuns
+	if excess <= 0 {
+		return
+	}
+	kept := r.order[:0]
+	for _, id := range r.order {
+		if excess > 0 && r.runs[id].finished {
+			delete(r.runs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	r.order = kept
+}
+
+// Wrap 包装 Workflow.Execute 返回的事件流，透传所有事件的同时进行记录
+func (r *RunRecorder) Wrap(ctx context.Context, reader *stream.Reader[*RunEvent]) *stream.Reader[*RunEvent] {
+	out, writer := stream.Pipe[*RunEvent](10)
+
+	go func() {
+		defer writer.Close()
+		defer reader.Close()
+
+		for {
+			event, err := reader.Recv()
+			if err != nil && errors.Is(err, io.EOF) {
+				return
+			}
+
+			_ = r.Record(ctx, event, err)
+
+			if writer.Send(event, err) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Events 返回某次运行记录的全部事件（按发出顺序）
+func (r *RunRecorder) Events(ctx context.Context, runID string) ([]*RecordedEvent, error) {
+	r.mu.RLock()
+	run, ok := r.runs[runID]
+	if ok {
+		result := make([]*RecordedEvent, len(run.events))
+		copy(result, run.events)
+		r.mu.RUnlock()
+		return result, nil
+	}
+	r.mu.RUnlock()
+
+	if r.DB != nil {
+		items, err := r.DB.List(ctx, runEventsKey(runID))
+		if err != nil {
+			return nil, fmt.Errorf("load run events: %w", err)
+		}
+		if len(items) > 0 {
+			stored := make([]*RecordedEvent, 0, len(items))
+			for _, item := range items {
+				var rec RecordedEvent
+				if err := store.DecodeValue(item, &rec); err != nil {
+					return nil, fmt.Errorf("decode run event: %w", err)
+				}
+				stored = append(stored, &rec)
+			}
+			sort.Slice(stored, func(i, j int) bool { return stored[i].Seq < stored[j].Seq })
+			return stored, nil
+		}
+	}
+
+	return nil, fmt.Errorf("run %s not recorded", runID)
+}
+
+// RunIDs 返回内存中已记录的运行 ID（按首次记录顺序）
+func (r *RunRecorder) RunIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, len(r.order))
+	copy(ids, r.order)
+	return ids
+}
+
+// Report 生成某次运行的报告
+func (r *RunRecorder) Report(ctx context.Context, runID string) (*RunReport, error) {
+	events, err := r.Events(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildRunReport(events), nil
+}
+
+// ReplayRun 回放已记录的运行，返回与原始运行相同顺序的事件流
+func ReplayRun(recorder *RunRecorder, runID string) (*stream.Reader[*RunEvent], error) {
+	if recorder == nil {
+		return nil, errors.New("recorder is nil")
+	}
+
+	events, err := recorder.Events(context.Background(), runID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := stream.Pipe[*RunEvent](len(events) + 1)
+	go func() {
+		defer writer.Close()
+		for _, rec := range events {
+			var sendErr error
+			if rec.Error != "" {
+				sendErr = errors.New(rec.Error)
+			}
+			if writer.Send(rec.Event, sendErr) {
+				return
+			}
+		}
+	}()
+
+	return reader, nil
+}
+
+// ===== 运行报告 =====
+
+// StepReport 单个步骤的执行摘要
+type StepReport struct {
+	StepID    string
+	StepName  string
+	Index     int
+	Status    RunStatus
+	Output    any
+	Error     string
+	Progress  int
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  float64
+}
+
+// RunReport 一次运行的执行摘要
+type RunReport struct {
+	RunID        string
+	WorkflowID   string
+	WorkflowName string
+	Status       RunStatus
+	Error        string
+	Output       any
+	Metrics      *RunMetrics
+	Steps        []*StepReport
+	EventCount   int
+	StartTime    time.Time
+	EndTime      time.Time
+	Duration     float64
+}
+
+// Step 按名称获取步骤摘要
+func (rr *RunReport) Step(name string) *StepReport {
+	for _, step := range rr.Steps {
+		if step.StepName == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// BuildRunReport 根据记录的事件生成运行报告
+func BuildRunReport(events []*RecordedEvent) *RunReport {
+	report := &RunReport{
+		Status: RunStatusRunning,
+		Steps:  make([]*StepReport, 0),
+	}
+	steps := make(map[string]*StepReport)
+
+	getStep := func(event *RunEvent) *StepReport {
+		key := event.StepID
+		if key == "" {
+			key = event.StepName
+		}
+		step, ok := steps[key]
+		if !ok {
+			step = &StepReport{
+				StepID:   event.StepID,
+				StepName: event.StepName,
+				Index:    len(report.Steps),
+				Status:   RunStatusRunning,
+			}
+			steps[key] = step
+			report.Steps = append(report.Steps, step)
+		}
+		return step
+	}
+
+	for _, rec := range events {
+		event := rec.Event
+		if event == nil {
+			continue
+		}
+		report.EventCount++
+		data, _ := event.Data.(map[string]any)
+
+		switch event.Type {
+		case EventWorkflowStarted:
+			report.RunID = event.RunID
+			report.WorkflowID = event.WorkflowID
+			report.WorkflowName = event.WorkflowName
+			report.StartTime = event.Timestamp
+
+		case EventStepStarted:
+			step := getStep(event)
+			step.StartTime = event.Timestamp
+			switch idx := data["index"].(type) {
+			case int:
+				step.Index = idx
+			case float64:
+				step.Index = int(idx)
+			}
+
+		case EventStepProgress:
+			step := getStep(event)
+			step.Progress++
+			if content, ok := stepOutputContent(event.Data); ok {
+				step.Output = content
+			}
+
+		case EventStepCompleted:
+			step := getStep(event)
+			step.Status = RunStatusCompleted
+			step.EndTime = event.Timestamp
+			if content, ok := stepOutputContent(data["output"]); ok {
+				step.Output = content
+			}
+			step.Duration = reportDuration(data, step.StartTime, step.EndTime)
+
+		case EventStepFailed:
+			step := getStep(event)
+			step.Status = RunStatusFailed
+			step.EndTime = event.Timestamp
+			if msg, ok := data["error"].(string); ok {
+				step.Error = msg
+			}
+			step.Duration = reportDuration(data, step.StartTime, step.EndTime)
+
+		case EventStepSkipped:
+			step := getStep(event)
+			step.Status = RunStatusCancelled
+
+		case EventWorkflowCompleted, EventWorkflowFailed, EventWorkflowCancelled:
+			report.EndTime = event.Timestamp
+			report.Duration = reportDuration(data, report.StartTime, report.EndTime)
+			if metrics, ok := decodeEventData[RunMetrics](data["metrics"]); ok {
+				report.Metrics = metrics
+			}
+
+			switch event.Type {
+			case EventWorkflowCompleted:
+				report.Status = RunStatusCompleted
+				report.Output = data["output"]
+			case EventWorkflowFailed:
+				report.Status = RunStatusFailed
+			default:
+				report.Status = RunStatusCancelled
+			}
+
+			if rec.Error != "" {
+				report.Error = rec.Error
+			} else if msg, ok := data["error"].(string); ok {
+				report.Error = msg
+			}
+		}
+
+		if report.RunID == "" {
+			report.RunID = event.RunID
+		}
+	}
+
+	sort.SliceStable(report.Steps, func(i, j int) bool {
+		return report.Steps[i].Index < report.Steps[j].Index
+	})
+
+	return report
+}
+
+// decodeEventData 读取事件数据中的结构体
+// 内存中的事件保存的是 *T，从存储加载的事件经过 JSON 往返后是 map，需要重新解码
+func decodeEventData[T any](value any) (*T, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case *T:
+		return v, v != nil
+	}
+	var decoded T
+	if err := store.DecodeValue(value, &decoded); err != nil {
+		return nil, false
+	}
+	return &decoded, true
+}
+
+// stepOutputContent 读取事件数据中 StepOutput 的 Content
+// StepOutput.Error 是接口类型无法从 JSON 还原，因此只解码 Content
+func stepOutputContent(value any) (any, bool) {
+	if output, ok := value.(*StepOutput); ok {
+		if output == nil {
+			return nil, false
+		}
+		return output.Content, true
+	}
+	output, ok := decodeEventData[struct{ Content any }](value)
+	if !ok {
+		return nil, false
+	}
+	return output.Content, true
+}
+
+// reportDuration 优先使用事件中的 duration 字段，否则根据时间戳计算
+func reportDuration(data map[string]any, start, end time.Time) float64 {
+	if d, ok := data["duration"].(float64); ok {
+		return d
+	}
+	if !start.IsZero() && !end.IsZero() {
+		return end.Sub(start).Seconds()
+	}
+	return 0
+}
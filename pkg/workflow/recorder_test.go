@@ -0,0 +1,249 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/astercloud/aster/pkg/store"
+	"github.com/astercloud/aster/pkg/stream"
+)
+
+func collectRunEvents(t *testing.T, reader *stream.Reader[*RunEvent]) ([]*RunEvent, []error) {
+	t.Helper()
+
+	var events []*RunEvent
+	var errs []error
+	for {
+		event, err := reader.Recv()
+		if err != nil && errors.Is(err, io.EOF) {
+			break
+		}
+		events = append(events, event)
+		errs = append(errs, err)
+	}
+	return events, errs
+}
+
+func newRecorderTestWorkflow() *Workflow {
+	wf := New("recorder-test").WithStream()
+	wf.AddStep(TransformFunction("upper", func(input any) any {
+		return input.(string) + "-upper"
+	}))
+	wf.AddStep(TransformFunction("suffix", func(input any) any {
+		return input.(string) + "-suffix"
+	}))
+	return wf
+}
+
+func TestRunRecorder_RecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRunRecorder(nil)
+
+	wf := newRecorderTestWorkflow()
+	original, _ := collectRunEvents(t, recorder.Wrap(ctx, wf.Execute(ctx, &WorkflowInput{Input: "hello"})))
+	if len(original) == 0 {
+		t.Fatal("expected events from workflow run")
+	}
+
+	runID := original[0].RunID
+	if ids := recorder.RunIDs(); len(ids) != 1 || ids[0] != runID {
+		t.Fatalf("expected recorded run %s, got %v", runID, ids)
+	}
+
+	replayReader, err := ReplayRun(recorder, runID)
+	if err != nil {
+		t.Fatalf("ReplayRun failed: %v", err)
+	}
+	replayed, _ := collectRunEvents(t, replayReader)
+
+	if len(replayed) != len(original) {
+		t.Fatalf("expected %d replayed events, got %d", len(original), len(replayed))
+	}
+	for i := range original {
+		if replayed[i].Type != original[i].Type || replayed[i].EventID != original[i].EventID {
+			t.Errorf("event %d mismatch: got %s/%s, want %s/%s",
+				i, replayed[i].Type, replayed[i].EventID, original[i].Type, original[i].EventID)
+		}
+	}
+
+	report, err := recorder.Report(ctx, runID)
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if report.Status != RunStatusCompleted {
+		t.Errorf("expected completed status, got %s", report.Status)
+	}
+	if report.EventCount != len(original) {
+		t.Errorf("expected event count %d, got %d", len(original), report.EventCount)
+	}
+	if len(report.Steps) != 2 || report.Steps[0].StepName != "upper" || report.Steps[1].StepName != "suffix" {
+		t.Fatalf("unexpected step reports: %+v", report.Steps)
+	}
+	if out := report.Step("suffix").Output; out != "hello-suffix" {
+		t.Errorf("expected final step output, got %v", out)
+	}
+
+	last := original[len(original)-1]
+	metrics := last.Data.(map[string]any)["metrics"].(*RunMetrics)
+	if report.Metrics != metrics {
+		t.Error("expected report metrics to match the completed event metrics")
+	}
+	if report.Metrics.SuccessfulSteps != 2 {
+		t.Errorf("expected 2 successful steps, got %d", report.Metrics.SuccessfulSteps)
+	}
+	for _, step := range report.Steps {
+		if step.Status != RunStatusCompleted {
+			t.Errorf("step %s expected completed, got %s", step.StepName, step.Status)
+		}
+	}
+}
+
+func TestRunRecorder_ReplaysFailure(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRunRecorder(nil)
+
+	wf := New("recorder-fail").WithStream()
+	wf.AddStep(SimpleFunction("boom", func(input any) (any, error) {
+		return nil, errors.New("boom")
+	}))
+
+	original, origErrs := collectRunEvents(t, recorder.Wrap(ctx, wf.Execute(ctx, &WorkflowInput{Input: "x"})))
+	runID := original[0].RunID
+
+	replayReader, err := ReplayRun(recorder, runID)
+	if err != nil {
+		t.Fatalf("ReplayRun failed: %v", err)
+	}
+	_, replayErrs := collectRunEvents(t, replayReader)
+
+	if len(replayErrs) != len(origErrs) {
+		t.Fatalf("expected %d replayed events, got %d", len(origErrs), len(replayErrs))
+	}
+	lastErr := replayErrs[len(replayErrs)-1]
+	if lastErr == nil || lastErr.Error() != origErrs[len(origErrs)-1].Error() {
+		t.Errorf("expected replayed error %v, got %v", origErrs[len(origErrs)-1], lastErr)
+	}
+
+	report, _ := recorder.Report(ctx, runID)
+	if report.Status != RunStatusFailed || report.Error != "boom" {
+		t.Errorf("expected failed report with error, got %s / %q", report.Status, report.Error)
+	}
+	if step := report.Step("boom"); step == nil || step.Status != RunStatusFailed {
+		t.Errorf("expected failed step report, got %+v", step)
+	}
+}
+
+func TestRunRecorder_PersistsToStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := store.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONStore failed: %v", err)
+	}
+
+	wf := newRecorderTestWorkflow()
+	original, _ := collectRunEvents(t, NewRunRecorder(db).Wrap(ctx, wf.Execute(ctx, &WorkflowInput{Input: "hello"})))
+	runID := original[0].RunID
+
+	// 新的记录器从存储中读取
+	replayReader, err := ReplayRun(NewRunRecorder(db), runID)
+	if err != nil {
+		t.Fatalf("ReplayRun from store failed: %v", err)
+	}
+	replayed, _ := collectRunEvents(t, replayReader)
+
+	if len(replayed) != len(original) {
+		t.Fatalf("expected %d replayed events, got %d", len(original), len(replayed))
+	}
+	for i := range original {
+		if replayed[i].Type != original[i].Type {
+			t.Errorf("event %d type mismatch: got %s, want %s", i, replayed[i].Type, original[i].Type)
+		}
+	}
+
+	// 从存储重建的报告与内存中的一致
+	report := BuildRunReport(mustEvents(t, NewRunRecorder(db), runID))
+	if report.Status != RunStatusCompleted || len(report.Steps) != 2 {
+		t.Fatalf("unexpected report from store: status=%s steps=%d", report.Status, len(report.Steps))
+	}
+	if report.Steps[0].StepName != "upper" || report.Steps[1].StepName != "suffix" {
+		t.Errorf("unexpected step order: %+v", report.Steps)
+	}
+	if out := report.Step("upper").Output; out != "hello-upper" {
+		t.Errorf("expected step output from store, got %v", out)
+	}
+	if out := report.Step("suffix").Output; out != "hello-suffix" {
+		t.Errorf("expected step output from store, got %v", out)
+	}
+	if report.Output != "hello-suffix" {
+		t.Errorf("expected workflow output from store, got %v", report.Output)
+	}
+	if report.Metrics == nil || report.Metrics.SuccessfulSteps != 2 || report.Metrics.TotalSteps != 2 {
+		t.Errorf("expected metrics from store, got %+v", report.Metrics)
+	}
+	if report.EventCount != len(original) {
+		t.Errorf("expected event count %d, got %d", len(original), report.EventCount)
+	}
+}
+
+func TestRunRecorder_BoundsRetainedRuns(t *testing.T) {
+	ctx := context.Background()
+	db, err := store.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONStore failed: %v", err)
+	}
+	recorder := NewRunRecorder(db)
+	recorder.MaxRuns = 2
+
+	// 未结束的运行优先保留
+	pending := &RunEvent{Type: EventWorkflowStarted, RunID: "pending"}
+	if err := recorder.Record(ctx, pending, nil); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	var runIDs []string
+	for range 3 {
+		events, _ := collectRunEvents(t, recorder.Wrap(ctx, newRecorderTestWorkflow().Execute(ctx, &WorkflowInput{Input: "hello"})))
+		runIDs = append(runIDs, events[0].RunID)
+	}
+
+	if ids := recorder.RunIDs(); len(ids) != 2 || ids[0] != "pending" || ids[1] != runIDs[2] {
+		t.Fatalf("expected pending and latest run retained, got %v", ids)
+	}
+
+	// 被移除的运行仍可从存储读取
+	if report := BuildRunReport(mustEvents(t, recorder, runIDs[0])); report.Status != RunStatusCompleted {
+		t.Errorf("expected evicted run from store, got status %s", report.Status)
+	}
+
+	memOnly := NewRunRecorder(nil)
+	memOnly.MaxRuns = 1
+	for _, id := range []string{"a", "b"} {
+		_ = memOnly.Record(ctx, &RunEvent{Type: EventWorkflowCompleted, RunID: id}, nil)
+	}
+	if _, err := memOnly.Events(ctx, "a"); err == nil {
+		t.Error("expected evicted run to be gone without a store")
+	}
+
+	// 运行中的不会被移除，超出上限也保留完整事件与连续序号
+	for _, id := range []string{"x", "y", "x"} {
+		_ = memOnly.Record(ctx, &RunEvent{Type: EventStepStarted, RunID: id}, nil)
+	}
+	if ids := memOnly.RunIDs(); len(ids) != 3 {
+		t.Fatalf("running runs must not be evicted, got %v", ids)
+	}
+	events := mustEvents(t, memOnly, "x")
+	if len(events) != 2 || events[0].Seq != 0 || events[1].Seq != 1 {
+		t.Errorf("unexpected events for running run: %+v", events)
+	}
+}
+
+func mustEvents(t *testing.T, recorder *RunRecorder, runID string) []*RecordedEvent {
+	t.Helper()
+	events, err := recorder.Events(context.Background(), runID)
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	return events
+}
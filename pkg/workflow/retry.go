@@ -0,0 +1,166 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/astercloud/aster/pkg/stream"
+)
+
+// RetryPolicy 步骤重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含首次执行），<= 1 时不重试
+	MaxAttempts int
+
+	// Backoff 首次重试前的等待时长，之后每次重试翻倍
+	Backoff time.Duration
+
+	// MaxBackoff 单次等待时长上限，0 表示不限制
+	MaxBackoff time.Duration
+
+	// RetryableErrors 判断错误是否可重试，为空时所有错误都重试
+	RetryableErrors func(error) bool
+}
+
+// retryable 判断错误是否可重试，上下文取消和超时不重试
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return p.RetryableErrors == nil || p.RetryableErrors(err)
+}
+
+// backoff 第 attempt 次失败后的等待时长
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < attempt && wait > 0 && wait <= math.MaxInt64/2; i++ {
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			break
+		}
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// ===== RetryStep =====
+
+// RetryStep 在内部步骤出错时按 RetryPolicy 指数退避重试
+//
+// 每次重试前发送一条进度输出（Metadata 包含 retry_attempt、error 和 backoff），
+// 最终输出的 Metrics.RetryCount 记录实际重试次数。
+type RetryStep struct {
+	step   Step
+	policy RetryPolicy
+}
+
+// WithRetry 为步骤添加重试
+func WithRetry(step Step, policy RetryPolicy) *RetryStep {
+	return &RetryStep{step: step, policy: policy}
+}
+
+func (s *RetryStep) ID() string          { return s.step.ID() }
+func (s *RetryStep) Name() string        { return s.step.Name() }
+func (s *RetryStep) Type() StepType      { return s.step.Type() }
+func (s *RetryStep) Description() string { return s.step.Description() }
+func (s *RetryStep) Config() *StepConfig { return s.step.Config() }
+
+// Inner 返回被包装的步骤
+func (s *RetryStep) Inner() Step { return s.step }
+
+func (s *RetryStep) Execute(ctx context.Context, input *StepInput) *stream.Reader[*StepOutput] {
+	reader, writer := stream.Pipe[*StepOutput](1)
+
+	go func() {
+		defer writer.Close()
+
+		for attempt := 1; ; attempt++ {
+			output, err := s.runAttempt(ctx, input, writer)
+			if err == nil {
+				if output != nil {
+					setRetryCount(output, attempt-1)
+				}
+				writer.Send(output, nil)
+				return
+			}
+
+			if attempt >= s.policy.MaxAttempts || !s.policy.retryable(err) {
+				if output == nil {
+					output = &StepOutput{StepID: s.ID(), StepName: s.Name(), StepType: s.Type(), Error: err}
+				}
+				setRetryCount(output, attempt-1)
+				writer.Send(output, err)
+				return
+			}
+
+			wait := s.policy.backoff(attempt)
+			writer.Send(&StepOutput{
+				StepID:   s.ID(),
+				StepName: s.Name(),
+				StepType: s.Type(),
+				Error:    err,
+				Metadata: map[string]any{
+					"retry_attempt": attempt,
+					"error":         err.Error(),
+					"backoff":       wait.String(),
+				},
+				Metrics: &StepMetrics{RetryCount: attempt},
+			}, nil)
+
+			select {
+			case <-ctx.Done():
+				writer.Send(&StepOutput{
+					StepID:   s.ID(),
+					StepName: s.Name(),
+					StepType: s.Type(),
+					Error:    ctx.Err(),
+					Metrics:  &StepMetrics{RetryCount: attempt - 1},
+				}, ctx.Err())
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+
+	return reader
+}
+
+// runAttempt 执行一次内部步骤，转发中间输出，返回最终输出
+func (s *RetryStep) runAttempt(ctx context.Context, input *StepInput, writer *stream.Writer[*StepOutput]) (*StepOutput, error) {
+	var last *StepOutput
+	reader := s.step.Execute(ctx, input)
+	for {
+		output, err := reader.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return last, nil
+			}
+			return output, err
+		}
+		if last != nil {
+			writer.Send(last, nil)
+		}
+		last = output
+	}
+}
+
+// setRetryCount 在输出指标中记录重试次数
+func setRetryCount(output *StepOutput, retries int) {
+	if output.Metrics == nil {
+		output.Metrics = &StepMetrics{}
+	}
+	output.Metrics.RetryCount = retries
+}
+
+// countRetries 将步骤输出中的重试次数计入运行指标，缓存命中的输出不计入
+func countRetries(metrics *RunMetrics, output *StepOutput) {
+	if metrics == nil || output == nil || output.FromCache || output.Metrics == nil {
+		return
+	}
+	metrics.TotalRetries += output.Metrics.RetryCount
+}
@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient network error")
+
+// flakyStep 前 failures 次执行返回 err，之后成功
+func flakyStep(failures int, err error, calls *int) Step {
+	return NewFunctionStep("flaky", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		*calls++
+		if *calls <= failures {
+			return nil, err
+		}
+		return &StepOutput{Content: "ok"}, nil
+	})
+}
+
+func runMetrics(t *testing.T, events []*RunEvent) (*RunEvent, *RunMetrics) {
+	t.Helper()
+	last := events[len(events)-1]
+	data, _ := last.Data.(map[string]any)
+	metrics, _ := data["metrics"].(*RunMetrics)
+	if metrics == nil {
+		t.Fatalf("final event %s has no metrics", last.Type)
+	}
+	return last, metrics
+}
+
+func TestWithRetry_RecoversFromTransientErrors(t *testing.T) {
+	calls := 0
+	wf := New("retry").WithStream()
+	wf.StreamExecutorEvents = true
+	wf.AddStep(WithRetry(flakyStep(2, errTransient, &calls), RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last, metrics := runMetrics(t, events)
+
+	if last.Type != EventWorkflowCompleted || last.Data.(map[string]any)["output"] != "ok" {
+		t.Fatalf("expected completed run with output ok, got %s %+v", last.Type, last.Data)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if metrics.TotalRetries != 2 || metrics.SuccessfulSteps != 1 || metrics.FailedSteps != 0 {
+		t.Errorf("unexpected metrics: retries=%d successful=%d failed=%d", metrics.TotalRetries, metrics.SuccessfulSteps, metrics.FailedSteps)
+	}
+
+	var attempts []any
+	for _, event := range events {
+		if event.Type != EventStepProgress {
+			continue
+		}
+		if output, ok := event.Data.(*StepOutput); ok && output.Metadata["retry_attempt"] != nil {
+			attempts = append(attempts, output.Metadata["retry_attempt"])
+		}
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("expected progress events for attempts 1 and 2, got %v", attempts)
+	}
+}
+
+func TestWithRetry_StopsOnNonRetryableError(t *testing.T) {
+	calls := 0
+	fatal := errors.New("invalid input")
+	wf := New("retry-fatal")
+	wf.AddStep(WithRetry(flakyStep(5, fatal, &calls), RetryPolicy{
+		MaxAttempts:     5,
+		Backoff:         time.Millisecond,
+		RetryableErrors: func(err error) bool { return errors.Is(err, errTransient) },
+	}))
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last, metrics := runMetrics(t, events)
+
+	if last.Type != EventWorkflowFailed || calls != 1 {
+		t.Errorf("expected a single failed attempt, got %s after %d calls", last.Type, calls)
+	}
+	if metrics.TotalRetries != 0 || metrics.FailedSteps != 1 {
+		t.Errorf("unexpected metrics: retries=%d failed=%d", metrics.TotalRetries, metrics.FailedSteps)
+	}
+}
+
+func TestWithRetry_ExhaustsAttempts(t *testing.T) {
+	calls := 0
+	wf := New("retry-exhaust")
+	wf.AddStep(WithRetry(flakyStep(5, errTransient, &calls), RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	last, metrics := runMetrics(t, events)
+
+	if last.Type != EventWorkflowFailed || calls != 3 {
+		t.Errorf("expected failure after 3 attempts, got %s after %d calls", last.Type, calls)
+	}
+	if metrics.TotalRetries != 2 || metrics.FailedSteps != 1 {
+		t.Errorf("unexpected metrics: retries=%d failed=%d", metrics.TotalRetries, metrics.FailedSteps)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		if got := policy.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
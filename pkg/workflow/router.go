@@ -33,7 +33,6 @@ func NewRouter(name string, selector func(*StepInput) []Step, choices []Step) *R
 			Name:        name,
 			Type:        StepTypeRouter,
 			MaxRetries:  1,
-			Timeout:     10 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -97,6 +96,7 @@ func (r *Router) Execute(ctx context.Context, input *StepInput) *stream.Reader[*
 					Audio:               input.Audio,
 					Files:               input.Files,
 					WorkflowSession:     input.WorkflowSession,
+					SharedContext:       input.SharedContext,
 				}
 			}
 
@@ -270,6 +270,7 @@ func (r *Router) ExecuteStream(ctx context.Context, input *StepInput, streamEven
 					Audio:               input.Audio,
 					Files:               input.Files,
 					WorkflowSession:     input.WorkflowSession,
+					SharedContext:       input.SharedContext,
 				}
 			}
 
@@ -450,7 +451,6 @@ func DynamicRouter(name string, selector func(*StepInput) []Step) *Router {
 			Name:        name,
 			Type:        StepTypeRouter,
 			MaxRetries:  1,
-			Timeout:     10 * time.Minute,
 			SkipOnError: false,
 		},
 	}
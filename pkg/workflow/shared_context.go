@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"maps"
+	"sync"
+)
+
+// SharedContext 步骤间共享的线程安全键值存储
+//
+// 同一次运行中的所有步骤（包括并行分支、循环体、Graph/Map 节点和子 Workflow）
+// 通过 StepInput.SharedContext 访问同一个实例。
+//
+// 可见性保证：
+//   - 所有读写由互斥锁保护，Set 完成后开始的 Get 一定能读到该值（或更新的值）；
+//   - 顺序步骤中，前一步骤的所有写入对后续步骤可见；
+//   - 并行分支（ParallelStep、Graph 中互不依赖的节点、MapStep 元素）之间没有顺序保证，
+//     需要读-改-写时使用 Update；并行步骤结束后，所有分支的写入对其后续步骤可见；
+//   - Graph 中节点的写入对依赖它的节点可见。
+type SharedContext struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+// NewSharedContext 创建 SharedContext，initial 会被复制
+func NewSharedContext(initial map[string]any) *SharedContext {
+	values := make(map[string]any, len(initial))
+	maps.Copy(values, initial)
+	return &SharedContext{values: values}
+}
+
+// Get 读取值
+func (c *SharedContext) Get(key string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// Set 写入值
+func (c *SharedContext) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+// Delete 删除值
+func (c *SharedContext) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+}
+
+// Update 原子地读-改-写，fn 在持有锁时调用，不能再访问同一个 SharedContext
+func (c *SharedContext) Update(key string, fn func(current any, exists bool) any) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, exists := c.values[key]
+	value := fn(current, exists)
+	c.values[key] = value
+	return value
+}
+
+// Snapshot 返回当前所有值的浅拷贝
+func (c *SharedContext) Snapshot() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.values)
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// recordFinding 以 Update 追加发现，模拟并行校验步骤
+func recordFinding(name string) *FunctionStep {
+	return NewFunctionStep(name, func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		input.SharedContext.Update("findings", func(current any, exists bool) any {
+			findings, _ := current.([]string)
+			return append(findings, name)
+		})
+		input.SharedContext.Set(name+"_done", true)
+		return &StepOutput{Content: name}, nil
+	})
+}
+
+func TestSharedContext_ParallelBranches(t *testing.T) {
+	wf := New("shared-context")
+	wf.AddStep(NewParallelStep("checks", recordFinding("validate"), recordFinding("lint"), recordFinding("save")))
+
+	var aggregated []string
+	wf.AddStep(NewFunctionStep("aggregate", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		value, _ := input.SharedContext.Get("findings")
+		aggregated = append([]string(nil), value.([]string)...)
+		sort.Strings(aggregated)
+		input.SharedContext.Delete("save_done")
+		return &StepOutput{Content: len(aggregated)}, nil
+	}))
+
+	shared := NewSharedContext(map[string]any{"seed": 1})
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x", SharedContext: shared}))
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted {
+		t.Fatalf("expected completion, got %s", last.Type)
+	}
+	if want := []string{"lint", "save", "validate"}; !reflect.DeepEqual(aggregated, want) {
+		t.Errorf("aggregated = %v, want %v", aggregated, want)
+	}
+
+	snapshot := last.Data.(map[string]any)["shared_context"].(map[string]any)
+	if snapshot["seed"] != 1 || snapshot["validate_done"] != true || snapshot["lint_done"] != true {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if _, ok := snapshot["save_done"]; ok {
+		t.Error("deleted key should not appear in the snapshot")
+	}
+	if _, ok := shared.Get("validate_done"); !ok {
+		t.Error("caller-provided SharedContext should receive the writes")
+	}
+}
+
+func TestSharedContext_CreatedWhenMissing(t *testing.T) {
+	wf := New("shared-default")
+	wf.AddStep(NewFunctionStep("write", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		input.SharedContext.Set("k", "v")
+		return &StepOutput{Content: "ok"}, nil
+	}))
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	snapshot := events[len(events)-1].Data.(map[string]any)["shared_context"].(map[string]any)
+	if snapshot["k"] != "v" {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestSharedContext_ConcurrentUpdate(t *testing.T) {
+	shared := NewSharedContext(nil)
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shared.Update("count", func(current any, exists bool) any {
+				n, _ := current.(int)
+				return n + 1
+			})
+			shared.Set(fmt.Sprintf("k%d", i), i)
+			_ = shared.Snapshot()
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := shared.Get("count"); value != 50 {
+		t.Errorf("count = %v, want 50", value)
+	}
+	if got := len(shared.Snapshot()); got != 51 {
+		t.Errorf("expected 51 keys, got %d", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -43,7 +45,6 @@ func NewAgentStep(name string, agent *agent.Agent) *AgentStep {
 			Name:        name,
 			Type:        StepTypeAgent,
 			MaxRetries:  3,
-			Timeout:     5 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -96,6 +97,12 @@ func (s *AgentStep) WithTimeout(timeout time.Duration) *AgentStep {
 	return s
 }
 
+// WithOnTimeout 设置超时后的处理方式
+func (s *AgentStep) WithOnTimeout(policy TimeoutPolicy) *AgentStep {
+	s.config.OnTimeout = policy
+	return s
+}
+
 // ===== RoomStep =====
 
 type RoomStep struct {
@@ -115,7 +122,6 @@ func NewRoomStep(name string, room *core.Room) *RoomStep {
 			Name:        name,
 			Type:        StepTypeRoom,
 			MaxRetries:  3,
-			Timeout:     10 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -182,7 +188,6 @@ func NewFunctionStep(name string, executor func(ctx context.Context, input *Step
 			Name:        name,
 			Type:        StepTypeFunction,
 			MaxRetries:  1,
-			Timeout:     1 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -244,6 +249,36 @@ func (s *FunctionStep) WithTimeout(timeout time.Duration) *FunctionStep {
 	return s
 }
 
+// WithOnTimeout 设置超时后的处理方式
+func (s *FunctionStep) WithOnTimeout(policy TimeoutPolicy) *FunctionStep {
+	s.config.OnTimeout = policy
+	return s
+}
+
+// WithNonResumable 标记步骤输出无法持久化，检查点恢复时重新执行
+func (s *FunctionStep) WithNonResumable() *FunctionStep {
+	s.config.NonResumable = true
+	return s
+}
+
+// WithCache 按输入缓存步骤输出，只应用于无副作用的确定性步骤
+func (s *FunctionStep) WithCache(ttl time.Duration) *FunctionStep {
+	s.config.CacheTTL = ttl
+	return s
+}
+
+// WithCacheVersion 设置缓存版本，修改版本使已有缓存失效
+func (s *FunctionStep) WithCacheVersion(version string) *FunctionStep {
+	s.config.CacheVersion = version
+	return s
+}
+
+// WithCacheKey 设置缓存键前缀，用于区分同名步骤或在改名后沿用已有缓存
+func (s *FunctionStep) WithCacheKey(key string) *FunctionStep {
+	s.config.CacheKey = key
+	return s
+}
+
 // ===== Helper Functions =====
 
 func SimpleFunction(name string, fn func(input any) (any, error)) *FunctionStep {
@@ -294,7 +329,6 @@ func NewConditionStep(name string, condition func(*StepInput) bool, ifTrue, ifFa
 			Name:        name,
 			Type:        StepTypeCondition,
 			MaxRetries:  1,
-			Timeout:     5 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -389,7 +423,6 @@ func NewLoopStep(name string, body Step, maxIterations int) *LoopStep {
 			Name:        name,
 			Type:        StepTypeLoop,
 			MaxRetries:  1,
-			Timeout:     30 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -417,6 +450,7 @@ func (s *LoopStep) Execute(ctx context.Context, input *StepInput) *stream.Reader
 				PreviousStepOutputs: input.PreviousStepOutputs,
 				AdditionalData:      input.AdditionalData,
 				SessionState:        input.SessionState,
+				SharedContext:       input.SharedContext,
 			}
 
 			if lastOutput != nil {
@@ -503,7 +537,6 @@ func NewParallelStep(name string, steps ...Step) *ParallelStep {
 			Name:        name,
 			Type:        StepTypeParallel,
 			MaxRetries:  1,
-			Timeout:     10 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -598,27 +631,32 @@ func (s *ParallelStep) Execute(ctx context.Context, input *StepInput) *stream.Re
 
 // ===== RouterStep =====
 
+// RouterStep 按 router 返回的路由名选择步骤执行，路由可在构造后动态注册
+// 路由名未注册时使用默认路由，没有默认路由时步骤失败
 type RouterStep struct {
 	id          string
 	name        string
 	description string
 	router      func(*StepInput) string
+	mu          sync.RWMutex
 	routes      map[string]Step
 	defaultStep Step
 	config      *StepConfig
 }
 
 func NewRouterStep(name string, router func(*StepInput) string, routes map[string]Step) *RouterStep {
+	if routes == nil {
+		routes = make(map[string]Step)
+	}
 	return &RouterStep{
 		id:     uuid.New().String(),
 		name:   name,
 		router: router,
-		routes: routes,
+		routes: maps.Clone(routes),
 		config: &StepConfig{
 			Name:        name,
 			Type:        StepTypeRouter,
 			MaxRetries:  1,
-			Timeout:     5 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -638,9 +676,10 @@ func (s *RouterStep) Execute(ctx context.Context, input *StepInput) *stream.Read
 		startTime := time.Now()
 
 		routeName := s.router(input)
-		step, exists := s.routes[routeName]
+		metadata := map[string]any{"route": routeName}
+		step, exists, defaultStep := s.lookup(routeName)
 		if !exists {
-			if s.defaultStep == nil {
+			if defaultStep == nil {
 				err := fmt.Errorf("route '%s' not found", routeName)
 				errorOutput := &StepOutput{
 					StepID:    s.id,
@@ -655,8 +694,9 @@ func (s *RouterStep) Execute(ctx context.Context, input *StepInput) *stream.Read
 				writer.Send(errorOutput, err)
 				return
 			}
-			step = s.defaultStep
-			routeName = "default"
+			step = defaultStep
+			metadata["route"] = "default"
+			metadata["requested_route"] = routeName
 		}
 
 		var routeOutput *StepOutput
@@ -674,7 +714,7 @@ func (s *RouterStep) Execute(ctx context.Context, input *StepInput) *stream.Read
 					Error:     err,
 					StartTime: startTime,
 					EndTime:   time.Now(),
-					Metadata:  map[string]any{"route": routeName},
+					Metadata:  metadata,
 				}
 				errorOutput.Duration = errorOutput.EndTime.Sub(errorOutput.StartTime).Seconds()
 				writer.Send(errorOutput, err)
@@ -691,7 +731,7 @@ func (s *RouterStep) Execute(ctx context.Context, input *StepInput) *stream.Read
 			StartTime:   startTime,
 			EndTime:     time.Now(),
 			NestedSteps: []*StepOutput{routeOutput},
-			Metadata:    map[string]any{"route": routeName},
+			Metadata:    metadata,
 			Metrics:     &StepMetrics{ExecutionTime: time.Since(startTime).Seconds()},
 		}
 		output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
@@ -701,11 +741,50 @@ func (s *RouterStep) Execute(ctx context.Context, input *StepInput) *stream.Read
 	return reader
 }
 
+// NewRouterStepWithDefault 创建带默认路由的 RouterStep
+func NewRouterStepWithDefault(name string, router func(*StepInput) string, routes map[string]Step, defaultStep Step) *RouterStep {
+	return NewRouterStep(name, router, routes).WithDefault(defaultStep)
+}
+
 func (s *RouterStep) WithDefault(step Step) *RouterStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.defaultStep = step
 	return s
 }
 
+// AddRoute 注册（或替换）路由，可在执行期间并发调用
+func (s *RouterStep) AddRoute(name string, step Step) *RouterStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[name] = step
+	return s
+}
+
+// RemoveRoute 移除路由，返回路由是否存在
+func (s *RouterStep) RemoveRoute(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.routes[name]
+	delete(s.routes, name)
+	return exists
+}
+
+// Routes 返回已注册的路由名（按名称排序）
+func (s *RouterStep) Routes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.routes))
+}
+
+// lookup 查找路由和默认路由
+func (s *RouterStep) lookup(name string) (Step, bool, Step) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	step, exists := s.routes[name]
+	return step, exists, s.defaultStep
+}
+
 // ===== StepsGroup =====
 
 type StepsGroup struct {
@@ -725,7 +804,6 @@ func NewStepsGroup(name string, steps ...Step) *StepsGroup {
 			Name:        name,
 			Type:        StepTypeSteps,
 			MaxRetries:  1,
-			Timeout:     30 * time.Minute,
 			SkipOnError: false,
 		},
 	}
@@ -753,6 +831,7 @@ func (s *StepsGroup) Execute(ctx context.Context, input *StepInput) *stream.Read
 				PreviousStepOutputs: input.PreviousStepOutputs,
 				AdditionalData:      input.AdditionalData,
 				SessionState:        input.SessionState,
+				SharedContext:       input.SharedContext,
 			}
 
 			if lastOutput != nil {
@@ -810,3 +889,68 @@ func (s *StepsGroup) Execute(ctx context.Context, input *StepInput) *stream.Read
 
 	return reader
 }
+
+// ===== BreakStep =====
+
+// BreakStep 条件提前结束 Workflow
+//
+// predicate 返回 true 时 Workflow 在此步骤后正常结束（EventWorkflowCompleted），
+// 以 PreviousStepContent 作为输出；否则原样传递 PreviousStepContent 给后续步骤。
+type BreakStep struct {
+	id          string
+	name        string
+	description string
+	predicate   func(*StepInput) bool
+	config      *StepConfig
+}
+
+func NewBreakStep(name string, predicate func(*StepInput) bool) *BreakStep {
+	return &BreakStep{
+		id:        uuid.New().String(),
+		name:      name,
+		predicate: predicate,
+		config: &StepConfig{
+			Name:        name,
+			Type:        StepTypeBreak,
+			MaxRetries:  1,
+			SkipOnError: false,
+		},
+	}
+}
+
+func (s *BreakStep) ID() string          { return s.id }
+func (s *BreakStep) Name() string        { return s.name }
+func (s *BreakStep) Type() StepType      { return StepTypeBreak }
+func (s *BreakStep) Description() string { return s.description }
+func (s *BreakStep) Config() *StepConfig { return s.config }
+
+func (s *BreakStep) Execute(ctx context.Context, input *StepInput) *stream.Reader[*StepOutput] {
+	reader, writer := stream.Pipe[*StepOutput](1)
+
+	go func() {
+		defer writer.Close()
+		startTime := time.Now()
+
+		shouldBreak := s.predicate != nil && s.predicate(input)
+		output := &StepOutput{
+			StepID:    s.id,
+			StepName:  s.name,
+			StepType:  StepTypeBreak,
+			Content:   input.PreviousStepContent,
+			Break:     shouldBreak,
+			StartTime: startTime,
+			EndTime:   time.Now(),
+			Metadata:  map[string]any{"break": shouldBreak},
+			Metrics:   &StepMetrics{ExecutionTime: time.Since(startTime).Seconds()},
+		}
+		output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
+		writer.Send(output, nil)
+	}()
+
+	return reader
+}
+
+func (s *BreakStep) WithDescription(desc string) *BreakStep {
+	s.description = desc
+	return s
+}
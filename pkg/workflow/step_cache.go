@@ -0,0 +1,136 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+)
+
+// StepCache 步骤输出缓存
+type StepCache interface {
+	Get(key string) (*StepOutput, bool)
+	Set(key string, output *StepOutput, ttl time.Duration)
+}
+
+// stepCacheSweepInterval 读取时清理过期条目的最小间隔
+const stepCacheSweepInterval = time.Minute
+
+// MemoryStepCache 基于内存的步骤缓存
+// 写入和读取时都复制输出，调用方修改返回值不会影响缓存。
+type MemoryStepCache struct {
+	mu        sync.Mutex
+	entries   map[string]stepCacheEntry
+	nextSweep time.Time
+}
+
+type stepCacheEntry struct {
+	output    *StepOutput
+	expiresAt time.Time
+}
+
+// NewMemoryStepCache 创建内存步骤缓存
+func NewMemoryStepCache() *MemoryStepCache {
+	return &MemoryStepCache{entries: make(map[string]stepCacheEntry)}
+}
+
+// Get 获取未过期的缓存输出，并清理已过期的条目
+func (c *MemoryStepCache) Get(key string) (*StepOutput, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextSweep) {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(stepCacheSweepInterval)
+	}
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if now.After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return cloneStepOutput(entry.output), true
+}
+
+// Set 写入缓存输出
+func (c *MemoryStepCache) Set(key string, output *StepOutput, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = stepCacheEntry{output: cloneStepOutput(output), expiresAt: time.Now().Add(ttl)}
+}
+
+// Len 返回缓存条目数（含尚未清理的过期条目）
+func (c *MemoryStepCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// stepCacheKey 根据步骤缓存键前缀和输入哈希计算缓存键
+// 前缀默认为步骤类型和名称（步骤 ID 每次构建都会变化，不能用于跨进程命中），
+// 哈希包含缓存版本，输入无法序列化时返回 false，此时不使用缓存。
+func stepCacheKey(step Step, input *StepInput) (string, bool) {
+	config := step.Config()
+	prefix := config.CacheKey
+	if prefix == "" {
+		prefix = string(step.Type()) + "/" + step.Name()
+	}
+	data, err := json.Marshal(map[string]any{
+		"version":  config.CacheVersion,
+		"input":    input.Input,
+		"previous": input.PreviousStepContent,
+		"data":     input.AdditionalData,
+		"state":    input.SessionState,
+		"images":   input.Images,
+		"videos":   input.Videos,
+		"audio":    input.Audio,
+		"files":    input.Files,
+	})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return prefix + ":" + hex.EncodeToString(sum[:]), true
+}
+
+// cloneStepOutput 复制步骤输出，Metadata、Metrics 和嵌套步骤各自独立，Content 按值复制
+func cloneStepOutput(output *StepOutput) *StepOutput {
+	if output == nil {
+		return nil
+	}
+	clone := *output
+	clone.Metadata = maps.Clone(output.Metadata)
+	if output.Metrics != nil {
+		metrics := *output.Metrics
+		metrics.Custom = maps.Clone(output.Metrics.Custom)
+		clone.Metrics = &metrics
+	}
+	if output.NestedSteps != nil {
+		clone.NestedSteps = make([]*StepOutput, len(output.NestedSteps))
+		for i, nested := range output.NestedSteps {
+			clone.NestedSteps[i] = cloneStepOutput(nested)
+		}
+	}
+	return &clone
+}
+
+// cachedStepOutput 复制缓存输出并标记 FromCache
+// 自定义的 StepCache 可能直接返回内部保存的输出，这里总是复制一份
+func cachedStepOutput(cached *StepOutput) *StepOutput {
+	output := cloneStepOutput(cached)
+	output.FromCache = true
+	output.StartTime = time.Now()
+	output.EndTime = output.StartTime
+	output.Duration = 0
+	return output
+}
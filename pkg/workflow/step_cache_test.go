@@ -0,0 +1,166 @@
+package workflow
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runCacheWorkflow 执行 Workflow 并返回缓存步骤的 step_completed 事件
+func runCacheWorkflow(t *testing.T, wf *Workflow, input string) *RunEvent {
+	t.Helper()
+
+	events, errs := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: input}))
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, event := range events {
+		if event.Type == EventStepCompleted && event.StepName == "expensive" {
+			return event
+		}
+	}
+	t.Fatal("missing step_completed event for cached step")
+	return nil
+}
+
+func TestStepCache_SkipsRepeatedExecution(t *testing.T) {
+	var calls atomic.Int32
+	step := SimpleFunction("expensive", func(input any) (any, error) {
+		calls.Add(1)
+		return input.(string) + "-computed", nil
+	}).WithCache(time.Minute)
+
+	wf := New("cache-test").WithStream()
+	wf.AddStep(step)
+
+	first := runCacheWorkflow(t, wf, "x")
+	second := runCacheWorkflow(t, wf, "x")
+
+	if calls.Load() != 1 {
+		t.Errorf("expected cached step to run once, ran %d times", calls.Load())
+	}
+	if first.Data.(map[string]any)["from_cache"] != false {
+		t.Error("first run should not come from cache")
+	}
+	data := second.Data.(map[string]any)
+	output := data["output"].(*StepOutput)
+	if data["from_cache"] != true || !output.FromCache || output.Content != "x-computed" {
+		t.Errorf("expected cached output, got %+v", output)
+	}
+
+	// 不同输入不命中缓存
+	runCacheWorkflow(t, wf, "y")
+	if calls.Load() != 2 {
+		t.Errorf("expected new input to run step, ran %d times", calls.Load())
+	}
+
+	// 版本变化使缓存失效
+	step.WithCacheVersion("v2")
+	runCacheWorkflow(t, wf, "x")
+	if calls.Load() != 3 {
+		t.Errorf("expected version bump to invalidate cache, ran %d times", calls.Load())
+	}
+}
+
+func TestStepCache_ExpiresAfterTTL(t *testing.T) {
+	var calls atomic.Int32
+	wf := New("cache-ttl").WithStream()
+	wf.AddStep(SimpleFunction("expensive", func(input any) (any, error) {
+		calls.Add(1)
+		return input, nil
+	}).WithCache(20 * time.Millisecond))
+
+	runCacheWorkflow(t, wf, "x")
+	time.Sleep(40 * time.Millisecond)
+	runCacheWorkflow(t, wf, "x")
+
+	if calls.Load() != 2 {
+		t.Errorf("expected expired cache to rerun step, ran %d times", calls.Load())
+	}
+}
+
+func TestStepCache_UncachedStepsAlwaysRun(t *testing.T) {
+	var calls atomic.Int32
+	wf := New("no-cache").WithStream()
+	wf.AddStep(SimpleFunction("expensive", func(input any) (any, error) {
+		calls.Add(1)
+		return input, nil
+	}))
+
+	runCacheWorkflow(t, wf, "x")
+	runCacheWorkflow(t, wf, "x")
+
+	if calls.Load() != 2 {
+		t.Errorf("expected step without cache to run twice, ran %d times", calls.Load())
+	}
+}
+
+func TestStepCache_KeyedByStepName(t *testing.T) {
+	var calls atomic.Int32
+	newStep := func(name string) *FunctionStep {
+		return SimpleFunction(name, func(input any) (any, error) {
+			calls.Add(1)
+			return input, nil
+		}).WithCache(time.Minute)
+	}
+	cache := NewMemoryStepCache()
+	run := func(step *FunctionStep) {
+		wf := New("rebuilt").WithStream().WithStepCache(cache)
+		wf.AddStep(step)
+		runCacheWorkflow(t, wf, "x")
+	}
+
+	// 重新构建的同名步骤（ID 不同）命中已有缓存
+	run(newStep("expensive"))
+	run(newStep("expensive"))
+	if calls.Load() != 1 {
+		t.Errorf("expected rebuilt step to hit cache, ran %d times", calls.Load())
+	}
+
+	// 显式缓存键区分同名步骤
+	run(newStep("expensive").WithCacheKey("variant"))
+	if calls.Load() != 2 {
+		t.Errorf("expected distinct cache key to miss, ran %d times", calls.Load())
+	}
+	run(newStep("expensive").WithCacheKey("variant"))
+	if calls.Load() != 2 {
+		t.Errorf("expected same cache key to hit, ran %d times", calls.Load())
+	}
+}
+
+func TestMemoryStepCache_ReturnsCopies(t *testing.T) {
+	cache := NewMemoryStepCache()
+	output := &StepOutput{Content: "v", Metadata: map[string]any{"k": "v"}, Metrics: &StepMetrics{TotalTokens: 1}}
+	cache.Set("key", output, time.Minute)
+	output.Metadata["k"] = "changed"
+
+	got, ok := cache.Get("key")
+	if !ok || got.Metadata["k"] != "v" {
+		t.Fatalf("expected cached copy to be isolated from the original, got %+v", got)
+	}
+	got.Metadata["k"] = "mutated"
+	got.Metrics.TotalTokens = 99
+
+	again, _ := cache.Get("key")
+	if again.Metadata["k"] != "v" || again.Metrics.TotalTokens != 1 {
+		t.Errorf("expected cache to be isolated from returned copies, got %+v", again)
+	}
+}
+
+func TestMemoryStepCache_EvictsExpiredOnRead(t *testing.T) {
+	cache := NewMemoryStepCache()
+	cache.Set("old-1", &StepOutput{}, time.Millisecond)
+	cache.Set("old-2", &StepOutput{}, time.Millisecond)
+	cache.Set("fresh", &StepOutput{}, time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := cache.Get("fresh"); !ok {
+		t.Fatal("expected fresh entry to be cached")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected expired entries to be evicted, %d left", cache.Len())
+	}
+}
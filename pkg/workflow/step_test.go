@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newBreakWorkflow(calls *int) *Workflow {
+	wf := New("break").WithStream()
+	wf.AddStep(TransformFunction("draft", func(input any) any {
+		return input.(string) + "-draft"
+	}))
+	wf.AddStep(NewBreakStep("good-enough", func(input *StepInput) bool {
+		return input.Input == "short"
+	}))
+	wf.AddStep(NewFunctionStep("polish", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		*calls++
+		return &StepOutput{Content: input.PreviousStepContent.(string) + "-polished"}, nil
+	}))
+	return wf
+}
+
+func TestBreakStep_StopsWorkflow(t *testing.T) {
+	calls := 0
+	events, errs := collectRunEvents(t, newBreakWorkflow(&calls).Execute(context.Background(), &WorkflowInput{Input: "short"}))
+
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted || errs[len(errs)-1] != nil {
+		t.Fatalf("expected workflow_completed, got %s (%v)", last.Type, errs[len(errs)-1])
+	}
+	data := last.Data.(map[string]any)
+	if data["output"] != "short-draft" {
+		t.Errorf("output = %v, want the content before the break", data["output"])
+	}
+	if calls != 0 {
+		t.Error("steps after the break should not run")
+	}
+
+	metrics := data["metrics"].(*RunMetrics)
+	if metrics.TotalSteps != 2 || metrics.SuccessfulSteps != 2 || metrics.FailedSteps != 0 || metrics.BreakStep != "good-enough" {
+		t.Errorf("unexpected metrics: total=%d successful=%d failed=%d break=%q",
+			metrics.TotalSteps, metrics.SuccessfulSteps, metrics.FailedSteps, metrics.BreakStep)
+	}
+}
+
+func TestBreakStep_PassesThroughWhenFalse(t *testing.T) {
+	calls := 0
+	events, _ := collectRunEvents(t, newBreakWorkflow(&calls).Execute(context.Background(), &WorkflowInput{Input: "long"}))
+
+	data := events[len(events)-1].Data.(map[string]any)
+	if data["output"] != "long-draft-polished" {
+		t.Errorf("output = %v, break step should pass previous content through", data["output"])
+	}
+	metrics := data["metrics"].(*RunMetrics)
+	if metrics.TotalSteps != 3 || metrics.BreakStep != "" {
+		t.Errorf("unexpected metrics: total=%d break=%q", metrics.TotalSteps, metrics.BreakStep)
+	}
+}
+
+func constStep(name string) *FunctionStep {
+	return NewFunctionStep(name, func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		return &StepOutput{Content: name}, nil
+	})
+}
+
+func TestRouterStep_DynamicRoutes(t *testing.T) {
+	router := NewRouterStep("router", func(input *StepInput) string {
+		return input.Input.(string)
+	}, nil)
+
+	if _, err := drainStep(context.Background(), router, &StepInput{Input: "a"}); err == nil {
+		t.Fatal("expected error for unknown route without default")
+	}
+
+	router.AddRoute("a", constStep("route-a")).AddRoute("b", constStep("route-b"))
+	output, err := drainStep(context.Background(), router, &StepInput{Input: "b"})
+	if err != nil || output.Content != "route-b" {
+		t.Fatalf("unexpected result %v (%v)", output, err)
+	}
+	if got := router.Routes(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Routes() = %v", got)
+	}
+
+	if !router.RemoveRoute("b") || router.RemoveRoute("b") {
+		t.Error("RemoveRoute should report whether the route existed")
+	}
+	if _, err := drainStep(context.Background(), router, &StepInput{Input: "b"}); err == nil {
+		t.Error("removed route should no longer be selected")
+	}
+}
+
+func TestRouterStep_DefaultRoute(t *testing.T) {
+	router := NewRouterStepWithDefault("router", func(input *StepInput) string {
+		return "unknown"
+	}, map[string]Step{"a": constStep("route-a")}, constStep("fallback"))
+
+	output, err := drainStep(context.Background(), router, &StepInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Content != "fallback" || output.Metadata["route"] != "default" || output.Metadata["requested_route"] != "unknown" {
+		t.Errorf("unexpected output: %v %+v", output.Content, output.Metadata)
+	}
+}
+
+func TestRouterStep_ConcurrentRegistration(t *testing.T) {
+	router := NewRouterStepWithDefault("router", func(input *StepInput) string {
+		return input.Input.(string)
+	}, nil, constStep("fallback"))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		name := fmt.Sprintf("r%d", i)
+		go func() {
+			defer wg.Done()
+			router.AddRoute(name, constStep(name))
+			router.RemoveRoute(name)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := drainStep(context.Background(), router, &StepInput{Input: name}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,242 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/astercloud/aster/pkg/stream"
+	"github.com/google/uuid"
+)
+
+// subWorkflowMetricsKey StepOutput.Metadata 中保存内部运行指标的键
+const subWorkflowMetricsKey = "sub_workflow_metrics"
+
+// ===== SubWorkflowStep =====
+
+// SubWorkflowStep 将一个完整的 Workflow 作为单个步骤嵌入到父 Workflow 中
+//
+// 内部 Workflow 使用父步骤的 Input 和 PreviousStepContent 运行，最终输出作为本步骤的输出。
+// 内部 Workflow 启用 StreamEvents 时，其步骤事件会以 "<步骤名>/<内部步骤名>" 的形式
+// 作为本步骤的进度输出转发。取消和超时通过 ctx 传递到内部 Workflow。
+type SubWorkflowStep struct {
+	id          string
+	name        string
+	description string
+	inner       *Workflow
+	config      *StepConfig
+}
+
+func NewSubWorkflowStep(name string, inner *Workflow) *SubWorkflowStep {
+	return &SubWorkflowStep{
+		id:          uuid.New().String(),
+		name:        name,
+		description: innerDescription(inner),
+		inner:       inner,
+		config: &StepConfig{
+			Name:        name,
+			Type:        StepTypeSubWorkflow,
+			MaxRetries:  1,
+			SkipOnError: false,
+		},
+	}
+}
+
+func (s *SubWorkflowStep) ID() string          { return s.id }
+func (s *SubWorkflowStep) Name() string        { return s.name }
+func (s *SubWorkflowStep) Type() StepType      { return StepTypeSubWorkflow }
+func (s *SubWorkflowStep) Description() string { return s.description }
+func (s *SubWorkflowStep) Config() *StepConfig { return s.config }
+
+// Inner 返回被嵌入的 Workflow
+func (s *SubWorkflowStep) Inner() *Workflow { return s.inner }
+
+func (s *SubWorkflowStep) Execute(ctx context.Context, input *StepInput) *stream.Reader[*StepOutput] {
+	reader, writer := stream.Pipe[*StepOutput](10)
+
+	go func() {
+		defer writer.Close()
+		startTime := time.Now()
+
+		fail := func(err error, metrics *RunMetrics) {
+			errorOutput := &StepOutput{
+				StepID:    s.id,
+				StepName:  s.name,
+				StepType:  StepTypeSubWorkflow,
+				Error:     err,
+				StartTime: startTime,
+				EndTime:   time.Now(),
+				Metadata:  s.metadata(metrics),
+			}
+			errorOutput.Duration = errorOutput.EndTime.Sub(errorOutput.StartTime).Seconds()
+			writer.Send(errorOutput, err)
+		}
+
+		if s.inner == nil {
+			fail(errors.New("sub workflow is nil"), nil)
+			return
+		}
+
+		if s.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+			defer cancel()
+		}
+
+		innerReader := s.inner.Execute(ctx, &WorkflowInput{
+			Input:               input.Input,
+			PreviousStepContent: input.PreviousStepContent,
+			AdditionalData:      input.AdditionalData,
+			SessionState:        input.SessionState,
+			Images:              input.Images,
+			Videos:              input.Videos,
+			Audio:               input.Audio,
+			Files:               input.Files,
+			SharedContext:       input.SharedContext,
+		})
+		defer innerReader.Close()
+
+		for {
+			event, err := innerReader.Recv()
+			if err != nil && errors.Is(err, io.EOF) {
+				fail(errors.New("sub workflow ended without completion"), nil)
+				return
+			}
+
+			data, _ := event.eventData()
+			metrics, _ := data["metrics"].(*RunMetrics)
+
+			if err != nil {
+				fail(fmt.Errorf("sub workflow %s: %w", s.inner.Name, err), metrics)
+				return
+			}
+
+			switch event.Type {
+			case EventWorkflowCompleted:
+				output := &StepOutput{
+					StepID:      s.id,
+					StepName:    s.name,
+					StepType:    StepTypeSubWorkflow,
+					Content:     data["output"],
+					StartTime:   startTime,
+					EndTime:     time.Now(),
+					Metadata:    s.metadata(metrics),
+					NestedSteps: s.nestedSteps(data),
+				}
+				output.Metadata["run_id"] = event.RunID
+				output.Duration = output.EndTime.Sub(output.StartTime).Seconds()
+				output.Metrics = &StepMetrics{ExecutionTime: output.Duration}
+				if metrics != nil {
+					output.Metrics.InputTokens = metrics.TotalInputTokens
+					output.Metrics.OutputTokens = metrics.TotalOutputTokens
+					output.Metrics.TotalTokens = metrics.TotalTokens
+				}
+				writer.Send(output, nil)
+				return
+
+			case EventStepCompleted, EventStepFailed, EventStepProgress:
+				// 转发内部步骤事件，步骤名按父步骤命名空间化
+				progress := &StepOutput{
+					StepID:    event.StepID,
+					StepName:  s.name + "/" + event.StepName,
+					StepType:  StepTypeSubWorkflow,
+					StartTime: event.Timestamp,
+					EndTime:   event.Timestamp,
+					Metadata: map[string]any{
+						"sub_workflow": s.inner.Name,
+						"event":        string(event.Type),
+					},
+				}
+				if inner, ok := event.Data.(*StepOutput); ok {
+					progress.Content = inner.Content
+				} else if inner, ok := data["output"].(*StepOutput); ok && inner != nil {
+					progress.Content = inner.Content
+				} else if msg, ok := data["error"].(string); ok {
+					progress.Metadata["error"] = msg
+				}
+				if writer.Send(progress, nil) {
+					return
+				}
+			}
+		}
+	}()
+
+	return reader
+}
+
+// WithDescription 设置描述
+func (s *SubWorkflowStep) WithDescription(desc string) *SubWorkflowStep {
+	s.description = desc
+	return s
+}
+
+// WithTimeout 设置超时（包含整个内部 Workflow 的执行）
+func (s *SubWorkflowStep) WithTimeout(timeout time.Duration) *SubWorkflowStep {
+	s.config.Timeout = timeout
+	return s
+}
+
+// metadata 构建输出元数据
+func (s *SubWorkflowStep) metadata(metrics *RunMetrics) map[string]any {
+	metadata := map[string]any{
+		"sub_workflow_id":   s.inner.ID,
+		"sub_workflow_name": s.inner.Name,
+	}
+	if metrics != nil {
+		metadata[subWorkflowMetricsKey] = metrics
+	}
+	return metadata
+}
+
+// nestedSteps 按内部 Workflow 的步骤顺序整理内部步骤输出
+func (s *SubWorkflowStep) nestedSteps(data map[string]any) []*StepOutput {
+	outputs, _ := data["step_outputs"].(map[string]*StepOutput)
+	nested := make([]*StepOutput, 0, len(outputs))
+	for _, step := range s.inner.Steps {
+		if output, ok := outputs[step.Name()]; ok {
+			nested = append(nested, output)
+		}
+	}
+	return nested
+}
+
+// innerDescription 默认使用内部 Workflow 的描述
+func innerDescription(inner *Workflow) string {
+	if inner == nil {
+		return ""
+	}
+	return inner.Description
+}
+
+// eventData 返回事件的 map 数据
+func (e *RunEvent) eventData() (map[string]any, bool) {
+	if e == nil {
+		return nil, false
+	}
+	data, ok := e.Data.(map[string]any)
+	return data, ok
+}
+
+// mergeSubWorkflowMetrics 将子 Workflow 的步骤统计合并到父运行指标
+// 子 Workflow 步骤本身在父 Workflow 中已计为一步，这里替换为其内部步骤数
+func mergeSubWorkflowMetrics(metrics *RunMetrics, stepName string, output *StepOutput) {
+	if metrics == nil || output == nil || output.Metadata == nil {
+		return
+	}
+	inner, ok := output.Metadata[subWorkflowMetricsKey].(*RunMetrics)
+	if !ok || inner == nil {
+		return
+	}
+
+	metrics.TotalSteps += inner.TotalSteps - 1
+	metrics.SuccessfulSteps += inner.SuccessfulSteps - 1
+	metrics.FailedSteps += inner.FailedSteps
+	metrics.SkippedSteps += inner.SkippedSteps
+	metrics.TotalRetries += inner.TotalRetries
+
+	for name, stepMetrics := range inner.StepMetrics {
+		metrics.StepMetrics[stepName+"/"+name] = stepMetrics
+	}
+}
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appendPrevious 返回在上一步输出后追加后缀的步骤
+func appendPrevious(name, suffix string) *FunctionStep {
+	return NewFunctionStep(name, func(_ context.Context, input *StepInput) (*StepOutput, error) {
+		prev, _ := input.PreviousStepContent.(string)
+		if prev == "" {
+			prev, _ = input.Input.(string)
+		}
+		return &StepOutput{Content: prev + suffix}, nil
+	})
+}
+
+func TestSubWorkflowStep_ComposesWorkflows(t *testing.T) {
+	ctx := context.Background()
+
+	inner := New("inner").WithStream()
+	inner.AddStep(appendPrevious("a", "-a"))
+	inner.AddStep(appendPrevious("b", "-b"))
+
+	parent := New("parent").WithStream()
+	parent.AddStep(appendPrevious("prepare", "-prepared"))
+	parent.AddStep(NewSubWorkflowStep("sub", inner))
+	parent.AddStep(appendPrevious("finish", "-done"))
+
+	events, errs := collectRunEvents(t, parent.Execute(ctx, &WorkflowInput{Input: "x"}))
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted {
+		t.Fatalf("expected workflow_completed, got %s", last.Type)
+	}
+	data := last.Data.(map[string]any)
+	if data["output"] != "x-prepared-a-b-done" {
+		t.Errorf("unexpected output: %v", data["output"])
+	}
+
+	metrics := data["metrics"].(*RunMetrics)
+	if metrics.TotalSteps != 4 || metrics.SuccessfulSteps != 4 {
+		t.Errorf("expected 4 total/successful steps, got %d/%d", metrics.TotalSteps, metrics.SuccessfulSteps)
+	}
+	if metrics.StepMetrics["sub/a"] == nil || metrics.StepMetrics["sub/b"] == nil {
+		t.Errorf("expected namespaced inner step metrics, got %v", metrics.StepMetrics)
+	}
+
+	// 内部步骤事件作为子步骤的进度转发
+	var forwarded []string
+	for _, event := range events {
+		if event.Type != EventStepProgress || event.StepName != "sub" {
+			continue
+		}
+		if output, ok := event.Data.(*StepOutput); ok && output.Metadata["event"] == string(EventStepCompleted) {
+			forwarded = append(forwarded, output.StepName)
+		}
+	}
+	if strings.Join(forwarded, ",") != "sub/a,sub/b" {
+		t.Errorf("expected forwarded inner events [sub/a sub/b], got %v", forwarded)
+	}
+
+	subOutput := data["step_outputs"].(map[string]*StepOutput)["sub"]
+	if len(subOutput.NestedSteps) != 2 || subOutput.NestedSteps[0].Content != "x-prepared-a" {
+		t.Errorf("unexpected nested steps: %+v", subOutput.NestedSteps)
+	}
+}
+
+func TestSubWorkflowStep_TimeoutPropagates(t *testing.T) {
+	inner := New("slow")
+	inner.AddStep(NewFunctionStep("wait", func(ctx context.Context, _ *StepInput) (*StepOutput, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return &StepOutput{Content: "too late"}, nil
+		}
+	}))
+
+	parent := New("parent")
+	parent.AddStep(NewSubWorkflowStep("sub", inner).WithTimeout(50 * time.Millisecond))
+
+	start := time.Now()
+	events, errs := collectRunEvents(t, parent.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("timeout was not propagated to inner workflow")
+	}
+
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowFailed {
+		t.Fatalf("expected workflow_failed, got %s", last.Type)
+	}
+	if err := errs[len(errs)-1]; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package workflow
+
+import "context"
+
+type textDeltaKey struct{}
+
+type textStreamKey struct{}
+
+// EmitTextDelta 在步骤执行过程中输出增量文本（如 LLM 逐 token 输出）
+// 仅在 Workflow 开启 StreamEvents 或通过 WorkflowAgent.RunStreamText 运行时转发为
+// EventStepTextDelta 事件，否则忽略
+func EmitTextDelta(ctx context.Context, delta string) {
+	if delta == "" {
+		return
+	}
+	if emit, ok := ctx.Value(textDeltaKey{}).(func(string)); ok {
+		emit(delta)
+	}
+}
+
+// withTextStream 标记本次运行需要转发增量文本
+func withTextStream(ctx context.Context) context.Context {
+	return context.WithValue(ctx, textStreamKey{}, true)
+}
+
+// textStreamEnabled 判断本次运行是否需要转发增量文本
+func textStreamEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(textStreamKey{}).(bool)
+	return enabled
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+
+	"github.com/astercloud/aster/pkg/stream"
+)
+
+// ErrStepTimeout 步骤执行超过 StepConfig.Timeout
+var ErrStepTimeout = errors.New("step timeout")
+
+// TimeoutPolicy 步骤超时后的处理方式
+type TimeoutPolicy string
+
+const (
+	// TimeoutPolicyDefault 按 SkipOnError 处理
+	TimeoutPolicyDefault TimeoutPolicy = ""
+	// TimeoutPolicyAbort 终止 Workflow
+	TimeoutPolicyAbort TimeoutPolicy = "abort"
+	// TimeoutPolicyContinue 跳过该步骤继续执行
+	TimeoutPolicyContinue TimeoutPolicy = "continue"
+)
+
+// continueOnError 判断步骤失败后是否继续执行后续步骤
+func continueOnError(config *StepConfig, err error) bool {
+	if errors.Is(err, ErrStepTimeout) && config.OnTimeout != TimeoutPolicyDefault {
+		return config.OnTimeout == TimeoutPolicyContinue
+	}
+	return config.SkipOnError
+}
+
+// stepResult 步骤流中的一次接收结果
+type stepResult struct {
+	output *StepOutput
+	err    error
+}
+
+// receiveSteps 在独立 goroutine 中接收步骤输出，使调用方可以同时等待超时
+// 收到错误（包括 EOF）或 ctx 结束后停止接收
+func receiveSteps(ctx context.Context, reader *stream.Reader[*StepOutput]) <-chan stepResult {
+	results := make(chan stepResult)
+	go func() {
+		for {
+			output, err := reader.Recv()
+			select {
+			case results <- stepResult{output: output, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return results
+}
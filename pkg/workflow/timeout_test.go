@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// sleepingStep 忽略 ctx 睡眠 d 后返回，用于模拟挂起的步骤
+func sleepingStep(name string, d time.Duration) *FunctionStep {
+	return NewFunctionStep(name, func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		time.Sleep(d)
+		return &StepOutput{Content: name}, nil
+	})
+}
+
+func stepFailedEvent(events []*RunEvent, stepName string) *RunEvent {
+	for _, event := range events {
+		if event.Type == EventStepFailed && event.StepName == stepName {
+			return event
+		}
+	}
+	return nil
+}
+
+func TestStepTimeout_Abort(t *testing.T) {
+	wf := New("timeout-abort").WithStream()
+	wf.AddStep(sleepingStep("hung", time.Second).WithTimeout(20 * time.Millisecond))
+	wf.AddStep(sleepingStep("after", 0))
+
+	start := time.Now()
+	events, errs := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("workflow should not wait for the hung step, took %s", elapsed)
+	}
+
+	failed := stepFailedEvent(events, "hung")
+	if failed == nil || failed.Data.(map[string]any)["reason"] != "timeout" {
+		t.Fatalf("expected step_failed event with timeout reason, got %+v", failed)
+	}
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowFailed || !errors.Is(errs[len(errs)-1], ErrStepTimeout) {
+		t.Errorf("expected workflow to fail with ErrStepTimeout, got %s (%v)", last.Type, errs[len(errs)-1])
+	}
+}
+
+func TestStepTimeout_Continue(t *testing.T) {
+	wf := New("timeout-continue").WithStream()
+	wf.AddStep(sleepingStep("hung", time.Second).WithTimeout(20 * time.Millisecond).WithOnTimeout(TimeoutPolicyContinue))
+	wf.AddStep(sleepingStep("after", 0))
+
+	events, _ := collectRunEvents(t, wf.Execute(context.Background(), &WorkflowInput{Input: "x"}))
+
+	if stepFailedEvent(events, "hung") == nil {
+		t.Error("expected step_failed event for the timed out step")
+	}
+	last := events[len(events)-1]
+	if last.Type != EventWorkflowCompleted || last.Data.(map[string]any)["output"] != "after" {
+		t.Errorf("workflow should continue after the timeout, got %s %+v", last.Type, last.Data)
+	}
+}
+
+func TestContinueOnError(t *testing.T) {
+	timeoutErr := ErrStepTimeout
+	otherErr := errors.New("boom")
+
+	if continueOnError(&StepConfig{SkipOnError: true, OnTimeout: TimeoutPolicyAbort}, timeoutErr) {
+		t.Error("OnTimeout abort should override SkipOnError for timeouts")
+	}
+	if !continueOnError(&StepConfig{SkipOnError: true, OnTimeout: TimeoutPolicyAbort}, otherErr) {
+		t.Error("other errors should follow SkipOnError")
+	}
+	if !continueOnError(&StepConfig{SkipOnError: true}, timeoutErr) {
+		t.Error("default timeout policy should follow SkipOnError")
+	}
+}
+
+func TestStepTimeout_NoDefault(t *testing.T) {
+	noop := NewFunctionStep("noop", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		return &StepOutput{}, nil
+	})
+	for _, step := range []Step{
+		noop,
+		NewRouter("router", nil, nil),
+		NewMapStep("map", noop, 1),
+		NewGraph("graph"),
+		NewSubWorkflowStep("sub", New("inner")),
+	} {
+		if timeout := step.Config().Timeout; timeout != 0 {
+			t.Errorf("%s: expected no default timeout, got %s", step.Name(), timeout)
+		}
+	}
+}
@@ -6,14 +6,18 @@ import "time"
 type StepType string
 
 const (
-	StepTypeAgent     StepType = "agent"
-	StepTypeRoom      StepType = "room"
-	StepTypeFunction  StepType = "function"
-	StepTypeCondition StepType = "condition"
-	StepTypeLoop      StepType = "loop"
-	StepTypeParallel  StepType = "parallel"
-	StepTypeRouter    StepType = "router"
-	StepTypeSteps     StepType = "steps"
+	StepTypeAgent       StepType = "agent"
+	StepTypeRoom        StepType = "room"
+	StepTypeFunction    StepType = "function"
+	StepTypeCondition   StepType = "condition"
+	StepTypeLoop        StepType = "loop"
+	StepTypeParallel    StepType = "parallel"
+	StepTypeRouter      StepType = "router"
+	StepTypeSteps       StepType = "steps"
+	StepTypeSubWorkflow StepType = "sub_workflow"
+	StepTypeBreak       StepType = "break"
+	StepTypeGraph       StepType = "graph"
+	StepTypeMap         StepType = "map"
 )
 
 // StepInput 步骤输入
@@ -28,6 +32,7 @@ type StepInput struct {
 	Audio               []any
 	Files               []any
 	WorkflowSession     *WorkflowSession
+	SharedContext       *SharedContext // 同一次运行中所有步骤共享
 }
 
 func (si *StepInput) GetInputAsString() string {
@@ -68,16 +73,18 @@ type StepOutput struct {
 	StartTime   time.Time
 	EndTime     time.Time
 	Duration    float64
+	FromCache   bool // 输出来自步骤缓存
+	Break       bool // 为 true 时 Workflow 在此步骤后正常结束
 }
 
 // StepMetrics 步骤指标
 type StepMetrics struct {
-	ExecutionTime float64
-	InputTokens   int
-	OutputTokens  int
-	TotalTokens   int
-	RetryCount    int
-	Custom        map[string]any
+	ExecutionTime float64        `json:"execution_time"`
+	InputTokens   int            `json:"input_tokens"`
+	OutputTokens  int            `json:"output_tokens"`
+	TotalTokens   int            `json:"total_tokens"`
+	RetryCount    int            `json:"retry_count"`
+	Custom        map[string]any `json:"custom,omitempty"`
 }
 
 // WorkflowInput Workflow 输入
@@ -91,6 +98,12 @@ type WorkflowInput struct {
 	SessionID      string
 	UserID         string
 	SessionState   map[string]any
+
+	// SharedContext 步骤间共享的键值存储，为空时自动创建
+	SharedContext *SharedContext
+
+	// PreviousStepContent 第一个步骤的 PreviousStepContent（子 Workflow 用于承接父步骤输出）
+	PreviousStepContent any
 }
 
 // WorkflowOutput Workflow 输出
@@ -112,15 +125,18 @@ type WorkflowOutput struct {
 
 // RunMetrics Workflow 运行指标
 type RunMetrics struct {
-	TotalExecutionTime float64
-	TotalSteps         int
-	SuccessfulSteps    int
-	FailedSteps        int
-	SkippedSteps       int
-	TotalInputTokens   int
-	TotalOutputTokens  int
-	TotalTokens        int
-	StepMetrics        map[string]*StepMetrics
+	TotalExecutionTime float64                 `json:"total_execution_time"`
+	TotalSteps         int                     `json:"total_steps"`
+	SuccessfulSteps    int                     `json:"successful_steps"`
+	FailedSteps        int                     `json:"failed_steps"`
+	SkippedSteps       int                     `json:"skipped_steps"`
+	TotalRetries       int                     `json:"total_retries"`        // 步骤重试次数（不计入成功/失败步骤数）
+	BreakStep          string                  `json:"break_step,omitempty"` // 提前结束 Workflow 的步骤名称，此时 TotalSteps 为实际执行的步骤数
+	TotalInputTokens   int                     `json:"total_input_tokens"`
+	TotalOutputTokens  int                     `json:"total_output_tokens"`
+	TotalTokens        int                     `json:"total_tokens"`
+	StepMetrics        map[string]*StepMetrics `json:"step_metrics,omitempty"`
+	Steps              []StepMetric            `json:"steps,omitempty"` // 按执行顺序记录的步骤指标，嵌套步骤紧随其父步骤
 }
 
 // RunStatus 运行状态
@@ -167,6 +183,7 @@ const (
 	EventWorkflowStarted   WorkflowEventType = "workflow_started"
 	EventStepStarted       WorkflowEventType = "step_started"
 	EventStepProgress      WorkflowEventType = "step_progress"
+	EventStepTextDelta     WorkflowEventType = "step_text_delta"
 	EventStepCompleted     WorkflowEventType = "step_completed"
 	EventStepFailed        WorkflowEventType = "step_failed"
 	EventStepSkipped       WorkflowEventType = "step_skipped"
@@ -196,8 +213,15 @@ type StepConfig struct {
 	Description           string
 	Type                  StepType
 	MaxRetries            int
-	Timeout               time.Duration
+	Timeout               time.Duration // 步骤执行超时，0 表示不限制
 	SkipOnError           bool
+	OnTimeout             TimeoutPolicy // 超时后的处理方式，为空时按 SkipOnError 处理
 	StrictInputValidation bool
+	NonResumable          bool // 输出无法持久化，检查点恢复时重新执行
 	Metadata              map[string]any
+
+	// 缓存（仅用于无副作用的确定性步骤）
+	CacheTTL     time.Duration // >0 时按输入缓存步骤输出
+	CacheVersion string        // 修改后使已有缓存失效
+	CacheKey     string        // 缓存键前缀，为空时使用步骤类型和名称
 }
@@ -60,6 +60,12 @@ type Workflow struct {
 	AddWorkflowHistory bool
 	NumHistoryRuns     int
 
+	// 步骤缓存（仅对设置了 CacheTTL 的步骤生效，nil 表示禁用）
+	StepCache StepCache
+
+	// 检查点（每个步骤成功后保存执行状态，nil 表示禁用）
+	Checkpointer Checkpointer
+
 	// 内部状态
 	workflowSession *WorkflowSession
 }
@@ -84,6 +90,7 @@ func New(name string) *Workflow {
 		AddWorkflowHistory:   false,
 		NumHistoryRuns:       3,
 		CacheSession:         false,
+		StepCache:            NewMemoryStepCache(),
 	}
 }
 
@@ -136,6 +143,12 @@ func (w *Workflow) WithHistory(numRuns int) *Workflow {
 	return w
 }
 
+// WithStepCache 设置步骤缓存存储
+func (w *Workflow) WithStepCache(cache StepCache) *Workflow {
+	w.StepCache = cache
+	return w
+}
+
 // WithSession 设置会话
 func (w *Workflow) WithSession(sessionID string) *Workflow {
 	w.SessionID = sessionID
@@ -160,6 +173,13 @@ func (w *Workflow) Validate() error {
 			return fmt.Errorf("duplicate step name: %s", step.Name())
 		}
 		stepNames[step.Name()] = true
+
+		// 校验步骤自身的结构（如 Graph 的循环依赖）
+		if v, ok := step.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("invalid step %s: %w", step.Name(), err)
+			}
+		}
 	}
 
 	if w.AddWorkflowHistory && w.DB == nil {
@@ -307,6 +327,11 @@ func (w *Workflow) SaveRun(run *WorkflowRun) error {
 
 // Execute 执行 Workflow
 func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Reader[*RunEvent] {
+	return w.execute(ctx, input, nil)
+}
+
+// execute 执行 Workflow，checkpoint 不为空时跳过已完成的步骤并恢复其状态
+func (w *Workflow) execute(ctx context.Context, input *WorkflowInput, checkpoint *Checkpoint) *stream.Reader[*RunEvent] {
 	reader, writer := stream.Pipe[*RunEvent](10)
 
 	go func() {
@@ -324,6 +349,20 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 		// 生成 RunID
 		runID := uuid.New().String()
 		startTime := time.Now()
+		metrics := &RunMetrics{TotalSteps: len(w.Steps), StepMetrics: make(map[string]*StepMetrics)}
+		startStep := 0
+		if checkpoint != nil {
+			runID = checkpoint.ID
+			startTime = checkpoint.StartTime
+			startStep = checkpoint.NextStep
+			if checkpoint.Metrics != nil {
+				metrics = checkpoint.Metrics
+				metrics.TotalSteps = len(w.Steps)
+				if metrics.StepMetrics == nil {
+					metrics.StepMetrics = make(map[string]*StepMetrics)
+				}
+			}
+		}
 
 		// 创建运行记录
 		run := &WorkflowRun{
@@ -334,22 +373,27 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 			StepOutputs: make(map[string]*StepOutput),
 			Status:      RunStatusRunning,
 			StartTime:   startTime,
-			Metrics:     &RunMetrics{TotalSteps: len(w.Steps), StepMetrics: make(map[string]*StepMetrics)},
+			Metrics:     metrics,
 		}
 
 		// 发送开始事件
+		startedData := map[string]any{
+			"input":      input.Input,
+			"session_id": sessionID,
+			"user_id":    userID,
+		}
+		if checkpoint != nil {
+			startedData["resumed"] = true
+			startedData["resume_step"] = startStep
+		}
 		if writer.Send(&RunEvent{
 			Type:         EventWorkflowStarted,
 			EventID:      uuid.New().String(),
 			WorkflowID:   w.ID,
 			WorkflowName: w.Name,
 			RunID:        runID,
-			Timestamp:    startTime,
-			Data: map[string]any{
-				"input":      input.Input,
-				"session_id": sessionID,
-				"user_id":    userID,
-			},
+			Timestamp:    time.Now(),
+			Data:         startedData,
 		}, nil) {
 			return
 		}
@@ -363,11 +407,61 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 			maps.Copy(sessionState, input.SessionState)
 		}
 
+		shared := input.SharedContext
+		if shared == nil {
+			shared = NewSharedContext(nil)
+		}
+
 		stepOutputs := make(map[string]*StepOutput)
 		var lastOutput *StepOutput
 
+		// 从检查点恢复已完成步骤的输出
+		if checkpoint != nil {
+			maps.Copy(sessionState, checkpoint.SessionState)
+			for key, value := range checkpoint.SharedContext {
+				shared.Set(key, value)
+			}
+			for name, content := range checkpoint.StepContents {
+				output := &StepOutput{StepName: name, Content: content}
+				stepOutputs[name] = output
+				run.StepOutputs[name] = output
+			}
+			if startStep > 0 {
+				lastOutput = &StepOutput{StepName: checkpoint.LastStep, Content: checkpoint.PreviousStepContent}
+			}
+		}
+
+		// 终止执行
+		fail := func(err error) {
+			run.Status = RunStatusFailed
+			run.Error = err.Error()
+			run.EndTime = time.Now()
+			run.Duration = run.EndTime.Sub(run.StartTime).Seconds()
+			run.Metrics.TotalExecutionTime = run.Duration
+
+			_ = w.SaveRun(run)
+
+			writer.Send(&RunEvent{
+				Type:         EventWorkflowFailed,
+				EventID:      uuid.New().String(),
+				WorkflowID:   w.ID,
+				WorkflowName: w.Name,
+				RunID:        runID,
+				Timestamp:    run.EndTime,
+				Data: map[string]any{
+					"error":    err.Error(),
+					"duration": run.Duration,
+					"metrics":  run.Metrics,
+				},
+			}, err)
+		}
+
 		// 执行步骤
 		for i, step := range w.Steps {
+			if i < startStep {
+				continue
+			}
+
 			stepInput := &StepInput{
 				Input:               input.Input,
 				PreviousStepContent: nil,
@@ -379,10 +473,13 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 				Audio:               input.Audio,
 				Files:               input.Files,
 				WorkflowSession:     session,
+				SharedContext:       shared,
 			}
 
 			if lastOutput != nil {
 				stepInput.PreviousStepContent = lastOutput.Content
+			} else if i == 0 {
+				stepInput.PreviousStepContent = input.PreviousStepContent
 			}
 
 			stepStartTime := time.Now()
@@ -406,31 +503,17 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 			var stepOutput *StepOutput
 			var stepError error
 
-			stepReader := step.Execute(ctx, stepInput)
-			for {
-				output, err := stepReader.Recv()
-				if err != nil {
-					if errors.Is(err, io.EOF) {
-						break
-					}
-					stepError = err
-					break
-				}
-				stepOutput = output
-
-				// 流式进度事件
-				if w.StreamEvents && w.StreamExecutorEvents && output != nil {
-					writer.Send(&RunEvent{
-						Type:         EventStepProgress,
-						EventID:      uuid.New().String(),
-						WorkflowID:   w.ID,
-						WorkflowName: w.Name,
-						RunID:        runID,
-						StepID:       step.ID(),
-						StepName:     step.Name(),
-						Timestamp:    time.Now(),
-						Data:         output,
-					}, nil)
+			// 步骤缓存
+			cacheKey, cacheable := "", false
+			if ttl := step.Config().CacheTTL; ttl > 0 && w.StepCache != nil {
+				cacheKey, cacheable = stepCacheKey(step, stepInput)
+			}
+			if cached, ok := w.lookupStepCache(cacheKey, cacheable); ok {
+				stepOutput = cached
+			} else {
+				stepOutput, stepError = w.runStep(ctx, step, stepInput, writer, runID)
+				if cacheable && stepError == nil && stepOutput != nil {
+					w.StepCache.Set(cacheKey, stepOutput, step.Config().CacheTTL)
 				}
 			}
 
@@ -438,8 +521,22 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 
 			if stepError != nil {
 				run.Metrics.FailedSteps++
+				countRetries(run.Metrics, stepOutput)
+				status := StepStatusFailed
+				if continueOnError(step.Config(), stepError) {
+					status = StepStatusSkipped
+				}
+				run.Metrics.recordStep(step, stepOutput, status, stepEndTime.Sub(stepStartTime).Seconds(), stepError)
 
 				if w.StreamEvents {
+					data := map[string]any{
+						"error":    stepError.Error(),
+						"duration": stepEndTime.Sub(stepStartTime).Seconds(),
+					}
+					if errors.Is(stepError, ErrStepTimeout) {
+						data["reason"] = "timeout"
+						data["timeout"] = step.Config().Timeout.Seconds()
+					}
 					writer.Send(&RunEvent{
 						Type:         EventStepFailed,
 						EventID:      uuid.New().String(),
@@ -449,36 +546,12 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 						StepID:       step.ID(),
 						StepName:     step.Name(),
 						Timestamp:    stepEndTime,
-						Data: map[string]any{
-							"error":    stepError.Error(),
-							"duration": stepEndTime.Sub(stepStartTime).Seconds(),
-						},
+						Data:         data,
 					}, nil)
 				}
 
-				if !step.Config().SkipOnError {
-					// 终止执行
-					run.Status = RunStatusFailed
-					run.Error = stepError.Error()
-					run.EndTime = time.Now()
-					run.Duration = run.EndTime.Sub(run.StartTime).Seconds()
-					run.Metrics.TotalExecutionTime = run.Duration
-
-					_ = w.SaveRun(run)
-
-					writer.Send(&RunEvent{
-						Type:         EventWorkflowFailed,
-						EventID:      uuid.New().String(),
-						WorkflowID:   w.ID,
-						WorkflowName: w.Name,
-						RunID:        runID,
-						Timestamp:    run.EndTime,
-						Data: map[string]any{
-							"error":    stepError.Error(),
-							"duration": run.Duration,
-							"metrics":  run.Metrics,
-						},
-					}, stepError)
+				if !continueOnError(step.Config(), stepError) {
+					fail(stepError)
 					return
 				}
 
@@ -497,7 +570,10 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 					run.Metrics.TotalInputTokens += stepOutput.Metrics.InputTokens
 					run.Metrics.TotalOutputTokens += stepOutput.Metrics.OutputTokens
 				}
+				mergeSubWorkflowMetrics(run.Metrics, step.Name(), stepOutput)
+				countRetries(run.Metrics, stepOutput)
 			}
+			run.Metrics.recordStep(step, stepOutput, StepStatusCompleted, stepEndTime.Sub(stepStartTime).Seconds(), nil)
 
 			if w.StreamEvents {
 				writer.Send(&RunEvent{
@@ -510,12 +586,25 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 					StepName:     step.Name(),
 					Timestamp:    stepEndTime,
 					Data: map[string]any{
-						"output":   stepOutput,
-						"duration": stepEndTime.Sub(stepStartTime).Seconds(),
+						"output":     stepOutput,
+						"duration":   stepEndTime.Sub(stepStartTime).Seconds(),
+						"from_cache": stepOutput != nil && stepOutput.FromCache,
 					},
 				}, nil)
 			}
 
+			// 保存检查点，不可恢复的步骤之后不保存
+			if w.Checkpointer != nil && !step.Config().NonResumable {
+				cp := newCheckpoint(run, w, input, userID, sessionState, shared)
+				cp.NextStep = i + 1
+				cp.LastStep = step.Name()
+				cp.PreviousStepContent = contentOf(lastOutput)
+				if err := w.Checkpointer.SaveState(ctx, cp); err != nil {
+					fail(fmt.Errorf("save checkpoint after step %s: %w", step.Name(), err))
+					return
+				}
+			}
+
 			// 检查上下文取消
 			if ctx.Err() != nil {
 				run.Status = RunStatusCancelled
@@ -537,6 +626,13 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 				}, ctx.Err())
 				return
 			}
+
+			// BreakStep 提前结束
+			if stepOutput != nil && stepOutput.Break {
+				run.Metrics.TotalSteps = i + 1
+				run.Metrics.BreakStep = step.Name()
+				break
+			}
 		}
 
 		// 完成
@@ -554,6 +650,15 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 		session.State = sessionState
 		_ = w.SaveRun(run)
 
+		// 标记检查点已完成，防止重复恢复；此时结果已产生，保存失败不影响运行结果
+		if w.Checkpointer != nil {
+			cp := newCheckpoint(run, w, input, userID, sessionState, shared)
+			cp.NextStep = len(w.Steps)
+			cp.PreviousStepContent = run.Output
+			cp.Completed = true
+			_ = w.Checkpointer.SaveState(ctx, cp)
+		}
+
 		writer.Send(&RunEvent{
 			Type:         EventWorkflowCompleted,
 			EventID:      uuid.New().String(),
@@ -562,11 +667,12 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 			RunID:        runID,
 			Timestamp:    run.EndTime,
 			Data: map[string]any{
-				"output":       run.Output,
-				"duration":     run.Duration,
-				"metrics":      run.Metrics,
-				"session_id":   sessionID,
-				"step_outputs": stepOutputs,
+				"output":         run.Output,
+				"duration":       run.Duration,
+				"metrics":        run.Metrics,
+				"session_id":     sessionID,
+				"step_outputs":   stepOutputs,
+				"shared_context": shared.Snapshot(),
 			},
 		}, nil)
 	}()
@@ -574,6 +680,99 @@ func (w *Workflow) Execute(ctx context.Context, input *WorkflowInput) *stream.Re
 	return reader
 }
 
+// runStep 执行单个步骤并转发进度事件
+// 步骤配置了 Timeout 时超时后取消步骤的 ctx 并返回 ErrStepTimeout（同时匹配 context.DeadlineExceeded），
+// 不等待忽略 ctx 的步骤结束
+func (w *Workflow) runStep(ctx context.Context, step Step, stepInput *StepInput, writer *stream.Writer[*RunEvent], runID string) (*StepOutput, error) {
+	var stepOutput *StepOutput
+
+	parent := ctx
+	timeout := step.Config().Timeout
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 转发步骤通过 EmitTextDelta 输出的增量文本
+	if w.StreamEvents || textStreamEnabled(ctx) {
+		ctx = context.WithValue(ctx, textDeltaKey{}, func(delta string) {
+			writer.Send(&RunEvent{
+				Type:         EventStepTextDelta,
+				EventID:      uuid.New().String(),
+				WorkflowID:   w.ID,
+				WorkflowName: w.Name,
+				RunID:        runID,
+				StepID:       step.ID(),
+				StepName:     step.Name(),
+				Timestamp:    time.Now(),
+				Data:         map[string]any{"delta": delta},
+			}, nil)
+		})
+	}
+
+	stepReader := step.Execute(ctx, stepInput)
+	results := receiveSteps(ctx, stepReader)
+	timedOut := func() error {
+		stepReader.Close()
+		if parent.Err() != nil {
+			return parent.Err()
+		}
+		return fmt.Errorf("%w: %s exceeded %s: %w", ErrStepTimeout, step.Name(), timeout, context.DeadlineExceeded)
+	}
+	for {
+		var output *StepOutput
+		var err error
+		select {
+		case <-ctx.Done():
+			return stepOutput, timedOut()
+		case result := <-results:
+			output, err = result.output, result.err
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return stepOutput, nil
+			}
+			if output != nil {
+				stepOutput = output
+			}
+			// 步骤自行响应 ctx 取消时同样视为超时
+			if ctx.Err() != nil {
+				return stepOutput, timedOut()
+			}
+			return stepOutput, err
+		}
+		stepOutput = output
+
+		// 流式进度事件
+		if w.StreamEvents && w.StreamExecutorEvents && output != nil {
+			writer.Send(&RunEvent{
+				Type:         EventStepProgress,
+				EventID:      uuid.New().String(),
+				WorkflowID:   w.ID,
+				WorkflowName: w.Name,
+				RunID:        runID,
+				StepID:       step.ID(),
+				StepName:     step.Name(),
+				Timestamp:    time.Now(),
+				Data:         output,
+			}, nil)
+		}
+	}
+}
+
+// lookupStepCache 查找步骤缓存，命中时返回标记 FromCache 的输出副本
+func (w *Workflow) lookupStepCache(key string, cacheable bool) (*StepOutput, bool) {
+	if !cacheable {
+		return nil, false
+	}
+	cached, ok := w.StepCache.Get(key)
+	if !ok || cached == nil {
+		return nil, false
+	}
+	return cachedStepOutput(cached), true
+}
+
 // ===== 辅助方法 =====
 
 // GetWorkflowData 获取 Workflow 数据
@@ -211,6 +211,18 @@ func (wa *WorkflowAgent) Run(ctx context.Context, input string) (string, error)
 
 // RunStream 流式运行 WorkflowAgent
 func (wa *WorkflowAgent) RunStream(ctx context.Context, input string) <-chan AgentStreamEvent {
+	return wa.runStream(ctx, input, false)
+}
+
+// RunStreamText 流式运行 WorkflowAgent，并输出步骤的增量文本
+// 在 RunStream 事件的基础上，步骤通过 EmitTextDelta 输出的文本以 AgentEventTextChunk 事件发出，
+// Data 包含 delta、step_id 和 step_name
+func (wa *WorkflowAgent) RunStreamText(ctx context.Context, input string) <-chan AgentStreamEvent {
+	return wa.runStream(withTextStream(ctx), input, true)
+}
+
+// runStream 流式运行 workflow，text 为 false 时丢弃增量文本事件
+func (wa *WorkflowAgent) runStream(ctx context.Context, input string, text bool) <-chan AgentStreamEvent {
 	eventChan := make(chan AgentStreamEvent, 100)
 
 	go func() {
@@ -238,6 +250,22 @@ func (wa *WorkflowAgent) RunStream(ctx context.Context, input string) <-chan Age
 				continue
 			}
 
+			if event.Type == EventStepTextDelta {
+				if text {
+					data, _ := event.Data.(map[string]any)
+					eventChan <- AgentStreamEvent{
+						Type:      AgentEventTextChunk,
+						Timestamp: event.Timestamp,
+						Data: map[string]any{
+							"delta":     data["delta"],
+							"step_id":   event.StepID,
+							"step_name": event.StepName,
+						},
+					}
+				}
+				continue
+			}
+
 			eventChan <- AgentStreamEvent{
 				Type:      AgentEventWorkflowEvent,
 				Timestamp: time.Now(),
@@ -299,6 +327,7 @@ const (
 	AgentEventWorkflowStart AgentEventType = "workflow_start"
 	AgentEventWorkflowEvent AgentEventType = "workflow_event"
 	AgentEventResponse      AgentEventType = "agent_response"
+	AgentEventTextChunk     AgentEventType = "agent_text_chunk"
 	AgentEventComplete      AgentEventType = "agent_complete"
 	AgentEventError         AgentEventType = "agent_error"
 )
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newTextStreamingWorkflow() *Workflow {
+	wf := New("text-stream")
+	wf.AddStep(NewFunctionStep("generate", func(ctx context.Context, input *StepInput) (*StepOutput, error) {
+		var sb strings.Builder
+		for _, token := range []string{"Hel", "lo", " world"} {
+			EmitTextDelta(ctx, token)
+			sb.WriteString(token)
+		}
+		return &StepOutput{Content: sb.String()}, nil
+	}))
+	return wf
+}
+
+func TestWorkflowAgent_RunStreamText(t *testing.T) {
+	agent := NewWorkflowAgent("", "", false, 0).AttachWorkflow(newTextStreamingWorkflow())
+
+	var text strings.Builder
+	var response any
+	for event := range agent.RunStreamText(context.Background(), "hi") {
+		switch event.Type {
+		case AgentEventTextChunk:
+			if event.Data["step_name"] != "generate" {
+				t.Errorf("unexpected step name %v", event.Data["step_name"])
+			}
+			text.WriteString(event.Data["delta"].(string))
+		case AgentEventWorkflowEvent:
+			if re := event.Data["workflow_event"].(*RunEvent); re.Type == EventStepTextDelta {
+				t.Error("text deltas should not be reported as workflow events")
+			}
+		case AgentEventResponse:
+			response = event.Data["response"]
+		case AgentEventError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if text.String() != "Hello world" {
+		t.Errorf("streamed text = %q, want %q", text.String(), "Hello world")
+	}
+	if response != "Hello world" {
+		t.Errorf("response = %v, want final step output", response)
+	}
+}
+
+func TestWorkflowAgent_RunUnaffectedByTextDeltas(t *testing.T) {
+	agent := NewWorkflowAgent("", "", false, 0).AttachWorkflow(newTextStreamingWorkflow())
+
+	for event := range agent.RunStream(context.Background(), "hi") {
+		if event.Type == AgentEventTextChunk {
+			t.Fatal("RunStream should not emit text chunks")
+		}
+	}
+
+	output, err := agent.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if output != "Hello world" {
+		t.Errorf("Run output = %q, want %q", output, "Hello world")
+	}
+}
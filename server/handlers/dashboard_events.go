@@ -622,6 +622,17 @@ func (c *DashboardEventConnection) extractEventInfo(agentID string, envelope typ
 			"output_tokens": e.OutputTokens,
 			"total_tokens":  e.TotalTokens,
 		}
+	case *types.MonitorRunUsageEvent:
+		info["data"] = map[string]any{
+			"agent_id":              agentID,
+			"run_id":                e.RunID,
+			"model_calls":           e.ModelCalls,
+			"input_tokens":          e.Usage.InputTokens,
+			"output_tokens":         e.Usage.OutputTokens,
+			"total_tokens":          e.Usage.TotalTokens,
+			"cache_creation_tokens": e.Usage.CacheCreationTokens,
+			"cache_read_tokens":     e.Usage.CacheReadTokens,
+		}
 	case *types.MonitorToolExecutedEvent:
 		info["data"] = map[string]any{
 			"agent_id":  agentID,
@@ -57,6 +57,7 @@ export type AgentEvent =
   | ProgressDoneEvent
   | ProgressTodoUpdateEvent
   | ProgressSessionSummarizedEvent
+  | ProgressConversationSummarizedEvent
   | ControlPermissionRequiredEvent
   | ControlPermissionDecidedEvent
   | ControlAskUserEvent
@@ -157,6 +158,17 @@ export interface ProgressSessionSummarizedEvent {
   summary_preview: string;    // 摘要预览
 }
 
+// 较早的对话已被摘要替换
+export interface ProgressConversationSummarizedEvent {
+  type: "conversation_summarized";
+  summary: string;            // 完整摘要
+  replaced_from: number;      // 被替换消息的起始索引
+  replaced_to: number;        // 被替换消息的结束索引（不含）
+  replaced_count: number;     // 被替换的消息数
+  tool_calls: number;         // 摘要中保留的工具调用数
+  notice: string;             // 面向用户的提示文案
+}
+
 // Todo Events
 export interface TodoItemData {
   id: string;